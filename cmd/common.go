@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"crypto/x509/pkix"
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/gruntwork-io/go-commons/entrypoint"
 	"github.com/gruntwork-io/go-commons/errors"
@@ -332,3 +336,10 @@ func parseKubectlOptions(cliContext *cli.Context) (*kubectl.KubectlOptions, erro
 	}
 	return kubectlOptions, nil
 }
+
+// newInterruptibleContext returns a context that is cancelled when the process receives an interrupt (Ctrl-C) or
+// termination signal, so that long running operations can stop promptly instead of waiting out their retries. The
+// returned cancel function must be called to stop listening for the signals.
+func newInterruptibleContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
@@ -3,35 +3,79 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/gruntwork-io/go-commons/collections"
 	"github.com/gruntwork-io/go-commons/entrypoint"
 	"github.com/gruntwork-io/go-commons/errors"
 	"github.com/gruntwork-io/go-commons/shell"
 	"github.com/urfave/cli"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/aws-iam-authenticator/pkg/token"
+	"sigs.k8s.io/yaml"
 
 	"github.com/gruntwork-io/kubergrunt/eks"
 	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/logging"
 )
 
+const (
+	cleanupTargetsTableOutput = "table"
+	cleanupTargetsJSONOutput  = "json"
+	cleanupTargetsYAMLOutput  = "yaml"
+)
+
+var cleanupTargetsOutputFormats = []string{cleanupTargetsTableOutput, cleanupTargetsJSONOutput, cleanupTargetsYAMLOutput}
+
 var (
 	eksClusterArnFlag = cli.StringFlag{
 		Name:  "eks-cluster-arn",
 		Usage: "(Required) The AWS ARN of the EKS cluster that kubectl should authenticate against.",
 	}
+	eksClusterArnOrContextFlag = cli.StringFlag{
+		Name:  "eks-cluster-arn",
+		Usage: "The AWS ARN of the EKS cluster to operate on. Either this or --context is required.",
+	}
+	eksContextFlag = cli.StringFlag{
+		Name:  "context",
+		Usage: "The kubectl config context of the EKS cluster to operate on, in place of --eks-cluster-arn. The context must reference the EKS cluster by its ARN, or authenticate with kubergrunt eks token or aws eks get-token. Either this or --eks-cluster-arn is required.",
+	}
 	waitFlag = cli.BoolFlag{
 		Name:  "wait",
 		Usage: "Whether or not to wait for the command to succeed.",
 	}
+	waitForActiveFlag = cli.BoolFlag{
+		Name:  "wait-for-active",
+		Usage: "When set, wait for the EKS cluster to reach the ACTIVE state (e.g., while it is being created or updated) before running the command.",
+	}
+	waitForActiveTimeoutFlag = cli.DurationFlag{
+		Name:  "wait-for-active-timeout",
+		Value: 20 * time.Minute,
+		Usage: "The maximum amount of time to wait for the EKS cluster to reach the ACTIVE state with --wait-for-active, expressed as a duration (e.g., 20m = 20 minutes).",
+	}
 	ignoreRecoveryFileFlag = cli.BoolFlag{
 		Name:  "ignore-recovery-file",
 		Usage: "Ignore existing recovery file and start deploy process from the beginning.",
 	}
+	deployDryRunFlag = cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "When set, only print the plan of the roll out (the instances that will be replaced in order, and the current and target launch template versions) and check its prerequisites, without changing anything. Exits with an error if the prerequisites are not met.",
+	}
 	eksKubectlContextNameFlag = cli.StringFlag{
 		Name:  KubectlContextNameFlagName,
 		Usage: "The name to use for the config context that is set up to authenticate with the EKS cluster. Defaults to the cluster ARN.",
 	}
+	setCurrentContextFlag = cli.BoolFlag{
+		Name:  "set-current-context",
+		Usage: "When set, switch the current kubectl config context to the context that is set up for the EKS cluster.",
+	}
 
 	clusterRegionFlag = cli.StringFlag{
 		Name:  "region",
@@ -50,6 +94,29 @@ var (
 		Name:  "delete-emptydir-data",
 		Usage: "Continue even if there are pods using emptyDir (local data that will be deleted when the node is drained).",
 	}
+	maxEvictionsPerSecondFlag = cli.Float64Flag{
+		Name:  "max-evictions-per-second",
+		Value: eks.DefaultMaxEvictionsPerSecond,
+		Usage: "The maximum rate of Pod eviction requests while draining, to avoid overwhelming the API server and the admission webhooks. Set to a negative value to disable the rate limit.",
+	}
+	evictionOrderFlag = cli.StringFlag{
+		Name:  "eviction-order",
+		Value: string(eks.EvictionOrderPriorityAscending),
+		Usage: fmt.Sprintf("The order in which the Pods are evicted while draining, based on their priority. One of: %s. Defaults to evicting the lowest priority Pods first, so that critical workloads get the most time to reschedule.", strings.Join(eks.EvictionOrders(), ", ")),
+	}
+	drainExcludeNamespacesFlag = cli.StringSliceFlag{
+		Name:  "exclude-namespaces",
+		Usage: "A namespace whose Pods are not evicted while draining. The Pods are left running on the node, which stays cordoned. Pass multiple times for multiple namespaces. Can not be combined with --include-namespaces.",
+	}
+	drainIncludeNamespacesFlag = cli.StringSliceFlag{
+		Name:  "include-namespaces",
+		Usage: "When set, only the Pods in this namespace are evicted while draining. Pass multiple times for multiple namespaces. Can not be combined with --exclude-namespaces.",
+	}
+	maxUnavailableFlag = cli.IntFlag{
+		Name:  "max-unavailable",
+		Value: 1,
+		Usage: "The maximum number of instances to replace at a time during the rolling deployment. Defaults to 1.",
+	}
 	waitMaxRetriesFlag = cli.IntFlag{
 		Name:  "max-retries",
 		Value: 0,
@@ -69,12 +136,52 @@ var (
 	// Token related flags
 	clusterIDFlag = cli.StringFlag{
 		Name:  "cluster-id",
-		Usage: "The name of the EKS cluster for which to retrieve an auth token.",
+		Usage: "The name of the EKS cluster for which to retrieve an auth token. The cluster is looked up in the region of the AWS credentials. Either this or --eks-cluster-arn is required.",
+	}
+	tokenClusterArnFlag = cli.StringFlag{
+		Name:  "eks-cluster-arn",
+		Usage: "The AWS ARN of the EKS cluster for which to retrieve an auth token. Either this or --cluster-id is required.",
 	}
 	tokenAsTFDataFlag = cli.BoolFlag{
 		Name:  "as-tf-data",
 		Usage: "Output the EKS authentication token in a format compatible for use as an external data source in Terraform.",
 	}
+	tokenCacheDirFlag = cli.StringFlag{
+		Name:  "token-cache-dir",
+		Usage: "The directory to cache the EKS authentication tokens in. (default: \"~/.kube/cache/kubergrunt\")",
+	}
+	tokenNoCacheFlag = cli.BoolFlag{
+		Name:  "no-cache",
+		Usage: "When set, always generate a new EKS authentication token instead of using the token cache.",
+	}
+	tokenTimeoutFlag = cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "The maximum amount of time to spend generating the EKS authentication token, expressed as a duration (e.g., 30s = 30 seconds). This bounds each request to AWS (e.g., to fetch the credentials or assume the IAM role) as well as the whole generation. Zero means no timeout, which is the default so that MFA prompts can be answered. When zero, each request to AWS is still bounded by the global --http-timeout, if set.",
+	}
+	tokenTTLFlag = cli.DurationFlag{
+		Name:  "token-ttl",
+		Usage: "The lifetime of the generated token, expressed as a duration (e.g., 5m = 5 minutes). This sets the X-Amz-Expires of the presigned STS URL and the expirationTimestamp of the ExecCredential output, so that kubectl refreshes the token sooner. Must be between 1 and 14 minutes, and is clamped with a warning otherwise. Defaults to 14 minutes.",
+	}
+	tokenExecCredentialVersionFlag = cli.StringFlag{
+		Name:  "exec-credential-version",
+		Usage: "The version of the client.authentication.k8s.io API to use for the ExecCredential output. Must be one of v1beta1 or v1. When unset, the version requested by kubectl is used, falling back to v1beta1 if kubectl does not request one. Ignored when --as-tf-data is set.",
+	}
+
+	// Flags for getting the cluster CA certificate
+	clusterCAOutputFileFlag = cli.StringFlag{
+		Name:  "output-file",
+		Usage: "Path to write the CA certificate of the EKS cluster to. When omitted, the certificate is written to stdout.",
+	}
+	clusterCABase64Flag = cli.BoolFlag{
+		Name:  "base64",
+		Usage: "When set, output the base64 encoded PEM certificate as returned by the EKS API, instead of decoding it, for embedding in configuration files.",
+	}
+
+	// Configurations for verifying tokens
+	verifyTokenStdinFlag = cli.BoolFlag{
+		Name:  "token-stdin",
+		Usage: "When set, read the token to verify from stdin, either as the raw token or the ExecCredential JSON output of kubergrunt eks token, instead of generating one with the current AWS credentials.",
+	}
 
 	// Flags for getting OIDC issuer CA thumbprint
 	oidcIssuerUrlFlag = cli.StringFlag{
@@ -95,17 +202,89 @@ var (
 		Name:  "skip-aws-vpc-cni",
 		Usage: "Whether or not to skip syncing aws-vpc-cni service to EKS control plane version.",
 	}
+	syncComponentFlag = cli.StringSliceFlag{
+		Name:  "component",
+		Usage: "Only sync the given core component. Must be one of kube-proxy, coredns, or aws-vpc-cni. Can be passed multiple times to sync multiple components. Can not be combined with the --skip-* options.",
+	}
+	syncDryRunFlag = cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "When set, only print a diff of the currently deployed image of each core component against the image it would be updated to, without changing anything.",
+	}
+	syncServerSideFlag = cli.BoolFlag{
+		Name:  "server-side",
+		Usage: "When set, update the core components with server-side apply, so that the ownership of the updated fields is tracked and repeated syncs don't conflict with the EKS managed add-ons.",
+	}
+	syncAllowIncompatibleFlag = cli.BoolFlag{
+		Name:  "allow-incompatible",
+		Usage: "When set, sync the core components even if AWS does not list their versions as compatible with the Kubernetes version of the cluster, or if they would be downgraded across more than one minor version. A warning is logged instead.",
+	}
+	syncFieldManagerFlag = cli.StringFlag{
+		Name:  "field-manager",
+		Value: eks.DefaultFieldManager,
+		Usage: "The name of the field manager that owns the fields updated with --server-side.",
+	}
 
 	// Flags for cleaning up security group
 	securityGroupIDFlag = cli.StringFlag{
 		Name:  "security-group-id",
-		Usage: "ID of the Security Group created by EKS to manage EKS nodes.",
+		Usage: "ID of the Security Group created by EKS to manage EKS nodes. When omitted, looked up from the EKS cluster, which requires the cluster to still exist.",
 	}
 
 	vpcIDFlag = cli.StringFlag{
 		Name:  "vpc-id",
 		Usage: "(Required) ID of the VPC where EKS is running.",
 	}
+	// The VPC ID is optional for cleanup-security-group, as it can be looked up from the cluster.
+	cleanupVPCIDFlag = cli.StringFlag{
+		Name:  vpcIDFlag.Name,
+		Usage: "ID of the VPC where EKS is running. When omitted, looked up from the EKS cluster, which requires the cluster to still exist.",
+	}
+
+	cleanupConcurrencyFlag = cli.IntFlag{
+		Name:  "concurrency",
+		Value: eks.DefaultCleanupConcurrency,
+		Usage: "The maximum number of network interfaces to detach and delete in parallel while cleaning up the security groups. Defaults to 10.",
+	}
+
+	cleanupDryRunFlag = cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Only log the network interfaces and security groups that would be detached and deleted, without modifying any resources. AWS permissions are still validated.",
+	}
+
+	cleanupTagFilterFlag = cli.StringSliceFlag{
+		Name:  "tag-filter",
+		Usage: "key=value pair to additionally clean up the security groups in the VPC with the given tag. Pass in just the key to match any value of the tag. Pass in multiple times for multiple tags. The security groups tagged by the AWS Load Balancer Controller and the legacy ALB ingress controller are always cleaned up.",
+	}
+
+	cleanupSkipALBCleanupFlag = cli.BoolFlag{
+		Name:  "skip-alb-cleanup",
+		Usage: "Only clean up the given security group and its network interfaces, skipping the sweep of the security groups tagged by the AWS Load Balancer Controller, the legacy ALB ingress controller, and --tag-filter. Use this for clusters that do not use ALB ingress.",
+	}
+
+	cleanupOverallTimeoutFlag = cli.DurationFlag{
+		Name:  "overall-timeout",
+		Usage: "The deadline for the whole cleanup as duration (e.g 10m = 10 minutes). When it passes, the cleanup is aborted, and the resources that are not cleaned up yet are reported. Rerun the command to resume the cleanup. Defaults to no deadline.",
+	}
+
+	cleanupRegionFlag = cli.StringFlag{
+		Name:  "region",
+		Usage: "The AWS region code (e.g us-east-1) of the security groups, when they are in a different region than the EKS cluster. The cluster ARN is still used for the name of the cluster. Defaults to the region of the cluster ARN.",
+	}
+
+	cleanupOutputFileFlag = cli.StringFlag{
+		Name:  "output-file",
+		Usage: "Path to write a JSON summary of the cleanup to, listing the deleted and remaining resources, the phase durations, and the errors. The file is written even when the cleanup fails.",
+	}
+
+	cleanupForceFlag = cli.BoolFlag{
+		Name:  "force",
+		Usage: "Clean up the security groups even if the EKS cluster still exists, or if the security group does not belong to the cluster. By default, the cleanup is refused unless the cluster is deleted or in the DELETING or FAILED state, as deleting the security groups of a running cluster breaks the cluster, and unless the security group is the cluster security group or is tagged with the name of the cluster.",
+	}
+
+	tagClusterResourcesTagFlag = cli.StringSliceFlag{
+		Name:  "tag",
+		Usage: "key=value pair of a tag to apply to the resources of the EKS cluster. Pass in multiple times for multiple tags.",
+	}
 
 	clusterNameFlag = cli.StringFlag{
 		Name:  "eks-cluster-name",
@@ -116,6 +295,121 @@ var (
 		Name:  "fargate-profile-arn",
 		Usage: "The ARN of the Fargate profile.",
 	}
+	corednsClusterArnFlag = cli.StringFlag{
+		Name:  "eks-cluster-arn",
+		Usage: "The AWS ARN of the EKS cluster. When set, verifies that a Fargate profile selects the coredns Pods and restarts them on Fargate, instead of using --eks-cluster-name and --fargate-profile-arn.",
+	}
+
+	nodeNameFlag = cli.StringFlag{
+		Name:  "node-name",
+		Usage: "(Required) The name of the Kubernetes node to replace.",
+	}
+	nodeEmptyNodeNameFlag = cli.StringFlag{
+		Name:  "node-name",
+		Usage: "(Required) The name of the Kubernetes node to wait on.",
+	}
+	rolloutNamespaceFlag = cli.StringFlag{
+		Name:  "namespace",
+		Value: "default",
+		Usage: "The namespace of the Deployment to wait on. Defaults to default.",
+	}
+	rolloutDeploymentNameFlag = cli.StringFlag{
+		Name:  "deployment-name",
+		Usage: "(Required) The name of the Deployment to wait on.",
+	}
+	rolloutTimeoutFlag = cli.DurationFlag{
+		Name:  "timeout",
+		Value: 10 * time.Minute,
+		Usage: "The length of time as duration (e.g 10m = 10 minutes) to wait for the rollout to complete before giving up, zero means infinite. Defaults to 10 minutes.",
+	}
+	nodeEmptyTimeoutFlag = cli.DurationFlag{
+		Name:  "timeout",
+		Value: 15 * time.Minute,
+		Usage: "The length of time as duration (e.g 10m = 10 minutes) to wait for the Pods on the node to terminate before giving up, zero means infinite. Defaults to 15 minutes.",
+	}
+	instanceRefreshMinHealthyPercentageFlag = cli.IntFlag{
+		Name:  "min-healthy-percentage",
+		Usage: "The percentage of the desired capacity of the Auto Scaling Group that must stay healthy during the instance refresh, which bounds how many instances are replaced at a time. Defaults to the AWS default of 90.",
+	}
+	instanceRefreshInstanceWarmupFlag = cli.DurationFlag{
+		Name:  "instance-warmup",
+		Usage: "The length of time as duration (e.g 5m = 5 minutes) to wait after a new instance is healthy before it counts towards the healthy capacity of the Auto Scaling Group. Defaults to the health check grace period of the Auto Scaling Group.",
+	}
+	instanceRefreshTimeoutFlag = cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "The length of time as duration (e.g 2h = 2 hours) to wait for the instance refresh to complete before giving up, zero means infinite. The instance refresh keeps going in AWS after the timeout. Defaults to infinite.",
+	}
+	instanceRefreshDrainLifecycleHookFlag = cli.StringFlag{
+		Name:  "drain-lifecycle-hook",
+		Usage: "The name of a termination lifecycle hook of the Auto Scaling Group. When set, the node of each instance that waits on the hook is drained before the lifecycle action is completed and the instance is terminated. The heartbeat timeout of the hook must be longer than --drain-timeout.",
+	}
+
+	nodeGroupNameFlag = cli.StringFlag{
+		Name:  "nodegroup-name",
+		Usage: "(Required) The name of the EKS managed node group.",
+	}
+	nodeGroupNodeSelectorFlag = cli.StringFlag{
+		Name:  "node-selector",
+		Usage: "A label selector (e.g., workload=gpu,zone!=us-east-1a) to only act on the nodes of the node group that match it, in the same format as kubectl get nodes --selector. Defaults to all the nodes of the node group.",
+	}
+	nodeGroupKubernetesVersionFlag = cli.StringFlag{
+		Name:  "kubernetes-version",
+		Usage: "The Kubernetes version to upgrade the managed node group to. Defaults to the Kubernetes version of the EKS cluster.",
+	}
+	nodeGroupForceFlag = cli.BoolFlag{
+		Name:  "force",
+		Usage: "Replace the nodes even if draining them is blocked by a PodDisruptionBudget. By default, the upgrade fails in that case.",
+	}
+
+	nodeGroupTaintFlag = cli.StringFlag{
+		Name:  "taint",
+		Usage: "A taint in the format key[=value]:effect (e.g., maintenance=true:NoSchedule) to add to the nodes when cordoning them, or to remove from the nodes when uncordoning them.",
+	}
+
+	nodeGroupKeyNameFlag = cli.StringFlag{
+		Name:  "key-name",
+		Usage: "(Required) The name of the EC2 key pair to rotate the SSH key of the nodes to.",
+	}
+
+	// Flags for updating the aws-auth ConfigMap
+	awsAuthMapRoleFlag = cli.StringSliceFlag{
+		Name:  "map-role",
+		Usage: `An IAM role mapping to upsert into the mapRoles of the aws-auth ConfigMap, as json with the rolearn, username, and groups fields (e.g., {"rolearn": "arn:aws:iam::111111111111:role/admin", "username": "admin", "groups": ["system:masters"]}). Pass in multiple times for multiple roles.`,
+	}
+	awsAuthMapUserFlag = cli.StringSliceFlag{
+		Name:  "map-user",
+		Usage: "An IAM user mapping to upsert into the mapUsers of the aws-auth ConfigMap, as json with the userarn, username, and groups fields. Pass in multiple times for multiple users.",
+	}
+	awsAuthRemoveFlag = cli.BoolFlag{
+		Name:  "remove",
+		Usage: "When set, remove the mappings with the ARNs passed in with --map-role and --map-user instead of upserting them. Only the rolearn and userarn fields are needed in this mode.",
+	}
+	awsAuthDryRunFlag = cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "When set, only print the resulting aws-auth ConfigMap as YAML, without changing anything.",
+	}
+
+	nodeGroupVersionsJSONFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "When set, print the report as JSON instead of a table.",
+	}
+
+	describeOIDCJSONFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "When set, print the description as JSON instead of a table.",
+	}
+
+	cleanupTargetsOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Value: cleanupTargetsTableOutput,
+		Usage: fmt.Sprintf("The format of the report. One of: %s. The json and yaml formats follow the schema of eks.CleanupTarget. Defaults to a table.", strings.Join(cleanupTargetsOutputFormats, ", ")),
+	}
+
+	verifyAccessOperationFlag = cli.StringFlag{
+		Name:  "operation",
+		Value: eks.VerifyAccessOperationAll,
+		Usage: fmt.Sprintf("The operation to check the permissions for. One of: %s.", strings.Join(eks.VerifyAccessOperations(), ", ")),
+	}
 )
 
 // SetupEksCommand creates the cli.Command entry for the eks subcommand of kubergrunt
@@ -143,11 +437,12 @@ func SetupEksCommand() cli.Command {
 					cli.Command{
 						Name:        "fargate",
 						Usage:       "Remove annotation on coredns deployment resource.",
-						Description: "Remove annotation on coredns deployment resource to enable fargate.",
+						Description: "Remove annotation on coredns deployment resource to enable fargate. When --eks-cluster-arn is set, this first verifies that an active Fargate profile selects the coredns Pods in the kube-system namespace, and rolls the coredns Pods so that they are scheduled on Fargate.",
 						Action:      scheduleCorednsFargate,
 						Flags: []cli.Flag{
 							clusterNameFlag,
 							fargateProfileArnFlag,
+							corednsClusterArnFlag,
 						},
 					},
 				},
@@ -158,12 +453,26 @@ func SetupEksCommand() cli.Command {
 				Description: "This will verify that the Kubernetes API server is up and accepting traffic for the specified EKS cluster. This does not verify kubectl authentication: use kubectl directly for that purpose.",
 				Action:      verifyCluster,
 				Flags: []cli.Flag{
-					eksClusterArnFlag,
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
 					waitFlag,
 					waitMaxRetriesFlag,
 					waitSleepBetweenRetriesFlag,
 				},
 			},
+			cli.Command{
+				Name:        "verify-access",
+				Usage:       "Verify that the current credentials have the permissions kubergrunt needs.",
+				Description: "Runs cheap read only probes to check that the current credentials have the AWS and Kubernetes permissions needed to operate on the EKS cluster: STS GetCallerIdentity, EKS DescribeCluster, EC2 DescribeSecurityGroups (as a dry run), and a Kubernetes SelfSubjectAccessReview for each of the API permissions that kubergrunt needs. Prints a table of the checks, and exits with an error if any of them failed. Pass --operation to only check the permissions needed by a specific operation.",
+				Action:      verifyAccess,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					verifyAccessOperationFlag,
+				},
+			},
 			cli.Command{
 				Name:        "configure",
 				Usage:       "Set up kubectl to be able to authenticate with EKS.",
@@ -173,6 +482,9 @@ func SetupEksCommand() cli.Command {
 					eksClusterArnFlag,
 					eksKubectlContextNameFlag,
 					genericKubeconfigFlag,
+					setCurrentContextFlag,
+					waitForActiveFlag,
+					waitForActiveTimeoutFlag,
 				},
 			},
 			cli.Command{
@@ -182,7 +494,40 @@ func SetupEksCommand() cli.Command {
 				Action:      getAuthToken,
 				Flags: []cli.Flag{
 					clusterIDFlag,
+					tokenClusterArnFlag,
 					tokenAsTFDataFlag,
+					tokenExecCredentialVersionFlag,
+					tokenCacheDirFlag,
+					tokenNoCacheFlag,
+					tokenTimeoutFlag,
+					tokenTTLFlag,
+				},
+			},
+			cli.Command{
+				Name:        "verify-token",
+				Usage:       "Verify that the Kubernetes API server of the EKS cluster accepts a token.",
+				Description: "Generates a token with the current AWS credentials as the token command does, or reads one from stdin with --token-stdin, and uses it to call the Kubernetes API server of the EKS cluster. Prints the Kubernetes user and groups that the token authenticates as, using a SelfSubjectReview, as JSON. This helps debug aws-auth ConfigMap misconfigurations, where a valid token is rejected or maps to a user without the expected groups. On clusters that do not serve SelfSubjectReview (before Kubernetes 1.27), this only confirms that the token authenticates.",
+				Action:      verifyToken,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					verifyTokenStdinFlag,
+				},
+			},
+			cli.Command{
+				Name:        "aws-auth",
+				Usage:       "Map IAM roles and users to Kubernetes users and groups in the aws-auth ConfigMap.",
+				Description: "Merges the IAM role and user mappings passed in with --map-role and --map-user into the mapRoles and mapUsers of the aws-auth ConfigMap in the kube-system namespace, which EKS uses to map IAM identities to Kubernetes users and groups for RBAC. Mappings are keyed by ARN, so an existing mapping for the same ARN is replaced instead of duplicated, and all the other mappings are preserved. Pass --remove to remove the mappings with the given ARNs instead. The command fails without changing anything if the existing mappings are not valid.",
+				Action:      updateAwsAuth,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					awsAuthMapRoleFlag,
+					awsAuthMapUserFlag,
+					awsAuthRemoveFlag,
+					awsAuthDryRunFlag,
 				},
 			},
 			cli.Command{
@@ -194,6 +539,53 @@ func SetupEksCommand() cli.Command {
 					oidcIssuerUrlFlag,
 				},
 			},
+			cli.Command{
+				Name:        "cluster-ca",
+				Usage:       "Output the CA certificate of the Kubernetes API server of the EKS cluster.",
+				Description: "Looks up the CA certificate of the Kubernetes API server of the EKS cluster and writes it in PEM format to --output-file, or stdout. This is useful to configure tools that need the cluster CA separately from a kubeconfig, such as sidecars and service meshes.",
+				Action:      getClusterCA,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					clusterCAOutputFileFlag,
+					clusterCABase64Flag,
+				},
+			},
+			cli.Command{
+				Name:        "associate-oidc-provider",
+				Usage:       "Register the OIDC issuer of the EKS cluster as an IAM OIDC provider.",
+				Description: "Looks up the OIDC issuer of the EKS cluster and its root CA thumbprint, and creates the corresponding IAM OIDC provider if it does not already exist. This is necessary to use IAM Roles for Service Accounts.",
+				Action:      associateOIDCProvider,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+				},
+			},
+			cli.Command{
+				Name:        "describe-oidc",
+				Usage:       "Describe the OIDC issuer of the EKS cluster and its IAM OIDC provider.",
+				Description: "Looks up the OIDC issuer URL of the EKS cluster, the ARN of the corresponding IAM OIDC provider, whether the provider exists, and the thumbprints and audiences it trusts. This is useful to troubleshoot the authentication of Service Accounts with IAM Roles for Service Accounts.",
+				Action:      describeOIDC,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					describeOIDCJSONFlag,
+				},
+			},
+			cli.Command{
+				Name:        "rotate-oidc-thumbprint",
+				Usage:       "Update the IAM OIDC provider of the EKS cluster to trust the current thumbprint of its OIDC issuer.",
+				Description: "Recomputes the root CA thumbprint of the OIDC issuer of the EKS cluster, and updates the IAM OIDC provider for the issuer to trust only that thumbprint if it does not already trust it. This is idempotent, so it is safe to run on a schedule to catch rotations of the CA of the issuer, which otherwise break IAM Roles for Service Accounts.",
+				Action:      rotateOIDCThumbprint,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+				},
+			},
 			cli.Command{
 				Name:  "sync-core-components",
 				Usage: "Update the core Kubernetes applications deployed on to the EKS cluster to match the Kubernetes version.",
@@ -209,12 +601,21 @@ Each of these are managed in Kubernetes as DaemonSet, Deployment, and DaemonSet
 The versions deployed are based on what is listed in the official guide provided by AWS: https://docs.aws.amazon.com/eks/latest/userguide/update-cluster.html`,
 				Action: syncClusterComponents,
 				Flags: []cli.Flag{
-					eksClusterArnFlag,
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
 					waitFlag,
 					waitTimeoutFlag,
 					syncSkipKubeProxyFlag,
 					syncSkipCoreDNSFlag,
 					syncSkipVPCCNIFlag,
+					syncComponentFlag,
+					syncDryRunFlag,
+					syncServerSideFlag,
+					syncFieldManagerFlag,
+					syncAllowIncompatibleFlag,
+					waitForActiveFlag,
+					waitForActiveTimeoutFlag,
 				},
 			},
 			cli.Command{
@@ -236,6 +637,8 @@ This command includes retry loops for certain stages (e.g waiting for the ASG to
 If max-retries is unspecified, this command will use a value that translates to a total wait time of 5 minutes per wave of ASG, where each wave is 10 instances. For example, if the number of instances in the ASG is 15 instances, this translates to 2 waves, which leads to a total wait time of 10 minutes. To achieve a 10 minute wait time with the default sleep between retries (15 seconds), the max retries needs to be set to 40.
 
 As the deploy command contains multiple stages, this command also generates a recovery file (.kubergrunt.state) containing the current deploy state in the working directory. The state file is used to resume the deploy operation from the point of failure, and is automatically deleted upon completion of the command. You can optionally ignore the state file with --ignore-recovery-file flag, which will generate a new recovery file.
+
+Pass --dry-run to preview the roll out without changing anything: this prints the instances that will be replaced in order, along with the current and target launch template versions, and checks that the ASG is in a steady state with all instances healthy.
 `,
 				Action: rollOutDeployment,
 				Flags: []cli.Flag{
@@ -252,6 +655,37 @@ As the deploy command contains multiple stages, this command also generates a re
 					waitMaxRetriesFlag,
 					waitSleepBetweenRetriesFlag,
 					ignoreRecoveryFileFlag,
+					deployDryRunFlag,
+				},
+			},
+			cli.Command{
+				Name:  "rolling-deploy",
+				Usage: "Roll out cluster updates to worker nodes, replacing a few instances at a time.",
+				Description: `Performs a rolling deployment of changes to the underlying EC2 instances of a self managed worker group in an EKS cluster, replacing at most --max-unavailable instances at a time. Unlike the deploy command, this does not need room in the Auto Scaling Group to double its capacity. This subcommand will repeat the following until all instances run the current launch template version of the Auto Scaling Group:
+
+  1. Increase the desired capacity of the Auto Scaling Group by up to --max-unavailable instances to launch new EKS workers.
+  2. Wait for the new nodes to be ready for Pod scheduling in Kubernetes.
+  3. Cordon and drain the same number of old EKS workers (using the equivalent of "kubectl drain").
+  4. Remove the old EKS workers from the Auto Scaling Group and terminate them.
+
+If draining an old EKS worker fails, the command aborts without terminating any instances so that you can investigate. The old EKS workers are left cordoned in that case. Rerun the command to continue the roll out.
+
+This command includes retry loops that are configurable with the options --max-retries and --sleep-between-retries, similar to the deploy command.
+
+Pass --dry-run to preview the roll out without changing anything: this prints the outdated instances that will be replaced, in batches of --max-unavailable, along with the current and target launch template versions and the max size the Auto Scaling Group needs, and checks that the ASG is in a steady state with all instances healthy.
+`,
+				Action: rollingDeployment,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					clusterAsgNameFlag,
+					maxUnavailableFlag,
+					drainTimeoutFlag,
+					deleteEmptyDirDataFlag,
+					waitMaxRetriesFlag,
+					waitSleepBetweenRetriesFlag,
+					deployDryRunFlag,
 				},
 			},
 			cli.Command{
@@ -283,15 +717,244 @@ You can also drain multiple ASGs by providing the "--asg-name" option multiple t
 					deleteEmptyDirDataFlag,
 				},
 			},
+			cli.Command{
+				Name:  "replace-node",
+				Usage: "Drain a single worker node and replace its EC2 instance.",
+				Description: `Evacuates and replaces a single bad worker node of a self managed worker group in an EKS cluster. This subcommand will:
+
+  1. Look up the EC2 instance of the node from its spec.providerID, and the Auto Scaling Group it is in.
+  2. Cordon and drain the node, respecting PodDisruptionBudgets.
+  3. Detach the instance from the Auto Scaling Group without decrementing the desired capacity, so that a replacement instance launches.
+  4. Terminate the instance.
+  5. Wait for the replacement instance to join the cluster as a Ready node.
+
+The ID of the replacement instance is printed to stdout. If draining the node fails, the command aborts without terminating the instance, and the node is left cordoned.
+`,
+				Action: replaceNode,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					nodeNameFlag,
+					drainTimeoutFlag,
+					deleteEmptyDirDataFlag,
+					maxEvictionsPerSecondFlag,
+					evictionOrderFlag,
+					drainExcludeNamespacesFlag,
+					drainIncludeNamespacesFlag,
+				},
+			},
+			cli.Command{
+				Name:  "instance-refresh",
+				Usage: "Replace the instances of a worker Auto Scaling Group with an EC2 Auto Scaling instance refresh.",
+				Description: `Replaces the instances of a self managed worker group in an EKS cluster with the native instance refresh of EC2 Auto Scaling, as an alternative to the rolling-deploy command. This subcommand will start a rolling instance refresh of the Auto Scaling Group with the given --min-healthy-percentage and --instance-warmup, then wait for it to complete, logging its progress. If the instance refresh fails or is cancelled, the command exits with an error reporting the status and reason from AWS.
+
+The instance refresh does not drain the nodes before terminating their instances on its own. To drain them, add a termination lifecycle hook (autoscaling:EC2_INSTANCE_TERMINATING) to the Auto Scaling Group, and pass its name with --drain-lifecycle-hook. The node of each instance that waits on the hook is then drained, respecting PodDisruptionBudgets, before the lifecycle action is completed. If draining a node fails, the instance refresh is cancelled so that no other instance is replaced, and the instance is terminated when the hook times out.
+`,
+				Action: instanceRefresh,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					clusterAsgNameFlag,
+					instanceRefreshMinHealthyPercentageFlag,
+					instanceRefreshInstanceWarmupFlag,
+					instanceRefreshTimeoutFlag,
+					instanceRefreshDrainLifecycleHookFlag,
+					drainTimeoutFlag,
+					deleteEmptyDirDataFlag,
+					maxEvictionsPerSecondFlag,
+					evictionOrderFlag,
+					drainExcludeNamespacesFlag,
+					drainIncludeNamespacesFlag,
+				},
+			},
+			cli.Command{
+				Name:        "wait-for-node-empty",
+				Usage:       "Wait for all the Pods on a Kubernetes node to terminate.",
+				Description: "Waits until there are no more Pods on the node, other than mirror Pods, Pods managed by a DaemonSet and Pods that have completed, which are not evicted when draining the node. Use this after draining a node and before terminating its instance. If there are still Pods on the node after the timeout, the command exits with an error listing them.",
+				Action:      waitForNodeEmpty,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					nodeEmptyNodeNameFlag,
+					nodeEmptyTimeoutFlag,
+				},
+			},
+			cli.Command{
+				Name:        "wait-for-rollout",
+				Usage:       "Wait for the rollout of a Kubernetes Deployment to complete.",
+				Description: "Waits until the rollout of the Deployment is complete, the same way as kubectl rollout status: the latest generation of the Deployment is observed, all the replicas are updated and available, and the old replicas are terminated. If the rollout does not complete within the timeout, or exceeds the progress deadline of the Deployment, the command exits with an error describing the last observed status.",
+				Action:      waitForRollout,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					rolloutNamespaceFlag,
+					rolloutDeploymentNameFlag,
+					rolloutTimeoutFlag,
+				},
+			},
+			cli.Command{
+				Name:        "upgrade-nodegroup",
+				Usage:       "Upgrade the Kubernetes version of an EKS managed node group.",
+				Description: "Starts the upgrade of the EKS managed node group to the given Kubernetes version using the EKS UpdateNodegroupVersion API, which rolls the nodes of the node group, and waits for the update to complete while reporting its progress. If the update fails, the errors reported by EKS are logged, and the command exits with an error. Pass --force to replace the nodes even if draining them is blocked by a PodDisruptionBudget.",
+				Action:      upgradeNodeGroup,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					nodeGroupNameFlag,
+					nodeGroupKubernetesVersionFlag,
+					nodeGroupForceFlag,
+				},
+			},
+			cli.Command{
+				Name:        "cordon-nodegroup",
+				Usage:       "Cordon all the nodes of an EKS managed node group.",
+				Description: "Marks all the nodes of the EKS managed node group, found by the eks.amazonaws.com/nodegroup label, as unschedulable, without draining them. Pass --taint to also add a taint to the nodes, and --node-selector to only cordon the nodes that match a label selector. Nodes that are already cordoned are skipped.",
+				Action:      cordonNodeGroup,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					nodeGroupNameFlag,
+					nodeGroupNodeSelectorFlag,
+					nodeGroupTaintFlag,
+				},
+			},
+			cli.Command{
+				Name:        "uncordon-nodegroup",
+				Usage:       "Uncordon all the nodes of an EKS managed node group.",
+				Description: "Marks all the nodes of the EKS managed node group, found by the eks.amazonaws.com/nodegroup label, as schedulable again. Pass --taint to also remove the taint that was added with cordon-nodegroup, and --node-selector to only uncordon the nodes that match a label selector. Nodes that are already schedulable are skipped.",
+				Action:      uncordonNodeGroup,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					nodeGroupNameFlag,
+					nodeGroupNodeSelectorFlag,
+					nodeGroupTaintFlag,
+				},
+			},
+			cli.Command{
+				Name:        "rotate-nodegroup-key",
+				Usage:       "Rotate the SSH key of the nodes of an EKS managed node group.",
+				Description: "Creates a new version of the launch template of the EKS managed node group with the given EC2 key pair, and rolls the node group to the new version, waiting for the update to complete. The key pair is verified to exist before anything is changed. The node group must be deployed with a launch template.",
+				Action:      rotateNodeGroupKey,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					nodeGroupNameFlag,
+					nodeGroupKeyNameFlag,
+				},
+			},
+			cli.Command{
+				Name:        "nodegroup-versions",
+				Usage:       "List the nodes of an EKS managed node group with the launch template version they run.",
+				Description: "Maps each node of the EKS managed node group to its EC2 instance, and prints a table of the launch template version the instance was launched with against the current launch template version of the node group, marking the stale nodes that the next rolling deploy replaces. Pass --json to print the report as JSON instead. This is read only.",
+				Action:      listNodeGroupVersions,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					nodeGroupNameFlag,
+					nodeGroupNodeSelectorFlag,
+					nodeGroupVersionsJSONFlag,
+				},
+			},
 			cli.Command{
 				Name:        "cleanup-security-group",
 				Usage:       "Delete the AWS-managed security group created for the EKS cluster.",
-				Description: "When destroying the EKS cluster, the AWS provider leaves behind the security group created for the EKS cluster. This command makes sure to clean up that resource. It can be called before or after the EKS cluster is destroyed. It must be called with the AWS-managed security-group-id for the EKS cluster, but it also finds other security groups by tag associated with the EKS cluster.",
+				Description: "When destroying the EKS cluster, the AWS provider leaves behind the security group created for the EKS cluster. This command makes sure to clean up that resource. It must be called after the EKS cluster is destroyed (or while it is being deleted), unless --force is passed. It must be called with the AWS-managed security-group-id for the EKS cluster, but it also finds other security groups by tag associated with the EKS cluster.",
 				Action:      cleanupSecurityGroup,
 				Flags: []cli.Flag{
-					eksClusterArnFlag,
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
 					securityGroupIDFlag,
+					cleanupVPCIDFlag,
+					cleanupConcurrencyFlag,
+					cleanupDryRunFlag,
+					cleanupForceFlag,
+					cleanupTagFilterFlag,
+					cleanupSkipALBCleanupFlag,
+					cleanupOverallTimeoutFlag,
+					cleanupRegionFlag,
+					cleanupOutputFileFlag,
+					waitMaxRetriesFlag,
+					waitSleepBetweenRetriesFlag,
+				},
+			},
+			cli.Command{
+				Name:        "cleanup-load-balancers",
+				Usage:       "Delete the load balancers provisioned for the Kubernetes Services of the EKS cluster.",
+				Description: "When destroying the EKS cluster, the Classic and Network Load Balancers provisioned for Services of type LoadBalancer are left behind if the Services were not deleted first. This command finds all the load balancers tagged as owned by the EKS cluster, deletes them, and waits for the deletion to complete. The target groups owned by the EKS cluster that are left without a load balancer are then deleted as well.",
+				Action:      cleanupLoadBalancers,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+				},
+			},
+			cli.Command{
+				Name:        "cleanup-persistent-volumes",
+				Usage:       "Delete the EBS volumes provisioned for the PersistentVolumes of the EKS cluster.",
+				Description: "When destroying the EKS cluster, the EBS volumes that were dynamically provisioned for PersistentVolumeClaims are left behind if the claims were not deleted first. This command finds all the unattached EBS volumes tagged for the EKS cluster and deletes them. Volumes that are in use are never deleted.",
+				Action:      cleanupPersistentVolumes,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					cleanupDryRunFlag,
+				},
+			},
+			cli.Command{
+				Name:        "cleanup-fargate-enis",
+				Usage:       "Delete the network interfaces left behind by the Fargate profiles of the EKS cluster.",
+				Description: "Deleting a Fargate profile sometimes leaves behind the network interfaces created for the Fargate Pods, which block deleting the subnets and the VPC. This command finds the network interfaces in the VPC that were created by Fargate for the EKS cluster, and detaches and deletes them. Network interfaces that are still used by a running Pod are skipped, so this is safe to run repeatedly.",
+				Action:      cleanupFargateENIs,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					vpcIDFlag,
+				},
+			},
+			cli.Command{
+				Name:        "describe-cleanup-targets",
+				Usage:       "List the resources that the cleanup commands would affect, for all the EKS clusters in a VPC.",
+				Description: "Finds the security groups tagged for EKS clusters (by EKS, the AWS Load Balancer Controller, the legacy ALB ingress controller, or with kubernetes.io/cluster/<name>: owned) along with the network interfaces that use them, and the load balancers owned by EKS clusters in the VPC. The resources are printed to stdout grouped by cluster name, as a table or as JSON or YAML with --output, so that they can be reviewed before tearing down the clusters. This is read only: no resources are modified.",
+				Action:      describeCleanupTargets,
+				Flags: []cli.Flag{
+					clusterRegionFlag,
 					vpcIDFlag,
+					cleanupTargetsOutputFlag,
+				},
+			},
+			cli.Command{
+				Name:        "verify-cleanup",
+				Usage:       "Verify that the teardown of an EKS cluster did not leave any resources behind.",
+				Description: "Checks that there are no security groups tagged for the EKS cluster in the VPC, no network interfaces that use them or that reference the cluster in their description, no load balancers owned by the cluster, and no EBS volumes tagged for the cluster. The leftovers are printed to stdout as JSON, and the command exits with an error if there are any, so that CI can assert that the teardown is clean. This is read only: no resources are modified.",
+				Action:      verifyCleanup,
+				Flags: []cli.Flag{
+					clusterRegionFlag,
+					vpcIDFlag,
+					clusterNameFlag,
+				},
+			},
+			cli.Command{
+				Name:        "tag-cluster-resources",
+				Usage:       "Tag the resources of the EKS cluster, e.g. for cost allocation.",
+				Description: "Applies the tags to the resources of the EKS cluster: the security groups in the VPC of the cluster that are tagged for the cluster, the network interfaces that use them or that reference the cluster in their description, the load balancers owned by the cluster, and the EBS volumes tagged for the cluster. Resources that already have all the tags are skipped, so this is safe to run repeatedly. Logs how many resources of each type were tagged.",
+				Action:      tagClusterResources,
+				Flags: []cli.Flag{
+					eksClusterArnOrContextFlag,
+					eksContextFlag,
+					genericKubeconfigFlag,
+					tagClusterResourcesTagFlag,
 				},
 			},
 		},
@@ -301,7 +964,7 @@ You can also drain multiple ASGs by providing the "--asg-name" option multiple t
 // Command action for `kubergrunt eks verify`
 func verifyCluster(cliContext *cli.Context) error {
 	// Check for required flags
-	eksClusterArn, err := entrypoint.StringFlagRequiredE(cliContext, eksClusterArnFlag.Name)
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
 	if err != nil {
 		return err
 	}
@@ -311,6 +974,29 @@ func verifyCluster(cliContext *cli.Context) error {
 	return eks.VerifyCluster(eksClusterArn, wait, waitMaxRetries, waitSleepBetweenRetries)
 }
 
+// Command action for `kubergrunt eks verify-access`
+func verifyAccess(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+
+	results, err := eks.VerifyAccess(eksClusterArn, cliContext.String(verifyAccessOperationFlag.Name))
+	if len(results) > 0 {
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "CHECK\tRESULT\tDETAIL")
+		for _, result := range results {
+			outcome := "PASS"
+			if !result.Passed {
+				outcome = "FAIL"
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\n", result.Name, outcome, result.Detail)
+		}
+		writer.Flush()
+	}
+	return err
+}
+
 // Command action for `kubergrunt eks configure`
 func setupKubectl(cliContext *cli.Context) error {
 	// Check for required flags
@@ -333,25 +1019,45 @@ func setupKubectl(cliContext *cli.Context) error {
 		return errors.WithStackTrace(err)
 	}
 
-	cluster, err := eksawshelper.GetClusterByArn(eksClusterArn)
+	if err := waitForClusterActiveIfRequested(cliContext, eksClusterArn); err != nil {
+		return err
+	}
+	clusterInfo, err := eksawshelper.GetClusterInfo(eksClusterArn)
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
 	return eks.ConfigureKubectlForEks(
-		cluster,
+		clusterInfo.Cluster,
 		kubectlOptions,
+		cliContext.Bool(setCurrentContextFlag.Name),
 	)
 }
 
+// waitForClusterActiveIfRequested waits for the EKS cluster to be active when the --wait-for-active flag is set.
+func waitForClusterActiveIfRequested(cliContext *cli.Context, eksClusterArn string) error {
+	if !cliContext.Bool(waitForActiveFlag.Name) {
+		return nil
+	}
+	return eks.WaitForClusterActive(eksClusterArn, cliContext.Duration(waitForActiveTimeoutFlag.Name))
+}
+
 // Command action for `kubergrunt eks token`
 func getAuthToken(cliContext *cli.Context) error {
-	clusterID, err := entrypoint.StringFlagRequiredE(cliContext, "cluster-id")
-	if err != nil {
-		return errors.WithStackTrace(err)
+	clusterID := cliContext.String(clusterIDFlag.Name)
+	clusterArn := cliContext.String(tokenClusterArnFlag.Name)
+	if clusterID == "" && clusterArn == "" {
+		return entrypoint.NewRequiredArgsError("Either --cluster-id or --eks-cluster-arn is required")
 	}
 	tokenAsTFData := cliContext.Bool(tokenAsTFDataFlag.Name)
+	// Unless the version is explicitly set, use the version that kubectl requested.
+	execCredentialVersion := eksawshelper.GetExecCredentialAPIVersionFromEnv()
+	if cliContext.IsSet(tokenExecCredentialVersionFlag.Name) {
+		execCredentialVersion = cliContext.String(tokenExecCredentialVersionFlag.Name)
+	}
 
-	tok, jsonData, err := eksawshelper.GetKubernetesTokenForCluster(clusterID)
+	eksawshelper.SetRequestTimeout(cliContext.Duration(tokenTimeoutFlag.Name))
+	eksawshelper.SetTokenTTL(cliContext.Duration(tokenTTLFlag.Name))
+	tok, err := getKubernetesToken(cliContext, clusterID, clusterArn)
 	if err != nil {
 		return err
 	}
@@ -368,12 +1074,131 @@ func getAuthToken(cliContext *cli.Context) error {
 		os.Stdout.Write(bytesOut)
 	} else {
 		// `kubectl` will parse the JSON from stdout to read in what token to use for authenticating with the cluster.
+		jsonData, err := eksawshelper.FormatExecCredential(tok, execCredentialVersion)
+		if err != nil {
+			return err
+		}
 		fmt.Println(jsonData)
 	}
 	return nil
 }
 
-// Command action for `kubergrunt eks oidc-thumbprint`
+// getKubernetesToken returns a token for the EKS cluster, using the token cache unless it is disabled with --no-cache.
+// The cluster is identified by the ARN when it is provided, and by the cluster ID otherwise.
+func getKubernetesToken(cliContext *cli.Context, clusterID string, clusterArn string) (*token.Token, error) {
+	if cliContext.Bool(tokenNoCacheFlag.Name) {
+		if clusterArn != "" {
+			return eksawshelper.GetKubernetesTokenForClusterArn(clusterArn)
+		}
+		tok, _, err := eksawshelper.GetKubernetesTokenForCluster(clusterID)
+		return tok, err
+	}
+
+	cacheDir := cliContext.String(tokenCacheDirFlag.Name)
+	if cacheDir == "" {
+		defaultCacheDir, err := eksawshelper.DefaultTokenCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		cacheDir = defaultCacheDir
+	}
+	cache := eksawshelper.NewTokenCache(cacheDir)
+	if clusterArn != "" {
+		return eksawshelper.GetKubernetesTokenForClusterArnWithCache(clusterArn, cache)
+	}
+	return eksawshelper.GetKubernetesTokenForClusterWithCache(clusterID, cache)
+}
+
+// Command action for `kubergrunt eks verify-token`
+func verifyToken(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+
+	var identity *eks.TokenIdentity
+	if cliContext.Bool(verifyTokenStdinFlag.Name) {
+		input, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		if strings.TrimSpace(string(input)) == "" {
+			return entrypoint.NewRequiredArgsError("No token was read from stdin with --token-stdin")
+		}
+		identity, err = eks.VerifyGivenToken(eksClusterArn, string(input))
+		if err != nil {
+			return err
+		}
+	} else {
+		identity, err = eks.VerifyToken(eksClusterArn)
+		if err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(identity)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// Command action for `kubergrunt eks aws-auth`
+func updateAwsAuth(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+
+	roleMappings := []eks.RoleMapping{}
+	for _, mappingJSON := range cliContext.StringSlice(awsAuthMapRoleFlag.Name) {
+		var mapping eks.RoleMapping
+		if err := unmarshalJSONStrict(mappingJSON, &mapping); err != nil {
+			return err
+		}
+		roleMappings = append(roleMappings, mapping)
+	}
+	userMappings := []eks.UserMapping{}
+	for _, mappingJSON := range cliContext.StringSlice(awsAuthMapUserFlag.Name) {
+		var mapping eks.UserMapping
+		if err := unmarshalJSONStrict(mappingJSON, &mapping); err != nil {
+			return err
+		}
+		userMappings = append(userMappings, mapping)
+	}
+	if len(roleMappings) == 0 && len(userMappings) == 0 {
+		return entrypoint.NewRequiredArgsError(fmt.Sprintf("At least one of --%s or --%s is required", awsAuthMapRoleFlag.Name, awsAuthMapUserFlag.Name))
+	}
+
+	options := eks.AwsAuthUpdateOptions{
+		Remove: cliContext.Bool(awsAuthRemoveFlag.Name),
+		DryRun: cliContext.Bool(awsAuthDryRunFlag.Name),
+	}
+	configMap, err := eks.UpdateAwsAuthMapping(eksClusterArn, roleMappings, userMappings, options)
+	if err != nil {
+		return err
+	}
+	if options.DryRun {
+		configMap.APIVersion = "v1"
+		configMap.Kind = "ConfigMap"
+		configMap.ManagedFields = nil
+		data, err := yaml.Marshal(configMap)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		fmt.Print(string(data))
+	}
+	return nil
+}
+
+// unmarshalJSONStrict parses the json passed in to a flag into out, rejecting unknown fields so that typos are caught.
+func unmarshalJSONStrict(jsonString string, out interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(jsonString))
+	decoder.DisallowUnknownFields()
+	return errors.WithStackTrace(decoder.Decode(out))
+}
+
+// Command action for `kubergrunt eks oidc-thumbprint`
 func getOIDCThumbprint(cliContext *cli.Context) error {
 	issuerURL, err := entrypoint.StringFlagRequiredE(cliContext, oidcIssuerUrlFlag.Name)
 	if err != nil {
@@ -391,6 +1216,94 @@ func getOIDCThumbprint(cliContext *cli.Context) error {
 	return nil
 }
 
+// Command action for `kubergrunt eks cluster-ca`
+func getClusterCA(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+	outputPath := cliContext.String(clusterCAOutputFileFlag.Name)
+	if cliContext.Bool(clusterCABase64Flag.Name) {
+		return eks.GetClusterCABase64(eksClusterArn, outputPath)
+	}
+	return eks.GetClusterCA(eksClusterArn, outputPath)
+}
+
+// Command action for `kubergrunt eks associate-oidc-provider`
+func associateOIDCProvider(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+	association, err := eks.AssociateOIDCProvider(eksClusterArn)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(association)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// Command action for `kubergrunt eks describe-oidc`
+func describeOIDC(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+	description, err := eks.DescribeOIDC(eksClusterArn)
+	if err != nil {
+		return err
+	}
+	if cliContext.Bool(describeOIDCJSONFlag.Name) {
+		data, err := json.Marshal(description)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	return writeOIDCDescriptionTable(os.Stdout, description)
+}
+
+// writeOIDCDescriptionTable writes the OIDC description as a table, with a row per field.
+func writeOIDCDescriptionTable(out io.Writer, description *eks.OIDCDescription) error {
+	joinOrNone := func(list []string) string {
+		if len(list) == 0 {
+			return "<none>"
+		}
+		return strings.Join(list, ", ")
+	}
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(writer, "CLUSTER\t%s\n", description.ClusterArn)
+	fmt.Fprintf(writer, "ISSUER URL\t%s\n", description.IssuerURL)
+	fmt.Fprintf(writer, "PROVIDER ARN\t%s\n", description.ProviderArn)
+	fmt.Fprintf(writer, "PROVIDER EXISTS\t%t\n", description.ProviderExists)
+	fmt.Fprintf(writer, "THUMBPRINTS\t%s\n", joinOrNone(description.Thumbprints))
+	fmt.Fprintf(writer, "CLIENT IDS\t%s\n", joinOrNone(description.ClientIDs))
+	return errors.WithStackTrace(writer.Flush())
+}
+
+// Command action for `kubergrunt eks rotate-oidc-thumbprint`
+func rotateOIDCThumbprint(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+	rotation, err := eks.RotateOIDCThumbprint(eksClusterArn)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(rotation)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
 // Command action for `kubergrunt eks deploy`
 func rollOutDeployment(cliContext *cli.Context) error {
 	kubectlOptions, err := parseKubectlOptions(cliContext)
@@ -415,6 +1328,7 @@ func rollOutDeployment(cliContext *cli.Context) error {
 	ignoreRecoveryFile := cliContext.Bool(ignoreRecoveryFileFlag.Name)
 	waitMaxRetries := cliContext.Int(waitMaxRetriesFlag.Name)
 	waitSleepBetweenRetries := cliContext.Duration(waitSleepBetweenRetriesFlag.Name)
+	dryRun := cliContext.Bool(deployDryRunFlag.Name)
 
 	return eks.RollOutDeployment(
 		region,
@@ -425,6 +1339,31 @@ func rollOutDeployment(cliContext *cli.Context) error {
 		waitMaxRetries,
 		waitSleepBetweenRetries,
 		ignoreRecoveryFile,
+		dryRun,
+	)
+}
+
+// Command action for `kubergrunt eks rolling-deploy`
+func rollingDeployment(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+
+	asgNames := cliContext.StringSlice(clusterAsgNameFlag.Name)
+	if len(asgNames) != 1 {
+		return ExactlyOneASGErr{flagName: clusterAsgNameFlag.Name}
+	}
+
+	return eks.RollingDeployment(
+		asgNames[0],
+		eksClusterArn,
+		cliContext.Int(maxUnavailableFlag.Name),
+		cliContext.Duration(drainTimeoutFlag.Name),
+		cliContext.Bool(deleteEmptyDirDataFlag.Name),
+		cliContext.Int(waitMaxRetriesFlag.Name),
+		cliContext.Duration(waitSleepBetweenRetriesFlag.Name),
+		cliContext.Bool(deployDryRunFlag.Name),
 	)
 }
 
@@ -456,9 +1395,244 @@ func drainASG(cliContext *cli.Context) error {
 	)
 }
 
+// Command action for `kubergrunt eks replace-node`
+func replaceNode(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+	nodeName, err := entrypoint.StringFlagRequiredE(cliContext, nodeNameFlag.Name)
+	if err != nil {
+		return err
+	}
+
+	opts := eks.DrainOptions{
+		Timeout:            cliContext.Duration(drainTimeoutFlag.Name),
+		DeleteEmptyDirData: cliContext.Bool(deleteEmptyDirDataFlag.Name),
+
+		MaxEvictionsPerSecond: cliContext.Float64(maxEvictionsPerSecondFlag.Name),
+		EvictionOrder:         eks.EvictionOrder(cliContext.String(evictionOrderFlag.Name)),
+
+		ExcludeNamespaces: cliContext.StringSlice(drainExcludeNamespacesFlag.Name),
+		IncludeNamespaces: cliContext.StringSlice(drainIncludeNamespacesFlag.Name),
+	}
+	newInstanceID, err := eks.ReplaceNode(eksClusterArn, nodeName, opts)
+	if newInstanceID != "" {
+		fmt.Println(newInstanceID)
+	}
+	return err
+}
+
+// Command action for `kubergrunt eks instance-refresh`
+func instanceRefresh(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+	asgNames := cliContext.StringSlice(clusterAsgNameFlag.Name)
+	if len(asgNames) != 1 {
+		return ExactlyOneASGErr{flagName: clusterAsgNameFlag.Name}
+	}
+
+	opts := eks.InstanceRefreshOptions{
+		Timeout:                cliContext.Duration(instanceRefreshTimeoutFlag.Name),
+		DrainLifecycleHookName: cliContext.String(instanceRefreshDrainLifecycleHookFlag.Name),
+		DrainOptions: eks.DrainOptions{
+			Timeout:            cliContext.Duration(drainTimeoutFlag.Name),
+			DeleteEmptyDirData: cliContext.Bool(deleteEmptyDirDataFlag.Name),
+
+			MaxEvictionsPerSecond: cliContext.Float64(maxEvictionsPerSecondFlag.Name),
+			EvictionOrder:         eks.EvictionOrder(cliContext.String(evictionOrderFlag.Name)),
+
+			ExcludeNamespaces: cliContext.StringSlice(drainExcludeNamespacesFlag.Name),
+			IncludeNamespaces: cliContext.StringSlice(drainIncludeNamespacesFlag.Name),
+		},
+	}
+	// Both settings fall back to the AWS defaults when they are not set, and zero is a valid value for each of them.
+	if cliContext.IsSet(instanceRefreshMinHealthyPercentageFlag.Name) {
+		minHealthyPercentage := int64(cliContext.Int(instanceRefreshMinHealthyPercentageFlag.Name))
+		opts.MinHealthyPercentage = &minHealthyPercentage
+	}
+	if cliContext.IsSet(instanceRefreshInstanceWarmupFlag.Name) {
+		instanceWarmup := cliContext.Duration(instanceRefreshInstanceWarmupFlag.Name)
+		opts.InstanceWarmup = &instanceWarmup
+	}
+	return eks.StartInstanceRefresh(eksClusterArn, asgNames[0], opts)
+}
+
+// Command action for `kubergrunt eks wait-for-node-empty`
+func waitForNodeEmpty(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+	nodeName, err := entrypoint.StringFlagRequiredE(cliContext, nodeEmptyNodeNameFlag.Name)
+	if err != nil {
+		return err
+	}
+	return eks.WaitForNodeEmpty(eksClusterArn, nodeName, cliContext.Duration(nodeEmptyTimeoutFlag.Name))
+}
+
+// Command action for `kubergrunt eks wait-for-rollout`
+func waitForRollout(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+	deploymentName, err := entrypoint.StringFlagRequiredE(cliContext, rolloutDeploymentNameFlag.Name)
+	if err != nil {
+		return err
+	}
+	return eks.WaitForDeploymentRollout(
+		eksClusterArn,
+		cliContext.String(rolloutNamespaceFlag.Name),
+		deploymentName,
+		cliContext.Duration(rolloutTimeoutFlag.Name),
+	)
+}
+
+// Command action for `kubergrunt eks upgrade-nodegroup`
+func upgradeNodeGroup(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+	nodeGroupName, err := entrypoint.StringFlagRequiredE(cliContext, nodeGroupNameFlag.Name)
+	if err != nil {
+		return err
+	}
+	return eks.UpgradeNodeGroup(
+		eksClusterArn,
+		nodeGroupName,
+		cliContext.String(nodeGroupKubernetesVersionFlag.Name),
+		cliContext.Bool(nodeGroupForceFlag.Name),
+	)
+}
+
+// Command action for `kubergrunt eks cordon-nodegroup`
+func cordonNodeGroup(cliContext *cli.Context) error {
+	return setNodeGroupCordoned(cliContext, eks.CordonNodeGroup)
+}
+
+// Command action for `kubergrunt eks uncordon-nodegroup`
+func uncordonNodeGroup(cliContext *cli.Context) error {
+	return setNodeGroupCordoned(cliContext, eks.UncordonNodeGroup)
+}
+
+// setNodeGroupCordoned parses the flags shared by cordon-nodegroup and uncordon-nodegroup, and calls the given function
+// to update the nodes.
+func setNodeGroupCordoned(
+	cliContext *cli.Context,
+	update func(clusterArn string, nodeGroupName string, taint *corev1.Taint, nodeSelector labels.Selector) (int, error),
+) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+	nodeGroupName, err := entrypoint.StringFlagRequiredE(cliContext, nodeGroupNameFlag.Name)
+	if err != nil {
+		return err
+	}
+	nodeSelector, err := nodeSelectorFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+	var taint *corev1.Taint
+	if taintSpec := cliContext.String(nodeGroupTaintFlag.Name); taintSpec != "" {
+		taint, err = eks.ParseTaint(taintSpec)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The number of nodes that were updated is logged by the update.
+	_, err = update(eksClusterArn, nodeGroupName, taint, nodeSelector)
+	return err
+}
+
+// nodeSelectorFromFlags parses the --node-selector flag, so that a malformed selector is reported before anything is
+// looked up. Returns nil if the flag is not set.
+func nodeSelectorFromFlags(cliContext *cli.Context) (labels.Selector, error) {
+	spec := cliContext.String(nodeGroupNodeSelectorFlag.Name)
+	if spec == "" {
+		return nil, nil
+	}
+	return eks.ParseNodeSelector(spec)
+}
+
+// Command action for `kubergrunt eks rotate-nodegroup-key`
+func rotateNodeGroupKey(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+	nodeGroupName, err := entrypoint.StringFlagRequiredE(cliContext, nodeGroupNameFlag.Name)
+	if err != nil {
+		return err
+	}
+	keyName, err := entrypoint.StringFlagRequiredE(cliContext, nodeGroupKeyNameFlag.Name)
+	if err != nil {
+		return err
+	}
+
+	// If the roll fails, the rotation is returned along with the error, so that we can report the new version.
+	rotation, err := eks.RotateNodeGroupKey(eksClusterArn, nodeGroupName, keyName)
+	if rotation != nil {
+		logging.GetProjectLogger().Infof(
+			"Launch template %s: old version %s, new version %s",
+			rotation.LaunchTemplateID,
+			rotation.OldLaunchTemplateVersion,
+			rotation.NewLaunchTemplateVersion,
+		)
+	}
+	return err
+}
+
+// Command action for `kubergrunt eks nodegroup-versions`
+func listNodeGroupVersions(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+	nodeGroupName, err := entrypoint.StringFlagRequiredE(cliContext, nodeGroupNameFlag.Name)
+	if err != nil {
+		return err
+	}
+
+	nodeSelector, err := nodeSelectorFromFlags(cliContext)
+	if err != nil {
+		return err
+	}
+
+	versions, err := eks.ListNodeGroupVersions(eksClusterArn, nodeGroupName, nodeSelector)
+	if err != nil {
+		return err
+	}
+	if cliContext.Bool(nodeGroupVersionsJSONFlag.Name) {
+		data, err := json.Marshal(versions)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("Node group %s is on version %s of launch template %s\n", versions.NodeGroupName, versions.LaunchTemplateVersion, versions.LaunchTemplateID)
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "NODE\tINSTANCE\tLAUNCH TEMPLATE\tVERSION\tSTATUS")
+	for _, node := range versions.Nodes {
+		status := "CURRENT"
+		if node.Stale {
+			status = "STALE"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", node.NodeName, node.InstanceID, node.LaunchTemplateID, node.LaunchTemplateVersion, status)
+	}
+	return errors.WithStackTrace(writer.Flush())
+}
+
 // Command action for `kubergrunt eks sync-core-components`
 func syncClusterComponents(cliContext *cli.Context) error {
-	eksClusterArn, err := entrypoint.StringFlagRequiredE(cliContext, eksClusterArnFlag.Name)
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
 	if err != nil {
 		return err
 	}
@@ -467,27 +1641,265 @@ func syncClusterComponents(cliContext *cli.Context) error {
 	skipKubeProxy := cliContext.Bool(syncSkipKubeProxyFlag.Name)
 	skipCoreDNS := cliContext.Bool(syncSkipCoreDNSFlag.Name)
 	skipVPCCNI := cliContext.Bool(syncSkipVPCCNIFlag.Name)
-	return eks.SyncClusterComponents(eksClusterArn, shouldWait, waitTimeout, eks.SkipComponentsConfig{KubeProxy: skipKubeProxy, CoreDNS: skipCoreDNS, VPCCNI: skipVPCCNI})
+	skipConfig := eks.SkipComponentsConfig{KubeProxy: skipKubeProxy, CoreDNS: skipCoreDNS, VPCCNI: skipVPCCNI}
+
+	if components := cliContext.StringSlice(syncComponentFlag.Name); len(components) > 0 {
+		if skipKubeProxy || skipCoreDNS || skipVPCCNI {
+			return entrypoint.NewRequiredArgsError(fmt.Sprintf("--%s can not be combined with the --skip-* options.", syncComponentFlag.Name))
+		}
+		skipConfig, err = eks.SkipComponentsConfigForComponents(components)
+		if err != nil {
+			return err
+		}
+	}
+	options := eks.SyncOptions{
+		DryRun: cliContext.Bool(syncDryRunFlag.Name),
+		Apply: eks.ApplyConfig{
+			ServerSide:   cliContext.Bool(syncServerSideFlag.Name),
+			FieldManager: cliContext.String(syncFieldManagerFlag.Name),
+		},
+		AllowIncompatible: cliContext.Bool(syncAllowIncompatibleFlag.Name),
+	}
+
+	if err := waitForClusterActiveIfRequested(cliContext, eksClusterArn); err != nil {
+		return err
+	}
+	return eks.SyncClusterComponents(eksClusterArn, shouldWait, waitTimeout, skipConfig, options)
 }
 
 // Command action for `kubergrunt eks cleanup-security-group`
 func cleanupSecurityGroup(cliContext *cli.Context) error {
-	eksClusterArn, err := entrypoint.StringFlagRequiredE(cliContext, eksClusterArnFlag.Name)
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
 
-	securityGroupID, err := entrypoint.StringFlagRequiredE(cliContext, securityGroupIDFlag.Name)
+	// The security group and VPC IDs are looked up from the cluster when they are omitted.
+	securityGroupID := cliContext.String(securityGroupIDFlag.Name)
+	vpcID := cliContext.String(cleanupVPCIDFlag.Name)
+
+	options := eks.CleanupOptions{
+		MaxRetries:  cliContext.Int(waitMaxRetriesFlag.Name),
+		Concurrency: cliContext.Int(cleanupConcurrencyFlag.Name),
+		DryRun:      cliContext.Bool(cleanupDryRunFlag.Name),
+		Force:       cliContext.Bool(cleanupForceFlag.Name),
+		TagFilters:  tagArgsToMap(cliContext.StringSlice(cleanupTagFilterFlag.Name)),
+
+		SkipLoadBalancerSweep: cliContext.Bool(cleanupSkipALBCleanupFlag.Name),
+		OverallTimeout:        cliContext.Duration(cleanupOverallTimeoutFlag.Name),
+		Region:                cliContext.String(cleanupRegionFlag.Name),
+	}
+	// The sleep-between-retries flag is shared with other commands that have a different default, so we only pass it
+	// through when it is explicitly set to fall back to the cleanup default otherwise.
+	if cliContext.IsSet(waitSleepBetweenRetriesFlag.Name) {
+		options.SleepBetweenRetries = cliContext.Duration(waitSleepBetweenRetriesFlag.Name)
+	}
+
+	ctx, cancel := newInterruptibleContext()
+	defer cancel()
+
+	// On timeout, the partial result is returned along with the error, so that we can report what was cleaned up.
+	result, err := eks.CleanupSecurityGroup(ctx, eksClusterArn, securityGroupID, vpcID, options)
+	if outputFile := cliContext.String(cleanupOutputFileFlag.Name); outputFile != "" {
+		summary := eks.NewCleanupSummary(eksClusterArn, result, err)
+		if writeErr := eks.WriteCleanupSummary(outputFile, summary); writeErr != nil {
+			if err != nil {
+				logging.GetProjectLogger().Errorf("Error writing the cleanup summary to %s: %v", outputFile, writeErr)
+				return err
+			}
+			return writeErr
+		}
+	}
+	if result == nil {
+		return err
+	}
+
+	logger := logging.GetProjectLogger()
+	if result.DryRun {
+		logger.Infof("(Dry run) Security groups that would be deleted: %v", result.DeletedSecurityGroupIDs)
+		logger.Infof("(Dry run) Network interfaces that would be deleted: %v", result.DeletedNetworkInterfaceIDs)
+	} else {
+		logger.Infof("Deleted security groups: %v", result.DeletedSecurityGroupIDs)
+		logger.Infof("Deleted network interfaces: %v", result.DeletedNetworkInterfaceIDs)
+	}
+	logger.Infof("Security groups that were already deleted: %v", result.AlreadyGoneSecurityGroupIDs)
+	return err
+}
+
+// Command action for `kubergrunt eks cleanup-load-balancers`
+func cleanupLoadBalancers(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
 
+	result, err := eks.CleanupLoadBalancers(eksClusterArn)
+	if err != nil {
+		return err
+	}
+
+	logger := logging.GetProjectLogger()
+	logger.Infof("Deleted load balancers: %v", result.DeletedLoadBalancerArns)
+	logger.Infof("Deleted Classic Load Balancers: %v", result.DeletedClassicLoadBalancerNames)
+	logger.Infof("Load balancers that were already deleted: %v", result.AlreadyGoneLoadBalancers)
+	logger.Infof("Deleted orphaned target groups: %v", result.DeletedTargetGroupArns)
+	logger.Infof("Orphaned target groups that were already deleted: %v", result.AlreadyGoneTargetGroupArns)
+	return nil
+}
+
+// Command action for `kubergrunt eks cleanup-persistent-volumes`
+func cleanupPersistentVolumes(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	dryRun := cliContext.Bool(cleanupDryRunFlag.Name)
+
+	result, err := eks.CleanupPersistentVolumes(eksClusterArn, dryRun)
+	if err != nil {
+		return err
+	}
+
+	logger := logging.GetProjectLogger()
+	if result.DryRun {
+		logger.Infof("(Dry run) EBS volumes that would be deleted: %v", result.DeletedVolumeIDs)
+	} else {
+		logger.Infof("Deleted EBS volumes: %v", result.DeletedVolumeIDs)
+	}
+	logger.Infof("EBS volumes that were already deleted: %v", result.AlreadyGoneVolumeIDs)
+	logger.Infof("EBS volumes that were skipped because they are in use: %v", result.SkippedInUseVolumeIDs)
+	return nil
+}
+
+// Command action for `kubergrunt eks cleanup-fargate-enis`
+func cleanupFargateENIs(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	vpcID, err := entrypoint.StringFlagRequiredE(cliContext, vpcIDFlag.Name)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	result, err := eks.CleanupFargateENIs(eksClusterArn, vpcID)
+	if err != nil {
+		return err
+	}
+
+	logger := logging.GetProjectLogger()
+	logger.Infof("Deleted network interfaces: %v", result.DeletedNetworkInterfaceIDs)
+	logger.Infof("Network interfaces that were skipped because they are in use by running Pods: %v", result.SkippedInUseNetworkInterfaceIDs)
+	return nil
+}
+
+// Command action for `kubergrunt eks describe-cleanup-targets`
+func describeCleanupTargets(cliContext *cli.Context) error {
+	region, err := entrypoint.StringFlagRequiredE(cliContext, clusterRegionFlag.Name)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	vpcID, err := entrypoint.StringFlagRequiredE(cliContext, vpcIDFlag.Name)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	output := cliContext.String(cleanupTargetsOutputFlag.Name)
+	if !collections.ListContainsElement(cleanupTargetsOutputFormats, output) {
+		return errors.WithStackTrace(InvalidOutputFormatErr{flagName: cleanupTargetsOutputFlag.Name, value: output, formats: cleanupTargetsOutputFormats})
+	}
+
+	targets, err := eks.DescribeCleanupTargets(region, vpcID)
+	if err != nil {
+		return err
+	}
+	switch output {
+	case cleanupTargetsJSONOutput:
+		data, err := json.Marshal(targets)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		fmt.Println(string(data))
+	case cleanupTargetsYAMLOutput:
+		data, err := yaml.Marshal(targets)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		fmt.Print(string(data))
+	default:
+		return writeCleanupTargetsTable(os.Stdout, targets)
+	}
+	return nil
+}
+
+// writeCleanupTargetsTable writes the cleanup targets as a table, with a row per resource. The network interfaces are
+// listed after the security group that they use.
+func writeCleanupTargetsTable(out io.Writer, targets []eks.CleanupTarget) error {
+	writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "CLUSTER\tRESOURCE\tID\tDETAILS")
+	for _, target := range targets {
+		for _, securityGroup := range target.SecurityGroups {
+			fmt.Fprintf(writer, "%s\tsecurity-group\t%s\t%s\n", target.ClusterName, securityGroup.ID, securityGroup.Name)
+			for _, eniID := range securityGroup.NetworkInterfaceIDs {
+				fmt.Fprintf(writer, "%s\tnetwork-interface\t%s\tuses %s\n", target.ClusterName, eniID, securityGroup.ID)
+			}
+		}
+		for _, lbArn := range target.LoadBalancerArns {
+			fmt.Fprintf(writer, "%s\tload-balancer\t%s\t\n", target.ClusterName, lbArn)
+		}
+		for _, lbName := range target.ClassicLoadBalancerNames {
+			fmt.Fprintf(writer, "%s\tclassic-load-balancer\t%s\t\n", target.ClusterName, lbName)
+		}
+	}
+	return errors.WithStackTrace(writer.Flush())
+}
+
+// Command action for `kubergrunt eks verify-cleanup`
+func verifyCleanup(cliContext *cli.Context) error {
+	region, err := entrypoint.StringFlagRequiredE(cliContext, clusterRegionFlag.Name)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
 	vpcID, err := entrypoint.StringFlagRequiredE(cliContext, vpcIDFlag.Name)
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
+	clusterName, err := entrypoint.StringFlagRequiredE(cliContext, clusterNameFlag.Name)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
 
-	return eks.CleanupSecurityGroup(eksClusterArn, securityGroupID, vpcID)
+	leftovers, err := eks.VerifyCleanup(region, vpcID, clusterName)
+	if leftovers != nil {
+		data, marshalErr := json.Marshal(leftovers)
+		if marshalErr != nil {
+			return errors.WithStackTrace(marshalErr)
+		}
+		fmt.Println(string(data))
+	}
+	return err
+}
+
+// Command action for `kubergrunt eks tag-cluster-resources`
+func tagClusterResources(cliContext *cli.Context) error {
+	eksClusterArn, err := eksClusterArnFromFlags(cliContext)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	tagArgs := cliContext.StringSlice(tagClusterResourcesTagFlag.Name)
+	if len(tagArgs) == 0 {
+		return entrypoint.NewRequiredArgsError("At least one --tag is required")
+	}
+
+	result, err := eks.TagClusterResources(eksClusterArn, tagArgsToMap(tagArgs))
+	if err != nil {
+		return err
+	}
+
+	logger := logging.GetProjectLogger()
+	logger.Infof("Tagged resources: %d security groups, %d network interfaces, %d load balancers, %d Classic Load Balancers, %d EBS volumes", result.Tagged.SecurityGroups, result.Tagged.NetworkInterfaces, result.Tagged.LoadBalancers, result.Tagged.ClassicLoadBalancers, result.Tagged.Volumes)
+	logger.Infof("Resources that were already tagged: %d security groups, %d network interfaces, %d load balancers, %d Classic Load Balancers, %d EBS volumes", result.AlreadyTagged.SecurityGroups, result.AlreadyTagged.NetworkInterfaces, result.AlreadyTagged.LoadBalancers, result.AlreadyTagged.ClassicLoadBalancers, result.AlreadyTagged.Volumes)
+	return nil
 }
 
 // Command action for `kubergrunt eks schedule-coredns ec2`
@@ -512,6 +1924,10 @@ func scheduleCorednsEc2(cliContext *cli.Context) error {
 
 // Command action for `kubergrunt eks schedule-coredns fargate`
 func scheduleCorednsFargate(cliContext *cli.Context) error {
+	if eksClusterArn := cliContext.String(corednsClusterArnFlag.Name); eksClusterArn != "" {
+		return eks.ScheduleCoreDNSForFargate(eksClusterArn)
+	}
+
 	kubectlOptions, err := parseKubectlOptions(cliContext)
 	if err != nil {
 		return err
@@ -529,3 +1945,20 @@ func scheduleCorednsFargate(cliContext *cli.Context) error {
 
 	return eks.ScheduleCoredns(kubectlOptions, eksClusterName, fargateProfileArn, "fargate")
 }
+
+// eksClusterArnFromFlags returns the ARN of the EKS cluster to operate on, which is either passed in directly with
+// --eks-cluster-arn, or looked up from the kubectl config context passed in with --context.
+func eksClusterArnFromFlags(cliContext *cli.Context) (string, error) {
+	eksClusterArn := cliContext.String(eksClusterArnOrContextFlag.Name)
+	contextName := cliContext.String(eksContextFlag.Name)
+	if eksClusterArn != "" && contextName != "" {
+		return "", MutuallyExclusiveFlagError{Message: fmt.Sprintf("Only one of --%s or --%s can be set", eksClusterArnOrContextFlag.Name, eksContextFlag.Name)}
+	}
+	if eksClusterArn != "" {
+		return eksClusterArn, nil
+	}
+	if contextName == "" {
+		return "", entrypoint.NewRequiredArgsError(fmt.Sprintf("Either --%s or --%s is required", eksClusterArnOrContextFlag.Name, eksContextFlag.Name))
+	}
+	return kubectl.GetEKSClusterArnFromContext(cliContext.String(genericKubeconfigFlag.Name), contextName)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/kubergrunt/eks"
+)
+
+func TestWriteCleanupTargetsTable(t *testing.T) {
+	t.Parallel()
+
+	targets := []eks.CleanupTarget{{
+		ClusterName: "prod",
+		SecurityGroups: []eks.CleanupTargetSecurityGroup{
+			{ID: "sg-0123", Name: "eks-cluster-sg-prod", NetworkInterfaceIDs: []string{"eni-0123"}},
+		},
+		LoadBalancerArns:         []string{},
+		ClassicLoadBalancerNames: []string{"a1b2c3"},
+	}}
+
+	var out bytes.Buffer
+	require.NoError(t, writeCleanupTargetsTable(&out, targets))
+	expected := "CLUSTER  RESOURCE               ID        DETAILS\n" +
+		"prod     security-group         sg-0123   eks-cluster-sg-prod\n" +
+		"prod     network-interface      eni-0123  uses sg-0123\n" +
+		"prod     classic-load-balancer  a1b2c3    \n"
+	assert.Equal(t, expected, out.String())
+}
+
+func TestWriteOIDCDescriptionTable(t *testing.T) {
+	t.Parallel()
+
+	description := &eks.OIDCDescription{
+		ClusterArn:  "arn:aws:eks:us-east-1:123456789012:cluster/prod",
+		IssuerURL:   "https://oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE",
+		ProviderArn: "arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE",
+		Thumbprints: []string{},
+		ClientIDs:   []string{},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeOIDCDescriptionTable(&out, description))
+	expected := "CLUSTER          arn:aws:eks:us-east-1:123456789012:cluster/prod\n" +
+		"ISSUER URL       https://oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE\n" +
+		"PROVIDER ARN     arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE\n" +
+		"PROVIDER EXISTS  false\n" +
+		"THUMBPRINTS      <none>\n" +
+		"CLIENT IDS       <none>\n"
+	assert.Equal(t, expected, out.String())
+}
@@ -1,6 +1,9 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // MutualExclusiveFlagError is returned when there is a violation of a mutually exclusive flag set.
 type MutuallyExclusiveFlagError struct {
@@ -19,3 +22,24 @@ type ExactlyOneASGErr struct {
 func (err ExactlyOneASGErr) Error() string {
 	return fmt.Sprintf("You must provide exactly one ASG using %s to this command.", err.flagName)
 }
+
+// InvalidOutputFormatErr is returned if the output format passed to a command is not one of the formats it supports.
+type InvalidOutputFormatErr struct {
+	flagName string
+	value    string
+	formats  []string
+}
+
+func (err InvalidOutputFormatErr) Error() string {
+	return fmt.Sprintf("%s is not a valid output format for --%s. Must be one of: %s.", err.value, err.flagName, strings.Join(err.formats, ", "))
+}
+
+// InvalidIPAddressErr is returned if a flag value that is expected to be an IP address can not be parsed as one.
+type InvalidIPAddressErr struct {
+	flagName string
+	value    string
+}
+
+func (err InvalidIPAddressErr) Error() string {
+	return fmt.Sprintf("%s is not a valid IP address for --%s.", err.value, err.flagName)
+}
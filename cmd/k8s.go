@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/gruntwork-io/go-commons/entrypoint"
@@ -18,6 +19,31 @@ var (
 		Name:  "namespace",
 		Usage: "(Required) The namespace where the Ingress resource to wait for is deployed to.",
 	}
+	ingressProbeFlag = cli.StringFlag{
+		Name:  "probe",
+		Usage: "After the endpoint is provisioned, also wait until it passes a health probe with this protocol. One of tcp, http, or https.",
+	}
+	ingressProbePortFlag = cli.IntFlag{
+		Name:  "probe-port",
+		Usage: "The port to probe the endpoint on. Defaults to 443 for https, and 80 otherwise.",
+	}
+	ingressProbePathFlag = cli.StringFlag{
+		Name:  "probe-path",
+		Value: "/",
+		Usage: "The path to request when probing the endpoint over http or https.",
+	}
+	ingressProbeStatusFlag = cli.IntFlag{
+		Name:  "probe-status",
+		Usage: "The status code that the endpoint must respond with when probing over http or https (e.g., 200). Defaults to accepting any status code below 500.",
+	}
+	ingressProbeFollowRedirectsFlag = cli.BoolFlag{
+		Name:  "probe-follow-redirects",
+		Usage: "When probing over http or https, follow redirects and check the status of the final response, instead of the redirect response.",
+	}
+	ingressProbeInsecureFlag = cli.BoolFlag{
+		Name:  "insecure",
+		Usage: "When probing over https, skip the verification of the TLS certificate of the endpoint, e.g., for self signed certificates.",
+	}
 
 	maxRetriesFlag = cli.IntFlag{
 		Name:  "max-retries",
@@ -43,11 +69,19 @@ func SetupK8SCommand() cli.Command {
 				Usage: "Wait for the Ingress endpoint to be provisioned.",
 				Description: `Waits for the Ingress endpoint to be provisioned. This will monitor the Ingress resource, continuously checking until the endpoint is allocated to the Ingress resource or times out. By default, this will try for 5 minutes (max retries 60 and time betweeen sleep of 5 seconds).
 
-You can configure the timeout settings using the --max-retries and --sleep-between-retries CLI args. This will check for --max-retries times, sleeping for --sleep-between-retries inbetween tries.`,
+You can configure the timeout settings using the --max-retries and --sleep-between-retries CLI args. This will check for --max-retries times, sleeping for --sleep-between-retries inbetween tries.
+
+Pass in --probe to additionally wait until the endpoint is reachable over TCP, HTTP, or HTTPS. In that case, the command waits up to --max-retries times --sleep-between-retries for the endpoint to pass the probe, and prints the endpoint to stdout.`,
 				Action: waitForIngressEndpoint,
 				Flags: []cli.Flag{
 					ingressNameFlag,
 					namespaceFlag,
+					ingressProbeFlag,
+					ingressProbePortFlag,
+					ingressProbePathFlag,
+					ingressProbeStatusFlag,
+					ingressProbeFollowRedirectsFlag,
+					ingressProbeInsecureFlag,
 
 					maxRetriesFlag,
 					sleepBetweenRetriesFlag,
@@ -110,6 +144,26 @@ func waitForIngressEndpoint(cliContext *cli.Context) error {
 	maxRetries := cliContext.Int(maxRetriesFlag.Name)
 	sleepBetweenRetries := cliContext.Duration(sleepBetweenRetriesFlag.Name)
 
+	probeProtocol := cliContext.String(ingressProbeFlag.Name)
+	if probeProtocol != "" {
+		probe := &kubectl.IngressProbe{
+			Protocol: kubectl.IngressProbeProtocol(probeProtocol),
+			Port:     cliContext.Int(ingressProbePortFlag.Name),
+			Path:     cliContext.String(ingressProbePathFlag.Name),
+
+			ExpectedStatus:  cliContext.Int(ingressProbeStatusFlag.Name),
+			FollowRedirects: cliContext.Bool(ingressProbeFollowRedirectsFlag.Name),
+			Insecure:        cliContext.Bool(ingressProbeInsecureFlag.Name),
+		}
+		timeout := time.Duration(maxRetries) * sleepBetweenRetries
+		endpoint, err := kubectl.WaitForIngressEndpointWithProbe(kubectlOptions, namespace, ingressName, timeout, probe)
+		if err != nil {
+			return err
+		}
+		fmt.Println(endpoint)
+		return nil
+	}
+
 	// Now call waiting logic for the ingress endpoint
 	return kubectl.WaitUntilIngressEndpointProvisioned(kubectlOptions, namespace, ingressName, maxRetries, sleepBetweenRetries)
 }
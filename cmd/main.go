@@ -3,9 +3,14 @@ package main
 import (
 	"github.com/gruntwork-io/go-commons/entrypoint"
 	"github.com/gruntwork-io/go-commons/errors"
-	"github.com/gruntwork-io/go-commons/logging"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+	"github.com/gruntwork-io/kubergrunt/metrics"
 )
 
 // This variable is set at build time using -ldflags parameters. For example, we typically set this flag in circle.yml
@@ -18,21 +23,133 @@ var VERSION string
 
 var (
 	logLevelFlag = cli.StringFlag{
-		Name:  "loglevel",
-		Value: logrus.InfoLevel.String(),
+		Name:   "log-level, loglevel",
+		Value:  logrus.InfoLevel.String(),
+		Usage:  "The minimum level of the log messages. Must be one of debug, info, warn, or error.",
+		EnvVar: logging.LogLevelEnvVar,
+	}
+	logFormatFlag = cli.StringFlag{
+		Name:  "log-format",
+		Value: logging.TextLogFormat,
+		Usage: "The format of the log messages. Must be one of text or json. With json, each message is logged as a JSON object with the level, message, time and structured fields as keys.",
+	}
+	quietFlag = cli.BoolFlag{
+		Name:  "quiet",
+		Usage: "Only log the completion of each phase and the errors of long running operations, without the progress of the individual resources and retries.",
+	}
+	profileFlag = cli.StringFlag{
+		Name:  "profile",
+		Usage: "The name of the AWS shared config profile to use for all AWS API calls. When omitted, the default credentials chain is used.",
+	}
+	assumeRoleFlag = cli.StringFlag{
+		Name:  "assume-role",
+		Usage: "The ARN of an IAM role to assume for all AWS API calls. When omitted, the credentials from the environment are used directly.",
+	}
+	assumeRoleExternalIDFlag = cli.StringFlag{
+		Name:  "assume-role-external-id",
+		Usage: "The external ID to use when assuming the IAM role provided with --assume-role.",
+	}
+	assumeRoleSessionNameFlag = cli.StringFlag{
+		Name:  "assume-role-session-name",
+		Usage: "The session name to use when assuming the IAM role provided with --assume-role. Defaults to a generated name.",
+	}
+	caBundleFlag = cli.StringFlag{
+		Name:   "ca-bundle",
+		Usage:  "Path to a PEM encoded bundle of CA certificates to trust, in addition to the system root CAs, for all AWS and Kubernetes API calls (e.g., the CA of a TLS intercepting proxy). Can also be set with the AWS_CA_BUNDLE environment variable.",
+		EnvVar: "AWS_CA_BUNDLE",
+	}
+	httpTimeoutFlag = cli.DurationFlag{
+		Name:  "http-timeout",
+		Usage: "The maximum amount of time that each HTTP request to the AWS and Kubernetes APIs can take, including reading the response, expressed as a duration (e.g., 1m = 1 minute). Zero means no timeout, which is the default.",
+	}
+	httpKeepAliveFlag = cli.DurationFlag{
+		Name:  "http-keepalive",
+		Value: eksawshelper.DefaultHTTPKeepAlive,
+		Usage: "The interval between the TCP keepalive probes of the connections to the AWS and Kubernetes APIs, expressed as a duration (e.g., 15s = 15 seconds). A negative value disables the probes.",
+	}
+	httpIdleConnTimeoutFlag = cli.DurationFlag{
+		Name:  "http-idle-conn-timeout",
+		Value: eksawshelper.DefaultHTTPIdleConnTimeout,
+		Usage: "How long an idle connection to the AWS and Kubernetes APIs is kept open for reuse before it is closed, expressed as a duration (e.g., 30s = 30 seconds). Zero means no limit.",
+	}
+	endpointOverrideFlag = cli.StringFlag{
+		Name:  "endpoint-override",
+		Usage: "The https URL of the Kubernetes API server to connect to instead of the endpoint of the EKS cluster, for private clusters that are only reachable through a VPN, a bastion host or a custom DNS name (e.g., https://localhost:8443). The certificate of the API server is still verified against the CA of the cluster. The bearer token is sent to this endpoint, so only use an endpoint you control.",
+	}
+	tlsServerNameFlag = cli.StringFlag{
+		Name:  "tls-server-name",
+		Usage: "The server name to verify the certificate of the Kubernetes API server against, which must be one of the SANs of the certificate. Defaults to the host of the endpoint of the EKS cluster when --endpoint-override is set.",
+	}
+	ec2MaxAttemptsFlag = cli.IntFlag{
+		Name:  "ec2-max-attempts",
+		Value: eksawshelper.DefaultEC2MaxAttempts,
+		Usage: "The number of times each EC2 API call is attempted before giving up on throttling and other transient errors.",
+	}
+	clusterNotFoundRetryTimeoutFlag = cli.DurationFlag{
+		Name:  "cluster-not-found-retry-timeout",
+		Value: eksawshelper.DefaultClusterNotFoundRetryTimeout,
+		Usage: "The maximum amount of time to retry looking up an EKS cluster that is reported as not found, which happens for a short while after the cluster is created, expressed as a duration (e.g., 10s = 10 seconds). Zero disables the retries.",
+	}
+	metricsAddrFlag = cli.StringFlag{
+		Name:  "metrics-addr",
+		Usage: "The address (e.g., :9090) of an HTTP server to start for the duration of the command, which exposes Prometheus metrics on the progress of the long running operations at /metrics. When omitted, no server is started.",
+	}
+	metadataKeyPrefixFlag = cli.StringFlag{
+		Name:  "metadata-key-prefix",
+		Value: kubectl.DefaultMetadataKeyPrefix,
+		Usage: "The prefix of the annotations that record the kubergrunt version and modification time on the Kubernetes resources that kubergrunt creates or modifies. Must be a DNS subdomain.",
 	}
 )
 
 // initCli initializes the CLI app before any command is actually executed. This function will handle all the setup
 // code, such as setting up the logger with the appropriate log level.
 func initCli(cliContext *cli.Context) error {
-	// Set logging level
-	logLevel := cliContext.String(logLevelFlag.Name)
-	level, err := logrus.ParseLevel(logLevel)
-	if err != nil {
+	// Set logging level and format
+	if err := logging.SetGlobalLogLevel(cliContext.String("log-level")); err != nil {
 		return errors.WithStackTrace(err)
 	}
-	logging.SetGlobalLogLevel(level)
+	if err := logging.SetGlobalLogFormat(cliContext.String(logFormatFlag.Name)); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	logging.SetGlobalQuiet(cliContext.Bool(quietFlag.Name))
+
+	// Configure the AWS profile and the IAM role to assume for all AWS operations
+	eksawshelper.SetProfile(cliContext.String(profileFlag.Name))
+	if roleArn := cliContext.String(assumeRoleFlag.Name); roleArn != "" {
+		eksawshelper.SetAssumeRoleConfig(&eksawshelper.AssumeRoleConfig{
+			RoleArn:     roleArn,
+			ExternalID:  cliContext.String(assumeRoleExternalIDFlag.Name),
+			SessionName: cliContext.String(assumeRoleSessionNameFlag.Name),
+		})
+	}
+	eksawshelper.SetEC2MaxAttempts(cliContext.Int(ec2MaxAttemptsFlag.Name))
+	eksawshelper.SetClusterNotFoundRetryTimeout(cliContext.Duration(clusterNotFoundRetryTimeoutFlag.Name))
+	eksawshelper.SetCABundle(cliContext.String(caBundleFlag.Name))
+	eksawshelper.SetHTTPConfig(eksawshelper.HTTPConfig{
+		Timeout:         cliContext.Duration(httpTimeoutFlag.Name),
+		KeepAlive:       cliContext.Duration(httpKeepAliveFlag.Name),
+		IdleConnTimeout: cliContext.Duration(httpIdleConnTimeoutFlag.Name),
+	})
+	err := kubehelper.SetEndpointOverride(kubehelper.EndpointOverride{
+		Endpoint:      cliContext.String(endpointOverrideFlag.Name),
+		TLSServerName: cliContext.String(tlsServerNameFlag.Name),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Expose the metrics on the progress of the command
+	if addr := cliContext.String(metricsAddrFlag.Name); addr != "" {
+		if err := metrics.Serve(addr); err != nil {
+			return err
+		}
+	}
+
+	// Configure the metadata recorded on the Kubernetes resources that kubergrunt creates or modifies
+	kubectl.SetKubergruntVersion(VERSION)
+	if err := kubectl.SetMetadataKeyPrefix(cliContext.String(metadataKeyPrefixFlag.Name)); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -57,6 +174,22 @@ func main() {
 
 	app.Flags = []cli.Flag{
 		logLevelFlag,
+		logFormatFlag,
+		quietFlag,
+		profileFlag,
+		assumeRoleFlag,
+		assumeRoleExternalIDFlag,
+		assumeRoleSessionNameFlag,
+		caBundleFlag,
+		httpTimeoutFlag,
+		httpKeepAliveFlag,
+		httpIdleConnTimeoutFlag,
+		endpointOverrideFlag,
+		tlsServerNameFlag,
+		ec2MaxAttemptsFlag,
+		clusterNotFoundRetryTimeoutFlag,
+		metricsAddrFlag,
+		metadataKeyPrefixFlag,
 	}
 	app.Commands = []cli.Command{
 		SetupEksCommand(),
@@ -2,10 +2,13 @@ package main
 
 import (
 	"crypto/x509/pkix"
+	"fmt"
+	"net"
 	"strings"
 	"time"
 
 	"github.com/gruntwork-io/go-commons/entrypoint"
+	"github.com/gruntwork-io/go-commons/errors"
 	"github.com/urfave/cli"
 
 	"github.com/gruntwork-io/kubergrunt/tls"
@@ -53,7 +56,52 @@ var (
 	// Flags for adding Subject Alternitive Names
 	tlsDNSNamesFlag = cli.StringSliceFlag{
 		Name:  "tls-dns-name",
-		Usage: "The subject alternitive name to add to the certificate. Pass in multiple times for multiple DNS names.",
+		Usage: "The subject alternitive name to add to the certificate. Pass in multiple times for multiple DNS names. Defaults to --tls-common-name when neither this nor --tls-ip-address is passed in.",
+	}
+	tlsIPAddressesFlag = cli.StringSliceFlag{
+		Name:  "tls-ip-address",
+		Usage: "An IP address to add to the certificate as a subject alternative name, e.g. the cluster IP of a Service. Pass in multiple times for multiple IP addresses.",
+	}
+
+	tlsForceFlag = cli.BoolFlag{
+		Name:  "force",
+		Usage: "When passed in, regenerate the certificates even if the Secret already holds a certificate that is not near expiry.",
+	}
+
+	// Mutual TLS flags
+	mtlsServerSecretNameFlag = cli.StringFlag{
+		Name:  "server-secret-name",
+		Usage: "Name to use for the Kubernetes Secret resource that will store the server certificate key pair. Required unless --output-dir is passed in.",
+	}
+	mtlsClientSecretNameFlag = cli.StringFlag{
+		Name:  "client-secret-name",
+		Usage: "Name to use for the Kubernetes Secret resource that will store the client certificate key pair. Required unless --output-dir is passed in.",
+	}
+	mtlsCASecretNameFlag = cli.StringFlag{
+		Name:  "ca-secret-name",
+		Usage: "The name of the Kubernetes Secret resource that holds the CA key pair used to sign the server and client certificates. When --ca is passed in, this is where the generated CA key pair is stored. Required unless both --ca and --output-dir are passed in.",
+	}
+	mtlsGenCAFlag = cli.BoolFlag{
+		Name:  "ca",
+		Usage: "When passed in, generate a new CA key pair to sign the server and client certificates, instead of loading it from --ca-secret-name.",
+	}
+	mtlsOutputDirFlag = cli.StringFlag{
+		Name:  "output-dir",
+		Usage: "When passed in, write the certificate key pairs as PEM files to this directory instead of storing them as Kubernetes Secrets.",
+	}
+
+	tlsDeleteForceFlag = cli.BoolFlag{
+		Name:  "force",
+		Usage: "When passed in, delete the Secret even if it is not labeled as managed by kubergrunt.",
+	}
+	tlsUntrackFromCAFlag = cli.BoolFlag{
+		Name:  "untrack-from-ca",
+		Usage: "When passed in, also remove the Secret from the issued certificates recorded on the CA that signed it, so that rotate-ca no longer tries to reissue it.",
+	}
+
+	tlsDropPreviousCAFlag = cli.BoolFlag{
+		Name:  "drop-previous-ca",
+		Usage: "When passed in, remove the previous CA certificate from the trust bundles of the issued certificates instead of rotating the CA.",
 	}
 
 	// Configurations for setting up the TLS certificates.
@@ -86,6 +134,14 @@ var (
 		Name:  "client-tls-country",
 		Usage: "The country where --client-tls-org is located.",
 	}
+	clientTLSDNSNamesFlag = cli.StringSliceFlag{
+		Name:  "client-tls-dns-name",
+		Usage: "The subject alternative name to add to the client certificate. Pass in multiple times for multiple DNS names. Defaults to --client-tls-common-name when neither this nor --client-tls-ip-address is passed in.",
+	}
+	clientTLSIPAddressesFlag = cli.StringSliceFlag{
+		Name:  "client-tls-ip-address",
+		Usage: "An IP address to add to the client certificate as a subject alternative name. Pass in multiple times for multiple IP addresses.",
+	}
 	clientTLSSubjectInfoFlags = TLSFlags{
 		SubjectInfoJsonFlagName: clientTLSSubjectJsonFlag.Name,
 		CommonNameFlagName:      clientTLSCommonNameFlag.Name,
@@ -138,6 +194,153 @@ Pass in a --ca-secret-name to sign the newly generated TLS key pair using the CA
 					tlsECDSACurveFlag,
 					tlsRSABitsFlag,
 					tlsDNSNamesFlag,
+					tlsIPAddressesFlag,
+
+					// Kubernetes auth flags
+					genericKubectlContextNameFlag,
+					genericKubeconfigFlag,
+					genericKubectlServerFlag,
+					genericKubectlCAFlag,
+					genericKubectlTokenFlag,
+					genericKubectlEKSClusterArnFlag,
+				},
+			},
+			cli.Command{
+				Name:  "gen-tls-secret",
+				Usage: "Generate a CA and a TLS certificate key pair signed by it, and store them as a kubernetes.io/tls Secret.",
+				Description: `Generate a new CA certificate key pair and a TLS certificate key pair signed by it, and store them in a Kubernetes Secret of type kubernetes.io/tls. The TLS certificate is stored in tls.crt, its private key in tls.key, and the CA certificate in ca.crt. The private key of the CA is not stored.
+
+If the Secret already exists and holds a certificate that is not near expiry, this does nothing unless --force is passed in. The certificate is near expiry when it expires within a third of --tls-validity, or 30 days, whichever is shorter.`,
+				Action: generateTLSSecretEntrypoint,
+				Flags: []cli.Flag{
+					// Secret config flags
+					tlsStoreNamespaceFlag,
+					tlsSecretNameFlag,
+					tlsForceFlag,
+
+					// TLS config flags
+					tlsSubjectJsonFlag,
+					tlsCommonNameFlag,
+					tlsOrgFlag,
+					tlsOrgUnitFlag,
+					tlsCityFlag,
+					tlsStateFlag,
+					tlsCountryFlag,
+					tlsValidityFlag,
+					tlsAlgorithmFlag,
+					tlsECDSACurveFlag,
+					tlsRSABitsFlag,
+					tlsDNSNamesFlag,
+					tlsIPAddressesFlag,
+
+					// Kubernetes auth flags
+					genericKubectlContextNameFlag,
+					genericKubeconfigFlag,
+					genericKubectlServerFlag,
+					genericKubectlCAFlag,
+					genericKubectlTokenFlag,
+					genericKubectlEKSClusterArnFlag,
+				},
+			},
+			cli.Command{
+				Name:  "gen-mtls",
+				Usage: "Generate a server and a client certificate key pair signed by the same CA, for mutual TLS.",
+				Description: `Generate a server certificate key pair and a client certificate key pair signed by the same CA, for mutual TLS between two services. The server certificate can only be used to authenticate TLS servers, and the client certificate can only be used to authenticate TLS clients. The subject and SANs of the server certificate are configured with the --tls-* flags, and those of the client certificate with the --client-tls-* flags.
+
+Pass in --ca to generate a new CA key pair, which is stored in the Secret --ca-secret-name. Otherwise, the CA key pair is loaded from the Secret --ca-secret-name, which must have been generated with gen --ca.
+
+The server and client certificate key pairs are stored in the Secrets --server-secret-name and --client-secret-name, along with the CA certificate in ca.crt. Pass in --output-dir to write them as PEM files to a directory instead: ca.crt, server.crt, server.pem, server.pub, client.crt, client.pem and client.pub. The private key of the CA is only written to the directory (ca.pem) when it is generated.`,
+				Action: generateMutualTLSEntrypoint,
+				Flags: []cli.Flag{
+					// Secret config flags
+					tlsStoreNamespaceFlag,
+					mtlsServerSecretNameFlag,
+					mtlsClientSecretNameFlag,
+					tlsSecretLabelsFlag,
+					tlsSecretAnnotationsFlag,
+					mtlsOutputDirFlag,
+
+					// CA config flags
+					mtlsGenCAFlag,
+					mtlsCASecretNameFlag,
+					tlsCANamespaceFlag,
+
+					// Server TLS config flags
+					tlsSubjectJsonFlag,
+					tlsCommonNameFlag,
+					tlsOrgFlag,
+					tlsOrgUnitFlag,
+					tlsCityFlag,
+					tlsStateFlag,
+					tlsCountryFlag,
+					tlsDNSNamesFlag,
+					tlsIPAddressesFlag,
+
+					// Client TLS config flags
+					clientTLSSubjectJsonFlag,
+					clientTLSCommonNameFlag,
+					clientTLSOrgFlag,
+					clientTLSOrgUnitFlag,
+					clientTLSCityFlag,
+					clientTLSStateFlag,
+					clientTLSCountryFlag,
+					clientTLSDNSNamesFlag,
+					clientTLSIPAddressesFlag,
+
+					// Common TLS config flags
+					tlsValidityFlag,
+					tlsAlgorithmFlag,
+					tlsECDSACurveFlag,
+					tlsRSABitsFlag,
+
+					// Kubernetes auth flags
+					genericKubectlContextNameFlag,
+					genericKubeconfigFlag,
+					genericKubectlServerFlag,
+					genericKubectlCAFlag,
+					genericKubectlTokenFlag,
+					genericKubectlEKSClusterArnFlag,
+				},
+			},
+			cli.Command{
+				Name:  "rotate-ca",
+				Usage: "Rotate a CA key pair generated with gen --ca, and reissue the certificates it issued.",
+				Description: `Rotate the CA certificate key pair stored in the Secret --secret-name, which must have been generated with gen --ca. This generates a new CA key pair with the same subject, key type, and validity, and reissues all the TLS certificates that were issued by the CA with gen, keeping their private keys. The ca.crt trust bundle in the Secrets of the reissued certificates holds both the new and the previous CA certificate, so that clients keep trusting certificates signed by either CA.
+
+Once all clients have picked up the new trust bundle, run this again with --drop-previous-ca to remove the previous CA certificate from the trust bundles.
+
+If reissuing some of the certificates fails, run this again without --drop-previous-ca to resume the rotation: the certificates that are not signed by the new CA yet are reissued with it, without generating another CA. Once all the certificates are signed by the new CA, the CA can not be rotated again until the previous CA is dropped.
+
+The names of the updated Secrets are printed to stdout. Certificates whose Secret no longer exists are reported as warnings.`,
+				Action: rotateCAEntrypoint,
+				Flags: []cli.Flag{
+					tlsStoreNamespaceFlag,
+					tlsSecretNameFlag,
+					tlsDropPreviousCAFlag,
+
+					// Kubernetes auth flags
+					genericKubectlContextNameFlag,
+					genericKubeconfigFlag,
+					genericKubectlServerFlag,
+					genericKubectlCAFlag,
+					genericKubectlTokenFlag,
+					genericKubectlEKSClusterArnFlag,
+				},
+			},
+			cli.Command{
+				Name:  "delete-secret",
+				Usage: "Delete a Kubernetes Secret created by the tls commands.",
+				Description: `Delete the Kubernetes Secret --secret-name, which must have been created by gen, gen-mtls, or gen-tls-secret. Secrets that are not labeled with app.kubernetes.io/managed-by=kubergrunt are refused unless --force is passed in. A Secret that does not exist is not an error.
+
+When --untrack-from-ca is passed in, the Secret is also removed from the issued certificates recorded on the CA Secret that signed it, so that rotate-ca no longer tries to reissue it.
+
+What was deleted is printed to stdout.`,
+				Action: deleteTLSSecretEntrypoint,
+				Flags: []cli.Flag{
+					tlsStoreNamespaceFlag,
+					tlsSecretNameFlag,
+					tlsDeleteForceFlag,
+					tlsUntrackFromCAFlag,
 
 					// Kubernetes auth flags
 					genericKubectlContextNameFlag,
@@ -199,7 +402,6 @@ func generateTLSCertEntrypoint(cliContext *cli.Context) error {
 	} else if tlsSecretFileNameBase == "" && !genCA {
 		tlsSecretFileNameBase = "tls"
 	}
-	dnsNames := cliContext.StringSlice(tlsDNSNamesFlag.Name)
 
 	// Convert flags to structs
 	tlsSecretOptions := tls.KubernetesSecretOptions{
@@ -222,10 +424,176 @@ func generateTLSCertEntrypoint(cliContext *cli.Context) error {
 		genCA,
 		tlsSecretFileNameBase,
 		tlsOptions,
-		dnsNames,
 	)
 }
 
+// generateTLSSecretEntrypoint will parse the CLI args and then call GenerateAndStoreTLSSecret.
+func generateTLSSecretEntrypoint(cliContext *cli.Context) error {
+	// Extract required args
+	tlsSecretNamespace, err := entrypoint.StringFlagRequiredE(cliContext, tlsStoreNamespaceFlag.Name)
+	if err != nil {
+		return err
+	}
+	tlsSecretName, err := entrypoint.StringFlagRequiredE(cliContext, tlsSecretNameFlag.Name)
+	if err != nil {
+		return err
+	}
+
+	// Extract structs based on multiple args
+	kubectlOptions, err := parseKubectlOptions(cliContext)
+	if err != nil {
+		return err
+	}
+	tlsOptions, err := parseTLSArgs(cliContext, false)
+	if err != nil {
+		return err
+	}
+
+	return tls.GenerateAndStoreTLSSecret(
+		kubectlOptions,
+		tlsSecretNamespace,
+		tlsSecretName,
+		tlsOptions,
+		cliContext.Bool(tlsForceFlag.Name),
+	)
+}
+
+// generateMutualTLSEntrypoint will parse the CLI args and then call GenerateMutualTLSAsK8SSecrets, or
+// GenerateMutualTLSToDirectory when --output-dir is passed in.
+func generateMutualTLSEntrypoint(cliContext *cli.Context) error {
+	outputDir := cliContext.String(mtlsOutputDirFlag.Name)
+	genCA := cliContext.Bool(mtlsGenCAFlag.Name)
+
+	// The namespace and CA Secret are only needed when storing or loading Secrets.
+	namespace := cliContext.String(tlsStoreNamespaceFlag.Name)
+	caSecretName := cliContext.String(mtlsCASecretNameFlag.Name)
+	if outputDir == "" || !genCA {
+		var err error
+		namespace, err = entrypoint.StringFlagRequiredE(cliContext, tlsStoreNamespaceFlag.Name)
+		if err != nil {
+			return err
+		}
+		caSecretName, err = entrypoint.StringFlagRequiredE(cliContext, mtlsCASecretNameFlag.Name)
+		if err != nil {
+			return err
+		}
+	}
+	// CA Secret Namespace defaults to the same as --namespace
+	caSecretNamespace := cliContext.String(tlsCANamespaceFlag.Name)
+	if caSecretNamespace == "" {
+		caSecretNamespace = namespace
+	}
+
+	// Extract structs based on multiple args
+	kubectlOptions, err := parseKubectlOptions(cliContext)
+	if err != nil {
+		return err
+	}
+	serverTLSOptions, err := parseTLSArgs(cliContext, false)
+	if err != nil {
+		return err
+	}
+	clientTLSOptions, err := parseTLSArgs(cliContext, true)
+	if err != nil {
+		return err
+	}
+
+	caSecretOptions := tls.KubernetesSecretOptions{
+		Name:        caSecretName,
+		Namespace:   caSecretNamespace,
+		Labels:      tagArgsToMap(cliContext.StringSlice(tlsSecretLabelsFlag.Name)),
+		Annotations: tagArgsToMap(cliContext.StringSlice(tlsSecretAnnotationsFlag.Name)),
+	}
+	if outputDir != "" {
+		return tls.GenerateMutualTLSToDirectory(kubectlOptions, caSecretOptions, genCA, outputDir, serverTLSOptions, clientTLSOptions)
+	}
+
+	serverSecretName, err := entrypoint.StringFlagRequiredE(cliContext, mtlsServerSecretNameFlag.Name)
+	if err != nil {
+		return err
+	}
+	clientSecretName, err := entrypoint.StringFlagRequiredE(cliContext, mtlsClientSecretNameFlag.Name)
+	if err != nil {
+		return err
+	}
+	serverSecretOptions := tls.KubernetesSecretOptions{
+		Name:        serverSecretName,
+		Namespace:   namespace,
+		Labels:      tagArgsToMap(cliContext.StringSlice(tlsSecretLabelsFlag.Name)),
+		Annotations: tagArgsToMap(cliContext.StringSlice(tlsSecretAnnotationsFlag.Name)),
+	}
+	clientSecretOptions := tls.KubernetesSecretOptions{
+		Name:        clientSecretName,
+		Namespace:   namespace,
+		Labels:      tagArgsToMap(cliContext.StringSlice(tlsSecretLabelsFlag.Name)),
+		Annotations: tagArgsToMap(cliContext.StringSlice(tlsSecretAnnotationsFlag.Name)),
+	}
+	return tls.GenerateMutualTLSAsK8SSecrets(
+		kubectlOptions,
+		caSecretOptions,
+		serverSecretOptions,
+		clientSecretOptions,
+		genCA,
+		serverTLSOptions,
+		clientTLSOptions,
+	)
+}
+
+// rotateCAEntrypoint will parse the CLI args and then call RotateCA.
+func rotateCAEntrypoint(cliContext *cli.Context) error {
+	namespace, err := entrypoint.StringFlagRequiredE(cliContext, tlsStoreNamespaceFlag.Name)
+	if err != nil {
+		return err
+	}
+	secretName, err := entrypoint.StringFlagRequiredE(cliContext, tlsSecretNameFlag.Name)
+	if err != nil {
+		return err
+	}
+	kubectlOptions, err := parseKubectlOptions(cliContext)
+	if err != nil {
+		return err
+	}
+
+	updated, err := tls.RotateCA(kubectlOptions, secretName, namespace, cliContext.Bool(tlsDropPreviousCAFlag.Name))
+	for _, ref := range updated {
+		fmt.Println(ref)
+	}
+	return err
+}
+
+// deleteTLSSecretEntrypoint will parse the CLI args and then call DeleteTLSSecretWithOptions.
+func deleteTLSSecretEntrypoint(cliContext *cli.Context) error {
+	namespace, err := entrypoint.StringFlagRequiredE(cliContext, tlsStoreNamespaceFlag.Name)
+	if err != nil {
+		return err
+	}
+	secretName, err := entrypoint.StringFlagRequiredE(cliContext, tlsSecretNameFlag.Name)
+	if err != nil {
+		return err
+	}
+	kubectlOptions, err := parseKubectlOptions(cliContext)
+	if err != nil {
+		return err
+	}
+
+	deletion, err := tls.DeleteTLSSecretWithOptions(
+		kubectlOptions,
+		namespace,
+		secretName,
+		cliContext.Bool(tlsDeleteForceFlag.Name),
+		cliContext.Bool(tlsUntrackFromCAFlag.Name),
+	)
+	if deletion.Deleted {
+		fmt.Printf("Deleted Secret %s\n", deletion.Ref)
+	} else if err == nil {
+		fmt.Printf("Secret %s does not exist\n", deletion.Ref)
+	}
+	if deletion.UntrackedFromCA != "" {
+		fmt.Printf("Removed %s from the issued certificates of CA %s\n", deletion.Ref, deletion.UntrackedFromCA)
+	}
+	return err
+}
+
 // tagArgsToMap takes args used for tags (e.g --secret-label) encoded as a string slice of key=value strings and
 // converts to a map.
 func tagArgsToMap(tagArgs []string) map[string]string {
@@ -243,8 +611,10 @@ func tagArgsToMap(tagArgs []string) map[string]string {
 func parseTLSArgs(cliContext *cli.Context, isClient bool) (tls.TLSOptions, error) {
 	var distinguishedName pkix.Name
 	var err error
+	dnsNamesFlag, ipAddressesFlag := tlsDNSNamesFlag, tlsIPAddressesFlag
 	if isClient {
 		distinguishedName, err = parseTLSFlagsToPkixName(cliContext, clientTLSSubjectInfoFlags)
+		dnsNamesFlag, ipAddressesFlag = clientTLSDNSNamesFlag, clientTLSIPAddressesFlag
 	} else {
 		distinguishedName, err = parseTLSFlagsToPkixName(cliContext, tlsSubjectInfoFlags)
 	}
@@ -257,6 +627,15 @@ func parseTLSArgs(cliContext *cli.Context, isClient bool) (tls.TLSOptions, error
 	tlsAlgorithm := cliContext.String(tlsAlgorithmFlag.Name)
 	tlsECDSACurve := cliContext.String(tlsECDSACurveFlag.Name)
 	tlsRSABits := cliContext.Int(tlsRSABitsFlag.Name)
+	tlsDNSNames := cliContext.StringSlice(dnsNamesFlag.Name)
+	tlsIPAddresses := []net.IP{}
+	for _, ipAddressStr := range cliContext.StringSlice(ipAddressesFlag.Name) {
+		ipAddress := net.ParseIP(ipAddressStr)
+		if ipAddress == nil {
+			return tls.TLSOptions{}, errors.WithStackTrace(InvalidIPAddressErr{flagName: ipAddressesFlag.Name, value: ipAddressStr})
+		}
+		tlsIPAddresses = append(tlsIPAddresses, ipAddress)
+	}
 
 	// Create tls options struct
 	tlsValidity := time.Duration(tlsValidityInDays) * 24 * time.Hour
@@ -266,6 +645,8 @@ func parseTLSArgs(cliContext *cli.Context, isClient bool) (tls.TLSOptions, error
 		PrivateKeyAlgorithm: tlsAlgorithm,
 		ECDSACurve:          tlsECDSACurve,
 		RSABits:             tlsRSABits,
+		DNSNames:            tlsDNSNames,
+		IPAddresses:         tlsIPAddresses,
 	}
 	if err := tlsOptions.Validate(); err != nil {
 		return tlsOptions, err
@@ -5,7 +5,9 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/aws/aws-sdk-go/service/elb"
 	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/gruntwork-io/go-commons/collections"
@@ -15,11 +17,12 @@ import (
 	"github.com/gruntwork-io/kubergrunt/commonerrors"
 	"github.com/gruntwork-io/kubergrunt/kubectl"
 	"github.com/gruntwork-io/kubergrunt/logging"
+	"github.com/gruntwork-io/kubergrunt/metrics"
 )
 
 // GetAsgByName will lookup an AutoScalingGroup that matches the given name. This will return an error if it can not
 // find any ASG that matches the given name.
-func GetAsgByName(svc *autoscaling.AutoScaling, asgName string) (*autoscaling.Group, error) {
+func GetAsgByName(svc autoscalingiface.AutoScalingAPI, asgName string) (*autoscaling.Group, error) {
 	input := autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: []*string{aws.String(asgName)}}
 	output, err := svc.DescribeAutoScalingGroups(&input)
 	if err != nil {
@@ -34,7 +37,7 @@ func GetAsgByName(svc *autoscaling.AutoScaling, asgName string) (*autoscaling.Gr
 
 // scaleUp will scale the ASG up, wait until all the nodes are available and return new instance IDs.
 func scaleUp(
-	asgSvc *autoscaling.AutoScaling,
+	asgSvc autoscalingiface.AutoScalingAPI,
 	asgName string,
 	originalInstanceIds []string,
 	desiredCapacity int64,
@@ -122,7 +125,7 @@ func waitAndVerifyNewInstances(
 
 // getLaunchedInstanceIds will return a list of instance IDs that are new in the ASG, given a list of IDs of the
 // existing instances before any change was made.
-func getLaunchedInstanceIds(svc *autoscaling.AutoScaling, asgName string, existingInstanceIds []string) ([]string, error) {
+func getLaunchedInstanceIds(svc autoscalingiface.AutoScalingAPI, asgName string, existingInstanceIds []string) ([]string, error) {
 	asg, err := GetAsgByName(svc, asgName)
 	if err != nil {
 		return nil, err
@@ -140,7 +143,7 @@ func getLaunchedInstanceIds(svc *autoscaling.AutoScaling, asgName string, existi
 
 // setAsgCapacity will set the desired capacity on the auto scaling group. This will not wait for the ASG to expand or
 // shrink to that size. See waitForCapacity to wait for the ASG to scale to the set capacity.
-func setAsgCapacity(svc *autoscaling.AutoScaling, asgName string, desiredCapacity int64) error {
+func setAsgCapacity(svc autoscalingiface.AutoScalingAPI, asgName string, desiredCapacity int64) error {
 	logger := logging.GetProjectLogger()
 	logger.Infof("Updating ASG %s desired capacity to %d.", asgName, desiredCapacity)
 
@@ -159,7 +162,7 @@ func setAsgCapacity(svc *autoscaling.AutoScaling, asgName string, desiredCapacit
 
 // setAsgMaxSize will set the max size on the auto scaling group. Note that updating the max size does not typically
 // change the cluster size.
-func setAsgMaxSize(svc *autoscaling.AutoScaling, asgName string, maxSize int64) error {
+func setAsgMaxSize(svc autoscalingiface.AutoScalingAPI, asgName string, maxSize int64) error {
 	logger := logging.GetProjectLogger()
 	logger.Infof("Updating ASG %s max size to %d.", asgName, maxSize)
 
@@ -178,7 +181,7 @@ func setAsgMaxSize(svc *autoscaling.AutoScaling, asgName string, maxSize int64)
 
 // waitForCapacity waits for the desired capacity to be reached
 func waitForCapacity(
-	svc *autoscaling.AutoScaling,
+	svc autoscalingiface.AutoScalingAPI,
 	asgName string,
 	maxRetries int,
 	sleepBetweenRetries time.Duration,
@@ -187,7 +190,7 @@ func waitForCapacity(
 	logger.Infof("Waiting for ASG %s to reach desired capacity.", asgName)
 
 	for i := 0; i < maxRetries; i++ {
-		logger.Infof("Checking ASG %s capacity.", asgName)
+		logger.Debugf("Checking ASG %s capacity.", asgName)
 		asg, err := GetAsgByName(svc, asgName)
 		if err != nil {
 			return err
@@ -199,8 +202,8 @@ func waitForCapacity(
 			return nil
 		}
 
-		logger.Infof("ASG %s not yet at desired capacity %d (current %d).", asgName, desiredCapacity, currentCapacity)
-		logger.Infof("Waiting for %s...", sleepBetweenRetries)
+		logger.Debugf("ASG %s not yet at desired capacity %d (current %d).", asgName, desiredCapacity, currentCapacity)
+		logger.Debugf("Waiting for %s...", sleepBetweenRetries)
 		time.Sleep(sleepBetweenRetries)
 	}
 	return errors.WithStackTrace(
@@ -221,11 +224,15 @@ func idsFromAsgInstances(instances []*autoscaling.Instance) []string {
 	return idList
 }
 
+// drainKubeNodes drains the nodes with kubectl. This is a variable so that the tests can stub out kubectl.
+var drainKubeNodes = kubectl.DrainNodes
+
 // Make the call to drain all the provided nodes in Kubernetes. This is different from terminating the instances:
 // - Taint the nodes so that new pods are not scheduled
 // - Evict all the pods gracefully
+// The drained nodes are counted in the metrics once all of them are drained.
 func drainNodesInAsg(
-	ec2Svc *ec2.EC2,
+	ec2Svc ec2iface.EC2API,
 	kubectlOptions *kubectl.KubectlOptions,
 	asgInstanceIds []string,
 	drainTimeout time.Duration,
@@ -237,7 +244,11 @@ func drainNodesInAsg(
 	}
 	eksKubeNodeNames := kubeNodeNamesFromInstances(instances)
 
-	return kubectl.DrainNodes(kubectlOptions, eksKubeNodeNames, drainTimeout, deleteEmptyDirData)
+	if err := drainKubeNodes(kubectlOptions, eksKubeNodeNames, drainTimeout, deleteEmptyDirData); err != nil {
+		return err
+	}
+	metrics.AddNodesDrained(len(eksKubeNodeNames))
+	return nil
 }
 
 // Make the call to cordon all the provided nodes in Kubernetes so that they won't be used to schedule new Pods.
@@ -255,9 +266,10 @@ func cordonNodesInAsg(
 	return kubectl.CordonNodes(kubectlOptions, eksKubeNodeNames)
 }
 
-// detachInstances will request AWS to detach the instances, removing them from the ASG. In the process, it will also
-// request to auto decrement the desired capacity.
-func detachInstances(asgSvc *autoscaling.AutoScaling, asgName string, idList []string) error {
+// detachInstances will request AWS to detach the instances, removing them from the ASG. When
+// shouldDecrementDesiredCapacity is true, it will also request to auto decrement the desired capacity. Otherwise, the
+// ASG launches new instances to replace the detached ones.
+func detachInstances(asgSvc autoscalingiface.AutoScalingAPI, asgName string, idList []string, shouldDecrementDesiredCapacity bool) error {
 	logger := logging.GetProjectLogger()
 	logger.Infof("Detaching %d instances from ASG %s", len(idList), asgName)
 
@@ -266,7 +278,7 @@ func detachInstances(asgSvc *autoscaling.AutoScaling, asgName string, idList []s
 		input := &autoscaling.DetachInstancesInput{
 			AutoScalingGroupName:           aws.String(asgName),
 			InstanceIds:                    aws.StringSlice(smallIDList),
-			ShouldDecrementDesiredCapacity: aws.Bool(true),
+			ShouldDecrementDesiredCapacity: aws.Bool(shouldDecrementDesiredCapacity),
 		}
 		_, err := asgSvc.DetachInstances(input)
 		if err != nil {
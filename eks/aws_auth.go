@@ -0,0 +1,276 @@
+package eks
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/gruntwork-io/go-commons/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+const (
+	awsAuthConfigMapName = "aws-auth"
+	awsAuthMapRolesKey   = "mapRoles"
+	awsAuthMapUsersKey   = "mapUsers"
+)
+
+// RoleMapping maps an IAM role to a Kubernetes user and groups, as an entry of the mapRoles key of the aws-auth
+// ConfigMap.
+type RoleMapping struct {
+	RoleARN  string   `json:"rolearn"`
+	Username string   `json:"username,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// UserMapping maps an IAM user to a Kubernetes user and groups, as an entry of the mapUsers key of the aws-auth
+// ConfigMap.
+type UserMapping struct {
+	UserARN  string   `json:"userarn"`
+	Username string   `json:"username,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// AwsAuthUpdateOptions configures how UpdateAwsAuthMapping updates the aws-auth ConfigMap.
+type AwsAuthUpdateOptions struct {
+	// Remove, when true, removes the mappings with the ARNs of the given mappings instead of upserting them. Only the
+	// ARNs of the mappings are used in this mode.
+	Remove bool
+
+	// DryRun, when true, computes the resulting ConfigMap without writing it back to the cluster.
+	DryRun bool
+}
+
+// UpsertAwsAuthMapping merges the given IAM role and user mappings into the aws-auth ConfigMap in the kube-system
+// namespace of the EKS cluster, which maps IAM identities to Kubernetes users and groups for RBAC. See
+// UpdateAwsAuthMapping.
+func UpsertAwsAuthMapping(clusterArn string, roleMappings []RoleMapping, userMappings []UserMapping) error {
+	_, err := UpdateAwsAuthMapping(clusterArn, roleMappings, userMappings, AwsAuthUpdateOptions{})
+	return err
+}
+
+// UpdateAwsAuthMapping reads the aws-auth ConfigMap in the kube-system namespace of the EKS cluster, merges the given
+// IAM role and user mappings into the mapRoles and mapUsers keys, and writes it back. The mappings are keyed by ARN, so
+// an existing mapping for the same ARN is replaced in place instead of being duplicated, and all the other entries are
+// preserved. When options.Remove is set, the mappings with the given ARNs are removed instead. The ConfigMap is created
+// if it does not exist yet.
+//
+// Returns the resulting ConfigMap, which is not written back when options.DryRun is set. An InvalidAwsAuthConfigMapError
+// is returned if the existing mapRoles or mapUsers are not valid YAML lists of mappings, in which case the ConfigMap is
+// left as is.
+func UpdateAwsAuthMapping(
+	clusterArn string,
+	roleMappings []RoleMapping,
+	userMappings []UserMapping,
+	options AwsAuthUpdateOptions,
+) (*corev1.ConfigMap, error) {
+	client, err := kubectl.GetKubernetesClientFromOptions(&kubectl.KubectlOptions{EKSClusterArn: clusterArn})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return updateAwsAuthMapping(context.Background(), client, roleMappings, userMappings, options)
+}
+
+func updateAwsAuthMapping(
+	ctx context.Context,
+	client kubernetes.Interface,
+	roleMappings []RoleMapping,
+	userMappings []UserMapping,
+	options AwsAuthUpdateOptions,
+) (*corev1.ConfigMap, error) {
+	logger := logging.GetProjectLogger()
+
+	if err := validateAwsAuthMappings(roleMappings, userMappings, options.Remove); err != nil {
+		return nil, err
+	}
+
+	var result *corev1.ConfigMap
+	// Retry on conflicts, so that concurrent updates of the ConfigMap (e.g., by EKS when a node group is created) are
+	// merged instead of overwritten.
+	err := kubehelper.RetryOnConflictOrServerError(func() error {
+		configMapAPI := client.CoreV1().ConfigMaps(componentNamespace)
+		configMap, err := configMapAPI.Get(ctx, awsAuthConfigMapName, metav1.GetOptions{})
+		exists := true
+		if apierrors.IsNotFound(err) {
+			exists = false
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: awsAuthConfigMapName, Namespace: componentNamespace},
+			}
+		} else if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		if !exists && options.Remove {
+			logger.Infof("ConfigMap %s/%s does not exist. Nothing to remove.", componentNamespace, awsAuthConfigMapName)
+			result = configMap
+			return nil
+		}
+
+		if err := mergeAwsAuthMappings(configMap, roleMappings, userMappings, options.Remove); err != nil {
+			return err
+		}
+		result = configMap
+		if options.DryRun {
+			return nil
+		}
+
+		if exists {
+			kubectl.MarkAsModifiedByKubergrunt(&configMap.ObjectMeta)
+			logger.Infof("Updating ConfigMap %s/%s", componentNamespace, awsAuthConfigMapName)
+			result, err = configMapAPI.Update(ctx, configMap, metav1.UpdateOptions{})
+		} else {
+			kubectl.MarkAsCreatedByKubergrunt(&configMap.ObjectMeta)
+			logger.Infof("Creating ConfigMap %s/%s", componentNamespace, awsAuthConfigMapName)
+			result, err = configMapAPI.Create(ctx, configMap, metav1.CreateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mergeAwsAuthMappings upserts (or removes) the given mappings in the mapRoles and mapUsers keys of the aws-auth
+// ConfigMap in place. Keys that end up without any mapping are removed from the ConfigMap.
+func mergeAwsAuthMappings(configMap *corev1.ConfigMap, roleMappings []RoleMapping, userMappings []UserMapping, remove bool) error {
+	existingRoles := []RoleMapping{}
+	if err := parseAwsAuthMappings(configMap, awsAuthMapRolesKey, &existingRoles); err != nil {
+		return err
+	}
+	for _, existing := range existingRoles {
+		if existing.RoleARN == "" {
+			return errors.WithStackTrace(InvalidAwsAuthConfigMapError{Key: awsAuthMapRolesKey, Reason: "an entry does not have a rolearn"})
+		}
+	}
+	existingUsers := []UserMapping{}
+	if err := parseAwsAuthMappings(configMap, awsAuthMapUsersKey, &existingUsers); err != nil {
+		return err
+	}
+	for _, existing := range existingUsers {
+		if existing.UserARN == "" {
+			return errors.WithStackTrace(InvalidAwsAuthConfigMapError{Key: awsAuthMapUsersKey, Reason: "an entry does not have a userarn"})
+		}
+	}
+
+	logger := logging.GetProjectLogger()
+	for _, mapping := range roleMappings {
+		index := -1
+		for i, existing := range existingRoles {
+			if existing.RoleARN == mapping.RoleARN {
+				index = i
+				break
+			}
+		}
+		switch {
+		case remove && index >= 0:
+			logger.Infof("Removing mapping of IAM role %s", mapping.RoleARN)
+			existingRoles = append(existingRoles[:index], existingRoles[index+1:]...)
+		case remove:
+			logger.Infof("IAM role %s is not mapped. Nothing to remove.", mapping.RoleARN)
+		case index >= 0:
+			logger.Infof("Updating mapping of IAM role %s to user %s in groups %s", mapping.RoleARN, mapping.Username, strings.Join(mapping.Groups, ", "))
+			existingRoles[index] = mapping
+		default:
+			logger.Infof("Adding mapping of IAM role %s to user %s in groups %s", mapping.RoleARN, mapping.Username, strings.Join(mapping.Groups, ", "))
+			existingRoles = append(existingRoles, mapping)
+		}
+	}
+	for _, mapping := range userMappings {
+		index := -1
+		for i, existing := range existingUsers {
+			if existing.UserARN == mapping.UserARN {
+				index = i
+				break
+			}
+		}
+		switch {
+		case remove && index >= 0:
+			logger.Infof("Removing mapping of IAM user %s", mapping.UserARN)
+			existingUsers = append(existingUsers[:index], existingUsers[index+1:]...)
+		case remove:
+			logger.Infof("IAM user %s is not mapped. Nothing to remove.", mapping.UserARN)
+		case index >= 0:
+			logger.Infof("Updating mapping of IAM user %s to user %s in groups %s", mapping.UserARN, mapping.Username, strings.Join(mapping.Groups, ", "))
+			existingUsers[index] = mapping
+		default:
+			logger.Infof("Adding mapping of IAM user %s to user %s in groups %s", mapping.UserARN, mapping.Username, strings.Join(mapping.Groups, ", "))
+			existingUsers = append(existingUsers, mapping)
+		}
+	}
+
+	if err := setAwsAuthMappings(configMap, awsAuthMapRolesKey, existingRoles, len(existingRoles)); err != nil {
+		return err
+	}
+	return setAwsAuthMappings(configMap, awsAuthMapUsersKey, existingUsers, len(existingUsers))
+}
+
+// parseAwsAuthMappings parses the YAML list of mappings under the given key of the aws-auth ConfigMap into out. Unknown
+// fields are rejected, so that they are not silently dropped when the ConfigMap is written back.
+func parseAwsAuthMappings(configMap *corev1.ConfigMap, key string, out interface{}) error {
+	data := strings.TrimSpace(configMap.Data[key])
+	if data == "" {
+		return nil
+	}
+	if err := yaml.UnmarshalStrict([]byte(data), out); err != nil {
+		return errors.WithStackTrace(InvalidAwsAuthConfigMapError{Key: key, Reason: err.Error()})
+	}
+	return nil
+}
+
+// setAwsAuthMappings serializes the mappings as YAML under the given key of the aws-auth ConfigMap, or removes the key
+// if there are no mappings.
+func setAwsAuthMappings(configMap *corev1.ConfigMap, key string, mappings interface{}, numMappings int) error {
+	if numMappings == 0 {
+		delete(configMap.Data, key)
+		return nil
+	}
+	data, err := yaml.Marshal(mappings)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	configMap.Data[key] = string(data)
+	return nil
+}
+
+// validateAwsAuthMappings checks that the ARNs of the given mappings are IAM role and user ARNs respectively. When
+// upserting, the same ARN can not be mapped more than once.
+func validateAwsAuthMappings(roleMappings []RoleMapping, userMappings []UserMapping, remove bool) error {
+	seen := map[string]bool{}
+	for _, mapping := range roleMappings {
+		if err := validateIAMArn(mapping.RoleARN, "role/", seen, remove); err != nil {
+			return err
+		}
+	}
+	for _, mapping := range userMappings {
+		if err := validateIAMArn(mapping.UserARN, "user/", seen, remove); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateIAMArn(arnString string, resourcePrefix string, seen map[string]bool, remove bool) error {
+	parsedArn, err := arn.Parse(arnString)
+	if err != nil {
+		return errors.WithStackTrace(InvalidAwsAuthMappingError{ARN: arnString, Reason: err.Error()})
+	}
+	if parsedArn.Service != "iam" || !strings.HasPrefix(parsedArn.Resource, resourcePrefix) {
+		return errors.WithStackTrace(InvalidAwsAuthMappingError{ARN: arnString, Reason: "expected an IAM " + strings.TrimSuffix(resourcePrefix, "/") + " ARN"})
+	}
+	if seen[arnString] && !remove {
+		return errors.WithStackTrace(InvalidAwsAuthMappingError{ARN: arnString, Reason: "mapped more than once"})
+	}
+	seen[arnString] = true
+	return nil
+}
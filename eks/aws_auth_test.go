@@ -0,0 +1,212 @@
+package eks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	testNodeRoleArn  = "arn:aws:iam::111111111111:role/eks-node"
+	testAdminRoleArn = "arn:aws:iam::111111111111:role/admin"
+	testAdminUserArn = "arn:aws:iam::111111111111:user/admin"
+
+	testAwsAuthMapRoles = `- rolearn: arn:aws:iam::111111111111:role/eks-node
+  username: system:node:{{EC2PrivateDNSName}}
+  groups:
+  - system:bootstrappers
+  - system:nodes
+`
+)
+
+func TestUpdateAwsAuthMappingUpsertsByArn(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(newTestAwsAuthConfigMap(map[string]string{awsAuthMapRolesKey: testAwsAuthMapRoles}))
+	roleMappings := []RoleMapping{{RoleARN: testAdminRoleArn, Username: "admin", Groups: []string{"system:masters"}}}
+	userMappings := []UserMapping{{UserARN: testAdminUserArn, Username: "admin", Groups: []string{"system:masters"}}}
+
+	_, err := updateAwsAuthMapping(context.Background(), client, roleMappings, userMappings, AwsAuthUpdateOptions{})
+	require.NoError(t, err)
+	// Upserting again with a different username should update the entry in place.
+	roleMappings[0].Username = "cluster-admin"
+	_, err = updateAwsAuthMapping(context.Background(), client, roleMappings, nil, AwsAuthUpdateOptions{})
+	require.NoError(t, err)
+
+	roles, users := getTestAwsAuthMappings(t, client)
+	require.Len(t, roles, 2)
+	assert.Equal(t, testNodeRoleArn, roles[0].RoleARN)
+	assert.Equal(t, "system:node:{{EC2PrivateDNSName}}", roles[0].Username)
+	assert.Equal(t, []string{"system:bootstrappers", "system:nodes"}, roles[0].Groups)
+	assert.Equal(t, RoleMapping{RoleARN: testAdminRoleArn, Username: "cluster-admin", Groups: []string{"system:masters"}}, roles[1])
+	assert.Equal(t, userMappings, users)
+}
+
+func TestUpdateAwsAuthMappingRemovesByArn(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(newTestAwsAuthConfigMap(map[string]string{awsAuthMapRolesKey: testAwsAuthMapRoles}))
+	_, err := updateAwsAuthMapping(context.Background(), client, []RoleMapping{{RoleARN: testAdminRoleArn, Username: "admin"}}, nil, AwsAuthUpdateOptions{})
+	require.NoError(t, err)
+
+	// Only the ARN is used when removing, and unknown ARNs are ignored.
+	removeRoles := []RoleMapping{{RoleARN: testAdminRoleArn}}
+	removeUsers := []UserMapping{{UserARN: testAdminUserArn}}
+	_, err = updateAwsAuthMapping(context.Background(), client, removeRoles, removeUsers, AwsAuthUpdateOptions{Remove: true})
+	require.NoError(t, err)
+
+	roles, users := getTestAwsAuthMappings(t, client)
+	require.Len(t, roles, 1)
+	assert.Equal(t, testNodeRoleArn, roles[0].RoleARN)
+	assert.Empty(t, users)
+}
+
+func TestUpdateAwsAuthMappingRetriesOnConflict(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(newTestAwsAuthConfigMap(map[string]string{awsAuthMapRolesKey: testAwsAuthMapRoles}))
+	// Simulate a concurrent update of the ConfigMap by failing the first update with a conflict.
+	updates := 0
+	client.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		updates++
+		if updates == 1 {
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, awsAuthConfigMapName, assert.AnError)
+		}
+		return false, nil, nil
+	})
+
+	roleMappings := []RoleMapping{{RoleARN: testAdminRoleArn, Username: "admin", Groups: []string{"system:masters"}}}
+	_, err := updateAwsAuthMapping(context.Background(), client, roleMappings, nil, AwsAuthUpdateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, updates)
+
+	roles, _ := getTestAwsAuthMappings(t, client)
+	require.Len(t, roles, 2)
+	assert.Equal(t, roleMappings[0], roles[1])
+}
+
+func TestUpdateAwsAuthMappingCreatesConfigMap(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset()
+	userMappings := []UserMapping{{UserARN: testAdminUserArn, Username: "admin", Groups: []string{"system:masters"}}}
+	_, err := updateAwsAuthMapping(context.Background(), client, nil, userMappings, AwsAuthUpdateOptions{})
+	require.NoError(t, err)
+
+	roles, users := getTestAwsAuthMappings(t, client)
+	assert.Empty(t, roles)
+	assert.Equal(t, userMappings, users)
+}
+
+func TestUpdateAwsAuthMappingDryRunDoesNotWrite(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(newTestAwsAuthConfigMap(map[string]string{awsAuthMapRolesKey: testAwsAuthMapRoles}))
+	roleMappings := []RoleMapping{{RoleARN: testAdminRoleArn, Username: "admin", Groups: []string{"system:masters"}}}
+	configMap, err := updateAwsAuthMapping(context.Background(), client, roleMappings, nil, AwsAuthUpdateOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Contains(t, configMap.Data[awsAuthMapRolesKey], testAdminRoleArn)
+
+	roles, _ := getTestAwsAuthMappings(t, client)
+	assert.Len(t, roles, 1)
+}
+
+func TestUpdateAwsAuthMappingRejectsInvalidConfigMap(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		mapRoles string
+	}{
+		{"NotAList", "rolearn: arn:aws:iam::111111111111:role/eks-node"},
+		{"UnknownField", "- rolearn: arn:aws:iam::111111111111:role/eks-node\n  usrname: typo"},
+		{"MissingArn", "- username: admin"},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := fake.NewSimpleClientset(newTestAwsAuthConfigMap(map[string]string{awsAuthMapRolesKey: testCase.mapRoles}))
+			roleMappings := []RoleMapping{{RoleARN: testAdminRoleArn, Username: "admin"}}
+			_, err := updateAwsAuthMapping(context.Background(), client, roleMappings, nil, AwsAuthUpdateOptions{})
+			require.Error(t, err)
+			_, isInvalidErr := errors.Unwrap(err).(InvalidAwsAuthConfigMapError)
+			assert.True(t, isInvalidErr, err.Error())
+
+			configMap, err := client.CoreV1().ConfigMaps(componentNamespace).Get(context.Background(), awsAuthConfigMapName, metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, testCase.mapRoles, configMap.Data[awsAuthMapRolesKey])
+		})
+	}
+}
+
+func TestValidateAwsAuthMappings(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name         string
+		roleMappings []RoleMapping
+		userMappings []UserMapping
+		remove       bool
+		expectErr    bool
+	}{
+		{"Valid", []RoleMapping{{RoleARN: testAdminRoleArn}}, []UserMapping{{UserARN: testAdminUserArn}}, false, false},
+		{"NotAnArn", []RoleMapping{{RoleARN: "admin"}}, nil, false, true},
+		{"UserArnAsRole", []RoleMapping{{RoleARN: testAdminUserArn}}, nil, false, true},
+		{"NotIAM", nil, []UserMapping{{UserARN: "arn:aws:sts::111111111111:assumed-role/admin/session"}}, false, true},
+		{"Duplicate", []RoleMapping{{RoleARN: testAdminRoleArn}, {RoleARN: testAdminRoleArn}}, nil, false, true},
+		{"DuplicateRemove", []RoleMapping{{RoleARN: testAdminRoleArn}, {RoleARN: testAdminRoleArn}}, nil, true, false},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := validateAwsAuthMappings(testCase.roleMappings, testCase.userMappings, testCase.remove)
+			if testCase.expectErr {
+				require.Error(t, err)
+				_, isInvalidErr := errors.Unwrap(err).(InvalidAwsAuthMappingError)
+				assert.True(t, isInvalidErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func newTestAwsAuthConfigMap(data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: awsAuthConfigMapName, Namespace: componentNamespace},
+		Data:       data,
+	}
+}
+
+func getTestAwsAuthMappings(t *testing.T, client kubernetes.Interface) ([]RoleMapping, []UserMapping) {
+	configMap, err := client.CoreV1().ConfigMaps(componentNamespace).Get(context.Background(), awsAuthConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+
+	roles := []RoleMapping{}
+	require.NoError(t, yaml.Unmarshal([]byte(configMap.Data[awsAuthMapRolesKey]), &roles))
+	users := []UserMapping{}
+	require.NoError(t, yaml.Unmarshal([]byte(configMap.Data[awsAuthMapUsersKey]), &users))
+	return roles, users
+}
@@ -0,0 +1,121 @@
+package eks
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/retry"
+	"github.com/sirupsen/logrus"
+)
+
+// BackoffConfig configures the exponential backoff used between polls when waiting for AWS resources to reach a
+// desired state.
+type BackoffConfig struct {
+	// Base is the interval to sleep before the first retry. The interval doubles on each subsequent retry.
+	Base time.Duration
+
+	// Max caps the interval between retries.
+	Max time.Duration
+
+	// Jitter is the fraction of each interval, between 0 and 1, that is randomized. A value of 1 means full jitter, where
+	// the sleep is picked uniformly between 0 and the computed interval, while 0 disables jitter entirely.
+	Jitter float64
+}
+
+// DefaultBackoffConfig returns the backoff settings used when cleaning up security groups: starting at 1 second, capped
+// at 30 seconds, with full jitter.
+func DefaultBackoffConfig() BackoffConfig {
+	return BackoffConfig{
+		Base:   1 * time.Second,
+		Max:    30 * time.Second,
+		Jitter: 1.0,
+	}
+}
+
+// withDefaults returns a copy of the config where the zero valued Base and Max are replaced with the defaults, so that
+// a partial config (e.g., only overriding Base) does not poll without sleeping. The zero config is replaced entirely,
+// while in a partial config a zero Jitter is kept, as it disables jitter.
+func (config BackoffConfig) withDefaults() BackoffConfig {
+	defaults := DefaultBackoffConfig()
+	if config == (BackoffConfig{}) {
+		return defaults
+	}
+	if config.Base <= 0 {
+		config.Base = defaults.Base
+	}
+	if config.Max <= 0 {
+		config.Max = defaults.Max
+	}
+	return config
+}
+
+// interval returns the amount of time to sleep before the given retry attempt (0 indexed).
+func (config BackoffConfig) interval(attempt int) time.Duration {
+	// A max below the base would cap every interval below the base, so the base wins.
+	maxInterval := config.Max
+	if maxInterval < config.Base {
+		maxInterval = config.Base
+	}
+
+	interval := maxInterval
+	// Guard against overflow on large attempt counts: once the exponent exceeds the max, there is no need to compute it.
+	if exp := float64(config.Base) * math.Pow(2, float64(attempt)); exp < float64(maxInterval) {
+		interval = time.Duration(exp)
+	}
+
+	jitter := math.Min(math.Max(config.Jitter, 0), 1)
+	fixed := float64(interval) * (1 - jitter)
+	randomized := float64(interval) * jitter * rand.Float64()
+	return time.Duration(fixed + randomized)
+}
+
+// doWithBackoff runs the specified action until it succeeds, sleeping between attempts according to the backoff config.
+// This mirrors retry.DoWithRetry: if the action returns a FatalError, that error is returned immediately, and if the
+// action does not succeed within the timeout, a MaxRetriesExceeded error is returned. If the context is cancelled, the
+// loop is aborted immediately and the context error is returned.
+func doWithBackoff(
+	ctx context.Context,
+	logger *logrus.Entry,
+	actionDescription string,
+	config BackoffConfig,
+	timeout time.Duration,
+	action func() error,
+) error {
+	deadline := time.Now().Add(timeout)
+
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		logger.Debug(actionDescription)
+
+		err := action()
+		if err == nil {
+			return nil
+		}
+
+		if _, isFatalErr := err.(retry.FatalError); isFatalErr {
+			logger.Infof("Returning due to fatal error: %v", err)
+			return err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return retry.MaxRetriesExceeded{Description: actionDescription, MaxRetries: attempt}
+		}
+
+		sleep := config.interval(attempt)
+		if sleep > remaining {
+			sleep = remaining
+		}
+		logger.Debugf("%s returned an error: %s. Attempt %d. Sleeping for %s and will retry.", actionDescription, err.Error(), attempt+1, sleep)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sleep):
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package eks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/retry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+func TestBackoffIntervalGrowsExponentiallyUpToMax(t *testing.T) {
+	t.Parallel()
+
+	config := BackoffConfig{Base: 1 * time.Second, Max: 10 * time.Second, Jitter: 0}
+	assert.Equal(t, 1*time.Second, config.interval(0))
+	assert.Equal(t, 2*time.Second, config.interval(1))
+	assert.Equal(t, 8*time.Second, config.interval(3))
+	assert.Equal(t, 10*time.Second, config.interval(4))
+	assert.Equal(t, 10*time.Second, config.interval(100))
+}
+
+func TestBackoffConfigWithDefaults(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		config   BackoffConfig
+		expected BackoffConfig
+		// expectedFirstInterval and expectedMaxInterval are the intervals of the first and a late retry without jitter.
+		expectedFirstInterval time.Duration
+		expectedMaxInterval   time.Duration
+	}{
+		{"zero", BackoffConfig{}, DefaultBackoffConfig(), 1 * time.Second, 30 * time.Second},
+		{"base-only", BackoffConfig{Base: 2 * time.Second}, BackoffConfig{Base: 2 * time.Second, Max: 30 * time.Second}, 2 * time.Second, 30 * time.Second},
+		{"max-only", BackoffConfig{Max: 30 * time.Second}, BackoffConfig{Base: 1 * time.Second, Max: 30 * time.Second}, 1 * time.Second, 30 * time.Second},
+		{"jitter-only", BackoffConfig{Jitter: 0.5}, BackoffConfig{Base: 1 * time.Second, Max: 30 * time.Second, Jitter: 0.5}, 1 * time.Second, 30 * time.Second},
+		{"max-below-base", BackoffConfig{Base: time.Minute, Max: 30 * time.Second}, BackoffConfig{Base: time.Minute, Max: 30 * time.Second}, time.Minute, time.Minute},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			config := testCase.config.withDefaults()
+			assert.Equal(t, testCase.expected, config)
+
+			config.Jitter = 0
+			assert.Equal(t, testCase.expectedFirstInterval, config.interval(0))
+			assert.Equal(t, testCase.expectedMaxInterval, config.interval(100))
+		})
+	}
+}
+
+func TestBackoffIntervalWithFullJitterStaysInBounds(t *testing.T) {
+	t.Parallel()
+
+	config := BackoffConfig{Base: 1 * time.Second, Max: 10 * time.Second, Jitter: 1}
+	for attempt := 0; attempt < 10; attempt++ {
+		interval := config.interval(attempt)
+		assert.True(t, interval >= 0)
+		assert.True(t, interval <= 10*time.Second)
+	}
+}
+
+func TestDoWithBackoffReturnsMaxRetriesExceededAfterTimeout(t *testing.T) {
+	t.Parallel()
+
+	config := BackoffConfig{Base: 1 * time.Millisecond, Max: 5 * time.Millisecond, Jitter: 1}
+	err := doWithBackoff(context.Background(), logging.GetProjectLogger(), "always fails", config, 50*time.Millisecond, func() error {
+		return errors.New("not ready")
+	})
+	require.Error(t, err)
+	assert.True(t, isMaxRetriesExceededErr(err))
+}
+
+func TestDoWithBackoffAbortsOnCancelledContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := doWithBackoff(ctx, logging.GetProjectLogger(), "cancelled", DefaultBackoffConfig(), time.Minute, func() error {
+		calls++
+		cancel()
+		return errors.New("not ready")
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDoWithBackoffHaltsOnFatalError(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	err := doWithBackoff(context.Background(), logging.GetProjectLogger(), "fatal", DefaultBackoffConfig(), time.Minute, func() error {
+		calls++
+		return retry.FatalError{Underlying: errors.New("boom")}
+	})
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
@@ -1,17 +1,27 @@
 package eks
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/gruntwork-io/go-commons/collections"
 	"github.com/gruntwork-io/go-commons/errors"
 	"github.com/gruntwork-io/go-commons/retry"
+	"github.com/hashicorp/go-multierror"
+	"github.com/sirupsen/logrus"
 
 	"github.com/gruntwork-io/kubergrunt/eksawshelper"
 	"github.com/gruntwork-io/kubergrunt/logging"
+	"github.com/gruntwork-io/kubergrunt/metrics"
 )
 
 // Set wait variables for NetworkInterface detaching and deleting
@@ -20,121 +30,824 @@ const (
 	waitMaxRetries          int           = 30
 )
 
+// DefaultCleanupConcurrency is the default number of network interfaces that are detached, deleted, and polled in
+// parallel when cleaning up a security group.
+const DefaultCleanupConcurrency = 10
+
+// defaultSecurityGroupTagKeys lists the tag keys that the load balancer controllers set to the name of the cluster on the
+// security groups they create: the AWS Load Balancer Controller, and the legacy ALB ingress controller.
+var defaultSecurityGroupTagKeys = []string{"elbv2.k8s.aws/cluster", "kubernetes.io/cluster-name"}
+
+// cleanupProgressReportInterval is the minimum amount of time between the logs of the overall progress of detaching and
+// deleting the network interfaces of a security group.
+const cleanupProgressReportInterval = 5 * time.Second
+
+// cleanupMetricsOperation is the operation label of the phase durations of the cleanup in the metrics.
+const cleanupMetricsOperation = "cleanup"
+
+// CleanupOptions configures how CleanupSecurityGroup clears out the dependencies of the security groups. Any zero valued
+// field is replaced with its default, so that callers only need to set the options they want to override.
+type CleanupOptions struct {
+	// MaxRetries and SleepBetweenRetries set the overall time budget (MaxRetries * SleepBetweenRetries) for deleting
+	// each network interface, and for waiting on each network interface to be detached and deleted. Defaults to 30
+	// retries, 10 seconds apart.
+	MaxRetries          int
+	SleepBetweenRetries time.Duration
+
+	// Concurrency is the maximum number of network interfaces that are processed in parallel. Defaults to 10.
+	Concurrency int
+
+	// Backoff controls the interval between the retries of deleting each network interface, and the polling interval
+	// while waiting for each network interface to be detached and deleted. Defaults to DefaultBackoffConfig.
+	Backoff BackoffConfig
+
+	// DryRun, when true, performs all the lookups and logs every detach and delete that would happen, but sends the calls
+	// that modify resources with the DryRun flag so that only the permissions are validated.
+	DryRun bool
+
+	// Force, when true, skips the safety checks before cleaning up the security groups: that the EKS cluster is deleted,
+	// and that the given security group belongs to the cluster.
+	Force bool
+
+	// TagFilters maps tag keys to values, to additionally clean up the security groups in the VPC that carry any of
+	// these tags. An empty value matches any value of the tag. The security groups tagged by the AWS Load Balancer
+	// Controller (elbv2.k8s.aws/cluster) and the legacy ALB ingress controller (kubernetes.io/cluster-name) with the
+	// name of the cluster are always cleaned up.
+	TagFilters map[string]string
+
+	// SkipLoadBalancerSweep, when true, only cleans up the given security group and its network interfaces, without
+	// looking up and deleting the security groups of the load balancer controllers and the ones matching TagFilters.
+	// Use this for clusters that do not use ALB ingress, so that a security group that carries the tags of the cluster
+	// for other reasons is kept.
+	SkipLoadBalancerSweep bool
+
+	// OverallTimeout is the deadline for the whole cleanup, across all the phases and security groups. When it passes,
+	// the cleanup is aborted, and the partial result is returned with a CleanupTimeoutError listing the resources that
+	// are not cleaned up yet. Zero means no deadline.
+	OverallTimeout time.Duration
+
+	// Region overrides the region of the EC2 API calls, for setups where the security groups live in a different region
+	// than the one in the cluster ARN (e.g., a security group referenced from a peered region). The cluster ARN is still
+	// used for the name of the cluster and for checking that the cluster is deleted. Defaults to the region of the
+	// cluster ARN.
+	Region string
+}
+
+// DefaultCleanupOptions returns the default options for CleanupSecurityGroup.
+func DefaultCleanupOptions() CleanupOptions {
+	return CleanupOptions{
+		MaxRetries:          waitMaxRetries,
+		SleepBetweenRetries: waitSleepBetweenRetries,
+		Concurrency:         DefaultCleanupConcurrency,
+		Backoff:             DefaultBackoffConfig(),
+	}
+}
+
+// withDefaults returns a copy of the options where the zero valued fields are replaced with the defaults.
+func (options CleanupOptions) withDefaults() CleanupOptions {
+	defaults := DefaultCleanupOptions()
+	if options.MaxRetries <= 0 {
+		options.MaxRetries = defaults.MaxRetries
+	}
+	if options.SleepBetweenRetries <= 0 {
+		options.SleepBetweenRetries = defaults.SleepBetweenRetries
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = defaults.Concurrency
+	}
+	options.Backoff = options.Backoff.withDefaults()
+	return options
+}
+
+// waitTimeout returns the overall budget for polling a single network interface when waiting for it to be detached or
+// deleted.
+func (options CleanupOptions) waitTimeout() time.Duration {
+	return time.Duration(options.MaxRetries) * options.SleepBetweenRetries
+}
+
+// CleanupResult describes the resources that were cleaned up by CleanupSecurityGroup.
+type CleanupResult struct {
+	// DeletedSecurityGroupIDs lists the IDs of the security groups that were deleted.
+	DeletedSecurityGroupIDs []string
+
+	// DeletedNetworkInterfaceIDs lists the IDs of the network interfaces that were deleted to free up the security
+	// groups.
+	DeletedNetworkInterfaceIDs []string
+
+	// AlreadyGoneSecurityGroupIDs lists the IDs of the security groups that were already deleted by the time we tried to
+	// delete them.
+	AlreadyGoneSecurityGroupIDs []string
+
+	// DryRun is true when the cleanup ran in dry run mode, in which case the deleted IDs list the resources that would
+	// have been deleted.
+	DryRun bool
+
+	// PhaseDurations records how long each phase of the cleanup took.
+	PhaseDurations CleanupPhaseDurations
+
+	// foundSecurityGroupIDs and foundNetworkInterfaceIDs record the resources that were found to clean up, to report the
+	// ones that are left when the cleanup times out.
+	foundSecurityGroupIDs    []string
+	foundNetworkInterfaceIDs []string
+}
+
+// remaining returns the IDs of the security groups and network interfaces that were found to clean up, but are not
+// deleted yet.
+func (result *CleanupResult) remaining() ([]string, []string) {
+	cleanedUpSecurityGroupIDs := append(append([]string{}, result.DeletedSecurityGroupIDs...), result.AlreadyGoneSecurityGroupIDs...)
+	remainingSecurityGroupIDs := []string{}
+	for _, groupID := range result.foundSecurityGroupIDs {
+		if !collections.ListContainsElement(cleanedUpSecurityGroupIDs, groupID) {
+			remainingSecurityGroupIDs = append(remainingSecurityGroupIDs, groupID)
+		}
+	}
+	remainingNetworkInterfaceIDs := []string{}
+	for _, networkInterfaceID := range result.foundNetworkInterfaceIDs {
+		if !collections.ListContainsElement(result.DeletedNetworkInterfaceIDs, networkInterfaceID) {
+			remainingNetworkInterfaceIDs = append(remainingNetworkInterfaceIDs, networkInterfaceID)
+		}
+	}
+	return remainingSecurityGroupIDs, remainingNetworkInterfaceIDs
+}
+
+// CleanupPhaseDurations records the time spent in each phase of CleanupSecurityGroup. The network interface phases are
+// summed across all the security groups that were cleaned up, and so overlap with LoadBalancerSweep, which covers
+// everything done for the Load Balancer Controller's security groups (and the ones with the custom tags).
+type CleanupPhaseDurations struct {
+	// Describe is the time spent looking up the security groups and their network interfaces.
+	Describe time.Duration
+
+	// Detach is the time spent detaching the network interfaces.
+	Detach time.Duration
+
+	// WaitDetach is the time spent waiting for the network interfaces to be detached.
+	WaitDetach time.Duration
+
+	// Delete is the time spent deleting the network interfaces and the security groups.
+	Delete time.Duration
+
+	// WaitDelete is the time spent waiting for the network interfaces to be deleted.
+	WaitDelete time.Duration
+
+	// LoadBalancerSweep is the time spent revoking the rules of and deleting the Load Balancer Controller's security
+	// groups.
+	LoadBalancerSweep time.Duration
+
+	// Total is the time the whole cleanup took.
+	Total time.Duration
+}
+
+// String returns a summary of the phase durations, rounded to a tenth of a second.
+func (durations CleanupPhaseDurations) String() string {
+	round := func(duration time.Duration) time.Duration { return duration.Round(100 * time.Millisecond) }
+	return fmt.Sprintf(
+		"%s (describe %s, detach %s, wait-detach %s, delete %s, wait-delete %s, load balancer sweep %s)",
+		round(durations.Total),
+		round(durations.Describe),
+		round(durations.Detach),
+		round(durations.WaitDetach),
+		round(durations.Delete),
+		round(durations.WaitDelete),
+		round(durations.LoadBalancerSweep),
+	)
+}
+
 // CleanupSecurityGroup deletes the AWS EKS managed security group, which otherwise doesn't get cleaned up when
 // destroying the EKS cluster. It also attempts to delete the security group left by ALB ingress controller, if applicable.
+// As deleting the security groups of a running cluster breaks the cluster, this refuses to proceed with a
+// ClusterNotDeletedError unless the EKS cluster no longer exists or is in the DELETING or FAILED state. Set Force in the
+// options to skip this check. If the VPC no longer exists, the security groups are considered already cleaned up: a
+// warning is logged and this returns without an error.
+// Refer to CleanupOptions for the available settings to control how the dependencies of each security group are
+// cleared. On success, the returned CleanupResult lists the resources that were deleted. Cancelling the context aborts
+// the cleanup, including any in flight AWS API calls and retry loops, and returns the context error. Set OverallTimeout in
+// the options to bound the whole cleanup: if it runs out, the partial result is returned along with a
+// CleanupTimeoutError, and the cleanup can be resumed by running it again.
+// The securityGroupID and vpcID can be left empty to look them up from the resourcesVpcConfig of the EKS cluster, which
+// only works while the cluster is still being deleted. Once the cluster is fully deleted, this returns a
+// ClusterVPCConfigNotFoundError, and the IDs must be passed in explicitly.
 func CleanupSecurityGroup(
+	ctx context.Context,
 	clusterArn string,
 	securityGroupID string,
 	vpcID string,
-) error {
-	logger := logging.GetProjectLogger()
+	options CleanupOptions,
+) (*CleanupResult, error) {
+	logger := logging.GetProjectLogger().WithField("clusterArn", clusterArn)
+	options = options.withDefaults()
 
 	region, err := eksawshelper.GetRegionFromArn(clusterArn)
 	if err != nil {
-		return errors.WithStackTrace(err)
+		return nil, errors.WithStackTrace(err)
 	}
 
 	clusterID, err := eksawshelper.GetClusterNameFromArn(clusterArn)
 	if err != nil {
-		return errors.WithStackTrace(err)
+		return nil, errors.WithStackTrace(err)
 	}
 
-	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	sess, err := eksawshelper.NewAuthenticatedSession(cleanupRegion(logger, region, options.Region))
 	if err != nil {
-		return errors.WithStackTrace(err)
+		return nil, errors.WithStackTrace(err)
 	}
-	ec2Svc := ec2.New(sess)
+	ec2Svc := eksawshelper.NewEC2Client(sess)
 	logger.Infof("Successfully authenticated with AWS")
 
-	// 1. Delete provided EKS security group
-	err = deleteDependencies(ec2Svc, securityGroupID)
+	// The cluster is described once, both to check that it is deleted and to look up the IDs that are not provided.
+	var clusterInfo *eksawshelper.ClusterInfo
+	if !options.Force || securityGroupID == "" || vpcID == "" {
+		clusterInfo, err = lookupClusterInfo(clusterArn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Force {
+		logger.Warn("Skipping the check that the EKS cluster is deleted.")
+	} else if err := verifyClusterDeleted(clusterArn, clusterInfo); err != nil {
+		return nil, err
+	}
+
+	securityGroupID, vpcID, err = lookupClusterVPCConfig(clusterArn, clusterInfo, securityGroupID, vpcID)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Force {
+		logger.Warn("Skipping the check that the security group belongs to the EKS cluster.")
+	} else if err := verifySecurityGroupOfCluster(ctx, ec2Svc, clusterID, clusterInfo, securityGroupID); err != nil {
+		return nil, err
+	}
+
+	if options.DryRun {
+		logger.Infof("Running in dry run mode: no resources will be modified")
+	}
+	return cleanupClusterSecurityGroups(ctx, ec2Svc, clusterID, securityGroupID, vpcID, options)
+}
+
+// cleanupRegion returns the region to use for the EC2 API calls of the cleanup, which is the override region when set,
+// or else the region of the cluster ARN. A warning is logged when the override differs from the region of the ARN, so
+// that a mistake in the override is visible.
+func cleanupRegion(logger *logrus.Entry, arnRegion string, overrideRegion string) string {
+	if overrideRegion == "" || overrideRegion == arnRegion {
+		return arnRegion
+	}
+	logger.Warnf("Using region %s for the EC2 API calls instead of the region %s of the EKS cluster ARN.", overrideRegion, arnRegion)
+	return overrideRegion
+}
+
+// cleanupClusterSecurityGroups deletes the given EKS security group, along with the other security groups of the cluster
+// in the VPC. If the VPC no longer exists, this logs a warning and returns the resources that were cleaned up so far
+// without an error. If the overall timeout of the options runs out, this returns the resources that were cleaned up so
+// far along with a CleanupTimeoutError.
+func cleanupClusterSecurityGroups(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	clusterID string,
+	securityGroupID string,
+	vpcID string,
+	options CleanupOptions,
+) (*CleanupResult, error) {
+	logger := logging.GetProjectLogger()
+
+	cleanupCtx := ctx
+	if options.OverallTimeout > 0 {
+		var cancel context.CancelFunc
+		cleanupCtx, cancel = context.WithTimeout(ctx, options.OverallTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result := &CleanupResult{DryRun: options.DryRun}
+	err := cleanupClusterSecurityGroupsInVPC(cleanupCtx, ec2Svc, clusterID, securityGroupID, vpcID, options, result)
+	result.PhaseDurations.Total = time.Since(start)
+	metrics.ObservePhaseDuration(cleanupMetricsOperation, "total", result.PhaseDurations.Total)
+	switch {
+	case isVPCNotFoundErr(err):
+		logger.Warnf("VPC %s no longer exists, so its security groups are already cleaned up.", vpcID)
+	case err != nil && ctx.Err() == nil && cleanupCtx.Err() == context.DeadlineExceeded:
+		remainingSecurityGroupIDs, remainingNetworkInterfaceIDs := result.remaining()
+		logger.Errorf("Cleanup timed out after %s: %v", options.OverallTimeout, err)
+		return result, errors.WithStackTrace(CleanupTimeoutError{
+			Timeout:                      options.OverallTimeout,
+			RemainingSecurityGroupIDs:    remainingSecurityGroupIDs,
+			RemainingNetworkInterfaceIDs: remainingNetworkInterfaceIDs,
+		})
+	case err != nil:
+		return nil, err
+	}
+	logger.Infof("Cleanup completed in %s", result.PhaseDurations)
+	return result, nil
+}
+
+// cleanupClusterSecurityGroupsInVPC runs the steps of cleanupClusterSecurityGroups, recording the resources that were
+// deleted in the result.
+func cleanupClusterSecurityGroupsInVPC(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	clusterID string,
+	securityGroupID string,
+	vpcID string,
+	options CleanupOptions,
+	result *CleanupResult,
+) error {
+	logger := logging.GetProjectLogger()
+	durations := &result.PhaseDurations
+	result.foundSecurityGroupIDs = append(result.foundSecurityGroupIDs, securityGroupID)
+
+	if options.SkipLoadBalancerSweep {
+		if len(options.TagFilters) > 0 {
+			logger.Warnf("Ignoring the tag filters %v, as the sweep of the security groups by tag is skipped.", options.TagFilters)
+		}
+		logger.Info("Skipping the sweep of the security groups of the load balancer controllers.")
+		return cleanupSecurityGroup(ctx, ec2Svc, securityGroupID, options, result)
+	}
+
+	// 1. Look up Load Balancer Controller's security groups, and the ones with the custom tags, if they exist
+	describeStart := time.Now()
+	securityGroups, err := lookupSecurityGroups(ctx, ec2Svc, vpcID, clusterID, options.TagFilters, securityGroupID)
+	durations.Describe += time.Since(describeStart)
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
 
-	logger.Infof("Deleting security group %s", securityGroupID)
-	delSGInput := &ec2.DeleteSecurityGroupInput{
-		GroupId: aws.String(securityGroupID),
+	// 2. Revoke the rules in the Load Balancer Controller's security groups that reference the other security groups we
+	// are deleting, as AWS blocks deleting a security group that is referenced in the rules of another group. This way,
+	// the order in which the security groups are deleted does not matter.
+	revokeStart := time.Now()
+	clusterSecurityGroupIDs := []string{securityGroupID}
+	for _, sg := range securityGroups {
+		clusterSecurityGroupIDs = append(clusterSecurityGroupIDs, aws.StringValue(sg.GroupId))
+	}
+	result.foundSecurityGroupIDs = clusterSecurityGroupIDs
+	err = revokeCrossReferencingRules(ctx, ec2Svc, securityGroups, clusterSecurityGroupIDs, options.DryRun)
+	durations.LoadBalancerSweep += time.Since(revokeStart)
+	if err != nil {
+		return err
+	}
+
+	// 3. Delete provided EKS security group
+	if err := cleanupSecurityGroup(ctx, ec2Svc, securityGroupID, options, result); err != nil {
+		return err
+	}
+
+	// 4. Delete Load Balancer Controller's security groups, and the ones with the custom tags
+	sweepStart := time.Now()
+	defer func() { durations.LoadBalancerSweep += time.Since(sweepStart) }()
+	for _, sg := range securityGroups {
+		if err := cleanupSecurityGroup(ctx, ec2Svc, aws.StringValue(sg.GroupId), options, result); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookupClusterInfo returns the details of the EKS cluster, or nil if the cluster no longer exists.
+func lookupClusterInfo(clusterArn string) (*eksawshelper.ClusterInfo, error) {
+	clusterInfo, err := eksawshelper.GetClusterInfo(clusterArn)
+	if _, isNotFoundErr := errors.Unwrap(err).(eksawshelper.ClusterNotFoundError); isNotFoundErr {
+		return nil, nil
+	}
+	return clusterInfo, err
+}
+
+// lookupClusterVPCConfig returns the given security group and VPC IDs, filling in the ones that are empty with the
+// cluster security group and the VPC of the EKS cluster. Returns a ClusterVPCConfigNotFoundError if an ID needs to be
+// looked up, but the cluster no longer exists (clusterInfo is nil).
+func lookupClusterVPCConfig(
+	clusterArn string,
+	clusterInfo *eksawshelper.ClusterInfo,
+	securityGroupID string,
+	vpcID string,
+) (string, string, error) {
+	if securityGroupID != "" && vpcID != "" {
+		return securityGroupID, vpcID, nil
+	}
+	if clusterInfo == nil {
+		return "", "", errors.WithStackTrace(ClusterVPCConfigNotFoundError{ClusterArn: clusterArn})
+	}
+	logger := logging.GetProjectLogger().WithField("clusterArn", clusterArn)
+
+	if securityGroupID == "" {
+		securityGroupID = clusterInfo.ClusterSecurityGroupID
+		logger.Infof("Found cluster security group %s", securityGroupID)
+	}
+	if vpcID == "" {
+		vpcID = clusterInfo.VPCID
+		logger.Infof("Found VPC %s", vpcID)
+	}
+	if securityGroupID == "" || vpcID == "" {
+		return "", "", errors.WithStackTrace(ClusterVPCConfigNotFoundError{ClusterArn: clusterArn})
+	}
+	return securityGroupID, vpcID, nil
+}
+
+// verifySecurityGroupOfCluster returns a SecurityGroupNotOfClusterError unless the security group is the cluster security
+// group of the EKS cluster, or is tagged with the name of the cluster (see securityGroupTaggedForCluster), so that
+// passing in the ID of an unrelated security group does not delete it. A security group that no longer exists passes
+// the check, as there is nothing to delete. The clusterInfo is nil if the cluster no longer exists, in which case only the
+// tags are checked.
+func verifySecurityGroupOfCluster(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	clusterID string,
+	clusterInfo *eksawshelper.ClusterInfo,
+	securityGroupID string,
+) error {
+	if clusterInfo != nil && clusterInfo.ClusterSecurityGroupID == securityGroupID {
+		return nil
+	}
+
+	output, err := ec2Svc.DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{
+		GroupIds: aws.StringSlice([]string{securityGroupID}),
+	})
+	if isSGNotFoundErr(err) {
+		return nil
 	}
-	_, err = ec2Svc.DeleteSecurityGroup(delSGInput)
 	if err != nil {
-		if awsErr, isAwsErr := err.(awserr.Error); isAwsErr && awsErr.Code() == "InvalidGroup.NotFound" {
-			logger.Infof("Security group %s already deleted.", securityGroupID)
+		return errors.WithStackTrace(err)
+	}
+	for _, sg := range output.SecurityGroups {
+		if aws.StringValue(sg.GroupId) == securityGroupID && securityGroupTaggedForCluster(sg.Tags, clusterID) {
 			return nil
 		}
+	}
+	return errors.WithStackTrace(SecurityGroupNotOfClusterError{SecurityGroupID: securityGroupID, ClusterName: clusterID})
+}
+
+// securityGroupTaggedForCluster returns true if any of the tags references the named cluster: the tags that EKS and the
+// load balancer controllers set to the name of the cluster, or a kubernetes.io/cluster/<name> tag with any value.
+func securityGroupTaggedForCluster(tags []*ec2.Tag, clusterName string) bool {
+	tagKeys := append([]string{eksClusterSecurityGroupTagKey}, defaultSecurityGroupTagKeys...)
+	for _, tag := range tags {
+		key := aws.StringValue(tag.Key)
+		if collections.ListContainsElement(tagKeys, key) && aws.StringValue(tag.Value) == clusterName {
+			return true
+		}
+		if key == clusterOwnershipTagKeyPrefix+clusterName {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyClusterDeleted returns a ClusterNotDeletedError unless the EKS cluster no longer exists (clusterInfo is nil), or
+// is in the DELETING or FAILED state.
+func verifyClusterDeleted(clusterArn string, clusterInfo *eksawshelper.ClusterInfo) error {
+	logger := logging.GetProjectLogger().WithField("clusterArn", clusterArn)
+
+	if clusterInfo == nil {
+		logger.Info("EKS cluster is deleted.")
+		return nil
+	}
+
+	status := clusterInfo.Status
+	if status != eks.ClusterStatusDeleting && status != eks.ClusterStatusFailed {
+		return errors.WithStackTrace(ClusterNotDeletedError{ClusterArn: clusterArn, Status: status})
+	}
+	logger.Infof("EKS cluster is in %s state.", status)
+	return nil
+}
+
+// cleanupSecurityGroup deletes the dependencies of the given security group and then the security group itself,
+// recording the resources that were deleted in the result.
+func cleanupSecurityGroup(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	securityGroupID string,
+	options CleanupOptions,
+	result *CleanupResult,
+) error {
+	logger := logging.GetProjectLogger().WithField("securityGroupID", securityGroupID)
+	dryRun := options.DryRun
+
+	deletedNetworkInterfaceIDs, err := deleteDependencies(ctx, ec2Svc, securityGroupID, options, result)
+	result.DeletedNetworkInterfaceIDs = append(result.DeletedNetworkInterfaceIDs, deletedNetworkInterfaceIDs...)
+	if err != nil {
 		return errors.WithStackTrace(err)
 	}
-	logger.Infof("Successfully deleted security group with name=%s", securityGroupID)
 
-	// 2, Delete Load Balancer Controller's security group, if it exists
-	sgResult, err := lookupSecurityGroup(ec2Svc, vpcID, clusterID)
+	logger.Info("Deleting security group")
+	input := &ec2.DeleteSecurityGroupInput{
+		GroupId: aws.String(securityGroupID),
+		DryRun:  aws.Bool(dryRun),
+	}
+
+	// The delete can fail with DependencyViolation if there are still resources referencing the security group, which
+	// may be resources that are in the process of being deleted (eventual consistency), or resources that are not
+	// managed by this routine. We retry the delete in that case, tracking the blocking dependencies so that we can
+	// report them if the delete never succeeds.
+	deleteStart := time.Now()
+	alreadyGone := false
+	var blockingDependencies *securityGroupDependencies
+	err = doWithBackoff(
+		ctx,
+		logger,
+		fmt.Sprintf("Delete security group %s", securityGroupID),
+		options.Backoff, options.waitTimeout(),
+		func() error {
+			_, err := ec2Svc.DeleteSecurityGroupWithContext(ctx, input)
+
+			switch {
+			case err == nil:
+				return nil // exit retry loop with success
+
+			case dryRun && isDryRunOperationErr(err):
+				return nil // exit retry loop with success
+
+			case isSGNotFoundErr(err):
+				alreadyGone = true
+				return nil // exit retry loop with success
+
+			case isDependencyViolationErr(err):
+				dependencies, lookupErr := findSecurityGroupDependencies(ctx, ec2Svc, securityGroupID)
+				if lookupErr != nil {
+					return retry.FatalError{Underlying: lookupErr} // halt retries with error
+				}
+				blockingDependencies = dependencies
+				logger.Debugf(
+					"Security group is still referenced by network interfaces %v and security groups %v",
+					dependencies.networkInterfaceIDs,
+					dependencies.securityGroupIDs,
+				)
+				return errors.WithStackTrace(err) // continue retrying
+
+			default:
+				return retry.FatalError{Underlying: err} // halt retries with error
+			}
+		})
+	result.PhaseDurations.Delete += time.Since(deleteStart)
 	if err != nil {
+		if isMaxRetriesExceededErr(err) && blockingDependencies != nil {
+			return errors.WithStackTrace(SecurityGroupDependencyViolationError{
+				SecurityGroupID:             securityGroupID,
+				NetworkInterfaceIDs:         blockingDependencies.networkInterfaceIDs,
+				ReferencingSecurityGroupIDs: blockingDependencies.securityGroupIDs,
+			})
+		}
+		if fatalErr, isFatalErr := err.(retry.FatalError); isFatalErr {
+			return errors.WithStackTrace(fatalErr.Underlying)
+		}
 		return errors.WithStackTrace(err)
 	}
 
-	for _, result := range sgResult.SecurityGroups {
-		groupID := aws.StringValue(result.GroupId)
-		groupName := aws.StringValue(result.GroupName)
+	switch {
+	case alreadyGone:
+		logger.Info("Security group already deleted.")
+		result.AlreadyGoneSecurityGroupIDs = append(result.AlreadyGoneSecurityGroupIDs, securityGroupID)
+	case dryRun:
+		logger.Info("(Dry run) Would delete security group")
+		result.DeletedSecurityGroupIDs = append(result.DeletedSecurityGroupIDs, securityGroupID)
+	default:
+		logger.Info("Successfully deleted security group")
+		result.DeletedSecurityGroupIDs = append(result.DeletedSecurityGroupIDs, securityGroupID)
+	}
+	return nil
+}
 
-		err = deleteDependencies(ec2Svc, groupID)
-		if err != nil {
-			return errors.WithStackTrace(err)
+// revokeCrossReferencingRules revokes the ingress and egress rules of the given security groups that reference any of the
+// other security groups in referencedGroupIDs. Rules that reference the group itself are left alone, as they do not block
+// the deletion of the group.
+func revokeCrossReferencingRules(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	securityGroups []*ec2.SecurityGroup,
+	referencedGroupIDs []string,
+	dryRun bool,
+) error {
+	logger := logging.GetProjectLogger()
+
+	for _, sg := range securityGroups {
+		groupID := aws.StringValue(sg.GroupId)
+		groupLogger := logger.WithField("securityGroupID", groupID)
+
+		ingress := filterPermissionsReferencingGroups(sg.IpPermissions, groupID, referencedGroupIDs)
+		if len(ingress) > 0 {
+			groupLogger.Infof("Revoking %d ingress rules of security group that reference other cluster security groups", len(ingress))
+			_, err := ec2Svc.RevokeSecurityGroupIngressWithContext(ctx, &ec2.RevokeSecurityGroupIngressInput{
+				GroupId:       sg.GroupId,
+				IpPermissions: ingress,
+				DryRun:        aws.Bool(dryRun),
+			})
+			if err := handleRevokeErr(err, groupID, dryRun); err != nil {
+				return err
+			}
 		}
 
-		input := &ec2.DeleteSecurityGroupInput{GroupId: aws.String(groupID)}
-		_, err := ec2Svc.DeleteSecurityGroup(input)
-		if err != nil {
-			if awsErr, isAwsErr := err.(awserr.Error); isAwsErr && awsErr.Code() == "InvalidGroup.NotFound" {
-				logger.Infof("Security group %s already deleted.", groupID)
-				return nil
+		egress := filterPermissionsReferencingGroups(sg.IpPermissionsEgress, groupID, referencedGroupIDs)
+		if len(egress) > 0 {
+			groupLogger.Infof("Revoking %d egress rules of security group that reference other cluster security groups", len(egress))
+			_, err := ec2Svc.RevokeSecurityGroupEgressWithContext(ctx, &ec2.RevokeSecurityGroupEgressInput{
+				GroupId:       sg.GroupId,
+				IpPermissions: egress,
+				DryRun:        aws.Bool(dryRun),
+			})
+			if err := handleRevokeErr(err, groupID, dryRun); err != nil {
+				return err
 			}
-			return errors.WithStackTrace(err)
 		}
+	}
+	return nil
+}
 
-		logger.Infof("Successfully deleted security group with name=%s, id=%s", groupID, groupName)
+// handleRevokeErr interprets the error from revoking security group rules, treating the cases where there is nothing
+// left to revoke as success.
+func handleRevokeErr(err error, groupID string, dryRun bool) error {
+	logger := logging.GetProjectLogger().WithField("securityGroupID", groupID)
+
+	awsErr, isAwsErr := err.(awserr.Error)
+	switch {
+	case err == nil:
+		logger.Info("Successfully revoked rules of security group")
+		return nil
+	case dryRun && isDryRunOperationErr(err):
+		logger.Info("(Dry run) Would revoke rules of security group")
+		return nil
+	case isSGNotFoundErr(err):
+		logger.Info("Security group already deleted.")
+		return nil
+	case isAwsErr && awsErr.Code() == "InvalidPermission.NotFound":
+		logger.Info("Rules of security group already revoked.")
+		return nil
+	default:
+		return errors.WithStackTrace(err)
 	}
+}
 
-	return nil
+// filterPermissionsReferencingGroups returns the subset of the given permissions that reference any of the groups in
+// referencedGroupIDs, other than the group that owns the permissions. Only the matching UserIdGroupPairs are kept on
+// each returned permission, so that revoking them does not affect the other sources of the rule (e.g., CIDR blocks).
+func filterPermissionsReferencingGroups(
+	permissions []*ec2.IpPermission,
+	ownerGroupID string,
+	referencedGroupIDs []string,
+) []*ec2.IpPermission {
+	filtered := []*ec2.IpPermission{}
+	for _, permission := range permissions {
+		pairs := []*ec2.UserIdGroupPair{}
+		for _, pair := range permission.UserIdGroupPairs {
+			pairGroupID := aws.StringValue(pair.GroupId)
+			if pairGroupID != ownerGroupID && collections.ListContainsElement(referencedGroupIDs, pairGroupID) {
+				pairs = append(pairs, pair)
+			}
+		}
+		if len(pairs) > 0 {
+			filtered = append(filtered, &ec2.IpPermission{
+				IpProtocol:       permission.IpProtocol,
+				FromPort:         permission.FromPort,
+				ToPort:           permission.ToPort,
+				UserIdGroupPairs: pairs,
+			})
+		}
+	}
+	return filtered
+}
+
+// securityGroupDependencies lists the resources that reference a security group, preventing it from being deleted.
+type securityGroupDependencies struct {
+	networkInterfaceIDs []string
+	securityGroupIDs    []string
+}
+
+// findSecurityGroupDependencies looks up the resources that still reference the given security group: the network
+// interfaces that use the group, and the other security groups that have ingress or egress rules referencing the group.
+func findSecurityGroupDependencies(ctx context.Context, ec2Svc ec2iface.EC2API, securityGroupID string) (*securityGroupDependencies, error) {
+	dependencies := &securityGroupDependencies{
+		networkInterfaceIDs: []string{},
+		securityGroupIDs:    []string{},
+	}
+
+	networkInterfaces, err := findNetworkInterfaces(ctx, ec2Svc, securityGroupID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ni := range networkInterfaces {
+		dependencies.networkInterfaceIDs = append(dependencies.networkInterfaceIDs, aws.StringValue(ni.NetworkInterfaceId))
+	}
+
+	seen := map[string]bool{securityGroupID: true}
+	for _, filterName := range []string{"ip-permission.group-id", "egress.ip-permission.group-id"} {
+		input := &ec2.DescribeSecurityGroupsInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String(filterName),
+					Values: []*string{aws.String(securityGroupID)},
+				},
+			},
+		}
+		// Handle pagination by repeatedly making the API call while there is a next token set.
+		for {
+			sgResult, err := ec2Svc.DescribeSecurityGroupsWithContext(ctx, input)
+			if err != nil {
+				return nil, errors.WithStackTrace(err)
+			}
+			for _, sg := range sgResult.SecurityGroups {
+				groupID := aws.StringValue(sg.GroupId)
+				if !seen[groupID] {
+					seen[groupID] = true
+					dependencies.securityGroupIDs = append(dependencies.securityGroupIDs, groupID)
+				}
+			}
+			if sgResult.NextToken == nil {
+				break
+			}
+			input.NextToken = sgResult.NextToken
+		}
+	}
+	return dependencies, nil
 }
 
 // Detach and delete elastic network interfaces used by the security group
-// so that the security group can be deleted.
-func deleteDependencies(ec2Svc *ec2.EC2, securityGroupID string) error {
-	networkInterfacesResult, err := findNetworkInterfaces(ec2Svc, securityGroupID)
+// so that the security group can be deleted. Returns the IDs of the network interfaces that were deleted. In dry run
+// mode, the detach and delete calls are only validated and the waits are skipped. The network interfaces that are found
+// and the time spent in each phase are recorded in the given result.
+func deleteDependencies(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	securityGroupID string,
+	options CleanupOptions,
+	result *CleanupResult,
+) ([]string, error) {
+	durations := &result.PhaseDurations
+
+	describeStart := time.Now()
+	networkInterfaces, err := findNetworkInterfaces(ctx, ec2Svc, securityGroupID)
+	durations.Describe += time.Since(describeStart)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	for _, ni := range networkInterfaces {
+		result.foundNetworkInterfaceIDs = append(result.foundNetworkInterfaceIDs, aws.StringValue(ni.NetworkInterfaceId))
 	}
 
-	err = detachNetworkInterfaces(ec2Svc, networkInterfacesResult, securityGroupID)
+	logger := logging.GetProjectLogger().WithField("securityGroupID", securityGroupID)
+	return detachAndDeleteNetworkInterfaces(ctx, ec2Svc, logger, networkInterfaces, options, durations)
+}
+
+// detachAndDeleteNetworkInterfaces detaches the given network interfaces, waits for them to be detached, and then
+// deletes them and waits for the deletion to complete. The fields of the logger are attached to the logs of each network
+// interface. Returns the IDs of the network interfaces that were deleted. In dry run mode, the detach and delete calls
+// are only validated and the waits are skipped. The time spent in each phase is added to the given durations.
+func detachAndDeleteNetworkInterfaces(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	logger *logrus.Entry,
+	networkInterfaces []*ec2.NetworkInterface,
+	options CleanupOptions,
+	durations *CleanupPhaseDurations,
+) ([]string, error) {
+	concurrency := options.Concurrency
+	dryRun := options.DryRun
+	// The overall progress is always logged, while the logs of each network interface are silenced in quiet mode.
+	niLogger := logging.ProgressLogger(logger)
+
+	phaseStart := time.Now()
+	err := detachNetworkInterfaces(ctx, ec2Svc, niLogger, networkInterfaces, concurrency, dryRun)
+	durations.Detach += time.Since(phaseStart)
+	metrics.ObservePhaseDuration(cleanupMetricsOperation, "detach", time.Since(phaseStart))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if len(networkInterfacesResult.NetworkInterfaces) > 0 {
-		err = waitForNetworkInterfacesToBeDetached(ec2Svc, networkInterfacesResult.NetworkInterfaces, waitMaxRetries, waitSleepBetweenRetries)
+	progress := newCleanupProgress(logger, len(networkInterfaces), cleanupProgressReportInterval)
+
+	if len(networkInterfaces) > 0 && !dryRun {
+		phaseStart = time.Now()
+		err = waitForNetworkInterfacesToBeDetached(ctx, ec2Svc, networkInterfaces, concurrency, options.Backoff, options.waitTimeout(), progress)
+		durations.WaitDetach += time.Since(phaseStart)
+		metrics.ObservePhaseDuration(cleanupMetricsOperation, "wait_detach", time.Since(phaseStart))
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	err = deleteNetworkInterfaces(ec2Svc, networkInterfacesResult, securityGroupID, waitMaxRetries, waitSleepBetweenRetries)
-	if err != nil {
-		return err
+	phaseStart = time.Now()
+	deletedNetworkInterfaceIDs, err := deleteNetworkInterfaces(ctx, ec2Svc, niLogger, networkInterfaces, concurrency, dryRun, options.Backoff, options.waitTimeout())
+	durations.Delete += time.Since(phaseStart)
+	metrics.ObservePhaseDuration(cleanupMetricsOperation, "delete", time.Since(phaseStart))
+	if err != nil || dryRun {
+		return deletedNetworkInterfaceIDs, err
 	}
 
-	err = waitForNetworkInterfacesToBeDeleted(ec2Svc, networkInterfacesResult.NetworkInterfaces, waitMaxRetries, waitSleepBetweenRetries)
+	phaseStart = time.Now()
+	err = waitForNetworkInterfacesToBeDeleted(ctx, ec2Svc, networkInterfaces, concurrency, options.Backoff, options.waitTimeout(), progress)
+	durations.WaitDelete += time.Since(phaseStart)
+	metrics.ObservePhaseDuration(cleanupMetricsOperation, "wait_delete", time.Since(phaseStart))
 	if err != nil {
-		return err
+		return deletedNetworkInterfaceIDs, err
 	}
 
-	return nil
+	return deletedNetworkInterfaceIDs, nil
 }
 
+// findNetworkInterfaces returns all the network interfaces that are associated with the given security group.
 func findNetworkInterfaces(
-	ec2Svc *ec2.EC2,
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
 	securityGroupID string,
-) (*ec2.DescribeNetworkInterfacesOutput, error) {
-	logger := logging.GetProjectLogger()
+) ([]*ec2.NetworkInterface, error) {
+	logger := logging.ProgressLogger(logging.GetProjectLogger().WithField("securityGroupID", securityGroupID))
 
 	describeNetworkInterfacesInput := &ec2.DescribeNetworkInterfacesInput{
 		Filters: []*ec2.Filter{
@@ -144,122 +857,180 @@ func findNetworkInterfaces(
 			},
 		},
 	}
-	networkInterfacesResult, err := ec2Svc.DescribeNetworkInterfaces(describeNetworkInterfacesInput)
-	if err != nil {
-		return nil, errors.WithStackTrace(err)
+	networkInterfaces := []*ec2.NetworkInterface{}
+	// Handle pagination by repeatedly making the API call while there is a next token set.
+	for {
+		networkInterfacesResult, err := ec2Svc.DescribeNetworkInterfacesWithContext(ctx, describeNetworkInterfacesInput)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		networkInterfaces = append(networkInterfaces, networkInterfacesResult.NetworkInterfaces...)
+		if networkInterfacesResult.NextToken == nil {
+			break
+		}
+		describeNetworkInterfacesInput.NextToken = networkInterfacesResult.NextToken
 	}
-	for _, ni := range networkInterfacesResult.NetworkInterfaces {
-		logger.Infof("Found network interface %s", aws.StringValue(ni.NetworkInterfaceId))
+	for _, ni := range networkInterfaces {
+		logger.WithField("networkInterfaceId", aws.StringValue(ni.NetworkInterfaceId)).Info("Found network interface")
 	}
-	return networkInterfacesResult, nil
+	return networkInterfaces, nil
 }
 
 func detachNetworkInterfaces(
-	ec2Svc *ec2.EC2,
-	networkInterfaces *ec2.DescribeNetworkInterfacesOutput,
-	securityGroupID string,
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	logger *logrus.Entry,
+	networkInterfaces []*ec2.NetworkInterface,
+	concurrency int,
+	dryRun bool,
 ) error {
-	logger := logging.GetProjectLogger()
-
-	for _, ni := range networkInterfaces.NetworkInterfaces {
-		// First check the network interface has an attachment. It might have gotten detached before we can even process it.
-		// If it doesn't have an attachment, process the next network interface.
-		if ni.Attachment == nil || aws.StringValue(ni.Attachment.Status) == "detached" {
-			logger.Infof("Network interface %s is detached.", aws.StringValue(ni.NetworkInterfaceId))
-			continue
-		}
+	return forEachNetworkInterface(networkInterfaces, concurrency, func(ni *ec2.NetworkInterface) error {
+		return detachNetworkInterface(ctx, ec2Svc, logger, ni, dryRun)
+	})
+}
 
-		err := requestDetach(ec2Svc, ni)
-
-		switch {
-		// Base case: no error means we can process the next interface.
-		case err == nil:
-			logger.Infof("Requested to detach network interface %s for security group %s", aws.StringValue(ni.NetworkInterfaceId), securityGroupID)
-			continue
-		// The attachment is already gone, so process the next network interface.
-		case isNIAttachmentNotFoundErr(err):
-			logger.Infof("Network interface %s is detached.", aws.StringValue(ni.NetworkInterfaceId))
-			continue
-		// Any other kind of error means we failed this cleanup.
-		default:
-			return errors.WithStackTrace(err)
-		}
+func detachNetworkInterface(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	logger *logrus.Entry,
+	ni *ec2.NetworkInterface,
+	dryRun bool,
+) error {
+	logger = logger.WithField("networkInterfaceId", aws.StringValue(ni.NetworkInterfaceId))
+
+	// First check the network interface has an attachment. It might have gotten detached before we can even process it,
+	// e.g., by a previous run of the cleanup that was interrupted. If it doesn't have an attachment, there is nothing to
+	// do. If it is already being detached, we only need to wait for the detachment to complete.
+	switch {
+	case ni.Attachment == nil || aws.StringValue(ni.Attachment.Status) == ec2.AttachmentStatusDetached:
+		logger.Info("Network interface is detached.")
+		return nil
+	case aws.StringValue(ni.Attachment.Status) == ec2.AttachmentStatusDetaching:
+		logger.Info("Network interface is already being detached.")
+		return nil
 	}
 
-	return nil
+	err := requestDetach(ctx, ec2Svc, ni, dryRun)
+
+	switch {
+	// In dry run mode, the DryRunOperation error means the detach would have succeeded.
+	case dryRun && isDryRunOperationErr(err):
+		logger.Info("(Dry run) Would detach network interface")
+		return nil
+	// Base case: no error means the detach was requested.
+	case err == nil:
+		logger.Info("Requested to detach network interface")
+		metrics.AddNetworkInterfacesDetached(1)
+		return nil
+	// The attachment is already gone, so there is nothing to do.
+	case isNIAttachmentNotFoundErr(err):
+		logger.Info("Network interface is detached.")
+		return nil
+	// The network interface was deleted since we looked it up, so there is nothing to do.
+	case isNINotFoundErr(err):
+		logger.Info("Network interface is already deleted.")
+		return nil
+	// Any other kind of error means we failed this cleanup.
+	default:
+		return errors.WithStackTrace(err)
+	}
 }
 
 func deleteNetworkInterfaces(
-	ec2Svc *ec2.EC2,
-	networkInterfaces *ec2.DescribeNetworkInterfacesOutput,
-	securityGroupID string,
-	maxRetries int,
-	sleepBetweenRetries time.Duration,
-) error {
-	logger := logging.GetProjectLogger()
-
-	for _, ni := range networkInterfaces.NetworkInterfaces {
-		logger.Infof("Attempting to delete network interface %s", aws.StringValue(ni.NetworkInterfaceId))
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	logger *logrus.Entry,
+	networkInterfaces []*ec2.NetworkInterface,
+	concurrency int,
+	dryRun bool,
+	backoff BackoffConfig,
+	timeout time.Duration,
+) ([]string, error) {
+
+	// Track the network interfaces that were deleted. The mutex guards the slice, since the deletions happen
+	// concurrently.
+	var mutex sync.Mutex
+	deletedNetworkInterfaceIDs := []string{}
+
+	err := forEachNetworkInterface(networkInterfaces, concurrency, func(ni *ec2.NetworkInterface) error {
+		niLogger := logger.WithField("networkInterfaceId", aws.StringValue(ni.NetworkInterfaceId))
+		niLogger.Info("Attempting to delete network interface")
 		deleteNetworkInterfacesInput := &ec2.DeleteNetworkInterfaceInput{
 			NetworkInterfaceId: ni.NetworkInterfaceId,
+			DryRun:             aws.Bool(dryRun),
 		}
 
-		err := retry.DoWithRetry(
-			logger.Logger,
+		err := doWithBackoff(
+			ctx,
+			niLogger,
 			"Request Delete Network Interface",
-			maxRetries, sleepBetweenRetries,
+			backoff, timeout,
 			func() error {
-				_, err := ec2Svc.DeleteNetworkInterface(deleteNetworkInterfacesInput)
+				_, err := ec2Svc.DeleteNetworkInterfaceWithContext(ctx, deleteNetworkInterfacesInput)
 
 				if err == nil {
-					logger.Infof("Requested to delete network interface %s for security group %s", aws.StringValue(ni.NetworkInterfaceId), securityGroupID)
+					niLogger.Info("Requested to delete network interface")
+					metrics.AddNetworkInterfacesDeleted(1)
+					mutex.Lock()
+					deletedNetworkInterfaceIDs = append(deletedNetworkInterfaceIDs, aws.StringValue(ni.NetworkInterfaceId))
+					mutex.Unlock()
 					return nil
 				}
 
 				awsErr, isAwsErr := err.(awserr.Error)
 
 				switch {
+				// In dry run mode, the DryRunOperation error means the delete would have succeeded.
+				case dryRun && isDryRunOperationErr(err):
+					niLogger.Info("(Dry run) Would delete network interface")
+					mutex.Lock()
+					deletedNetworkInterfaceIDs = append(deletedNetworkInterfaceIDs, aws.StringValue(ni.NetworkInterfaceId))
+					mutex.Unlock()
+					return nil // exit retry loop with success
+
 				// Note: Handle InvalidNetworkInterfaceID.NotFound error. We have a process, terraformVpcCniAwareDestroy, that
 				// automatically cleans up detached ENIs. When that process runs, this loop will not be able to find those ENIs
 				// anymore. But we're also thinking about removing that process in the future, because it might be obsolete now.
 				// The process lives in terraform-aws-eks. If we handle the cleanup well in kubergrunt, we don't need that.
 				// AWS might now be set to automatically delete detached ENIs, so it's doubly not needed, and we may even remove
 				// the steps here to delete network interfaces and wait for their deletion.
-				case isAwsErr && awsErr.Code() == "InvalidNetworkInterfaceID.NotFound":
-					logger.Infof("Network interface %s is deleted.", aws.StringValue(ni.NetworkInterfaceId))
+				// This also makes rerunning an interrupted cleanup safe, as the network interfaces it deleted are skipped.
+				case isNINotFoundErr(err):
+					niLogger.Info("Network interface is deleted.")
 					return nil // exit retry loop with success
 
 				// Note: Handle InvalidParameterValue: Network interface [eni-id] is currently in use.
 				// We suspect this is an issue with eventual consistency around AWS's resource state.
 				case isAwsErr && awsErr.Code() == "InvalidParameterValue":
-					logger.Infof("Waiting for network interface %s to not be in-use (eventual consistency issue).", aws.StringValue(ni.NetworkInterfaceId))
+					niLogger.Debug("Waiting for network interface to not be in-use (eventual consistency issue).")
 					return errors.WithStackTrace(err) // continue retrying
 
 				default:
-					logger.Errorf("Error requesting deleting network interface %s", aws.StringValue(ni.NetworkInterfaceId))
+					niLogger.Error("Error requesting deleting network interface")
 					return retry.FatalError{Underlying: err} // halt retries with error
 				}
 			})
 
 		// All the retries failed or we hit a fatal error.
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+		return err
+	})
+	return deletedNetworkInterfaceIDs, err
 }
 
 func waitForNetworkInterfacesToBeDetached(
-	ec2Svc *ec2.EC2,
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
 	networkInterfaces []*ec2.NetworkInterface,
-	maxRetries int,
-	sleepBetweenRetries time.Duration,
+	concurrency int,
+	backoff BackoffConfig,
+	timeout time.Duration,
+	progress *cleanupProgress,
 ) error {
-	logger := logging.GetProjectLogger()
+	logger := logging.ProgressLogger(logging.GetProjectLogger())
 
-	for _, ni := range networkInterfaces {
-		logger.Infof("Waiting for network interface %s to reach detached state.", aws.StringValue(ni.NetworkInterfaceId))
+	return forEachNetworkInterface(networkInterfaces, concurrency, func(ni *ec2.NetworkInterface) error {
+		niLogger := logger.WithField("networkInterfaceId", aws.StringValue(ni.NetworkInterfaceId))
+		niLogger.Info("Waiting for network interface to reach detached state.")
 
 		// Poll for the new status
 		describeNetworkInterfacesInput := &ec2.DescribeNetworkInterfaceAttributeInput{
@@ -267,35 +1038,41 @@ func waitForNetworkInterfacesToBeDetached(
 			NetworkInterfaceId: ni.NetworkInterfaceId,
 		}
 
-		err := retry.DoWithRetry(
-			logger.Logger,
+		err := doWithBackoff(
+			ctx,
+			niLogger,
 			"Wait for Network Interface to be Detached",
-			maxRetries, sleepBetweenRetries,
+			backoff, timeout,
 			func() error {
-				niResult, err := ec2Svc.DescribeNetworkInterfaceAttribute(describeNetworkInterfacesInput)
+				niResult, err := ec2Svc.DescribeNetworkInterfaceAttributeWithContext(ctx, describeNetworkInterfacesInput)
 
 				switch {
 				// Yay, we're detached, process the next network interface.
 				case err == nil && isNIDetached(niResult):
-					logger.Infof("Network interface %s is detached.", aws.StringValue(ni.NetworkInterfaceId))
+					niLogger.Info("Network interface is detached.")
 					return nil // exit retry loop with success
 
 				// Since we checked whether the NI was detached in the first case, no error in this switch means the NI is
 				// not detached, so we need to retry.
 				case err == nil:
 					if niResult.Attachment != nil {
-						logger.Warnf("Network interface %s attachment status: %s", aws.StringValue(ni.NetworkInterfaceId), aws.StringValue(niResult.Attachment.Status))
+						niLogger.Debugf("Network interface attachment status: %s", aws.StringValue(niResult.Attachment.Status))
 					}
 					return errors.WithStackTrace(fmt.Errorf("Network Interface %s not detached.", aws.StringValue(ni.NetworkInterfaceId))) // continue retrying
 
 				// If the NI cannot be found, then it is already deleted so halt the loop and move on to the next NI.
 				case isNINotFoundErr(err):
-					logger.Infof("Network interface %s is already deleted.", aws.StringValue(ni.NetworkInterfaceId))
+					niLogger.Info("Network interface is already deleted.")
 					return nil // exit retry loop with success
 
+				// We are polling too fast, so back off and try again.
+				case request.IsErrorThrottle(err):
+					niLogger.Debug("Throttled while polling attachment for network interface")
+					return errors.WithStackTrace(err) // continue retrying
+
 				// All other errors are unretryable errors.
 				default:
-					logger.Errorf("Error polling attachment for network interface %s", aws.StringValue(ni.NetworkInterfaceId))
+					niLogger.Error("Error polling attachment for network interface")
 					return retry.FatalError{Underlying: err} // halt retries with error
 				}
 			})
@@ -307,44 +1084,60 @@ func waitForNetworkInterfacesToBeDetached(
 			}
 			return err
 		}
-	}
-
-	return nil
+		progress.markDetached()
+		return nil
+	})
 }
 
 func waitForNetworkInterfacesToBeDeleted(
-	ec2Svc *ec2.EC2,
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
 	networkInterfaces []*ec2.NetworkInterface,
-	maxRetries int,
-	sleepBetweenRetries time.Duration,
+	concurrency int,
+	backoff BackoffConfig,
+	timeout time.Duration,
+	progress *cleanupProgress,
 ) error {
-	logger := logging.GetProjectLogger()
+	logger := logging.ProgressLogger(logging.GetProjectLogger())
 
-	for _, ni := range networkInterfaces {
-		logger.Infof("Waiting for network interface %s to be deleted.", aws.StringValue(ni.NetworkInterfaceId))
+	return forEachNetworkInterface(networkInterfaces, concurrency, func(ni *ec2.NetworkInterface) error {
+		niLogger := logger.WithField("networkInterfaceId", aws.StringValue(ni.NetworkInterfaceId))
+		niLogger.Info("Waiting for network interface to be deleted.")
 
 		// Poll for the new status
 		describeNetworkInterfacesInput := &ec2.DescribeNetworkInterfacesInput{
 			NetworkInterfaceIds: []*string{ni.NetworkInterfaceId},
 		}
 
-		err := retry.DoWithRetry(
-			logger.Logger,
+		// Track the last status we saw, so that we can report it if the network interface never gets deleted.
+		lastStatus := aws.StringValue(ni.Status)
+
+		err := doWithBackoff(
+			ctx,
+			niLogger,
 			"Wait for Network Interface to be Deleted",
-			maxRetries, sleepBetweenRetries,
+			backoff, timeout,
 			func() error {
-				_, err := ec2Svc.DescribeNetworkInterfaces(describeNetworkInterfacesInput)
+				niResult, err := ec2Svc.DescribeNetworkInterfacesWithContext(ctx, describeNetworkInterfacesInput)
 
 				switch {
 				// If there's no error, we have to keep trying.
 				case err == nil:
+					for _, describedNI := range niResult.NetworkInterfaces {
+						lastStatus = aws.StringValue(describedNI.Status)
+					}
 					return errors.WithStackTrace(fmt.Errorf("Network Interface %s not deleted.", aws.StringValue(ni.NetworkInterfaceId))) // continue retrying
 
 				// Yay, it's deleted, process the next network interface.
 				case isNINotFoundErr(err):
-					logger.Infof("Network interface %s is deleted.", aws.StringValue(ni.NetworkInterfaceId))
+					niLogger.Info("Network interface is deleted.")
 					return nil // exit retry loop with success
 
+				// We are polling too fast, so back off and try again.
+				case request.IsErrorThrottle(err):
+					niLogger.Debug("Throttled while polling network interface")
+					return errors.WithStackTrace(err) // continue retrying
+
 				default:
 					return retry.FatalError{Underlying: err} // halt retries with error
 				}
@@ -353,42 +1146,193 @@ func waitForNetworkInterfacesToBeDeleted(
 		// All the retries failed or we hit a fatal error.
 		if err != nil {
 			if isMaxRetriesExceededErr(err) {
-				return errors.WithStackTrace(NetworkInterfaceDeletedTimeoutError{aws.StringValue(ni.NetworkInterfaceId)})
+				return errors.WithStackTrace(NetworkInterfaceDeletedTimeoutError{
+					NetworkInterfaceID: aws.StringValue(ni.NetworkInterfaceId),
+					LastStatus:         lastStatus,
+				})
 			}
 			return err
 		}
+		progress.markDeleted()
+		return nil
+	})
+}
+
+// cleanupProgress tracks how many of the network interfaces of a security group have been detached and deleted, and
+// logs the progress across both phases as the wait loops advance, so that operators can tell the cleanup is not stuck.
+// To avoid flooding the logs, the progress is logged at most once every reportInterval, in addition to when each phase
+// completes. This is safe to update from concurrent goroutines.
+type cleanupProgress struct {
+	logger         *logrus.Entry
+	total          int
+	reportInterval time.Duration
+
+	mutex        sync.Mutex
+	detached     int
+	deleted      int
+	lastReported time.Time
+}
+
+func newCleanupProgress(logger *logrus.Entry, total int, reportInterval time.Duration) *cleanupProgress {
+	return &cleanupProgress{
+		logger:         logger,
+		total:          total,
+		reportInterval: reportInterval,
 	}
+}
 
-	return nil
+// markDetached records that another network interface reached the detached state.
+func (progress *cleanupProgress) markDetached() {
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+	progress.detached++
+	progress.report(progress.detached == progress.total)
+}
+
+// markDeleted records that another network interface was deleted.
+func (progress *cleanupProgress) markDeleted() {
+	progress.mutex.Lock()
+	defer progress.mutex.Unlock()
+	progress.deleted++
+	progress.report(progress.deleted == progress.total)
+}
+
+// report logs the current progress if the report interval has passed since the last report, or if force is true. The
+// caller must hold the mutex.
+func (progress *cleanupProgress) report(force bool) {
+	now := time.Now()
+	if !force && now.Sub(progress.lastReported) < progress.reportInterval {
+		return
+	}
+	progress.lastReported = now
+
+	// Each network interface goes through two phases: detach and delete.
+	percentComplete := 100 * (progress.detached + progress.deleted) / (2 * progress.total)
+	progress.logger.Infof(
+		"Detached %d/%d and deleted %d/%d network interfaces (%d%% complete)",
+		progress.detached, progress.total,
+		progress.deleted, progress.total,
+		percentComplete,
+	)
+}
+
+// forEachNetworkInterface calls fn on each of the given network interfaces, running up to concurrency calls in parallel.
+// Unlike a sequential loop, this does not halt on the first failure: every network interface is processed, and the
+// errors from all the calls are collected into a single error.
+func forEachNetworkInterface(
+	networkInterfaces []*ec2.NetworkInterface,
+	concurrency int,
+	fn func(ni *ec2.NetworkInterface) error,
+) error {
+	logger := logging.GetProjectLogger()
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Asynchronously process each network interface, collecting each goroutine error in channels. The semaphore
+	// channel bounds the number of goroutines that are actively making API calls at any given time.
+	wg := new(sync.WaitGroup)
+	wg.Add(len(networkInterfaces))
+	semaphore := make(chan struct{}, concurrency)
+	errChans := make([]chan error, len(networkInterfaces))
+	for i, ni := range networkInterfaces {
+		errChan := make(chan error, 1)
+		errChans[i] = errChan
+		go func(ni *ec2.NetworkInterface) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			errChan <- fn(ni)
+		}(ni)
+	}
+	wg.Wait()
+
+	// Collect all the errors from the async calls into a single error struct.
+	var allErrs *multierror.Error
+	for i, errChan := range errChans {
+		if err := <-errChan; err != nil {
+			allErrs = multierror.Append(allErrs, err)
+			logger.WithField("networkInterfaceId", aws.StringValue(networkInterfaces[i].NetworkInterfaceId)).Errorf("Error processing network interface: %s", err)
+		}
+	}
+	return allErrs.ErrorOrNil()
 }
 
-// Used to look up the security group for the ALB ingress controller
-func lookupSecurityGroup(
-	ec2Svc *ec2.EC2,
+// Used to look up the security groups for the load balancer controllers, along with the security groups that carry any
+// of the custom tag filters. As EC2 filters on different tags can only be combined with AND, each tag is looked up
+// separately and the results are deduplicated. The security group with the ID excludeGroupID is never returned.
+func lookupSecurityGroups(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
 	vpcID string,
 	clusterID string,
-) (*ec2.DescribeSecurityGroupsOutput, error) {
+	tagFilters map[string]string,
+	excludeGroupID string,
+) ([]*ec2.SecurityGroup, error) {
 	logger := logging.GetProjectLogger()
 
-	logger.Infof("Looking up security group containing tag for EKS cluster %s", clusterID)
-	sgInput := &ec2.DescribeSecurityGroupsInput{
-		Filters: []*ec2.Filter{
-			{
-				Name:   aws.String("vpc-id"),
-				Values: []*string{aws.String(vpcID)},
-			},
-			{
-				Name:   aws.String("tag:elbv2.k8s.aws/cluster"),
-				Values: []*string{aws.String(clusterID)},
-			},
-		}}
+	tagFilterList := []*ec2.Filter{}
+	for _, tagKey := range defaultSecurityGroupTagKeys {
+		tagFilterList = append(tagFilterList, securityGroupTagFilter(tagKey, clusterID))
+	}
+	customTagKeys := make([]string, 0, len(tagFilters))
+	for tagKey := range tagFilters {
+		customTagKeys = append(customTagKeys, tagKey)
+	}
+	sort.Strings(customTagKeys)
+	for _, tagKey := range customTagKeys {
+		tagFilterList = append(tagFilterList, securityGroupTagFilter(tagKey, tagFilters[tagKey]))
+	}
 
-	sgResult, err := ec2Svc.DescribeSecurityGroups(sgInput)
-	if err != nil {
-		return nil, errors.WithStackTrace(err)
+	securityGroups := []*ec2.SecurityGroup{}
+	seen := map[string]bool{excludeGroupID: true}
+	for _, tagFilter := range tagFilterList {
+		logger.Infof("Looking up security groups for EKS cluster %s with filter %s=%v", clusterID, aws.StringValue(tagFilter.Name), aws.StringValueSlice(tagFilter.Values))
+		input := &ec2.DescribeSecurityGroupsInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("vpc-id"),
+					Values: []*string{aws.String(vpcID)},
+				},
+				tagFilter,
+			},
+		}
+		// Handle pagination by repeatedly making the API call while there is a next token set.
+		for {
+			sgResult, err := ec2Svc.DescribeSecurityGroupsWithContext(ctx, input)
+			if err != nil {
+				return nil, errors.WithStackTrace(err)
+			}
+			for _, sg := range sgResult.SecurityGroups {
+				groupID := aws.StringValue(sg.GroupId)
+				if !seen[groupID] {
+					seen[groupID] = true
+					securityGroups = append(securityGroups, sg)
+				}
+			}
+			if sgResult.NextToken == nil {
+				break
+			}
+			input.NextToken = sgResult.NextToken
+		}
 	}
+	return securityGroups, nil
+}
 
-	return sgResult, nil
+// securityGroupTagFilter returns the filter for security groups with the given tag. When the value is empty, any value
+// of the tag matches.
+func securityGroupTagFilter(tagKey string, tagValue string) *ec2.Filter {
+	if tagValue == "" {
+		return &ec2.Filter{
+			Name:   aws.String("tag-key"),
+			Values: []*string{aws.String(tagKey)},
+		}
+	}
+	return &ec2.Filter{
+		Name:   aws.String(fmt.Sprintf("tag:%s", tagKey)),
+		Values: []*string{aws.String(tagValue)},
+	}
 }
 
 func isNIAttachmentNotFoundErr(err error) bool {
@@ -405,19 +1349,57 @@ func isNINotFoundErr(err error) bool {
 	return isAwsErr && awsErr.Code() == "InvalidNetworkInterfaceID.NotFound"
 }
 
+// isDryRunOperationErr returns true if the error is the one returned by AWS when a request made with the DryRun flag
+// would have succeeded.
+func isDryRunOperationErr(err error) bool {
+	awsErr, isAwsErr := err.(awserr.Error)
+	return isAwsErr && awsErr.Code() == "DryRunOperation"
+}
+
+func isSGNotFoundErr(err error) bool {
+	awsErr, isAwsErr := err.(awserr.Error)
+	return isAwsErr && awsErr.Code() == "InvalidGroup.NotFound"
+}
+
+// isVPCNotFoundErr returns true if the error, or any of the errors it wraps, is the error returned by AWS when the VPC
+// does not exist.
+func isVPCNotFoundErr(err error) bool {
+	switch typedErr := errors.Unwrap(err).(type) {
+	case awserr.Error:
+		return typedErr.Code() == "InvalidVpcID.NotFound"
+	case *multierror.Error:
+		for _, wrappedErr := range typedErr.Errors {
+			if isVPCNotFoundErr(wrappedErr) {
+				return true
+			}
+		}
+	case retry.FatalError:
+		return isVPCNotFoundErr(typedErr.Underlying)
+	}
+	return false
+}
+
+func isDependencyViolationErr(err error) bool {
+	awsErr, isAwsErr := err.(awserr.Error)
+	return isAwsErr && awsErr.Code() == "DependencyViolation"
+}
+
 func isMaxRetriesExceededErr(err error) bool {
 	_, isRetryErr := err.(retry.MaxRetriesExceeded)
 	return isRetryErr
 }
 
 func requestDetach(
-	ec2Svc *ec2.EC2,
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
 	ni *ec2.NetworkInterface,
+	dryRun bool,
 ) error {
 	detachInput := &ec2.DetachNetworkInterfaceInput{
 		AttachmentId: aws.String(aws.StringValue(ni.Attachment.AttachmentId)),
+		DryRun:       aws.Bool(dryRun),
 	}
-	_, err := ec2Svc.DetachNetworkInterface(detachInput)
+	_, err := ec2Svc.DetachNetworkInterfaceWithContext(ctx, detachInput)
 
 	return err
 }
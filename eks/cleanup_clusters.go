@@ -0,0 +1,83 @@
+package eks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// ClusterCleanupTarget identifies the security groups of an EKS cluster to clean up with CleanupSecurityGroups, with the
+// same inputs as CleanupSecurityGroup.
+type ClusterCleanupTarget struct {
+	ClusterArn      string
+	SecurityGroupID string
+	VPCID           string
+}
+
+// ClusterCleanupReport is the outcome of cleaning up the security groups of a single cluster with
+// CleanupSecurityGroups. Result is the result of CleanupSecurityGroup, which may be partial or nil if Err is set.
+type ClusterCleanupReport struct {
+	Target ClusterCleanupTarget
+	Result *CleanupResult
+	Err    error
+}
+
+// CleanupSecurityGroups runs CleanupSecurityGroup for each of the given clusters with the given options, cleaning up to
+// concurrency clusters in parallel. The cleanup of each cluster is independent: a failure does not abort the cleanup of
+// the other clusters. Returns a report for each cluster, in the order of the targets, along with an error combining a
+// ClusterCleanupFailedError for each cluster that failed.
+func CleanupSecurityGroups(
+	ctx context.Context,
+	targets []ClusterCleanupTarget,
+	concurrency int,
+	options CleanupOptions,
+) ([]ClusterCleanupReport, error) {
+	return cleanupSecurityGroupsOfClusters(ctx, targets, concurrency, func(ctx context.Context, target ClusterCleanupTarget) (*CleanupResult, error) {
+		return CleanupSecurityGroup(ctx, target.ClusterArn, target.SecurityGroupID, target.VPCID, options)
+	})
+}
+
+// cleanupSecurityGroupsOfClusters calls cleanup on each of the given targets, running up to concurrency calls in
+// parallel, and collects the outcome of each call into the reports.
+func cleanupSecurityGroupsOfClusters(
+	ctx context.Context,
+	targets []ClusterCleanupTarget,
+	concurrency int,
+	cleanup func(ctx context.Context, target ClusterCleanupTarget) (*CleanupResult, error),
+) ([]ClusterCleanupReport, error) {
+	logger := logging.GetProjectLogger()
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Each goroutine only writes the report at its own index, so the reports don't need to be guarded. The semaphore
+	// channel bounds the number of clusters that are being cleaned up at any given time.
+	reports := make([]ClusterCleanupReport, len(targets))
+	wg := new(sync.WaitGroup)
+	wg.Add(len(targets))
+	semaphore := make(chan struct{}, concurrency)
+	for i, target := range targets {
+		go func(i int, target ClusterCleanupTarget) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			result, err := cleanup(ctx, target)
+			reports[i] = ClusterCleanupReport{Target: target, Result: result, Err: err}
+		}(i, target)
+	}
+	wg.Wait()
+
+	var allErrs *multierror.Error
+	for _, report := range reports {
+		if report.Err != nil {
+			logger.WithField("clusterArn", report.Target.ClusterArn).Errorf("Error cleaning up security groups: %s", report.Err)
+			allErrs = multierror.Append(allErrs, errors.WithStackTrace(ClusterCleanupFailedError{ClusterArn: report.Target.ClusterArn, UnderlyingErr: report.Err}))
+		}
+	}
+	return reports, allErrs.ErrorOrNil()
+}
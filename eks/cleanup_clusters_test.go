@@ -0,0 +1,71 @@
+package eks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupSecurityGroupsOfClustersContinuesAfterFailure(t *testing.T) {
+	t.Parallel()
+
+	targets := []ClusterCleanupTarget{
+		{ClusterArn: "arn:aws:eks:us-east-1:111111111111:cluster/dev", SecurityGroupID: "sg-dev", VPCID: "vpc-dev"},
+		{ClusterArn: "arn:aws:eks:us-east-1:111111111111:cluster/stage", SecurityGroupID: "sg-stage", VPCID: "vpc-stage"},
+		{ClusterArn: "arn:aws:eks:us-east-1:111111111111:cluster/prod", SecurityGroupID: "sg-prod", VPCID: "vpc-prod"},
+	}
+	stageErr := fmt.Errorf("security group sg-stage is still in use")
+
+	// Track the number of cleanups in flight to check that the concurrency is bounded.
+	var mutex sync.Mutex
+	inFlight := 0
+	maxInFlight := 0
+	cleanup := func(ctx context.Context, target ClusterCleanupTarget) (*CleanupResult, error) {
+		mutex.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mutex.Unlock()
+		defer func() {
+			mutex.Lock()
+			inFlight--
+			mutex.Unlock()
+		}()
+
+		if target.SecurityGroupID == "sg-stage" {
+			return nil, stageErr
+		}
+		return &CleanupResult{DeletedSecurityGroupIDs: []string{target.SecurityGroupID}}, nil
+	}
+
+	reports, err := cleanupSecurityGroupsOfClusters(context.Background(), targets, 2, cleanup)
+	require.Error(t, err)
+	require.Len(t, reports, 3)
+	assert.LessOrEqual(t, maxInFlight, 2)
+
+	for i, report := range reports {
+		assert.Equal(t, targets[i], report.Target)
+	}
+	assert.NoError(t, reports[0].Err)
+	assert.Equal(t, []string{"sg-dev"}, reports[0].Result.DeletedSecurityGroupIDs)
+	assert.Equal(t, stageErr, reports[1].Err)
+	assert.Nil(t, reports[1].Result)
+	assert.NoError(t, reports[2].Err)
+	assert.Equal(t, []string{"sg-prod"}, reports[2].Result.DeletedSecurityGroupIDs)
+
+	multiErr, isMultiErr := err.(*multierror.Error)
+	require.True(t, isMultiErr)
+	require.Len(t, multiErr.Errors, 1)
+	assert.Equal(
+		t,
+		ClusterCleanupFailedError{ClusterArn: targets[1].ClusterArn, UnderlyingErr: stageErr},
+		errors.Unwrap(multiErr.Errors[0]),
+	)
+}
@@ -0,0 +1,238 @@
+package eks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/gruntwork-io/go-commons/collections"
+	"github.com/gruntwork-io/go-commons/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// FargateENICleanupResult describes the network interfaces that were cleaned up by CleanupFargateENIs.
+type FargateENICleanupResult struct {
+	// DeletedNetworkInterfaceIDs lists the IDs of the leaked Fargate network interfaces that were deleted.
+	DeletedNetworkInterfaceIDs []string
+
+	// SkippedInUseNetworkInterfaceIDs lists the IDs of the Fargate network interfaces that were left alone, because they
+	// are still used by a running Pod.
+	SkippedInUseNetworkInterfaceIDs []string
+}
+
+// CleanupFargateENIs deletes the network interfaces that are left behind in the VPC when deleting the Fargate profiles
+// of the EKS cluster, which otherwise block deleting the subnets and the VPC. These are the network interfaces in the VPC
+// whose description references the cluster, and whose description or requester references Fargate. The network
+// interfaces that are still used by a running Pod of the cluster (matched by the Pod IPs) are skipped, so this is safe to
+// run repeatedly, including while the cluster is still running.
+func CleanupFargateENIs(clusterArn string, vpcID string) (*FargateENICleanupResult, error) {
+	logger := logging.GetProjectLogger().WithField("clusterArn", clusterArn)
+	ctx := context.Background()
+
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	clusterID, err := eksawshelper.GetClusterNameFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	ec2Svc := eksawshelper.NewEC2Client(sess)
+	logger.Infof("Successfully authenticated with AWS")
+
+	networkInterfaces, err := findFargateNetworkInterfaces(ctx, ec2Svc, vpcID, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	result := &FargateENICleanupResult{
+		DeletedNetworkInterfaceIDs:      []string{},
+		SkippedInUseNetworkInterfaceIDs: []string{},
+	}
+	if len(networkInterfaces) == 0 {
+		logger.Info("No Fargate network interfaces found.")
+		return result, nil
+	}
+
+	livePodIPs, err := lookupLivePodIPs(ctx, eks.New(sess), clusterArn, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	leakedNetworkInterfaces := []*ec2.NetworkInterface{}
+	for _, ni := range networkInterfaces {
+		if isUsedByLivePod(ni, livePodIPs) {
+			logger.WithField("networkInterfaceId", aws.StringValue(ni.NetworkInterfaceId)).Info("Network interface is used by a running Pod. Skipping.")
+			result.SkippedInUseNetworkInterfaceIDs = append(result.SkippedInUseNetworkInterfaceIDs, aws.StringValue(ni.NetworkInterfaceId))
+			continue
+		}
+		leakedNetworkInterfaces = append(leakedNetworkInterfaces, ni)
+	}
+
+	deletedNetworkInterfaceIDs, err := detachAndDeleteNetworkInterfaces(ctx, ec2Svc, logger, leakedNetworkInterfaces, DefaultCleanupOptions(), &CleanupPhaseDurations{})
+	result.DeletedNetworkInterfaceIDs = append(result.DeletedNetworkInterfaceIDs, deletedNetworkInterfaceIDs...)
+	return result, err
+}
+
+// findFargateNetworkInterfaces returns the network interfaces in the VPC that were created by Fargate for the given
+// cluster.
+func findFargateNetworkInterfaces(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	vpcID string,
+	clusterID string,
+) ([]*ec2.NetworkInterface, error) {
+	logger := logging.GetProjectLogger()
+	logger.Infof("Looking up Fargate network interfaces of EKS cluster %s in VPC %s", clusterID, vpcID)
+
+	clusterNetworkInterfaces, err := findNetworkInterfacesDescribingCluster(ctx, ec2Svc, vpcID, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	networkInterfaces := []*ec2.NetworkInterface{}
+	for _, ni := range clusterNetworkInterfaces {
+		if isFargateNetworkInterface(ni, clusterID) {
+			logger.WithField("networkInterfaceId", aws.StringValue(ni.NetworkInterfaceId)).Info("Found Fargate network interface")
+			networkInterfaces = append(networkInterfaces, ni)
+		}
+	}
+	return networkInterfaces, nil
+}
+
+// findNetworkInterfacesDescribingCluster returns the network interfaces in the VPC whose description references the
+// cluster (see networkInterfaceDescribesCluster), e.g. the network interfaces that EKS creates for the control plane
+// and the Fargate Pods.
+func findNetworkInterfacesDescribingCluster(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	vpcID string,
+	clusterID string,
+) ([]*ec2.NetworkInterface, error) {
+	input := &ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("vpc-id"),
+				Values: []*string{aws.String(vpcID)},
+			},
+			{
+				Name:   aws.String("description"),
+				Values: []*string{aws.String(fmt.Sprintf("*%s*", clusterID))},
+			},
+		},
+	}
+	networkInterfaces := []*ec2.NetworkInterface{}
+	// Handle pagination by repeatedly making the API call while there is a next token set.
+	for {
+		output, err := ec2Svc.DescribeNetworkInterfacesWithContext(ctx, input)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		for _, ni := range output.NetworkInterfaces {
+			if networkInterfaceDescribesCluster(ni, clusterID) {
+				networkInterfaces = append(networkInterfaces, ni)
+			}
+		}
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	return networkInterfaces, nil
+}
+
+// isFargateNetworkInterface returns true if the description of the network interface references the cluster (see
+// networkInterfaceDescribesCluster), and either the description or the requester references Fargate.
+func isFargateNetworkInterface(ni *ec2.NetworkInterface, clusterID string) bool {
+	description := aws.StringValue(ni.Description)
+	if !networkInterfaceDescribesCluster(ni, clusterID) {
+		return false
+	}
+	return strings.Contains(strings.ToLower(description), "fargate") ||
+		strings.Contains(strings.ToLower(aws.StringValue(ni.RequesterId)), "fargate")
+}
+
+// networkInterfaceDescribesCluster returns true if the description of the network interface references the cluster
+// (e.g., `Amazon EKS <cluster>`). The description wildcard filter also matches clusters whose name contains the given
+// one, so the cluster name must match a whole word.
+func networkInterfaceDescribesCluster(ni *ec2.NetworkInterface, clusterID string) bool {
+	return collections.ListContainsElement(strings.Fields(aws.StringValue(ni.Description)), clusterID)
+}
+
+// lookupLivePodIPs returns the IPs of the Pods of the cluster that are not terminated. If the cluster no longer exists,
+// or is being deleted, there are no live Pods.
+func lookupLivePodIPs(ctx context.Context, eksSvc eksiface.EKSAPI, clusterArn string, clusterID string) ([]string, error) {
+	logger := logging.GetProjectLogger().WithField("clusterArn", clusterArn)
+
+	output, err := eksSvc.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{Name: aws.String(clusterID)})
+	awsErr, isAwsErr := err.(awserr.Error)
+	switch {
+	case isAwsErr && awsErr.Code() == eks.ErrCodeResourceNotFoundException:
+		logger.Info("EKS cluster is deleted, so no network interfaces are used by running Pods.")
+		return []string{}, nil
+	case err != nil:
+		return nil, errors.WithStackTrace(err)
+	}
+	status := aws.StringValue(output.Cluster.Status)
+	if status == eks.ClusterStatusDeleting || status == eks.ClusterStatusFailed {
+		logger.Infof("EKS cluster is in %s state, so no network interfaces are used by running Pods.", status)
+		return []string{}, nil
+	}
+
+	client, err := kubectl.GetKubernetesClientFromOptions(&kubectl.KubectlOptions{EKSClusterArn: clusterArn})
+	if err != nil {
+		return nil, err
+	}
+	return listLivePodIPs(ctx, client)
+}
+
+// listLivePodIPs returns the IPs of all the Pods in the cluster that are not terminated.
+func listLivePodIPs(ctx context.Context, client kubernetes.Interface) ([]string, error) {
+	pods, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	podIPs := []string{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if pod.Status.PodIP != "" {
+			podIPs = append(podIPs, pod.Status.PodIP)
+		}
+		for _, podIP := range pod.Status.PodIPs {
+			if podIP.IP != "" && !collections.ListContainsElement(podIPs, podIP.IP) {
+				podIPs = append(podIPs, podIP.IP)
+			}
+		}
+	}
+	return podIPs, nil
+}
+
+// isUsedByLivePod returns true if any of the private IPs of the network interface is the IP of a live Pod.
+func isUsedByLivePod(ni *ec2.NetworkInterface, livePodIPs []string) bool {
+	if collections.ListContainsElement(livePodIPs, aws.StringValue(ni.PrivateIpAddress)) {
+		return true
+	}
+	for _, address := range ni.PrivateIpAddresses {
+		if collections.ListContainsElement(livePodIPs, aws.StringValue(address.PrivateIpAddress)) {
+			return true
+		}
+	}
+	return false
+}
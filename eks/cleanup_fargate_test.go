@@ -0,0 +1,65 @@
+package eks
+
+import (
+	"context"
+	"testing"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFindFargateNetworkInterfacesOnlyReturnsFargateENIsOfCluster(t *testing.T) {
+	t.Parallel()
+
+	ec2Svc := &fakeEC2{
+		networkInterfacePages: [][]*ec2.NetworkInterface{
+			{
+				{NetworkInterfaceId: awsgo.String("eni-fargate"), Description: awsgo.String("Amazon EKS fargate-profile prod")},
+				{NetworkInterfaceId: awsgo.String("eni-requester"), Description: awsgo.String("Amazon EKS prod"), RequesterId: awsgo.String("AROAEXAMPLE:aws-fargate")},
+			},
+			{
+				{NetworkInterfaceId: awsgo.String("eni-control-plane"), Description: awsgo.String("Amazon EKS prod")},
+				{NetworkInterfaceId: awsgo.String("eni-other-cluster"), Description: awsgo.String("Amazon EKS fargate-profile prod-2")},
+			},
+		},
+	}
+
+	networkInterfaces, err := findFargateNetworkInterfaces(context.Background(), ec2Svc, "vpc-123", "prod")
+	require.NoError(t, err)
+	ids := []string{}
+	for _, ni := range networkInterfaces {
+		ids = append(ids, awsgo.StringValue(ni.NetworkInterfaceId))
+	}
+	assert.Equal(t, []string{"eni-fargate", "eni-requester"}, ids)
+}
+
+func TestLivePodIPsSkipTheNetworkInterfacesOfRunningPods(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.1"},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "completed", Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodSucceeded, PodIP: "10.0.0.2"},
+		},
+	)
+	livePodIPs, err := listLivePodIPs(context.Background(), client)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, livePodIPs)
+
+	inUse := &ec2.NetworkInterface{
+		PrivateIpAddress:   awsgo.String("10.0.0.3"),
+		PrivateIpAddresses: []*ec2.NetworkInterfacePrivateIpAddress{{PrivateIpAddress: awsgo.String("10.0.0.1")}},
+	}
+	leaked := &ec2.NetworkInterface{PrivateIpAddress: awsgo.String("10.0.0.2")}
+	assert.True(t, isUsedByLivePod(inUse, livePodIPs))
+	assert.False(t, isUsedByLivePod(leaked, livePodIPs))
+}
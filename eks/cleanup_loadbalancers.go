@@ -0,0 +1,410 @@
+package eks
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/retry"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// describeTagsBatchSize is the maximum number of load balancers that can be passed to a single DescribeTags call, for
+// both the ELB and ELBv2 APIs.
+const describeTagsBatchSize = 20
+
+// LoadBalancerCleanupResult describes the load balancers that were cleaned up by CleanupLoadBalancers.
+type LoadBalancerCleanupResult struct {
+	// DeletedLoadBalancerArns lists the ARNs of the Network and Application Load Balancers that were deleted.
+	DeletedLoadBalancerArns []string
+
+	// DeletedClassicLoadBalancerNames lists the names of the Classic Load Balancers that were deleted.
+	DeletedClassicLoadBalancerNames []string
+
+	// AlreadyGoneLoadBalancers lists the ARNs (or names, for Classic Load Balancers) of the load balancers that were
+	// already deleted by the time we tried to delete them.
+	AlreadyGoneLoadBalancers []string
+
+	// DeletedTargetGroupArns lists the ARNs of the orphaned target groups that were deleted.
+	DeletedTargetGroupArns []string
+
+	// AlreadyGoneTargetGroupArns lists the ARNs of the orphaned target groups that were already deleted by the time we
+	// tried to delete them.
+	AlreadyGoneTargetGroupArns []string
+}
+
+// CleanupLoadBalancers deletes the Classic, Network, and Application Load Balancers that are owned by the EKS cluster,
+// which otherwise are left behind when the cluster is destroyed without first deleting the `LoadBalancer` Services. A
+// load balancer is considered owned by the cluster when it is tagged with `kubernetes.io/cluster/<name>: owned`. This
+// waits for each load balancer to be deleted before returning. Once the load balancers are gone, the target groups owned
+// by the cluster that are no longer attached to any load balancer are deleted as well, as they are not deleted along
+// with their load balancer.
+func CleanupLoadBalancers(clusterArn string) (*LoadBalancerCleanupResult, error) {
+	logger := logging.GetProjectLogger()
+
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	clusterID, err := eksawshelper.GetClusterNameFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	elbSvc := elb.New(sess)
+	elbv2Svc := elbv2.New(sess)
+	logger.Infof("Successfully authenticated with AWS")
+
+	result := &LoadBalancerCleanupResult{}
+	if err := cleanupClassicLoadBalancers(elbSvc, clusterID, result); err != nil {
+		return nil, err
+	}
+	if err := cleanupV2LoadBalancers(elbv2Svc, clusterID, result); err != nil {
+		return nil, err
+	}
+	if err := cleanupOrphanedTargetGroups(elbv2Svc, clusterID, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// cleanupClassicLoadBalancers deletes the Classic Load Balancers owned by the cluster and waits for them to be deleted,
+// recording the results.
+func cleanupClassicLoadBalancers(elbSvc elbiface.ELBAPI, clusterID string, result *LoadBalancerCleanupResult) error {
+	logger := logging.GetProjectLogger()
+
+	lbNames, err := findClassicLoadBalancersOwnedByCluster(elbSvc, clusterID)
+	if err != nil {
+		return err
+	}
+
+	for _, lbName := range lbNames {
+		logger.Infof("Deleting Classic Load Balancer %s", lbName)
+		_, err := elbSvc.DeleteLoadBalancer(&elb.DeleteLoadBalancerInput{LoadBalancerName: aws.String(lbName)})
+		if err != nil {
+			if isLoadBalancerNotFoundErr(err) {
+				logger.Infof("Classic Load Balancer %s already deleted.", lbName)
+				result.AlreadyGoneLoadBalancers = append(result.AlreadyGoneLoadBalancers, lbName)
+				continue
+			}
+			return errors.WithStackTrace(err)
+		}
+
+		if err := waitForClassicLoadBalancerToBeDeleted(elbSvc, lbName); err != nil {
+			return err
+		}
+		logger.Infof("Successfully deleted Classic Load Balancer %s", lbName)
+		result.DeletedClassicLoadBalancerNames = append(result.DeletedClassicLoadBalancerNames, lbName)
+	}
+	return nil
+}
+
+// cleanupV2LoadBalancers deletes the Network and Application Load Balancers owned by the cluster and waits for them to
+// be deleted, recording the results.
+func cleanupV2LoadBalancers(elbv2Svc elbv2iface.ELBV2API, clusterID string, result *LoadBalancerCleanupResult) error {
+	logger := logging.GetProjectLogger()
+
+	lbArns, err := findV2LoadBalancersOwnedByCluster(elbv2Svc, clusterID)
+	if err != nil {
+		return err
+	}
+
+	for _, lbArn := range lbArns {
+		logger.Infof("Deleting load balancer %s", lbArn)
+		_, err := elbv2Svc.DeleteLoadBalancer(&elbv2.DeleteLoadBalancerInput{LoadBalancerArn: aws.String(lbArn)})
+		if err != nil {
+			if isLoadBalancerNotFoundErr(err) {
+				logger.Infof("Load balancer %s already deleted.", lbArn)
+				result.AlreadyGoneLoadBalancers = append(result.AlreadyGoneLoadBalancers, lbArn)
+				continue
+			}
+			return errors.WithStackTrace(err)
+		}
+
+		logger.Infof("Waiting for load balancer %s to be deleted.", lbArn)
+		err = elbv2Svc.WaitUntilLoadBalancersDeleted(&elbv2.DescribeLoadBalancersInput{LoadBalancerArns: aws.StringSlice([]string{lbArn})})
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		logger.Infof("Successfully deleted load balancer %s", lbArn)
+		result.DeletedLoadBalancerArns = append(result.DeletedLoadBalancerArns, lbArn)
+	}
+	return nil
+}
+
+// cleanupOrphanedTargetGroups deletes the target groups owned by the cluster that are not attached to any load balancer,
+// recording the results. This is expected to run after the load balancers of the cluster are deleted.
+func cleanupOrphanedTargetGroups(elbv2Svc elbv2iface.ELBV2API, clusterID string, result *LoadBalancerCleanupResult) error {
+	logger := logging.GetProjectLogger()
+
+	tgArns, err := findOrphanedTargetGroupsOwnedByCluster(elbv2Svc, clusterID)
+	if err != nil {
+		return err
+	}
+
+	for _, tgArn := range tgArns {
+		logger.Infof("Deleting target group %s", tgArn)
+		err := deleteTargetGroup(elbv2Svc, tgArn)
+		switch {
+		case isTargetGroupNotFoundErr(err):
+			logger.Infof("Target group %s already deleted.", tgArn)
+			result.AlreadyGoneTargetGroupArns = append(result.AlreadyGoneTargetGroupArns, tgArn)
+		case err != nil:
+			return err
+		default:
+			logger.Infof("Successfully deleted target group %s", tgArn)
+			result.DeletedTargetGroupArns = append(result.DeletedTargetGroupArns, tgArn)
+		}
+	}
+	return nil
+}
+
+// deleteTargetGroup deletes the given target group, retrying while it is reported as in use. The listeners of a load
+// balancer that was just deleted can take a while to release its target groups.
+func deleteTargetGroup(elbv2Svc elbv2iface.ELBV2API, tgArn string) error {
+	logger := logging.GetProjectLogger()
+
+	err := retry.DoWithRetry(
+		logger.Logger,
+		fmt.Sprintf("Delete target group %s", tgArn),
+		waitMaxRetries, waitSleepBetweenRetries,
+		func() error {
+			_, err := elbv2Svc.DeleteTargetGroup(&elbv2.DeleteTargetGroupInput{TargetGroupArn: aws.String(tgArn)})
+			awsErr, isAwsErr := err.(awserr.Error)
+			switch {
+			case err == nil:
+				return nil // exit retry loop with success
+			case isAwsErr && awsErr.Code() == elbv2.ErrCodeResourceInUseException:
+				return err // continue retrying
+			default:
+				return retry.FatalError{Underlying: err} // halt retries with error
+			}
+		},
+	)
+	if fatalErr, isFatalErr := err.(retry.FatalError); isFatalErr {
+		return errors.WithStackTrace(fatalErr.Underlying)
+	}
+	return errors.WithStackTrace(err)
+}
+
+// findClassicLoadBalancersOwnedByCluster returns the names of all the Classic Load Balancers in the region that are
+// tagged as owned by the given cluster.
+func findClassicLoadBalancersOwnedByCluster(elbSvc elbiface.ELBAPI, clusterID string) ([]string, error) {
+	descriptions, err := findClassicLoadBalancerTagsOwnedByCluster(elbSvc, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	ownedNames := []string{}
+	for _, description := range descriptions {
+		ownedNames = append(ownedNames, aws.StringValue(description.LoadBalancerName))
+	}
+	return ownedNames, nil
+}
+
+// findClassicLoadBalancerTagsOwnedByCluster returns the tags of all the Classic Load Balancers in the region that are
+// tagged as owned by the given cluster.
+func findClassicLoadBalancerTagsOwnedByCluster(elbSvc elbiface.ELBAPI, clusterID string) ([]*elb.TagDescription, error) {
+	logger := logging.GetProjectLogger()
+	logger.Infof("Looking up Classic Load Balancers owned by EKS cluster %s", clusterID)
+
+	allNames := []string{}
+	err := elbSvc.DescribeLoadBalancersPages(
+		&elb.DescribeLoadBalancersInput{},
+		func(page *elb.DescribeLoadBalancersOutput, lastPage bool) bool {
+			for _, lb := range page.LoadBalancerDescriptions {
+				allNames = append(allNames, aws.StringValue(lb.LoadBalancerName))
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	owned := []*elb.TagDescription{}
+	for _, batch := range batchStrings(allNames, describeTagsBatchSize) {
+		tagsResp, err := elbSvc.DescribeTags(&elb.DescribeTagsInput{LoadBalancerNames: aws.StringSlice(batch)})
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		for _, description := range tagsResp.TagDescriptions {
+			for _, tag := range description.Tags {
+				if isClusterOwnershipTag(aws.StringValue(tag.Key), aws.StringValue(tag.Value), clusterID) {
+					logger.Infof("Found Classic Load Balancer %s", aws.StringValue(description.LoadBalancerName))
+					owned = append(owned, description)
+					break
+				}
+			}
+		}
+	}
+	return owned, nil
+}
+
+// findV2LoadBalancersOwnedByCluster returns the ARNs of all the Network and Application Load Balancers in the region
+// that are tagged as owned by the given cluster.
+func findV2LoadBalancersOwnedByCluster(elbv2Svc elbv2iface.ELBV2API, clusterID string) ([]string, error) {
+	descriptions, err := findV2LoadBalancerTagsOwnedByCluster(elbv2Svc, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	ownedArns := []string{}
+	for _, description := range descriptions {
+		ownedArns = append(ownedArns, aws.StringValue(description.ResourceArn))
+	}
+	return ownedArns, nil
+}
+
+// findV2LoadBalancerTagsOwnedByCluster returns the tags of all the Network and Application Load Balancers in the
+// region that are tagged as owned by the given cluster.
+func findV2LoadBalancerTagsOwnedByCluster(elbv2Svc elbv2iface.ELBV2API, clusterID string) ([]*elbv2.TagDescription, error) {
+	logger := logging.GetProjectLogger()
+	logger.Infof("Looking up load balancers owned by EKS cluster %s", clusterID)
+
+	allArns := []string{}
+	err := elbv2Svc.DescribeLoadBalancersPages(
+		&elbv2.DescribeLoadBalancersInput{},
+		func(page *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+			for _, lb := range page.LoadBalancers {
+				allArns = append(allArns, aws.StringValue(lb.LoadBalancerArn))
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	owned, err := filterV2ResourcesOwnedByCluster(elbv2Svc, allArns, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	for _, description := range owned {
+		logger.Infof("Found load balancer %s", aws.StringValue(description.ResourceArn))
+	}
+	return owned, nil
+}
+
+// findOrphanedTargetGroupsOwnedByCluster returns the ARNs of all the target groups in the region that are tagged as owned
+// by the given cluster and are not attached to any load balancer.
+func findOrphanedTargetGroupsOwnedByCluster(elbv2Svc elbv2iface.ELBV2API, clusterID string) ([]string, error) {
+	logger := logging.GetProjectLogger()
+	logger.Infof("Looking up orphaned target groups owned by EKS cluster %s", clusterID)
+
+	orphanedArns := []string{}
+	err := elbv2Svc.DescribeTargetGroupsPages(
+		&elbv2.DescribeTargetGroupsInput{},
+		func(page *elbv2.DescribeTargetGroupsOutput, lastPage bool) bool {
+			for _, tg := range page.TargetGroups {
+				if len(tg.LoadBalancerArns) == 0 {
+					orphanedArns = append(orphanedArns, aws.StringValue(tg.TargetGroupArn))
+				}
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	owned, err := filterV2ResourcesOwnedByCluster(elbv2Svc, orphanedArns, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	ownedArns := []string{}
+	for _, description := range owned {
+		logger.Infof("Found orphaned target group %s", aws.StringValue(description.ResourceArn))
+		ownedArns = append(ownedArns, aws.StringValue(description.ResourceArn))
+	}
+	return ownedArns, nil
+}
+
+// filterV2ResourcesOwnedByCluster returns the tags of the given ELBv2 resources (load balancers or target groups) that
+// are tagged as owned by the given cluster. The tags are looked up in batches, as DescribeTags accepts at most
+// describeTagsBatchSize resources per call.
+func filterV2ResourcesOwnedByCluster(elbv2Svc elbv2iface.ELBV2API, arns []string, clusterID string) ([]*elbv2.TagDescription, error) {
+	owned := []*elbv2.TagDescription{}
+	for _, batch := range batchStrings(arns, describeTagsBatchSize) {
+		tagsResp, err := elbv2Svc.DescribeTags(&elbv2.DescribeTagsInput{ResourceArns: aws.StringSlice(batch)})
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		for _, description := range tagsResp.TagDescriptions {
+			for _, tag := range description.Tags {
+				if isClusterOwnershipTag(aws.StringValue(tag.Key), aws.StringValue(tag.Value), clusterID) {
+					owned = append(owned, description)
+					break
+				}
+			}
+		}
+	}
+	return owned, nil
+}
+
+// waitForClassicLoadBalancerToBeDeleted polls until the given Classic Load Balancer can no longer be found. The ELB API
+// does not provide a waiter for deletion, so we implement our own.
+func waitForClassicLoadBalancerToBeDeleted(elbSvc elbiface.ELBAPI, lbName string) error {
+	logger := logging.GetProjectLogger()
+
+	return retry.DoWithRetry(
+		logger.Logger,
+		fmt.Sprintf("Wait for Classic Load Balancer %s to be deleted", lbName),
+		waitMaxRetries, waitSleepBetweenRetries,
+		func() error {
+			resp, err := elbSvc.DescribeLoadBalancers(&elb.DescribeLoadBalancersInput{LoadBalancerNames: aws.StringSlice([]string{lbName})})
+			switch {
+			case isLoadBalancerNotFoundErr(err):
+				return nil // exit retry loop with success
+			case err != nil:
+				return retry.FatalError{Underlying: err} // halt retries with error
+			case len(resp.LoadBalancerDescriptions) == 0:
+				return nil // exit retry loop with success
+			default:
+				return fmt.Errorf("Classic Load Balancer %s not deleted.", lbName) // continue retrying
+			}
+		},
+	)
+}
+
+// isClusterOwnershipTag returns true if the given tag marks the resource as owned by the cluster.
+func isClusterOwnershipTag(key string, value string, clusterID string) bool {
+	return key == fmt.Sprintf("kubernetes.io/cluster/%s", clusterID) && value == "owned"
+}
+
+// isLoadBalancerNotFoundErr returns true if the error is the one returned by the ELB and ELBv2 APIs when the load
+// balancer does not exist. Note that both APIs use the same error code.
+func isLoadBalancerNotFoundErr(err error) bool {
+	awsErr, isAwsErr := err.(awserr.Error)
+	return isAwsErr && awsErr.Code() == elbv2.ErrCodeLoadBalancerNotFoundException
+}
+
+// isTargetGroupNotFoundErr returns true if the error is the one returned by the ELBv2 API when the target group does not
+// exist.
+func isTargetGroupNotFoundErr(err error) bool {
+	awsErr, isAwsErr := errors.Unwrap(err).(awserr.Error)
+	return isAwsErr && awsErr.Code() == elbv2.ErrCodeTargetGroupNotFoundException
+}
+
+// batchStrings splits the given list into batches with at most batchSize elements.
+func batchStrings(list []string, batchSize int) [][]string {
+	batches := [][]string{}
+	for len(list) > batchSize {
+		batches = append(batches, list[:batchSize])
+		list = list[batchSize:]
+	}
+	if len(list) > 0 {
+		batches = append(batches, list)
+	}
+	return batches
+}
@@ -0,0 +1,106 @@
+package eks
+
+import (
+	"fmt"
+	"testing"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCleanupV2LoadBalancersPaginatesAndDeletesOrphanedTargetGroups(t *testing.T) {
+	t.Parallel()
+
+	elbv2Svc := &fakeELBV2{tags: map[string][]*elbv2.Tag{}, pageSize: 10}
+	expectedLoadBalancerArns := []string{}
+	// Every third load balancer is owned by the cluster, and the others are either shared with it or owned by another
+	// cluster.
+	for i := 0; i < 45; i++ {
+		lbArn := fmt.Sprintf("arn:aws:elasticloadbalancing:us-east-1:111111111111:loadbalancer/net/lb-%d", i)
+		elbv2Svc.loadBalancers = append(elbv2Svc.loadBalancers, &elbv2.LoadBalancer{LoadBalancerArn: awsgo.String(lbArn)})
+		switch i % 3 {
+		case 0:
+			elbv2Svc.tags[lbArn] = testV2ClusterTags("prod", "owned")
+			expectedLoadBalancerArns = append(expectedLoadBalancerArns, lbArn)
+		case 1:
+			elbv2Svc.tags[lbArn] = testV2ClusterTags("prod", "shared")
+		case 2:
+			elbv2Svc.tags[lbArn] = testV2ClusterTags("stage", "owned")
+		}
+	}
+	targetGroup := func(name string, lbIndex int, cluster string) *elbv2.TargetGroup {
+		tgArn := "arn:aws:elasticloadbalancing:us-east-1:111111111111:targetgroup/" + name
+		elbv2Svc.tags[tgArn] = testV2ClusterTags(cluster, "owned")
+		tg := &elbv2.TargetGroup{TargetGroupArn: awsgo.String(tgArn)}
+		if lbIndex >= 0 {
+			tg.LoadBalancerArns = []*string{elbv2Svc.loadBalancers[lbIndex].LoadBalancerArn}
+		}
+		return tg
+	}
+	elbv2Svc.targetGroups = []*elbv2.TargetGroup{
+		targetGroup("of-deleted-lb", 3, "prod"),
+		targetGroup("already-orphaned", -1, "prod"),
+		targetGroup("of-shared-lb", 4, "prod"),
+		targetGroup("of-other-cluster", -1, "stage"),
+	}
+
+	result := &LoadBalancerCleanupResult{}
+	require.NoError(t, cleanupV2LoadBalancers(elbv2Svc, "prod", result))
+	require.NoError(t, cleanupOrphanedTargetGroups(elbv2Svc, "prod", result))
+
+	assert.Equal(t, expectedLoadBalancerArns, result.DeletedLoadBalancerArns)
+	assert.Equal(t, expectedLoadBalancerArns, elbv2Svc.deletedLoadBalancerArns)
+	assert.Equal(t, []string{
+		"arn:aws:elasticloadbalancing:us-east-1:111111111111:targetgroup/of-deleted-lb",
+		"arn:aws:elasticloadbalancing:us-east-1:111111111111:targetgroup/already-orphaned",
+	}, result.DeletedTargetGroupArns)
+	assert.Equal(t, result.DeletedTargetGroupArns, elbv2Svc.deletedTargetGroupArns)
+
+	// The tags of the 45 load balancers are looked up in 3 batches, and then the tags of the 3 orphaned target groups in
+	// a single batch.
+	require.Len(t, elbv2Svc.describeTagsInputs, 4)
+	for _, input := range elbv2Svc.describeTagsInputs {
+		assert.LessOrEqual(t, len(input.ResourceArns), describeTagsBatchSize)
+	}
+	assert.Len(t, elbv2Svc.describeTagsInputs[3].ResourceArns, 3)
+}
+
+func TestBatchStrings(t *testing.T) {
+	t.Parallel()
+
+	var testCases = []struct {
+		name      string
+		list      []string
+		batchSize int
+		expected  [][]string
+	}{
+		{"empty", []string{}, 2, [][]string{}},
+		{"single-partial-batch", []string{"a"}, 2, [][]string{{"a"}}},
+		{"exact-batches", []string{"a", "b", "c", "d"}, 2, [][]string{{"a", "b"}, {"c", "d"}}},
+		{"trailing-partial-batch", []string{"a", "b", "c"}, 2, [][]string{{"a", "b"}, {"c"}}},
+	}
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.expected, batchStrings(testCase.list, testCase.batchSize))
+		})
+	}
+}
+
+func TestIsClusterOwnershipTag(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isClusterOwnershipTag("kubernetes.io/cluster/my-cluster", "owned", "my-cluster"))
+	assert.False(t, isClusterOwnershipTag("kubernetes.io/cluster/my-cluster", "shared", "my-cluster"))
+	assert.False(t, isClusterOwnershipTag("kubernetes.io/cluster/other-cluster", "owned", "my-cluster"))
+}
+
+func testV2ClusterTags(clusterID string, value string) []*elbv2.Tag {
+	return []*elbv2.Tag{
+		{Key: awsgo.String("kubernetes.io/cluster/" + clusterID), Value: awsgo.String(value)},
+		{Key: awsgo.String("kubernetes.io/service-name"), Value: awsgo.String("default/web")},
+	}
+}
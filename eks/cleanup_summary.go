@@ -0,0 +1,112 @@
+package eks
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/hashicorp/go-multierror"
+)
+
+// CleanupSummary is the machine readable summary of a run of CleanupSecurityGroup, for tooling that wraps kubergrunt.
+// The remaining IDs list the resources that were found to clean up but are not deleted yet, e.g., when the cleanup
+// timed out, so that the caller can decide what to retry.
+type CleanupSummary struct {
+	ClusterArn                   string                `json:"cluster_arn"`
+	DryRun                       bool                  `json:"dry_run"`
+	DeletedSecurityGroupIDs      []string              `json:"deleted_security_group_ids"`
+	DeletedNetworkInterfaceIDs   []string              `json:"deleted_network_interface_ids"`
+	AlreadyGoneSecurityGroupIDs  []string              `json:"already_gone_security_group_ids"`
+	RemainingSecurityGroupIDs    []string              `json:"remaining_security_group_ids"`
+	RemainingNetworkInterfaceIDs []string              `json:"remaining_network_interface_ids"`
+	PhaseDurations               CleanupSummaryTimings `json:"phase_durations_seconds"`
+	Errors                       []string              `json:"errors"`
+}
+
+// CleanupSummaryTimings records the phase durations of CleanupPhaseDurations in seconds.
+type CleanupSummaryTimings struct {
+	Describe          float64 `json:"describe"`
+	Detach            float64 `json:"detach"`
+	WaitDetach        float64 `json:"wait_detach"`
+	Delete            float64 `json:"delete"`
+	WaitDelete        float64 `json:"wait_delete"`
+	LoadBalancerSweep float64 `json:"load_balancer_sweep"`
+	Total             float64 `json:"total"`
+}
+
+// NewCleanupSummary summarizes the result and error returned by CleanupSecurityGroup. The result may be nil, or partial,
+// when the cleanup failed. The empty lists are kept as empty arrays rather than null in the JSON.
+func NewCleanupSummary(clusterArn string, result *CleanupResult, cleanupErr error) CleanupSummary {
+	summary := CleanupSummary{
+		ClusterArn:                   clusterArn,
+		DeletedSecurityGroupIDs:      []string{},
+		DeletedNetworkInterfaceIDs:   []string{},
+		AlreadyGoneSecurityGroupIDs:  []string{},
+		RemainingSecurityGroupIDs:    []string{},
+		RemainingNetworkInterfaceIDs: []string{},
+		Errors:                       []string{},
+	}
+	if result != nil {
+		summary.DryRun = result.DryRun
+		summary.DeletedSecurityGroupIDs = append(summary.DeletedSecurityGroupIDs, result.DeletedSecurityGroupIDs...)
+		summary.DeletedNetworkInterfaceIDs = append(summary.DeletedNetworkInterfaceIDs, result.DeletedNetworkInterfaceIDs...)
+		summary.AlreadyGoneSecurityGroupIDs = append(summary.AlreadyGoneSecurityGroupIDs, result.AlreadyGoneSecurityGroupIDs...)
+		summary.RemainingSecurityGroupIDs, summary.RemainingNetworkInterfaceIDs = result.remaining()
+
+		durations := result.PhaseDurations
+		summary.PhaseDurations = CleanupSummaryTimings{
+			Describe:          durations.Describe.Seconds(),
+			Detach:            durations.Detach.Seconds(),
+			WaitDetach:        durations.WaitDetach.Seconds(),
+			Delete:            durations.Delete.Seconds(),
+			WaitDelete:        durations.WaitDelete.Seconds(),
+			LoadBalancerSweep: durations.LoadBalancerSweep.Seconds(),
+			Total:             durations.Total.Seconds(),
+		}
+	}
+
+	// List each of the combined errors separately, so that the caller does not need to parse the multierror format.
+	if multiErr, isMultiErr := errors.Unwrap(cleanupErr).(*multierror.Error); isMultiErr {
+		for _, err := range multiErr.Errors {
+			summary.Errors = append(summary.Errors, err.Error())
+		}
+	} else if cleanupErr != nil {
+		summary.Errors = append(summary.Errors, cleanupErr.Error())
+	}
+	return summary
+}
+
+// WriteCleanupSummary writes the summary as JSON to the given path. The summary is written to a temporary file in the
+// same directory and renamed into place, so that the file is never left truncated if the process is killed.
+func WriteCleanupSummary(path string, summary CleanupSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	// This is a noop once the file is renamed.
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return errors.WithStackTrace(err)
+	}
+	if err := tmpFile.Chmod(0644); err != nil {
+		tmpFile.Close()
+		return errors.WithStackTrace(err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return errors.WithStackTrace(err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return errors.WithStackTrace(os.Rename(tmpFile.Name(), path))
+}
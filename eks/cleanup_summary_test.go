@@ -0,0 +1,58 @@
+package eks
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCleanupSummaryOfPartialResult(t *testing.T) {
+	t.Parallel()
+
+	result := &CleanupResult{
+		DeletedSecurityGroupIDs:    []string{"sg-1"},
+		DeletedNetworkInterfaceIDs: []string{"eni-1"},
+		PhaseDurations:             CleanupPhaseDurations{Detach: 1500 * time.Millisecond, Total: 3 * time.Second},
+		foundSecurityGroupIDs:      []string{"sg-1", "sg-2"},
+		foundNetworkInterfaceIDs:   []string{"eni-1", "eni-2"},
+	}
+	cleanupErr := errors.WithStackTrace(multierror.Append(nil, errors.WithStackTrace(CleanupTimeoutError{Timeout: time.Minute}), ClusterNotDeletedError{ClusterArn: "arn", Status: "ACTIVE"}))
+
+	summary := NewCleanupSummary("arn", result, cleanupErr)
+	assert.Equal(t, []string{"sg-1"}, summary.DeletedSecurityGroupIDs)
+	assert.Equal(t, []string{"eni-1"}, summary.DeletedNetworkInterfaceIDs)
+	assert.Equal(t, []string{}, summary.AlreadyGoneSecurityGroupIDs)
+	assert.Equal(t, []string{"sg-2"}, summary.RemainingSecurityGroupIDs)
+	assert.Equal(t, []string{"eni-2"}, summary.RemainingNetworkInterfaceIDs)
+	assert.Equal(t, 1.5, summary.PhaseDurations.Detach)
+	assert.Equal(t, 3.0, summary.PhaseDurations.Total)
+	assert.Len(t, summary.Errors, 2)
+}
+
+func TestWriteCleanupSummaryWithoutResult(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "summary.json")
+	summary := NewCleanupSummary("arn", nil, ClusterNotDeletedError{ClusterArn: "arn", Status: "ACTIVE"})
+	require.NoError(t, WriteCleanupSummary(path, summary))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	var written map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, "arn", written["cluster_arn"])
+	assert.Equal(t, []interface{}{}, written["deleted_security_group_ids"])
+	assert.Len(t, written["errors"], 1)
+
+	// Only the summary is left in the directory, without the temporary file.
+	files, err := ioutil.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
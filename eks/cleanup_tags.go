@@ -0,0 +1,290 @@
+package eks
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/gruntwork-io/go-commons/errors"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+const (
+	// createTagsBatchSize is the maximum number of resources that can be passed to a single EC2 CreateTags call.
+	createTagsBatchSize = 1000
+
+	// addTagsBatchSize is the maximum number of load balancers that can be passed to a single ELBv2 AddTags call. The
+	// Classic Load Balancer API only accepts one load balancer per AddTags call.
+	addTagsBatchSize = 20
+)
+
+// ClusterResourceCounts counts the resources of an EKS cluster by type.
+type ClusterResourceCounts struct {
+	SecurityGroups       int `json:"security_groups"`
+	NetworkInterfaces    int `json:"network_interfaces"`
+	LoadBalancers        int `json:"load_balancers"`
+	ClassicLoadBalancers int `json:"classic_load_balancers"`
+	Volumes              int `json:"volumes"`
+}
+
+// TagClusterResourcesResult describes the resources that were tagged by TagClusterResources.
+type TagClusterResourcesResult struct {
+	// Tagged counts the resources that were missing some of the tags, which were tagged.
+	Tagged ClusterResourceCounts `json:"tagged"`
+
+	// AlreadyTagged counts the resources that already had all the tags with the same values, which were skipped.
+	AlreadyTagged ClusterResourceCounts `json:"already_tagged"`
+}
+
+// TagClusterResources applies the given tags to the resources of the EKS cluster, e.g. for cost allocation before the
+// cluster is torn down. The resources are found the same way as VerifyCleanup finds the leftovers of a cluster: the
+// security groups in the VPC of the cluster that are tagged for the cluster, the network interfaces in the VPC that use
+// them or that reference the cluster in their description, the load balancers that are owned by the cluster, and the
+// EBS volumes that are tagged for the cluster. Resources that already have all the tags with the same values are
+// skipped, so that this can be run repeatedly. Existing tags with the same keys but different values are overwritten.
+func TagClusterResources(clusterArn string, tags map[string]string) (*TagClusterResourcesResult, error) {
+	logger := logging.GetProjectLogger()
+
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	clusterID, err := eksawshelper.GetClusterNameFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	clusterInfo, err := eksawshelper.GetClusterInfo(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	ec2Svc := eksawshelper.NewEC2Client(sess)
+	elbSvc := elb.New(sess)
+	elbv2Svc := elbv2.New(sess)
+	logger.Infof("Successfully authenticated with AWS")
+
+	return tagClusterResources(context.Background(), ec2Svc, elbSvc, elbv2Svc, clusterInfo.VPCID, clusterID, tags)
+}
+
+func tagClusterResources(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	elbSvc elbiface.ELBAPI,
+	elbv2Svc elbv2iface.ELBV2API,
+	vpcID string,
+	clusterID string,
+	tags map[string]string,
+) (*TagClusterResourcesResult, error) {
+	logger := logging.GetProjectLogger().WithField("cluster", clusterID)
+	logger.Infof("Tagging the resources of EKS cluster %s with %v", clusterID, tags)
+	result := &TagClusterResourcesResult{}
+
+	// Security groups and network interfaces
+	securityGroups, err := describeVPCSecurityGroups(ctx, ec2Svc, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	clusterSecurityGroupIDs := map[string]bool{}
+	untaggedSecurityGroupIDs := []string{}
+	for _, securityGroup := range securityGroups {
+		if !securityGroupTaggedForCluster(securityGroup.Tags, clusterID) {
+			continue
+		}
+		clusterSecurityGroupIDs[aws.StringValue(securityGroup.GroupId)] = true
+		if ec2TagsContain(securityGroup.Tags, tags) {
+			result.AlreadyTagged.SecurityGroups++
+		} else {
+			untaggedSecurityGroupIDs = append(untaggedSecurityGroupIDs, aws.StringValue(securityGroup.GroupId))
+		}
+	}
+	if err := createEC2Tags(ctx, ec2Svc, untaggedSecurityGroupIDs, tags); err != nil {
+		return nil, err
+	}
+	result.Tagged.SecurityGroups = len(untaggedSecurityGroupIDs)
+
+	networkInterfaces, err := describeVPCNetworkInterfaces(ctx, ec2Svc, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	untaggedNetworkInterfaceIDs := []string{}
+	for _, ni := range networkInterfaces {
+		if !networkInterfaceBelongsToCluster(ni, clusterID, clusterSecurityGroupIDs) {
+			continue
+		}
+		if ec2TagsContain(ni.TagSet, tags) {
+			result.AlreadyTagged.NetworkInterfaces++
+		} else {
+			untaggedNetworkInterfaceIDs = append(untaggedNetworkInterfaceIDs, aws.StringValue(ni.NetworkInterfaceId))
+		}
+	}
+	if err := createEC2Tags(ctx, ec2Svc, untaggedNetworkInterfaceIDs, tags); err != nil {
+		return nil, err
+	}
+	result.Tagged.NetworkInterfaces = len(untaggedNetworkInterfaceIDs)
+
+	// Load balancers
+	v2LoadBalancers, err := findV2LoadBalancerTagsOwnedByCluster(elbv2Svc, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	untaggedLoadBalancerArns := []string{}
+	for _, description := range v2LoadBalancers {
+		if elbv2TagsContain(description.Tags, tags) {
+			result.AlreadyTagged.LoadBalancers++
+		} else {
+			untaggedLoadBalancerArns = append(untaggedLoadBalancerArns, aws.StringValue(description.ResourceArn))
+		}
+	}
+	for _, batch := range batchStrings(untaggedLoadBalancerArns, addTagsBatchSize) {
+		logger.Infof("Tagging load balancers %v", batch)
+		input := &elbv2.AddTagsInput{ResourceArns: aws.StringSlice(batch), Tags: newELBV2Tags(tags)}
+		if _, err := elbv2Svc.AddTags(input); err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+	}
+	result.Tagged.LoadBalancers = len(untaggedLoadBalancerArns)
+
+	classicLoadBalancers, err := findClassicLoadBalancerTagsOwnedByCluster(elbSvc, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	for _, description := range classicLoadBalancers {
+		if elbTagsContain(description.Tags, tags) {
+			result.AlreadyTagged.ClassicLoadBalancers++
+			continue
+		}
+		logger.Infof("Tagging Classic Load Balancer %s", aws.StringValue(description.LoadBalancerName))
+		input := &elb.AddTagsInput{LoadBalancerNames: []*string{description.LoadBalancerName}, Tags: newELBTags(tags)}
+		if _, err := elbSvc.AddTags(input); err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		result.Tagged.ClassicLoadBalancers++
+	}
+
+	// Volumes that are being deleted are not tagged.
+	volumes, err := findVolumesOwnedByCluster(
+		ec2Svc,
+		clusterID,
+		[]string{ec2.VolumeStateCreating, ec2.VolumeStateAvailable, ec2.VolumeStateInUse, ec2.VolumeStateError},
+	)
+	if err != nil {
+		return nil, err
+	}
+	untaggedVolumeIDs := []string{}
+	for _, volume := range volumes {
+		if ec2TagsContain(volume.Tags, tags) {
+			result.AlreadyTagged.Volumes++
+		} else {
+			untaggedVolumeIDs = append(untaggedVolumeIDs, aws.StringValue(volume.VolumeId))
+		}
+	}
+	if err := createEC2Tags(ctx, ec2Svc, untaggedVolumeIDs, tags); err != nil {
+		return nil, err
+	}
+	result.Tagged.Volumes = len(untaggedVolumeIDs)
+
+	logger.Infof("Successfully tagged the resources of EKS cluster %s", clusterID)
+	return result, nil
+}
+
+// networkInterfaceBelongsToCluster returns true if the network interface uses one of the security groups of the cluster,
+// or its description references the cluster (see networkInterfaceDescribesCluster).
+func networkInterfaceBelongsToCluster(ni *ec2.NetworkInterface, clusterID string, clusterSecurityGroupIDs map[string]bool) bool {
+	for _, group := range ni.Groups {
+		if clusterSecurityGroupIDs[aws.StringValue(group.GroupId)] {
+			return true
+		}
+	}
+	return networkInterfaceDescribesCluster(ni, clusterID)
+}
+
+// createEC2Tags applies the tags to the EC2 resources with the given IDs, in batches.
+func createEC2Tags(ctx context.Context, ec2Svc ec2iface.EC2API, resourceIDs []string, tags map[string]string) error {
+	logger := logging.GetProjectLogger()
+	ec2Tags := []*ec2.Tag{}
+	for _, key := range sortedTagKeys(tags) {
+		ec2Tags = append(ec2Tags, &ec2.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	for _, batch := range batchStrings(resourceIDs, createTagsBatchSize) {
+		logger.Infof("Tagging EC2 resources %v", batch)
+		_, err := ec2Svc.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{Resources: aws.StringSlice(batch), Tags: ec2Tags})
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+	return nil
+}
+
+func newELBTags(tags map[string]string) []*elb.Tag {
+	elbTags := []*elb.Tag{}
+	for _, key := range sortedTagKeys(tags) {
+		elbTags = append(elbTags, &elb.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return elbTags
+}
+
+func newELBV2Tags(tags map[string]string) []*elbv2.Tag {
+	elbv2Tags := []*elbv2.Tag{}
+	for _, key := range sortedTagKeys(tags) {
+		elbv2Tags = append(elbv2Tags, &elbv2.Tag{Key: aws.String(key), Value: aws.String(tags[key])})
+	}
+	return elbv2Tags
+}
+
+// ec2TagsContain, elbTagsContain and elbv2TagsContain return true if the tags of a resource include all the given
+// tags, with the same values.
+func ec2TagsContain(resourceTags []*ec2.Tag, tags map[string]string) bool {
+	existing := map[string]string{}
+	for _, tag := range resourceTags {
+		existing[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tagsContain(existing, tags)
+}
+
+func elbTagsContain(resourceTags []*elb.Tag, tags map[string]string) bool {
+	existing := map[string]string{}
+	for _, tag := range resourceTags {
+		existing[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tagsContain(existing, tags)
+}
+
+func elbv2TagsContain(resourceTags []*elbv2.Tag, tags map[string]string) bool {
+	existing := map[string]string{}
+	for _, tag := range resourceTags {
+		existing[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tagsContain(existing, tags)
+}
+
+func tagsContain(existing map[string]string, tags map[string]string) bool {
+	for key, value := range tags {
+		existingValue, hasKey := existing[key]
+		if !hasKey || existingValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedTagKeys returns the keys of the tags in order, so that the tags are always applied in the same order.
+func sortedTagKeys(tags map[string]string) []string {
+	keys := []string{}
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,105 @@
+package eks
+
+import (
+	"context"
+	"testing"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagClusterResourcesSkipsResourcesThatAreAlreadyTagged(t *testing.T) {
+	t.Parallel()
+
+	costTags := map[string]string{"team": "platform", "cost-center": "1234"}
+	taggedEC2 := []*ec2.Tag{
+		{Key: awsgo.String("team"), Value: awsgo.String("platform")},
+		{Key: awsgo.String("cost-center"), Value: awsgo.String("1234")},
+	}
+	ec2Svc := &fakeEC2{
+		securityGroups: []*ec2.SecurityGroup{
+			{
+				GroupId: awsgo.String("sg-prod"),
+				Tags:    []*ec2.Tag{{Key: awsgo.String("aws:eks:cluster-name"), Value: awsgo.String("prod")}},
+			},
+			{
+				GroupId: awsgo.String("sg-prod-tagged"),
+				Tags:    append([]*ec2.Tag{{Key: awsgo.String("kubernetes.io/cluster/prod"), Value: awsgo.String("owned")}}, taggedEC2...),
+			},
+			{
+				GroupId: awsgo.String("sg-stage"),
+				Tags:    []*ec2.Tag{{Key: awsgo.String("aws:eks:cluster-name"), Value: awsgo.String("stage")}},
+			},
+		},
+		networkInterfacePages: [][]*ec2.NetworkInterface{{
+			{NetworkInterfaceId: awsgo.String("eni-node"), Groups: []*ec2.GroupIdentifier{{GroupId: awsgo.String("sg-prod")}}},
+			{NetworkInterfaceId: awsgo.String("eni-control-plane"), Description: awsgo.String("Amazon EKS prod"), TagSet: taggedEC2},
+			{NetworkInterfaceId: awsgo.String("eni-stage"), Groups: []*ec2.GroupIdentifier{{GroupId: awsgo.String("sg-stage")}}},
+		}},
+		volumes: []*ec2.Volume{
+			// A tag with a different value is overwritten.
+			{VolumeId: awsgo.String("vol-pv"), Tags: []*ec2.Tag{{Key: awsgo.String("team"), Value: awsgo.String("data")}}},
+		},
+	}
+	elbSvc := &fakeELB{
+		loadBalancers: []*elb.LoadBalancerDescription{{LoadBalancerName: awsgo.String("clb-prod")}},
+		tags: map[string][]*elb.Tag{
+			"clb-prod": {{Key: awsgo.String("kubernetes.io/cluster/prod"), Value: awsgo.String("owned")}},
+		},
+	}
+	elbv2Svc := &fakeELBV2{
+		loadBalancers: []*elbv2.LoadBalancer{{LoadBalancerArn: awsgo.String("arn:nlb/prod")}},
+		tags: map[string][]*elbv2.Tag{
+			"arn:nlb/prod": {
+				{Key: awsgo.String("kubernetes.io/cluster/prod"), Value: awsgo.String("owned")},
+				{Key: awsgo.String("team"), Value: awsgo.String("platform")},
+				{Key: awsgo.String("cost-center"), Value: awsgo.String("1234")},
+			},
+		},
+	}
+
+	result, err := tagClusterResources(context.Background(), ec2Svc, elbSvc, elbv2Svc, "vpc-123", "prod", costTags)
+	require.NoError(t, err)
+	assert.Equal(t, &TagClusterResourcesResult{
+		Tagged:        ClusterResourceCounts{SecurityGroups: 1, NetworkInterfaces: 1, ClassicLoadBalancers: 1, Volumes: 1},
+		AlreadyTagged: ClusterResourceCounts{SecurityGroups: 1, NetworkInterfaces: 1, LoadBalancers: 1},
+	}, result)
+
+	expectedEC2Tags := []*ec2.Tag{
+		{Key: awsgo.String("cost-center"), Value: awsgo.String("1234")},
+		{Key: awsgo.String("team"), Value: awsgo.String("platform")},
+	}
+	assert.Equal(t, []*ec2.CreateTagsInput{
+		{Resources: awsgo.StringSlice([]string{"sg-prod"}), Tags: expectedEC2Tags},
+		{Resources: awsgo.StringSlice([]string{"eni-node"}), Tags: expectedEC2Tags},
+		{Resources: awsgo.StringSlice([]string{"vol-pv"}), Tags: expectedEC2Tags},
+	}, ec2Svc.createdTags)
+	require.Len(t, elbSvc.addedTags, 1)
+	assert.Equal(t, awsgo.StringSlice([]string{"clb-prod"}), elbSvc.addedTags[0].LoadBalancerNames)
+	assert.Empty(t, elbv2Svc.addedTags)
+}
+
+func TestTagClusterResourcesIsIdempotent(t *testing.T) {
+	t.Parallel()
+
+	ec2Svc := &fakeEC2{
+		securityGroups: []*ec2.SecurityGroup{{
+			GroupId: awsgo.String("sg-prod"),
+			Tags: []*ec2.Tag{
+				{Key: awsgo.String("aws:eks:cluster-name"), Value: awsgo.String("prod")},
+				{Key: awsgo.String("team"), Value: awsgo.String("platform")},
+			},
+		}},
+		networkInterfacePages: [][]*ec2.NetworkInterface{{}},
+	}
+
+	result, err := tagClusterResources(context.Background(), ec2Svc, &fakeELB{}, &fakeELBV2{}, "vpc-123", "prod", map[string]string{"team": "platform"})
+	require.NoError(t, err)
+	assert.Equal(t, ClusterResourceCounts{}, result.Tagged)
+	assert.Equal(t, ClusterResourceCounts{SecurityGroups: 1}, result.AlreadyTagged)
+	assert.Empty(t, ec2Svc.createdTags)
+}
@@ -0,0 +1,336 @@
+package eks
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/gruntwork-io/go-commons/errors"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+const (
+	// eksClusterSecurityGroupTagKey is the tag that EKS sets to the name of the cluster on the cluster security group.
+	eksClusterSecurityGroupTagKey = "aws:eks:cluster-name"
+
+	// clusterOwnershipTagKeyPrefix is the prefix of the kubernetes.io/cluster/<name> tag that the Kubernetes cloud
+	// provider and the load balancer controllers set on the resources they create for a cluster.
+	clusterOwnershipTagKeyPrefix = "kubernetes.io/cluster/"
+)
+
+// CleanupTarget lists the resources of an EKS cluster in a VPC that the cleanup commands would delete. This is the
+// schema of the JSON and YAML output of `kubergrunt eks describe-cleanup-targets`, which is consumed by other tools, so
+// fields may be added, but must not be renamed or removed. The lists are empty rather than null when there are no
+// resources.
+type CleanupTarget struct {
+	// ClusterName is the name of the EKS cluster the resources are tagged for.
+	ClusterName string `json:"cluster_name"`
+
+	// SecurityGroups lists the security groups of the cluster, along with the network interfaces that use them.
+	SecurityGroups []CleanupTargetSecurityGroup `json:"security_groups"`
+
+	// LoadBalancerArns lists the ARNs of the Network and Application Load Balancers owned by the cluster.
+	LoadBalancerArns []string `json:"load_balancer_arns"`
+
+	// ClassicLoadBalancerNames lists the names of the Classic Load Balancers owned by the cluster.
+	ClassicLoadBalancerNames []string `json:"classic_load_balancer_names"`
+}
+
+// CleanupTargetSecurityGroup describes a security group of an EKS cluster, and the network interfaces that have to be
+// detached and deleted to delete it.
+type CleanupTargetSecurityGroup struct {
+	ID                  string   `json:"id"`
+	Name                string   `json:"name"`
+	NetworkInterfaceIDs []string `json:"network_interface_ids"`
+}
+
+// DescribeCleanupTargets reports the resources in the VPC that the cleanup commands would affect, grouped by the name
+// of the EKS cluster they belong to, so that they can be reviewed before tearing down the clusters. This covers the
+// security groups tagged with the name of a cluster by EKS (aws:eks:cluster-name), the AWS Load Balancer Controller
+// (elbv2.k8s.aws/cluster), or the legacy ALB ingress controller (kubernetes.io/cluster-name), or tagged as owned by a
+// cluster (kubernetes.io/cluster/<name>: owned), along with the network interfaces that use them. It also covers the
+// Classic, Network, and Application Load Balancers in the VPC that are owned by a cluster. This is read only: no
+// resources are modified. The targets are sorted by cluster name.
+func DescribeCleanupTargets(region string, vpcID string) ([]CleanupTarget, error) {
+	logger := logging.GetProjectLogger()
+
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	ec2Svc := eksawshelper.NewEC2Client(sess)
+	elbSvc := elb.New(sess)
+	elbv2Svc := elbv2.New(sess)
+	logger.Infof("Successfully authenticated with AWS")
+
+	return describeCleanupTargets(context.Background(), ec2Svc, elbSvc, elbv2Svc, vpcID)
+}
+
+func describeCleanupTargets(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	elbSvc elbiface.ELBAPI,
+	elbv2Svc elbv2iface.ELBV2API,
+	vpcID string,
+) ([]CleanupTarget, error) {
+	logger := logging.GetProjectLogger().WithField("vpcID", vpcID)
+
+	targets := map[string]*CleanupTarget{}
+	targetForCluster := func(clusterName string) *CleanupTarget {
+		target, hasTarget := targets[clusterName]
+		if !hasTarget {
+			target = &CleanupTarget{
+				ClusterName:              clusterName,
+				SecurityGroups:           []CleanupTargetSecurityGroup{},
+				LoadBalancerArns:         []string{},
+				ClassicLoadBalancerNames: []string{},
+			}
+			targets[clusterName] = target
+		}
+		return target
+	}
+
+	logger.Infof("Looking up security groups and network interfaces in VPC %s", vpcID)
+	securityGroups, err := describeVPCSecurityGroups(ctx, ec2Svc, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	networkInterfaceIDsByGroup, err := describeVPCNetworkInterfaceIDsByGroup(ctx, ec2Svc, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sg := range securityGroups {
+		clusterName := clusterNameFromSecurityGroupTags(sg.Tags)
+		if clusterName == "" {
+			continue
+		}
+		groupID := aws.StringValue(sg.GroupId)
+		networkInterfaceIDs := networkInterfaceIDsByGroup[groupID]
+		if networkInterfaceIDs == nil {
+			networkInterfaceIDs = []string{}
+		}
+		target := targetForCluster(clusterName)
+		target.SecurityGroups = append(target.SecurityGroups, CleanupTargetSecurityGroup{
+			ID:                  groupID,
+			Name:                aws.StringValue(sg.GroupName),
+			NetworkInterfaceIDs: networkInterfaceIDs,
+		})
+	}
+
+	logger.Infof("Looking up load balancers in VPC %s", vpcID)
+	classicOwners, err := findClassicLoadBalancerOwnersInVPC(elbSvc, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	for _, owner := range classicOwners {
+		target := targetForCluster(owner.clusterName)
+		target.ClassicLoadBalancerNames = append(target.ClassicLoadBalancerNames, owner.loadBalancer)
+	}
+	v2Owners, err := findV2LoadBalancerOwnersInVPC(elbv2Svc, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	for _, owner := range v2Owners {
+		target := targetForCluster(owner.clusterName)
+		target.LoadBalancerArns = append(target.LoadBalancerArns, owner.loadBalancer)
+	}
+
+	clusterNames := []string{}
+	for clusterName := range targets {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	sort.Strings(clusterNames)
+	sortedTargets := []CleanupTarget{}
+	for _, clusterName := range clusterNames {
+		sortedTargets = append(sortedTargets, *targets[clusterName])
+	}
+	logger.Infof("Found cleanup targets for %d EKS clusters in VPC %s", len(sortedTargets), vpcID)
+	return sortedTargets, nil
+}
+
+// describeVPCSecurityGroups returns all the security groups in the VPC.
+func describeVPCSecurityGroups(ctx context.Context, ec2Svc ec2iface.EC2API, vpcID string) ([]*ec2.SecurityGroup, error) {
+	input := &ec2.DescribeSecurityGroupsInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("vpc-id"),
+				Values: []*string{aws.String(vpcID)},
+			},
+		},
+	}
+	securityGroups := []*ec2.SecurityGroup{}
+	// Handle pagination by repeatedly making the API call while there is a next token set.
+	for {
+		sgResult, err := ec2Svc.DescribeSecurityGroupsWithContext(ctx, input)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		securityGroups = append(securityGroups, sgResult.SecurityGroups...)
+		if sgResult.NextToken == nil {
+			break
+		}
+		input.NextToken = sgResult.NextToken
+	}
+	return securityGroups, nil
+}
+
+// describeVPCNetworkInterfaceIDsByGroup returns the IDs of the network interfaces in the VPC, keyed by the IDs of the
+// security groups that they use.
+func describeVPCNetworkInterfaceIDsByGroup(ctx context.Context, ec2Svc ec2iface.EC2API, vpcID string) (map[string][]string, error) {
+	networkInterfaces, err := describeVPCNetworkInterfaces(ctx, ec2Svc, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	networkInterfaceIDsByGroup := map[string][]string{}
+	for _, ni := range networkInterfaces {
+		for _, group := range ni.Groups {
+			groupID := aws.StringValue(group.GroupId)
+			networkInterfaceIDsByGroup[groupID] = append(networkInterfaceIDsByGroup[groupID], aws.StringValue(ni.NetworkInterfaceId))
+		}
+	}
+	return networkInterfaceIDsByGroup, nil
+}
+
+// describeVPCNetworkInterfaces returns all the network interfaces in the VPC.
+func describeVPCNetworkInterfaces(ctx context.Context, ec2Svc ec2iface.EC2API, vpcID string) ([]*ec2.NetworkInterface, error) {
+	input := &ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{
+			{
+				Name:   aws.String("vpc-id"),
+				Values: []*string{aws.String(vpcID)},
+			},
+		},
+	}
+	networkInterfaces := []*ec2.NetworkInterface{}
+	// Handle pagination by repeatedly making the API call while there is a next token set.
+	for {
+		niResult, err := ec2Svc.DescribeNetworkInterfacesWithContext(ctx, input)
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		networkInterfaces = append(networkInterfaces, niResult.NetworkInterfaces...)
+		if niResult.NextToken == nil {
+			break
+		}
+		input.NextToken = niResult.NextToken
+	}
+	return networkInterfaces, nil
+}
+
+// clusterNameFromSecurityGroupTags returns the name of the EKS cluster that the security group with the given tags
+// belongs to, or an empty string if the security group is not tagged for a cluster.
+func clusterNameFromSecurityGroupTags(tags []*ec2.Tag) string {
+	tagKeys := append([]string{eksClusterSecurityGroupTagKey}, defaultSecurityGroupTagKeys...)
+	for _, tagKey := range tagKeys {
+		for _, tag := range tags {
+			if aws.StringValue(tag.Key) == tagKey && aws.StringValue(tag.Value) != "" {
+				return aws.StringValue(tag.Value)
+			}
+		}
+	}
+	for _, tag := range tags {
+		if clusterName := clusterNameFromOwnershipTag(aws.StringValue(tag.Key), aws.StringValue(tag.Value)); clusterName != "" {
+			return clusterName
+		}
+	}
+	return ""
+}
+
+// clusterNameFromOwnershipTag returns the name of the cluster if the given tag marks the resource as owned by a cluster
+// (see isClusterOwnershipTag), or an empty string otherwise.
+func clusterNameFromOwnershipTag(key string, value string) string {
+	clusterName := strings.TrimPrefix(key, clusterOwnershipTagKeyPrefix)
+	if clusterName == key || clusterName == "" || !isClusterOwnershipTag(key, value, clusterName) {
+		return ""
+	}
+	return clusterName
+}
+
+// loadBalancerOwner records the cluster that owns a load balancer, identified by its ARN (or name, for Classic Load
+// Balancers).
+type loadBalancerOwner struct {
+	loadBalancer string
+	clusterName  string
+}
+
+// findClassicLoadBalancerOwnersInVPC returns the Classic Load Balancers in the VPC that are owned by a cluster.
+func findClassicLoadBalancerOwnersInVPC(elbSvc elbiface.ELBAPI, vpcID string) ([]loadBalancerOwner, error) {
+	names := []string{}
+	err := elbSvc.DescribeLoadBalancersPages(
+		&elb.DescribeLoadBalancersInput{},
+		func(page *elb.DescribeLoadBalancersOutput, lastPage bool) bool {
+			for _, lb := range page.LoadBalancerDescriptions {
+				if aws.StringValue(lb.VPCId) == vpcID {
+					names = append(names, aws.StringValue(lb.LoadBalancerName))
+				}
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	owners := []loadBalancerOwner{}
+	for _, batch := range batchStrings(names, describeTagsBatchSize) {
+		tagsResp, err := elbSvc.DescribeTags(&elb.DescribeTagsInput{LoadBalancerNames: aws.StringSlice(batch)})
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		for _, description := range tagsResp.TagDescriptions {
+			for _, tag := range description.Tags {
+				if clusterName := clusterNameFromOwnershipTag(aws.StringValue(tag.Key), aws.StringValue(tag.Value)); clusterName != "" {
+					owners = append(owners, loadBalancerOwner{aws.StringValue(description.LoadBalancerName), clusterName})
+					break
+				}
+			}
+		}
+	}
+	return owners, nil
+}
+
+// findV2LoadBalancerOwnersInVPC returns the Network and Application Load Balancers in the VPC that are owned by a
+// cluster.
+func findV2LoadBalancerOwnersInVPC(elbv2Svc elbv2iface.ELBV2API, vpcID string) ([]loadBalancerOwner, error) {
+	arns := []string{}
+	err := elbv2Svc.DescribeLoadBalancersPages(
+		&elbv2.DescribeLoadBalancersInput{},
+		func(page *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+			for _, lb := range page.LoadBalancers {
+				if aws.StringValue(lb.VpcId) == vpcID {
+					arns = append(arns, aws.StringValue(lb.LoadBalancerArn))
+				}
+			}
+			return true
+		},
+	)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	owners := []loadBalancerOwner{}
+	for _, batch := range batchStrings(arns, describeTagsBatchSize) {
+		tagsResp, err := elbv2Svc.DescribeTags(&elbv2.DescribeTagsInput{ResourceArns: aws.StringSlice(batch)})
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		for _, description := range tagsResp.TagDescriptions {
+			for _, tag := range description.Tags {
+				if clusterName := clusterNameFromOwnershipTag(aws.StringValue(tag.Key), aws.StringValue(tag.Value)); clusterName != "" {
+					owners = append(owners, loadBalancerOwner{aws.StringValue(description.ResourceArn), clusterName})
+					break
+				}
+			}
+		}
+	}
+	return owners, nil
+}
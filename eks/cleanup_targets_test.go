@@ -0,0 +1,230 @@
+package eks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeELB is a stub of the Classic Load Balancer API that returns a single page of load balancers, along with their
+// tags keyed by load balancer name.
+type fakeELB struct {
+	elbiface.ELBAPI
+
+	loadBalancers []*elb.LoadBalancerDescription
+	tags          map[string][]*elb.Tag
+
+	// addedTags records the inputs AddTags was called with.
+	addedTags []*elb.AddTagsInput
+}
+
+func (fake *fakeELB) AddTags(input *elb.AddTagsInput) (*elb.AddTagsOutput, error) {
+	fake.addedTags = append(fake.addedTags, input)
+	return &elb.AddTagsOutput{}, nil
+}
+
+func (fake *fakeELB) DescribeLoadBalancersPages(input *elb.DescribeLoadBalancersInput, fn func(*elb.DescribeLoadBalancersOutput, bool) bool) error {
+	fn(&elb.DescribeLoadBalancersOutput{LoadBalancerDescriptions: fake.loadBalancers}, true)
+	return nil
+}
+
+func (fake *fakeELB) DescribeTags(input *elb.DescribeTagsInput) (*elb.DescribeTagsOutput, error) {
+	output := &elb.DescribeTagsOutput{}
+	for _, name := range input.LoadBalancerNames {
+		output.TagDescriptions = append(output.TagDescriptions, &elb.TagDescription{LoadBalancerName: name, Tags: fake.tags[awsgo.StringValue(name)]})
+	}
+	return output, nil
+}
+
+// fakeELBV2 is a stub of the ELBv2 API that returns the load balancers and target groups, along with their tags keyed by
+// ARN.
+type fakeELBV2 struct {
+	elbv2iface.ELBV2API
+
+	loadBalancers []*elbv2.LoadBalancer
+	targetGroups  []*elbv2.TargetGroup
+	tags          map[string][]*elbv2.Tag
+
+	// pageSize is the number of load balancers and target groups returned in each page. Zero returns a single page.
+	pageSize int
+
+	// addedTags, describeTagsInputs, deletedLoadBalancerArns and deletedTargetGroupArns record the calls to AddTags,
+	// DescribeTags, DeleteLoadBalancer, and DeleteTargetGroup.
+	addedTags               []*elbv2.AddTagsInput
+	describeTagsInputs      []*elbv2.DescribeTagsInput
+	deletedLoadBalancerArns []string
+	deletedTargetGroupArns  []string
+}
+
+func (fake *fakeELBV2) AddTags(input *elbv2.AddTagsInput) (*elbv2.AddTagsOutput, error) {
+	fake.addedTags = append(fake.addedTags, input)
+	return &elbv2.AddTagsOutput{}, nil
+}
+
+func (fake *fakeELBV2) DescribeLoadBalancersPages(input *elbv2.DescribeLoadBalancersInput, fn func(*elbv2.DescribeLoadBalancersOutput, bool) bool) error {
+	loadBalancers := fake.loadBalancers
+	for fake.pageSize > 0 && len(loadBalancers) > fake.pageSize {
+		if !fn(&elbv2.DescribeLoadBalancersOutput{LoadBalancers: loadBalancers[:fake.pageSize]}, false) {
+			return nil
+		}
+		loadBalancers = loadBalancers[fake.pageSize:]
+	}
+	fn(&elbv2.DescribeLoadBalancersOutput{LoadBalancers: loadBalancers}, true)
+	return nil
+}
+
+func (fake *fakeELBV2) DescribeTargetGroupsPages(input *elbv2.DescribeTargetGroupsInput, fn func(*elbv2.DescribeTargetGroupsOutput, bool) bool) error {
+	targetGroups := fake.targetGroups
+	for fake.pageSize > 0 && len(targetGroups) > fake.pageSize {
+		if !fn(&elbv2.DescribeTargetGroupsOutput{TargetGroups: targetGroups[:fake.pageSize]}, false) {
+			return nil
+		}
+		targetGroups = targetGroups[fake.pageSize:]
+	}
+	fn(&elbv2.DescribeTargetGroupsOutput{TargetGroups: targetGroups}, true)
+	return nil
+}
+
+func (fake *fakeELBV2) DeleteLoadBalancer(input *elbv2.DeleteLoadBalancerInput) (*elbv2.DeleteLoadBalancerOutput, error) {
+	fake.deletedLoadBalancerArns = append(fake.deletedLoadBalancerArns, awsgo.StringValue(input.LoadBalancerArn))
+	// Detach the target groups of the load balancer, as AWS does once the load balancer is deleted.
+	for _, tg := range fake.targetGroups {
+		remaining := []*string{}
+		for _, lbArn := range tg.LoadBalancerArns {
+			if awsgo.StringValue(lbArn) != awsgo.StringValue(input.LoadBalancerArn) {
+				remaining = append(remaining, lbArn)
+			}
+		}
+		tg.LoadBalancerArns = remaining
+	}
+	return &elbv2.DeleteLoadBalancerOutput{}, nil
+}
+
+func (fake *fakeELBV2) WaitUntilLoadBalancersDeleted(input *elbv2.DescribeLoadBalancersInput) error {
+	return nil
+}
+
+func (fake *fakeELBV2) DeleteTargetGroup(input *elbv2.DeleteTargetGroupInput) (*elbv2.DeleteTargetGroupOutput, error) {
+	fake.deletedTargetGroupArns = append(fake.deletedTargetGroupArns, awsgo.StringValue(input.TargetGroupArn))
+	return &elbv2.DeleteTargetGroupOutput{}, nil
+}
+
+func (fake *fakeELBV2) DescribeTags(input *elbv2.DescribeTagsInput) (*elbv2.DescribeTagsOutput, error) {
+	fake.describeTagsInputs = append(fake.describeTagsInputs, input)
+	output := &elbv2.DescribeTagsOutput{}
+	for _, arn := range input.ResourceArns {
+		output.TagDescriptions = append(output.TagDescriptions, &elbv2.TagDescription{ResourceArn: arn, Tags: fake.tags[awsgo.StringValue(arn)]})
+	}
+	return output, nil
+}
+
+func TestDescribeCleanupTargetsGroupsByCluster(t *testing.T) {
+	t.Parallel()
+
+	securityGroup := func(groupID string, tagKey string, tagValue string) *ec2.SecurityGroup {
+		return &ec2.SecurityGroup{
+			GroupId:   awsgo.String(groupID),
+			GroupName: awsgo.String(groupID + "-name"),
+			Tags:      []*ec2.Tag{{Key: awsgo.String(tagKey), Value: awsgo.String(tagValue)}},
+		}
+	}
+	ec2Svc := &fakeEC2{
+		securityGroups: []*ec2.SecurityGroup{
+			securityGroup("sg-prod-eks", "aws:eks:cluster-name", "prod"),
+			securityGroup("sg-prod-lb", "elbv2.k8s.aws/cluster", "prod"),
+			securityGroup("sg-stage-alb", "kubernetes.io/cluster-name", "stage"),
+			securityGroup("sg-stage-elb", "kubernetes.io/cluster/stage", "owned"),
+			securityGroup("sg-shared", "kubernetes.io/cluster/stage", "shared"),
+			securityGroup("sg-other", "team", "platform"),
+		},
+		networkInterfacePages: [][]*ec2.NetworkInterface{{
+			{
+				NetworkInterfaceId: awsgo.String("eni-1"),
+				Groups:             []*ec2.GroupIdentifier{{GroupId: awsgo.String("sg-prod-eks")}, {GroupId: awsgo.String("sg-prod-lb")}},
+			},
+			{
+				NetworkInterfaceId: awsgo.String("eni-2"),
+				Groups:             []*ec2.GroupIdentifier{{GroupId: awsgo.String("sg-other")}},
+			},
+		}},
+	}
+	elbSvc := &fakeELB{
+		loadBalancers: []*elb.LoadBalancerDescription{
+			{LoadBalancerName: awsgo.String("stage-classic"), VPCId: awsgo.String("vpc-123")},
+			{LoadBalancerName: awsgo.String("other-vpc-classic"), VPCId: awsgo.String("vpc-456")},
+		},
+		tags: map[string][]*elb.Tag{
+			"stage-classic":     {{Key: awsgo.String("kubernetes.io/cluster/stage"), Value: awsgo.String("owned")}},
+			"other-vpc-classic": {{Key: awsgo.String("kubernetes.io/cluster/stage"), Value: awsgo.String("owned")}},
+		},
+	}
+	elbv2Svc := &fakeELBV2{
+		loadBalancers: []*elbv2.LoadBalancer{
+			{LoadBalancerArn: awsgo.String("arn:nlb/dev"), VpcId: awsgo.String("vpc-123")},
+			{LoadBalancerArn: awsgo.String("arn:nlb/unowned"), VpcId: awsgo.String("vpc-123")},
+		},
+		tags: map[string][]*elbv2.Tag{
+			"arn:nlb/dev": {{Key: awsgo.String("kubernetes.io/cluster/dev"), Value: awsgo.String("owned")}},
+		},
+	}
+
+	targets, err := describeCleanupTargets(context.Background(), ec2Svc, elbSvc, elbv2Svc, "vpc-123")
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		[]CleanupTarget{
+			{
+				ClusterName:              "dev",
+				SecurityGroups:           []CleanupTargetSecurityGroup{},
+				LoadBalancerArns:         []string{"arn:nlb/dev"},
+				ClassicLoadBalancerNames: []string{},
+			},
+			{
+				ClusterName: "prod",
+				SecurityGroups: []CleanupTargetSecurityGroup{
+					{ID: "sg-prod-eks", Name: "sg-prod-eks-name", NetworkInterfaceIDs: []string{"eni-1"}},
+					{ID: "sg-prod-lb", Name: "sg-prod-lb-name", NetworkInterfaceIDs: []string{"eni-1"}},
+				},
+				LoadBalancerArns:         []string{},
+				ClassicLoadBalancerNames: []string{},
+			},
+			{
+				ClusterName: "stage",
+				SecurityGroups: []CleanupTargetSecurityGroup{
+					{ID: "sg-stage-alb", Name: "sg-stage-alb-name", NetworkInterfaceIDs: []string{}},
+					{ID: "sg-stage-elb", Name: "sg-stage-elb-name", NetworkInterfaceIDs: []string{}},
+				},
+				LoadBalancerArns:         []string{},
+				ClassicLoadBalancerNames: []string{"stage-classic"},
+			},
+		},
+		targets,
+	)
+
+	// The empty lists are serialized as empty arrays rather than null.
+	data, err := json.Marshal(targets[0])
+	require.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`{"cluster_name": "dev", "security_groups": [], "load_balancer_arns": ["arn:nlb/dev"], "classic_load_balancer_names": []}`,
+		string(data),
+	)
+}
+
+func TestClusterNameFromOwnershipTag(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "prod", clusterNameFromOwnershipTag("kubernetes.io/cluster/prod", "owned"))
+	assert.Equal(t, "", clusterNameFromOwnershipTag("kubernetes.io/cluster/prod", "shared"))
+	assert.Equal(t, "", clusterNameFromOwnershipTag("kubernetes.io/cluster/", "owned"))
+	assert.Equal(t, "", clusterNameFromOwnershipTag("kubernetes.io/cluster-name", "owned"))
+}
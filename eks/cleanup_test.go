@@ -1,22 +1,733 @@
 package eks
 
 import (
+	"bytes"
+	"context"
 	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	awsgo "github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/retry"
 	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
 	"github.com/gruntwork-io/terratest/modules/aws"
 	"github.com/gruntwork-io/terratest/modules/random"
 	"github.com/gruntwork-io/terratest/modules/terraform"
 	test_structure "github.com/gruntwork-io/terratest/modules/test-structure"
+	"github.com/hashicorp/go-multierror"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 )
 
 const cleanupTestCasesFolder = "./fixture/cleanup-test"
 
+// fakeEC2 is a stub of the EC2 API for unit testing the cleanup routines without hitting AWS. Only the methods that are
+// used in the tests are implemented: calling any other method will panic.
+type fakeEC2 struct {
+	ec2iface.EC2API
+
+	// networkInterfacePages is the list of pages returned by DescribeNetworkInterfaces, in order.
+	networkInterfacePages [][]*ec2.NetworkInterface
+
+	// deleteVolumeErrs maps volume IDs to the error returned by DeleteVolume for that volume.
+	deleteVolumeErrs map[string]error
+
+	// deletedVolumeIDs records the volume IDs that DeleteVolume was called with.
+	deletedVolumeIDs []string
+
+	// volumes is the list of volumes returned by DescribeVolumesPages, regardless of the filters.
+	volumes []*ec2.Volume
+
+	// securityGroups is the list of security groups returned by DescribeSecurityGroups, regardless of the filters.
+	securityGroups []*ec2.SecurityGroup
+
+	// describeSecurityGroupsErr is the error returned by DescribeSecurityGroups.
+	describeSecurityGroupsErr error
+
+	// describeSecurityGroupsInputs records the inputs DescribeSecurityGroups was called with.
+	describeSecurityGroupsInputs []*ec2.DescribeSecurityGroupsInput
+
+	// deleteSecurityGroupErr is the error returned by DeleteSecurityGroup.
+	deleteSecurityGroupErr error
+
+	// revokedIngress and revokedEgress record the inputs RevokeSecurityGroupIngress and RevokeSecurityGroupEgress were
+	// called with.
+	revokedIngress []*ec2.RevokeSecurityGroupIngressInput
+	revokedEgress  []*ec2.RevokeSecurityGroupEgressInput
+
+	// createdTags records the inputs CreateTags was called with.
+	createdTags []*ec2.CreateTagsInput
+}
+
+func (fake *fakeEC2) CreateTagsWithContext(ctx awsgo.Context, input *ec2.CreateTagsInput, opts ...request.Option) (*ec2.CreateTagsOutput, error) {
+	fake.createdTags = append(fake.createdTags, input)
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+func (fake *fakeEC2) RevokeSecurityGroupIngressWithContext(ctx awsgo.Context, input *ec2.RevokeSecurityGroupIngressInput, opts ...request.Option) (*ec2.RevokeSecurityGroupIngressOutput, error) {
+	fake.revokedIngress = append(fake.revokedIngress, input)
+	return &ec2.RevokeSecurityGroupIngressOutput{}, nil
+}
+
+func (fake *fakeEC2) RevokeSecurityGroupEgressWithContext(ctx awsgo.Context, input *ec2.RevokeSecurityGroupEgressInput, opts ...request.Option) (*ec2.RevokeSecurityGroupEgressOutput, error) {
+	fake.revokedEgress = append(fake.revokedEgress, input)
+	return &ec2.RevokeSecurityGroupEgressOutput{}, nil
+}
+
+func (fake *fakeEC2) DescribeSecurityGroupsWithContext(ctx awsgo.Context, input *ec2.DescribeSecurityGroupsInput, opts ...request.Option) (*ec2.DescribeSecurityGroupsOutput, error) {
+	fake.describeSecurityGroupsInputs = append(fake.describeSecurityGroupsInputs, input)
+	if fake.describeSecurityGroupsErr != nil {
+		return nil, fake.describeSecurityGroupsErr
+	}
+	return &ec2.DescribeSecurityGroupsOutput{SecurityGroups: fake.securityGroups}, nil
+}
+
+func (fake *fakeEC2) DeleteSecurityGroupWithContext(ctx awsgo.Context, input *ec2.DeleteSecurityGroupInput, opts ...request.Option) (*ec2.DeleteSecurityGroupOutput, error) {
+	return &ec2.DeleteSecurityGroupOutput{}, fake.deleteSecurityGroupErr
+}
+
+func (fake *fakeEC2) DeleteVolume(input *ec2.DeleteVolumeInput) (*ec2.DeleteVolumeOutput, error) {
+	volumeID := awsgo.StringValue(input.VolumeId)
+	fake.deletedVolumeIDs = append(fake.deletedVolumeIDs, volumeID)
+	return &ec2.DeleteVolumeOutput{}, fake.deleteVolumeErrs[volumeID]
+}
+
+func (fake *fakeEC2) DescribeVolumesPages(input *ec2.DescribeVolumesInput, fn func(*ec2.DescribeVolumesOutput, bool) bool) error {
+	fn(&ec2.DescribeVolumesOutput{Volumes: fake.volumes}, true)
+	return nil
+}
+
+func (fake *fakeEC2) DescribeNetworkInterfacesWithContext(ctx awsgo.Context, input *ec2.DescribeNetworkInterfacesInput, opts ...request.Option) (*ec2.DescribeNetworkInterfacesOutput, error) {
+	page := 0
+	if input.NextToken != nil {
+		var err error
+		page, err = strconv.Atoi(awsgo.StringValue(input.NextToken))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	output := &ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: fake.networkInterfacePages[page]}
+	if page+1 < len(fake.networkInterfacePages) {
+		output.NextToken = awsgo.String(strconv.Itoa(page + 1))
+	}
+	return output, nil
+}
+
+// fakeEKS is a stub of the EKS API that describes a single cluster. When cluster is nil, the cluster is not found.
+type fakeEKS struct {
+	eksiface.EKSAPI
+
+	cluster *eks.Cluster
+}
+
+func (fake *fakeEKS) DescribeClusterWithContext(ctx awsgo.Context, input *eks.DescribeClusterInput, opts ...request.Option) (*eks.DescribeClusterOutput, error) {
+	if fake.cluster == nil {
+		return nil, awserr.New(eks.ErrCodeResourceNotFoundException, "No cluster found", nil)
+	}
+	return &eks.DescribeClusterOutput{Cluster: fake.cluster}, nil
+}
+
+// fakeNetworkInterfaceEC2 is a stateful stub of the EC2 API for the network interfaces of a security group, so that the
+// cleanup can be run repeatedly against the same resources. Detaching a network interface marks it as detached, and
+// deleting it removes it.
+type fakeNetworkInterfaceEC2 struct {
+	ec2iface.EC2API
+
+	mutex sync.Mutex
+
+	// networkInterfaces maps the IDs of the existing network interfaces to their state.
+	networkInterfaces map[string]*ec2.NetworkInterface
+
+	// deleteNetworkInterfaceErrs maps network interface IDs to the error returned by DeleteNetworkInterface.
+	deleteNetworkInterfaceErrs map[string]error
+
+	// detachNetworkInterfaceErr, when set, is the error returned by DetachNetworkInterface.
+	detachNetworkInterfaceErr error
+
+	// detachedAttachmentIDs records the attachment IDs that DetachNetworkInterface was called with.
+	detachedAttachmentIDs []string
+}
+
+// copyNetworkInterface returns a copy of the network interface, so that the callers do not race with state changes.
+func copyNetworkInterface(ni *ec2.NetworkInterface) *ec2.NetworkInterface {
+	copied := *ni
+	if ni.Attachment != nil {
+		attachment := *ni.Attachment
+		copied.Attachment = &attachment
+	}
+	return &copied
+}
+
+func (fake *fakeNetworkInterfaceEC2) DescribeNetworkInterfacesWithContext(ctx awsgo.Context, input *ec2.DescribeNetworkInterfacesInput, opts ...request.Option) (*ec2.DescribeNetworkInterfacesOutput, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	networkInterfaceIDs := awsgo.StringValueSlice(input.NetworkInterfaceIds)
+	if len(networkInterfaceIDs) == 0 {
+		for networkInterfaceID := range fake.networkInterfaces {
+			networkInterfaceIDs = append(networkInterfaceIDs, networkInterfaceID)
+		}
+		sort.Strings(networkInterfaceIDs)
+	}
+	output := &ec2.DescribeNetworkInterfacesOutput{}
+	for _, networkInterfaceID := range networkInterfaceIDs {
+		ni, exists := fake.networkInterfaces[networkInterfaceID]
+		if !exists {
+			return nil, awserr.New("InvalidNetworkInterfaceID.NotFound", "The networkInterface ID does not exist", nil)
+		}
+		output.NetworkInterfaces = append(output.NetworkInterfaces, copyNetworkInterface(ni))
+	}
+	return output, nil
+}
+
+func (fake *fakeNetworkInterfaceEC2) DescribeNetworkInterfaceAttributeWithContext(ctx awsgo.Context, input *ec2.DescribeNetworkInterfaceAttributeInput, opts ...request.Option) (*ec2.DescribeNetworkInterfaceAttributeOutput, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	ni, exists := fake.networkInterfaces[awsgo.StringValue(input.NetworkInterfaceId)]
+	if !exists {
+		return nil, awserr.New("InvalidNetworkInterfaceID.NotFound", "The networkInterface ID does not exist", nil)
+	}
+	// Detachments that are in progress complete by the time they are polled.
+	if ni.Attachment != nil && awsgo.StringValue(ni.Attachment.Status) == ec2.AttachmentStatusDetaching {
+		ni.Attachment.Status = awsgo.String(ec2.AttachmentStatusDetached)
+	}
+	return &ec2.DescribeNetworkInterfaceAttributeOutput{Attachment: copyNetworkInterface(ni).Attachment}, nil
+}
+
+func (fake *fakeNetworkInterfaceEC2) DetachNetworkInterfaceWithContext(ctx awsgo.Context, input *ec2.DetachNetworkInterfaceInput, opts ...request.Option) (*ec2.DetachNetworkInterfaceOutput, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	attachmentID := awsgo.StringValue(input.AttachmentId)
+	fake.detachedAttachmentIDs = append(fake.detachedAttachmentIDs, attachmentID)
+	if fake.detachNetworkInterfaceErr != nil {
+		return nil, fake.detachNetworkInterfaceErr
+	}
+	for _, ni := range fake.networkInterfaces {
+		if ni.Attachment != nil && awsgo.StringValue(ni.Attachment.AttachmentId) == attachmentID {
+			ni.Attachment.Status = awsgo.String(ec2.AttachmentStatusDetached)
+			return &ec2.DetachNetworkInterfaceOutput{}, nil
+		}
+	}
+	return nil, awserr.New("InvalidAttachmentID.NotFound", "The attachment ID does not exist", nil)
+}
+
+func (fake *fakeNetworkInterfaceEC2) DeleteNetworkInterfaceWithContext(ctx awsgo.Context, input *ec2.DeleteNetworkInterfaceInput, opts ...request.Option) (*ec2.DeleteNetworkInterfaceOutput, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+
+	networkInterfaceID := awsgo.StringValue(input.NetworkInterfaceId)
+	if err := fake.deleteNetworkInterfaceErrs[networkInterfaceID]; err != nil {
+		return nil, err
+	}
+	if _, exists := fake.networkInterfaces[networkInterfaceID]; !exists {
+		return nil, awserr.New("InvalidNetworkInterfaceID.NotFound", "The networkInterface ID does not exist", nil)
+	}
+	delete(fake.networkInterfaces, networkInterfaceID)
+	return &ec2.DeleteNetworkInterfaceOutput{}, nil
+}
+
+func TestVerifyClusterDeleted(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		clusterInfo *eksawshelper.ClusterInfo
+		expectError bool
+	}{
+		{"not-found", nil, false},
+		{"deleting", &eksawshelper.ClusterInfo{Status: eks.ClusterStatusDeleting}, false},
+		{"failed", &eksawshelper.ClusterInfo{Status: eks.ClusterStatusFailed}, false},
+		{"active", &eksawshelper.ClusterInfo{Status: eks.ClusterStatusActive}, true},
+		{"creating", &eksawshelper.ClusterInfo{Status: eks.ClusterStatusCreating}, true},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			clusterArn := "arn:aws:eks:us-east-1:111111111111:cluster/test"
+			err := verifyClusterDeleted(clusterArn, testCase.clusterInfo)
+			if !testCase.expectError {
+				require.NoError(t, err)
+				return
+			}
+			notDeletedErr, isNotDeletedErr := errors.Unwrap(err).(ClusterNotDeletedError)
+			require.True(t, isNotDeletedErr)
+			require.Equal(t, testCase.clusterInfo.Status, notDeletedErr.Status)
+		})
+	}
+}
+
+func TestWaitForNetworkInterfacesToBeDeletedReturnsDeletedTimeoutError(t *testing.T) {
+	t.Parallel()
+
+	stuckNI := &ec2.NetworkInterface{
+		NetworkInterfaceId: awsgo.String("eni-stuck"),
+		Status:             awsgo.String(ec2.NetworkInterfaceStatusInUse),
+	}
+	fake := &fakeEC2{networkInterfacePages: [][]*ec2.NetworkInterface{{stuckNI}}}
+	backoff := BackoffConfig{Base: 1 * time.Millisecond, Max: 5 * time.Millisecond}
+
+	progress := newCleanupProgress(logging.GetProjectLogger(), 1, cleanupProgressReportInterval)
+	err := waitForNetworkInterfacesToBeDeleted(context.Background(), fake, []*ec2.NetworkInterface{stuckNI}, 1, backoff, 20*time.Millisecond, progress)
+	require.Error(t, err)
+
+	multiErr, isMultiErr := err.(*multierror.Error)
+	require.True(t, isMultiErr)
+	require.Len(t, multiErr.Errors, 1)
+	timeoutErr, isTimeoutErr := errors.Unwrap(multiErr.Errors[0]).(NetworkInterfaceDeletedTimeoutError)
+	require.True(t, isTimeoutErr)
+	require.Equal(t, "eni-stuck", timeoutErr.NetworkInterfaceID)
+	require.Equal(t, ec2.NetworkInterfaceStatusInUse, timeoutErr.LastStatus)
+	require.Contains(t, timeoutErr.Error(), "in-use")
+}
+
+func TestDeleteDependenciesResumesAfterInterruptedCleanup(t *testing.T) {
+	t.Parallel()
+
+	attachedNI := func(networkInterfaceID string, status string) *ec2.NetworkInterface {
+		return &ec2.NetworkInterface{
+			NetworkInterfaceId: awsgo.String(networkInterfaceID),
+			Status:             awsgo.String(ec2.NetworkInterfaceStatusInUse),
+			Attachment: &ec2.NetworkInterfaceAttachment{
+				AttachmentId: awsgo.String("attach-" + networkInterfaceID),
+				Status:       awsgo.String(status),
+			},
+		}
+	}
+	fake := &fakeNetworkInterfaceEC2{
+		networkInterfaces: map[string]*ec2.NetworkInterface{
+			"eni-1": attachedNI("eni-1", ec2.AttachmentStatusAttached),
+			"eni-2": attachedNI("eni-2", ec2.AttachmentStatusAttached),
+			"eni-3": attachedNI("eni-3", ec2.AttachmentStatusDetaching),
+		},
+		// Interrupt the first run after the detach phase.
+		deleteNetworkInterfaceErrs: map[string]error{
+			"eni-1": awserr.New("UnauthorizedOperation", "You are not authorized to perform this operation.", nil),
+		},
+	}
+	options := CleanupOptions{
+		MaxRetries:          2,
+		SleepBetweenRetries: 1 * time.Millisecond,
+		Concurrency:         1,
+		Backoff:             BackoffConfig{Base: 1 * time.Millisecond, Max: 5 * time.Millisecond},
+	}.withDefaults()
+
+	_, err := deleteDependencies(context.Background(), fake, "sg-eks", options, &CleanupResult{})
+	require.Error(t, err)
+	// The network interface that was already being detached is not detached again.
+	require.Equal(t, []string{"attach-eni-1", "attach-eni-2"}, fake.detachedAttachmentIDs)
+	require.Contains(t, fake.networkInterfaces, "eni-1")
+
+	// Rerunning the cleanup skips the detach of the network interfaces that are already detached, and deletes the rest.
+	fake.deleteNetworkInterfaceErrs = nil
+	result := &CleanupResult{}
+	deletedNetworkInterfaceIDs, err := deleteDependencies(context.Background(), fake, "sg-eks", options, result)
+	require.NoError(t, err)
+	require.Equal(t, []string{"attach-eni-1", "attach-eni-2"}, fake.detachedAttachmentIDs)
+	require.Empty(t, fake.networkInterfaces)
+	require.ElementsMatch(t, result.foundNetworkInterfaceIDs, deletedNetworkInterfaceIDs)
+
+	// Running the cleanup again once everything is deleted is a no-op.
+	deletedNetworkInterfaceIDs, err = deleteDependencies(context.Background(), fake, "sg-eks", options, &CleanupResult{})
+	require.NoError(t, err)
+	require.Empty(t, deletedNetworkInterfaceIDs)
+}
+
+func TestDetachNetworkInterfaceToleratesDeletedNetworkInterface(t *testing.T) {
+	t.Parallel()
+
+	// The network interface was deleted after it was looked up.
+	fake := &fakeNetworkInterfaceEC2{
+		networkInterfaces:         map[string]*ec2.NetworkInterface{},
+		detachNetworkInterfaceErr: awserr.New("InvalidNetworkInterfaceID.NotFound", "The networkInterface ID does not exist", nil),
+	}
+	ni := &ec2.NetworkInterface{
+		NetworkInterfaceId: awsgo.String("eni-gone"),
+		Attachment: &ec2.NetworkInterfaceAttachment{
+			AttachmentId: awsgo.String("attach-eni-gone"),
+			Status:       awsgo.String(ec2.AttachmentStatusAttached),
+		},
+	}
+	err := detachNetworkInterface(context.Background(), fake, logging.GetProjectLogger(), ni, false)
+	require.NoError(t, err)
+}
+
+func TestDeleteNetworkInterfacesAbortsPromptlyOnCancellation(t *testing.T) {
+	t.Parallel()
+
+	inUseNI := &ec2.NetworkInterface{NetworkInterfaceId: awsgo.String("eni-in-use")}
+	fake := &fakeNetworkInterfaceEC2{
+		networkInterfaces: map[string]*ec2.NetworkInterface{"eni-in-use": inUseNI},
+		deleteNetworkInterfaceErrs: map[string]error{
+			"eni-in-use": awserr.New("InvalidParameterValue", "Network interface 'eni-in-use' is currently in use.", nil),
+		},
+	}
+	// The sleep between retries is much longer than the test, so that a return after the cancellation can only come from
+	// aborting the sleep.
+	backoff := BackoffConfig{Base: time.Minute, Max: time.Minute}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := deleteNetworkInterfaces(ctx, fake, logging.GetProjectLogger(), []*ec2.NetworkInterface{inUseNI}, 1, false, backoff, time.Hour)
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 10*time.Second)
+	require.Contains(t, err.Error(), context.Canceled.Error())
+	require.Contains(t, fake.networkInterfaces, "eni-in-use")
+}
+
+func TestCleanupProgressThrottlesReports(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.Out = &out
+	logger.Formatter = &logrus.TextFormatter{DisableTimestamp: true}
+
+	// With a long report interval, only the first update and the completion of each phase are logged.
+	progress := newCleanupProgress(logrus.NewEntry(logger), 3, time.Hour)
+	for i := 0; i < 3; i++ {
+		progress.markDetached()
+	}
+	for i := 0; i < 3; i++ {
+		progress.markDeleted()
+	}
+
+	require.Equal(
+		t,
+		[]string{
+			"Detached 1/3 and deleted 0/3 network interfaces (16% complete)",
+			"Detached 3/3 and deleted 0/3 network interfaces (50% complete)",
+			"Detached 3/3 and deleted 3/3 network interfaces (100% complete)",
+		},
+		parseTestLogMessages(t, out.String()),
+	)
+}
+
+func TestCleanupRegion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		overrideRegion   string
+		expectedRegion   string
+		expectedMessages []string
+	}{
+		{"NoOverride", "", "us-east-1", []string{}},
+		{"SameRegion", "us-east-1", "us-east-1", []string{}},
+		{
+			"DifferentRegion",
+			"us-west-2",
+			"us-west-2",
+			[]string{"Using region us-west-2 for the EC2 API calls instead of the region us-east-1 of the EKS cluster ARN."},
+		},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+			logger := logrus.New()
+			logger.Out = &out
+			logger.Formatter = &logrus.TextFormatter{DisableTimestamp: true}
+
+			region := cleanupRegion(logrus.NewEntry(logger), "us-east-1", testCase.overrideRegion)
+			require.Equal(t, testCase.expectedRegion, region)
+			if len(testCase.expectedMessages) == 0 {
+				require.Empty(t, out.String())
+			} else {
+				require.Equal(t, testCase.expectedMessages, parseTestLogMessages(t, out.String()))
+			}
+		})
+	}
+}
+
+// parseTestLogMessages returns the messages in the given text formatted logs.
+func parseTestLogMessages(t *testing.T, logs string) []string {
+	messages := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(logs), "\n") {
+		_, message, found := strings.Cut(line, "msg=")
+		require.True(t, found)
+		unquoted, err := strconv.Unquote(message)
+		require.NoError(t, err)
+		messages = append(messages, unquoted)
+	}
+	return messages
+}
+
+func TestCleanupSecurityGroupReportsBlockingDependencies(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeEC2{
+		networkInterfacePages:  [][]*ec2.NetworkInterface{{}},
+		securityGroups:         []*ec2.SecurityGroup{{GroupId: awsgo.String("sg-referencing")}},
+		deleteSecurityGroupErr: awserr.New("DependencyViolation", "resource sg-1 has a dependent object", nil),
+	}
+	options := CleanupOptions{
+		MaxRetries:          2,
+		SleepBetweenRetries: 10 * time.Millisecond,
+		Backoff:             BackoffConfig{Base: 1 * time.Millisecond, Max: 5 * time.Millisecond},
+	}.withDefaults()
+
+	err := cleanupSecurityGroup(context.Background(), fake, "sg-1", options, &CleanupResult{})
+	require.Error(t, err)
+
+	dependencyErr, isDependencyErr := errors.Unwrap(err).(SecurityGroupDependencyViolationError)
+	require.True(t, isDependencyErr)
+	require.Equal(t, "sg-1", dependencyErr.SecurityGroupID)
+	require.Equal(t, []string{"sg-referencing"}, dependencyErr.ReferencingSecurityGroupIDs)
+}
+
+func TestLookupSecurityGroupsMatchesEachTagFilter(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeEC2{
+		securityGroups: []*ec2.SecurityGroup{
+			{GroupId: awsgo.String("sg-eks")},
+			{GroupId: awsgo.String("sg-lb")},
+		},
+	}
+	tagFilters := map[string]string{"team": "platform", "cleanup": ""}
+
+	securityGroups, err := lookupSecurityGroups(context.Background(), fake, "vpc-123", "prod", tagFilters, "sg-eks")
+	require.NoError(t, err)
+
+	// The excluded security group is skipped, and each security group is returned once even when it matches every filter.
+	require.Len(t, securityGroups, 1)
+	require.Equal(t, "sg-lb", awsgo.StringValue(securityGroups[0].GroupId))
+
+	tagFilterStrings := []string{}
+	for _, input := range fake.describeSecurityGroupsInputs {
+		require.Len(t, input.Filters, 2)
+		require.Equal(t, "vpc-id", awsgo.StringValue(input.Filters[0].Name))
+		tagFilter := input.Filters[1]
+		tagFilterStrings = append(tagFilterStrings, awsgo.StringValue(tagFilter.Name)+"="+awsgo.StringValue(tagFilter.Values[0]))
+	}
+	require.Equal(
+		t,
+		[]string{
+			"tag:elbv2.k8s.aws/cluster=prod",
+			"tag:kubernetes.io/cluster-name=prod",
+			"tag-key=cleanup",
+			"tag:team=platform",
+		},
+		tagFilterStrings,
+	)
+}
+
+func TestCleanupClusterSecurityGroupsSucceedsWhenVPCIsDeleted(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeEC2{describeSecurityGroupsErr: awserr.New("InvalidVpcID.NotFound", "The vpc ID 'vpc-123' does not exist", nil)}
+
+	result, err := cleanupClusterSecurityGroups(context.Background(), fake, "prod", "sg-eks", "vpc-123", DefaultCleanupOptions())
+	require.NoError(t, err)
+	require.Empty(t, result.DeletedSecurityGroupIDs)
+}
+
+func TestCleanupClusterSecurityGroupsSkipsLoadBalancerSweep(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeEC2{
+		securityGroups:        []*ec2.SecurityGroup{{GroupId: awsgo.String("sg-lb")}},
+		networkInterfacePages: [][]*ec2.NetworkInterface{{}},
+	}
+	options := DefaultCleanupOptions()
+	options.SkipLoadBalancerSweep = true
+	options.TagFilters = map[string]string{"team": "platform"}
+
+	result, err := cleanupClusterSecurityGroups(context.Background(), fake, "prod", "sg-eks", "vpc-123", options)
+	require.NoError(t, err)
+	require.Equal(t, []string{"sg-eks"}, result.DeletedSecurityGroupIDs)
+	require.Empty(t, fake.describeSecurityGroupsInputs)
+}
+
+func TestCleanupClusterSecurityGroupsReturnsPartialResultOnTimeout(t *testing.T) {
+	t.Parallel()
+
+	// The network interface is deleted, but the security group stays referenced until the overall timeout runs out.
+	fake := &fakeEC2{
+		networkInterfacePages:  [][]*ec2.NetworkInterface{{}},
+		deleteSecurityGroupErr: awserr.New("DependencyViolation", "resource sg-eks has a dependent object", nil),
+	}
+	options := CleanupOptions{
+		Backoff:        BackoffConfig{Base: 1 * time.Millisecond, Max: 5 * time.Millisecond},
+		OverallTimeout: 50 * time.Millisecond,
+	}.withDefaults()
+
+	result, err := cleanupClusterSecurityGroups(context.Background(), fake, "prod", "sg-eks", "vpc-123", options)
+	require.Error(t, err)
+	require.NotNil(t, result)
+	require.Empty(t, result.DeletedSecurityGroupIDs)
+	require.Greater(t, result.PhaseDurations.Total, options.OverallTimeout)
+
+	timeoutErr, isTimeoutErr := errors.Unwrap(err).(CleanupTimeoutError)
+	require.True(t, isTimeoutErr)
+	require.Equal(t, []string{"sg-eks"}, timeoutErr.RemainingSecurityGroupIDs)
+	require.Empty(t, timeoutErr.RemainingNetworkInterfaceIDs)
+}
+
+func TestCleanupResultRemaining(t *testing.T) {
+	t.Parallel()
+
+	result := &CleanupResult{
+		DeletedSecurityGroupIDs:     []string{"sg-lb"},
+		DeletedNetworkInterfaceIDs:  []string{"eni-1"},
+		AlreadyGoneSecurityGroupIDs: []string{"sg-gone"},
+		foundSecurityGroupIDs:       []string{"sg-eks", "sg-lb", "sg-gone", "sg-custom"},
+		foundNetworkInterfaceIDs:    []string{"eni-1", "eni-2"},
+	}
+	remainingSecurityGroupIDs, remainingNetworkInterfaceIDs := result.remaining()
+	require.Equal(t, []string{"sg-eks", "sg-custom"}, remainingSecurityGroupIDs)
+	require.Equal(t, []string{"eni-2"}, remainingNetworkInterfaceIDs)
+}
+
+func TestCleanupPhaseDurationsString(t *testing.T) {
+	t.Parallel()
+
+	durations := CleanupPhaseDurations{
+		Describe:          1200 * time.Millisecond,
+		Detach:            30 * time.Second,
+		WaitDetach:        2 * time.Second,
+		Delete:            8*time.Second + 40*time.Millisecond,
+		WaitDelete:        500 * time.Millisecond,
+		LoadBalancerSweep: 12 * time.Second,
+		Total:             42 * time.Second,
+	}
+	require.Equal(
+		t,
+		"42s (describe 1.2s, detach 30s, wait-detach 2s, delete 8s, wait-delete 500ms, load balancer sweep 12s)",
+		durations.String(),
+	)
+}
+
+func TestIsVPCNotFoundErr(t *testing.T) {
+	t.Parallel()
+
+	vpcNotFoundErr := awserr.New("InvalidVpcID.NotFound", "The vpc ID 'vpc-123' does not exist", nil)
+	require.True(t, isVPCNotFoundErr(errors.WithStackTrace(vpcNotFoundErr)))
+	require.True(t, isVPCNotFoundErr(multierror.Append(nil, errors.WithStackTrace(retry.FatalError{Underlying: vpcNotFoundErr}))))
+	require.False(t, isVPCNotFoundErr(awserr.New("InvalidGroup.NotFound", "The security group does not exist", nil)))
+	require.False(t, isVPCNotFoundErr(nil))
+}
+
+func TestRevokeCrossReferencingRules(t *testing.T) {
+	t.Parallel()
+
+	groupPair := func(groupID string) *ec2.UserIdGroupPair {
+		return &ec2.UserIdGroupPair{GroupId: awsgo.String(groupID)}
+	}
+	securityGroups := []*ec2.SecurityGroup{
+		{
+			GroupId: awsgo.String("sg-a"),
+			IpPermissions: []*ec2.IpPermission{
+				{
+					IpProtocol:       awsgo.String("tcp"),
+					FromPort:         awsgo.Int64(443),
+					ToPort:           awsgo.Int64(443),
+					UserIdGroupPairs: []*ec2.UserIdGroupPair{groupPair("sg-b"), groupPair("sg-external")},
+					IpRanges:         []*ec2.IpRange{{CidrIp: awsgo.String("10.0.0.0/16")}},
+				},
+				{
+					IpProtocol:       awsgo.String("-1"),
+					UserIdGroupPairs: []*ec2.UserIdGroupPair{groupPair("sg-a")},
+				},
+			},
+			IpPermissionsEgress: []*ec2.IpPermission{
+				{
+					IpProtocol:       awsgo.String("-1"),
+					UserIdGroupPairs: []*ec2.UserIdGroupPair{groupPair("sg-eks")},
+				},
+			},
+		},
+		{
+			GroupId: awsgo.String("sg-b"),
+			IpPermissions: []*ec2.IpPermission{
+				{
+					IpProtocol: awsgo.String("tcp"),
+					IpRanges:   []*ec2.IpRange{{CidrIp: awsgo.String("0.0.0.0/0")}},
+				},
+			},
+		},
+	}
+	fake := &fakeEC2{}
+
+	require.NoError(t, revokeCrossReferencingRules(context.Background(), fake, securityGroups, []string{"sg-eks", "sg-a", "sg-b"}, false))
+
+	// Only the rule referencing sg-b should be revoked from the ingress of sg-a, without touching the CIDR source, the
+	// external group, or the self reference.
+	require.Len(t, fake.revokedIngress, 1)
+	require.Equal(t, "sg-a", awsgo.StringValue(fake.revokedIngress[0].GroupId))
+	require.Len(t, fake.revokedIngress[0].IpPermissions, 1)
+	revokedIngress := fake.revokedIngress[0].IpPermissions[0]
+	require.Equal(t, []*ec2.UserIdGroupPair{groupPair("sg-b")}, revokedIngress.UserIdGroupPairs)
+	require.Empty(t, revokedIngress.IpRanges)
+	require.Equal(t, int64(443), awsgo.Int64Value(revokedIngress.FromPort))
+
+	require.Len(t, fake.revokedEgress, 1)
+	require.Equal(t, "sg-a", awsgo.StringValue(fake.revokedEgress[0].GroupId))
+	require.Equal(t, []*ec2.UserIdGroupPair{groupPair("sg-eks")}, fake.revokedEgress[0].IpPermissions[0].UserIdGroupPairs)
+}
+
+func TestCleanupOptionsWithDefaults(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, DefaultCleanupOptions(), CleanupOptions{}.withDefaults())
+
+	options := CleanupOptions{MaxRetries: 60, DryRun: true}.withDefaults()
+	require.Equal(t, 60, options.MaxRetries)
+	require.Equal(t, waitSleepBetweenRetries, options.SleepBetweenRetries)
+	require.Equal(t, DefaultCleanupConcurrency, options.Concurrency)
+	require.True(t, options.DryRun)
+	require.Equal(t, 10*time.Minute, options.waitTimeout())
+}
+
+func TestFindNetworkInterfacesCollectsAllPages(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeEC2{
+		networkInterfacePages: [][]*ec2.NetworkInterface{
+			{
+				{NetworkInterfaceId: awsgo.String("eni-1")},
+				{NetworkInterfaceId: awsgo.String("eni-2")},
+			},
+			{
+				{NetworkInterfaceId: awsgo.String("eni-3")},
+			},
+		},
+	}
+
+	networkInterfaces, err := findNetworkInterfaces(context.Background(), fake, "sg-1")
+	require.NoError(t, err)
+
+	networkInterfaceIDs := []string{}
+	for _, ni := range networkInterfaces {
+		networkInterfaceIDs = append(networkInterfaceIDs, awsgo.StringValue(ni.NetworkInterfaceId))
+	}
+	require.Equal(t, []string{"eni-1", "eni-2", "eni-3"}, networkInterfaceIDs)
+}
+
 func TestDeleteSecurityGroupDependencies(t *testing.T) {
 	t.Parallel()
 
@@ -55,10 +766,12 @@ func testSGDependencyCleanup(t *testing.T, exampleName string) {
 	securityGroupId := terraform.OutputRequired(t, opts, "security_group_id")
 	sess, err := eksawshelper.NewAuthenticatedSession(awsRegion)
 	require.NoError(t, err)
-	ec2Svc := ec2.New(sess)
-	require.NoError(t, deleteDependencies(ec2Svc, securityGroupId))
+	ec2Svc := eksawshelper.NewEC2Client(sess)
+	deletedNetworkInterfaceIDs, err := deleteDependencies(context.Background(), ec2Svc, securityGroupId, DefaultCleanupOptions(), &CleanupResult{})
+	require.NoError(t, err)
 
 	networkInterfaceId := terraform.OutputRequired(t, opts, "eni_id")
+	require.Contains(t, deletedNetworkInterfaceIDs, networkInterfaceId)
 	describeNetworkInterfacesInput := &ec2.DescribeNetworkInterfacesInput{
 		NetworkInterfaceIds: awsgo.StringSlice([]string{networkInterfaceId}),
 	}
@@ -71,3 +784,90 @@ func testSGDependencyCleanup(t *testing.T, exampleName string) {
 	require.Equal(t, awsErr.Code(), "InvalidNetworkInterfaceID.NotFound")
 
 }
+
+func TestLookupClusterVPCConfig(t *testing.T) {
+	t.Parallel()
+
+	clusterArn := "arn:aws:eks:us-east-1:111111111111:cluster/test"
+	clusterInfo := &eksawshelper.ClusterInfo{ClusterSecurityGroupID: "sg-cluster", VPCID: "vpc-cluster"}
+
+	testCases := []struct {
+		name                    string
+		clusterInfo             *eksawshelper.ClusterInfo
+		securityGroupID         string
+		vpcID                   string
+		expectedSecurityGroupID string
+		expectedVPCID           string
+		expectNotFound          bool
+	}{
+		{"explicit-ids", nil, "sg-explicit", "vpc-explicit", "sg-explicit", "vpc-explicit", false},
+		{"looked-up-ids", clusterInfo, "", "", "sg-cluster", "vpc-cluster", false},
+		{"looked-up-security-group", clusterInfo, "", "vpc-explicit", "sg-cluster", "vpc-explicit", false},
+		{"looked-up-vpc", clusterInfo, "sg-explicit", "", "sg-explicit", "vpc-cluster", false},
+		{"deleted-cluster", nil, "", "vpc-explicit", "", "", true},
+		{"no-vpc-config", &eksawshelper.ClusterInfo{}, "", "", "", "", true},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			securityGroupID, vpcID, err := lookupClusterVPCConfig(clusterArn, testCase.clusterInfo, testCase.securityGroupID, testCase.vpcID)
+			if testCase.expectNotFound {
+				_, isNotFoundErr := errors.Unwrap(err).(ClusterVPCConfigNotFoundError)
+				require.True(t, isNotFoundErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedSecurityGroupID, securityGroupID)
+			require.Equal(t, testCase.expectedVPCID, vpcID)
+		})
+	}
+}
+
+func TestVerifySecurityGroupOfCluster(t *testing.T) {
+	t.Parallel()
+
+	tag := func(key string, value string) *ec2.Tag {
+		return &ec2.Tag{Key: awsgo.String(key), Value: awsgo.String(value)}
+	}
+	testCases := []struct {
+		name               string
+		clusterInfo        *eksawshelper.ClusterInfo
+		tags               []*ec2.Tag
+		describeErr        error
+		expectNotOfCluster bool
+	}{
+		{"cluster-security-group", &eksawshelper.ClusterInfo{ClusterSecurityGroupID: "sg-1"}, nil, nil, false},
+		{"eks-tag", nil, []*ec2.Tag{tag("aws:eks:cluster-name", "test")}, nil, false},
+		{"load-balancer-controller-tag", nil, []*ec2.Tag{tag("elbv2.k8s.aws/cluster", "test")}, nil, false},
+		{"shared-ownership-tag", nil, []*ec2.Tag{tag("kubernetes.io/cluster/test", "shared")}, nil, false},
+		{"already-deleted", nil, nil, awserr.New("InvalidGroup.NotFound", "The security group does not exist", nil), false},
+		{"other-cluster-tag", nil, []*ec2.Tag{tag("aws:eks:cluster-name", "prod")}, nil, true},
+		{"other-cluster-security-group", &eksawshelper.ClusterInfo{ClusterSecurityGroupID: "sg-2"}, nil, nil, true},
+		{"untagged", nil, []*ec2.Tag{tag("team", "test")}, nil, true},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			ec2Svc := &fakeEC2{
+				securityGroups:            []*ec2.SecurityGroup{{GroupId: awsgo.String("sg-1"), Tags: testCase.tags}},
+				describeSecurityGroupsErr: testCase.describeErr,
+			}
+			err := verifySecurityGroupOfCluster(context.Background(), ec2Svc, "test", testCase.clusterInfo, "sg-1")
+			if !testCase.expectNotOfCluster {
+				require.NoError(t, err)
+				return
+			}
+			notOfClusterErr, isNotOfClusterErr := errors.Unwrap(err).(SecurityGroupNotOfClusterError)
+			require.True(t, isNotOfClusterErr)
+			require.Equal(t, "sg-1", notOfClusterErr.SecurityGroupID)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package eks
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/gruntwork-io/go-commons/errors"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// CleanupLeftovers lists the resources of an EKS cluster that are still around after the cluster was torn down, as
+// found by VerifyCleanup. The lists are empty rather than null when there are no leftovers.
+type CleanupLeftovers struct {
+	ClusterName string `json:"cluster_name"`
+
+	// SecurityGroupIDs lists the security groups in the VPC that are tagged for the cluster (see DescribeCleanupTargets).
+	SecurityGroupIDs []string `json:"security_group_ids"`
+
+	// NetworkInterfaceIDs lists the network interfaces in the VPC that use one of the leftover security groups, or whose
+	// description references the cluster, such as the network interfaces of the control plane and the Fargate Pods.
+	NetworkInterfaceIDs []string `json:"network_interface_ids"`
+
+	// LoadBalancerArns and ClassicLoadBalancerNames list the load balancers in the VPC that are owned by the cluster.
+	LoadBalancerArns         []string `json:"load_balancer_arns"`
+	ClassicLoadBalancerNames []string `json:"classic_load_balancer_names"`
+
+	// VolumeIDs lists the EBS volumes that are tagged for the cluster, whether they are attached or not.
+	VolumeIDs []string `json:"volume_ids"`
+}
+
+// IsEmpty returns true if nothing was left behind.
+func (leftovers CleanupLeftovers) IsEmpty() bool {
+	return len(leftovers.SecurityGroupIDs) == 0 &&
+		len(leftovers.NetworkInterfaceIDs) == 0 &&
+		len(leftovers.LoadBalancerArns) == 0 &&
+		len(leftovers.ClassicLoadBalancerNames) == 0 &&
+		len(leftovers.VolumeIDs) == 0
+}
+
+// VerifyCleanup checks that the teardown of the EKS cluster did not leave anything behind: no security groups tagged
+// for the cluster in the VPC, no network interfaces in the VPC that use them or that reference the cluster in their
+// description, no load balancers in the VPC owned by the cluster, and no EBS volumes in the region tagged for the
+// cluster. This is read only: no resources are modified, so that CI can assert that the teardown is clean instead of
+// relying on the exit code of the cleanup commands.
+//
+// Returns the leftovers, along with a CleanupLeftoversError if there are any.
+func VerifyCleanup(region string, vpcID string, clusterName string) (*CleanupLeftovers, error) {
+	logger := logging.GetProjectLogger()
+
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	ec2Svc := eksawshelper.NewEC2Client(sess)
+	elbSvc := elb.New(sess)
+	elbv2Svc := elbv2.New(sess)
+	logger.Infof("Successfully authenticated with AWS")
+
+	return verifyCleanup(context.Background(), ec2Svc, elbSvc, elbv2Svc, vpcID, clusterName)
+}
+
+func verifyCleanup(
+	ctx context.Context,
+	ec2Svc ec2iface.EC2API,
+	elbSvc elbiface.ELBAPI,
+	elbv2Svc elbv2iface.ELBV2API,
+	vpcID string,
+	clusterName string,
+) (*CleanupLeftovers, error) {
+	logger := logging.GetProjectLogger().WithField("cluster", clusterName)
+	logger.Infof("Verifying that EKS cluster %s did not leave any resources behind in VPC %s", clusterName, vpcID)
+
+	leftovers := &CleanupLeftovers{
+		ClusterName:              clusterName,
+		SecurityGroupIDs:         []string{},
+		NetworkInterfaceIDs:      []string{},
+		LoadBalancerArns:         []string{},
+		ClassicLoadBalancerNames: []string{},
+		VolumeIDs:                []string{},
+	}
+	networkInterfaceIDs := map[string]bool{}
+
+	targets, err := describeCleanupTargets(ctx, ec2Svc, elbSvc, elbv2Svc, vpcID)
+	if err != nil {
+		return nil, err
+	}
+	for _, target := range targets {
+		if target.ClusterName != clusterName {
+			continue
+		}
+		for _, securityGroup := range target.SecurityGroups {
+			leftovers.SecurityGroupIDs = append(leftovers.SecurityGroupIDs, securityGroup.ID)
+			for _, eniID := range securityGroup.NetworkInterfaceIDs {
+				networkInterfaceIDs[eniID] = true
+			}
+		}
+		leftovers.LoadBalancerArns = append(leftovers.LoadBalancerArns, target.LoadBalancerArns...)
+		leftovers.ClassicLoadBalancerNames = append(leftovers.ClassicLoadBalancerNames, target.ClassicLoadBalancerNames...)
+	}
+
+	clusterNetworkInterfaces, err := findNetworkInterfacesDescribingCluster(ctx, ec2Svc, vpcID, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	for _, ni := range clusterNetworkInterfaces {
+		networkInterfaceIDs[aws.StringValue(ni.NetworkInterfaceId)] = true
+	}
+	for eniID := range networkInterfaceIDs {
+		leftovers.NetworkInterfaceIDs = append(leftovers.NetworkInterfaceIDs, eniID)
+	}
+	sort.Strings(leftovers.NetworkInterfaceIDs)
+
+	// Volumes that are being deleted are not considered leftovers.
+	volumes, err := findVolumesOwnedByCluster(
+		ec2Svc,
+		clusterName,
+		[]string{ec2.VolumeStateCreating, ec2.VolumeStateAvailable, ec2.VolumeStateInUse, ec2.VolumeStateError},
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, volume := range volumes {
+		leftovers.VolumeIDs = append(leftovers.VolumeIDs, aws.StringValue(volume.VolumeId))
+	}
+
+	if !leftovers.IsEmpty() {
+		return leftovers, errors.WithStackTrace(CleanupLeftoversError{ClusterName: clusterName, VPCID: vpcID, Leftovers: *leftovers})
+	}
+	logger.Infof("EKS cluster %s did not leave any resources behind in VPC %s", clusterName, vpcID)
+	return leftovers, nil
+}
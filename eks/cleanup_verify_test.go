@@ -0,0 +1,75 @@
+package eks
+
+import (
+	"context"
+	"testing"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyCleanupReportsLeftovers(t *testing.T) {
+	t.Parallel()
+
+	ec2Svc := &fakeEC2{
+		securityGroups: []*ec2.SecurityGroup{
+			{
+				GroupId: awsgo.String("sg-prod-lb"),
+				Tags:    []*ec2.Tag{{Key: awsgo.String("elbv2.k8s.aws/cluster"), Value: awsgo.String("prod")}},
+			},
+			{
+				GroupId: awsgo.String("sg-stage"),
+				Tags:    []*ec2.Tag{{Key: awsgo.String("aws:eks:cluster-name"), Value: awsgo.String("stage")}},
+			},
+		},
+		networkInterfacePages: [][]*ec2.NetworkInterface{{
+			{NetworkInterfaceId: awsgo.String("eni-lb"), Groups: []*ec2.GroupIdentifier{{GroupId: awsgo.String("sg-prod-lb")}}},
+			{NetworkInterfaceId: awsgo.String("eni-control-plane"), Description: awsgo.String("Amazon EKS prod")},
+			{NetworkInterfaceId: awsgo.String("eni-other-cluster"), Description: awsgo.String("Amazon EKS prod-2")},
+		}},
+		volumes: []*ec2.Volume{{VolumeId: awsgo.String("vol-pv")}},
+	}
+	elbv2Svc := &fakeELBV2{
+		loadBalancers: []*elbv2.LoadBalancer{{LoadBalancerArn: awsgo.String("arn:nlb/prod"), VpcId: awsgo.String("vpc-123")}},
+		tags: map[string][]*elbv2.Tag{
+			"arn:nlb/prod": {{Key: awsgo.String("kubernetes.io/cluster/prod"), Value: awsgo.String("owned")}},
+		},
+	}
+
+	leftovers, err := verifyCleanup(context.Background(), ec2Svc, &fakeELB{}, elbv2Svc, "vpc-123", "prod")
+	require.Error(t, err)
+	expected := CleanupLeftovers{
+		ClusterName:              "prod",
+		SecurityGroupIDs:         []string{"sg-prod-lb"},
+		NetworkInterfaceIDs:      []string{"eni-control-plane", "eni-lb"},
+		LoadBalancerArns:         []string{"arn:nlb/prod"},
+		ClassicLoadBalancerNames: []string{},
+		VolumeIDs:                []string{"vol-pv"},
+	}
+	assert.Equal(t, &expected, leftovers)
+	assert.Equal(t, CleanupLeftoversError{ClusterName: "prod", VPCID: "vpc-123", Leftovers: expected}, errors.Unwrap(err))
+}
+
+func TestVerifyCleanupPassesWhenNothingIsLeft(t *testing.T) {
+	t.Parallel()
+
+	ec2Svc := &fakeEC2{
+		securityGroups: []*ec2.SecurityGroup{{
+			GroupId: awsgo.String("sg-stage"),
+			Tags:    []*ec2.Tag{{Key: awsgo.String("aws:eks:cluster-name"), Value: awsgo.String("stage")}},
+		}},
+		networkInterfacePages: [][]*ec2.NetworkInterface{{
+			{NetworkInterfaceId: awsgo.String("eni-stage"), Description: awsgo.String("Amazon EKS stage")},
+		}},
+	}
+	elbSvc := &fakeELB{loadBalancers: []*elb.LoadBalancerDescription{}}
+
+	leftovers, err := verifyCleanup(context.Background(), ec2Svc, elbSvc, &fakeELBV2{}, "vpc-123", "prod")
+	require.NoError(t, err)
+	assert.True(t, leftovers.IsEmpty())
+}
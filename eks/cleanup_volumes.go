@@ -0,0 +1,166 @@
+package eks
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/gruntwork-io/go-commons/errors"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// VolumeCleanupResult describes the EBS volumes that were cleaned up by CleanupPersistentVolumes.
+type VolumeCleanupResult struct {
+	// DeletedVolumeIDs lists the IDs of the EBS volumes that were deleted.
+	DeletedVolumeIDs []string
+
+	// AlreadyGoneVolumeIDs lists the IDs of the EBS volumes that were already deleted by the time we tried to delete
+	// them.
+	AlreadyGoneVolumeIDs []string
+
+	// SkippedInUseVolumeIDs lists the IDs of the EBS volumes that were attached to an instance by the time we tried to
+	// delete them, and were thus left alone.
+	SkippedInUseVolumeIDs []string
+
+	// DryRun is true when the cleanup ran in dry run mode, in which case DeletedVolumeIDs lists the volumes that would
+	// have been deleted.
+	DryRun bool
+}
+
+// CleanupPersistentVolumes deletes the EBS volumes that were dynamically provisioned for PersistentVolumes of the EKS
+// cluster, which are left behind when the cluster is destroyed without first deleting the PersistentVolumeClaims. Only
+// unattached (available) volumes tagged with `kubernetes.io/cluster/<name>` or `KubernetesCluster: <name>` are deleted:
+// volumes that are in use are never touched. When dryRun is true, the volumes are only logged, and the delete calls are
+// sent with the DryRun flag so that only the permissions are validated.
+func CleanupPersistentVolumes(clusterArn string, dryRun bool) (*VolumeCleanupResult, error) {
+	logger := logging.GetProjectLogger()
+
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	clusterID, err := eksawshelper.GetClusterNameFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	ec2Svc := eksawshelper.NewEC2Client(sess)
+	logger.Infof("Successfully authenticated with AWS")
+
+	volumes, err := findAvailableVolumesOwnedByCluster(ec2Svc, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &VolumeCleanupResult{DryRun: dryRun}
+	if dryRun {
+		logger.Infof("Running in dry run mode: no resources will be modified")
+	}
+	for _, volume := range volumes {
+		if err := deleteVolume(ec2Svc, volume, dryRun, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// findAvailableVolumesOwnedByCluster returns all the unattached EBS volumes that are tagged for the given cluster.
+func findAvailableVolumesOwnedByCluster(ec2Svc ec2iface.EC2API, clusterID string) ([]*ec2.Volume, error) {
+	logging.GetProjectLogger().Infof("Looking up available EBS volumes for EKS cluster %s", clusterID)
+	return findVolumesOwnedByCluster(ec2Svc, clusterID, []string{ec2.VolumeStateAvailable})
+}
+
+// findVolumesOwnedByCluster returns all the EBS volumes in the given states that are tagged for the given cluster. The
+// EBS CSI driver and the legacy in-tree provisioner use different tags, so we look up both and deduplicate the results.
+func findVolumesOwnedByCluster(ec2Svc ec2iface.EC2API, clusterID string, states []string) ([]*ec2.Volume, error) {
+
+	tagFilters := []*ec2.Filter{
+		{
+			Name:   aws.String("tag-key"),
+			Values: []*string{aws.String(fmt.Sprintf("kubernetes.io/cluster/%s", clusterID))},
+		},
+		{
+			Name:   aws.String("tag:KubernetesCluster"),
+			Values: []*string{aws.String(clusterID)},
+		},
+	}
+
+	volumes := []*ec2.Volume{}
+	seen := map[string]bool{}
+	for _, tagFilter := range tagFilters {
+		input := &ec2.DescribeVolumesInput{
+			Filters: []*ec2.Filter{
+				{
+					Name:   aws.String("status"),
+					Values: aws.StringSlice(states),
+				},
+				tagFilter,
+			},
+		}
+		err := ec2Svc.DescribeVolumesPages(input, func(page *ec2.DescribeVolumesOutput, lastPage bool) bool {
+			for _, volume := range page.Volumes {
+				volumeID := aws.StringValue(volume.VolumeId)
+				if seen[volumeID] {
+					continue
+				}
+				seen[volumeID] = true
+				volumes = append(volumes, volume)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+	}
+	return volumes, nil
+}
+
+// deleteVolume deletes the given EBS volume, recording the outcome in the result. Since the volume may have been
+// attached after it was looked up, we defensively double check the state and handle the in use error from the API.
+func deleteVolume(ec2Svc ec2iface.EC2API, volume *ec2.Volume, dryRun bool, result *VolumeCleanupResult) error {
+	logger := logging.GetProjectLogger()
+	volumeID := aws.StringValue(volume.VolumeId)
+
+	if aws.StringValue(volume.State) != ec2.VolumeStateAvailable {
+		logger.Warnf("EBS volume %s is in state %s. Skipping.", volumeID, aws.StringValue(volume.State))
+		result.SkippedInUseVolumeIDs = append(result.SkippedInUseVolumeIDs, volumeID)
+		return nil
+	}
+
+	logger.Infof("Deleting EBS volume %s (%d GiB)", volumeID, aws.Int64Value(volume.Size))
+	_, err := ec2Svc.DeleteVolume(&ec2.DeleteVolumeInput{
+		VolumeId: volume.VolumeId,
+		DryRun:   aws.Bool(dryRun),
+	})
+
+	awsErr, isAwsErr := err.(awserr.Error)
+	switch {
+	case err == nil:
+		logger.Infof("Successfully deleted EBS volume %s", volumeID)
+		result.DeletedVolumeIDs = append(result.DeletedVolumeIDs, volumeID)
+		return nil
+	case dryRun && isDryRunOperationErr(err):
+		logger.Infof("(Dry run) Would delete EBS volume %s (%d GiB)", volumeID, aws.Int64Value(volume.Size))
+		result.DeletedVolumeIDs = append(result.DeletedVolumeIDs, volumeID)
+		return nil
+	case isAwsErr && awsErr.Code() == "InvalidVolume.NotFound":
+		logger.Infof("EBS volume %s already deleted.", volumeID)
+		result.AlreadyGoneVolumeIDs = append(result.AlreadyGoneVolumeIDs, volumeID)
+		return nil
+	case isAwsErr && awsErr.Code() == "VolumeInUse":
+		logger.Warnf("EBS volume %s was attached since it was looked up. Skipping.", volumeID)
+		result.SkippedInUseVolumeIDs = append(result.SkippedInUseVolumeIDs, volumeID)
+		return nil
+	default:
+		return errors.WithStackTrace(err)
+	}
+}
@@ -0,0 +1,54 @@
+package eks
+
+import (
+	"testing"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteVolumeRecordsOutcome(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeEC2{
+		deleteVolumeErrs: map[string]error{
+			"vol-gone":   awserr.New("InvalidVolume.NotFound", "volume not found", nil),
+			"vol-in-use": awserr.New("VolumeInUse", "volume is in use", nil),
+		},
+	}
+	volumes := []*ec2.Volume{
+		{VolumeId: awsgo.String("vol-deleted"), State: awsgo.String(ec2.VolumeStateAvailable)},
+		{VolumeId: awsgo.String("vol-gone"), State: awsgo.String(ec2.VolumeStateAvailable)},
+		{VolumeId: awsgo.String("vol-in-use"), State: awsgo.String(ec2.VolumeStateAvailable)},
+		{VolumeId: awsgo.String("vol-attached"), State: awsgo.String(ec2.VolumeStateInUse)},
+	}
+
+	result := &VolumeCleanupResult{}
+	for _, volume := range volumes {
+		require.NoError(t, deleteVolume(fake, volume, false, result))
+	}
+
+	assert.Equal(t, []string{"vol-deleted"}, result.DeletedVolumeIDs)
+	assert.Equal(t, []string{"vol-gone"}, result.AlreadyGoneVolumeIDs)
+	assert.Equal(t, []string{"vol-in-use", "vol-attached"}, result.SkippedInUseVolumeIDs)
+	// The attached volume must never be sent to the API.
+	assert.NotContains(t, fake.deletedVolumeIDs, "vol-attached")
+}
+
+func TestDeleteVolumeDryRun(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeEC2{
+		deleteVolumeErrs: map[string]error{
+			"vol-1": awserr.New("DryRunOperation", "Request would have succeeded, but DryRun flag is set.", nil),
+		},
+	}
+	volume := &ec2.Volume{VolumeId: awsgo.String("vol-1"), State: awsgo.String(ec2.VolumeStateAvailable)}
+
+	result := &VolumeCleanupResult{DryRun: true}
+	require.NoError(t, deleteVolume(fake, volume, true, result))
+	assert.Equal(t, []string{"vol-1"}, result.DeletedVolumeIDs)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/gruntwork-io/go-commons/errors"
 
 	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
 	"github.com/gruntwork-io/kubergrunt/logging"
 )
 
@@ -70,56 +71,92 @@ func clusterIsActive(clusterInfo *eks.Cluster) bool {
 	return clusterInfo != nil && aws.StringValue(clusterInfo.Status) == "ACTIVE"
 }
 
-// waitForClusterActive continuously queries the AWS API until the cluster reaches the ACTIVE state.
+// waitForClusterActive continuously queries the AWS API until the cluster reaches the ACTIVE state, checking up to
+// maxRetries times. See waitForClusterActiveWithTimeout.
 func waitForClusterActive(eksClusterArn string, maxRetries int, sleepBetweenRetries time.Duration) error {
+	timeout := time.Duration(maxRetries) * sleepBetweenRetries
+	return waitForClusterActiveWithTimeout(eksClusterArn, timeout, sleepBetweenRetries, eksawshelper.GetClusterByArn)
+}
+
+// clusterActivePollInterval is the time between each check of the state of the EKS cluster in WaitForClusterActive.
+const clusterActivePollInterval = 10 * time.Second
+
+// WaitForClusterActive polls the EKS DescribeCluster API until the cluster with the given ARN reaches the ACTIVE state,
+// e.g., after it was created or while it is being updated. The cluster not existing yet is not an error, so that this
+// can be chained directly after requesting the creation of the cluster. Returns an EKSClusterFailedError if the cluster
+// reaches the FAILED state, and an EKSClusterReadyTimeoutError if it is not active before the timeout.
+func WaitForClusterActive(clusterArn string, timeout time.Duration) error {
+	return waitForClusterActiveWithTimeout(clusterArn, timeout, clusterActivePollInterval, eksawshelper.GetClusterByArn)
+}
+
+func waitForClusterActiveWithTimeout(
+	clusterArn string,
+	timeout time.Duration,
+	pollInterval time.Duration,
+	getCluster func(clusterArn string) (*eks.Cluster, error),
+) error {
 	logger := logging.GetProjectLogger()
-	logger.Infof("Waiting for cluster %s to reach active state.", eksClusterArn)
-	for i := 0; i < maxRetries; i++ {
-		logger.Info("Checking EKS cluster info")
-		clusterInfo, err := eksawshelper.GetClusterByArn(eksClusterArn)
+	logger.Infof("Waiting for cluster %s to reach active state.", clusterArn)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		clusterInfo, err := getCluster(clusterArn)
+		switch {
 		// We do nothing with the error other than log, because it could mean the cluster hasn't been created yet.
-		if err != nil {
+		case err != nil:
 			logger.Warnf("Error retrieving cluster info %s", err)
-		}
-		if clusterIsActive(clusterInfo) {
-			logger.Infof("EKS cluster %s is active", eksClusterArn)
+		case clusterIsActive(clusterInfo):
+			logger.Infof("EKS cluster %s is active", clusterArn)
 			return nil
+		case aws.StringValue(clusterInfo.Status) == eks.ClusterStatusFailed:
+			return errors.WithStackTrace(EKSClusterFailedError{clusterArn})
+		default:
+			logger.Debugf("EKS cluster %s is in %s state", clusterArn, aws.StringValue(clusterInfo.Status))
 		}
-		logger.Warnf("EKS cluster %s is not active yet", eksClusterArn)
-		logger.Infof("Waiting for %s...", sleepBetweenRetries)
-		time.Sleep(sleepBetweenRetries)
+
+		if time.Now().Add(pollInterval).After(deadline) {
+			return errors.WithStackTrace(EKSClusterReadyTimeoutError{clusterArn})
+		}
+		logger.Debugf("Waiting for %s...", pollInterval)
+		time.Sleep(pollInterval)
 	}
-	return errors.WithStackTrace(EKSClusterReadyTimeoutError{eksClusterArn})
 }
 
 // checkKubernetesApiServer checks if the api server is up and accepting traffic.
 func checkKubernetesApiServer(eksClusterArn string) bool {
 	logger := logging.GetProjectLogger()
-	logger.Info("Checking EKS cluster info")
+	logger.Debug("Checking EKS cluster info")
 	clusterInfo, err := eksawshelper.GetClusterByArn(eksClusterArn)
 	if err != nil {
 		logger.Warnf("Error retrieving cluster info %s", err)
-		logger.Warnf("Marking api server as not ready")
+		logger.Debugf("Marking api server as not ready")
 		return false
 	}
 	endpoint := aws.StringValue(clusterInfo.Endpoint)
 	if endpoint == "" {
-		logger.Warnf("Api server endpoint not available")
-		logger.Warnf("Marking api server as not ready")
+		logger.Debugf("Api server endpoint not available")
+		logger.Debugf("Marking api server as not ready")
+		return false
+	}
+
+	endpoint, serverName, err := kubehelper.ResolveClusterEndpoint(endpoint)
+	if err != nil {
+		logger.Errorf("Error resolving EKS cluster %s endpoint: %s", eksClusterArn, err)
+		logger.Debugf("Marking api server as not ready")
 		return false
 	}
 
 	certificate := aws.StringValue(clusterInfo.CertificateAuthority.Data)
-	client, err := loadHttpClientWithCA(certificate)
+	client, err := loadHttpClientWithCA(certificate, serverName)
 	if err != nil {
 		logger.Errorf("Error loading certificate for EKS cluster %s endpoint: %s", eksClusterArn, err)
-		logger.Warnf("Marking api server as not ready")
+		logger.Debugf("Marking api server as not ready")
 		return false
 	}
 	resp, err := client.Head(endpoint)
 	if err != nil {
 		logger.Warnf("Error retrieiving info from endpoint: %s", err)
-		logger.Warnf("Marking api server as not ready")
+		logger.Debugf("Marking api server as not ready")
 		return false
 	}
 	// We look for 200 or 403 response. Both indicate the API server is up.
@@ -138,7 +175,7 @@ func checkKubernetesApiServer(eksClusterArn string) bool {
 			resp.StatusCode,
 			bodyString,
 		)
-		logger.Warnf("Marking api server as not ready")
+		logger.Debugf("Marking api server as not ready")
 		return false
 	}
 
@@ -150,14 +187,14 @@ func waitForKubernetesApiServer(eksClusterArn string, maxRetries int, sleepBetwe
 	logger := logging.GetProjectLogger()
 	logger.Infof("Waiting for cluster %s Kubernetes api server to accept traffic.", eksClusterArn)
 	for i := 0; i < maxRetries; i++ {
-		logger.Info("Checking EKS cluster info")
+		logger.Debug("Checking EKS cluster info")
 		available := checkKubernetesApiServer(eksClusterArn)
 		if available {
 			logger.Infof("EKS cluster %s Kubernetes api server is active", eksClusterArn)
 			return nil
 		}
-		logger.Warnf("EKS cluster %s Kubernetes api server is not active yet", eksClusterArn)
-		logger.Infof("Waiting for %s...", sleepBetweenRetries)
+		logger.Debugf("EKS cluster %s Kubernetes api server is not active yet", eksClusterArn)
+		logger.Debugf("Waiting for %s...", sleepBetweenRetries)
 		time.Sleep(sleepBetweenRetries)
 	}
 	return errors.WithStackTrace(EKSClusterReadyTimeoutError{eksClusterArn})
@@ -0,0 +1,65 @@
+package eks
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+
+	"github.com/gruntwork-io/go-commons/errors"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+)
+
+// GetClusterCA looks up the CA certificate of the Kubernetes API server of the EKS cluster, from the
+// certificateAuthority.data of DescribeCluster, and writes it in PEM format to the given path, or to stdout if the path
+// is empty. This is useful to configure the tools that need the cluster CA separately from a kubeconfig, such as
+// sidecars and service meshes.
+func GetClusterCA(clusterArn string, outputPath string) error {
+	return getAndWriteClusterCA(clusterArn, outputPath, false)
+}
+
+// GetClusterCABase64 is like GetClusterCA, but writes the base64 encoded PEM certificate as returned by DescribeCluster,
+// for embedding in configuration files (e.g., the certificate-authority-data of a kubeconfig).
+func GetClusterCABase64(clusterArn string, outputPath string) error {
+	return getAndWriteClusterCA(clusterArn, outputPath, true)
+}
+
+func getAndWriteClusterCA(clusterArn string, outputPath string, asBase64 bool) error {
+	clusterInfo, err := eksawshelper.GetClusterInfo(clusterArn)
+	if err != nil {
+		return err
+	}
+	data, err := clusterCAData(clusterInfo, asBase64)
+	if err != nil {
+		return err
+	}
+
+	if outputPath == "" {
+		_, err := os.Stdout.Write(data)
+		return errors.WithStackTrace(err)
+	}
+	// The CA certificate is public, so it is readable by everyone like the certificates in /etc/ssl/certs.
+	return errors.WithStackTrace(ioutil.WriteFile(outputPath, data, 0644))
+}
+
+// clusterCAData returns the CA certificate of the cluster, either decoded in PEM format or base64 encoded. Returns a
+// ClusterCANotFoundError if the cluster does not report a CA, and an InvalidClusterCAError if the CA data is not a base64
+// encoded PEM certificate.
+func clusterCAData(clusterInfo *eksawshelper.ClusterInfo, asBase64 bool) ([]byte, error) {
+	if clusterInfo.CertificateAuthorityData == "" {
+		return nil, errors.WithStackTrace(ClusterCANotFoundError{ClusterArn: clusterInfo.Arn})
+	}
+	caPEM, err := base64.StdEncoding.DecodeString(clusterInfo.CertificateAuthorityData)
+	if err != nil {
+		return nil, errors.WithStackTrace(InvalidClusterCAError{ClusterArn: clusterInfo.Arn, UnderlyingErr: err})
+	}
+	if block, _ := pem.Decode(caPEM); block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.WithStackTrace(InvalidClusterCAError{ClusterArn: clusterInfo.Arn})
+	}
+
+	if asBase64 {
+		return []byte(clusterInfo.CertificateAuthorityData + "\n"), nil
+	}
+	return caPEM, nil
+}
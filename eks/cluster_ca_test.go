@@ -0,0 +1,64 @@
+package eks
+
+import (
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+)
+
+func TestClusterCAData(t *testing.T) {
+	t.Parallel()
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("not really a certificate")})
+	caData := base64.StdEncoding.EncodeToString(caPEM)
+	clusterInfo := &eksawshelper.ClusterInfo{Arn: "arn:aws:eks:us-east-1:123456789012:cluster/test", CertificateAuthorityData: caData}
+
+	data, err := clusterCAData(clusterInfo, false)
+	require.NoError(t, err)
+	assert.Equal(t, caPEM, data)
+
+	data, err = clusterCAData(clusterInfo, true)
+	require.NoError(t, err)
+	assert.Equal(t, caData+"\n", string(data))
+}
+
+func TestClusterCADataErrors(t *testing.T) {
+	t.Parallel()
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("not really a key")})
+	testCases := []struct {
+		name   string
+		caData string
+	}{
+		{"Missing", ""},
+		{"NotBase64", "not base64!"},
+		{"NotPEM", base64.StdEncoding.EncodeToString([]byte("not PEM"))},
+		{"NotCertificate", base64.StdEncoding.EncodeToString(keyPEM)},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			clusterInfo := &eksawshelper.ClusterInfo{Arn: "arn:aws:eks:us-east-1:123456789012:cluster/test", CertificateAuthorityData: testCase.caData}
+			_, err := clusterCAData(clusterInfo, false)
+			require.Error(t, err)
+			switch errors.Unwrap(err).(type) {
+			case ClusterCANotFoundError:
+				assert.Equal(t, "Missing", testCase.name)
+			case InvalidClusterCAError:
+				assert.NotEqual(t, "Missing", testCase.name)
+			default:
+				t.Fatalf("Unexpected error type %T", errors.Unwrap(err))
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package eks
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWaitClusterArn = "arn:aws:eks:us-east-1:111111111111:cluster/prod"
+
+// newTestClusterStatusSequence returns a stub of GetClusterByArn that returns a cluster in each of the given states in
+// turn, where an empty state means the cluster does not exist yet, and then stays in the last state.
+func newTestClusterStatusSequence(statuses ...string) func(string) (*eks.Cluster, error) {
+	calls := 0
+	return func(clusterArn string) (*eks.Cluster, error) {
+		status := statuses[len(statuses)-1]
+		if calls < len(statuses) {
+			status = statuses[calls]
+		}
+		calls++
+		if status == "" {
+			return nil, fmt.Errorf("No cluster found for name: prod.")
+		}
+		return &eks.Cluster{Arn: awsgo.String(clusterArn), Status: awsgo.String(status)}, nil
+	}
+}
+
+func TestWaitForClusterActive(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		statuses    []string
+		expectedErr error
+	}{
+		{"AlreadyActive", []string{eks.ClusterStatusActive}, nil},
+		{"Creating", []string{"", eks.ClusterStatusCreating, eks.ClusterStatusActive}, nil},
+		{"Updating", []string{eks.ClusterStatusUpdating, eks.ClusterStatusActive}, nil},
+		{"Failed", []string{eks.ClusterStatusCreating, eks.ClusterStatusFailed}, EKSClusterFailedError{testWaitClusterArn}},
+		{"Timeout", []string{eks.ClusterStatusCreating}, EKSClusterReadyTimeoutError{testWaitClusterArn}},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			err := waitForClusterActiveWithTimeout(
+				testWaitClusterArn,
+				100*time.Millisecond,
+				time.Millisecond,
+				newTestClusterStatusSequence(testCase.statuses...),
+			)
+			if testCase.expectedErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Equal(t, testCase.expectedErr, errors.Unwrap(err))
+			}
+		})
+	}
+}
@@ -1,13 +1,26 @@
 package eks
 
 import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
 	"github.com/gruntwork-io/go-commons/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 
 	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/jsonpatch"
 	"github.com/gruntwork-io/kubergrunt/kubectl"
 	"github.com/gruntwork-io/kubergrunt/logging"
 )
 
+// corednsComputeTypeAnnotation is the annotation on the coredns Pod template that pins coredns to EC2 workers.
+const corednsComputeTypeAnnotation = "eks.amazonaws.com/compute-type"
+
 type CorednsAnnotation string
 
 const (
@@ -25,10 +38,11 @@ func ScheduleCoredns(
 ) error {
 	logger := logging.GetProjectLogger()
 
-	region, err := eksawshelper.GetRegionFromArn(fargateProfileArn)
+	parsedFargateProfileArn, err := arn.Parse(fargateProfileArn)
 	if err != nil {
-		return err
+		return errors.WithStackTrace(err)
 	}
+	region := parsedFargateProfileArn.Region
 	logger.Infof("Got region %s", region)
 
 	eksClusterArn, err := eksawshelper.GetClusterArnByNameAndRegion(clusterName, region)
@@ -70,6 +84,137 @@ func ScheduleCoredns(
 		}
 	}
 
+	if err := kubectl.AnnotateAsModifiedByKubergrunt(kubectlOptions, "deployment/coredns", "kube-system"); err != nil {
+		return err
+	}
+
 	logger.Infof("Patched")
 	return nil
 }
+
+// ScheduleCoreDNSForFargate removes the compute-type annotation from the coredns deployment of the EKS cluster, and rolls
+// the coredns Pods so that they are picked up by the Fargate scheduler. This first verifies that there is an active
+// Fargate profile that selects the coredns Pods in the kube-system namespace, returning a
+// NoFargateProfileForCoreDNSError otherwise, as the coredns Pods would be left pending without one.
+func ScheduleCoreDNSForFargate(clusterArn string) error {
+	logger := logging.GetProjectLogger()
+
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return err
+	}
+	clusterName, err := eksawshelper.GetClusterNameFromArn(clusterArn)
+	if err != nil {
+		return err
+	}
+	eksSvc, err := eksawshelper.NewEksClient(region)
+	if err != nil {
+		return err
+	}
+
+	kubectlOptions := &kubectl.KubectlOptions{EKSClusterArn: clusterArn}
+	clientset, err := kubectl.GetKubernetesClientFromOptions(kubectlOptions)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	deploymentAPI := clientset.AppsV1().Deployments(componentNamespace)
+	deployment, err := deploymentAPI.Get(context.Background(), corednsDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	profileName, err := findFargateProfileForPods(eksSvc, clusterName, componentNamespace, deployment.Spec.Template.Labels)
+	if err != nil {
+		return err
+	}
+	if profileName == "" {
+		return errors.WithStackTrace(NoFargateProfileForCoreDNSError{clusterName: clusterName})
+	}
+	logger.Infof("Found Fargate profile %s for coredns in EKS cluster %s", profileName, clusterName)
+
+	// Removing the annotation changes the Pod template, which rolls the coredns Pods. If the annotation is already
+	// removed, the Pods may still be pending from before the Fargate profile existed, so we restart the rollout instead.
+	if _, hasAnnotation := deployment.Spec.Template.Annotations[corednsComputeTypeAnnotation]; hasAnnotation {
+		logger.Infof("Removing %s annotation from coredns deployment", corednsComputeTypeAnnotation)
+		patch := []jsonpatch.PatchString{
+			{
+				Op:   jsonpatch.RemoveOp,
+				Path: "/spec/template/metadata/annotations/eks.amazonaws.com~1compute-type",
+			},
+		}
+		patchOpJson, err := json.Marshal(patch)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		if _, err := deploymentAPI.Patch(context.Background(), corednsDeploymentName, k8stypes.JSONPatchType, patchOpJson, metav1.PatchOptions{}); err != nil {
+			return errors.WithStackTrace(err)
+		}
+	} else {
+		logger.Infof("coredns deployment does not have the %s annotation. Restarting coredns Pods.", corednsComputeTypeAnnotation)
+		err := kubectl.RunKubectl(kubectlOptions, "rollout", "restart", "deployment/"+corednsDeploymentName, "-n", componentNamespace)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+	if err := annotateDeploymentAsModified(clientset, corednsDeploymentName); err != nil {
+		return err
+	}
+
+	logger.Infof("Successfully scheduled coredns on Fargate in EKS cluster %s", clusterName)
+	return nil
+}
+
+// findFargateProfileForPods returns the name of an active Fargate profile of the EKS cluster that selects Pods with the
+// given labels in the given namespace, or an empty string if there is none.
+func findFargateProfileForPods(eksSvc eksiface.EKSAPI, clusterName string, namespace string, podLabels map[string]string) (string, error) {
+	profileNames := []*string{}
+	err := eksSvc.ListFargateProfilesPages(
+		&eks.ListFargateProfilesInput{ClusterName: aws.String(clusterName)},
+		func(page *eks.ListFargateProfilesOutput, lastPage bool) bool {
+			profileNames = append(profileNames, page.FargateProfileNames...)
+			return true
+		},
+	)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	for _, profileName := range profileNames {
+		output, err := eksSvc.DescribeFargateProfile(&eks.DescribeFargateProfileInput{
+			ClusterName:        aws.String(clusterName),
+			FargateProfileName: profileName,
+		})
+		if err != nil {
+			return "", errors.WithStackTrace(err)
+		}
+		if fargateProfileSelectsPods(output.FargateProfile, namespace, podLabels) {
+			return aws.StringValue(profileName), nil
+		}
+	}
+	return "", nil
+}
+
+// fargateProfileSelectsPods returns true if the Fargate profile is active and has a selector that matches Pods with the
+// given labels in the given namespace. A selector matches if the namespace is the same, and all the labels of the
+// selector are on the Pod.
+func fargateProfileSelectsPods(profile *eks.FargateProfile, namespace string, podLabels map[string]string) bool {
+	if profile == nil || aws.StringValue(profile.Status) != eks.FargateProfileStatusActive {
+		return false
+	}
+	for _, selector := range profile.Selectors {
+		if aws.StringValue(selector.Namespace) != namespace {
+			continue
+		}
+		matches := true
+		for key, value := range selector.Labels {
+			if podValue, hasLabel := podLabels[key]; !hasLabel || podValue != aws.StringValue(value) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package eks
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFargateProfileSelectsPods(t *testing.T) {
+	t.Parallel()
+
+	corednsLabels := map[string]string{"k8s-app": "kube-dns", "eks.amazonaws.com/component": "coredns"}
+	profileWithSelector := func(status string, namespace string, labels map[string]string) *eks.FargateProfile {
+		return &eks.FargateProfile{
+			Status: aws.String(status),
+			Selectors: []*eks.FargateProfileSelector{
+				{Namespace: aws.String("default")},
+				{Namespace: aws.String(namespace), Labels: aws.StringMap(labels)},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		profile  *eks.FargateProfile
+		expected bool
+	}{
+		{"namespace-only", profileWithSelector(eks.FargateProfileStatusActive, "kube-system", nil), true},
+		{"matching-labels", profileWithSelector(eks.FargateProfileStatusActive, "kube-system", map[string]string{"k8s-app": "kube-dns"}), true},
+		{"other-labels", profileWithSelector(eks.FargateProfileStatusActive, "kube-system", map[string]string{"k8s-app": "metrics-server"}), false},
+		{"other-namespace", profileWithSelector(eks.FargateProfileStatusActive, "apps", nil), false},
+		{"not-active", profileWithSelector(eks.FargateProfileStatusCreating, "kube-system", nil), false},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.expected, fargateProfileSelectsPods(testCase.profile, "kube-system", corednsLabels))
+		})
+	}
+}
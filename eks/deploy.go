@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/autoscaling"
-	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/aws/aws-sdk-go/service/elb"
 	"github.com/aws/aws-sdk-go/service/elbv2"
 	"github.com/gruntwork-io/go-commons/errors"
@@ -13,8 +12,12 @@ import (
 	"github.com/gruntwork-io/kubergrunt/eksawshelper"
 	"github.com/gruntwork-io/kubergrunt/kubectl"
 	"github.com/gruntwork-io/kubergrunt/logging"
+	"github.com/gruntwork-io/kubergrunt/metrics"
 )
 
+// deployMetricsOperation is the operation label of the phase durations of the roll out in the metrics.
+const deployMetricsOperation = "deploy"
+
 // RollOutDeployment will perform a zero downtime roll out of the current launch configuration associated with the
 // provided ASG in the provided EKS cluster. This is accomplished by:
 // 1. Double the desired capacity of the Auto Scaling Group that powers the EKS Cluster. This will launch new EKS
@@ -27,6 +30,9 @@ import (
 // 6. Set the desired capacity down to the original value and remove the old EKS workers from the ASG.
 // The process is broken up into stages/checkpoints, state is stored along the way so that command can pick up
 // from a stage if something bad happens.
+// When dryRun is true, this only prints the plan of the roll out (the instances that will be replaced in order, along
+// with the current and target launch template versions) and checks the prerequisites of the roll out, without
+// changing anything. A RollOutPrerequisitesError is returned if the prerequisites are not met.
 func RollOutDeployment(
 	region string,
 	eksAsgName string,
@@ -36,6 +42,7 @@ func RollOutDeployment(
 	maxRetries int,
 	sleepBetweenRetries time.Duration,
 	ignoreRecoveryFile bool,
+	dryRun bool,
 ) (returnErr error) {
 	logger := logging.GetProjectLogger()
 	logger.Infof("Beginning roll out for EKS cluster worker group %s in %s", eksAsgName, region)
@@ -46,60 +53,64 @@ func RollOutDeployment(
 		return errors.WithStackTrace(err)
 	}
 	asgSvc := autoscaling.New(sess)
-	ec2Svc := ec2.New(sess)
+	ec2Svc := eksawshelper.NewEC2Client(sess)
 	elbSvc := elb.New(sess)
 	elbv2Svc := elbv2.New(sess)
 	logger.Infof("Successfully authenticated with AWS")
 
 	stateFile := defaultStateFile
 
+	if dryRun {
+		return planRollOutDeployment(asgSvc, ec2Svc, eksAsgName, stateFile, ignoreRecoveryFile)
+	}
+
 	// Retrieve state if one exists or construct a new one
 	state, err := initDeployState(stateFile, ignoreRecoveryFile, maxRetries, sleepBetweenRetries)
 	if err != nil {
 		return err
 	}
 
-	err = state.gatherASGInfo(asgSvc, []string{eksAsgName})
+	err = metrics.TimePhase(deployMetricsOperation, "gather_asg_info", func() error { return state.gatherASGInfo(asgSvc, []string{eksAsgName}) })
 	if err != nil {
 		return err
 	}
 
-	err = state.setMaxCapacity(asgSvc)
+	err = metrics.TimePhase(deployMetricsOperation, "set_max_capacity", func() error { return state.setMaxCapacity(asgSvc) })
 	if err != nil {
 		return err
 	}
 
-	err = state.scaleUp(asgSvc)
+	err = metrics.TimePhase(deployMetricsOperation, "scale_up", func() error { return state.scaleUp(asgSvc) })
 	if err != nil {
 		return err
 	}
 
-	err = state.waitForNodes(ec2Svc, elbSvc, elbv2Svc, kubectlOptions)
+	err = metrics.TimePhase(deployMetricsOperation, "wait_for_nodes", func() error { return state.waitForNodes(ec2Svc, elbSvc, elbv2Svc, kubectlOptions) })
 	if err != nil {
 		return err
 	}
 
-	err = state.cordonNodes(ec2Svc, kubectlOptions)
+	err = metrics.TimePhase(deployMetricsOperation, "cordon_nodes", func() error { return state.cordonNodes(ec2Svc, kubectlOptions) })
 	if err != nil {
 		return err
 	}
 
-	err = state.drainNodes(ec2Svc, kubectlOptions, drainTimeout, deleteEmptyDirData)
+	err = metrics.TimePhase(deployMetricsOperation, "drain_nodes", func() error { return state.drainNodes(ec2Svc, kubectlOptions, drainTimeout, deleteEmptyDirData) })
 	if err != nil {
 		return err
 	}
 
-	err = state.detachInstances(asgSvc)
+	err = metrics.TimePhase(deployMetricsOperation, "detach_instances", func() error { return state.detachInstances(asgSvc) })
 	if err != nil {
 		return err
 	}
 
-	err = state.terminateInstances(ec2Svc)
+	err = metrics.TimePhase(deployMetricsOperation, "terminate_instances", func() error { return state.terminateInstances(ec2Svc) })
 	if err != nil {
 		return err
 	}
 
-	err = state.restoreCapacity(asgSvc)
+	err = metrics.TimePhase(deployMetricsOperation, "restore_capacity", func() error { return state.restoreCapacity(asgSvc) })
 	if err != nil {
 		return err
 	}
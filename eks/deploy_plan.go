@@ -0,0 +1,215 @@
+package eks
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/gruntwork-io/go-commons/errors"
+
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// asgInstanceHealthy is the health status of an ASG instance that passes its health checks.
+const asgInstanceHealthy = "Healthy"
+
+// RollOutPlan describes what RollOutDeployment or RollingDeployment would do to an ASG, without changing anything.
+type RollOutPlan struct {
+	ASGName         string
+	DesiredCapacity int64
+	MaxSize         int64
+
+	// MaxSizeForUpdate is the max size the ASG needs during the roll out, to launch a new instance for each of the
+	// current instances, or for each instance of a batch in a rolling deployment.
+	MaxSizeForUpdate int64
+
+	// BatchSize is the number of instances that a rolling deployment replaces at a time. Zero means that all the
+	// instances are replaced at once.
+	BatchSize int
+
+	// Target describes the launch template version, or the launch configuration, that the new instances are launched
+	// with.
+	Target string
+
+	// Instances lists the current instances of the ASG, in the order they are cordoned and drained. RollOutDeployment
+	// replaces all of them, including the ones that already run the target version, while RollingDeployment only lists
+	// and replaces the outdated ones.
+	Instances []RollOutPlanInstance
+
+	// Problems lists the prerequisites of the roll out that the ASG does not meet.
+	Problems []string
+}
+
+// RollOutPlanInstance describes an instance that would be replaced by the roll out.
+type RollOutPlanInstance struct {
+	InstanceID string
+
+	// Current describes the launch template version, or the launch configuration, that the instance was launched with.
+	Current string
+
+	// UpToDate is true if the instance already runs the target version.
+	UpToDate bool
+}
+
+// String renders the plan for reviewing it before the roll out, e.g. as part of an approval step in a pipeline.
+func (plan RollOutPlan) String() string {
+	lines := []string{
+		fmt.Sprintf("Roll out plan for ASG %s:", plan.ASGName),
+		fmt.Sprintf("  Desired capacity: %d", plan.DesiredCapacity),
+	}
+	if plan.MaxSize < plan.MaxSizeForUpdate {
+		lines = append(lines, fmt.Sprintf("  Max size: %d (temporarily raised to %d during the roll out)", plan.MaxSize, plan.MaxSizeForUpdate))
+	} else {
+		lines = append(lines, fmt.Sprintf("  Max size: %d (enough headroom for %d instances during the roll out)", plan.MaxSize, plan.MaxSizeForUpdate))
+	}
+	lines = append(lines, fmt.Sprintf("  Target: %s", plan.Target))
+	indent := "    "
+	if plan.BatchSize > 0 {
+		lines = append(lines, fmt.Sprintf("  Instances to replace (%d), in batches of %d, in order:", len(plan.Instances), plan.BatchSize))
+		indent = "      "
+	} else {
+		lines = append(lines, fmt.Sprintf("  Instances to replace (%d), in order:", len(plan.Instances)))
+	}
+	for i, instance := range plan.Instances {
+		if plan.BatchSize > 0 && i%plan.BatchSize == 0 {
+			lines = append(lines, fmt.Sprintf("    Batch %d:", i/plan.BatchSize+1))
+		}
+		line := fmt.Sprintf("%s%d. %s (%s)", indent, i+1, instance.InstanceID, instance.Current)
+		if instance.UpToDate {
+			line += " - already up to date"
+		}
+		lines = append(lines, line)
+	}
+	if len(plan.Problems) > 0 {
+		lines = append(lines, "  Prerequisites not met:")
+		for _, problem := range plan.Problems {
+			lines = append(lines, fmt.Sprintf("    - %s", problem))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// planRollOutDeployment prints the plan of the roll out of the ASG, and returns a RollOutPrerequisitesError if the ASG
+// does not meet the prerequisites of the roll out. Nothing is changed, and the recovery file is neither read nor
+// written.
+func planRollOutDeployment(
+	asgSvc *autoscaling.AutoScaling,
+	ec2Svc ec2iface.EC2API,
+	asgName string,
+	stateFile string,
+	ignoreRecoveryFile bool,
+) error {
+	logger := logging.GetProjectLogger()
+	logger.Infof("Dry run: computing the roll out plan for ASG %s without changing anything.", asgName)
+
+	if _, err := os.Stat(stateFile); err == nil && !ignoreRecoveryFile {
+		logger.Warnf("Found the recovery file %s of a previous roll out. The roll out would resume from it instead of following this plan. Pass --ignore-recovery-file to start over.", stateFile)
+	}
+
+	asg, err := GetAsgByName(asgSvc, asgName)
+	if err != nil {
+		return err
+	}
+	targetVersion, err := resolveLaunchTemplateVersion(ec2Svc, currentLaunchTemplate(asg))
+	if err != nil {
+		return err
+	}
+
+	return reportRollOutPlan(newRollOutPlan(asg, targetVersion))
+}
+
+// reportRollOutPlan prints the plan of the roll out, and returns a RollOutPrerequisitesError if the ASG does not meet
+// the prerequisites of the roll out.
+func reportRollOutPlan(plan RollOutPlan) error {
+	fmt.Println(plan.String())
+	if len(plan.Problems) > 0 {
+		return errors.WithStackTrace(RollOutPrerequisitesError{ASGName: plan.ASGName, Problems: plan.Problems})
+	}
+	return nil
+}
+
+// newRollOutPlan computes the plan of the roll out of the ASG, given the resolved launch template version (see
+// resolveLaunchTemplateVersion) that new instances are launched with.
+func newRollOutPlan(asg *autoscaling.Group, targetVersion string) RollOutPlan {
+	spec := currentLaunchTemplate(asg)
+	desiredCapacity := aws.Int64Value(asg.DesiredCapacity)
+	plan := RollOutPlan{
+		ASGName:          aws.StringValue(asg.AutoScalingGroupName),
+		DesiredCapacity:  desiredCapacity,
+		MaxSize:          aws.Int64Value(asg.MaxSize),
+		MaxSizeForUpdate: desiredCapacity * 2,
+		Instances:        []RollOutPlanInstance{},
+		Problems:         []string{},
+	}
+	if spec != nil {
+		plan.Target = fmt.Sprintf("launch template %s version %s", launchTemplateSpecName(spec), targetVersion)
+	} else {
+		plan.Target = fmt.Sprintf("launch configuration %s", aws.StringValue(asg.LaunchConfigurationName))
+	}
+
+	if int64(len(asg.Instances)) != desiredCapacity {
+		plan.Problems = append(
+			plan.Problems,
+			fmt.Sprintf("The ASG is not in a steady state: it has %d instances for a desired capacity of %d.", len(asg.Instances), desiredCapacity),
+		)
+	}
+	for _, inst := range asg.Instances {
+		instanceID := aws.StringValue(inst.InstanceId)
+		plan.Instances = append(plan.Instances, RollOutPlanInstance{
+			InstanceID: instanceID,
+			Current:    describeInstanceLaunchVersion(inst),
+			UpToDate:   isInstanceUpToDate(inst, asg, spec, targetVersion),
+		})
+		if state := aws.StringValue(inst.LifecycleState); state != autoscaling.LifecycleStateInService {
+			plan.Problems = append(plan.Problems, fmt.Sprintf("Instance %s is %s instead of InService.", instanceID, state))
+		}
+		if health := aws.StringValue(inst.HealthStatus); health != asgInstanceHealthy {
+			plan.Problems = append(plan.Problems, fmt.Sprintf("Instance %s is %s instead of %s.", instanceID, health, asgInstanceHealthy))
+		}
+	}
+	return plan
+}
+
+// newRollingDeploymentPlan computes the plan of a rolling deployment of the ASG, which only replaces the outdated
+// instances, maxUnavailable at a time. The max size only needs room for one batch of new instances.
+func newRollingDeploymentPlan(asg *autoscaling.Group, targetVersion string, maxUnavailable int) RollOutPlan {
+	plan := newRollOutPlan(asg, targetVersion)
+	outdated := []RollOutPlanInstance{}
+	for _, instance := range plan.Instances {
+		if !instance.UpToDate {
+			outdated = append(outdated, instance)
+		}
+	}
+	plan.Instances = outdated
+	plan.BatchSize = maxUnavailable
+
+	batchSize := maxUnavailable
+	if len(outdated) < batchSize {
+		batchSize = len(outdated)
+	}
+	plan.MaxSizeForUpdate = plan.DesiredCapacity + int64(batchSize)
+	return plan
+}
+
+// describeInstanceLaunchVersion describes the launch template version, or the launch configuration, that the instance
+// was launched with.
+func describeInstanceLaunchVersion(inst *autoscaling.Instance) string {
+	if inst.LaunchTemplate != nil {
+		return fmt.Sprintf("launch template %s version %s", launchTemplateSpecName(inst.LaunchTemplate), aws.StringValue(inst.LaunchTemplate.Version))
+	}
+	if inst.LaunchConfigurationName != nil {
+		return fmt.Sprintf("launch configuration %s", aws.StringValue(inst.LaunchConfigurationName))
+	}
+	return "unknown launch template"
+}
+
+// launchTemplateSpecName returns the name of the launch template, falling back to its ID when the name is not set.
+func launchTemplateSpecName(spec *autoscaling.LaunchTemplateSpecification) string {
+	if spec.LaunchTemplateName != nil {
+		return aws.StringValue(spec.LaunchTemplateName)
+	}
+	return aws.StringValue(spec.LaunchTemplateId)
+}
@@ -0,0 +1,115 @@
+package eks
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRollOutPlanWithLaunchTemplate(t *testing.T) {
+	t.Parallel()
+
+	asg := &autoscaling.Group{
+		AutoScalingGroupName: aws.String("workers"),
+		DesiredCapacity:      aws.Int64(2),
+		MaxSize:              aws.Int64(3),
+		LaunchTemplate:       &autoscaling.LaunchTemplateSpecification{LaunchTemplateName: aws.String("lt-workers"), Version: aws.String("$Latest")},
+		Instances: []*autoscaling.Instance{
+			testPlanInstance("i-old", "4"),
+			testPlanInstance("i-new", "5"),
+		},
+	}
+
+	plan := newRollOutPlan(asg, "5")
+	assert.Equal(t, RollOutPlan{
+		ASGName:          "workers",
+		DesiredCapacity:  2,
+		MaxSize:          3,
+		MaxSizeForUpdate: 4,
+		Target:           "launch template lt-workers version 5",
+		Instances: []RollOutPlanInstance{
+			{InstanceID: "i-old", Current: "launch template lt-workers version 4"},
+			{InstanceID: "i-new", Current: "launch template lt-workers version 5", UpToDate: true},
+		},
+		Problems: []string{},
+	}, plan)
+	assert.Contains(t, plan.String(), "Max size: 3 (temporarily raised to 4 during the roll out)")
+}
+
+func TestNewRollOutPlanReportsUnmetPrerequisites(t *testing.T) {
+	t.Parallel()
+
+	unhealthy := testPlanInstance("i-unhealthy", "5")
+	unhealthy.HealthStatus = aws.String("Unhealthy")
+	pending := testPlanInstance("i-pending", "5")
+	pending.LifecycleState = aws.String(autoscaling.LifecycleStatePending)
+	asg := &autoscaling.Group{
+		AutoScalingGroupName: aws.String("workers"),
+		DesiredCapacity:      aws.Int64(3),
+		MaxSize:              aws.Int64(6),
+		LaunchTemplate:       &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: aws.String("lt-123"), Version: aws.String("5")},
+		Instances:            []*autoscaling.Instance{unhealthy, pending},
+	}
+
+	plan := newRollOutPlan(asg, "5")
+	assert.Equal(t, []string{
+		"The ASG is not in a steady state: it has 2 instances for a desired capacity of 3.",
+		"Instance i-unhealthy is Unhealthy instead of Healthy.",
+		"Instance i-pending is Pending instead of InService.",
+	}, plan.Problems)
+	assert.Contains(t, plan.String(), "Max size: 6 (enough headroom for 6 instances during the roll out)")
+}
+
+func TestNewRollingDeploymentPlanBatchesOutdatedInstances(t *testing.T) {
+	t.Parallel()
+
+	asg := &autoscaling.Group{
+		AutoScalingGroupName: aws.String("workers"),
+		DesiredCapacity:      aws.Int64(4),
+		MaxSize:              aws.Int64(5),
+		LaunchTemplate:       &autoscaling.LaunchTemplateSpecification{LaunchTemplateName: aws.String("lt-workers"), Version: aws.String("5")},
+		Instances: []*autoscaling.Instance{
+			testPlanInstance("i-old-1", "4"),
+			testPlanInstance("i-new", "5"),
+			testPlanInstance("i-old-2", "4"),
+			testPlanInstance("i-old-3", "3"),
+		},
+	}
+
+	plan := newRollingDeploymentPlan(asg, "5", 2)
+	assert.Equal(t, RollOutPlan{
+		ASGName:          "workers",
+		DesiredCapacity:  4,
+		MaxSize:          5,
+		MaxSizeForUpdate: 6,
+		BatchSize:        2,
+		Target:           "launch template lt-workers version 5",
+		Instances: []RollOutPlanInstance{
+			{InstanceID: "i-old-1", Current: "launch template lt-workers version 4"},
+			{InstanceID: "i-old-2", Current: "launch template lt-workers version 4"},
+			{InstanceID: "i-old-3", Current: "launch template lt-workers version 3"},
+		},
+		Problems: []string{},
+	}, plan)
+	rendered := plan.String()
+	assert.Contains(t, rendered, "Max size: 5 (temporarily raised to 6 during the roll out)")
+	assert.Contains(t, rendered, "Instances to replace (3), in batches of 2, in order:")
+	assert.Contains(t, rendered, "    Batch 2:\n      3. i-old-3 (launch template lt-workers version 3)")
+
+	// With fewer outdated instances than the batch size, the max size only needs room for the outdated instances.
+	assert.Equal(t, int64(7), newRollingDeploymentPlan(asg, "5", 5).MaxSizeForUpdate)
+}
+
+func testPlanInstance(instanceID string, launchTemplateVersion string) *autoscaling.Instance {
+	return &autoscaling.Instance{
+		InstanceId:     aws.String(instanceID),
+		LifecycleState: aws.String(autoscaling.LifecycleStateInService),
+		HealthStatus:   aws.String(asgInstanceHealthy),
+		LaunchTemplate: &autoscaling.LaunchTemplateSpecification{
+			LaunchTemplateName: aws.String("lt-workers"),
+			Version:            aws.String(launchTemplateVersion),
+		},
+	}
+}
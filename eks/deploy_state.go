@@ -289,7 +289,7 @@ func (state *DeployState) detachInstances(asgSvc *autoscaling.AutoScaling) error
 	}
 	asg := &state.ASGs[0]
 	state.logger.Infof("Removing old nodes from ASG %s: %s", asg.Name, strings.Join(asg.OriginalInstances, ","))
-	err := detachInstances(asgSvc, asg.Name, asg.OriginalInstances)
+	err := detachInstances(asgSvc, asg.Name, asg.OriginalInstances, true)
 	if err != nil {
 		state.logger.Errorf("Error while detaching the old instances.")
 		state.logger.Errorf("Either resume with the recovery file or continue to detach the old instances and then terminate the underlying instances to complete the rollout.")
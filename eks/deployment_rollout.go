@@ -0,0 +1,125 @@
+package eks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+const (
+	// deploymentRolloutPollInterval is the interval between checks of the status of a Deployment while waiting for its
+	// rollout to complete.
+	deploymentRolloutPollInterval = 5 * time.Second
+
+	// progressDeadlineExceededReason is the reason of the Progressing condition of a Deployment when the rollout did not
+	// make progress within the progressDeadlineSeconds of the Deployment.
+	progressDeadlineExceededReason = "ProgressDeadlineExceeded"
+)
+
+// WaitForDeploymentRollout waits until the rollout of the Deployment is complete, the same way as `kubectl rollout
+// status`: the Deployment controller has observed the latest generation of the Deployment, all the replicas are updated
+// and available, and the old replicas are terminated. Returns a DeploymentRolloutTimeoutError with the last observed
+// status if this does not happen within the timeout, or a DeploymentProgressDeadlineExceededError as soon as the
+// Deployment reports that the rollout is not making progress. A timeout of zero waits indefinitely.
+func WaitForDeploymentRollout(clusterArn string, namespace string, deploymentName string, timeout time.Duration) error {
+	client, err := kubectl.GetKubernetesClientFromOptions(&kubectl.KubectlOptions{EKSClusterArn: clusterArn})
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return waitForDeploymentRolloutWithClient(context.Background(), client, namespace, deploymentName, timeout, deploymentRolloutPollInterval)
+}
+
+func waitForDeploymentRolloutWithClient(
+	ctx context.Context,
+	client kubernetes.Interface,
+	namespace string,
+	deploymentName string,
+	timeout time.Duration,
+	pollInterval time.Duration,
+) error {
+	logger := logging.GetProjectLogger().WithField("deployment", deploymentName)
+	logger.Infof("Waiting for the rollout of Deployment %s (Namespace: %s) to complete", deploymentName, namespace)
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	lastStatus := "Deployment not retrieved yet"
+	for {
+		deployment, err := client.AppsV1().Deployments(namespace).Get(ctx, deploymentName, metav1.GetOptions{})
+		switch {
+		case err != nil && ctx.Err() == nil:
+			lastStatus = fmt.Sprintf("error retrieving Deployment: %s", err)
+		case err == nil:
+			if isDeploymentProgressDeadlineExceeded(deployment) {
+				return errors.WithStackTrace(DeploymentProgressDeadlineExceededError{Namespace: namespace, DeploymentName: deploymentName})
+			}
+			status, done := deploymentRolloutStatus(deployment)
+			if done {
+				logger.Infof("Rollout of Deployment %s (Namespace: %s) is complete", deploymentName, namespace)
+				return nil
+			}
+			lastStatus = status
+		}
+		logger.Debugf("Rollout of Deployment %s (Namespace: %s) is not complete yet: %s", deploymentName, namespace, lastStatus)
+
+		select {
+		case <-ctx.Done():
+			return errors.WithStackTrace(DeploymentRolloutTimeoutError{
+				Namespace:      namespace,
+				DeploymentName: deploymentName,
+				Timeout:        timeout,
+				LastStatus:     lastStatus,
+			})
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// deploymentRolloutStatus returns whether the rollout of the Deployment is complete, along with a description of what
+// the rollout is waiting for when it is not. This mirrors the checks of `kubectl rollout status`.
+func deploymentRolloutStatus(deployment *appsv1.Deployment) (string, bool) {
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return "waiting for the Deployment spec update to be observed", false
+	}
+
+	// The number of replicas defaults to 1 when it is not set.
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	switch {
+	case status.UpdatedReplicas < replicas:
+		return fmt.Sprintf("%d out of %d new replicas have been updated", status.UpdatedReplicas, replicas), false
+	case status.Replicas > status.UpdatedReplicas:
+		return fmt.Sprintf("%d old replicas are pending termination", status.Replicas-status.UpdatedReplicas), false
+	case status.AvailableReplicas < replicas:
+		return fmt.Sprintf("%d of %d updated replicas are available", status.AvailableReplicas, replicas), false
+	}
+	return "", true
+}
+
+// isDeploymentProgressDeadlineExceeded returns true if the Deployment reports that its rollout did not make progress
+// within its progress deadline, in which case the rollout will not complete without intervention.
+func isDeploymentProgressDeadlineExceeded(deployment *appsv1.Deployment) bool {
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return false
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == progressDeadlineExceededReason {
+			return true
+		}
+	}
+	return false
+}
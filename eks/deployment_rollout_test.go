@@ -0,0 +1,137 @@
+package eks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDeploymentRolloutStatus(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		generation     int64
+		status         appsv1.DeploymentStatus
+		expectedStatus string
+		expectedDone   bool
+	}{
+		{
+			"SpecUpdateNotObserved",
+			2,
+			appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3},
+			"waiting for the Deployment spec update to be observed",
+			false,
+		},
+		{
+			"ReplicasNotUpdated",
+			2,
+			appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 2, AvailableReplicas: 3},
+			"2 out of 3 new replicas have been updated",
+			false,
+		},
+		{
+			"OldReplicasPendingTermination",
+			2,
+			appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 4, UpdatedReplicas: 3, AvailableReplicas: 3},
+			"1 old replicas are pending termination",
+			false,
+		},
+		{
+			"ReplicasNotAvailable",
+			2,
+			appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 2},
+			"2 of 3 updated replicas are available",
+			false,
+		},
+		{
+			"Complete",
+			2,
+			appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3},
+			"",
+			true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			deployment := testDeployment("web", 3)
+			deployment.Generation = testCase.generation
+			deployment.Status = testCase.status
+			status, done := deploymentRolloutStatus(deployment)
+			assert.Equal(t, testCase.expectedStatus, status)
+			assert.Equal(t, testCase.expectedDone, done)
+		})
+	}
+}
+
+func TestWaitForDeploymentRolloutWaitsForReplicasToBeAvailable(t *testing.T) {
+	t.Parallel()
+
+	deployment := testDeployment("web", 2)
+	deployment.Status = appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1}
+	client := fake.NewSimpleClientset(deployment)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		updated := deployment.DeepCopy()
+		updated.Status.AvailableReplicas = 2
+		client.AppsV1().Deployments("default").UpdateStatus(context.Background(), updated, metav1.UpdateOptions{})
+	}()
+
+	err := waitForDeploymentRolloutWithClient(context.Background(), client, "default", "web", 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, err)
+}
+
+func TestWaitForDeploymentRolloutReportsLastStatusOnTimeout(t *testing.T) {
+	t.Parallel()
+
+	deployment := testDeployment("web", 3)
+	deployment.Status = appsv1.DeploymentStatus{ObservedGeneration: 1, Replicas: 3, UpdatedReplicas: 1, AvailableReplicas: 3}
+	client := fake.NewSimpleClientset(deployment)
+
+	err := waitForDeploymentRolloutWithClient(context.Background(), client, "default", "web", 50*time.Millisecond, 10*time.Millisecond)
+	require.Error(t, err)
+	assert.Equal(t, DeploymentRolloutTimeoutError{
+		Namespace:      "default",
+		DeploymentName: "web",
+		Timeout:        50 * time.Millisecond,
+		LastStatus:     "1 out of 3 new replicas have been updated",
+	}, errors.Unwrap(err))
+}
+
+func TestWaitForDeploymentRolloutFailsWhenProgressDeadlineIsExceeded(t *testing.T) {
+	t.Parallel()
+
+	deployment := testDeployment("web", 3)
+	deployment.Status = appsv1.DeploymentStatus{
+		ObservedGeneration: 1,
+		Replicas:           3,
+		UpdatedReplicas:    1,
+		Conditions: []appsv1.DeploymentCondition{{
+			Type:   appsv1.DeploymentProgressing,
+			Reason: progressDeadlineExceededReason,
+		}},
+	}
+	client := fake.NewSimpleClientset(deployment)
+
+	err := waitForDeploymentRolloutWithClient(context.Background(), client, "default", "web", 5*time.Second, 10*time.Millisecond)
+	require.Error(t, err)
+	assert.Equal(t, DeploymentProgressDeadlineExceededError{Namespace: "default", DeploymentName: "web"}, errors.Unwrap(err))
+}
+
+func testDeployment(name string, replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Generation: 1},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/service/autoscaling"
-	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/gruntwork-io/go-commons/errors"
 	"github.com/gruntwork-io/kubergrunt/eksawshelper"
 	"github.com/gruntwork-io/kubergrunt/kubectl"
@@ -31,7 +30,7 @@ func DrainASG(
 		return errors.WithStackTrace(err)
 	}
 	asgSvc := autoscaling.New(sess)
-	ec2Svc := ec2.New(sess)
+	ec2Svc := eksawshelper.NewEC2Client(sess)
 	logger.Infof("Successfully authenticated with AWS")
 
 	// Retrieve instance IDs for each ASG requested.
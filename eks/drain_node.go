@@ -0,0 +1,542 @@
+package eks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/collections"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/gruntwork-io/go-commons/retry"
+	"github.com/hashicorp/go-multierror"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+	"github.com/gruntwork-io/kubergrunt/metrics"
+)
+
+// DefaultDrainTimeout is the amount of time to wait for all the Pods to be evicted from a node when DrainOptions does
+// not set a timeout.
+const DefaultDrainTimeout = 15 * time.Minute
+
+// DefaultMaxEvictionsPerSecond is the rate at which Pods are evicted when DrainOptions does not set a rate. This matches
+// the default QPS of the Kubernetes client, so that a drain does not overwhelm the API server or the admission webhooks.
+const DefaultMaxEvictionsPerSecond = 5.0
+
+// EvictionOrder is the order in which DrainNode evicts the Pods on a node, based on their spec.priority.
+type EvictionOrder string
+
+const (
+	// EvictionOrderPriorityAscending evicts the Pods with the lowest priority first, so that the critical workloads stay
+	// up the longest, and get the most time to be rescheduled. This is the default.
+	EvictionOrderPriorityAscending EvictionOrder = "priority-ascending"
+
+	// EvictionOrderPriorityDescending evicts the Pods with the highest priority first.
+	EvictionOrderPriorityDescending EvictionOrder = "priority-descending"
+)
+
+// EvictionOrders returns the valid values of EvictionOrder.
+func EvictionOrders() []string {
+	return []string{string(EvictionOrderPriorityAscending), string(EvictionOrderPriorityDescending)}
+}
+
+// validate returns an InvalidEvictionOrderError if the eviction order is not one of EvictionOrders, or empty for the
+// default.
+func (order EvictionOrder) validate() error {
+	switch order {
+	case "", EvictionOrderPriorityAscending, EvictionOrderPriorityDescending:
+		return nil
+	}
+	return errors.WithStackTrace(InvalidEvictionOrderError{EvictionOrder: order})
+}
+
+// DrainOptions configures how Pods are evicted from a node when draining it.
+//
+// The defaults never bypass a PodDisruptionBudget or lose data. The following options are destructive:
+//   - DeleteEmptyDirData permanently deletes the data in the emptyDir volumes of the evicted Pods.
+//   - Force deletes the Pods that are still on the node after the timeout, bypassing their PodDisruptionBudgets. This can
+//     take a workload below its minimum availability.
+//   - GracePeriodSeconds shorter than the Pod's terminationGracePeriodSeconds cuts graceful shutdown short, and 0 kills
+//     the containers immediately. Combined with Force, the stuck Pods are deleted without any graceful shutdown.
+type DrainOptions struct {
+	// Timeout is the maximum amount of time to wait for all the Pods to be evicted from the node and terminate. Defaults
+	// to DefaultDrainTimeout.
+	Timeout time.Duration
+
+	// Backoff configures the interval between eviction attempts while a PodDisruptionBudget blocks the eviction.
+	// Defaults to DefaultBackoffConfig.
+	Backoff *BackoffConfig
+
+	// GracePeriodSeconds overrides the terminationGracePeriodSeconds of the Pods when evicting or deleting them. When
+	// nil, the grace period of each Pod is used.
+	GracePeriodSeconds *int64
+
+	// Force deletes the Pods that are still on the node once the timeout is reached, instead of returning a
+	// PodEvictionTimeoutError. This is the equivalent of `kubectl drain --force`.
+	Force bool
+
+	// DeleteEmptyDirData allows evicting Pods that use emptyDir volumes. When false, a node with such Pods is not
+	// drained and a PodsWithLocalStorageError is returned.
+	DeleteEmptyDirData bool
+
+	// MaxEvictionsPerSecond is the maximum rate of eviction requests, across all the nodes that are drained together, so
+	// that draining nodes with hundreds of Pods does not overwhelm the API server and the admission webhooks. Retries of
+	// evictions blocked by a PodDisruptionBudget are paced as well. Defaults to DefaultMaxEvictionsPerSecond. Set to a
+	// negative value to disable the rate limit.
+	MaxEvictionsPerSecond float64
+
+	// FailFast stops draining the remaining nodes as soon as one node fails to drain. Only used by DrainNodes, which
+	// otherwise keeps draining the other nodes.
+	FailFast bool
+
+	// EvictionOrder is the order in which the Pods on the node are evicted, based on their spec.priority. Pods with the
+	// same priority are evicted in order of namespace and name. Note that the evictions are issued in order, but the
+	// drain does not wait for a Pod to terminate before evicting the next one. Defaults to
+	// EvictionOrderPriorityAscending.
+	EvictionOrder EvictionOrder
+
+	// ExcludeNamespaces and IncludeNamespaces scope which Pods are evicted: when ExcludeNamespaces is set, the Pods in
+	// these namespaces are not evicted, and when IncludeNamespaces is set, only the Pods in these namespaces are evicted.
+	// The Pods that are not evicted are left running on the node, which stays cordoned, and are not deleted by Force.
+	// Only one of the two can be set.
+	ExcludeNamespaces []string
+	IncludeNamespaces []string
+}
+
+// validate returns an error if the options are invalid, before any node is cordoned.
+func (opts DrainOptions) validate() error {
+	if err := opts.EvictionOrder.validate(); err != nil {
+		return err
+	}
+	if len(opts.ExcludeNamespaces) > 0 && len(opts.IncludeNamespaces) > 0 {
+		return errors.WithStackTrace(ConflictingDrainNamespacesError{})
+	}
+	return nil
+}
+
+// filterPodsByNamespace splits the Pods into the ones to evict and the ones to skip, according to ExcludeNamespaces and
+// IncludeNamespaces.
+func (opts DrainOptions) filterPodsByNamespace(pods []corev1.Pod) ([]corev1.Pod, []corev1.Pod) {
+	if len(opts.ExcludeNamespaces) == 0 && len(opts.IncludeNamespaces) == 0 {
+		return pods, []corev1.Pod{}
+	}
+	toEvict := []corev1.Pod{}
+	skipped := []corev1.Pod{}
+	for _, pod := range pods {
+		evict := !collections.ListContainsElement(opts.ExcludeNamespaces, pod.Namespace)
+		if len(opts.IncludeNamespaces) > 0 {
+			evict = collections.ListContainsElement(opts.IncludeNamespaces, pod.Namespace)
+		}
+		if evict {
+			toEvict = append(toEvict, pod)
+		} else {
+			skipped = append(skipped, pod)
+		}
+	}
+	return toEvict, skipped
+}
+
+// newEvictionLimiter returns the rate limiter for the eviction requests configured by MaxEvictionsPerSecond.
+func (opts DrainOptions) newEvictionLimiter() *rate.Limiter {
+	maxEvictionsPerSecond := opts.MaxEvictionsPerSecond
+	switch {
+	case maxEvictionsPerSecond == 0:
+		maxEvictionsPerSecond = DefaultMaxEvictionsPerSecond
+	case maxEvictionsPerSecond < 0:
+		return rate.NewLimiter(rate.Inf, 1)
+	}
+	return rate.NewLimiter(rate.Limit(maxEvictionsPerSecond), 1)
+}
+
+// NodeDrainResult is the outcome of draining a single node with DrainNodes.
+type NodeDrainResult struct {
+	NodeName string
+
+	// Error is nil if the node was successfully drained.
+	Error error
+}
+
+// DrainNode cordons the node in the EKS cluster, and evicts all the Pods on it using the Kubernetes eviction API. Unlike
+// deleting the Pods directly, this lets the API server enforce PodDisruptionBudgets. Evictions that are blocked by a
+// PodDisruptionBudget are retried until the timeout, after which a PodEvictionTimeoutError is returned naming the Pods
+// that are still on the node, unless Force is set in the options. See DrainOptions for the options that are
+// destructive. Mirror Pods and Pods managed by a DaemonSet are left on the node, as they would be
+// recreated immediately.
+func DrainNode(clusterArn string, nodeName string, opts DrainOptions) error {
+	client, err := kubectl.GetKubernetesClientFromOptions(&kubectl.KubectlOptions{EKSClusterArn: clusterArn})
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return drainNode(context.Background(), client, nodeName, opts)
+}
+
+// DrainNodes cordons all the given nodes in the EKS cluster upfront, so that evicted Pods are not rescheduled on to
+// another node that is about to be drained, and then evicts the Pods from up to maxConcurrent nodes at a time (at least
+// 1). PodDisruptionBudgets are still enforced across the cluster, as all evictions go through the eviction API. The
+// returned results are in the same order as nodeNames, and the error is non-nil if any of the nodes failed to drain.
+// A failure on one node does not stop the others from draining, unless FailFast is set in the options, in which case
+// the nodes that have not been drained yet are reported with a NodeDrainSkippedError.
+func DrainNodes(clusterArn string, nodeNames []string, maxConcurrent int, opts DrainOptions) ([]NodeDrainResult, error) {
+	client, err := kubectl.GetKubernetesClientFromOptions(&kubectl.KubectlOptions{EKSClusterArn: clusterArn})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return drainNodes(context.Background(), client, nodeNames, maxConcurrent, opts)
+}
+
+func drainNodes(
+	ctx context.Context,
+	client kubernetes.Interface,
+	nodeNames []string,
+	maxConcurrent int,
+	opts DrainOptions,
+) ([]NodeDrainResult, error) {
+	logger := logging.GetProjectLogger()
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]NodeDrainResult, len(nodeNames))
+	cordoned := []int{}
+	for i, nodeName := range nodeNames {
+		results[i].NodeName = nodeName
+		if ctx.Err() != nil {
+			results[i].Error = errors.WithStackTrace(NodeDrainSkippedError{NodeName: nodeName})
+			continue
+		}
+		if err := cordonNodeWithClient(ctx, client, nodeName); err != nil {
+			logger.Errorf("Error cordoning node %s: %s", nodeName, err)
+			results[i].Error = err
+			if opts.FailFast {
+				cancel()
+			}
+			continue
+		}
+		cordoned = append(cordoned, i)
+	}
+
+	// Drain up to maxConcurrent nodes at a time, using a buffered channel as a semaphore. The nodes share a single
+	// eviction rate limiter, so that the rate applies to the whole drain.
+	limiter := opts.newEvictionLimiter()
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, maxConcurrent)
+	for _, i := range cordoned {
+		semaphore <- struct{}{}
+		if ctx.Err() != nil {
+			<-semaphore
+			results[i].Error = errors.WithStackTrace(NodeDrainSkippedError{NodeName: results[i].NodeName})
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *NodeDrainResult) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			result.Error = evictPodsFromNode(ctx, client, result.NodeName, opts, limiter)
+			if result.Error == nil {
+				metrics.AddNodesDrained(1)
+			}
+			if result.Error != nil && opts.FailFast {
+				logger.Errorf("Error draining node %s. Aborting drain of the remaining nodes.", result.NodeName)
+				cancel()
+			}
+		}(&results[i])
+	}
+	wg.Wait()
+
+	var drainErrs *multierror.Error
+	for _, result := range results {
+		if result.Error != nil {
+			drainErrs = multierror.Append(drainErrs, result.Error)
+		}
+	}
+	return results, drainErrs.ErrorOrNil()
+}
+
+func drainNode(ctx context.Context, client kubernetes.Interface, nodeName string, opts DrainOptions) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if err := cordonNodeWithClient(ctx, client, nodeName); err != nil {
+		return err
+	}
+	if err := evictPodsFromNode(ctx, client, nodeName, opts, opts.newEvictionLimiter()); err != nil {
+		return err
+	}
+	metrics.AddNodesDrained(1)
+	return nil
+}
+
+// evictPodsFromNode evicts all the Pods on the node, retrying evictions that are blocked by a PodDisruptionBudget and
+// waiting for the evicted Pods to terminate. Each eviction request waits on the limiter first. The node is expected to
+// be cordoned already.
+func evictPodsFromNode(
+	ctx context.Context,
+	client kubernetes.Interface,
+	nodeName string,
+	opts DrainOptions,
+	limiter *rate.Limiter,
+) error {
+	logger := logging.GetProjectLogger()
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultDrainTimeout
+	}
+	backoff := DefaultBackoffConfig()
+	if opts.Backoff != nil {
+		backoff = opts.Backoff.withDefaults()
+	}
+
+	pods, err := podsToEvict(ctx, client, nodeName)
+	if err != nil {
+		return err
+	}
+	pods, skipped := opts.filterPodsByNamespace(pods)
+	if len(skipped) > 0 {
+		logger.Warnf("Not evicting %d Pods from node %s, due to the namespaces to drain: %s", len(skipped), nodeName, strings.Join(podNames(skipped), ", "))
+		logger.Warnf("These Pods are left running on node %s, which stays cordoned.", nodeName)
+	}
+	sortPodsForEviction(pods, opts.EvictionOrder)
+	if !opts.DeleteEmptyDirData {
+		if localStoragePods := podsWithLocalStorage(pods); len(localStoragePods) > 0 {
+			return errors.WithStackTrace(PodsWithLocalStorageError{NodeName: nodeName, PodNames: podNames(localStoragePods)})
+		}
+	}
+	logger.Infof("Evicting %d Pods from node %s", len(pods), nodeName)
+
+	// Pods move from pending (not yet evicted) to evicted (waiting for the Pod to terminate), and are dropped once they
+	// are gone from the node.
+	pending := pods
+	evicted := []corev1.Pod{}
+	err = doWithBackoff(
+		ctx,
+		logger,
+		fmt.Sprintf("Evict Pods from node %s", nodeName),
+		backoff,
+		timeout,
+		func() error {
+			stillPending := []corev1.Pod{}
+			for _, pod := range pending {
+				if err := limiter.Wait(ctx); err != nil {
+					return retry.FatalError{Underlying: err}
+				}
+				err := evictPod(ctx, client, pod, opts.GracePeriodSeconds)
+				switch {
+				case err == nil || apierrors.IsNotFound(err):
+					logger.Infof("Evicted Pod %s/%s from node %s", pod.Namespace, pod.Name, nodeName)
+					evicted = append(evicted, pod)
+				case apierrors.IsTooManyRequests(err):
+					logger.Warnf("Eviction of Pod %s/%s is blocked by a PodDisruptionBudget: %s", pod.Namespace, pod.Name, err)
+					stillPending = append(stillPending, pod)
+				default:
+					return retry.FatalError{Underlying: err}
+				}
+			}
+			pending = stillPending
+
+			stillTerminating := []corev1.Pod{}
+			for _, pod := range evicted {
+				gone, err := isPodGone(ctx, client, pod)
+				if err != nil {
+					return retry.FatalError{Underlying: err}
+				}
+				if !gone {
+					stillTerminating = append(stillTerminating, pod)
+				}
+			}
+			evicted = stillTerminating
+
+			if remaining := len(pending) + len(evicted); remaining > 0 {
+				return fmt.Errorf("%d Pods are still on node %s", remaining, nodeName)
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		if isMaxRetriesExceededErr(err) && opts.Force {
+			logger.Warnf("Timed out evicting Pods from node %s. Deleting the remaining Pods.", nodeName)
+			return deletePods(ctx, client, append(pending, evicted...), opts.GracePeriodSeconds)
+		}
+		if isMaxRetriesExceededErr(err) {
+			return errors.WithStackTrace(PodEvictionTimeoutError{
+				NodeName: nodeName,
+				PodNames: podNames(append(pending, evicted...)),
+			})
+		}
+		if fatalErr, isFatalErr := err.(retry.FatalError); isFatalErr {
+			return errors.WithStackTrace(fatalErr.Underlying)
+		}
+		return errors.WithStackTrace(err)
+	}
+
+	logger.Infof("Successfully drained node %s", nodeName)
+	return nil
+}
+
+// cordonNodeWithClient marks the node as unschedulable, so that the evicted Pods are not rescheduled on to it.
+func cordonNodeWithClient(ctx context.Context, client kubernetes.Interface, nodeName string) error {
+	logger := logging.GetProjectLogger()
+
+	return kubehelper.RetryOnConflictOrServerError(func() error {
+		node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		if node.Spec.Unschedulable {
+			logger.Infof("Node %s is already cordoned", nodeName)
+			return nil
+		}
+		node.Spec.Unschedulable = true
+		kubectl.MarkAsModifiedByKubergrunt(&node.ObjectMeta)
+		if _, err := client.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{}); err != nil {
+			return errors.WithStackTrace(err)
+		}
+		logger.Infof("Cordoned node %s", nodeName)
+		return nil
+	})
+}
+
+// podsToEvict returns the Pods scheduled on the node that should be evicted to drain it. This skips mirror Pods, which
+// are managed by the kubelet directly and can not be evicted, and Pods managed by a DaemonSet, as the DaemonSet
+// controller ignores the unschedulable mark and would recreate them on the node.
+func podsToEvict(ctx context.Context, client kubernetes.Interface, nodeName string) ([]corev1.Pod, error) {
+	podList, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	pods := []corev1.Pod{}
+	for _, pod := range podList.Items {
+		if isMirrorPod(pod) || isDaemonSetPod(pod) {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// sortPodsForEviction sorts the Pods in place in the given eviction order, breaking ties by namespace and name so that
+// the order does not depend on the order in which the API server lists the Pods. Pods without a priority are sorted as
+// priority 0, the priority of the Pods without a PriorityClass.
+func sortPodsForEviction(pods []corev1.Pod, order EvictionOrder) {
+	descending := order == EvictionOrderPriorityDescending
+	sort.SliceStable(pods, func(i, j int) bool {
+		iPriority, jPriority := podPriority(pods[i]), podPriority(pods[j])
+		if iPriority != jPriority {
+			return (iPriority < jPriority) != descending
+		}
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+}
+
+func podPriority(pod corev1.Pod) int32 {
+	if pod.Spec.Priority == nil {
+		return 0
+	}
+	return *pod.Spec.Priority
+}
+
+func isMirrorPod(pod corev1.Pod) bool {
+	_, isMirror := pod.Annotations[corev1.MirrorPodAnnotationKey]
+	return isMirror
+}
+
+func isDaemonSetPod(pod corev1.Pod) bool {
+	controller := metav1.GetControllerOf(&pod)
+	return controller != nil && controller.Kind == "DaemonSet"
+}
+
+// podsWithLocalStorage returns the Pods that use emptyDir volumes, the data of which is lost when the Pod is evicted.
+func podsWithLocalStorage(pods []corev1.Pod) []corev1.Pod {
+	localStoragePods := []corev1.Pod{}
+	for _, pod := range pods {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.EmptyDir != nil {
+				localStoragePods = append(localStoragePods, pod)
+				break
+			}
+		}
+	}
+	return localStoragePods
+}
+
+// evictPod requests the eviction of the Pod through the policy/v1 eviction API. When gracePeriodSeconds is not nil, it
+// overrides the grace period of the Pod.
+func evictPod(ctx context.Context, client kubernetes.Interface, pod corev1.Pod, gracePeriodSeconds *int64) error {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	}
+	if gracePeriodSeconds != nil {
+		eviction.DeleteOptions = &metav1.DeleteOptions{GracePeriodSeconds: gracePeriodSeconds}
+	}
+	return client.PolicyV1().Evictions(pod.Namespace).Evict(ctx, eviction)
+}
+
+// deletePods deletes the Pods directly, bypassing any PodDisruptionBudgets. Pods that are already gone are ignored.
+func deletePods(ctx context.Context, client kubernetes.Interface, pods []corev1.Pod, gracePeriodSeconds *int64) error {
+	logger := logging.GetProjectLogger()
+
+	var deleteErrs *multierror.Error
+	for _, pod := range pods {
+		// Only delete the Pod we evicted, and not a new Pod with the same name created by its controller.
+		uid := pod.UID
+		err := client.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{
+			GracePeriodSeconds: gracePeriodSeconds,
+			Preconditions:      &metav1.Preconditions{UID: &uid},
+		})
+		if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+			deleteErrs = multierror.Append(deleteErrs, err)
+			continue
+		}
+		logger.Infof("Deleted Pod %s/%s", pod.Namespace, pod.Name)
+	}
+	return errors.WithStackTrace(deleteErrs.ErrorOrNil())
+}
+
+// isPodGone returns true if the Pod no longer exists. A Pod with the same name but a different UID is a new Pod
+// created by its controller, so the evicted Pod is considered gone in that case.
+func isPodGone(ctx context.Context, client kubernetes.Interface, pod corev1.Pod) (bool, error) {
+	current, err := client.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, errors.WithStackTrace(err)
+	}
+	return current.UID != pod.UID, nil
+}
+
+func podNames(pods []corev1.Pod) []string {
+	names := []string{}
+	for _, pod := range pods {
+		names = append(names, pod.Namespace+"/"+pod.Name)
+	}
+	return names
+}
@@ -0,0 +1,487 @@
+package eks
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/metrics"
+)
+
+const testDrainNodeName = "ip-10-0-0-1.ec2.internal"
+
+func TestDrainNodeEvictsPodsAndSkipsDaemonSetAndMirrorPods(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeDrainClient(
+		[]string{testDrainNodeName},
+		testPodOnNode("web"),
+		testPodOnNode("worker"),
+		testDaemonSetPodOnNode("fluentd"),
+		testMirrorPodOnNode("kube-proxy"),
+	)
+	err := drainNode(context.Background(), client, testDrainNodeName, testDrainOptions())
+	require.NoError(t, err)
+
+	node, err := client.CoreV1().Nodes().Get(context.Background(), testDrainNodeName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, node.Spec.Unschedulable)
+
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"default/fluentd", "default/kube-proxy"}, podNames(pods.Items))
+}
+
+func TestDrainNodeReturnsBlockedPodsAfterTimeout(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeDrainClient([]string{testDrainNodeName}, testPodOnNode("web"), testPodOnNode("blocked"))
+	err := drainNode(context.Background(), client, testDrainNodeName, testDrainOptions())
+	require.Error(t, err)
+
+	timeoutErr, isTimeoutErr := errors.Unwrap(err).(PodEvictionTimeoutError)
+	require.True(t, isTimeoutErr)
+	assert.Equal(t, []string{"default/blocked"}, timeoutErr.PodNames)
+}
+
+func TestDrainNodeWithEmptyDirPods(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name               string
+		deleteEmptyDirData bool
+	}{
+		{"blocks-drain", false},
+		{"delete-emptydir-data", true},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			pod := testPodOnNode("cache")
+			pod.Spec.Volumes = []corev1.Volume{{Name: "scratch", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+			client := newFakeDrainClient([]string{testDrainNodeName}, pod)
+			opts := testDrainOptions()
+			opts.DeleteEmptyDirData = testCase.deleteEmptyDirData
+
+			err := drainNode(context.Background(), client, testDrainNodeName, opts)
+			if testCase.deleteEmptyDirData {
+				require.NoError(t, err)
+				return
+			}
+			localStorageErr, isLocalStorageErr := errors.Unwrap(err).(PodsWithLocalStorageError)
+			require.True(t, isLocalStorageErr)
+			assert.Equal(t, []string{"default/cache"}, localStorageErr.PodNames)
+		})
+	}
+}
+
+func TestDrainNodeForceDeletesBlockedPodsAfterTimeout(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeDrainClient([]string{testDrainNodeName}, testPodOnNode("web"), testPodOnNode("blocked"))
+	gracePeriodSeconds := int64(5)
+	opts := testDrainOptions()
+	opts.Force = true
+	opts.GracePeriodSeconds = &gracePeriodSeconds
+
+	err := drainNode(context.Background(), client, testDrainNodeName, opts)
+	require.NoError(t, err)
+
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, pods.Items)
+
+	// The grace period override is passed on to the evictions.
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "create" && action.GetSubresource() == "eviction" {
+			eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1.Eviction)
+			require.NotNil(t, eviction.DeleteOptions)
+			assert.Equal(t, gracePeriodSeconds, *eviction.DeleteOptions.GracePeriodSeconds)
+		}
+	}
+}
+
+func TestDrainNodePacesEvictions(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeDrainClient([]string{testDrainNodeName}, testPodOnNode("web-1"), testPodOnNode("web-2"), testPodOnNode("web-3"))
+	opts := testDrainOptions()
+	opts.Timeout = 5 * time.Second
+	opts.MaxEvictionsPerSecond = 20
+
+	// The first eviction goes through immediately with the burst of the limiter, and the other two are paced 50ms apart.
+	start := time.Now()
+	err := drainNode(context.Background(), client, testDrainNodeName, opts)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestDrainNodeEvictsPodsInPriorityOrder(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		order         EvictionOrder
+		expectedOrder []string
+	}{
+		{"default", "", []string{"batch-a", "batch-b", "web", "critical"}},
+		{"ascending", EvictionOrderPriorityAscending, []string{"batch-a", "batch-b", "web", "critical"}},
+		{"descending", EvictionOrderPriorityDescending, []string{"critical", "web", "batch-a", "batch-b"}},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newFakeDrainClient(
+				[]string{testDrainNodeName},
+				testPodOnNodeWithPriority("critical", 1000000),
+				testPodOnNodeWithPriority("batch-b", -10),
+				testPodOnNode("web"),
+				testPodOnNodeWithPriority("batch-a", -10),
+				testDaemonSetPodOnNode("fluentd"),
+			)
+			opts := testDrainOptions()
+			opts.EvictionOrder = testCase.order
+			require.NoError(t, drainNode(context.Background(), client, testDrainNodeName, opts))
+
+			evicted := []string{}
+			for _, action := range client.Actions() {
+				if action.GetVerb() == "create" && action.GetSubresource() == "eviction" {
+					evicted = append(evicted, action.(k8stesting.CreateAction).GetObject().(*policyv1.Eviction).Name)
+				}
+			}
+			assert.Equal(t, testCase.expectedOrder, evicted)
+		})
+	}
+}
+
+func TestDrainNodeRejectsInvalidEvictionOrder(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeDrainClient([]string{testDrainNodeName}, testPodOnNode("web"))
+	opts := testDrainOptions()
+	opts.EvictionOrder = "random"
+	err := drainNode(context.Background(), client, testDrainNodeName, opts)
+	require.Error(t, err)
+	assert.Equal(t, InvalidEvictionOrderError{EvictionOrder: "random"}, errors.Unwrap(err))
+
+	// The node is not cordoned when the options are invalid.
+	node, err := client.CoreV1().Nodes().Get(context.Background(), testDrainNodeName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.False(t, node.Spec.Unschedulable)
+}
+
+func TestDrainNodeScopesEvictionsByNamespace(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name              string
+		excludeNamespaces []string
+		includeNamespaces []string
+		expectedRemaining []string
+	}{
+		{"Exclude", []string{"storage"}, nil, []string{"storage/operator"}},
+		{"Include", nil, []string{"storage"}, []string{"default/web"}},
+		{"IncludeMultiple", nil, []string{"default", "storage"}, []string{}},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newFakeDrainClient([]string{testDrainNodeName}, testPodOnNode("web"), testPodInNamespaceOnNode("storage", "operator"))
+			opts := testDrainOptions()
+			opts.ExcludeNamespaces = testCase.excludeNamespaces
+			opts.IncludeNamespaces = testCase.includeNamespaces
+			require.NoError(t, drainNode(context.Background(), client, testDrainNodeName, opts))
+
+			node, err := client.CoreV1().Nodes().Get(context.Background(), testDrainNodeName, metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.True(t, node.Spec.Unschedulable)
+
+			pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, testCase.expectedRemaining, podNames(pods.Items))
+		})
+	}
+}
+
+func TestDrainNodeRejectsConflictingNamespaces(t *testing.T) {
+	t.Parallel()
+
+	client := newFakeDrainClient([]string{testDrainNodeName}, testPodOnNode("web"))
+	opts := testDrainOptions()
+	opts.ExcludeNamespaces = []string{"storage"}
+	opts.IncludeNamespaces = []string{"default"}
+	err := drainNode(context.Background(), client, testDrainNodeName, opts)
+	require.Error(t, err)
+	assert.Equal(t, ConflictingDrainNamespacesError{}, errors.Unwrap(err))
+
+	node, err := client.CoreV1().Nodes().Get(context.Background(), testDrainNodeName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.False(t, node.Spec.Unschedulable)
+}
+
+func TestDrainOptionsNewEvictionLimiter(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, rate.Limit(DefaultMaxEvictionsPerSecond), DrainOptions{}.newEvictionLimiter().Limit())
+	assert.Equal(t, rate.Limit(2), DrainOptions{MaxEvictionsPerSecond: 2}.newEvictionLimiter().Limit())
+	assert.Equal(t, rate.Inf, DrainOptions{MaxEvictionsPerSecond: -1}.newEvictionLimiter().Limit())
+}
+
+func TestDrainNodesReportsPerNodeResults(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name             string
+		failFast         bool
+		expectedDrained  []string
+		expectedFailures []string
+		expectedSkipped  []string
+	}{
+		{"keep-going", false, []string{"node-a", "node-c"}, []string{"node-b"}, nil},
+		{"fail-fast", true, []string{"node-a"}, []string{"node-b"}, []string{"node-c"}},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			nodeNames := []string{"node-a", "node-b", "node-c"}
+			client := newFakeDrainClient(
+				nodeNames,
+				testPodOn("node-a", "web"),
+				testPodOn("node-b", "blocked"),
+				testPodOn("node-c", "worker"),
+			)
+			opts := testDrainOptions()
+			opts.FailFast = testCase.failFast
+
+			// Drain one node at a time so that the order is deterministic.
+			results, err := drainNodes(context.Background(), client, nodeNames, 1, opts)
+			require.Error(t, err)
+			require.Len(t, results, len(nodeNames))
+
+			drained := []string{}
+			failures := []string{}
+			skipped := []string{}
+			for i, result := range results {
+				assert.Equal(t, nodeNames[i], result.NodeName)
+				switch errors.Unwrap(result.Error).(type) {
+				case nil:
+					drained = append(drained, result.NodeName)
+				case NodeDrainSkippedError:
+					skipped = append(skipped, result.NodeName)
+				default:
+					failures = append(failures, result.NodeName)
+				}
+
+				// All nodes are cordoned upfront, regardless of fail fast.
+				node, err := client.CoreV1().Nodes().Get(context.Background(), result.NodeName, metav1.GetOptions{})
+				require.NoError(t, err)
+				assert.True(t, node.Spec.Unschedulable)
+			}
+			assert.Equal(t, testCase.expectedDrained, drained)
+			assert.Equal(t, testCase.expectedFailures, failures)
+			assert.ElementsMatch(t, testCase.expectedSkipped, skipped)
+		})
+	}
+}
+
+// NOTE: This test is not run in parallel since the metrics are recorded globally, and the other drain tests drain nodes.
+func TestDrainNodesCountsDrainedNodesInMetrics(t *testing.T) {
+	nodeNames := []string{"node-a", "node-b", "node-c"}
+	client := newFakeDrainClient(
+		nodeNames,
+		testPodOn("node-a", "web"),
+		testPodOn("node-b", "blocked"),
+		testPodOn("node-c", "worker"),
+	)
+
+	drainedBefore := nodesDrainedMetric(t)
+	_, err := drainNodes(context.Background(), client, nodeNames, 2, testDrainOptions())
+	require.Error(t, err)
+	// Only the nodes that were fully drained are counted, not the one that is blocked by the PodDisruptionBudget.
+	assert.Equal(t, drainedBefore+2, nodesDrainedMetric(t))
+}
+
+// NOTE: This test is not run in parallel since it stubs out kubectl globally, and the metrics are recorded globally.
+func TestDrainNodesInAsgCountsDrainedNodesInMetrics(t *testing.T) {
+	defer func() { drainKubeNodes = kubectl.DrainNodes }()
+	ec2Svc := &fakeInstancesEC2{privateDNSNames: map[string]string{
+		"i-1": "ip-10-0-0-1.ec2.internal",
+		"i-2": "ip-10-0-0-2.ec2.internal",
+	}}
+
+	drainedNodeNames := []string{}
+	drainKubeNodes = func(kubectlOptions *kubectl.KubectlOptions, nodeNames []string, timeout time.Duration, deleteEmptyDirData bool) error {
+		drainedNodeNames = append(drainedNodeNames, nodeNames...)
+		return nil
+	}
+	drainedBefore := nodesDrainedMetric(t)
+	require.NoError(t, drainNodesInAsg(ec2Svc, &kubectl.KubectlOptions{}, []string{"i-1", "i-2"}, time.Minute, false))
+	assert.Equal(t, []string{"ip-10-0-0-1.ec2.internal", "ip-10-0-0-2.ec2.internal"}, drainedNodeNames)
+	assert.Equal(t, drainedBefore+2, nodesDrainedMetric(t))
+
+	// A failed drain does not count any node.
+	drainKubeNodes = func(kubectlOptions *kubectl.KubectlOptions, nodeNames []string, timeout time.Duration, deleteEmptyDirData bool) error {
+		return errors.WithStackTrace(fmt.Errorf("error when evicting pods"))
+	}
+	require.Error(t, drainNodesInAsg(ec2Svc, &kubectl.KubectlOptions{}, []string{"i-1", "i-2"}, time.Minute, false))
+	assert.Equal(t, drainedBefore+2, nodesDrainedMetric(t))
+}
+
+// fakeInstancesEC2 is a stub of the EC2 API that describes the instances with the given private DNS names, by ID.
+type fakeInstancesEC2 struct {
+	ec2iface.EC2API
+
+	privateDNSNames map[string]string
+}
+
+func (fake *fakeInstancesEC2) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	reservation := &ec2.Reservation{}
+	for _, instanceID := range awsgo.StringValueSlice(input.InstanceIds) {
+		reservation.Instances = append(reservation.Instances, &ec2.Instance{
+			InstanceId:     awsgo.String(instanceID),
+			PrivateDnsName: awsgo.String(fake.privateDNSNames[instanceID]),
+		})
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{reservation}}, nil
+}
+
+// nodesDrainedMetric returns the current value of the nodes drained gauge in the metrics registry.
+func nodesDrainedMetric(t *testing.T) float64 {
+	families, err := metrics.Registry().Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == "kubergrunt_nodes_drained" {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	require.FailNow(t, "The nodes drained metric is not registered")
+	return 0
+}
+
+// newFakeDrainClient returns a fake Kubernetes client with the given nodes and Pods. Evicting a Pod named "blocked"
+// fails as if a PodDisruptionBudget disallows the eviction, while evicting any other Pod deletes it.
+func newFakeDrainClient(nodeNames []string, pods ...*corev1.Pod) *fake.Clientset {
+	objects := []runtime.Object{}
+	for _, nodeName := range nodeNames {
+		objects = append(objects, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}})
+	}
+	for _, pod := range pods {
+		objects = append(objects, pod)
+	}
+	client := fake.NewSimpleClientset(objects...)
+
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(k8stesting.CreateAction).GetObject().(metav1.Object)
+		if eviction.GetName() == "blocked" {
+			return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+		}
+		err := client.Tracker().Delete(action.GetResource(), eviction.GetNamespace(), eviction.GetName())
+		return true, nil, err
+	})
+
+	// The fake client ignores field selectors, so we filter the Pods by node ourselves.
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		fieldSelector := action.(k8stesting.ListAction).GetListRestrictions().Fields
+		obj, err := client.Tracker().List(action.GetResource(), corev1.SchemeGroupVersion.WithKind("Pod"), action.GetNamespace())
+		if err != nil {
+			return true, nil, err
+		}
+		podList := obj.(*corev1.PodList)
+		filtered := []corev1.Pod{}
+		for _, pod := range podList.Items {
+			if fieldSelector.Matches(fields.Set{"spec.nodeName": pod.Spec.NodeName}) {
+				filtered = append(filtered, pod)
+			}
+		}
+		podList.Items = filtered
+		return true, podList, nil
+	})
+	return client
+}
+
+func testDrainOptions() DrainOptions {
+	return DrainOptions{
+		Timeout: 100 * time.Millisecond,
+		Backoff: &BackoffConfig{Base: 1 * time.Millisecond, Max: 10 * time.Millisecond},
+
+		MaxEvictionsPerSecond: -1,
+	}
+}
+
+func testPodOnNode(name string) *corev1.Pod {
+	return testPodOn(testDrainNodeName, name)
+}
+
+func testPodOnNodeWithPriority(name string, priority int32) *corev1.Pod {
+	pod := testPodOnNode(name)
+	pod.Spec.Priority = &priority
+	return pod
+}
+
+func testPodInNamespaceOnNode(namespace string, name string) *corev1.Pod {
+	pod := testPodOnNode(name)
+	pod.Namespace = namespace
+	return pod
+}
+
+func testPodOn(nodeName string, name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID("uid-" + name)},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+	}
+}
+
+func testDaemonSetPodOnNode(name string) *corev1.Pod {
+	pod := testPodOnNode(name)
+	isController := true
+	pod.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: name, Controller: &isController}}
+	return pod
+}
+
+func testMirrorPodOnNode(name string) *corev1.Pod {
+	pod := testPodOnNode(name)
+	pod.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "mirror"}
+	return pod
+}
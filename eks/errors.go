@@ -3,6 +3,7 @@ package eks
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 // EKSClusterNotReady is returned when the EKS cluster is detected to not be in the ready state
@@ -26,6 +27,15 @@ func (err EKSClusterReadyTimeoutError) Error() string {
 	)
 }
 
+// EKSClusterFailedError is returned when the EKS cluster reaches the FAILED state while waiting for it to be active.
+type EKSClusterFailedError struct {
+	eksClusterArn string
+}
+
+func (err EKSClusterFailedError) Error() string {
+	return fmt.Sprintf("EKS cluster %s is in the FAILED state.", err.eksClusterArn)
+}
+
 // CouldNotMeetASGCapacityError represents an error related to waiting for ASG to reach desired capacity
 type CouldNotMeetASGCapacityError struct {
 	asgName string
@@ -125,6 +135,51 @@ func (err NoPeerCertificatesError) Error() string {
 	return fmt.Sprintf("Could not find any peer certificates for URL %s", err.URL)
 }
 
+// OIDCConfigRequestError is returned when the OIDC provider config could not be retrieved from the issuer.
+type OIDCConfigRequestError struct {
+	URL        string
+	StatusCode int
+}
+
+func (err OIDCConfigRequestError) Error() string {
+	return fmt.Sprintf("Request for OIDC config at %s failed with status code %d", err.URL, err.StatusCode)
+}
+
+// MissingJwksURIError is returned when the OIDC provider config does not specify where the JWKS keys are served.
+type MissingJwksURIError struct {
+	URL string
+}
+
+func (err MissingJwksURIError) Error() string {
+	return fmt.Sprintf("OIDC config at %s does not contain a jwks_uri", err.URL)
+}
+
+// OIDCIssuerNotFoundError is returned when the EKS cluster does not have an OIDC issuer.
+type OIDCIssuerNotFoundError struct {
+	ClusterArn string
+}
+
+func (err OIDCIssuerNotFoundError) Error() string {
+	return fmt.Sprintf("Could not find an OIDC issuer for EKS cluster %s", err.ClusterArn)
+}
+
+// OIDCProviderNotFoundError is returned when there is no IAM OIDC provider registered for the OIDC issuer of the EKS
+// cluster.
+type OIDCProviderNotFoundError struct {
+	ClusterArn  string
+	IssuerURL   string
+	ProviderArn string
+}
+
+func (err OIDCProviderNotFoundError) Error() string {
+	return fmt.Sprintf(
+		"Could not find the IAM OIDC provider %s for the OIDC issuer %s of EKS cluster %s. Run kubergrunt eks associate-oidc-provider to create it.",
+		err.ProviderArn,
+		err.IssuerURL,
+		err.ClusterArn,
+	)
+}
+
 // UnsupportedEKSVersion is returned when the Kubernetes version of the EKS cluster is not supported.
 type UnsupportedEKSVersion struct {
 	version string
@@ -145,6 +200,57 @@ func (err CoreComponentUnexpectedConfigurationErr) Error() string {
 	return fmt.Sprintf("Core component %s is in unexpected configuration: %s", err.component, err.reason)
 }
 
+// IncompatibleComponentVersionErr error is returned when the version a core component would be synced to is not listed
+// by AWS as compatible with the Kubernetes version of the EKS cluster.
+type IncompatibleComponentVersionErr struct {
+	component          string
+	version            string
+	kubernetesVersion  string
+	compatibleVersions []string
+}
+
+func (err IncompatibleComponentVersionErr) Error() string {
+	return fmt.Sprintf(
+		"Version %s of core component %s is not compatible with Kubernetes %s. Compatible versions: %s. Pass --allow-incompatible to sync anyway.",
+		err.version,
+		err.component,
+		err.kubernetesVersion,
+		strings.Join(err.compatibleVersions, ", "),
+	)
+}
+
+// ComponentDowngradeErr error is returned when syncing a core component would downgrade it across more than one minor
+// version.
+type ComponentDowngradeErr struct {
+	component      string
+	currentVersion string
+	targetVersion  string
+}
+
+func (err ComponentDowngradeErr) Error() string {
+	return fmt.Sprintf(
+		"Syncing core component %s from %s to %s would downgrade it across more than one minor version. Pass --allow-incompatible to sync anyway.",
+		err.component,
+		err.currentVersion,
+		err.targetVersion,
+	)
+}
+
+// UnknownCoreComponentErr error is returned when the name of a core component to sync is not recognized.
+type UnknownCoreComponentErr struct {
+	component string
+}
+
+func (err UnknownCoreComponentErr) Error() string {
+	return fmt.Sprintf(
+		"Unknown core component %s. Must be one of %s, %s, or %s.",
+		err.component,
+		KubeProxyComponentName,
+		CoreDNSComponentName,
+		VPCCNIComponentName,
+	)
+}
+
 // NetworkInterfaceDetachedTimeoutError is returned when we time out waiting for a network interface to be detached.
 type NetworkInterfaceDetachedTimeoutError struct {
 	networkInterfaceId string
@@ -158,17 +264,91 @@ func (err NetworkInterfaceDetachedTimeoutError) Error() string {
 }
 
 // NetworkInterfaceDeletedTimeoutError is returned when we time out waiting for a network interface to be deleted.
+// LastStatus is the status of the network interface (e.g., in-use or attaching) the last time it was seen, if known.
 type NetworkInterfaceDeletedTimeoutError struct {
-	networkInterfaceId string
+	NetworkInterfaceID string
+	LastStatus         string
 }
 
 func (err NetworkInterfaceDeletedTimeoutError) Error() string {
+	if err.LastStatus == "" {
+		return fmt.Sprintf(
+			"Timed out waiting for network interface %s to reach deleted state.",
+			err.NetworkInterfaceID,
+		)
+	}
 	return fmt.Sprintf(
-		"Timed out waiting for network interface %s to reach deleted state.",
-		err.networkInterfaceId,
+		"Timed out waiting for network interface %s to reach deleted state. Last seen status: %s.",
+		err.NetworkInterfaceID,
+		err.LastStatus,
 	)
 }
 
+// SecurityGroupDependencyViolationError is returned when a security group can not be deleted because other resources
+// still reference it.
+type SecurityGroupDependencyViolationError struct {
+	SecurityGroupID             string
+	NetworkInterfaceIDs         []string
+	ReferencingSecurityGroupIDs []string
+}
+
+func (err SecurityGroupDependencyViolationError) Error() string {
+	return fmt.Sprintf(
+		"Could not delete security group %s because it is still referenced by network interfaces %v and by rules in security groups %v.",
+		err.SecurityGroupID,
+		err.NetworkInterfaceIDs,
+		err.ReferencingSecurityGroupIDs,
+	)
+}
+
+// CleanupTimeoutError is returned when the overall timeout of CleanupSecurityGroup runs out before all the resources are
+// cleaned up. The remaining resources are cleaned up by running the cleanup again.
+type CleanupTimeoutError struct {
+	Timeout                      time.Duration
+	RemainingSecurityGroupIDs    []string
+	RemainingNetworkInterfaceIDs []string
+}
+
+func (err CleanupTimeoutError) Error() string {
+	return fmt.Sprintf(
+		"Timed out after %s cleaning up the security groups. Security groups not yet deleted: %v. Network interfaces not yet deleted: %v.",
+		err.Timeout,
+		err.RemainingSecurityGroupIDs,
+		err.RemainingNetworkInterfaceIDs,
+	)
+}
+
+// CleanupLeftoversError is returned by VerifyCleanup when resources of the EKS cluster are still around.
+type CleanupLeftoversError struct {
+	ClusterName string
+	VPCID       string
+	Leftovers   CleanupLeftovers
+}
+
+func (err CleanupLeftoversError) Error() string {
+	return fmt.Sprintf(
+		"EKS cluster %s left resources behind in VPC %s: %d security groups, %d network interfaces, %d load balancers, %d classic load balancers, and %d EBS volumes.",
+		err.ClusterName,
+		err.VPCID,
+		len(err.Leftovers.SecurityGroupIDs),
+		len(err.Leftovers.NetworkInterfaceIDs),
+		len(err.Leftovers.LoadBalancerArns),
+		len(err.Leftovers.ClassicLoadBalancerNames),
+		len(err.Leftovers.VolumeIDs),
+	)
+}
+
+// ClusterCleanupFailedError is returned by CleanupSecurityGroups for each cluster whose security groups could not be
+// cleaned up.
+type ClusterCleanupFailedError struct {
+	ClusterArn    string
+	UnderlyingErr error
+}
+
+func (err ClusterCleanupFailedError) Error() string {
+	return fmt.Sprintf("Error cleaning up the security groups of EKS cluster %s: %v", err.ClusterArn, err.UnderlyingErr)
+}
+
 // CouldNotFindLoadBalancerErr is returned when the given ELB can not be found.
 type CouldNotFindLoadBalancerErr struct {
 	name string
@@ -180,3 +360,449 @@ func (err CouldNotFindLoadBalancerErr) Error() string {
 		err.name,
 	)
 }
+
+// InvalidMaxUnavailableError is returned when the number of instances to replace at a time is not positive.
+type InvalidMaxUnavailableError struct {
+	maxUnavailable int
+}
+
+func (err InvalidMaxUnavailableError) Error() string {
+	return fmt.Sprintf("Max unavailable must be at least 1 (got %d).", err.maxUnavailable)
+}
+
+// InstanceNotUpToDateError is returned when an instance launched during a rolling deployment is not running the
+// current launch template version of the ASG.
+type InstanceNotUpToDateError struct {
+	asgName    string
+	instanceID string
+}
+
+func (err InstanceNotUpToDateError) Error() string {
+	return fmt.Sprintf(
+		"Instance %s launched during the roll out of ASG %s is not running the current launch template version. Was the launch template of the ASG updated during the roll out?",
+		err.instanceID,
+		err.asgName,
+	)
+}
+
+// RollOutPrerequisitesError is returned by a dry run of RollOutDeployment or RollingDeployment when the ASG does not
+// meet the prerequisites of the roll out.
+type RollOutPrerequisitesError struct {
+	ASGName  string
+	Problems []string
+}
+
+func (err RollOutPrerequisitesError) Error() string {
+	return fmt.Sprintf(
+		"ASG %s does not meet the prerequisites of the roll out: %s",
+		err.ASGName,
+		strings.Join(err.Problems, " "),
+	)
+}
+
+// InstanceRefreshFailedError is returned when an instance refresh of an ASG fails or is cancelled, with the status and
+// reason reported by AWS.
+type InstanceRefreshFailedError struct {
+	ASGName           string
+	InstanceRefreshID string
+	Status            string
+	StatusReason      string
+}
+
+func (err InstanceRefreshFailedError) Error() string {
+	return fmt.Sprintf(
+		"Instance refresh %s of ASG %s ended with status %s: %s",
+		err.InstanceRefreshID,
+		err.ASGName,
+		err.Status,
+		err.StatusReason,
+	)
+}
+
+// InstanceRefreshTimeoutError is returned when an instance refresh of an ASG does not complete within the timeout.
+type InstanceRefreshTimeoutError struct {
+	ASGName           string
+	InstanceRefreshID string
+	Timeout           time.Duration
+	LastStatus        string
+}
+
+func (err InstanceRefreshTimeoutError) Error() string {
+	return fmt.Sprintf(
+		"Timed out after %s waiting for instance refresh %s of ASG %s to complete. The instance refresh is still in progress in AWS. Last status: %s",
+		err.Timeout,
+		err.InstanceRefreshID,
+		err.ASGName,
+		err.LastStatus,
+	)
+}
+
+// LifecycleHookNotFoundError is returned when the ASG does not have the termination lifecycle hook that is needed to
+// drain the nodes before their instances are terminated.
+type LifecycleHookNotFoundError struct {
+	ASGName           string
+	LifecycleHookName string
+}
+
+func (err LifecycleHookNotFoundError) Error() string {
+	return fmt.Sprintf(
+		"ASG %s does not have a lifecycle hook named %s for the autoscaling:EC2_INSTANCE_TERMINATING transition.",
+		err.ASGName,
+		err.LifecycleHookName,
+	)
+}
+
+// InvalidEvictionOrderError is returned when the EvictionOrder of the DrainOptions is not one of EvictionOrders.
+type InvalidEvictionOrderError struct {
+	EvictionOrder EvictionOrder
+}
+
+func (err InvalidEvictionOrderError) Error() string {
+	return fmt.Sprintf("Invalid eviction order %s. Must be one of: %s.", err.EvictionOrder, strings.Join(EvictionOrders(), ", "))
+}
+
+// ConflictingDrainNamespacesError is returned when both ExcludeNamespaces and IncludeNamespaces are set in the
+// DrainOptions.
+type ConflictingDrainNamespacesError struct{}
+
+func (err ConflictingDrainNamespacesError) Error() string {
+	return "Only one of the namespaces to exclude from the drain and the namespaces to include in the drain can be set."
+}
+
+// PodEvictionTimeoutError is returned when the Pods on a node could not all be evicted within the drain timeout, e.g.
+// because a PodDisruptionBudget keeps blocking the eviction.
+type PodEvictionTimeoutError struct {
+	NodeName string
+	PodNames []string
+}
+
+func (err PodEvictionTimeoutError) Error() string {
+	return fmt.Sprintf(
+		"Timed out waiting for Pods to be evicted from node %s. The following Pods are still on the node: %s",
+		err.NodeName,
+		strings.Join(err.PodNames, ", "),
+	)
+}
+
+// NodeDrainSkippedError is returned for the nodes that were not drained because draining another node failed while
+// fail fast was enabled.
+type NodeDrainSkippedError struct {
+	NodeName string
+}
+
+func (err NodeDrainSkippedError) Error() string {
+	return fmt.Sprintf("Skipped draining node %s because draining another node failed.", err.NodeName)
+}
+
+// PodsWithLocalStorageError is returned when a node can not be drained because Pods on it use emptyDir volumes, and
+// deleting the emptyDir data was not allowed.
+type PodsWithLocalStorageError struct {
+	NodeName string
+	PodNames []string
+}
+
+func (err PodsWithLocalStorageError) Error() string {
+	return fmt.Sprintf(
+		"Can not drain node %s as the following Pods use emptyDir volumes, the data of which would be deleted: %s. Allow deleting the emptyDir data to drain the node.",
+		err.NodeName,
+		strings.Join(err.PodNames, ", "),
+	)
+}
+
+// NoFargateProfileForCoreDNSError is returned when scheduling coredns on Fargate, but there is no active Fargate profile
+// that selects the coredns Pods in the kube-system namespace.
+type NoFargateProfileForCoreDNSError struct {
+	clusterName string
+}
+
+func (err NoFargateProfileForCoreDNSError) Error() string {
+	return fmt.Sprintf(
+		"EKS cluster %s has no active Fargate profile that selects the coredns Pods in the kube-system namespace. Create a Fargate profile for the kube-system namespace first, otherwise the coredns Pods will be left pending.",
+		err.clusterName,
+	)
+}
+
+// ClusterNotDeletedError is returned when cleaning up the security groups of an EKS cluster that still exists, as
+// deleting them breaks the cluster.
+type ClusterNotDeletedError struct {
+	ClusterArn string
+	Status     string
+}
+
+func (err ClusterNotDeletedError) Error() string {
+	return fmt.Sprintf(
+		"EKS cluster %s is in %s state. Deleting its security groups would break the cluster, so they can only be cleaned up once the cluster is deleted or in the DELETING or FAILED state. Delete the cluster first, or force the cleanup to skip this check.",
+		err.ClusterArn,
+		err.Status,
+	)
+}
+
+// SecurityGroupNotOfClusterError is returned when cleaning up a security group that does not belong to the EKS cluster,
+// to avoid deleting an unrelated security group that was passed in by mistake.
+type SecurityGroupNotOfClusterError struct {
+	SecurityGroupID string
+	ClusterName     string
+}
+
+func (err SecurityGroupNotOfClusterError) Error() string {
+	return fmt.Sprintf(
+		"Security group %s is neither the cluster security group of EKS cluster %s nor tagged with the name of the cluster. Refusing to delete it. Double check the security group ID, or force the cleanup to skip this check.",
+		err.SecurityGroupID,
+		err.ClusterName,
+	)
+}
+
+// ClusterVPCConfigNotFoundError is returned when the security group or VPC ID to clean up needs to be looked up from
+// the EKS cluster, but the cluster no longer exists or does not report them.
+type ClusterVPCConfigNotFoundError struct {
+	ClusterArn string
+}
+
+func (err ClusterVPCConfigNotFoundError) Error() string {
+	return fmt.Sprintf(
+		"Could not look up the security group and VPC of EKS cluster %s, as the cluster is fully deleted. Pass in the security group and VPC IDs explicitly.",
+		err.ClusterArn,
+	)
+}
+
+// UnknownVerifyAccessOperationError is returned when VerifyAccess is called with an operation that it does not know the
+// permissions of.
+type UnknownVerifyAccessOperationError struct {
+	Operation string
+}
+
+func (err UnknownVerifyAccessOperationError) Error() string {
+	return fmt.Sprintf(
+		"Unknown operation %s. The permissions can be verified for the following operations: %s",
+		err.Operation,
+		strings.Join(VerifyAccessOperations(), ", "),
+	)
+}
+
+// MissingPermissionsError is returned by VerifyAccess when some of the permissions needed by the operation are not
+// granted.
+type MissingPermissionsError struct {
+	Operation    string
+	FailedChecks []string
+}
+
+func (err MissingPermissionsError) Error() string {
+	return fmt.Sprintf(
+		"Missing permissions for the %s operation. The following checks failed: %s",
+		err.Operation,
+		strings.Join(err.FailedChecks, ", "),
+	)
+}
+
+// InvalidProviderIDError is returned when the EC2 instance ID can not be determined from the spec.providerID of a node.
+type InvalidProviderIDError struct {
+	NodeName   string
+	ProviderID string
+}
+
+func (err InvalidProviderIDError) Error() string {
+	return fmt.Sprintf(
+		"Can not determine the EC2 instance of node %s from its provider ID %q. Expected a provider ID of the form aws:///AVAILABILITY_ZONE/INSTANCE_ID.",
+		err.NodeName,
+		err.ProviderID,
+	)
+}
+
+// InstanceNotInASGError is returned when replacing a node whose EC2 instance is not in an Auto Scaling Group, as there
+// is nothing that would launch a replacement.
+type InstanceNotInASGError struct {
+	InstanceID string
+}
+
+func (err InstanceNotInASGError) Error() string {
+	return fmt.Sprintf("EC2 instance %s is not in an Auto Scaling Group, so no replacement would be launched.", err.InstanceID)
+}
+
+// NodeNotReadyError is returned when the node of an EC2 instance does not become Ready and schedulable in time.
+type NodeNotReadyError struct {
+	InstanceID string
+	LastState  string
+}
+
+func (err NodeNotReadyError) Error() string {
+	return fmt.Sprintf("Timed out waiting for the node of EC2 instance %s to be ready: %s.", err.InstanceID, err.LastState)
+}
+
+// NodeNotEmptyError is returned when there are still Pods on a node after waiting for them to terminate.
+type NodeNotEmptyError struct {
+	NodeName string
+	PodNames []string
+}
+
+func (err NodeNotEmptyError) Error() string {
+	return fmt.Sprintf("Timed out waiting for the Pods on node %s to terminate. Pods still on the node: %s.", err.NodeName, strings.Join(err.PodNames, ", "))
+}
+
+// DeploymentRolloutTimeoutError is returned when the rollout of a Deployment does not complete within the timeout. It
+// includes the last observed status to help debug why the rollout is stuck.
+type DeploymentRolloutTimeoutError struct {
+	Namespace      string
+	DeploymentName string
+	Timeout        time.Duration
+	LastStatus     string
+}
+
+func (err DeploymentRolloutTimeoutError) Error() string {
+	return fmt.Sprintf(
+		"Timed out after %s waiting for the rollout of Deployment %s (Namespace: %s) to complete. Last observed status: %s",
+		err.Timeout,
+		err.DeploymentName,
+		err.Namespace,
+		err.LastStatus,
+	)
+}
+
+// DeploymentProgressDeadlineExceededError is returned when a Deployment reports that its rollout did not make progress
+// within its progressDeadlineSeconds.
+type DeploymentProgressDeadlineExceededError struct {
+	Namespace      string
+	DeploymentName string
+}
+
+func (err DeploymentProgressDeadlineExceededError) Error() string {
+	return fmt.Sprintf("Rollout of Deployment %s (Namespace: %s) exceeded its progress deadline.", err.DeploymentName, err.Namespace)
+}
+
+// NodeGroupUpdateFailedError is returned when an update of an EKS managed node group fails or is cancelled.
+type NodeGroupUpdateFailedError struct {
+	NodeGroupName string
+	UpdateID      string
+	Status        string
+	Errors        []string
+}
+
+func (err NodeGroupUpdateFailedError) Error() string {
+	message := fmt.Sprintf("Update %s of node group %s finished with status %s.", err.UpdateID, err.NodeGroupName, err.Status)
+	if len(err.Errors) > 0 {
+		message = fmt.Sprintf("%s Errors: %s", message, strings.Join(err.Errors, "; "))
+	}
+	return message
+}
+
+// NodeGroupLaunchTemplateNotFoundError is returned when the EKS managed node group is not deployed with a launch
+// template, so that its SSH key can not be rotated with a new launch template version.
+type NodeGroupLaunchTemplateNotFoundError struct {
+	NodeGroupName string
+}
+
+func (err NodeGroupLaunchTemplateNotFoundError) Error() string {
+	return fmt.Sprintf("Node group %s is not deployed with a launch template. The SSH key of a node group without a launch template can only be changed by recreating the node group.", err.NodeGroupName)
+}
+
+// KeyPairNotFoundError is returned when the EC2 key pair to rotate the SSH key of the nodes to does not exist.
+type KeyPairNotFoundError struct {
+	KeyName string
+}
+
+func (err KeyPairNotFoundError) Error() string {
+	return fmt.Sprintf("Could not find EC2 key pair %s.", err.KeyName)
+}
+
+// NoNodesInNodeGroupError is returned when there are no Kubernetes nodes labeled with the name of the EKS managed node
+// group.
+type NoNodesInNodeGroupError struct {
+	NodeGroupName string
+	NodeSelector  string
+}
+
+func (err NoNodesInNodeGroupError) Error() string {
+	if err.NodeSelector != "" {
+		return fmt.Sprintf(
+			"Could not find any nodes of node group %s, with the label %s=%s, that match the node selector %s.",
+			err.NodeGroupName,
+			nodeGroupLabelKey,
+			err.NodeGroupName,
+			err.NodeSelector,
+		)
+	}
+	return fmt.Sprintf("Could not find any nodes of node group %s, with the label %s=%s.", err.NodeGroupName, nodeGroupLabelKey, err.NodeGroupName)
+}
+
+// InvalidNodeSelectorError is returned when a node selector is not a valid Kubernetes label selector.
+type InvalidNodeSelectorError struct {
+	NodeSelector string
+	Reason       string
+}
+
+func (err InvalidNodeSelectorError) Error() string {
+	return fmt.Sprintf("Invalid node selector %s: %s. Expected a label selector such as workload=gpu,zone!=us-east-1a.", err.NodeSelector, err.Reason)
+}
+
+// InvalidTaintError is returned when a taint is not in the key[=value]:effect format, or has an unknown effect.
+type InvalidTaintError struct {
+	Taint string
+}
+
+func (err InvalidTaintError) Error() string {
+	return fmt.Sprintf("Invalid taint %s. Expected the format key[=value]:effect, where effect is one of NoSchedule, PreferNoSchedule, or NoExecute.", err.Taint)
+}
+
+// ClusterCANotFoundError is returned when the EKS cluster does not report the CA certificate of its Kubernetes API
+// server, e.g., while it is being created.
+type ClusterCANotFoundError struct {
+	ClusterArn string
+}
+
+func (err ClusterCANotFoundError) Error() string {
+	return fmt.Sprintf("EKS cluster %s does not have a certificate authority yet. Wait for the cluster to be ACTIVE.", err.ClusterArn)
+}
+
+// InvalidClusterCAError is returned when the certificate authority data of the EKS cluster is not a base64 encoded PEM
+// certificate.
+type InvalidClusterCAError struct {
+	ClusterArn    string
+	UnderlyingErr error
+}
+
+func (err InvalidClusterCAError) Error() string {
+	if err.UnderlyingErr != nil {
+		return fmt.Sprintf("The certificate authority data of EKS cluster %s is not valid base64: %s", err.ClusterArn, err.UnderlyingErr)
+	}
+	return fmt.Sprintf("The certificate authority data of EKS cluster %s is not a PEM encoded certificate.", err.ClusterArn)
+}
+
+// TokenNotAuthenticatedError is returned when the Kubernetes API server of the EKS cluster rejects a token.
+type TokenNotAuthenticatedError struct {
+	ClusterArn    string
+	UnderlyingErr error
+}
+
+func (err TokenNotAuthenticatedError) Error() string {
+	return fmt.Sprintf(
+		"The Kubernetes API server of EKS cluster %s rejected the token: %s. Check that the token is not expired, and that the IAM identity that signed it (see aws sts get-caller-identity) is mapped to a Kubernetes user in the aws-auth ConfigMap or the EKS access entries.",
+		err.ClusterArn,
+		err.UnderlyingErr,
+	)
+}
+
+// NoTokenInExecCredentialError is returned when the ExecCredential JSON passed in as a token does not hold a token.
+type NoTokenInExecCredentialError struct{}
+
+func (err NoTokenInExecCredentialError) Error() string {
+	return "The ExecCredential JSON does not have a token in status.token."
+}
+
+// InvalidAwsAuthConfigMapError is returned when a key of the aws-auth ConfigMap is not a valid YAML list of mappings.
+type InvalidAwsAuthConfigMapError struct {
+	Key    string
+	Reason string
+}
+
+func (err InvalidAwsAuthConfigMapError) Error() string {
+	return fmt.Sprintf("The %s key of ConfigMap %s/%s is invalid: %s. Fix the ConfigMap before updating it with kubergrunt.", err.Key, componentNamespace, awsAuthConfigMapName, err.Reason)
+}
+
+// InvalidAwsAuthMappingError is returned when a mapping to add to (or remove from) the aws-auth ConfigMap is invalid.
+type InvalidAwsAuthMappingError struct {
+	ARN    string
+	Reason string
+}
+
+func (err InvalidAwsAuthMappingError) Error() string {
+	return fmt.Sprintf("Invalid aws-auth mapping for %s: %s", err.ARN, err.Reason)
+}
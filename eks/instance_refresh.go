@@ -0,0 +1,315 @@
+package eks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/gruntwork-io/go-commons/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+const (
+	// DefaultInstanceRefreshPollInterval is the default interval between checks of the status of an instance refresh.
+	DefaultInstanceRefreshPollInterval = 15 * time.Second
+
+	// lifecycleTransitionTerminating is the lifecycle transition of the lifecycle hooks that pause the termination of
+	// the instances of an ASG.
+	lifecycleTransitionTerminating = "autoscaling:EC2_INSTANCE_TERMINATING"
+
+	// lifecycleActionResultContinue is the result of a lifecycle action that lets the ASG proceed with the transition.
+	lifecycleActionResultContinue = "CONTINUE"
+)
+
+// InstanceRefreshOptions configures the instance refresh started by StartInstanceRefresh.
+type InstanceRefreshOptions struct {
+	// MinHealthyPercentage is the percentage of the desired capacity of the ASG that must stay healthy during the
+	// instance refresh, which bounds how many instances are replaced at a time. When nil, the AWS default of 90% is used.
+	MinHealthyPercentage *int64
+
+	// InstanceWarmup is how long to wait after a new instance is healthy before it counts towards the healthy capacity
+	// of the ASG. When nil, the health check grace period of the ASG is used.
+	InstanceWarmup *time.Duration
+
+	// Timeout is the maximum amount of time to wait for the instance refresh to complete. Zero waits indefinitely. Note
+	// that the instance refresh keeps going in AWS after the timeout.
+	Timeout time.Duration
+
+	// PollInterval is the interval between checks of the status of the instance refresh. Defaults to
+	// DefaultInstanceRefreshPollInterval.
+	PollInterval time.Duration
+
+	// DrainLifecycleHookName is the name of a termination lifecycle hook of the ASG. When set, the node of each instance
+	// that waits on the hook to be terminated is drained with DrainOptions, before the lifecycle action is completed so
+	// that the instance is terminated. The heartbeat timeout of the hook must be longer than the drain timeout, as the
+	// instance is terminated when the hook times out.
+	DrainLifecycleHookName string
+
+	// DrainOptions configures how the nodes are drained when DrainLifecycleHookName is set.
+	DrainOptions DrainOptions
+}
+
+// StartInstanceRefresh replaces the instances of the ASG with the native instance refresh of EC2 Auto Scaling, as an
+// alternative to RollingDeployment. This starts a rolling instance refresh with the MinHealthyPercentage and
+// InstanceWarmup of the options, then waits for it to complete, logging its progress. Returns an
+// InstanceRefreshFailedError with the status and reason reported by AWS if the instance refresh fails or is cancelled,
+// or an InstanceRefreshTimeoutError if it does not complete within the timeout.
+//
+// Unlike RollingDeployment, the instance refresh does not drain the nodes before terminating them, unless the options
+// name a termination lifecycle hook of the ASG (see DrainLifecycleHookName). If draining a node fails, the instance
+// refresh is cancelled so that no other instance is replaced, and the instance is terminated when the hook times out.
+func StartInstanceRefresh(clusterArn string, asgName string, opts InstanceRefreshOptions) error {
+	logger := logging.GetProjectLogger()
+
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return err
+	}
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	asgSvc := autoscaling.New(sess)
+	logger.Infof("Successfully authenticated with AWS")
+
+	// The Kubernetes client is only needed to drain the nodes.
+	var client kubernetes.Interface
+	if opts.DrainLifecycleHookName != "" {
+		client, err = kubectl.GetKubernetesClientFromOptions(&kubectl.KubectlOptions{EKSClusterArn: clusterArn})
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+	return startInstanceRefresh(context.Background(), asgSvc, client, asgName, opts)
+}
+
+func startInstanceRefresh(
+	ctx context.Context,
+	asgSvc autoscalingiface.AutoScalingAPI,
+	client kubernetes.Interface,
+	asgName string,
+	opts InstanceRefreshOptions,
+) error {
+	logger := logging.GetProjectLogger().WithField("asg", asgName)
+
+	if opts.DrainLifecycleHookName != "" {
+		if err := opts.DrainOptions.validate(); err != nil {
+			return err
+		}
+		if err := verifyTerminationLifecycleHook(ctx, asgSvc, asgName, opts.DrainLifecycleHookName); err != nil {
+			return err
+		}
+	}
+
+	preferences := &autoscaling.RefreshPreferences{MinHealthyPercentage: opts.MinHealthyPercentage}
+	if opts.InstanceWarmup != nil {
+		preferences.InstanceWarmup = aws.Int64(int64(opts.InstanceWarmup.Seconds()))
+	}
+	output, err := asgSvc.StartInstanceRefreshWithContext(ctx, &autoscaling.StartInstanceRefreshInput{
+		AutoScalingGroupName: aws.String(asgName),
+		Strategy:             aws.String(autoscaling.RefreshStrategyRolling),
+		Preferences:          preferences,
+	})
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	instanceRefreshID := aws.StringValue(output.InstanceRefreshId)
+	logger.Infof("Started instance refresh %s of ASG %s", instanceRefreshID, asgName)
+
+	return waitForInstanceRefresh(ctx, asgSvc, client, asgName, instanceRefreshID, opts)
+}
+
+// waitForInstanceRefresh polls the status of the instance refresh until it completes, logging its progress whenever it
+// changes. When the options name a termination lifecycle hook, the instances that wait on it are drained along the way.
+func waitForInstanceRefresh(
+	ctx context.Context,
+	asgSvc autoscalingiface.AutoScalingAPI,
+	client kubernetes.Interface,
+	asgName string,
+	instanceRefreshID string,
+	opts InstanceRefreshOptions,
+) error {
+	logger := logging.GetProjectLogger().WithField("asg", asgName)
+
+	pollInterval := opts.PollInterval
+	if pollInterval == 0 {
+		pollInterval = DefaultInstanceRefreshPollInterval
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	drainedInstanceIDs := map[string]bool{}
+	lastStatus := "instance refresh not retrieved yet"
+	for {
+		refresh, err := describeInstanceRefresh(ctx, asgSvc, asgName, instanceRefreshID)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			return err
+		case err == nil:
+			status := describeInstanceRefreshProgress(refresh)
+			if status != lastStatus {
+				logger.Infof("Instance refresh %s of ASG %s: %s", instanceRefreshID, asgName, status)
+				lastStatus = status
+			}
+
+			switch aws.StringValue(refresh.Status) {
+			case autoscaling.InstanceRefreshStatusSuccessful:
+				logger.Infof("Successfully finished instance refresh %s of ASG %s", instanceRefreshID, asgName)
+				return nil
+			case autoscaling.InstanceRefreshStatusFailed, autoscaling.InstanceRefreshStatusCancelled:
+				return errors.WithStackTrace(InstanceRefreshFailedError{
+					ASGName:           asgName,
+					InstanceRefreshID: instanceRefreshID,
+					Status:            aws.StringValue(refresh.Status),
+					StatusReason:      aws.StringValue(refresh.StatusReason),
+				})
+			}
+
+			if opts.DrainLifecycleHookName != "" {
+				err := drainTerminatingInstances(ctx, asgSvc, client, asgName, opts, drainedInstanceIDs)
+				if err != nil && ctx.Err() == nil {
+					logger.Errorf("Error draining the instances that are being replaced. Cancelling instance refresh %s so that no other instance is replaced.", instanceRefreshID)
+					logger.Errorf("The instance that failed to drain is terminated when lifecycle hook %s times out.", opts.DrainLifecycleHookName)
+					if _, cancelErr := asgSvc.CancelInstanceRefreshWithContext(ctx, &autoscaling.CancelInstanceRefreshInput{
+						AutoScalingGroupName: aws.String(asgName),
+					}); cancelErr != nil {
+						logger.Errorf("Error cancelling instance refresh %s: %s", instanceRefreshID, cancelErr)
+					}
+					return err
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.WithStackTrace(InstanceRefreshTimeoutError{
+				ASGName:           asgName,
+				InstanceRefreshID: instanceRefreshID,
+				Timeout:           opts.Timeout,
+				LastStatus:        lastStatus,
+			})
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// describeInstanceRefresh returns the instance refresh of the ASG with the given ID.
+func describeInstanceRefresh(
+	ctx context.Context,
+	asgSvc autoscalingiface.AutoScalingAPI,
+	asgName string,
+	instanceRefreshID string,
+) (*autoscaling.InstanceRefresh, error) {
+	output, err := asgSvc.DescribeInstanceRefreshesWithContext(ctx, &autoscaling.DescribeInstanceRefreshesInput{
+		AutoScalingGroupName: aws.String(asgName),
+		InstanceRefreshIds:   aws.StringSlice([]string{instanceRefreshID}),
+	})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if len(output.InstanceRefreshes) == 0 {
+		return nil, errors.WithStackTrace(NewLookupError("instance refresh", instanceRefreshID, "status"))
+	}
+	return output.InstanceRefreshes[0], nil
+}
+
+// describeInstanceRefreshProgress describes the status and progress of the instance refresh for logging, e.g.
+// "InProgress, 40% complete, 3 instances left to update".
+func describeInstanceRefreshProgress(refresh *autoscaling.InstanceRefresh) string {
+	status := fmt.Sprintf(
+		"%s, %d%% complete, %d instances left to update",
+		aws.StringValue(refresh.Status),
+		aws.Int64Value(refresh.PercentageComplete),
+		aws.Int64Value(refresh.InstancesToUpdate),
+	)
+	if reason := aws.StringValue(refresh.StatusReason); reason != "" {
+		status = fmt.Sprintf("%s (%s)", status, reason)
+	}
+	return status
+}
+
+// verifyTerminationLifecycleHook returns a LifecycleHookNotFoundError if the ASG does not have a termination lifecycle
+// hook with the given name, in which case the instances would be terminated without waiting to be drained.
+func verifyTerminationLifecycleHook(ctx context.Context, asgSvc autoscalingiface.AutoScalingAPI, asgName string, hookName string) error {
+	output, err := asgSvc.DescribeLifecycleHooksWithContext(ctx, &autoscaling.DescribeLifecycleHooksInput{
+		AutoScalingGroupName: aws.String(asgName),
+		LifecycleHookNames:   aws.StringSlice([]string{hookName}),
+	})
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	for _, hook := range output.LifecycleHooks {
+		if aws.StringValue(hook.LifecycleHookName) == hookName && aws.StringValue(hook.LifecycleTransition) == lifecycleTransitionTerminating {
+			return nil
+		}
+	}
+	return errors.WithStackTrace(LifecycleHookNotFoundError{ASGName: asgName, LifecycleHookName: hookName})
+}
+
+// drainTerminatingInstances drains the nodes of the instances of the ASG that wait on the termination lifecycle hook,
+// and completes their lifecycle action so that they are terminated. The instances in drainedInstanceIDs are skipped, and
+// the drained instances are added to it. Instances that never joined the cluster are terminated right away.
+func drainTerminatingInstances(
+	ctx context.Context,
+	asgSvc autoscalingiface.AutoScalingAPI,
+	client kubernetes.Interface,
+	asgName string,
+	opts InstanceRefreshOptions,
+	drainedInstanceIDs map[string]bool,
+) error {
+	logger := logging.GetProjectLogger().WithField("asg", asgName)
+
+	output, err := asgSvc.DescribeAutoScalingGroupsWithContext(ctx, &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: aws.StringSlice([]string{asgName}),
+	})
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	if len(output.AutoScalingGroups) == 0 {
+		return errors.WithStackTrace(NewLookupError("ASG", asgName, "instances"))
+	}
+
+	for _, inst := range output.AutoScalingGroups[0].Instances {
+		instanceID := aws.StringValue(inst.InstanceId)
+		if aws.StringValue(inst.LifecycleState) != autoscaling.LifecycleStateTerminatingWait || drainedInstanceIDs[instanceID] {
+			continue
+		}
+
+		nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		if node := findNodeForInstance(nodes.Items, instanceID); node != nil {
+			logger.Infof("Draining node %s of instance %s before it is terminated", node.Name, instanceID)
+			if err := drainNode(ctx, client, node.Name, opts.DrainOptions); err != nil {
+				return err
+			}
+		} else {
+			logger.Warnf("Instance %s is not a node of the cluster. Terminating it without draining.", instanceID)
+		}
+
+		_, err = asgSvc.CompleteLifecycleActionWithContext(ctx, &autoscaling.CompleteLifecycleActionInput{
+			AutoScalingGroupName:  aws.String(asgName),
+			LifecycleHookName:     aws.String(opts.DrainLifecycleHookName),
+			InstanceId:            aws.String(instanceID),
+			LifecycleActionResult: aws.String(lifecycleActionResultContinue),
+		})
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		drainedInstanceIDs[instanceID] = true
+		logger.Infof("Completed lifecycle action %s of instance %s, which is now terminated", opts.DrainLifecycleHookName, instanceID)
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package eks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeInstanceRefreshAutoScaling is a stub of the Auto Scaling API for an ASG with a single instance refresh.
+type fakeInstanceRefreshAutoScaling struct {
+	autoscalingiface.AutoScalingAPI
+
+	// refreshes is the sequence of states of the instance refresh returned by DescribeInstanceRefreshes, one per call.
+	// The last state is returned once the sequence is exhausted.
+	refreshes []*autoscaling.InstanceRefresh
+
+	// lifecycleHooks is the list of lifecycle hooks returned by DescribeLifecycleHooks, regardless of the filters.
+	lifecycleHooks []*autoscaling.LifecycleHook
+
+	// instances is the list of instances of the ASG returned by DescribeAutoScalingGroups.
+	instances []*autoscaling.Instance
+
+	// startInputs, completedActions and cancelInputs record the inputs StartInstanceRefresh, CompleteLifecycleAction and
+	// CancelInstanceRefresh were called with.
+	startInputs      []*autoscaling.StartInstanceRefreshInput
+	completedActions []*autoscaling.CompleteLifecycleActionInput
+	cancelInputs     []*autoscaling.CancelInstanceRefreshInput
+}
+
+func (fake *fakeInstanceRefreshAutoScaling) StartInstanceRefreshWithContext(ctx awsgo.Context, input *autoscaling.StartInstanceRefreshInput, opts ...request.Option) (*autoscaling.StartInstanceRefreshOutput, error) {
+	fake.startInputs = append(fake.startInputs, input)
+	return &autoscaling.StartInstanceRefreshOutput{InstanceRefreshId: awsgo.String("refresh-123")}, nil
+}
+
+func (fake *fakeInstanceRefreshAutoScaling) DescribeInstanceRefreshesWithContext(ctx awsgo.Context, input *autoscaling.DescribeInstanceRefreshesInput, opts ...request.Option) (*autoscaling.DescribeInstanceRefreshesOutput, error) {
+	refresh := fake.refreshes[0]
+	if len(fake.refreshes) > 1 {
+		fake.refreshes = fake.refreshes[1:]
+	}
+	return &autoscaling.DescribeInstanceRefreshesOutput{InstanceRefreshes: []*autoscaling.InstanceRefresh{refresh}}, nil
+}
+
+func (fake *fakeInstanceRefreshAutoScaling) DescribeLifecycleHooksWithContext(ctx awsgo.Context, input *autoscaling.DescribeLifecycleHooksInput, opts ...request.Option) (*autoscaling.DescribeLifecycleHooksOutput, error) {
+	return &autoscaling.DescribeLifecycleHooksOutput{LifecycleHooks: fake.lifecycleHooks}, nil
+}
+
+func (fake *fakeInstanceRefreshAutoScaling) DescribeAutoScalingGroupsWithContext(ctx awsgo.Context, input *autoscaling.DescribeAutoScalingGroupsInput, opts ...request.Option) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	group := &autoscaling.Group{AutoScalingGroupName: input.AutoScalingGroupNames[0], Instances: fake.instances}
+	return &autoscaling.DescribeAutoScalingGroupsOutput{AutoScalingGroups: []*autoscaling.Group{group}}, nil
+}
+
+func (fake *fakeInstanceRefreshAutoScaling) CompleteLifecycleActionWithContext(ctx awsgo.Context, input *autoscaling.CompleteLifecycleActionInput, opts ...request.Option) (*autoscaling.CompleteLifecycleActionOutput, error) {
+	fake.completedActions = append(fake.completedActions, input)
+	return &autoscaling.CompleteLifecycleActionOutput{}, nil
+}
+
+func (fake *fakeInstanceRefreshAutoScaling) CancelInstanceRefreshWithContext(ctx awsgo.Context, input *autoscaling.CancelInstanceRefreshInput, opts ...request.Option) (*autoscaling.CancelInstanceRefreshOutput, error) {
+	fake.cancelInputs = append(fake.cancelInputs, input)
+	return &autoscaling.CancelInstanceRefreshOutput{}, nil
+}
+
+func TestStartInstanceRefreshWaitsForCompletion(t *testing.T) {
+	t.Parallel()
+
+	asgSvc := &fakeInstanceRefreshAutoScaling{
+		refreshes: []*autoscaling.InstanceRefresh{
+			testInstanceRefresh(autoscaling.InstanceRefreshStatusPending, 0, ""),
+			testInstanceRefresh(autoscaling.InstanceRefreshStatusInProgress, 50, "Waiting for instances to warm up"),
+			testInstanceRefresh(autoscaling.InstanceRefreshStatusSuccessful, 100, ""),
+		},
+	}
+	minHealthyPercentage := int64(75)
+	instanceWarmup := 5 * time.Minute
+	opts := InstanceRefreshOptions{
+		MinHealthyPercentage: &minHealthyPercentage,
+		InstanceWarmup:       &instanceWarmup,
+		PollInterval:         1 * time.Millisecond,
+	}
+
+	err := startInstanceRefresh(context.Background(), asgSvc, nil, "workers", opts)
+	require.NoError(t, err)
+	require.Len(t, asgSvc.startInputs, 1)
+	assert.Equal(t, "workers", awsgo.StringValue(asgSvc.startInputs[0].AutoScalingGroupName))
+	assert.Equal(t, autoscaling.RefreshStrategyRolling, awsgo.StringValue(asgSvc.startInputs[0].Strategy))
+	assert.Equal(t, &autoscaling.RefreshPreferences{
+		MinHealthyPercentage: awsgo.Int64(75),
+		InstanceWarmup:       awsgo.Int64(300),
+	}, asgSvc.startInputs[0].Preferences)
+}
+
+func TestStartInstanceRefreshReportsFailureReason(t *testing.T) {
+	t.Parallel()
+
+	asgSvc := &fakeInstanceRefreshAutoScaling{
+		refreshes: []*autoscaling.InstanceRefresh{
+			testInstanceRefresh(autoscaling.InstanceRefreshStatusInProgress, 20, ""),
+			testInstanceRefresh(autoscaling.InstanceRefreshStatusFailed, 20, "Instance i-new failed its health checks"),
+		},
+	}
+
+	err := startInstanceRefresh(context.Background(), asgSvc, nil, "workers", InstanceRefreshOptions{PollInterval: 1 * time.Millisecond})
+	require.Error(t, err)
+	assert.Equal(t, InstanceRefreshFailedError{
+		ASGName:           "workers",
+		InstanceRefreshID: "refresh-123",
+		Status:            autoscaling.InstanceRefreshStatusFailed,
+		StatusReason:      "Instance i-new failed its health checks",
+	}, errors.Unwrap(err))
+}
+
+func TestStartInstanceRefreshTimesOutWithLastStatus(t *testing.T) {
+	t.Parallel()
+
+	asgSvc := &fakeInstanceRefreshAutoScaling{
+		refreshes: []*autoscaling.InstanceRefresh{testInstanceRefresh(autoscaling.InstanceRefreshStatusInProgress, 40, "")},
+	}
+	opts := InstanceRefreshOptions{Timeout: 50 * time.Millisecond, PollInterval: 10 * time.Millisecond}
+
+	err := startInstanceRefresh(context.Background(), asgSvc, nil, "workers", opts)
+	require.Error(t, err)
+	assert.Equal(t, InstanceRefreshTimeoutError{
+		ASGName:           "workers",
+		InstanceRefreshID: "refresh-123",
+		Timeout:           50 * time.Millisecond,
+		LastStatus:        "InProgress, 40% complete, 3 instances left to update",
+	}, errors.Unwrap(err))
+}
+
+func TestStartInstanceRefreshDrainsInstancesWaitingOnLifecycleHook(t *testing.T) {
+	t.Parallel()
+
+	asgSvc := &fakeInstanceRefreshAutoScaling{
+		refreshes: []*autoscaling.InstanceRefresh{
+			testInstanceRefresh(autoscaling.InstanceRefreshStatusInProgress, 0, ""),
+			testInstanceRefresh(autoscaling.InstanceRefreshStatusSuccessful, 100, ""),
+		},
+		lifecycleHooks: []*autoscaling.LifecycleHook{testTerminationLifecycleHook("drain")},
+		instances: []*autoscaling.Instance{
+			{InstanceId: awsgo.String("i-old"), LifecycleState: awsgo.String(autoscaling.LifecycleStateTerminatingWait)},
+			{InstanceId: awsgo.String("i-new"), LifecycleState: awsgo.String(autoscaling.LifecycleStateInService)},
+		},
+	}
+	client := newFakeDrainClient([]string{}, testPodOn("node-old", "web"))
+	require.NoError(t, client.Tracker().Add(newTestNodeForInstance("node-old", "i-old", corev1.ConditionTrue, false)))
+	require.NoError(t, client.Tracker().Add(newTestNodeForInstance("node-new", "i-new", corev1.ConditionTrue, false)))
+	opts := InstanceRefreshOptions{
+		PollInterval:           1 * time.Millisecond,
+		DrainLifecycleHookName: "drain",
+		DrainOptions:           testDrainOptions(),
+	}
+
+	err := startInstanceRefresh(context.Background(), asgSvc, client, "workers", opts)
+	require.NoError(t, err)
+
+	require.Len(t, asgSvc.completedActions, 1)
+	assert.Equal(t, "i-old", awsgo.StringValue(asgSvc.completedActions[0].InstanceId))
+	assert.Equal(t, "drain", awsgo.StringValue(asgSvc.completedActions[0].LifecycleHookName))
+	assert.Equal(t, lifecycleActionResultContinue, awsgo.StringValue(asgSvc.completedActions[0].LifecycleActionResult))
+
+	oldNode, err := client.CoreV1().Nodes().Get(context.Background(), "node-old", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, oldNode.Spec.Unschedulable)
+	pods, err := client.CoreV1().Pods("").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, pods.Items)
+}
+
+func TestStartInstanceRefreshCancelsWhenDrainFails(t *testing.T) {
+	t.Parallel()
+
+	asgSvc := &fakeInstanceRefreshAutoScaling{
+		refreshes:      []*autoscaling.InstanceRefresh{testInstanceRefresh(autoscaling.InstanceRefreshStatusInProgress, 0, "")},
+		lifecycleHooks: []*autoscaling.LifecycleHook{testTerminationLifecycleHook("drain")},
+		instances: []*autoscaling.Instance{
+			{InstanceId: awsgo.String("i-old"), LifecycleState: awsgo.String(autoscaling.LifecycleStateTerminatingWait)},
+		},
+	}
+	// The eviction of the Pod named blocked is always rejected by its PodDisruptionBudget.
+	client := newFakeDrainClient([]string{}, testPodOn("node-old", "blocked"))
+	require.NoError(t, client.Tracker().Add(newTestNodeForInstance("node-old", "i-old", corev1.ConditionTrue, false)))
+	opts := InstanceRefreshOptions{
+		PollInterval:           1 * time.Millisecond,
+		DrainLifecycleHookName: "drain",
+		DrainOptions:           testDrainOptions(),
+	}
+
+	err := startInstanceRefresh(context.Background(), asgSvc, client, "workers", opts)
+	require.Error(t, err)
+	_, isEvictionTimeoutErr := errors.Unwrap(err).(PodEvictionTimeoutError)
+	assert.True(t, isEvictionTimeoutErr)
+	assert.Empty(t, asgSvc.completedActions)
+	assert.Len(t, asgSvc.cancelInputs, 1)
+}
+
+func TestStartInstanceRefreshRequiresTerminationLifecycleHook(t *testing.T) {
+	t.Parallel()
+
+	launchHook := testTerminationLifecycleHook("drain")
+	launchHook.LifecycleTransition = awsgo.String("autoscaling:EC2_INSTANCE_LAUNCHING")
+	asgSvc := &fakeInstanceRefreshAutoScaling{lifecycleHooks: []*autoscaling.LifecycleHook{launchHook}}
+	opts := InstanceRefreshOptions{DrainLifecycleHookName: "drain", DrainOptions: testDrainOptions()}
+
+	err := startInstanceRefresh(context.Background(), asgSvc, newFakeDrainClient([]string{}), "workers", opts)
+	require.Error(t, err)
+	assert.Equal(t, LifecycleHookNotFoundError{ASGName: "workers", LifecycleHookName: "drain"}, errors.Unwrap(err))
+	assert.Empty(t, asgSvc.startInputs)
+}
+
+func testInstanceRefresh(status string, percentageComplete int64, statusReason string) *autoscaling.InstanceRefresh {
+	refresh := &autoscaling.InstanceRefresh{
+		InstanceRefreshId:  awsgo.String("refresh-123"),
+		Status:             awsgo.String(status),
+		PercentageComplete: awsgo.Int64(percentageComplete),
+		InstancesToUpdate:  awsgo.Int64(3),
+	}
+	if statusReason != "" {
+		refresh.StatusReason = awsgo.String(statusReason)
+	}
+	return refresh
+}
+
+func testTerminationLifecycleHook(name string) *autoscaling.LifecycleHook {
+	return &autoscaling.LifecycleHook{
+		LifecycleHookName:   awsgo.String(name),
+		LifecycleTransition: awsgo.String(lifecycleTransitionTerminating),
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/gruntwork-io/go-commons/collections"
 	"github.com/gruntwork-io/go-commons/errors"
 
@@ -12,7 +13,7 @@ import (
 )
 
 // Given a list of instance IDs, fetch the instance details from AWS.
-func instanceDetailsFromIds(svc *ec2.EC2, idList []string) ([]*ec2.Instance, error) {
+func instanceDetailsFromIds(svc ec2iface.EC2API, idList []string) ([]*ec2.Instance, error) {
 	input := ec2.DescribeInstancesInput{InstanceIds: aws.StringSlice(idList)}
 	instances := []*ec2.Instance{}
 	// Handle pagination by repeatedly making the API call while there is a next token set.
@@ -2,21 +2,87 @@ package eks
 
 import (
 	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/gruntwork-io/go-commons/errors"
 	"github.com/gruntwork-io/go-commons/files"
 	"k8s.io/client-go/tools/clientcmd"
 
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
 	"github.com/gruntwork-io/kubergrunt/kubectl"
 	"github.com/gruntwork-io/kubergrunt/logging"
 )
 
-// ConfigureKubectlForEks adds a new context to the kubeconfig located at the given path that can authenticate with the
-// EKS cluster referenced by the given ARN.
+// ConfigureKubeconfig looks up the EKS cluster referenced by the given ARN and adds a context named after the cluster
+// ARN to the kubeconfig located at the given path, which authenticates with the cluster using `kubergrunt eks token`.
+// Refer to ConfigureKubectlForEks for more details.
+func ConfigureKubeconfig(clusterArn string, kubeconfigPath string, setCurrentContext bool) error {
+	clusterInfo, err := eksawshelper.GetClusterInfo(clusterArn)
+	if err != nil {
+		return err
+	}
+	kubectlOptions := &kubectl.KubectlOptions{ContextName: clusterArn, ConfigPath: kubeconfigPath}
+	return ConfigureKubectlForEks(clusterInfo.Cluster, kubectlOptions, setCurrentContext)
+}
+
+// RemoveKubeconfigEntry removes the cluster, user, and context entries for the EKS cluster referenced by the given ARN
+// from the kubeconfig located at the given path, undoing ConfigureKubeconfig, e.g., after the cluster is deleted. The
+// entries are identified by the cluster ARN rather than by name, so contexts with similar names that refer to other
+// clusters are kept. The current context is cleared if it pointed at a removed context. This is a noop if the
+// kubeconfig or the entries do not exist.
+func RemoveKubeconfigEntry(clusterArn string, kubeconfigPath string) error {
+	logger := logging.GetProjectLogger()
+
+	// Check before taking the lock, so that the lock file and its directory are not created for nothing.
+	if !files.FileExists(kubeconfigPath) {
+		logger.Infof("Kubectl config %s does not exist. Nothing to remove.", kubeconfigPath)
+		return nil
+	}
+	unlock, err := kubectl.LockConfigFile(kubeconfigPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	logger.Infof("Loading kubectl config %s.", kubeconfigPath)
+	rawConfig, err := clientcmd.LoadFromFile(kubeconfigPath)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	_, hasCluster := rawConfig.Clusters[clusterArn]
+	_, hasAuthInfo := rawConfig.AuthInfos[clusterArn]
+	removedContexts := kubectl.RemoveEksConfigContext(rawConfig, clusterArn)
+	if !hasCluster && !hasAuthInfo && len(removedContexts) == 0 {
+		logger.Infof("Kubectl config %s has no entries for EKS cluster %s. Nothing to remove.", kubeconfigPath, clusterArn)
+		return nil
+	}
+
+	logger.Infof("Saving kubectl config updates to %s.", kubeconfigPath)
+	if err := kubectl.WriteConfigAtomically(rawConfig, kubeconfigPath); err != nil {
+		return err
+	}
+	logger.Infof("Successfully saved kubectl config updates.")
+	return nil
+}
+
+// ConfigureKubectlForEks adds a context to the kubeconfig located at the given path that can authenticate with the
+// given EKS cluster. The cluster, auth info, and context entries are merged into the existing kubeconfig, updating them
+// in place if they already exist. The current context is only switched to the new context when setCurrentContext is
+// true. The kubeconfig is locked while it is updated, so that concurrent runs against the same file are serialized.
 func ConfigureKubectlForEks(
 	eksCluster *eks.Cluster,
 	kubectlOptions *kubectl.KubectlOptions,
+	setCurrentContext bool,
 ) error {
 	logger := logging.GetProjectLogger()
 
+	// Hold the lock on the config for the whole read-modify-write, so that concurrent runs do not drop each other's
+	// entries.
+	unlock, err := kubectl.LockConfigFile(kubectlOptions.ConfigPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Load config from disk and then get actual data structure containing the parsed config information
 	// Create a blank file if it does not exist already
 	if !files.FileExists(kubectlOptions.ConfigPath) {
@@ -24,20 +90,22 @@ func ConfigureKubectlForEks(
 			return err
 		}
 	}
+	// NOTE: we load the file directly instead of going through the client config loader, so that the config is not
+	// merged with other sources (e.g., the KUBECONFIG environment variable) and all the top level fields, like the
+	// preferences and extensions, are preserved when writing it back.
 	logger.Infof("Loading kubectl config %s.", kubectlOptions.ConfigPath)
-	kubeconfig := kubectl.LoadConfigFromPath(kubectlOptions.ConfigPath)
-	rawConfig, err := kubeconfig.RawConfig()
+	rawConfig, err := clientcmd.LoadFromFile(kubectlOptions.ConfigPath)
 	if err != nil {
-		return err
+		return errors.WithStackTrace(err)
 	}
 	logger.Infof("Successfully loaded and parsed kubectl config.")
 
 	// Update the config data structure with the EKS cluster info
-	err = kubectl.AddEksConfigContext(
-		&rawConfig,
+	logger.Infof("Configuring kubectl config context %s for authenticating with EKS cluster %s", kubectlOptions.ContextName, *eksCluster.Name)
+	err = kubectl.UpsertEksConfigContext(
+		rawConfig,
 		kubectlOptions.ContextName,
 		*eksCluster.Arn,
-		*eksCluster.Name,
 		*eksCluster.Endpoint,
 		*eksCluster.CertificateAuthority.Data,
 	)
@@ -46,14 +114,15 @@ func ConfigureKubectlForEks(
 	}
 
 	// Update the current context to the newly created context
-	logger.Infof("Setting current kubectl config context to %s.", kubectlOptions.ContextName)
-	rawConfig.CurrentContext = kubectlOptions.ContextName
-	logger.Info("Updated current kubectl config context.")
+	if setCurrentContext {
+		logger.Infof("Setting current kubectl config context to %s.", kubectlOptions.ContextName)
+		rawConfig.CurrentContext = kubectlOptions.ContextName
+		logger.Info("Updated current kubectl config context.")
+	}
 
 	// Finally, save the config to disk
 	logger.Infof("Saving kubectl config updates to %s.", kubectlOptions.ConfigPath)
-	err = clientcmd.ModifyConfig(kubeconfig.ConfigAccess(), rawConfig, false)
-	if err != nil {
+	if err := kubectl.WriteConfigAtomically(rawConfig, kubectlOptions.ConfigPath); err != nil {
 		return err
 	}
 	logger.Infof("Successfully saved kubectl config updates.")
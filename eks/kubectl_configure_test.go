@@ -5,13 +5,16 @@ import (
 	"io/ioutil"
 	"net/url"
 	"os"
+	"sync"
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/eks"
 	"github.com/gruntwork-io/terratest/modules/k8s"
 	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/gruntwork-io/kubergrunt/kubectl"
 )
@@ -36,7 +39,7 @@ func TestEksKubectlConfigureHonorsKubeConfigPath(t *testing.T) {
 		CertificateAuthority: &eks.Certificate{Data: aws.String(b64CertificateAuthorityData)},
 	}
 	options := &kubectl.KubectlOptions{ContextName: t.Name(), ConfigPath: kubeconfigPath}
-	err = ConfigureKubectlForEks(mockCluster, options)
+	err = ConfigureKubectlForEks(mockCluster, options, true)
 	require.NoError(t, err)
 
 	// Verify config was updated
@@ -46,13 +49,150 @@ func TestEksKubectlConfigureHonorsKubeConfigPath(t *testing.T) {
 	require.NotEqual(t, rawConfig, originalRawConfig)
 }
 
+func TestEksKubectlConfigurePreservesOtherEntries(t *testing.T) {
+	t.Parallel()
+
+	kubeconfigPath := generateTempConfigFrom(t, MULTI_CONTEXT_CONFIG)
+	defer os.Remove(kubeconfigPath)
+
+	originalConfig, err := clientcmd.LoadFromFile(kubeconfigPath)
+	require.NoError(t, err)
+
+	uniqueID := random.UniqueId()
+	mockCluster := &eks.Cluster{
+		Arn:                  aws.String("arn:aws:eks:us-east-2:111111111111:cluster/" + uniqueID),
+		Name:                 aws.String(uniqueID),
+		Endpoint:             aws.String("gruntwork.io"),
+		CertificateAuthority: &eks.Certificate{Data: aws.String(base64.StdEncoding.EncodeToString([]byte(uniqueID)))},
+	}
+	options := &kubectl.KubectlOptions{ContextName: *mockCluster.Arn, ConfigPath: kubeconfigPath}
+
+	// Configure twice to verify the entries are updated in place on subsequent runs.
+	require.NoError(t, ConfigureKubectlForEks(mockCluster, options, false))
+	mockCluster.Endpoint = aws.String("new.gruntwork.io")
+	require.NoError(t, ConfigureKubectlForEks(mockCluster, options, false))
+
+	config, err := clientcmd.LoadFromFile(kubeconfigPath)
+	require.NoError(t, err)
+
+	assert.Len(t, config.Contexts, len(originalConfig.Contexts)+1)
+	assert.Len(t, config.Clusters, len(originalConfig.Clusters)+1)
+	assert.Len(t, config.AuthInfos, len(originalConfig.AuthInfos)+1)
+	assert.Equal(t, "new.gruntwork.io", config.Clusters[*mockCluster.Arn].Server)
+
+	for name, context := range originalConfig.Contexts {
+		assert.Equal(t, context.Cluster, config.Contexts[name].Cluster)
+		assert.Equal(t, context.AuthInfo, config.Contexts[name].AuthInfo)
+		assert.Equal(t, context.Namespace, config.Contexts[name].Namespace)
+	}
+	for name, cluster := range originalConfig.Clusters {
+		assert.Equal(t, cluster.Server, config.Clusters[name].Server)
+		assert.Equal(t, cluster.CertificateAuthority, config.Clusters[name].CertificateAuthority)
+	}
+	for name, authInfo := range originalConfig.AuthInfos {
+		assert.Equal(t, authInfo.ClientCertificate, config.AuthInfos[name].ClientCertificate)
+		assert.Equal(t, authInfo.Token, config.AuthInfos[name].Token)
+	}
+	assert.Equal(t, originalConfig.CurrentContext, config.CurrentContext)
+	assert.Equal(t, originalConfig.Preferences.Colors, config.Preferences.Colors)
+
+	// Setting the current context should only change the current context.
+	require.NoError(t, ConfigureKubectlForEks(mockCluster, options, true))
+	config, err = clientcmd.LoadFromFile(kubeconfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, *mockCluster.Arn, config.CurrentContext)
+	assert.Len(t, config.Contexts, len(originalConfig.Contexts)+1)
+}
+
+func TestEksKubectlConfigureConcurrentlySerializesWrites(t *testing.T) {
+	t.Parallel()
+
+	kubeconfigPath := generateTempConfig(t)
+	defer os.Remove(kubeconfigPath)
+	defer os.Remove(kubeconfigPath + ".lock")
+
+	mockClusters := []*eks.Cluster{}
+	for i := 0; i < 2; i++ {
+		uniqueID := random.UniqueId()
+		mockClusters = append(mockClusters, &eks.Cluster{
+			Arn:                  aws.String("arn:aws:eks:us-east-2:111111111111:cluster/" + uniqueID),
+			Name:                 aws.String(uniqueID),
+			Endpoint:             aws.String("gruntwork.io"),
+			CertificateAuthority: &eks.Certificate{Data: aws.String(base64.StdEncoding.EncodeToString([]byte(uniqueID)))},
+		})
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(mockClusters))
+	for i, mockCluster := range mockClusters {
+		wg.Add(1)
+		go func(i int, mockCluster *eks.Cluster) {
+			defer wg.Done()
+			options := &kubectl.KubectlOptions{ContextName: *mockCluster.Arn, ConfigPath: kubeconfigPath}
+			errs[i] = ConfigureKubectlForEks(mockCluster, options, false)
+		}(i, mockCluster)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	config, err := clientcmd.LoadFromFile(kubeconfigPath)
+	require.NoError(t, err)
+	for _, mockCluster := range mockClusters {
+		assert.Contains(t, config.Contexts, *mockCluster.Arn)
+		assert.Contains(t, config.Clusters, *mockCluster.Arn)
+		assert.Contains(t, config.AuthInfos, *mockCluster.Arn)
+	}
+}
+
+func TestEksRemoveKubeconfigEntryRestoresConfig(t *testing.T) {
+	t.Parallel()
+
+	kubeconfigPath := generateTempConfigFrom(t, MULTI_CONTEXT_CONFIG)
+	defer os.Remove(kubeconfigPath)
+	defer os.Remove(kubeconfigPath + ".lock")
+
+	originalConfig, err := clientcmd.LoadFromFile(kubeconfigPath)
+	require.NoError(t, err)
+
+	uniqueID := random.UniqueId()
+	mockCluster := &eks.Cluster{
+		Arn:                  aws.String("arn:aws:eks:us-east-2:111111111111:cluster/" + uniqueID),
+		Name:                 aws.String(uniqueID),
+		Endpoint:             aws.String("gruntwork.io"),
+		CertificateAuthority: &eks.Certificate{Data: aws.String(base64.StdEncoding.EncodeToString([]byte(uniqueID)))},
+	}
+	options := &kubectl.KubectlOptions{ContextName: *mockCluster.Arn, ConfigPath: kubeconfigPath}
+	require.NoError(t, ConfigureKubectlForEks(mockCluster, options, true))
+
+	require.NoError(t, RemoveKubeconfigEntry(*mockCluster.Arn, kubeconfigPath))
+	config, err := clientcmd.LoadFromFile(kubeconfigPath)
+	require.NoError(t, err)
+	assert.Equal(t, "", config.CurrentContext)
+	assert.Len(t, config.Contexts, len(originalConfig.Contexts))
+	assert.Len(t, config.Clusters, len(originalConfig.Clusters))
+	assert.Len(t, config.AuthInfos, len(originalConfig.AuthInfos))
+	for name := range originalConfig.Contexts {
+		assert.Contains(t, config.Contexts, name)
+	}
+
+	// Removing entries that are already gone is a noop.
+	require.NoError(t, RemoveKubeconfigEntry(*mockCluster.Arn, kubeconfigPath))
+	require.NoError(t, RemoveKubeconfigEntry(*mockCluster.Arn, kubeconfigPath+"-does-not-exist"))
+}
+
 func generateTempConfig(t *testing.T) string {
+	return generateTempConfigFrom(t, BASIC_CONFIG)
+}
+
+func generateTempConfigFrom(t *testing.T, contents string) string {
 	escapedTestName := url.PathEscape(t.Name())
 	tmpfile, err := ioutil.TempFile("", escapedTestName)
 	require.NoError(t, err)
 	defer tmpfile.Close()
 
-	_, err = tmpfile.WriteString(BASIC_CONFIG)
+	_, err = tmpfile.WriteString(contents)
 	require.NoError(t, err)
 	return tmpfile.Name()
 }
@@ -79,3 +219,37 @@ users:
     client-certificate: /home/terratest/.minikube/client.crt
     client-key: /home/terratest/.minikube/client.key
 `
+
+const MULTI_CONTEXT_CONFIG = `apiVersion: v1
+clusters:
+- cluster:
+    certificate-authority: /home/terratest/.minikube/ca.crt
+    server: https://172.17.0.48:8443
+  name: minikube
+- cluster:
+    certificate-authority: /home/terratest/.kube/staging-ca.crt
+    server: https://staging.example.com
+  name: staging
+contexts:
+- context:
+    cluster: minikube
+    user: minikube
+  name: minikube
+- context:
+    cluster: staging
+    namespace: apps
+    user: staging-admin
+  name: staging
+current-context: staging
+kind: Config
+preferences:
+  colors: true
+users:
+- name: minikube
+  user:
+    client-certificate: /home/terratest/.minikube/client.crt
+    client-key: /home/terratest/.minikube/client.key
+- name: staging-admin
+  user:
+    token: staging-token
+`
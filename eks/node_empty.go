@@ -0,0 +1,95 @@
+package eks
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gruntwork-io/go-commons/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// nodeEmptyPollInterval is the interval between checks of the Pods on a node while waiting for the node to be empty.
+const nodeEmptyPollInterval = 5 * time.Second
+
+// WaitForNodeEmpty waits until there are no more Pods on the node, other than mirror Pods and Pods managed by a
+// DaemonSet, which are not evicted when draining, and Pods that have already completed (succeeded or failed). Use this
+// after draining a node and before terminating its instance, so that the Pods that are still shutting down are not
+// killed. Returns a NodeNotEmptyError naming the Pods that are still on the node if this does not happen within the
+// timeout. A timeout of zero waits indefinitely.
+func WaitForNodeEmpty(clusterArn string, nodeName string, timeout time.Duration) error {
+	client, err := kubectl.GetKubernetesClientFromOptions(&kubectl.KubectlOptions{EKSClusterArn: clusterArn})
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return waitForNodeEmptyWithClient(context.Background(), client, nodeName, timeout, nodeEmptyPollInterval)
+}
+
+func waitForNodeEmptyWithClient(
+	ctx context.Context,
+	client kubernetes.Interface,
+	nodeName string,
+	timeout time.Duration,
+	pollInterval time.Duration,
+) error {
+	logger := logging.GetProjectLogger().WithField("node", nodeName)
+	logger.Infof("Waiting for the Pods on node %s to terminate", nodeName)
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	remaining := []corev1.Pod{}
+	for {
+		pods, err := podsToEvict(ctx, client, nodeName)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			return err
+		case err == nil:
+			remaining = runningPods(pods)
+			if len(remaining) == 0 {
+				logger.Infof("All the Pods on node %s have terminated", nodeName)
+				return nil
+			}
+			logger.Debugf("%d Pods are still on node %s", len(remaining), nodeName)
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.WithStackTrace(NodeNotEmptyError{NodeName: nodeName, PodNames: podNames(remaining)})
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// runningPods returns the Pods that have not completed, i.e. that are not in the Succeeded or Failed phase.
+func runningPods(pods []corev1.Pod) []corev1.Pod {
+	running := []corev1.Pod{}
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			running = append(running, pod)
+		}
+	}
+	return running
+}
+
+// waitForInstanceNodesEmpty waits for the nodes of the given EC2 instances to be empty (see WaitForNodeEmpty), one node
+// at a time, with the timeout applying to each node.
+func waitForInstanceNodesEmpty(ec2Svc *ec2.EC2, clusterArn string, instanceIds []string, timeout time.Duration) error {
+	instances, err := instanceDetailsFromIds(ec2Svc, instanceIds)
+	if err != nil {
+		return err
+	}
+	for _, nodeName := range kubeNodeNamesFromInstances(instances) {
+		if err := WaitForNodeEmpty(clusterArn, nodeName, timeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package eks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForNodeEmptyIgnoresDaemonSetMirrorAndCompletedPods(t *testing.T) {
+	t.Parallel()
+
+	succeeded := testPodOnNode("job-succeeded")
+	succeeded.Status.Phase = corev1.PodSucceeded
+	failed := testPodOnNode("job-failed")
+	failed.Status.Phase = corev1.PodFailed
+	client := fake.NewSimpleClientset(
+		testDaemonSetPodOnNode("fluentd"),
+		testMirrorPodOnNode("kube-proxy"),
+		succeeded,
+		failed,
+	)
+
+	err := waitForNodeEmptyWithClient(context.Background(), client, testDrainNodeName, time.Second, 10*time.Millisecond)
+	assert.NoError(t, err)
+}
+
+func TestWaitForNodeEmptyWaitsForPodsToTerminate(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(testPodOnNode("web"))
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		client.CoreV1().Pods("default").Delete(context.Background(), "web", metav1.DeleteOptions{})
+	}()
+
+	err := waitForNodeEmptyWithClient(context.Background(), client, testDrainNodeName, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, err)
+}
+
+func TestWaitForNodeEmptyReportsRemainingPodsOnTimeout(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(testPodOnNode("web"), testDaemonSetPodOnNode("fluentd"))
+
+	err := waitForNodeEmptyWithClient(context.Background(), client, testDrainNodeName, 50*time.Millisecond, 10*time.Millisecond)
+	require.Error(t, err)
+	assert.Equal(t, NodeNotEmptyError{NodeName: testDrainNodeName, PodNames: []string{"default/web"}}, errors.Unwrap(err))
+}
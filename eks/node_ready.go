@@ -0,0 +1,97 @@
+package eks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// nodeReadyPollInterval is the interval between checks of the node list while waiting for a node to be ready.
+const nodeReadyPollInterval = 5 * time.Second
+
+// waitForNodeReady waits for the EC2 instance to join the EKS cluster as a node that is Ready and not cordoned. The node
+// is matched by its spec.providerID, so this does not depend on how the nodes are named. Returns a NodeNotReadyError
+// describing the last observed state of the node if this does not happen within the timeout.
+func waitForNodeReady(clusterArn string, instanceID string, timeout time.Duration) error {
+	client, err := kubectl.GetKubernetesClientFromOptions(&kubectl.KubectlOptions{EKSClusterArn: clusterArn})
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return waitForNodeReadyWithClient(context.Background(), client, instanceID, timeout, nodeReadyPollInterval)
+}
+
+func waitForNodeReadyWithClient(
+	ctx context.Context,
+	client kubernetes.Interface,
+	instanceID string,
+	timeout time.Duration,
+	pollInterval time.Duration,
+) error {
+	logger := logging.GetProjectLogger().WithField("instanceID", instanceID)
+	logger.Infof("Waiting for the node of instance %s to be ready", instanceID)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	lastState := "no node with the instance ID in its provider ID has registered"
+	for {
+		nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		switch {
+		case err != nil && ctx.Err() == nil:
+			return errors.WithStackTrace(err)
+		case err == nil:
+			if node := findNodeForInstance(nodes.Items, instanceID); node != nil {
+				isReady, state := describeNodeReadiness(*node)
+				if isReady {
+					logger.Infof("Node %s of instance %s is ready", node.Name, instanceID)
+					return nil
+				}
+				lastState = state
+			}
+			logger.Debugf("Node of instance %s is not ready yet: %s", instanceID, lastState)
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.WithStackTrace(NodeNotReadyError{InstanceID: instanceID, LastState: lastState})
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// findNodeForInstance returns the node whose spec.providerID (aws:///AVAILABILITY_ZONE/INSTANCE_ID) references the
+// instance, or nil if there is none.
+func findNodeForInstance(nodes []corev1.Node, instanceID string) *corev1.Node {
+	for i, node := range nodes {
+		if strings.HasSuffix(node.Spec.ProviderID, "/"+instanceID) {
+			return &nodes[i]
+		}
+	}
+	return nil
+}
+
+// describeNodeReadiness returns whether the node is Ready and schedulable, along with a description of its state.
+func describeNodeReadiness(node corev1.Node) (bool, string) {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type != corev1.NodeReady {
+			continue
+		}
+		if condition.Status != corev1.ConditionTrue {
+			return false, fmt.Sprintf("node %s has condition Ready=%s (%s: %s)", node.Name, condition.Status, condition.Reason, condition.Message)
+		}
+		if node.Spec.Unschedulable {
+			return false, fmt.Sprintf("node %s is Ready but cordoned", node.Name)
+		}
+		return true, fmt.Sprintf("node %s is Ready", node.Name)
+	}
+	return false, fmt.Sprintf("node %s does not report a Ready condition yet", node.Name)
+}
@@ -0,0 +1,88 @@
+package eks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWaitForNodeReadyMatchesProviderID(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(
+		newTestNodeForInstance("ip-10-0-0-1", "i-0aaaaaaaaaaaaaaaa", corev1.ConditionFalse, false),
+		newTestNodeForInstance("ip-10-0-0-2", "i-0bbbbbbbbbbbbbbbb", corev1.ConditionTrue, false),
+	)
+
+	err := waitForNodeReadyWithClient(context.Background(), client, "i-0bbbbbbbbbbbbbbbb", time.Second, time.Millisecond)
+	require.NoError(t, err)
+}
+
+func TestWaitForNodeReadyTimesOutWithLastNodeState(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name              string
+		nodes             []*corev1.Node
+		expectedLastState string
+	}{
+		{
+			"not-registered",
+			nil,
+			"no node with the instance ID in its provider ID has registered",
+		},
+		{
+			"not-ready",
+			[]*corev1.Node{newTestNodeForInstance("ip-10-0-0-1", "i-0aaaaaaaaaaaaaaaa", corev1.ConditionFalse, false)},
+			"node ip-10-0-0-1 has condition Ready=False (KubeletNotReady: container runtime network not ready)",
+		},
+		{
+			"cordoned",
+			[]*corev1.Node{newTestNodeForInstance("ip-10-0-0-1", "i-0aaaaaaaaaaaaaaaa", corev1.ConditionTrue, true)},
+			"node ip-10-0-0-1 is Ready but cordoned",
+		},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := fake.NewSimpleClientset()
+			for _, node := range testCase.nodes {
+				_, err := client.CoreV1().Nodes().Create(context.Background(), node, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			err := waitForNodeReadyWithClient(context.Background(), client, "i-0aaaaaaaaaaaaaaaa", 50*time.Millisecond, 10*time.Millisecond)
+			notReadyErr, isNotReadyErr := errors.Unwrap(err).(NodeNotReadyError)
+			require.True(t, isNotReadyErr)
+			assert.Equal(t, "i-0aaaaaaaaaaaaaaaa", notReadyErr.InstanceID)
+			assert.Equal(t, testCase.expectedLastState, notReadyErr.LastState)
+		})
+	}
+}
+
+func newTestNodeForInstance(name string, instanceID string, readyStatus corev1.ConditionStatus, cordoned bool) *corev1.Node {
+	condition := corev1.NodeCondition{Type: corev1.NodeReady, Status: readyStatus}
+	if readyStatus != corev1.ConditionTrue {
+		condition.Reason = "KubeletNotReady"
+		condition.Message = "container runtime network not ready"
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: corev1.NodeSpec{
+			ProviderID:    "aws:///us-east-1a/" + instanceID,
+			Unschedulable: cordoned,
+		},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{condition}},
+	}
+}
@@ -0,0 +1,195 @@
+package eks
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// nodeGroupLabelKey is the label that EKS sets on the nodes of a managed node group to the name of the node group.
+const nodeGroupLabelKey = "eks.amazonaws.com/nodegroup"
+
+// CordonNodeGroup cordons all the nodes of the EKS managed node group, found by the eks.amazonaws.com/nodegroup label,
+// so that no new Pods are scheduled on them. Unlike DrainNode, the Pods that are already on the nodes keep running.
+// When taint is not nil, it is also added to each node, e.g., to have a maintenance window taint that tolerating Pods can
+// react to. When nodeSelector is not nil, only the nodes of the node group that match it are cordoned. Nodes that are
+// already cordoned (and tainted) are skipped. Returns the number of nodes that were updated.
+func CordonNodeGroup(clusterArn string, nodeGroupName string, taint *corev1.Taint, nodeSelector labels.Selector) (int, error) {
+	client, err := kubectl.GetKubernetesClientFromOptions(&kubectl.KubectlOptions{EKSClusterArn: clusterArn})
+	if err != nil {
+		return 0, errors.WithStackTrace(err)
+	}
+	return setNodeGroupCordoned(context.Background(), client, nodeGroupName, taint, nodeSelector, true)
+}
+
+// UncordonNodeGroup reverts CordonNodeGroup, marking all the nodes of the EKS managed node group as schedulable again.
+// When taint is not nil, the taint with the same key and effect is also removed from each node. When nodeSelector is not
+// nil, only the nodes of the node group that match it are uncordoned. Nodes that are already schedulable (and
+// untainted) are skipped. Returns the number of nodes that were updated.
+func UncordonNodeGroup(clusterArn string, nodeGroupName string, taint *corev1.Taint, nodeSelector labels.Selector) (int, error) {
+	client, err := kubectl.GetKubernetesClientFromOptions(&kubectl.KubectlOptions{EKSClusterArn: clusterArn})
+	if err != nil {
+		return 0, errors.WithStackTrace(err)
+	}
+	return setNodeGroupCordoned(context.Background(), client, nodeGroupName, taint, nodeSelector, false)
+}
+
+// setNodeGroupCordoned cordons (or uncordons) the nodes of the node group that match the optional node selector, adding
+// (or removing) the optional taint, and returns the number of nodes that were updated.
+func setNodeGroupCordoned(
+	ctx context.Context,
+	client kubernetes.Interface,
+	nodeGroupName string,
+	taint *corev1.Taint,
+	nodeSelector labels.Selector,
+	cordon bool,
+) (int, error) {
+	logger := logging.GetProjectLogger().WithField("nodeGroup", nodeGroupName)
+
+	nodes, err := listNodeGroupNodes(ctx, client, nodeGroupName, nodeSelector)
+	if err != nil {
+		return 0, err
+	}
+	if len(nodes) == 0 {
+		return 0, errors.WithStackTrace(NoNodesInNodeGroupError{NodeGroupName: nodeGroupName, NodeSelector: selectorString(nodeSelector)})
+	}
+
+	updated := 0
+	for _, listedNode := range nodes {
+		// The listed node is used on the first attempt, and the latest version of the node is read again on retries, e.g.
+		// when the update conflicts.
+		node := listedNode.DeepCopy()
+		changed := false
+		err := kubehelper.RetryOnConflictOrServerError(func() error {
+			if node == nil {
+				latest, err := client.CoreV1().Nodes().Get(ctx, listedNode.Name, metav1.GetOptions{})
+				if err != nil {
+					return errors.WithStackTrace(err)
+				}
+				node = latest
+			}
+			changed = node.Spec.Unschedulable != cordon
+			node.Spec.Unschedulable = cordon
+			if taint != nil {
+				var taintChanged bool
+				node.Spec.Taints, taintChanged = setTaint(node.Spec.Taints, *taint, cordon)
+				changed = changed || taintChanged
+			}
+			if !changed {
+				return nil
+			}
+
+			kubectl.MarkAsModifiedByKubergrunt(&node.ObjectMeta)
+			_, err := client.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
+			node = nil
+			return err
+		})
+		if err != nil {
+			return updated, err
+		}
+		if !changed {
+			logger.Infof("Skipping node %s, which is already %s", listedNode.Name, cordonStateName(cordon))
+			continue
+		}
+		logger.Infof("Node %s is %s", listedNode.Name, cordonStateName(cordon))
+		updated++
+	}
+	logger.Infof("Updated %d of the %d nodes in node group %s", updated, len(nodes), nodeGroupName)
+	return updated, nil
+}
+
+// listNodeGroupNodes lists the nodes of the node group, found by the eks.amazonaws.com/nodegroup label, that also match
+// the node selector when it is not nil. The number of nodes that matched is logged.
+func listNodeGroupNodes(ctx context.Context, client kubernetes.Interface, nodeGroupName string, nodeSelector labels.Selector) ([]corev1.Node, error) {
+	logger := logging.GetProjectLogger().WithField("nodeGroup", nodeGroupName)
+
+	selector := labels.SelectorFromSet(labels.Set{nodeGroupLabelKey: nodeGroupName})
+	if nodeSelector != nil {
+		requirements, _ := nodeSelector.Requirements()
+		selector = selector.Add(requirements...)
+	}
+	nodeList, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if nodeSelectorString := selectorString(nodeSelector); nodeSelectorString != "" {
+		logger.Infof("Found %d nodes in node group %s matching the node selector %s", len(nodeList.Items), nodeGroupName, nodeSelectorString)
+	} else {
+		logger.Infof("Found %d nodes in node group %s", len(nodeList.Items), nodeGroupName)
+	}
+	return nodeList.Items, nil
+}
+
+// ParseNodeSelector parses a node selector in the label selector format used by `kubectl get nodes --selector`, e.g.,
+// workload=gpu,zone!=us-east-1a. An empty selector matches all the nodes. Returns an InvalidNodeSelectorError if the
+// syntax is not valid.
+func ParseNodeSelector(spec string) (labels.Selector, error) {
+	selector, err := labels.Parse(spec)
+	if err != nil {
+		return nil, errors.WithStackTrace(InvalidNodeSelectorError{NodeSelector: spec, Reason: err.Error()})
+	}
+	return selector, nil
+}
+
+// selectorString returns the string form of the node selector, or an empty string if it is nil.
+func selectorString(nodeSelector labels.Selector) string {
+	if nodeSelector == nil {
+		return ""
+	}
+	return nodeSelector.String()
+}
+
+// setTaint adds the taint to the list (or removes taints with the same key and effect from it when add is false), and
+// returns the updated list along with whether it changed. An existing taint with the same key and effect but a different
+// value is replaced.
+func setTaint(taints []corev1.Taint, taint corev1.Taint, add bool) ([]corev1.Taint, bool) {
+	updated := []corev1.Taint{}
+	changed := false
+	for _, existing := range taints {
+		if existing.Key != taint.Key || existing.Effect != taint.Effect {
+			updated = append(updated, existing)
+			continue
+		}
+		if add && existing.Value == taint.Value {
+			return taints, false
+		}
+		changed = true
+	}
+	if add {
+		updated = append(updated, taint)
+		changed = true
+	}
+	return updated, changed
+}
+
+func cordonStateName(cordon bool) string {
+	if cordon {
+		return "cordoned"
+	}
+	return "uncordoned"
+}
+
+// ParseTaint parses a taint in the format used by `kubectl taint`, key[=value]:effect, e.g.,
+// maintenance=true:NoSchedule. Returns an InvalidTaintError if the format or the effect is not valid.
+func ParseTaint(spec string) (*corev1.Taint, error) {
+	keyValue, effect, hasEffect := strings.Cut(spec, ":")
+	key, value, _ := strings.Cut(keyValue, "=")
+	if !hasEffect || key == "" {
+		return nil, errors.WithStackTrace(InvalidTaintError{Taint: spec})
+	}
+	switch corev1.TaintEffect(effect) {
+	case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+	default:
+		return nil, errors.WithStackTrace(InvalidTaintError{Taint: spec})
+	}
+	return &corev1.Taint{Key: key, Value: value, Effect: corev1.TaintEffect(effect)}, nil
+}
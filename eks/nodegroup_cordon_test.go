@@ -0,0 +1,159 @@
+package eks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testNodeGroupNode(name string, nodeGroupName string, unschedulable bool, taints ...corev1.Taint) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{nodeGroupLabelKey: nodeGroupName}},
+		Spec:       corev1.NodeSpec{Unschedulable: unschedulable, Taints: taints},
+	}
+}
+
+func TestSetNodeGroupCordonedCordonsAndUncordonsNodes(t *testing.T) {
+	t.Parallel()
+
+	taint := corev1.Taint{Key: "maintenance", Value: "true", Effect: corev1.TaintEffectNoSchedule}
+	client := fake.NewSimpleClientset(
+		testNodeGroupNode("node-1", "workers", false),
+		testNodeGroupNode("node-2", "workers", true, taint),
+		testNodeGroupNode("node-3", "other", false),
+	)
+	ctx := context.Background()
+
+	// node-2 is already cordoned and tainted, so only node-1 is updated.
+	updated, err := setNodeGroupCordoned(ctx, client, "workers", &taint, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+	for _, name := range []string{"node-1", "node-2"} {
+		node, err := client.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.True(t, node.Spec.Unschedulable)
+		assert.Equal(t, []corev1.Taint{taint}, node.Spec.Taints)
+	}
+	otherNode, err := client.CoreV1().Nodes().Get(ctx, "node-3", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.False(t, otherNode.Spec.Unschedulable)
+
+	updated, err = setNodeGroupCordoned(ctx, client, "workers", &taint, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated)
+	for _, name := range []string{"node-1", "node-2"} {
+		node, err := client.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.False(t, node.Spec.Unschedulable)
+		assert.Empty(t, node.Spec.Taints)
+	}
+}
+
+func TestSetNodeGroupCordonedWithoutNodes(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(testNodeGroupNode("node-1", "other", false))
+	_, err := setNodeGroupCordoned(context.Background(), client, "workers", nil, nil, true)
+	_, isNoNodesErr := errors.Unwrap(err).(NoNodesInNodeGroupError)
+	assert.True(t, isNoNodesErr)
+}
+
+func TestSetNodeGroupCordonedOnlyCordonsNodesMatchingSelector(t *testing.T) {
+	t.Parallel()
+
+	gpuNode := testNodeGroupNode("node-gpu", "workers", false)
+	gpuNode.Labels["workload"] = "gpu"
+	otherGroupGPUNode := testNodeGroupNode("node-other-gpu", "other", false)
+	otherGroupGPUNode.Labels["workload"] = "gpu"
+	client := fake.NewSimpleClientset(gpuNode, otherGroupGPUNode, testNodeGroupNode("node-cpu", "workers", false))
+	ctx := context.Background()
+
+	nodeSelector, err := ParseNodeSelector("workload=gpu")
+	require.NoError(t, err)
+	updated, err := setNodeGroupCordoned(ctx, client, "workers", nil, nodeSelector, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+
+	// Only the node that is both in the node group and matches the selector is cordoned.
+	for name, expectedUnschedulable := range map[string]bool{"node-gpu": true, "node-other-gpu": false, "node-cpu": false} {
+		node, err := client.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, expectedUnschedulable, node.Spec.Unschedulable, name)
+	}
+
+	noMatchSelector, err := ParseNodeSelector("workload in (inference)")
+	require.NoError(t, err)
+	_, err = setNodeGroupCordoned(ctx, client, "workers", nil, noMatchSelector, true)
+	assert.Equal(t, NoNodesInNodeGroupError{NodeGroupName: "workers", NodeSelector: "workload in (inference)"}, errors.Unwrap(err))
+}
+
+func TestParseNodeSelector(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		spec          string
+		expectedValid bool
+	}{
+		{"workload=gpu", true},
+		{"workload=gpu,zone!=us-east-1a", true},
+		{"!spot", true},
+		{"", true},
+		{"workload in (gpu", false},
+		{"workload in gpu", false},
+		{"work load=gpu", false},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.spec, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseNodeSelector(testCase.spec)
+			if testCase.expectedValid {
+				assert.NoError(t, err)
+				return
+			}
+			_, isInvalidErr := errors.Unwrap(err).(InvalidNodeSelectorError)
+			assert.True(t, isInvalidErr)
+		})
+	}
+}
+
+func TestParseTaint(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		spec          string
+		expectedTaint *corev1.Taint
+	}{
+		{"maintenance=true:NoSchedule", &corev1.Taint{Key: "maintenance", Value: "true", Effect: corev1.TaintEffectNoSchedule}},
+		{"maintenance:NoExecute", &corev1.Taint{Key: "maintenance", Effect: corev1.TaintEffectNoExecute}},
+		{"maintenance=true", nil},
+		{"=true:NoSchedule", nil},
+		{"maintenance=true:Sometimes", nil},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.spec, func(t *testing.T) {
+			t.Parallel()
+
+			taint, err := ParseTaint(testCase.spec)
+			if testCase.expectedTaint == nil {
+				_, isInvalidErr := errors.Unwrap(err).(InvalidTaintError)
+				assert.True(t, isInvalidErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedTaint, taint)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package eks
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/gruntwork-io/go-commons/errors"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// NodeGroupKeyRotation describes the launch template versions involved in rotating the SSH key of the nodes of an EKS
+// managed node group with RotateNodeGroupKey.
+type NodeGroupKeyRotation struct {
+	LaunchTemplateID string
+
+	// OldLaunchTemplateVersion is the launch template version the node group was deployed with before the rotation.
+	OldLaunchTemplateVersion string
+
+	// NewLaunchTemplateVersion is the launch template version with the new key pair that the node group is rolled to.
+	NewLaunchTemplateVersion string
+}
+
+// RotateNodeGroupKey rotates the SSH key of the nodes of the EKS managed node group to the given EC2 key pair. This
+// creates a new version of the launch template of the node group, based on the version the node group is deployed with
+// and only changing the key pair, and then updates the node group to the new version, which rolls the nodes, waiting
+// for the update to complete (see UpgradeNodeGroup). The key pair is verified to exist before anything is changed.
+// Returns a NodeGroupLaunchTemplateNotFoundError if the node group is not deployed with a launch template. On success,
+// the returned NodeGroupKeyRotation lists the old and new launch template versions. If the update of the node group
+// fails, the rotation is returned along with the error, so that the new launch template version can be reported.
+func RotateNodeGroupKey(clusterArn string, nodeGroupName string, newKeyName string) (*NodeGroupKeyRotation, error) {
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterID, err := eksawshelper.GetClusterNameFromArn(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	logging.GetProjectLogger().Infof("Successfully authenticated with AWS")
+
+	return rotateNodeGroupKey(
+		context.Background(),
+		eks.New(sess),
+		eksawshelper.NewEC2Client(sess),
+		clusterID,
+		nodeGroupName,
+		newKeyName,
+		nodeGroupUpdatePollInterval,
+	)
+}
+
+func rotateNodeGroupKey(
+	ctx context.Context,
+	eksSvc eksiface.EKSAPI,
+	ec2Svc ec2iface.EC2API,
+	clusterID string,
+	nodeGroupName string,
+	newKeyName string,
+	pollInterval time.Duration,
+) (*NodeGroupKeyRotation, error) {
+	logger := logging.GetProjectLogger().WithField("nodeGroup", nodeGroupName)
+
+	if err := verifyKeyPairExists(ctx, ec2Svc, newKeyName); err != nil {
+		return nil, err
+	}
+
+	output, err := eksSvc.DescribeNodegroupWithContext(ctx, &eks.DescribeNodegroupInput{
+		ClusterName:   aws.String(clusterID),
+		NodegroupName: aws.String(nodeGroupName),
+	})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	launchTemplate := output.Nodegroup.LaunchTemplate
+	if launchTemplate == nil || aws.StringValue(launchTemplate.Id) == "" {
+		return nil, errors.WithStackTrace(NodeGroupLaunchTemplateNotFoundError{NodeGroupName: nodeGroupName})
+	}
+	rotation := &NodeGroupKeyRotation{
+		LaunchTemplateID:         aws.StringValue(launchTemplate.Id),
+		OldLaunchTemplateVersion: aws.StringValue(launchTemplate.Version),
+	}
+
+	logger.Infof(
+		"Creating a version of launch template %s with key pair %s, based on version %s",
+		rotation.LaunchTemplateID,
+		newKeyName,
+		rotation.OldLaunchTemplateVersion,
+	)
+	versionOutput, err := ec2Svc.CreateLaunchTemplateVersionWithContext(ctx, &ec2.CreateLaunchTemplateVersionInput{
+		LaunchTemplateId:   launchTemplate.Id,
+		SourceVersion:      launchTemplate.Version,
+		VersionDescription: aws.String("Rotate SSH key to " + newKeyName),
+		LaunchTemplateData: &ec2.RequestLaunchTemplateData{KeyName: aws.String(newKeyName)},
+	})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	rotation.NewLaunchTemplateVersion = strconv.FormatInt(aws.Int64Value(versionOutput.LaunchTemplateVersion.VersionNumber), 10)
+	logger.Infof("Created version %s of launch template %s", rotation.NewLaunchTemplateVersion, rotation.LaunchTemplateID)
+
+	logger.Infof("Rolling node group %s to version %s of launch template %s", nodeGroupName, rotation.NewLaunchTemplateVersion, rotation.LaunchTemplateID)
+	input := &eks.UpdateNodegroupVersionInput{
+		ClusterName:   aws.String(clusterID),
+		NodegroupName: aws.String(nodeGroupName),
+		LaunchTemplate: &eks.LaunchTemplateSpecification{
+			Id:      launchTemplate.Id,
+			Version: aws.String(rotation.NewLaunchTemplateVersion),
+		},
+	}
+	if err := runNodeGroupUpdate(ctx, eksSvc, input, pollInterval); err != nil {
+		return rotation, err
+	}
+	return rotation, nil
+}
+
+// verifyKeyPairExists returns a KeyPairNotFoundError if there is no EC2 key pair with the given name.
+func verifyKeyPairExists(ctx context.Context, ec2Svc ec2iface.EC2API, keyName string) error {
+	output, err := ec2Svc.DescribeKeyPairsWithContext(ctx, &ec2.DescribeKeyPairsInput{KeyNames: aws.StringSlice([]string{keyName})})
+	if awsErr, isAwsErr := err.(awserr.Error); isAwsErr && awsErr.Code() == "InvalidKeyPair.NotFound" {
+		return errors.WithStackTrace(KeyPairNotFoundError{KeyName: keyName})
+	}
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	if len(output.KeyPairs) == 0 {
+		return errors.WithStackTrace(KeyPairNotFoundError{KeyName: keyName})
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package eks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNodeGroupWithLaunchTemplateEKS extends fakeNodeGroupEKS with a node group deployed with the given launch template.
+type fakeNodeGroupWithLaunchTemplateEKS struct {
+	*fakeNodeGroupEKS
+
+	launchTemplate *eks.LaunchTemplateSpecification
+}
+
+func (fake *fakeNodeGroupWithLaunchTemplateEKS) DescribeNodegroupWithContext(ctx awsgo.Context, input *eks.DescribeNodegroupInput, opts ...request.Option) (*eks.DescribeNodegroupOutput, error) {
+	return &eks.DescribeNodegroupOutput{Nodegroup: &eks.Nodegroup{NodegroupName: input.NodegroupName, LaunchTemplate: fake.launchTemplate}}, nil
+}
+
+// fakeLaunchTemplateEC2 is a stub of the EC2 API with the given key pairs, that records the created launch template
+// versions.
+type fakeLaunchTemplateEC2 struct {
+	ec2iface.EC2API
+
+	keyNames      []string
+	latestVersion int64
+
+	createVersionInputs []*ec2.CreateLaunchTemplateVersionInput
+}
+
+func (fake *fakeLaunchTemplateEC2) DescribeKeyPairsWithContext(ctx awsgo.Context, input *ec2.DescribeKeyPairsInput, opts ...request.Option) (*ec2.DescribeKeyPairsOutput, error) {
+	output := &ec2.DescribeKeyPairsOutput{}
+	for _, keyName := range input.KeyNames {
+		found := false
+		for _, existingKeyName := range fake.keyNames {
+			if awsgo.StringValue(keyName) == existingKeyName {
+				found = true
+				output.KeyPairs = append(output.KeyPairs, &ec2.KeyPairInfo{KeyName: keyName})
+			}
+		}
+		if !found {
+			return nil, awserr.New("InvalidKeyPair.NotFound", "The key pair does not exist", nil)
+		}
+	}
+	return output, nil
+}
+
+func (fake *fakeLaunchTemplateEC2) CreateLaunchTemplateVersionWithContext(ctx awsgo.Context, input *ec2.CreateLaunchTemplateVersionInput, opts ...request.Option) (*ec2.CreateLaunchTemplateVersionOutput, error) {
+	fake.createVersionInputs = append(fake.createVersionInputs, input)
+	fake.latestVersion++
+	return &ec2.CreateLaunchTemplateVersionOutput{
+		LaunchTemplateVersion: &ec2.LaunchTemplateVersion{
+			LaunchTemplateId: input.LaunchTemplateId,
+			VersionNumber:    awsgo.Int64(fake.latestVersion),
+		},
+	}, nil
+}
+
+func TestRotateNodeGroupKey(t *testing.T) {
+	t.Parallel()
+
+	eksSvc := &fakeNodeGroupWithLaunchTemplateEKS{
+		fakeNodeGroupEKS: &fakeNodeGroupEKS{statuses: []string{eks.UpdateStatusInProgress, eks.UpdateStatusSuccessful}},
+		launchTemplate:   &eks.LaunchTemplateSpecification{Id: awsgo.String("lt-123"), Version: awsgo.String("3")},
+	}
+	ec2Svc := &fakeLaunchTemplateEC2{keyNames: []string{"workers-2024"}, latestVersion: 3}
+
+	rotation, err := rotateNodeGroupKey(context.Background(), eksSvc, ec2Svc, "prod", "workers", "workers-2024", time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, &NodeGroupKeyRotation{LaunchTemplateID: "lt-123", OldLaunchTemplateVersion: "3", NewLaunchTemplateVersion: "4"}, rotation)
+
+	require.Len(t, ec2Svc.createVersionInputs, 1)
+	createInput := ec2Svc.createVersionInputs[0]
+	assert.Equal(t, "lt-123", awsgo.StringValue(createInput.LaunchTemplateId))
+	assert.Equal(t, "3", awsgo.StringValue(createInput.SourceVersion))
+	assert.Equal(t, &ec2.RequestLaunchTemplateData{KeyName: awsgo.String("workers-2024")}, createInput.LaunchTemplateData)
+
+	updateInput := eksSvc.updateInput
+	assert.Equal(t, "workers", awsgo.StringValue(updateInput.NodegroupName))
+	assert.Equal(t, &eks.LaunchTemplateSpecification{Id: awsgo.String("lt-123"), Version: awsgo.String("4")}, updateInput.LaunchTemplate)
+	assert.Nil(t, updateInput.Version)
+	assert.Len(t, eksSvc.describeInputs, 2)
+}
+
+func TestRotateNodeGroupKeyVerifiesKeyPairBeforeChanges(t *testing.T) {
+	t.Parallel()
+
+	eksSvc := &fakeNodeGroupWithLaunchTemplateEKS{
+		fakeNodeGroupEKS: &fakeNodeGroupEKS{statuses: []string{eks.UpdateStatusSuccessful}},
+		launchTemplate:   &eks.LaunchTemplateSpecification{Id: awsgo.String("lt-123"), Version: awsgo.String("3")},
+	}
+	ec2Svc := &fakeLaunchTemplateEC2{keyNames: []string{"workers-2023"}}
+
+	_, err := rotateNodeGroupKey(context.Background(), eksSvc, ec2Svc, "prod", "workers", "workers-2024", time.Millisecond)
+	require.Error(t, err)
+	assert.Equal(t, KeyPairNotFoundError{KeyName: "workers-2024"}, errors.Unwrap(err))
+	assert.Empty(t, ec2Svc.createVersionInputs)
+	assert.Nil(t, eksSvc.updateInput)
+}
+
+func TestRotateNodeGroupKeyRequiresLaunchTemplate(t *testing.T) {
+	t.Parallel()
+
+	eksSvc := &fakeNodeGroupWithLaunchTemplateEKS{fakeNodeGroupEKS: &fakeNodeGroupEKS{statuses: []string{eks.UpdateStatusSuccessful}}}
+	ec2Svc := &fakeLaunchTemplateEC2{keyNames: []string{"workers-2024"}}
+
+	_, err := rotateNodeGroupKey(context.Background(), eksSvc, ec2Svc, "prod", "workers", "workers-2024", time.Millisecond)
+	require.Error(t, err)
+	assert.Equal(t, NodeGroupLaunchTemplateNotFoundError{NodeGroupName: "workers"}, errors.Unwrap(err))
+	assert.Empty(t, ec2Svc.createVersionInputs)
+}
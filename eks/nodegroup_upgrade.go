@@ -0,0 +1,172 @@
+package eks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/gruntwork-io/go-commons/errors"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+const (
+	// nodeGroupUpdatePollInterval is the interval between checks of the status of a managed node group update.
+	nodeGroupUpdatePollInterval = 30 * time.Second
+
+	// nodeGroupUpdateProgressReportInterval is the minimum interval between progress reports while the update is in
+	// progress. Changes of the update status are always reported.
+	nodeGroupUpdateProgressReportInterval = 2 * time.Minute
+)
+
+// UpgradeNodeGroup upgrades the EKS managed node group to the given Kubernetes version using the EKS
+// UpdateNodegroupVersion API, which rolls the nodes of the node group, and waits for the update to complete while
+// reporting its progress. When version is empty, the node group is upgraded to the Kubernetes version of the cluster.
+// Set force to replace the nodes even if draining them is blocked by a PodDisruptionBudget, which would otherwise fail
+// the update. If the update fails, the errors reported by EKS are logged and returned in a NodeGroupUpdateFailedError.
+func UpgradeNodeGroup(clusterArn string, nodeGroupName string, version string, force bool) error {
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return err
+	}
+
+	clusterID, err := eksawshelper.GetClusterNameFromArn(clusterArn)
+	if err != nil {
+		return err
+	}
+
+	eksSvc, err := eksawshelper.NewEksClient(region)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	logging.GetProjectLogger().Infof("Successfully authenticated with AWS")
+
+	return upgradeNodeGroup(context.Background(), eksSvc, clusterID, nodeGroupName, version, force, nodeGroupUpdatePollInterval)
+}
+
+func upgradeNodeGroup(
+	ctx context.Context,
+	eksSvc eksiface.EKSAPI,
+	clusterID string,
+	nodeGroupName string,
+	version string,
+	force bool,
+	pollInterval time.Duration,
+) error {
+	logger := logging.GetProjectLogger().WithField("nodeGroup", nodeGroupName)
+
+	input := &eks.UpdateNodegroupVersionInput{
+		ClusterName:   aws.String(clusterID),
+		NodegroupName: aws.String(nodeGroupName),
+		Force:         aws.Bool(force),
+	}
+	if version != "" {
+		input.Version = aws.String(version)
+		logger.Infof("Upgrading node group %s of EKS cluster %s to Kubernetes version %s", nodeGroupName, clusterID, version)
+	} else {
+		logger.Infof("Upgrading node group %s of EKS cluster %s to the Kubernetes version of the cluster", nodeGroupName, clusterID)
+	}
+	if force {
+		logger.Warn("Forcing the update: nodes are replaced even if a PodDisruptionBudget blocks draining them.")
+	}
+	return runNodeGroupUpdate(ctx, eksSvc, input, pollInterval)
+}
+
+// runNodeGroupUpdate starts the given update of a managed node group, which rolls the nodes of the node group, and
+// waits for the update to complete.
+func runNodeGroupUpdate(
+	ctx context.Context,
+	eksSvc eksiface.EKSAPI,
+	input *eks.UpdateNodegroupVersionInput,
+	pollInterval time.Duration,
+) error {
+	clusterID := aws.StringValue(input.ClusterName)
+	nodeGroupName := aws.StringValue(input.NodegroupName)
+
+	output, err := eksSvc.UpdateNodegroupVersionWithContext(ctx, input)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	updateID := aws.StringValue(output.Update.Id)
+	logging.GetProjectLogger().WithField("nodeGroup", nodeGroupName).WithField("updateID", updateID).Infof("Started update %s", updateID)
+
+	return waitForNodeGroupUpdate(ctx, eksSvc, clusterID, nodeGroupName, updateID, pollInterval)
+}
+
+// waitForNodeGroupUpdate polls the status of the node group update until it either succeeds, fails, or is cancelled.
+func waitForNodeGroupUpdate(
+	ctx context.Context,
+	eksSvc eksiface.EKSAPI,
+	clusterID string,
+	nodeGroupName string,
+	updateID string,
+	pollInterval time.Duration,
+) error {
+	logger := logging.GetProjectLogger().WithField("nodeGroup", nodeGroupName).WithField("updateID", updateID)
+
+	start := time.Now()
+	lastStatus := ""
+	lastReported := start
+	for {
+		output, err := eksSvc.DescribeUpdateWithContext(ctx, &eks.DescribeUpdateInput{
+			Name:          aws.String(clusterID),
+			NodegroupName: aws.String(nodeGroupName),
+			UpdateId:      aws.String(updateID),
+		})
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		update := output.Update
+		status := aws.StringValue(update.Status)
+		elapsed := time.Since(start).Round(time.Second)
+
+		switch status {
+		case eks.UpdateStatusSuccessful:
+			logger.Infof("Successfully upgraded node group %s in %s", nodeGroupName, elapsed)
+			return nil
+		case eks.UpdateStatusFailed, eks.UpdateStatusCancelled:
+			updateErrors := formatUpdateErrors(update.Errors)
+			for _, updateErr := range updateErrors {
+				logger.Errorf("Update error: %s", updateErr)
+			}
+			return errors.WithStackTrace(NodeGroupUpdateFailedError{
+				NodeGroupName: nodeGroupName,
+				UpdateID:      updateID,
+				Status:        status,
+				Errors:        updateErrors,
+			})
+		}
+
+		if status != lastStatus || time.Since(lastReported) >= nodeGroupUpdateProgressReportInterval {
+			logger.Infof("Update of node group %s is %s (elapsed %s)", nodeGroupName, status, elapsed)
+			lastStatus = status
+			lastReported = time.Now()
+		} else {
+			logger.Debugf("Update of node group %s is %s (elapsed %s)", nodeGroupName, status, elapsed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.WithStackTrace(ctx.Err())
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// formatUpdateErrors returns a description of each of the errors reported by EKS for an update.
+func formatUpdateErrors(updateErrors []*eks.ErrorDetail) []string {
+	formatted := []string{}
+	for _, updateErr := range updateErrors {
+		message := fmt.Sprintf("%s: %s", aws.StringValue(updateErr.ErrorCode), aws.StringValue(updateErr.ErrorMessage))
+		if len(updateErr.ResourceIds) > 0 {
+			message = fmt.Sprintf("%s (resources: %s)", message, strings.Join(aws.StringValueSlice(updateErr.ResourceIds), ", "))
+		}
+		formatted = append(formatted, message)
+	}
+	return formatted
+}
@@ -0,0 +1,78 @@
+package eks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNodeGroupEKS starts node group updates, and reports the given update statuses in order, repeating the last one.
+type fakeNodeGroupEKS struct {
+	eksiface.EKSAPI
+
+	statuses     []string
+	updateErrors []*eks.ErrorDetail
+
+	updateInput    *eks.UpdateNodegroupVersionInput
+	describeInputs []*eks.DescribeUpdateInput
+}
+
+func (fake *fakeNodeGroupEKS) UpdateNodegroupVersionWithContext(ctx awsgo.Context, input *eks.UpdateNodegroupVersionInput, opts ...request.Option) (*eks.UpdateNodegroupVersionOutput, error) {
+	fake.updateInput = input
+	return &eks.UpdateNodegroupVersionOutput{Update: &eks.Update{Id: awsgo.String("update-1"), Status: awsgo.String(eks.UpdateStatusInProgress)}}, nil
+}
+
+func (fake *fakeNodeGroupEKS) DescribeUpdateWithContext(ctx awsgo.Context, input *eks.DescribeUpdateInput, opts ...request.Option) (*eks.DescribeUpdateOutput, error) {
+	fake.describeInputs = append(fake.describeInputs, input)
+	status := fake.statuses[len(fake.statuses)-1]
+	if len(fake.describeInputs) <= len(fake.statuses) {
+		status = fake.statuses[len(fake.describeInputs)-1]
+	}
+	return &eks.DescribeUpdateOutput{Update: &eks.Update{Id: input.UpdateId, Status: awsgo.String(status), Errors: fake.updateErrors}}, nil
+}
+
+func TestUpgradeNodeGroupWaitsForUpdateToSucceed(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeNodeGroupEKS{statuses: []string{eks.UpdateStatusInProgress, eks.UpdateStatusInProgress, eks.UpdateStatusSuccessful}}
+	err := upgradeNodeGroup(context.Background(), fake, "prod", "workers", "1.25", true, time.Millisecond)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.25", awsgo.StringValue(fake.updateInput.Version))
+	assert.True(t, awsgo.BoolValue(fake.updateInput.Force))
+	require.Len(t, fake.describeInputs, 3)
+	assert.Equal(t, "update-1", awsgo.StringValue(fake.describeInputs[0].UpdateId))
+	assert.Equal(t, "workers", awsgo.StringValue(fake.describeInputs[0].NodegroupName))
+}
+
+func TestUpgradeNodeGroupReturnsUpdateErrors(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeNodeGroupEKS{
+		statuses: []string{eks.UpdateStatusInProgress, eks.UpdateStatusFailed},
+		updateErrors: []*eks.ErrorDetail{{
+			ErrorCode:    awsgo.String(eks.ErrorCodePodEvictionFailure),
+			ErrorMessage: awsgo.String("Reached max retries while trying to evict pods from nodes in node group workers"),
+			ResourceIds:  awsgo.StringSlice([]string{"ip-10-0-0-1.ec2.internal"}),
+		}},
+	}
+	err := upgradeNodeGroup(context.Background(), fake, "prod", "workers", "", false, time.Millisecond)
+
+	assert.Nil(t, fake.updateInput.Version)
+	updateErr, isUpdateErr := errors.Unwrap(err).(NodeGroupUpdateFailedError)
+	require.True(t, isUpdateErr)
+	assert.Equal(t, eks.UpdateStatusFailed, updateErr.Status)
+	assert.Equal(
+		t,
+		[]string{"PodEvictionFailure: Reached max retries while trying to evict pods from nodes in node group workers (resources: ip-10-0-0-1.ec2.internal)"},
+		updateErr.Errors,
+	)
+}
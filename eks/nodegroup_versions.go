@@ -0,0 +1,211 @@
+package eks
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/gruntwork-io/go-commons/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// NodeGroupVersions reports the launch template version that each node of an EKS managed node group was launched with,
+// against the current launch template version of the node group.
+type NodeGroupVersions struct {
+	NodeGroupName string `json:"nodegroup_name"`
+
+	// LaunchTemplateID and LaunchTemplateVersion are the launch template that the Auto Scaling Group of the node group
+	// launches new instances with. Note that when the node group is deployed with a custom launch template, EKS launches
+	// the instances from a copy of it, so this is not the launch template passed in to EKS.
+	LaunchTemplateID      string `json:"launch_template_id"`
+	LaunchTemplateVersion string `json:"launch_template_version"`
+
+	Nodes []NodeLaunchTemplateVersion `json:"nodes"`
+}
+
+// NodeLaunchTemplateVersion is the launch template version that a node was launched with, as reported by the Auto Scaling
+// Group of the node group. The launch template fields are empty if the EC2 instance of the node is not in the Auto
+// Scaling Group, or was not launched from a launch template.
+type NodeLaunchTemplateVersion struct {
+	NodeName              string `json:"node_name"`
+	InstanceID            string `json:"instance_id"`
+	LaunchTemplateID      string `json:"launch_template_id"`
+	LaunchTemplateVersion string `json:"launch_template_version"`
+
+	// Stale is true when the node is not on the current launch template version of the node group, and is replaced by
+	// the next rolling deploy.
+	Stale bool `json:"stale"`
+}
+
+// StaleNodeNames returns the names of the nodes that are not on the current launch template version of the node group.
+func (versions NodeGroupVersions) StaleNodeNames() []string {
+	names := []string{}
+	for _, node := range versions.Nodes {
+		if node.Stale {
+			names = append(names, node.NodeName)
+		}
+	}
+	return names
+}
+
+// ListNodeGroupVersions maps each node of the EKS managed node group, found by the eks.amazonaws.com/nodegroup label, to
+// its EC2 instance, and reports the launch template version the instance was launched with against the current launch
+// template version of the node group, to decide whether a rolling deploy is needed. When nodeSelector is not nil, only
+// the nodes of the node group that match it are reported. This is read only. Returns a
+// NodeGroupLaunchTemplateNotFoundError if the Auto Scaling Group of the node group does not use a launch template.
+func ListNodeGroupVersions(clusterArn string, nodeGroupName string, nodeSelector labels.Selector) (*NodeGroupVersions, error) {
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterID, err := eksawshelper.GetClusterNameFromArn(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	logging.GetProjectLogger().Infof("Successfully authenticated with AWS")
+
+	client, err := kubectl.GetKubernetesClientFromOptions(&kubectl.KubectlOptions{EKSClusterArn: clusterArn})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	return listNodeGroupVersions(
+		context.Background(),
+		eks.New(sess),
+		autoscaling.New(sess),
+		eksawshelper.NewEC2Client(sess),
+		client,
+		clusterID,
+		nodeGroupName,
+		nodeSelector,
+	)
+}
+
+func listNodeGroupVersions(
+	ctx context.Context,
+	eksSvc eksiface.EKSAPI,
+	asgSvc autoscalingiface.AutoScalingAPI,
+	ec2Svc ec2iface.EC2API,
+	client kubernetes.Interface,
+	clusterID string,
+	nodeGroupName string,
+	nodeSelector labels.Selector,
+) (*NodeGroupVersions, error) {
+	logger := logging.GetProjectLogger().WithField("nodeGroup", nodeGroupName)
+
+	output, err := eksSvc.DescribeNodegroupWithContext(ctx, &eks.DescribeNodegroupInput{
+		ClusterName:   aws.String(clusterID),
+		NodegroupName: aws.String(nodeGroupName),
+	})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	asgNames := []*string{}
+	if output.Nodegroup.Resources != nil {
+		for _, asg := range output.Nodegroup.Resources.AutoScalingGroups {
+			asgNames = append(asgNames, asg.Name)
+		}
+	}
+	if len(asgNames) == 0 {
+		return nil, errors.WithStackTrace(NodeGroupLaunchTemplateNotFoundError{NodeGroupName: nodeGroupName})
+	}
+
+	asgOutput, err := asgSvc.DescribeAutoScalingGroupsWithContext(ctx, &autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: asgNames})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	// EKS managed node groups have a single Auto Scaling Group, but this is not guaranteed by the API, so the instances
+	// are compared against the launch template of the Auto Scaling Group they are in.
+	versions := &NodeGroupVersions{NodeGroupName: nodeGroupName, Nodes: []NodeLaunchTemplateVersion{}}
+	instances := map[string]*autoscaling.Instance{}
+	instanceGroups := map[string]*autoscaling.Group{}
+	currentVersions := map[string]string{}
+	for _, asg := range asgOutput.AutoScalingGroups {
+		spec := currentLaunchTemplate(asg)
+		if spec == nil {
+			return nil, errors.WithStackTrace(NodeGroupLaunchTemplateNotFoundError{NodeGroupName: nodeGroupName})
+		}
+		version, err := resolveLaunchTemplateVersion(ec2Svc, spec)
+		if err != nil {
+			return nil, err
+		}
+		asgName := aws.StringValue(asg.AutoScalingGroupName)
+		currentVersions[asgName] = version
+		for _, inst := range asg.Instances {
+			instances[aws.StringValue(inst.InstanceId)] = inst
+			instanceGroups[aws.StringValue(inst.InstanceId)] = asg
+		}
+		if versions.LaunchTemplateID == "" {
+			versions.LaunchTemplateID = launchTemplateIdentifier(spec.LaunchTemplateId, spec.LaunchTemplateName)
+			versions.LaunchTemplateVersion = version
+		}
+	}
+	if len(currentVersions) == 0 {
+		return nil, errors.WithStackTrace(NodeGroupLaunchTemplateNotFoundError{NodeGroupName: nodeGroupName})
+	}
+	logger.Infof("Node group is on version %s of launch template %s", versions.LaunchTemplateVersion, versions.LaunchTemplateID)
+
+	nodes, err := listNodeGroupNodes(ctx, client, nodeGroupName, nodeSelector)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		// Nodes that can not be mapped to an instance of the node group are reported as stale with empty launch
+		// template fields, as there is no telling what they were launched with.
+		nodeVersion := NodeLaunchTemplateVersion{NodeName: node.Name, Stale: true}
+		instanceID, err := instanceIDFromProviderID(node.Name, node.Spec.ProviderID)
+		if err != nil {
+			logger.Warnf("Could not find the EC2 instance of node %s: %s", node.Name, err)
+			versions.Nodes = append(versions.Nodes, nodeVersion)
+			continue
+		}
+		nodeVersion.InstanceID = instanceID
+
+		inst, found := instances[instanceID]
+		if !found {
+			logger.Warnf("EC2 instance %s of node %s is not in the Auto Scaling Group of the node group", instanceID, node.Name)
+			versions.Nodes = append(versions.Nodes, nodeVersion)
+			continue
+		}
+		if inst.LaunchTemplate != nil {
+			nodeVersion.LaunchTemplateID = launchTemplateIdentifier(inst.LaunchTemplate.LaunchTemplateId, inst.LaunchTemplate.LaunchTemplateName)
+			nodeVersion.LaunchTemplateVersion = aws.StringValue(inst.LaunchTemplate.Version)
+		}
+		asg := instanceGroups[instanceID]
+		currentVersion := currentVersions[aws.StringValue(asg.AutoScalingGroupName)]
+		nodeVersion.Stale = !isInstanceUpToDate(inst, asg, currentLaunchTemplate(asg), currentVersion)
+		versions.Nodes = append(versions.Nodes, nodeVersion)
+	}
+	sort.Slice(versions.Nodes, func(i, j int) bool { return versions.Nodes[i].NodeName < versions.Nodes[j].NodeName })
+
+	if stale := versions.StaleNodeNames(); len(stale) > 0 {
+		logger.Infof("%d of %d nodes are not on the current launch template version", len(stale), len(versions.Nodes))
+	} else {
+		logger.Infof("All %d nodes are on the current launch template version", len(versions.Nodes))
+	}
+	return versions, nil
+}
+
+// launchTemplateIdentifier returns the ID of a launch template, or its name if the ID is not set.
+func launchTemplateIdentifier(id *string, name *string) string {
+	if aws.StringValue(id) != "" {
+		return aws.StringValue(id)
+	}
+	return aws.StringValue(name)
+}
@@ -0,0 +1,137 @@
+package eks
+
+import (
+	"context"
+	"testing"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeNodeGroupASGEKS is a stub of the EKS API with a node group backed by the given Auto Scaling Groups.
+type fakeNodeGroupASGEKS struct {
+	eksiface.EKSAPI
+
+	asgNames []string
+}
+
+func (fake *fakeNodeGroupASGEKS) DescribeNodegroupWithContext(ctx awsgo.Context, input *eks.DescribeNodegroupInput, opts ...request.Option) (*eks.DescribeNodegroupOutput, error) {
+	resources := &eks.NodegroupResources{}
+	for _, asgName := range fake.asgNames {
+		resources.AutoScalingGroups = append(resources.AutoScalingGroups, &eks.AutoScalingGroup{Name: awsgo.String(asgName)})
+	}
+	return &eks.DescribeNodegroupOutput{Nodegroup: &eks.Nodegroup{NodegroupName: input.NodegroupName, Resources: resources}}, nil
+}
+
+// fakeAutoScaling is a stub of the Auto Scaling API with the given Auto Scaling Groups.
+type fakeAutoScaling struct {
+	autoscalingiface.AutoScalingAPI
+
+	groups []*autoscaling.Group
+}
+
+func (fake *fakeAutoScaling) DescribeAutoScalingGroupsWithContext(ctx awsgo.Context, input *autoscaling.DescribeAutoScalingGroupsInput, opts ...request.Option) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	return &autoscaling.DescribeAutoScalingGroupsOutput{AutoScalingGroups: fake.groups}, nil
+}
+
+// fakeLaunchTemplatesEC2 is a stub of the EC2 API with a single launch template at the given latest version.
+type fakeLaunchTemplatesEC2 struct {
+	ec2iface.EC2API
+
+	latestVersion int64
+}
+
+func (fake *fakeLaunchTemplatesEC2) DescribeLaunchTemplates(input *ec2.DescribeLaunchTemplatesInput) (*ec2.DescribeLaunchTemplatesOutput, error) {
+	return &ec2.DescribeLaunchTemplatesOutput{
+		LaunchTemplates: []*ec2.LaunchTemplate{{
+			LaunchTemplateId:     awsgo.String("lt-123"),
+			LatestVersionNumber:  awsgo.Int64(fake.latestVersion),
+			DefaultVersionNumber: awsgo.Int64(1),
+		}},
+	}, nil
+}
+
+func TestListNodeGroupVersions(t *testing.T) {
+	t.Parallel()
+
+	asg := &autoscaling.Group{
+		AutoScalingGroupName: awsgo.String("eks-workers"),
+		LaunchTemplate:       &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: awsgo.String("lt-123"), Version: awsgo.String("$Latest")},
+		Instances: []*autoscaling.Instance{
+			newTestASGInstance("i-current", "3"),
+			newTestASGInstance("i-stale", "2"),
+		},
+	}
+	client := fake.NewSimpleClientset(
+		newTestNodeGroupNode("node-current", "aws:///us-east-1a/i-current"),
+		newTestNodeGroupNode("node-stale", "aws:///us-east-1a/i-stale"),
+		newTestNodeGroupNode("node-unknown", "aws:///us-east-1a/i-gone"),
+	)
+
+	versions, err := listNodeGroupVersions(
+		context.Background(),
+		&fakeNodeGroupASGEKS{asgNames: []string{"eks-workers"}},
+		&fakeAutoScaling{groups: []*autoscaling.Group{asg}},
+		&fakeLaunchTemplatesEC2{latestVersion: 3},
+		client,
+		"prod",
+		"workers",
+		nil,
+	)
+	require.NoError(t, err)
+	assert.Equal(t, &NodeGroupVersions{
+		NodeGroupName:         "workers",
+		LaunchTemplateID:      "lt-123",
+		LaunchTemplateVersion: "3",
+		Nodes: []NodeLaunchTemplateVersion{
+			{NodeName: "node-current", InstanceID: "i-current", LaunchTemplateID: "lt-123", LaunchTemplateVersion: "3", Stale: false},
+			{NodeName: "node-stale", InstanceID: "i-stale", LaunchTemplateID: "lt-123", LaunchTemplateVersion: "2", Stale: true},
+			{NodeName: "node-unknown", InstanceID: "i-gone", Stale: true},
+		},
+	}, versions)
+	assert.Equal(t, []string{"node-stale", "node-unknown"}, versions.StaleNodeNames())
+}
+
+func TestListNodeGroupVersionsWithoutLaunchTemplate(t *testing.T) {
+	t.Parallel()
+
+	asg := &autoscaling.Group{AutoScalingGroupName: awsgo.String("eks-workers"), LaunchConfigurationName: awsgo.String("workers")}
+	_, err := listNodeGroupVersions(
+		context.Background(),
+		&fakeNodeGroupASGEKS{asgNames: []string{"eks-workers"}},
+		&fakeAutoScaling{groups: []*autoscaling.Group{asg}},
+		&fakeLaunchTemplatesEC2{},
+		fake.NewSimpleClientset(),
+		"prod",
+		"workers",
+		nil,
+	)
+	require.Error(t, err)
+	assert.Equal(t, NodeGroupLaunchTemplateNotFoundError{NodeGroupName: "workers"}, errors.Unwrap(err))
+}
+
+func newTestASGInstance(instanceID string, version string) *autoscaling.Instance {
+	return &autoscaling.Instance{
+		InstanceId:     awsgo.String(instanceID),
+		LaunchTemplate: &autoscaling.LaunchTemplateSpecification{LaunchTemplateId: awsgo.String("lt-123"), Version: awsgo.String(version)},
+	}
+}
+
+func newTestNodeGroupNode(name string, providerID string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{nodeGroupLabelKey: "workers"}},
+		Spec:       corev1.NodeSpec{ProviderID: providerID},
+	}
+}
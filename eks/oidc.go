@@ -2,6 +2,8 @@ package eks
 
 import (
 	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/hex"
 	"encoding/json"
 	"io/ioutil"
@@ -12,6 +14,7 @@ import (
 
 	"github.com/gruntwork-io/go-commons/errors"
 
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
 	"github.com/gruntwork-io/kubergrunt/logging"
 )
 
@@ -25,7 +28,8 @@ type PartialOIDCConfig struct {
 
 // GetOIDCThumbprint will retrieve the thumbprint of the root CA for the OIDC Provider identified by the issuer URL.
 // This is done by first looking up the domain where the keys are provided, and then looking up the TLS certificate
-// chain for that domain.
+// chain for that domain. The returned thumbprint is the hex encoded SHA1 fingerprint of the top of the chain, which is
+// the format expected by the IAM OIDC provider resource (e.g., `aws_iam_openid_connect_provider` in Terraform).
 func GetOIDCThumbprint(issuerURL string) (*Thumbprint, error) {
 	logger := logging.GetProjectLogger()
 	logger.Infof("Retrieving OIDC Issuer (%s) CA Thumbprint", issuerURL)
@@ -36,13 +40,17 @@ func GetOIDCThumbprint(issuerURL string) (*Thumbprint, error) {
 		return nil, err
 	}
 
-	jwksURL, err := getJwksURL(openidConfigURL)
+	httpClient, err := eksawshelper.NewHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	jwksURL, err := getJwksURL(httpClient, openidConfigURL)
 	if err != nil {
 		logger.Errorf("Error retrieving JWKS URI from Issuer Config URL %s", openidConfigURL)
 		return nil, err
 	}
 
-	thumbprint, err := getThumbprint(jwksURL)
+	thumbprint, err := getThumbprint(jwksURL, &tls.Config{})
 	if err != nil {
 		logger.Errorf("Error retrieving root CA Thumbprint for JWKS URL %s", jwksURL)
 		return nil, err
@@ -63,14 +71,19 @@ func getOIDCConfigURL(issuerURL string) (string, error) {
 	return openidConfigURL, nil
 }
 
-// getJwksURL returns the configured URL where the JWKS keys can be retrieved from the provider.
-func getJwksURL(openidConfigURL string) (string, error) {
-	resp, err := http.Get(openidConfigURL)
+// getJwksURL returns the configured URL where the JWKS keys can be retrieved from the provider. Redirects are followed
+// when fetching the config, so that issuers served behind a redirect are supported. Relative JWKS URIs are resolved
+// against the URL the config was ultimately served from.
+func getJwksURL(client *http.Client, openidConfigURL string) (string, error) {
+	resp, err := client.Get(openidConfigURL)
 	if err != nil {
 		return "", errors.WithStackTrace(err)
 	}
 
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.WithStackTrace(OIDCConfigRequestError{URL: openidConfigURL, StatusCode: resp.StatusCode})
+	}
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return "", errors.WithStackTrace(err)
@@ -80,12 +93,22 @@ func getJwksURL(openidConfigURL string) (string, error) {
 	if err := json.Unmarshal(body, &partialOIDCConfig); err != nil {
 		return "", errors.WithStackTrace(err)
 	}
+	if partialOIDCConfig.JwksURI == "" {
+		return "", errors.WithStackTrace(MissingJwksURIError{URL: openidConfigURL})
+	}
 
-	return partialOIDCConfig.JwksURI, nil
+	jwksURL, err := resp.Request.URL.Parse(partialOIDCConfig.JwksURI)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return jwksURL.String(), nil
 }
 
-// getThumbprint will get the root CA from TLS certificate chain for the FQDN of the JWKS URL.
-func getThumbprint(jwksURL string) (string, error) {
+// getThumbprint will get the root CA from TLS certificate chain for the FQDN of the JWKS URL. We connect directly over
+// TLS rather than making an HTTP request, so that the certificate chain is always the one for the JWKS host even if it
+// redirects elsewhere. The chain is then walked up to the top using the verified chains from the handshake, as servers
+// are not required to present the root CA. The tlsConfig can be used to customize the trusted root CAs.
+func getThumbprint(jwksURL string, tlsConfig *tls.Config) (string, error) {
 	parsedURL, err := url.Parse(jwksURL)
 	if err != nil {
 		return "", errors.WithStackTrace(err)
@@ -95,21 +118,37 @@ func getThumbprint(jwksURL string) (string, error) {
 		hostname = net.JoinHostPort(hostname, "443")
 	}
 
-	resp, err := http.Get("https://" + hostname)
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.ServerName = parsedURL.Hostname()
+	conn, err := tls.Dial("tcp", hostname, tlsConfig)
 	if err != nil {
 		return "", errors.WithStackTrace(err)
 	}
-	defer resp.Body.Close()
+	defer conn.Close()
 
-	peerCerts := resp.TLS.PeerCertificates
-	numCerts := len(peerCerts)
-	if numCerts == 0 {
+	root := topOfChain(conn.ConnectionState())
+	if root == nil {
 		return "", errors.WithStackTrace(NoPeerCertificatesError{jwksURL})
 	}
+	return sha1Hash(root.Raw), nil
+}
+
+// topOfChain returns the certificate at the top of the certificate chain for the TLS connection. This is the root CA of
+// the first verified chain, falling back to the last certificate presented by the peer when the chain was not verified.
+// Returns nil if there are no certificates.
+func topOfChain(state tls.ConnectionState) *x509.Certificate {
+	for _, chain := range state.VerifiedChains {
+		if len(chain) > 0 {
+			return chain[len(chain)-1]
+		}
+	}
 
+	numCerts := len(state.PeerCertificates)
+	if numCerts == 0 {
+		return nil
+	}
 	// root CA certificate is the last one in the list
-	root := peerCerts[numCerts-1]
-	return sha1Hash(root.Raw), nil
+	return state.PeerCertificates[numCerts-1]
 }
 
 // sha1Hash computes the SHA1 of the byte array and returns the hex encoding as a string.
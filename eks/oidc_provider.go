@@ -0,0 +1,319 @@
+package eks
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/gruntwork-io/go-commons/errors"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// oidcProviderClientID is the audience of the tokens issued to Service Accounts for IAM Roles for Service Accounts.
+const oidcProviderClientID = "sts.amazonaws.com"
+
+// OIDCProviderAssociation describes the IAM OIDC provider that is associated with an EKS cluster.
+type OIDCProviderAssociation struct {
+	// ProviderArn is the ARN of the IAM OIDC provider for the cluster's OIDC issuer.
+	ProviderArn string `json:"provider_arn"`
+
+	// Created is true if the IAM OIDC provider was created, and false if it already existed.
+	Created bool `json:"created"`
+}
+
+// OIDCDescription describes the OIDC issuer of an EKS cluster and the IAM OIDC provider for it, which are needed for IAM
+// Roles for Service Accounts.
+type OIDCDescription struct {
+	// ClusterArn is the ARN of the EKS cluster.
+	ClusterArn string `json:"cluster_arn"`
+
+	// IssuerURL is the URL of the OIDC issuer of the cluster, as returned by DescribeCluster.
+	IssuerURL string `json:"issuer_url"`
+
+	// ProviderArn is the ARN that the IAM OIDC provider for the issuer has, or would have once it is created.
+	ProviderArn string `json:"provider_arn"`
+
+	// ProviderExists is true if the IAM OIDC provider exists.
+	ProviderExists bool `json:"provider_exists"`
+
+	// Thumbprints lists the thumbprints of the CA certificates that the IAM OIDC provider trusts. Empty if the provider
+	// does not exist.
+	Thumbprints []string `json:"thumbprints"`
+
+	// ClientIDs lists the audiences that the IAM OIDC provider accepts, which must include sts.amazonaws.com for IAM
+	// Roles for Service Accounts. Empty if the provider does not exist.
+	ClientIDs []string `json:"client_ids"`
+}
+
+// DescribeOIDC looks up the OIDC issuer of the EKS cluster, and whether the IAM OIDC provider for it exists along with
+// the thumbprints and audiences it trusts. This is useful to troubleshoot the authentication of Service Accounts with
+// IAM Roles for Service Accounts. Returns an OIDCIssuerNotFoundError if the cluster has no OIDC issuer.
+func DescribeOIDC(clusterArn string) (*OIDCDescription, error) {
+	logger := logging.GetProjectLogger()
+
+	clusterInfo, err := eksawshelper.GetClusterInfo(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+	issuerURL := clusterInfo.OIDCIssuerURL
+	if issuerURL == "" {
+		return nil, errors.WithStackTrace(OIDCIssuerNotFoundError{ClusterArn: clusterArn})
+	}
+	logger.Infof("Found OIDC issuer %s for EKS cluster %s", issuerURL, clusterArn)
+
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	iamSvc := iam.New(sess)
+	logger.Infof("Successfully authenticated with AWS")
+
+	return describeOIDC(iamSvc, clusterArn, issuerURL)
+}
+
+// describeOIDC looks up the IAM OIDC provider for the issuer URL of the given cluster. The provider is looked up by the
+// ARN derived from the cluster ARN and the issuer URL, so that a missing provider is reported along with the ARN it
+// should have.
+func describeOIDC(iamSvc iamiface.IAMAPI, clusterArn string, issuerURL string) (*OIDCDescription, error) {
+	providerArn, err := oidcProviderArnForIssuer(clusterArn, issuerURL)
+	if err != nil {
+		return nil, err
+	}
+	description := &OIDCDescription{
+		ClusterArn:  clusterArn,
+		IssuerURL:   issuerURL,
+		ProviderArn: providerArn,
+		Thumbprints: []string{},
+		ClientIDs:   []string{},
+	}
+
+	output, err := iamSvc.GetOpenIDConnectProvider(&iam.GetOpenIDConnectProviderInput{OpenIDConnectProviderArn: aws.String(providerArn)})
+	if err != nil {
+		if awsErr, isAwsErr := err.(awserr.Error); isAwsErr && awsErr.Code() == iam.ErrCodeNoSuchEntityException {
+			return description, nil
+		}
+		return nil, errors.WithStackTrace(err)
+	}
+	description.ProviderExists = true
+	description.Thumbprints = append(description.Thumbprints, aws.StringValueSlice(output.ThumbprintList)...)
+	description.ClientIDs = append(description.ClientIDs, aws.StringValueSlice(output.ClientIDList)...)
+	return description, nil
+}
+
+// oidcProviderArnForIssuer returns the ARN of the IAM OIDC provider for the given issuer URL, in the partition and
+// account of the given EKS cluster. IAM OIDC provider ARNs end with the issuer URL without the scheme.
+func oidcProviderArnForIssuer(clusterArn string, issuerURL string) (string, error) {
+	parsedClusterArn, err := arn.Parse(clusterArn)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	providerArn := arn.ARN{
+		Partition: parsedClusterArn.Partition,
+		Service:   "iam",
+		AccountID: parsedClusterArn.AccountID,
+		Resource:  "oidc-provider/" + strings.TrimSuffix(strings.TrimPrefix(issuerURL, "https://"), "/"),
+	}
+	return providerArn.String(), nil
+}
+
+// OIDCThumbprintRotation describes the outcome of RotateOIDCThumbprint.
+type OIDCThumbprintRotation struct {
+	// ProviderArn is the ARN of the IAM OIDC provider for the cluster's OIDC issuer.
+	ProviderArn string `json:"provider_arn"`
+
+	// OldThumbprints lists the thumbprints that the IAM OIDC provider trusted before the rotation.
+	OldThumbprints []string `json:"old_thumbprints"`
+
+	// NewThumbprint is the current thumbprint of the root CA of the OIDC issuer.
+	NewThumbprint string `json:"new_thumbprint"`
+
+	// Changed is true if the thumbprints of the IAM OIDC provider were updated, and false if the provider already
+	// trusted the current thumbprint.
+	Changed bool `json:"changed"`
+}
+
+// RotateOIDCThumbprint recomputes the thumbprint of the root CA of the OIDC issuer of the EKS cluster, and updates the
+// IAM OIDC provider for the issuer to trust only that thumbprint if it does not already trust it. This is idempotent,
+// so that it can be run on a schedule to catch the rotations of the CA of the issuer. Returns an
+// OIDCProviderNotFoundError if there is no IAM OIDC provider for the issuer.
+func RotateOIDCThumbprint(clusterArn string) (*OIDCThumbprintRotation, error) {
+	logger := logging.GetProjectLogger()
+
+	clusterInfo, err := eksawshelper.GetClusterInfo(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+	issuerURL := clusterInfo.OIDCIssuerURL
+	if issuerURL == "" {
+		return nil, errors.WithStackTrace(OIDCIssuerNotFoundError{ClusterArn: clusterArn})
+	}
+	logger.Infof("Found OIDC issuer %s for EKS cluster %s", issuerURL, clusterArn)
+
+	thumbprint, err := GetOIDCThumbprint(issuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	iamSvc := iam.New(sess)
+	logger.Infof("Successfully authenticated with AWS")
+
+	return rotateOIDCThumbprint(iamSvc, clusterArn, issuerURL, thumbprint.Thumbprint)
+}
+
+// rotateOIDCThumbprint updates the IAM OIDC provider for the issuer URL of the given cluster to trust only the given
+// thumbprint, unless it already trusts it.
+func rotateOIDCThumbprint(iamSvc iamiface.IAMAPI, clusterArn string, issuerURL string, thumbprint string) (*OIDCThumbprintRotation, error) {
+	logger := logging.GetProjectLogger()
+
+	description, err := describeOIDC(iamSvc, clusterArn, issuerURL)
+	if err != nil {
+		return nil, err
+	}
+	if !description.ProviderExists {
+		return nil, errors.WithStackTrace(OIDCProviderNotFoundError{
+			ClusterArn:  clusterArn,
+			IssuerURL:   issuerURL,
+			ProviderArn: description.ProviderArn,
+		})
+	}
+	rotation := &OIDCThumbprintRotation{
+		ProviderArn:    description.ProviderArn,
+		OldThumbprints: description.Thumbprints,
+		NewThumbprint:  thumbprint,
+	}
+
+	// The thumbprints are hex encoded, so they are compared case insensitively.
+	for _, oldThumbprint := range description.Thumbprints {
+		if strings.EqualFold(oldThumbprint, thumbprint) {
+			logger.Infof("No change: IAM OIDC provider %s already trusts the current thumbprint %s", description.ProviderArn, thumbprint)
+			return rotation, nil
+		}
+	}
+
+	logger.Infof(
+		"Updating the thumbprints of IAM OIDC provider %s from %s to %s",
+		description.ProviderArn,
+		strings.Join(description.Thumbprints, ", "),
+		thumbprint,
+	)
+	_, err = iamSvc.UpdateOpenIDConnectProviderThumbprint(&iam.UpdateOpenIDConnectProviderThumbprintInput{
+		OpenIDConnectProviderArn: aws.String(description.ProviderArn),
+		ThumbprintList:           aws.StringSlice([]string{thumbprint}),
+	})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	logger.Infof("Successfully updated the thumbprints of IAM OIDC provider %s", description.ProviderArn)
+	rotation.Changed = true
+	return rotation, nil
+}
+
+// AssociateOIDCProvider registers the OIDC issuer of the EKS cluster as an IAM OIDC provider, which is necessary to use
+// IAM Roles for Service Accounts. This is idempotent: the provider is only created if there is no existing IAM OIDC
+// provider for the issuer URL.
+func AssociateOIDCProvider(clusterArn string) (*OIDCProviderAssociation, error) {
+	logger := logging.GetProjectLogger()
+
+	clusterInfo, err := eksawshelper.GetClusterInfo(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+	issuerURL := clusterInfo.OIDCIssuerURL
+	if issuerURL == "" {
+		return nil, errors.WithStackTrace(OIDCIssuerNotFoundError{ClusterArn: clusterArn})
+	}
+	logger.Infof("Found OIDC issuer %s for EKS cluster %s", issuerURL, clusterArn)
+
+	thumbprint, err := GetOIDCThumbprint(issuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	iamSvc := iam.New(sess)
+	logger.Infof("Successfully authenticated with AWS")
+
+	return associateOIDCProvider(iamSvc, issuerURL, thumbprint.Thumbprint)
+}
+
+// associateOIDCProvider creates the IAM OIDC provider for the issuer URL with the given thumbprint, unless one already
+// exists.
+func associateOIDCProvider(iamSvc iamiface.IAMAPI, issuerURL string, thumbprint string) (*OIDCProviderAssociation, error) {
+	logger := logging.GetProjectLogger()
+
+	providerArn, err := findOIDCProviderArn(iamSvc, issuerURL)
+	if err != nil {
+		return nil, err
+	}
+	if providerArn != "" {
+		logger.Infof("IAM OIDC provider %s already exists for issuer %s", providerArn, issuerURL)
+		return &OIDCProviderAssociation{ProviderArn: providerArn, Created: false}, nil
+	}
+
+	logger.Infof("Creating IAM OIDC provider for issuer %s", issuerURL)
+	output, err := iamSvc.CreateOpenIDConnectProvider(&iam.CreateOpenIDConnectProviderInput{
+		Url:            aws.String(issuerURL),
+		ClientIDList:   aws.StringSlice([]string{oidcProviderClientID}),
+		ThumbprintList: aws.StringSlice([]string{thumbprint}),
+	})
+	if err != nil {
+		// The provider may have been created concurrently since we last looked it up, in which case we report it as
+		// already present.
+		if awsErr, isAwsErr := err.(awserr.Error); isAwsErr && awsErr.Code() == iam.ErrCodeEntityAlreadyExistsException {
+			providerArn, lookupErr := findOIDCProviderArn(iamSvc, issuerURL)
+			if lookupErr != nil {
+				return nil, lookupErr
+			}
+			logger.Infof("IAM OIDC provider %s already exists for issuer %s", providerArn, issuerURL)
+			return &OIDCProviderAssociation{ProviderArn: providerArn, Created: false}, nil
+		}
+		return nil, errors.WithStackTrace(err)
+	}
+
+	providerArn = aws.StringValue(output.OpenIDConnectProviderArn)
+	logger.Infof("Successfully created IAM OIDC provider %s", providerArn)
+	return &OIDCProviderAssociation{ProviderArn: providerArn, Created: true}, nil
+}
+
+// findOIDCProviderArn returns the ARN of the IAM OIDC provider for the given issuer URL, or an empty string if there is
+// none. IAM OIDC provider ARNs end with the issuer URL without the scheme, so we can match on the ARN directly without
+// looking up the details of each provider.
+func findOIDCProviderArn(iamSvc iamiface.IAMAPI, issuerURL string) (string, error) {
+	output, err := iamSvc.ListOpenIDConnectProviders(&iam.ListOpenIDConnectProvidersInput{})
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	arnSuffix := ":oidc-provider/" + strings.TrimSuffix(strings.TrimPrefix(issuerURL, "https://"), "/")
+	for _, provider := range output.OpenIDConnectProviderList {
+		providerArn := aws.StringValue(provider.Arn)
+		if strings.HasSuffix(providerArn, arnSuffix) {
+			return providerArn, nil
+		}
+	}
+	return "", nil
+}
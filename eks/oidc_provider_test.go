@@ -0,0 +1,200 @@
+package eks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIAM is an in memory implementation of the IAM OIDC provider API calls used by associateOIDCProvider,
+// describeOIDC and rotateOIDCThumbprint. All the providers trust the same thumbprints.
+type fakeIAM struct {
+	iamiface.IAMAPI
+
+	providerArns []string
+	thumbprints  []string
+	createInputs []*iam.CreateOpenIDConnectProviderInput
+	updateInputs []*iam.UpdateOpenIDConnectProviderThumbprintInput
+}
+
+func (fake *fakeIAM) ListOpenIDConnectProviders(input *iam.ListOpenIDConnectProvidersInput) (*iam.ListOpenIDConnectProvidersOutput, error) {
+	output := &iam.ListOpenIDConnectProvidersOutput{}
+	for _, providerArn := range fake.providerArns {
+		output.OpenIDConnectProviderList = append(output.OpenIDConnectProviderList, &iam.OpenIDConnectProviderListEntry{Arn: aws.String(providerArn)})
+	}
+	return output, nil
+}
+
+func (fake *fakeIAM) CreateOpenIDConnectProvider(input *iam.CreateOpenIDConnectProviderInput) (*iam.CreateOpenIDConnectProviderOutput, error) {
+	fake.createInputs = append(fake.createInputs, input)
+	providerArn := "arn:aws:iam::123456789012:oidc-provider/" + aws.StringValue(input.Url)[len("https://"):]
+	fake.providerArns = append(fake.providerArns, providerArn)
+	return &iam.CreateOpenIDConnectProviderOutput{OpenIDConnectProviderArn: aws.String(providerArn)}, nil
+}
+
+func (fake *fakeIAM) GetOpenIDConnectProvider(input *iam.GetOpenIDConnectProviderInput) (*iam.GetOpenIDConnectProviderOutput, error) {
+	for _, providerArn := range fake.providerArns {
+		if providerArn == aws.StringValue(input.OpenIDConnectProviderArn) {
+			return &iam.GetOpenIDConnectProviderOutput{
+				ClientIDList:   aws.StringSlice([]string{oidcProviderClientID}),
+				ThumbprintList: aws.StringSlice(fake.thumbprints),
+			}, nil
+		}
+	}
+	return nil, awserr.New(iam.ErrCodeNoSuchEntityException, "OpenIDConnect Provider not found", nil)
+}
+
+func (fake *fakeIAM) UpdateOpenIDConnectProviderThumbprint(input *iam.UpdateOpenIDConnectProviderThumbprintInput) (*iam.UpdateOpenIDConnectProviderThumbprintOutput, error) {
+	fake.updateInputs = append(fake.updateInputs, input)
+	fake.thumbprints = aws.StringValueSlice(input.ThumbprintList)
+	return &iam.UpdateOpenIDConnectProviderThumbprintOutput{}, nil
+}
+
+const (
+	testOIDCThumbprint    = "9e99a48a9960b14926bb7f3b02e22da2b0ab7280"
+	testOIDCNewThumbprint = "06b25927c42a721631c1efd9431e648fa62e1e39"
+)
+
+func TestDescribeOIDC(t *testing.T) {
+	t.Parallel()
+
+	const clusterArn = "arn:aws:eks:us-east-1:123456789012:cluster/prod"
+	const issuerURL = "https://oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE"
+	const expectedArn = "arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE"
+
+	missing, err := describeOIDC(&fakeIAM{}, clusterArn, issuerURL)
+	require.NoError(t, err)
+	assert.Equal(t, &OIDCDescription{
+		ClusterArn:  clusterArn,
+		IssuerURL:   issuerURL,
+		ProviderArn: expectedArn,
+		Thumbprints: []string{},
+		ClientIDs:   []string{},
+	}, missing)
+
+	existing, err := describeOIDC(&fakeIAM{providerArns: []string{expectedArn}, thumbprints: []string{testOIDCThumbprint}}, clusterArn, issuerURL)
+	require.NoError(t, err)
+	assert.Equal(t, &OIDCDescription{
+		ClusterArn:     clusterArn,
+		IssuerURL:      issuerURL,
+		ProviderArn:    expectedArn,
+		ProviderExists: true,
+		Thumbprints:    []string{testOIDCThumbprint},
+		ClientIDs:      []string{oidcProviderClientID},
+	}, existing)
+}
+
+func TestRotateOIDCThumbprint(t *testing.T) {
+	t.Parallel()
+
+	const clusterArn = "arn:aws:eks:us-east-1:123456789012:cluster/prod"
+	const issuerURL = "https://oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE"
+	const providerArn = "arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE"
+
+	testCases := []struct {
+		name            string
+		thumbprints     []string
+		expectedChanged bool
+	}{
+		{"stale", []string{testOIDCThumbprint}, true},
+		{"current", []string{testOIDCThumbprint, testOIDCNewThumbprint}, false},
+		{"current-uppercase", []string{strings.ToUpper(testOIDCNewThumbprint)}, false},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeIAM{providerArns: []string{providerArn}, thumbprints: testCase.thumbprints}
+			rotation, err := rotateOIDCThumbprint(fake, clusterArn, issuerURL, testOIDCNewThumbprint)
+			require.NoError(t, err)
+			assert.Equal(t, &OIDCThumbprintRotation{
+				ProviderArn:    providerArn,
+				OldThumbprints: testCase.thumbprints,
+				NewThumbprint:  testOIDCNewThumbprint,
+				Changed:        testCase.expectedChanged,
+			}, rotation)
+
+			if testCase.expectedChanged {
+				require.Len(t, fake.updateInputs, 1)
+				assert.Equal(t, providerArn, aws.StringValue(fake.updateInputs[0].OpenIDConnectProviderArn))
+				assert.Equal(t, []string{testOIDCNewThumbprint}, aws.StringValueSlice(fake.updateInputs[0].ThumbprintList))
+			} else {
+				assert.Empty(t, fake.updateInputs)
+			}
+		})
+	}
+}
+
+func TestRotateOIDCThumbprintRequiresProvider(t *testing.T) {
+	t.Parallel()
+
+	const clusterArn = "arn:aws:eks:us-east-1:123456789012:cluster/prod"
+	const issuerURL = "https://oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE"
+
+	_, err := rotateOIDCThumbprint(&fakeIAM{}, clusterArn, issuerURL, testOIDCNewThumbprint)
+	require.Error(t, err)
+	assert.Equal(t, OIDCProviderNotFoundError{
+		ClusterArn:  clusterArn,
+		IssuerURL:   issuerURL,
+		ProviderArn: "arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE",
+	}, errors.Unwrap(err))
+}
+
+func TestOIDCProviderArnForIssuerUsesClusterPartition(t *testing.T) {
+	t.Parallel()
+
+	providerArn, err := oidcProviderArnForIssuer("arn:aws-cn:eks:cn-north-1:123456789012:cluster/prod", "https://oidc.eks.cn-north-1.amazonaws.com.cn/id/EXAMPLE/")
+	require.NoError(t, err)
+	assert.Equal(t, "arn:aws-cn:iam::123456789012:oidc-provider/oidc.eks.cn-north-1.amazonaws.com.cn/id/EXAMPLE", providerArn)
+}
+
+func TestAssociateOIDCProvider(t *testing.T) {
+	t.Parallel()
+
+	const issuerURL = "https://oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE"
+	const expectedArn = "arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/EXAMPLE"
+
+	testCases := []struct {
+		name            string
+		existingArns    []string
+		expectedCreated bool
+	}{
+		{"no-providers", nil, true},
+		{"other-provider", []string{"arn:aws:iam::123456789012:oidc-provider/oidc.eks.us-east-1.amazonaws.com/id/OTHER"}, true},
+		{"already-exists", []string{expectedArn}, false},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			fake := &fakeIAM{providerArns: testCase.existingArns}
+			association, err := associateOIDCProvider(fake, issuerURL, "thumbprint")
+			require.NoError(t, err)
+			assert.Equal(t, expectedArn, association.ProviderArn)
+			assert.Equal(t, testCase.expectedCreated, association.Created)
+
+			if testCase.expectedCreated {
+				require.Len(t, fake.createInputs, 1)
+				assert.Equal(t, []string{"thumbprint"}, aws.StringValueSlice(fake.createInputs[0].ThumbprintList))
+				assert.Equal(t, []string{oidcProviderClientID}, aws.StringValueSlice(fake.createInputs[0].ClientIDList))
+			} else {
+				assert.Empty(t, fake.createInputs)
+			}
+		})
+	}
+}
@@ -1,9 +1,22 @@
 package eks
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetOIDCConfigURL(t *testing.T) {
@@ -48,7 +61,7 @@ func TestGetOIDCConfigURL(t *testing.T) {
 func TestGetJwksURL(t *testing.T) {
 	const configURL = "https://accounts.google.com/.well-known/openid-configuration"
 	const expected = "https://www.googleapis.com/oauth2/v3/certs"
-	jwksURL, err := getJwksURL(configURL)
+	jwksURL, err := getJwksURL(http.DefaultClient, configURL)
 	assert.NoError(t, err)
 	assert.Equal(t, jwksURL, expected)
 }
@@ -56,7 +69,91 @@ func TestGetJwksURL(t *testing.T) {
 func TestGetThumbprint(t *testing.T) {
 	const jwksURL = "https://www.googleapis.com/oauth2/v3/certs"
 	const expected = "08745487e891c19e3078c1f2a07e452950ef36f6"
-	thumbprint, err := getThumbprint(jwksURL)
+	thumbprint, err := getThumbprint(jwksURL, &tls.Config{})
 	assert.NoError(t, err)
 	assert.Equal(t, expected, thumbprint)
 }
+
+func TestGetJwksURLFollowsRedirects(t *testing.T) {
+	t.Parallel()
+
+	configServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"issuer": "https://example.com", "jwks_uri": "/keys"}`)
+	}))
+	defer configServer.Close()
+	issuerServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, configServer.URL+r.URL.Path, http.StatusFound)
+	}))
+	defer issuerServer.Close()
+
+	configURL, err := getOIDCConfigURL(issuerServer.URL)
+	require.NoError(t, err)
+	jwksURL, err := getJwksURL(http.DefaultClient, configURL)
+	require.NoError(t, err)
+	assert.Equal(t, configServer.URL+"/keys", jwksURL)
+}
+
+func TestGetJwksURLErrorsOnMissingJwksURI(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"issuer": "https://example.com"}`)
+	}))
+	defer server.Close()
+
+	_, err := getJwksURL(http.DefaultClient, server.URL)
+	require.Error(t, err)
+}
+
+func TestGetThumbprintReturnsTopOfChain(t *testing.T) {
+	t.Parallel()
+
+	caKey, caCert := mustCreateTestCert(t, nil, nil, true)
+	leafKey, leafCert := mustCreateTestCert(t, caCert, caKey, false)
+
+	// Only the leaf is presented by the server, so the root CA must be looked up by walking the chain.
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{leafCert.Raw}, PrivateKey: leafKey}},
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(caCert)
+	thumbprint, err := getThumbprint(server.URL+"/keys", &tls.Config{RootCAs: rootCAs})
+	require.NoError(t, err)
+	assert.Equal(t, sha1Hash(caCert.Raw), thumbprint)
+	assert.NotEqual(t, sha1Hash(leafCert.Raw), thumbprint)
+}
+
+// mustCreateTestCert creates a certificate for localhost, signed by the given parent. When parent is nil, the
+// certificate is self signed.
+func mustCreateTestCert(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, isCA bool) (*ecdsa.PrivateKey, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("kubergrunt-test-%s", serialNumber)},
+		NotBefore:             time.Now().Add(-1 * time.Hour),
+		NotAfter:              time.Now().Add(1 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		parent = template
+		parentKey = key
+	}
+
+	certBytes, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(certBytes)
+	require.NoError(t, err)
+	return key, cert
+}
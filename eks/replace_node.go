@@ -0,0 +1,163 @@
+package eks
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/gruntwork-io/go-commons/collections"
+	"github.com/gruntwork-io/go-commons/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// Retry settings for waiting for the replacement instance to launch and join the cluster in ReplaceNode, for a total
+// wait time of 10 minutes per stage.
+const (
+	replaceNodeMaxRetries          = 40
+	replaceNodeSleepBetweenRetries = 15 * time.Second
+)
+
+// ReplaceNode evacuates and replaces a single worker node of the EKS cluster. This will:
+// 1. Look up the EC2 instance of the node from its spec.providerID, and the Auto Scaling Group the instance is in.
+// 2. Cordon and drain the node, respecting PodDisruptionBudgets (see DrainNode and DrainOptions).
+// 3. Detach the instance from the ASG without decrementing the desired capacity, so that the ASG launches a replacement.
+// 4. Terminate the instance.
+// 5. Wait for the replacement instance to join the cluster as a Ready node.
+// Returns the ID of the replacement instance. If draining fails, the node is left cordoned and no instance is touched.
+func ReplaceNode(clusterArn string, nodeName string, opts DrainOptions) (string, error) {
+	logger := logging.GetProjectLogger()
+	logger.Infof("Replacing node %s of EKS cluster %s", nodeName, clusterArn)
+	ctx := context.Background()
+
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return "", err
+	}
+
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	asgSvc := autoscaling.New(sess)
+	ec2Svc := eksawshelper.NewEC2Client(sess)
+	logger.Infof("Successfully authenticated with AWS")
+
+	kubectlOptions := &kubectl.KubectlOptions{EKSClusterArn: clusterArn}
+	client, err := kubectl.GetKubernetesClientFromOptions(kubectlOptions)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	instanceID, err := instanceIDFromProviderID(nodeName, node.Spec.ProviderID)
+	if err != nil {
+		return "", err
+	}
+	asgName, err := asgNameForInstance(asgSvc, instanceID)
+	if err != nil {
+		return "", err
+	}
+	logger.Infof("Node %s is instance %s in ASG %s", nodeName, instanceID, asgName)
+
+	asg, err := GetAsgByName(asgSvc, asgName)
+	if err != nil {
+		return "", err
+	}
+	existingInstanceIds := idsFromAsgInstances(asg.Instances)
+
+	if err := drainNode(ctx, client, nodeName, opts); err != nil {
+		logger.Errorf("Error draining node %s. Aborting without terminating instance %s.", nodeName, instanceID)
+		logger.Errorf("The node is left cordoned. Investigate the error below, then either uncordon it or rerun the command.")
+		return "", err
+	}
+
+	if err := detachInstances(asgSvc, asgName, []string{instanceID}, false); err != nil {
+		return "", err
+	}
+	if err := terminateInstances(ec2Svc, []string{instanceID}); err != nil {
+		return "", err
+	}
+
+	newInstanceID, err := waitForReplacementInstance(asgSvc, asgName, existingInstanceIds, replaceNodeMaxRetries, replaceNodeSleepBetweenRetries)
+	if err != nil {
+		return "", err
+	}
+	err = waitForNodeReadyWithClient(ctx, client, newInstanceID, replaceNodeMaxRetries*replaceNodeSleepBetweenRetries, nodeReadyPollInterval)
+	if err != nil {
+		return newInstanceID, err
+	}
+
+	logger.Infof("Successfully replaced node %s (instance %s) with instance %s", nodeName, instanceID, newInstanceID)
+	return newInstanceID, nil
+}
+
+// instanceIDFromProviderID extracts the EC2 instance ID from the spec.providerID of a node, which is of the form
+// aws:///AVAILABILITY_ZONE/INSTANCE_ID.
+func instanceIDFromProviderID(nodeName string, providerID string) (string, error) {
+	if !strings.HasPrefix(providerID, "aws://") {
+		return "", errors.WithStackTrace(InvalidProviderIDError{NodeName: nodeName, ProviderID: providerID})
+	}
+	parts := strings.Split(providerID, "/")
+	instanceID := parts[len(parts)-1]
+	if !strings.HasPrefix(instanceID, "i-") {
+		return "", errors.WithStackTrace(InvalidProviderIDError{NodeName: nodeName, ProviderID: providerID})
+	}
+	return instanceID, nil
+}
+
+// asgNameForInstance returns the name of the Auto Scaling Group that the EC2 instance is in.
+func asgNameForInstance(asgSvc *autoscaling.AutoScaling, instanceID string) (string, error) {
+	output, err := asgSvc.DescribeAutoScalingInstances(&autoscaling.DescribeAutoScalingInstancesInput{
+		InstanceIds: aws.StringSlice([]string{instanceID}),
+	})
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	if len(output.AutoScalingInstances) == 0 {
+		return "", errors.WithStackTrace(InstanceNotInASGError{InstanceID: instanceID})
+	}
+	return aws.StringValue(output.AutoScalingInstances[0].AutoScalingGroupName), nil
+}
+
+// waitForReplacementInstance waits for the ASG to launch an instance that is not in existingInstanceIds, and returns
+// its ID.
+func waitForReplacementInstance(
+	asgSvc *autoscaling.AutoScaling,
+	asgName string,
+	existingInstanceIds []string,
+	maxRetries int,
+	sleepBetweenRetries time.Duration,
+) (string, error) {
+	logger := logging.GetProjectLogger()
+	logger.Infof("Waiting for ASG %s to launch a replacement instance.", asgName)
+
+	for i := 0; i < maxRetries; i++ {
+		asg, err := GetAsgByName(asgSvc, asgName)
+		if err != nil {
+			return "", err
+		}
+		for _, instanceID := range idsFromAsgInstances(asg.Instances) {
+			if !collections.ListContainsElement(existingInstanceIds, instanceID) {
+				logger.Infof("ASG %s launched replacement instance %s.", asgName, instanceID)
+				return instanceID, nil
+			}
+		}
+		logger.Debugf("ASG %s has not launched a replacement instance yet. Waiting for %s...", asgName, sleepBetweenRetries)
+		time.Sleep(sleepBetweenRetries)
+	}
+	return "", errors.WithStackTrace(
+		NewCouldNotMeetASGCapacityError(
+			asgName,
+			"Timed out waiting for a replacement instance to launch.",
+		),
+	)
+}
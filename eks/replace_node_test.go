@@ -0,0 +1,41 @@
+package eks
+
+import (
+	"testing"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceIDFromProviderID(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		providerID         string
+		expectedInstanceID string
+	}{
+		{"aws:///us-east-1a/i-0123456789abcdef0", "i-0123456789abcdef0"},
+		{"aws:///us-gov-west-1b/i-0fedcba9876543210", "i-0fedcba9876543210"},
+		{"", ""},
+		{"aws:///us-east-1a/fargate-ip-10-0-0-1.ec2.internal", ""},
+		{"gce://project/zone/instance", ""},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.providerID, func(t *testing.T) {
+			t.Parallel()
+
+			instanceID, err := instanceIDFromProviderID("node", testCase.providerID)
+			if testCase.expectedInstanceID == "" {
+				_, isInvalidProviderIDErr := errors.Unwrap(err).(InvalidProviderIDError)
+				assert.True(t, isInvalidProviderIDErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedInstanceID, instanceID)
+		})
+	}
+}
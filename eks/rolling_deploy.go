@@ -0,0 +1,350 @@
+package eks
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/gruntwork-io/go-commons/collections"
+	"github.com/gruntwork-io/go-commons/errors"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+const (
+	launchTemplateVersionLatest  = "$Latest"
+	launchTemplateVersionDefault = "$Default"
+)
+
+// RollingDeployment will roll out the current launch template (or launch configuration) of the provided self managed
+// worker ASG in the EKS cluster, replacing at most maxUnavailable instances at a time. Unlike RollOutDeployment, which
+// doubles the capacity of the ASG, this only needs room for maxUnavailable extra instances. This is accomplished by
+// repeating the following until all instances in the ASG run the current launch template version:
+// 1. Increase the desired capacity of the ASG by up to maxUnavailable to launch new instances.
+// 2. Wait for the new instances to be Ready nodes in Kubernetes, and registered to any external load balancers.
+// 3. Cordon and drain the same number of outdated instances.
+// 4. Detach the drained instances from the ASG and terminate them.
+// If draining fails, the roll out is aborted without terminating anything, so that the operator can investigate. Note
+// that the outdated instances are left cordoned in that case. The max size of the ASG is restored whether the roll out
+// succeeds or is aborted.
+//
+// When dryRun is true, this only prints the plan of the roll out (the outdated instances that will be replaced, in
+// batches of maxUnavailable, along with the current and target launch template versions and the max size the ASG needs)
+// and checks its prerequisites, without changing anything.
+func RollingDeployment(
+	asgName string,
+	clusterArn string,
+	maxUnavailable int,
+	drainTimeout time.Duration,
+	deleteEmptyDirData bool,
+	maxRetries int,
+	sleepBetweenRetries time.Duration,
+	dryRun bool,
+) error {
+	logger := logging.GetProjectLogger()
+	logger.Infof("Beginning rolling deployment for EKS cluster worker group %s in cluster %s", asgName, clusterArn)
+
+	if maxUnavailable < 1 {
+		return errors.WithStackTrace(InvalidMaxUnavailableError{maxUnavailable})
+	}
+
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return err
+	}
+
+	// Construct clients for AWS
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	asgSvc := autoscaling.New(sess)
+	ec2Svc := eksawshelper.NewEC2Client(sess)
+	logger.Infof("Successfully authenticated with AWS")
+
+	maxRetries = ensureMaxRetries(maxRetries, sleepBetweenRetries, int64(maxUnavailable))
+	steps := &rollingDeploymentClusterSteps{
+		ec2Svc:              ec2Svc,
+		elbSvc:              elb.New(sess),
+		elbv2Svc:            elbv2.New(sess),
+		kubectlOptions:      &kubectl.KubectlOptions{EKSClusterArn: clusterArn},
+		clusterArn:          clusterArn,
+		drainTimeout:        drainTimeout,
+		deleteEmptyDirData:  deleteEmptyDirData,
+		maxRetries:          maxRetries,
+		sleepBetweenRetries: sleepBetweenRetries,
+	}
+	err = rollingDeployment(asgSvc, ec2Svc, steps, asgName, maxUnavailable, maxRetries, sleepBetweenRetries, dryRun)
+	if err != nil || dryRun {
+		return err
+	}
+	logger.Infof("Successfully finished rolling deployment for EKS cluster worker group %s in cluster %s", asgName, clusterArn)
+	return nil
+}
+
+func rollingDeployment(
+	asgSvc autoscalingiface.AutoScalingAPI,
+	ec2Svc ec2iface.EC2API,
+	steps rollingDeploymentSteps,
+	asgName string,
+	maxUnavailable int,
+	maxRetries int,
+	sleepBetweenRetries time.Duration,
+	dryRun bool,
+) (returnErr error) {
+	logger := logging.GetProjectLogger()
+
+	asg, err := GetAsgByName(asgSvc, asgName)
+	if err != nil {
+		return err
+	}
+	launchTemplateVersion, err := resolveLaunchTemplateVersion(ec2Svc, currentLaunchTemplate(asg))
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		logger.Infof("Dry run: computing the roll out plan for ASG %s without changing anything.", asgName)
+		return reportRollOutPlan(newRollingDeploymentPlan(asg, launchTemplateVersion, maxUnavailable))
+	}
+	originalMaxSize := aws.Int64Value(asg.MaxSize)
+	maxSize := originalMaxSize
+
+	// Restore the max size of the ASG however the roll out ends, so that an aborted roll out does not leave the ASG
+	// able to grow past its configured size.
+	defer func() {
+		if maxSize == originalMaxSize {
+			return
+		}
+		if err := setAsgMaxSize(asgSvc, asgName, originalMaxSize); err != nil {
+			logger.Errorf("Error restoring the max size of ASG %s to %d: %s", asgName, originalMaxSize, err)
+			logger.Errorf("Restore the max size manually once the desired capacity of the ASG is back to at most %d.", originalMaxSize)
+			if returnErr == nil {
+				returnErr = err
+			}
+		}
+	}()
+
+	launchedInstanceIds := []string{}
+	for {
+		asg, err = GetAsgByName(asgSvc, asgName)
+		if err != nil {
+			return err
+		}
+		outdatedIds := outdatedInstanceIds(asg, launchTemplateVersion)
+		if len(outdatedIds) == 0 {
+			return nil
+		}
+		// Guard against replacing instances forever if the new instances don't match what we expect them to launch
+		// with, e.g. because the launch template of the ASG was changed while the roll out is in progress.
+		for _, instanceID := range outdatedIds {
+			if collections.ListContainsElement(launchedInstanceIds, instanceID) {
+				return errors.WithStackTrace(InstanceNotUpToDateError{asgName: asgName, instanceID: instanceID})
+			}
+		}
+		logger.Infof("%d instances in ASG %s are not running the current launch template version.", len(outdatedIds), asgName)
+
+		batch := outdatedIds
+		if len(batch) > maxUnavailable {
+			batch = batch[:maxUnavailable]
+		}
+		desiredCapacity := aws.Int64Value(asg.DesiredCapacity) + int64(len(batch))
+		if desiredCapacity > maxSize {
+			if err := setAsgMaxSize(asgSvc, asgName, desiredCapacity); err != nil {
+				return err
+			}
+			maxSize = desiredCapacity
+		}
+
+		newInstanceIds, err := scaleUp(
+			asgSvc,
+			asgName,
+			idsFromAsgInstances(asg.Instances),
+			desiredCapacity,
+			maxRetries,
+			sleepBetweenRetries,
+		)
+		if err != nil {
+			return err
+		}
+		launchedInstanceIds = append(launchedInstanceIds, newInstanceIds...)
+
+		if err := steps.waitForNewInstances(newInstanceIds); err != nil {
+			return err
+		}
+
+		if err := steps.cordon(batch); err != nil {
+			logger.Errorf("Error cordoning instances %v. Aborting roll out.", batch)
+			return err
+		}
+		if err := steps.drain(batch); err != nil {
+			logger.Errorf("Error draining instances %v. Aborting roll out without terminating any instances.", batch)
+			logger.Errorf("The instances are left cordoned. Investigate the error below, then either uncordon them or rerun the command to continue the roll out.")
+			return err
+		}
+		// Pods that are still shutting down after the drain are killed when the instance is terminated, so wait for them
+		// to be gone first.
+		if err := steps.waitForNodesEmpty(batch); err != nil {
+			logger.Errorf("Error waiting for the Pods on instances %v to terminate. Aborting roll out without terminating any instances.", batch)
+			return err
+		}
+
+		if err := detachInstances(asgSvc, asgName, batch, true); err != nil {
+			return err
+		}
+		if err := steps.terminate(batch); err != nil {
+			return err
+		}
+	}
+}
+
+// rollingDeploymentSteps are the steps of a rolling deployment that act on the EC2 instances and Kubernetes nodes, as
+// opposed to the ASG.
+type rollingDeploymentSteps interface {
+	// waitForNewInstances waits for the new instances to be Ready nodes, and registered to any external load balancers.
+	waitForNewInstances(instanceIds []string) error
+	cordon(instanceIds []string) error
+	drain(instanceIds []string) error
+	// waitForNodesEmpty waits for the Pods that are still shutting down on the drained nodes to terminate.
+	waitForNodesEmpty(instanceIds []string) error
+	terminate(instanceIds []string) error
+}
+
+// rollingDeploymentClusterSteps implements the rollingDeploymentSteps against the EKS cluster.
+type rollingDeploymentClusterSteps struct {
+	ec2Svc              *ec2.EC2
+	elbSvc              *elb.ELB
+	elbv2Svc            *elbv2.ELBV2
+	kubectlOptions      *kubectl.KubectlOptions
+	clusterArn          string
+	drainTimeout        time.Duration
+	deleteEmptyDirData  bool
+	maxRetries          int
+	sleepBetweenRetries time.Duration
+}
+
+func (steps *rollingDeploymentClusterSteps) waitForNewInstances(instanceIds []string) error {
+	logger := logging.GetProjectLogger()
+
+	// Wait for each new instance to be a Ready node, matching the nodes by their provider ID, before checking the load
+	// balancers.
+	for _, instanceID := range instanceIds {
+		if err := waitForNodeReady(steps.clusterArn, instanceID, time.Duration(steps.maxRetries)*steps.sleepBetweenRetries); err != nil {
+			logger.Errorf("Undo by terminating all the new instances and trying again")
+			return err
+		}
+	}
+	return waitAndVerifyNewInstances(
+		steps.ec2Svc,
+		steps.elbSvc,
+		steps.elbv2Svc,
+		instanceIds,
+		steps.kubectlOptions,
+		steps.maxRetries,
+		steps.sleepBetweenRetries,
+	)
+}
+
+func (steps *rollingDeploymentClusterSteps) cordon(instanceIds []string) error {
+	return cordonNodesInAsg(steps.ec2Svc, steps.kubectlOptions, instanceIds)
+}
+
+func (steps *rollingDeploymentClusterSteps) drain(instanceIds []string) error {
+	return drainNodesInAsg(steps.ec2Svc, steps.kubectlOptions, instanceIds, steps.drainTimeout, steps.deleteEmptyDirData)
+}
+
+func (steps *rollingDeploymentClusterSteps) waitForNodesEmpty(instanceIds []string) error {
+	return waitForInstanceNodesEmpty(steps.ec2Svc, steps.clusterArn, instanceIds, steps.drainTimeout)
+}
+
+func (steps *rollingDeploymentClusterSteps) terminate(instanceIds []string) error {
+	return terminateInstances(steps.ec2Svc, instanceIds)
+}
+
+// currentLaunchTemplate returns the launch template that the ASG launches new instances with, or nil if the ASG uses a
+// launch configuration.
+func currentLaunchTemplate(asg *autoscaling.Group) *autoscaling.LaunchTemplateSpecification {
+	if asg.LaunchTemplate != nil {
+		return asg.LaunchTemplate
+	}
+	if asg.MixedInstancesPolicy != nil && asg.MixedInstancesPolicy.LaunchTemplate != nil {
+		return asg.MixedInstancesPolicy.LaunchTemplate.LaunchTemplateSpecification
+	}
+	return nil
+}
+
+// resolveLaunchTemplateVersion returns the version number of the launch template that new instances are launched with.
+// ASGs can refer to the $Latest or $Default version of a launch template, while the instances always report the actual
+// version number, so we look up the number to compare against. Returns an empty string if there is no launch template.
+func resolveLaunchTemplateVersion(ec2Svc ec2iface.EC2API, spec *autoscaling.LaunchTemplateSpecification) (string, error) {
+	if spec == nil {
+		return "", nil
+	}
+	version := aws.StringValue(spec.Version)
+	if version != "" && version != launchTemplateVersionLatest && version != launchTemplateVersionDefault {
+		return version, nil
+	}
+
+	input := &ec2.DescribeLaunchTemplatesInput{}
+	templateID := aws.StringValue(spec.LaunchTemplateId)
+	if templateID != "" {
+		input.LaunchTemplateIds = []*string{spec.LaunchTemplateId}
+	} else {
+		templateID = aws.StringValue(spec.LaunchTemplateName)
+		input.LaunchTemplateNames = []*string{spec.LaunchTemplateName}
+	}
+	output, err := ec2Svc.DescribeLaunchTemplates(input)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	if len(output.LaunchTemplates) == 0 {
+		return "", errors.WithStackTrace(NewLookupError("launch template", templateID, "version"))
+	}
+
+	launchTemplate := output.LaunchTemplates[0]
+	if version == launchTemplateVersionLatest {
+		return strconv.FormatInt(aws.Int64Value(launchTemplate.LatestVersionNumber), 10), nil
+	}
+	// Both $Default and an omitted version refer to the default version of the launch template.
+	return strconv.FormatInt(aws.Int64Value(launchTemplate.DefaultVersionNumber), 10), nil
+}
+
+// outdatedInstanceIds returns the IDs of the instances in the ASG that were not launched with the current launch
+// template version (as resolved by resolveLaunchTemplateVersion), or the current launch configuration if the ASG does
+// not use a launch template.
+func outdatedInstanceIds(asg *autoscaling.Group, launchTemplateVersion string) []string {
+	spec := currentLaunchTemplate(asg)
+	idList := []string{}
+	for _, inst := range asg.Instances {
+		if !isInstanceUpToDate(inst, asg, spec, launchTemplateVersion) {
+			idList = append(idList, aws.StringValue(inst.InstanceId))
+		}
+	}
+	return idList
+}
+
+func isInstanceUpToDate(
+	inst *autoscaling.Instance,
+	asg *autoscaling.Group,
+	spec *autoscaling.LaunchTemplateSpecification,
+	launchTemplateVersion string,
+) bool {
+	if spec == nil {
+		return inst.LaunchConfigurationName != nil &&
+			aws.StringValue(inst.LaunchConfigurationName) == aws.StringValue(asg.LaunchConfigurationName)
+	}
+	if inst.LaunchTemplate == nil || aws.StringValue(inst.LaunchTemplate.Version) != launchTemplateVersion {
+		return false
+	}
+	if spec.LaunchTemplateId != nil && inst.LaunchTemplate.LaunchTemplateId != nil {
+		return aws.StringValue(inst.LaunchTemplate.LaunchTemplateId) == aws.StringValue(spec.LaunchTemplateId)
+	}
+	return aws.StringValue(inst.LaunchTemplate.LaunchTemplateName) == aws.StringValue(spec.LaunchTemplateName)
+}
@@ -0,0 +1,229 @@
+package eks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/gruntwork-io/go-commons/collections"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutdatedInstanceIds(t *testing.T) {
+	t.Parallel()
+
+	launchTemplateInstance := func(id string, templateID string, version string) *autoscaling.Instance {
+		return &autoscaling.Instance{
+			InstanceId: aws.String(id),
+			LaunchTemplate: &autoscaling.LaunchTemplateSpecification{
+				LaunchTemplateId:   aws.String(templateID),
+				LaunchTemplateName: aws.String("workers"),
+				Version:            aws.String(version),
+			},
+		}
+	}
+	currentTemplate := &autoscaling.LaunchTemplateSpecification{
+		LaunchTemplateId:   aws.String("lt-current"),
+		LaunchTemplateName: aws.String("workers"),
+		Version:            aws.String(launchTemplateVersionLatest),
+	}
+
+	testCases := []struct {
+		name     string
+		asg      *autoscaling.Group
+		version  string
+		expected []string
+	}{
+		{
+			"launch-template",
+			&autoscaling.Group{
+				LaunchTemplate: currentTemplate,
+				Instances: []*autoscaling.Instance{
+					launchTemplateInstance("i-old-version", "lt-current", "2"),
+					launchTemplateInstance("i-current", "lt-current", "3"),
+					launchTemplateInstance("i-other-template", "lt-other", "3"),
+				},
+			},
+			"3",
+			[]string{"i-old-version", "i-other-template"},
+		},
+		{
+			"mixed-instances-policy",
+			&autoscaling.Group{
+				MixedInstancesPolicy: &autoscaling.MixedInstancesPolicy{
+					LaunchTemplate: &autoscaling.LaunchTemplate{LaunchTemplateSpecification: currentTemplate},
+				},
+				Instances: []*autoscaling.Instance{
+					launchTemplateInstance("i-current", "lt-current", "3"),
+					{InstanceId: aws.String("i-launch-config"), LaunchConfigurationName: aws.String("workers-lc")},
+				},
+			},
+			"3",
+			[]string{"i-launch-config"},
+		},
+		{
+			"launch-configuration",
+			&autoscaling.Group{
+				LaunchConfigurationName: aws.String("workers-v2"),
+				Instances: []*autoscaling.Instance{
+					{InstanceId: aws.String("i-old"), LaunchConfigurationName: aws.String("workers-v1")},
+					{InstanceId: aws.String("i-current"), LaunchConfigurationName: aws.String("workers-v2")},
+					{InstanceId: aws.String("i-deleted-config")},
+				},
+			},
+			"",
+			[]string{"i-old", "i-deleted-config"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.expected, outdatedInstanceIds(testCase.asg, testCase.version))
+		})
+	}
+}
+
+func TestRollingDeploymentReplacesOutdatedInstances(t *testing.T) {
+	t.Parallel()
+
+	asgSvc := newTestRollingDeploymentAutoScaling()
+	steps := &fakeRollingDeploymentSteps{}
+
+	require.NoError(t, rollingDeployment(asgSvc, nil, steps, "workers", 1, 1, 0, false))
+
+	assert.Equal(t, [][]string{{"i-old-1"}, {"i-old-2"}}, steps.drained)
+	assert.Equal(t, [][]string{{"i-old-1"}, {"i-old-2"}}, steps.terminated)
+	assert.Equal(t, []string{"i-old-1", "i-old-2"}, asgSvc.detached)
+	// The max size is raised once for the first batch, and restored at the end.
+	require.Len(t, asgSvc.updateInputs, 2)
+	assert.Equal(t, int64(3), aws.Int64Value(asgSvc.updateInputs[0].MaxSize))
+	assert.Equal(t, int64(2), aws.Int64Value(asgSvc.updateInputs[1].MaxSize))
+}
+
+func TestRollingDeploymentDryRunDoesNotChangeAnything(t *testing.T) {
+	t.Parallel()
+
+	asgSvc := newTestRollingDeploymentAutoScaling()
+	steps := &fakeRollingDeploymentSteps{}
+
+	require.NoError(t, rollingDeployment(asgSvc, nil, steps, "workers", 1, 1, 0, true))
+
+	assert.Empty(t, asgSvc.updateInputs)
+	assert.Equal(t, 0, asgSvc.launched)
+	assert.Empty(t, asgSvc.detached)
+	assert.Empty(t, steps.drained)
+	assert.Empty(t, steps.terminated)
+}
+
+func TestRollingDeploymentRestoresMaxSizeWhenDrainFails(t *testing.T) {
+	t.Parallel()
+
+	asgSvc := newTestRollingDeploymentAutoScaling()
+	steps := &fakeRollingDeploymentSteps{drainErr: fmt.Errorf("cannot evict pod as it would violate the pod's disruption budget")}
+
+	err := rollingDeployment(asgSvc, nil, steps, "workers", 1, 1, 0, false)
+	require.Error(t, err)
+
+	// The max size is raised to launch the new instance, then restored to the original max size on abort.
+	require.Len(t, asgSvc.updateInputs, 2)
+	assert.Equal(t, int64(3), aws.Int64Value(asgSvc.updateInputs[0].MaxSize))
+	assert.Equal(t, int64(2), aws.Int64Value(asgSvc.updateInputs[1].MaxSize))
+	assert.Equal(t, [][]string{{"i-old-1"}}, steps.drained)
+	assert.Empty(t, steps.terminated)
+	assert.Empty(t, asgSvc.detached)
+}
+
+// newTestRollingDeploymentAutoScaling returns a stub of an ASG at its max size of 2, with both instances running an
+// outdated launch template version.
+func newTestRollingDeploymentAutoScaling() *fakeRollingDeploymentAutoScaling {
+	return &fakeRollingDeploymentAutoScaling{
+		group: &autoscaling.Group{
+			AutoScalingGroupName: aws.String("workers"),
+			DesiredCapacity:      aws.Int64(2),
+			MaxSize:              aws.Int64(2),
+			LaunchTemplate: &autoscaling.LaunchTemplateSpecification{
+				LaunchTemplateName: aws.String("lt-workers"),
+				Version:            aws.String("2"),
+			},
+			Instances: []*autoscaling.Instance{
+				testPlanInstance("i-old-1", "1"),
+				testPlanInstance("i-old-2", "1"),
+			},
+		},
+	}
+}
+
+// fakeRollingDeploymentAutoScaling is a stub of the ASG API for a single ASG. Raising the desired capacity launches
+// instances with the launch template version of the ASG.
+type fakeRollingDeploymentAutoScaling struct {
+	autoscalingiface.AutoScalingAPI
+
+	group        *autoscaling.Group
+	launched     int
+	updateInputs []*autoscaling.UpdateAutoScalingGroupInput
+	detached     []string
+}
+
+func (fake *fakeRollingDeploymentAutoScaling) DescribeAutoScalingGroups(input *autoscaling.DescribeAutoScalingGroupsInput) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
+	return &autoscaling.DescribeAutoScalingGroupsOutput{AutoScalingGroups: []*autoscaling.Group{fake.group}}, nil
+}
+
+func (fake *fakeRollingDeploymentAutoScaling) UpdateAutoScalingGroup(input *autoscaling.UpdateAutoScalingGroupInput) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
+	fake.updateInputs = append(fake.updateInputs, input)
+	fake.group.MaxSize = input.MaxSize
+	return &autoscaling.UpdateAutoScalingGroupOutput{}, nil
+}
+
+func (fake *fakeRollingDeploymentAutoScaling) SetDesiredCapacity(input *autoscaling.SetDesiredCapacityInput) (*autoscaling.SetDesiredCapacityOutput, error) {
+	for int64(len(fake.group.Instances)) < aws.Int64Value(input.DesiredCapacity) {
+		fake.launched++
+		instanceID := fmt.Sprintf("i-new-%d", fake.launched)
+		fake.group.Instances = append(fake.group.Instances, testPlanInstance(instanceID, aws.StringValue(fake.group.LaunchTemplate.Version)))
+	}
+	fake.group.DesiredCapacity = input.DesiredCapacity
+	return &autoscaling.SetDesiredCapacityOutput{}, nil
+}
+
+func (fake *fakeRollingDeploymentAutoScaling) DetachInstances(input *autoscaling.DetachInstancesInput) (*autoscaling.DetachInstancesOutput, error) {
+	instanceIds := aws.StringValueSlice(input.InstanceIds)
+	fake.detached = append(fake.detached, instanceIds...)
+	remaining := []*autoscaling.Instance{}
+	for _, inst := range fake.group.Instances {
+		if !collections.ListContainsElement(instanceIds, aws.StringValue(inst.InstanceId)) {
+			remaining = append(remaining, inst)
+		}
+	}
+	fake.group.Instances = remaining
+	if aws.BoolValue(input.ShouldDecrementDesiredCapacity) {
+		fake.group.DesiredCapacity = aws.Int64(int64(len(remaining)))
+	}
+	return &autoscaling.DetachInstancesOutput{}, nil
+}
+
+// fakeRollingDeploymentSteps records the instances passed to each step, failing the drain with drainErr when set.
+type fakeRollingDeploymentSteps struct {
+	drainErr   error
+	drained    [][]string
+	terminated [][]string
+}
+
+func (fake *fakeRollingDeploymentSteps) waitForNewInstances(instanceIds []string) error { return nil }
+func (fake *fakeRollingDeploymentSteps) cordon(instanceIds []string) error              { return nil }
+func (fake *fakeRollingDeploymentSteps) waitForNodesEmpty(instanceIds []string) error   { return nil }
+
+func (fake *fakeRollingDeploymentSteps) drain(instanceIds []string) error {
+	fake.drained = append(fake.drained, instanceIds)
+	return fake.drainErr
+}
+
+func (fake *fakeRollingDeploymentSteps) terminate(instanceIds []string) error {
+	fake.terminated = append(fake.terminated, instanceIds)
+	return nil
+}
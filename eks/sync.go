@@ -6,11 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/blang/semver/v4"
@@ -20,18 +20,22 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8stypes "k8s.io/apimachinery/pkg/types"
+	appsv1apply "k8s.io/client-go/applyconfigurations/apps/v1"
+	corev1apply "k8s.io/client-go/applyconfigurations/core/v1"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/gruntwork-io/kubergrunt/commonerrors"
 	"github.com/gruntwork-io/kubergrunt/eksawshelper"
 	"github.com/gruntwork-io/kubergrunt/jsonpatch"
 	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
 	"github.com/gruntwork-io/kubergrunt/logging"
 )
 
 const (
 	kubeProxyRepoPath = "eks/kube-proxy"
 	coreDNSRepoPath   = "eks/coredns"
+	vpcCNIRepoPath    = "amazon-k8s-cni"
 
 	// Largest eksbuild tag we will try looking for.
 	maxEKSBuild = 20
@@ -86,6 +90,8 @@ const (
 	componentNamespace        = "kube-system"
 	kubeProxyDaemonSetName    = "kube-proxy"
 	corednsDeploymentName     = "coredns"
+	vpcCNIDaemonSetName       = "aws-node"
+	vpcCNIContainerName       = "aws-node"
 	corednsClusterRoleName    = "system:coredns"
 	corednsConfigMapName      = "coredns"
 	corednsConfigMapConfigKey = "Corefile"
@@ -94,6 +100,13 @@ const (
 	endpointslicesResource = "endpointslices"
 )
 
+// Names of the core components, as accepted by SkipComponentsConfigForComponents.
+const (
+	KubeProxyComponentName = "kube-proxy"
+	CoreDNSComponentName   = "coredns"
+	VPCCNIComponentName    = "aws-vpc-cni"
+)
+
 // SkipComponentsConfig represents the components that should be skipped in the sync command.
 type SkipComponentsConfig struct {
 	KubeProxy bool
@@ -101,12 +114,70 @@ type SkipComponentsConfig struct {
 	VPCCNI    bool
 }
 
+// SkipComponentsConfigForComponents returns the SkipComponentsConfig that only syncs the given components. Returns an
+// error if any of the names is not one of kube-proxy, coredns, or aws-vpc-cni.
+func SkipComponentsConfigForComponents(components []string) (SkipComponentsConfig, error) {
+	skipConfig := SkipComponentsConfig{KubeProxy: true, CoreDNS: true, VPCCNI: true}
+	for _, component := range components {
+		switch component {
+		case KubeProxyComponentName:
+			skipConfig.KubeProxy = false
+		case CoreDNSComponentName:
+			skipConfig.CoreDNS = false
+		case VPCCNIComponentName:
+			skipConfig.VPCCNI = false
+		default:
+			return skipConfig, errors.WithStackTrace(UnknownCoreComponentErr{component})
+		}
+	}
+	return skipConfig, nil
+}
+
 type componentVersions struct {
 	kubeProxy string
 	coreDNS   string
 	vpcCNI    string
 }
 
+// DefaultFieldManager is the default name of the field manager that owns the fields of the core components updated
+// with server-side apply.
+const DefaultFieldManager = "kubergrunt"
+
+// ApplyConfig configures how the core components are updated in the sync command.
+type ApplyConfig struct {
+	// ServerSide, when true, updates the core components with server-side apply, so that the ownership of the updated
+	// fields is tracked by the API server, and repeated syncs don't conflict with the fields managed by the EKS add-on
+	// controller. Otherwise, the components are updated with client-side patches.
+	ServerSide bool
+
+	// FieldManager is the name of the field manager that owns the fields set with server-side apply. Defaults to
+	// DefaultFieldManager.
+	FieldManager string
+}
+
+// fieldManager returns the name of the field manager to use for server-side apply.
+func (config ApplyConfig) fieldManager() string {
+	if config.FieldManager == "" {
+		return DefaultFieldManager
+	}
+	return config.FieldManager
+}
+
+// SyncOptions configures how SyncClusterComponents updates the core components.
+type SyncOptions struct {
+	// DryRun, when true, only prints a diff of the currently deployed image of each component against the image it would
+	// be updated to, without changing anything on the cluster.
+	DryRun bool
+
+	// Apply configures whether the components are updated with server-side apply.
+	Apply ApplyConfig
+
+	// AllowIncompatible, when true, only logs a warning for the component versions that are not listed by AWS as
+	// compatible with the Kubernetes version of the cluster, or that are downgraded across more than one minor version,
+	// and syncs anyway.
+	AllowIncompatible bool
+}
+
 // SyncClusterComponents will perform the steps described in
 // https://docs.aws.amazon.com/eks/latest/userguide/update-cluster.html
 // There are three core applications on an EKS cluster:
@@ -119,11 +190,22 @@ type componentVersions struct {
 // the k8s API and kubectl command under the hood to patch the manifests to deploy the expected version based on what
 // the current Kubernetes version is of the cluster. As such, this command should be run every time the Kubernetes
 // version is updated on the EKS cluster.
+//
+// When options.DryRun is true, this only prints a diff of the currently deployed image of each component against the image it
+// would be updated to (e.g. "coredns: v1.8.7-eksbuild.3 -> v1.9.3-eksbuild.7"), without changing anything on the
+// cluster. Otherwise, the same diff is logged before each component is updated, so that the changes are auditable.
+//
+// Refer to ApplyConfig, set in options.Apply, for updating the components with server-side apply.
+//
+// Before changing anything, this verifies that each component version is listed by AWS as compatible with the
+// Kubernetes version of the cluster (as published for the EKS add-ons), and that no component is downgraded across more
+// than one minor version. Set options.AllowIncompatible to only log a warning for the incompatible versions and sync anyway.
 func SyncClusterComponents(
 	eksClusterArn string,
 	shouldWait bool,
 	waitTimeout string,
 	skipConfig SkipComponentsConfig,
+	options SyncOptions,
 ) error {
 	logger := logging.GetProjectLogger()
 
@@ -178,10 +260,37 @@ func SyncClusterComponents(
 		return err
 	}
 
+	diffs, err := getComponentImageDiffs(
+		clientset,
+		skipConfig,
+		componentVersions{kubeProxy: kubeProxyVersion, coreDNS: coreDNSVersion, vpcCNI: amznVPCCNIVersion},
+		awsRegion,
+	)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Checking compatibility of the core component versions with the Kubernetes version.")
+	eksSvc, err := eksawshelper.NewEksClient(awsRegion)
+	if err != nil {
+		return err
+	}
+	if err := checkComponentVersionsCompatibility(eksSvc, k8sVersion, diffs, options.AllowIncompatible); err != nil {
+		return err
+	}
+
+	if options.DryRun {
+		logger.Info("Dry run: the following changes would be made to the core components.")
+		for _, diff := range diffs {
+			fmt.Println(diff.String())
+		}
+		return nil
+	}
+
 	if skipConfig.KubeProxy {
 		logger.Info("Skipping kube-proxy sync.")
 	} else {
-		if err := upgradeKubeProxy(kubectlOptions, clientset, awsRegion, kubeProxyVersion, shouldWait, waitTimeout); err != nil {
+		if err := upgradeKubeProxy(kubectlOptions, clientset, awsRegion, kubeProxyVersion, shouldWait, waitTimeout, options.Apply); err != nil {
 			return err
 		}
 	}
@@ -189,7 +298,7 @@ func SyncClusterComponents(
 	if skipConfig.CoreDNS {
 		logger.Info("Skipping coredns sync.")
 	} else {
-		if err := upgradeCoreDNS(kubectlOptions, clientset, awsRegion, coreDNSVersion, shouldWait, waitTimeout); err != nil {
+		if err := upgradeCoreDNS(clientset, awsRegion, coreDNSVersion, shouldWait, waitTimeout, options.Apply); err != nil {
 			return err
 		}
 	}
@@ -197,9 +306,20 @@ func SyncClusterComponents(
 	if skipConfig.VPCCNI {
 		logger.Info("Skipping aws-vpc-cni.")
 	} else {
-		if err := updateVPCCNI(kubectlOptions, awsRegion, amznVPCCNIVersion); err != nil {
+		currentImage, err := getCurrentDeployedVPCCNIImage(clientset)
+		if err != nil {
+			return err
+		}
+		logger.Infof("Applying change %s", componentImageDiff{VPCCNIComponentName, currentImage, vpcCNITargetImage(awsRegion, amznVPCCNIVersion)})
+		if err := updateVPCCNI(kubectlOptions, awsRegion, amznVPCCNIVersion, options.Apply); err != nil {
 			return err
 		}
+		if shouldWait {
+			logger.Info("Waiting until new image for aws-vpc-cni is rolled out.")
+			if err := waitForRollout(kubectlOptions, "daemonset", vpcCNIDaemonSetName, waitTimeout); err != nil {
+				return err
+			}
+		}
 	}
 
 	logger.Info("Successfully updated core components.")
@@ -215,10 +335,11 @@ func upgradeKubeProxy(
 	kubeProxyVersion string,
 	shouldWait bool,
 	waitTimeout string,
+	applyConfig ApplyConfig,
 ) error {
 	logger := logging.GetProjectLogger()
 
-	targetImage := fmt.Sprintf("%s/%s:v%s", getRepoDomain(awsRegion), kubeProxyRepoPath, kubeProxyVersion)
+	targetImage := kubeProxyTargetImage(awsRegion, kubeProxyVersion)
 	currentImage, err := getCurrentDeployedKubeProxyImage(clientset)
 	if err != nil {
 		return err
@@ -228,23 +349,13 @@ func upgradeKubeProxy(
 		return nil
 	}
 
-	logger.Infof("Upgrading current deployed version of kube-proxy (%s) to match expected version (%s).", currentImage, targetImage)
-	if err := updateKubeProxyDaemonsetImage(clientset, targetImage); err != nil {
+	logger.Infof("Applying change %s", componentImageDiff{KubeProxyComponentName, currentImage, targetImage})
+	if err := updateKubeProxyDaemonsetImage(clientset, targetImage, applyConfig); err != nil {
 		return err
 	}
 	if shouldWait {
 		logger.Info("Waiting until new image for kube-proxy is rolled out.")
-		// Ideally we will implement the following routine using the raw client-go library, but implementing this
-		// functionality directly on the API is fairly complex, and thus we rely on the built in mechanism in kubectl
-		// instead.
-		args := []string{
-			"rollout",
-			"status",
-			fmt.Sprintf("daemonset/%s", kubeProxyDaemonSetName),
-			"-n", componentNamespace,
-			"--timeout", waitTimeout,
-		}
-		return kubectl.RunKubectl(kubectlOptions, args...)
+		return waitForRollout(kubectlOptions, "daemonset", kubeProxyDaemonSetName, waitTimeout)
 	}
 	return nil
 }
@@ -268,7 +379,29 @@ func getCurrentDeployedKubeProxyImage(clientset *kubernetes.Clientset) (string,
 }
 
 // updateKubeProxyDaemonsetImage will update the deployed kube-proxy DaemonSet to the specified target container image.
-func updateKubeProxyDaemonsetImage(clientset *kubernetes.Clientset, targetImage string) error {
+func updateKubeProxyDaemonsetImage(clientset *kubernetes.Clientset, targetImage string, applyConfig ApplyConfig) error {
+	daemonsetAPI := clientset.AppsV1().DaemonSets(componentNamespace)
+	if applyConfig.ServerSide {
+		// The apply patch needs the name of the container, which is the only one, as checked when looking up the
+		// current image.
+		daemonset, err := daemonsetAPI.Get(context.Background(), kubeProxyDaemonSetName, metav1.GetOptions{})
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		patch, err := kubeProxyImageApplyPatch(daemonset.Spec.Template.Spec.Containers[0].Name, targetImage)
+		if err != nil {
+			return err
+		}
+		err = kubehelper.RetryOnConflictOrServerError(func() error {
+			_, err := daemonsetAPI.Patch(context.Background(), kubeProxyDaemonSetName, k8stypes.ApplyPatchType, patch, serverSideApplyOptions(applyConfig))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return annotateDaemonSetAsModified(clientset, kubeProxyDaemonSetName)
+	}
+
 	patch := []jsonpatch.PatchString{
 		{
 			Op: jsonpatch.ReplaceOp,
@@ -281,22 +414,80 @@ func updateKubeProxyDaemonsetImage(clientset *kubernetes.Clientset, targetImage
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
-	daemonsetAPI := clientset.AppsV1().DaemonSets(componentNamespace)
-	if _, err := daemonsetAPI.Patch(context.Background(), kubeProxyDaemonSetName, k8stypes.JSONPatchType, patchOpJson, metav1.PatchOptions{}); err != nil {
-		return errors.WithStackTrace(err)
+	err = kubehelper.RetryOnConflictOrServerError(func() error {
+		_, err := daemonsetAPI.Patch(context.Background(), kubeProxyDaemonSetName, k8stypes.JSONPatchType, patchOpJson, metav1.PatchOptions{})
+		return err
+	})
+	if err != nil {
+		return err
 	}
-	return nil
+	return annotateDaemonSetAsModified(clientset, kubeProxyDaemonSetName)
+}
+
+// kubeProxyImageApplyPatch returns the server-side apply patch that only sets the image of the given container of the
+// kube-proxy DaemonSet, so that the field manager only takes ownership of the image.
+func kubeProxyImageApplyPatch(containerName string, targetImage string) ([]byte, error) {
+	daemonset := appsv1apply.DaemonSet(kubeProxyDaemonSetName, componentNamespace).
+		WithSpec(appsv1apply.DaemonSetSpec().WithTemplate(imagePodTemplateApplyConfig(containerName, targetImage)))
+	patch, err := json.Marshal(daemonset)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return patch, nil
+}
+
+// imagePodTemplateApplyConfig returns the apply configuration of a Pod template that only sets the image of the given
+// container.
+func imagePodTemplateApplyConfig(containerName string, targetImage string) *corev1apply.PodTemplateSpecApplyConfiguration {
+	return corev1apply.PodTemplateSpec().WithSpec(
+		corev1apply.PodSpec().WithContainers(corev1apply.Container().WithName(containerName).WithImage(targetImage)),
+	)
+}
+
+// annotateDaemonSetAsModified records kubergrunt in the annotations of the given DaemonSet in the kube-system namespace,
+// after its image is patched. This is a separate merge patch, as the JSON patch can't add the annotations without
+// replacing the existing ones, and the server-side apply patch would hand the ownership of the annotations to the
+// field manager.
+func annotateDaemonSetAsModified(clientset kubernetes.Interface, name string) error {
+	patch, err := kubectl.ModifiedByKubergruntMergePatch()
+	if err != nil {
+		return err
+	}
+	return kubehelper.RetryOnConflictOrServerError(func() error {
+		_, err := clientset.AppsV1().DaemonSets(componentNamespace).Patch(context.Background(), name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+}
+
+// annotateDeploymentAsModified is like annotateDaemonSetAsModified, but for Deployments.
+func annotateDeploymentAsModified(clientset kubernetes.Interface, name string) error {
+	patch, err := kubectl.ModifiedByKubergruntMergePatch()
+	if err != nil {
+		return err
+	}
+	return kubehelper.RetryOnConflictOrServerError(func() error {
+		_, err := clientset.AppsV1().Deployments(componentNamespace).Patch(context.Background(), name, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+}
+
+// serverSideApplyOptions returns the options of the server-side apply patches. The conflicts are forced, so that the
+// field manager takes over the ownership of the image from the manager that last set it (e.g., the EKS add-on
+// controller).
+func serverSideApplyOptions(applyConfig ApplyConfig) metav1.PatchOptions {
+	force := true
+	return metav1.PatchOptions{FieldManager: applyConfig.fieldManager(), Force: &force}
 }
 
 // upgradeCoreDNS will update to the latest coredns version if necessary. If shouldWait is set to true, this routine
 // will wait until the new images are fully rolled out before continuing.
 func upgradeCoreDNS(
-	kubectlOptions *kubectl.KubectlOptions,
 	clientset *kubernetes.Clientset,
 	awsRegion string,
 	coreDNSVersion string,
 	shouldWait bool,
 	waitTimeout string,
+	applyConfig ApplyConfig,
 ) error {
 	logger := logging.GetProjectLogger()
 
@@ -331,7 +522,7 @@ func upgradeCoreDNS(
 		logger.Info("ClusterRole permissions for coredns is up to date. Skipping adjusting ClusterRole permissions.")
 	}
 
-	targetImage := fmt.Sprintf("%s/%s:v%s", getRepoDomain(awsRegion), coreDNSRepoPath, coreDNSVersion)
+	targetImage := coreDNSTargetImage(awsRegion, coreDNSVersion)
 	currentImage, err := getCurrentDeployedCoreDNSImage(clientset)
 	if err != nil {
 		return err
@@ -341,48 +532,152 @@ func upgradeCoreDNS(
 		return nil
 	}
 
-	logger.Infof("Upgrading current deployed version of coredns (%s) to match expected version (%s).", currentImage, targetImage)
-	if err := updateCoreDNSDeploymentImage(clientset, targetImage); err != nil {
+	logger.Infof("Applying change %s", componentImageDiff{CoreDNSComponentName, currentImage, targetImage})
+	if err := updateCoreDNSDeploymentImage(clientset, targetImage, applyConfig); err != nil {
 		return err
 	}
 
 	if shouldWait {
 		logger.Info("Waiting until new image for coredns is rolled out.")
-		// Ideally we will implement the following routine using the raw client-go library, but implementing this
-		// functionality directly on the API is fairly complex, and thus we rely on the built in mechanism in kubectl
-		// instead.
-		args := []string{
-			"rollout",
-			"status",
-			fmt.Sprintf("deployment/%s", corednsDeploymentName),
-			"-n", componentNamespace,
-			"--timeout", waitTimeout,
+		timeout, err := time.ParseDuration(waitTimeout)
+		if err != nil {
+			return errors.WithStackTrace(err)
 		}
-		return kubectl.RunKubectl(kubectlOptions, args...)
+		return waitForDeploymentRolloutWithClient(context.Background(), clientset, componentNamespace, corednsDeploymentName, timeout, deploymentRolloutPollInterval)
 	}
 	return nil
 }
 
+// waitForRollout waits until the rollout of the given workload (e.g. daemonset or deployment) in the kube-system
+// namespace is complete, and all the Pods are updated and available.
+func waitForRollout(kubectlOptions *kubectl.KubectlOptions, kind string, name string, waitTimeout string) error {
+	// Ideally we will implement the following routine using the raw client-go library, but implementing this
+	// functionality directly on the API is fairly complex, and thus we rely on the built in mechanism in kubectl
+	// instead.
+	args := []string{
+		"rollout",
+		"status",
+		fmt.Sprintf("%s/%s", kind, name),
+		"-n", componentNamespace,
+		"--timeout", waitTimeout,
+	}
+	return kubectl.RunKubectl(kubectlOptions, args...)
+}
+
+// componentImageDiff describes the change to the container image of a core component that the sync makes.
+type componentImageDiff struct {
+	component    string
+	currentImage string
+	targetImage  string
+}
+
+// String renders the diff as a single line, e.g. "coredns: v1.8.7-eksbuild.3 -> v1.9.3-eksbuild.7". When the image
+// repository is the same, only the tags are shown to keep the output concise.
+func (diff componentImageDiff) String() string {
+	if diff.currentImage == diff.targetImage {
+		return fmt.Sprintf("%s: %s (up to date)", diff.component, imageTagOrImage(diff.currentImage))
+	}
+	currentRepo, currentTag := splitImage(diff.currentImage)
+	targetRepo, targetTag := splitImage(diff.targetImage)
+	if currentRepo == targetRepo && currentTag != "" && targetTag != "" {
+		return fmt.Sprintf("%s: %s -> %s", diff.component, currentTag, targetTag)
+	}
+	return fmt.Sprintf("%s: %s -> %s", diff.component, diff.currentImage, diff.targetImage)
+}
+
+// splitImage splits the container image into the repository and tag. The tag is empty if the image has no tag. Note
+// that the repository can contain a colon for the registry port, so we only look for the tag after the last slash.
+func splitImage(image string) (string, string) {
+	lastSlash := strings.LastIndex(image, "/")
+	lastColon := strings.LastIndex(image, ":")
+	if lastColon <= lastSlash {
+		return image, ""
+	}
+	return image[:lastColon], image[lastColon+1:]
+}
+
+func imageTagOrImage(image string) string {
+	if _, tag := splitImage(image); tag != "" {
+		return tag
+	}
+	return image
+}
+
+// getComponentImageDiffs looks up the currently deployed image of each component that is not skipped, and returns it
+// along with the image the sync deploys.
+func getComponentImageDiffs(
+	clientset *kubernetes.Clientset,
+	skipConfig SkipComponentsConfig,
+	versions componentVersions,
+	awsRegion string,
+) ([]componentImageDiff, error) {
+	diffs := []componentImageDiff{}
+	if !skipConfig.KubeProxy {
+		currentImage, err := getCurrentDeployedKubeProxyImage(clientset)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, componentImageDiff{KubeProxyComponentName, currentImage, kubeProxyTargetImage(awsRegion, versions.kubeProxy)})
+	}
+	if !skipConfig.CoreDNS {
+		currentImage, err := getCurrentDeployedCoreDNSImage(clientset)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, componentImageDiff{CoreDNSComponentName, currentImage, coreDNSTargetImage(awsRegion, versions.coreDNS)})
+	}
+	if !skipConfig.VPCCNI {
+		currentImage, err := getCurrentDeployedVPCCNIImage(clientset)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, componentImageDiff{VPCCNIComponentName, currentImage, vpcCNITargetImage(awsRegion, versions.vpcCNI)})
+	}
+	return diffs, nil
+}
+
+func kubeProxyTargetImage(awsRegion string, kubeProxyVersion string) string {
+	return fmt.Sprintf("%s/%s:v%s", getRepoDomain(awsRegion), kubeProxyRepoPath, kubeProxyVersion)
+}
+
+func coreDNSTargetImage(awsRegion string, coreDNSVersion string) string {
+	return fmt.Sprintf("%s/%s:v%s", getRepoDomain(awsRegion), coreDNSRepoPath, coreDNSVersion)
+}
+
+func vpcCNITargetImage(awsRegion string, vpcCNIVersion string) string {
+	return fmt.Sprintf("%s/%s:v%s", getRepoDomain(awsRegion), vpcCNIRepoPath, vpcCNIVersion)
+}
+
 // updateCorednsConfigMapFor170Compatibility updates the ConfigMap to remove traces of the upstream keyword, which was
 // removed starting with 1.7.0.
 func updateCorednsConfigMapFor170Compatibility(clientset *kubernetes.Clientset) error {
 	logger := logging.GetProjectLogger()
-	corednsConfigMap, err := getCorednsConfigMap(clientset)
-	if err != nil {
-		return err
-	}
-	if strings.Contains(corednsConfigMap.Data[corednsConfigMapConfigKey], "upstream") {
-		logger.Info("Detected old configuration for coredns. Reformatting configuration to latest.")
-		if err := removeUpstreamKeywordFromCorednsConfigMap(clientset, corednsConfigMap); err != nil {
+	return kubehelper.RetryOnConflictOrServerError(func() error {
+		corednsConfigMap, err := getCorednsConfigMap(clientset)
+		if err != nil {
 			return err
 		}
-	}
-	return nil
+		if strings.Contains(corednsConfigMap.Data[corednsConfigMapConfigKey], "upstream") {
+			logger.Info("Detected old configuration for coredns. Reformatting configuration to latest.")
+			if err := removeUpstreamKeywordFromCorednsConfigMap(clientset, corednsConfigMap); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // updateCorednsPermissionsFor183Compatibility updates the coredns ClusterRole to include permissions that are
 // additionally needed starting with 1.8.3.
 func updateCorednsPermissionsFor183Compatibility(clientset *kubernetes.Clientset) error {
+	return kubehelper.RetryOnConflictOrServerError(func() error {
+		return addCorednsEndpointSlicesPermissions(clientset)
+	})
+}
+
+// addCorednsEndpointSlicesPermissions adds the list and watch permissions on endpointslices to the latest version of the
+// coredns ClusterRole, if they are missing.
+func addCorednsEndpointSlicesPermissions(clientset *kubernetes.Clientset) error {
 	logger := logging.GetProjectLogger()
 
 	corednsClusterRole, err := getCorednsClusterRole(clientset)
@@ -412,6 +707,7 @@ func updateCorednsPermissionsFor183Compatibility(clientset *kubernetes.Clientset
 	corednsClusterRole.Rules = append(corednsClusterRole.Rules, newRule)
 
 	// Now save the updated ClusterRole
+	kubectl.MarkAsModifiedByKubergrunt(&corednsClusterRole.ObjectMeta)
 	clusterRoleAPI := clientset.RbacV1().ClusterRoles()
 	_, err = clusterRoleAPI.Update(context.Background(), corednsClusterRole, metav1.UpdateOptions{})
 	return errors.WithStackTrace(err)
@@ -456,8 +752,51 @@ func getCurrentDeployedCoreDNSImage(clientset *kubernetes.Clientset) (string, er
 	return deploymentContainers[0].Image, nil
 }
 
+// getCurrentDeployedVPCCNIImage will return the currently configured image of the aws-node container on the VPC CNI
+// daemonset. Newer versions of the VPC CNI run additional containers in the daemonset, so we look up the container by
+// name.
+func getCurrentDeployedVPCCNIImage(clientset *kubernetes.Clientset) (string, error) {
+	daemonset, err := clientset.AppsV1().DaemonSets(componentNamespace).Get(context.Background(), vpcCNIDaemonSetName, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	for _, container := range daemonset.Spec.Template.Spec.Containers {
+		if container.Name == vpcCNIContainerName {
+			return container.Image, nil
+		}
+	}
+	err = CoreComponentUnexpectedConfigurationErr{
+		component: VPCCNIComponentName,
+		reason:    fmt.Sprintf("could not find container %s", vpcCNIContainerName),
+	}
+	return "", errors.WithStackTrace(err)
+}
+
 // updateCoreDNSDeploymentImage will update the deployed coredns Deployment to the specified target container image.
-func updateCoreDNSDeploymentImage(clientset *kubernetes.Clientset, targetImage string) error {
+func updateCoreDNSDeploymentImage(clientset *kubernetes.Clientset, targetImage string, applyConfig ApplyConfig) error {
+	deploymentAPI := clientset.AppsV1().Deployments(componentNamespace)
+	if applyConfig.ServerSide {
+		// The apply patch needs the name of the container, which is the only one, as checked when looking up the
+		// current image.
+		deployment, err := deploymentAPI.Get(context.Background(), corednsDeploymentName, metav1.GetOptions{})
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		patch, err := coreDNSImageApplyPatch(deployment.Spec.Template.Spec.Containers[0].Name, targetImage)
+		if err != nil {
+			return err
+		}
+		err = kubehelper.RetryOnConflictOrServerError(func() error {
+			_, err := deploymentAPI.Patch(context.Background(), corednsDeploymentName, k8stypes.ApplyPatchType, patch, serverSideApplyOptions(applyConfig))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return annotateDeploymentAsModified(clientset, corednsDeploymentName)
+	}
+
 	patch := []jsonpatch.PatchString{
 		{
 			Op: jsonpatch.ReplaceOp,
@@ -470,11 +809,26 @@ func updateCoreDNSDeploymentImage(clientset *kubernetes.Clientset, targetImage s
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
-	deploymentAPI := clientset.AppsV1().Deployments(componentNamespace)
-	if _, err := deploymentAPI.Patch(context.Background(), corednsDeploymentName, k8stypes.JSONPatchType, patchOpJson, metav1.PatchOptions{}); err != nil {
-		return errors.WithStackTrace(err)
+	err = kubehelper.RetryOnConflictOrServerError(func() error {
+		_, err := deploymentAPI.Patch(context.Background(), corednsDeploymentName, k8stypes.JSONPatchType, patchOpJson, metav1.PatchOptions{})
+		return err
+	})
+	if err != nil {
+		return err
 	}
-	return nil
+	return annotateDeploymentAsModified(clientset, corednsDeploymentName)
+}
+
+// coreDNSImageApplyPatch returns the server-side apply patch that only sets the image of the given container of the
+// coredns Deployment, so that the field manager only takes ownership of the image.
+func coreDNSImageApplyPatch(containerName string, targetImage string) ([]byte, error) {
+	deployment := appsv1apply.Deployment(corednsDeploymentName, componentNamespace).
+		WithSpec(appsv1apply.DeploymentSpec().WithTemplate(imagePodTemplateApplyConfig(containerName, targetImage)))
+	patch, err := json.Marshal(deployment)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return patch, nil
 }
 
 // getCorednsConfigMap returns the configmap object containing the coredns configuration for the EKS cluster.
@@ -507,8 +861,9 @@ func getBaseURLForVPCCNIManifest(vpcCNIVersion string) (string, error) {
 // updateVPCCNI will apply the manifest to deploy the latest patch release of the target AWS VPC CNI version. Ideally we
 // would implement this using the raw Kubernetes API, but the CNI manifest contains additional resources on top of the
 // daemonset, and thus it is better to apply the manifests directly using kubectl than to translate it into underlying
-// API calls.
-func updateVPCCNI(kubectlOptions *kubectl.KubectlOptions, region string, vpcCNIVersion string) error {
+// API calls. With server-side apply, the manifest is applied with `kubectl apply --server-side`, with the same field
+// manager as the other components.
+func updateVPCCNI(kubectlOptions *kubectl.KubectlOptions, region string, vpcCNIVersion string, applyConfig ApplyConfig) error {
 	var manifestPath string
 
 	// Figure out the manifest URL based on region
@@ -541,7 +896,14 @@ func updateVPCCNI(kubectlOptions *kubectl.KubectlOptions, region string, vpcCNIV
 			return err
 		}
 	}
-	return kubectl.RunKubectl(kubectlOptions, "apply", "-f", manifestPath)
+	args := []string{"apply", "-f", manifestPath}
+	if applyConfig.ServerSide {
+		args = append(args, "--server-side", "--force-conflicts", "--field-manager", applyConfig.fieldManager())
+	}
+	if err := kubectl.RunKubectl(kubectlOptions, args...); err != nil {
+		return err
+	}
+	return kubectl.AnnotateAsModifiedByKubergrunt(kubectlOptions, "daemonset/"+vpcCNIDaemonSetName, componentNamespace)
 }
 
 // downloadVPCCNIManifestAndUpdateRegion will download the VPC CNI Kubernetes manifest at the given URL, update the
@@ -554,7 +916,11 @@ func downloadVPCCNIManifestAndUpdateRegion(url string, fpath string, region stri
 	}
 	defer out.Close()
 
-	resp, err := http.Get(url)
+	httpClient, err := eksawshelper.NewHTTPClient()
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Get(url)
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
@@ -609,6 +975,7 @@ func removeUpstreamKeywordFromCorednsConfigMap(clientset *kubernetes.Clientset,
 	corednsConfigMap.Data[corednsConfigMapConfigKey] = newConfigData
 
 	// Now save the new configmap
+	kubectl.MarkAsModifiedByKubergrunt(&corednsConfigMap.ObjectMeta)
 	configMapAPI := clientset.CoreV1().ConfigMaps(corednsConfigMap.ObjectMeta.Namespace)
 	_, err = configMapAPI.Update(context.Background(), corednsConfigMap, metav1.UpdateOptions{})
 	return errors.WithStackTrace(err)
@@ -0,0 +1,135 @@
+package eks
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/blang/semver/v4"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// eksAddonNames maps the names of the core components to the names of the corresponding EKS add-ons, for which AWS
+// publishes the versions that are compatible with each Kubernetes version.
+var eksAddonNames = map[string]string{
+	KubeProxyComponentName: "kube-proxy",
+	CoreDNSComponentName:   "coredns",
+	VPCCNIComponentName:    "vpc-cni",
+}
+
+// checkComponentVersionsCompatibility verifies that each of the given image changes is safe for a cluster running the
+// given Kubernetes version: the target version must be listed by AWS as compatible with the Kubernetes version, and must
+// not downgrade the component across more than one minor version. When allowIncompatible is true, the violations are
+// logged as warnings instead of being returned as errors.
+func checkComponentVersionsCompatibility(
+	eksSvc eksiface.EKSAPI,
+	kubernetesVersion string,
+	diffs []componentImageDiff,
+	allowIncompatible bool,
+) error {
+	logger := logging.GetProjectLogger()
+
+	var violations *multierror.Error
+	for _, diff := range diffs {
+		if diff.currentImage == diff.targetImage {
+			continue
+		}
+		compatibleVersions, err := getCompatibleAddonVersions(eksSvc, eksAddonNames[diff.component], kubernetesVersion)
+		if err != nil {
+			return err
+		}
+		if len(compatibleVersions) == 0 {
+			logger.Warnf("AWS does not list any version of %s compatible with Kubernetes %s. Skipping the compatibility check.", diff.component, kubernetesVersion)
+			continue
+		}
+		if err := checkComponentVersionCompatibility(diff, kubernetesVersion, compatibleVersions); err != nil {
+			violations = multierror.Append(violations, err)
+		}
+	}
+	if violations == nil {
+		return nil
+	}
+
+	if !allowIncompatible {
+		return errors.WithStackTrace(violations.ErrorOrNil())
+	}
+	for _, violation := range violations.Errors {
+		logger.Warnf("WARNING: %s", violation)
+	}
+	logger.Warn("WARNING: Syncing the core components to incompatible versions, as requested with --allow-incompatible. This can break the cluster.")
+	return nil
+}
+
+// checkComponentVersionCompatibility returns an error if the target image of the given change is not one of the
+// compatible versions, or if it downgrades the component across more than one minor version. The versions are compared
+// on the semantic version, ignoring the eksbuild suffixes, which differ between the images and the add-ons. The
+// downgrade check is skipped if the current image is not tagged with a version (e.g., a custom image).
+func checkComponentVersionCompatibility(diff componentImageDiff, kubernetesVersion string, compatibleVersions []string) error {
+	targetTag := imageTagOrImage(diff.targetImage)
+	targetVersion, err := parseComponentVersion(targetTag)
+	if err != nil {
+		return err
+	}
+
+	currentTag := imageTagOrImage(diff.currentImage)
+	if currentVersion, err := parseComponentVersion(currentTag); err == nil {
+		isMajorDowngrade := currentVersion.Major > targetVersion.Major
+		isMultiMinorDowngrade := currentVersion.Major == targetVersion.Major && currentVersion.Minor > targetVersion.Minor+1
+		if isMajorDowngrade || isMultiMinorDowngrade {
+			return ComponentDowngradeErr{component: diff.component, currentVersion: currentTag, targetVersion: targetTag}
+		}
+	}
+
+	for _, compatibleVersion := range compatibleVersions {
+		parsedVersion, err := parseComponentVersion(compatibleVersion)
+		if err == nil && parsedVersion.EQ(targetVersion) {
+			return nil
+		}
+	}
+	return IncompatibleComponentVersionErr{
+		component:          diff.component,
+		version:            targetTag,
+		kubernetesVersion:  kubernetesVersion,
+		compatibleVersions: compatibleVersions,
+	}
+}
+
+// parseComponentVersion parses the semantic version of a core component from an image tag or an add-on version, such as
+// v1.27.6-minimal-eksbuild.2, dropping the leading v and the build suffix after the first dash.
+func parseComponentVersion(version string) (semver.Version, error) {
+	version = strings.TrimPrefix(version, "v")
+	if dashIndex := strings.Index(version, "-"); dashIndex >= 0 {
+		version = version[:dashIndex]
+	}
+	parsedVersion, err := semver.Parse(version)
+	if err != nil {
+		return semver.Version{}, errors.WithStackTrace(err)
+	}
+	return parsedVersion, nil
+}
+
+// getCompatibleAddonVersions returns the versions of the given EKS add-on that AWS lists as compatible with the given
+// Kubernetes version.
+func getCompatibleAddonVersions(eksSvc eksiface.EKSAPI, addonName string, kubernetesVersion string) ([]string, error) {
+	input := &eks.DescribeAddonVersionsInput{
+		AddonName:         aws.String(addonName),
+		KubernetesVersion: aws.String(kubernetesVersion),
+	}
+	versions := []string{}
+	err := eksSvc.DescribeAddonVersionsPages(input, func(page *eks.DescribeAddonVersionsOutput, lastPage bool) bool {
+		for _, addon := range page.Addons {
+			for _, addonVersion := range addon.AddonVersions {
+				versions = append(versions, aws.StringValue(addonVersion.AddonVersion))
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return versions, nil
+}
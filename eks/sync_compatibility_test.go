@@ -0,0 +1,95 @@
+package eks
+
+import (
+	"testing"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAddonVersionsEKS is a stub of the EKS API that returns a single page with the given versions of each add-on, keyed
+// by add-on name.
+type fakeAddonVersionsEKS struct {
+	eksiface.EKSAPI
+
+	addonVersions map[string][]string
+}
+
+func (fake *fakeAddonVersionsEKS) DescribeAddonVersionsPages(input *eks.DescribeAddonVersionsInput, fn func(*eks.DescribeAddonVersionsOutput, bool) bool) error {
+	addon := &eks.AddonInfo{AddonName: input.AddonName}
+	for _, version := range fake.addonVersions[awsgo.StringValue(input.AddonName)] {
+		addon.AddonVersions = append(addon.AddonVersions, &eks.AddonVersionInfo{AddonVersion: awsgo.String(version)})
+	}
+	fn(&eks.DescribeAddonVersionsOutput{Addons: []*eks.AddonInfo{addon}}, true)
+	return nil
+}
+
+func TestCheckComponentVersionCompatibility(t *testing.T) {
+	t.Parallel()
+
+	const repo = "602401143452.dkr.ecr.us-west-2.amazonaws.com/eks/kube-proxy"
+	compatibleVersions := []string{"v1.27.6-eksbuild.2", "v1.27.4-eksbuild.1"}
+
+	testCases := []struct {
+		name         string
+		currentImage string
+		targetImage  string
+		expectedErr  error
+	}{
+		{"Compatible", repo + ":v1.26.9-minimal-eksbuild.2", repo + ":v1.27.6-minimal-eksbuild.1", nil},
+		{"CustomCurrentImage", "kube-proxy:custom", repo + ":v1.27.4-minimal-eksbuild.1", nil},
+		{
+			"NotListed",
+			repo + ":v1.26.9-minimal-eksbuild.2",
+			repo + ":v1.28.2-minimal-eksbuild.1",
+			IncompatibleComponentVersionErr{KubeProxyComponentName, "v1.28.2-minimal-eksbuild.1", "1.27", compatibleVersions},
+		},
+		{
+			"DowngradeAcrossMinors",
+			repo + ":v1.29.0-minimal-eksbuild.1",
+			repo + ":v1.27.6-minimal-eksbuild.1",
+			ComponentDowngradeErr{KubeProxyComponentName, "v1.29.0-minimal-eksbuild.1", "v1.27.6-minimal-eksbuild.1"},
+		},
+		{"DowngradeOneMinor", repo + ":v1.28.2-minimal-eksbuild.1", repo + ":v1.27.6-minimal-eksbuild.1", nil},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			diff := componentImageDiff{KubeProxyComponentName, testCase.currentImage, testCase.targetImage}
+			err := checkComponentVersionCompatibility(diff, "1.27", compatibleVersions)
+			assert.Equal(t, testCase.expectedErr, err)
+		})
+	}
+}
+
+func TestCheckComponentVersionsCompatibility(t *testing.T) {
+	t.Parallel()
+
+	eksSvc := &fakeAddonVersionsEKS{
+		addonVersions: map[string][]string{
+			"coredns": {"v1.10.1-eksbuild.4"},
+			"vpc-cni": {"v1.15.1-eksbuild.1"},
+		},
+	}
+	const repo = "602401143452.dkr.ecr.us-west-2.amazonaws.com"
+	diffs := []componentImageDiff{
+		// Unchanged images are not checked, and components without any listed version are skipped.
+		{KubeProxyComponentName, repo + "/eks/kube-proxy:v1.27.6-minimal-eksbuild.2", repo + "/eks/kube-proxy:v1.27.6-minimal-eksbuild.2"},
+		{CoreDNSComponentName, repo + "/eks/coredns:v1.9.3-eksbuild.7", repo + "/eks/coredns:v1.10.1-eksbuild.4"},
+		{VPCCNIComponentName, repo + "/amazon-k8s-cni:v1.12.6-eksbuild.2", repo + "/amazon-k8s-cni:v1.16.0"},
+	}
+
+	err := checkComponentVersionsCompatibility(eksSvc, "1.27", diffs, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Version v1.16.0 of core component aws-vpc-cni is not compatible with Kubernetes 1.27")
+	assert.NotContains(t, err.Error(), "coredns")
+
+	// With the escape hatch, the violations are only logged.
+	assert.NoError(t, checkComponentVersionsCompatibility(eksSvc, "1.27", diffs, true))
+}
@@ -32,6 +32,93 @@ func TestGetEKSContainerImageURL(t *testing.T) {
 	assert.Equal(t, expected, actual)
 }
 
+func TestSkipComponentsConfigForComponents(t *testing.T) {
+	t.Parallel()
+
+	skipConfig, err := SkipComponentsConfigForComponents([]string{CoreDNSComponentName})
+	require.NoError(t, err)
+	assert.Equal(t, SkipComponentsConfig{KubeProxy: true, CoreDNS: false, VPCCNI: true}, skipConfig)
+
+	skipConfig, err = SkipComponentsConfigForComponents([]string{KubeProxyComponentName, VPCCNIComponentName})
+	require.NoError(t, err)
+	assert.Equal(t, SkipComponentsConfig{KubeProxy: false, CoreDNS: true, VPCCNI: false}, skipConfig)
+
+	_, err = SkipComponentsConfigForComponents([]string{"kube-dns"})
+	assert.Error(t, err)
+}
+
+func TestComponentImageDiffString(t *testing.T) {
+	t.Parallel()
+
+	const repo = "602401143452.dkr.ecr.us-west-2.amazonaws.com/eks/coredns"
+	testCases := []struct {
+		name         string
+		currentImage string
+		targetImage  string
+		expected     string
+	}{
+		{"tag-change", repo + ":v1.8.7", repo + ":v1.9.3", "coredns: v1.8.7 -> v1.9.3"},
+		{"up-to-date", repo + ":v1.9.3", repo + ":v1.9.3", "coredns: v1.9.3 (up to date)"},
+		{"repo-change", "registry.example.com:5000/coredns:v1.8.7", repo + ":v1.9.3", "coredns: registry.example.com:5000/coredns:v1.8.7 -> " + repo + ":v1.9.3"},
+		{"untagged", "coredns", repo + ":v1.9.3", "coredns: coredns -> " + repo + ":v1.9.3"},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			diff := componentImageDiff{CoreDNSComponentName, testCase.currentImage, testCase.targetImage}
+			assert.Equal(t, testCase.expected, diff.String())
+		})
+	}
+}
+
+func TestImageApplyPatches(t *testing.T) {
+	t.Parallel()
+
+	const image = "602401143452.dkr.ecr.us-west-2.amazonaws.com/eks/coredns:v1.10.1-eksbuild.4"
+
+	patch, err := coreDNSImageApplyPatch("coredns", image)
+	require.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`{
+			"apiVersion": "apps/v1",
+			"kind": "Deployment",
+			"metadata": {"name": "coredns", "namespace": "kube-system"},
+			"spec": {"template": {"spec": {"containers": [{"name": "coredns", "image": "`+image+`"}]}}}
+		}`,
+		string(patch),
+	)
+
+	patch, err = kubeProxyImageApplyPatch("kube-proxy", image)
+	require.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`{
+			"apiVersion": "apps/v1",
+			"kind": "DaemonSet",
+			"metadata": {"name": "kube-proxy", "namespace": "kube-system"},
+			"spec": {"template": {"spec": {"containers": [{"name": "kube-proxy", "image": "`+image+`"}]}}}
+		}`,
+		string(patch),
+	)
+}
+
+func TestServerSideApplyOptions(t *testing.T) {
+	t.Parallel()
+
+	options := serverSideApplyOptions(ApplyConfig{ServerSide: true})
+	assert.Equal(t, DefaultFieldManager, options.FieldManager)
+	require.NotNil(t, options.Force)
+	assert.True(t, *options.Force)
+
+	options = serverSideApplyOptions(ApplyConfig{ServerSide: true, FieldManager: "platform-team"})
+	assert.Equal(t, "platform-team", options.FieldManager)
+}
+
 func TestDownloadVPCCNIManifestAndUpdateRegion(t *testing.T) {
 	t.Parallel()
 
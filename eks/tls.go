@@ -6,10 +6,12 @@ import (
 	"encoding/base64"
 	"github.com/gruntwork-io/go-commons/errors"
 	"net/http"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
 )
 
 // loadHttpCA takes base64 encoded certificate authority data and and loads a certificate pool that includes the
-// provided CA data.
+// provided CA data, along with the CA bundle configured with eksawshelper.SetCABundle.
 func loadHttpCA(b64CAData string) (*x509.CertPool, error) {
 	caCert, err := base64.StdEncoding.DecodeString(b64CAData)
 	if err != nil {
@@ -17,23 +19,32 @@ func loadHttpCA(b64CAData string) (*x509.CertPool, error) {
 	}
 	caCertPool := x509.NewCertPool()
 	caCertPool.AppendCertsFromPEM(caCert)
+
+	caBundle, err := eksawshelper.LoadCABundle()
+	if err != nil {
+		return nil, err
+	}
+	caCertPool.AppendCertsFromPEM(caBundle)
 	return caCertPool, nil
 }
 
 // loadHttpClientWithCA takes base64 enconded certificate authority data and loads it into an HTTP client that can
-// verify TLS endpoints with the CA data.
-func loadHttpClientWithCA(b64CAData string) (*http.Client, error) {
+// verify TLS endpoints with the CA data. The requests go through the proxy configured in the environment, with the
+// timeouts of eksawshelper.NewHTTPClient. When serverName is not empty, the certificates are verified against it
+// instead of the host of the requests.
+func loadHttpClientWithCA(b64CAData string, serverName string) (*http.Client, error) {
 	caCertPool, err := loadHttpCA(b64CAData)
 	if err != nil {
 		return nil, err
 	}
 
-	client := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				RootCAs: caCertPool,
-			},
-		},
+	client, err := eksawshelper.NewHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	client.Transport.(*http.Transport).TLSClientConfig = &tls.Config{
+		RootCAs:    caCertPool,
+		ServerName: serverName,
 	}
 	return client, nil
 }
@@ -0,0 +1,285 @@
+package eks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/gruntwork-io/go-commons/errors"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// The operations that can be passed to VerifyAccess to scope which permissions are checked.
+const (
+	// VerifyAccessOperationAll checks the permissions needed by all the operations.
+	VerifyAccessOperationAll = "all"
+
+	// VerifyAccessOperationDeploy checks the permissions needed to roll out worker nodes with deploy, rolling-deploy and
+	// drain.
+	VerifyAccessOperationDeploy = "deploy"
+
+	// VerifyAccessOperationSync checks the permissions needed by sync-core-components.
+	VerifyAccessOperationSync = "sync-core-components"
+
+	// VerifyAccessOperationCleanup checks the permissions needed by the cleanup commands.
+	VerifyAccessOperationCleanup = "cleanup"
+)
+
+// kubernetesPermission is a Kubernetes API permission that is checked with a SelfSubjectAccessReview.
+type kubernetesPermission struct {
+	verb        string
+	group       string
+	resource    string
+	subresource string
+	namespace   string
+}
+
+func (permission kubernetesPermission) String() string {
+	resource := permission.resource
+	if permission.subresource != "" {
+		resource = fmt.Sprintf("%s/%s", resource, permission.subresource)
+	}
+	if permission.group != "" {
+		resource = fmt.Sprintf("%s.%s", resource, permission.group)
+	}
+	if permission.namespace != "" {
+		return fmt.Sprintf("%s %s in %s", permission.verb, resource, permission.namespace)
+	}
+	return fmt.Sprintf("%s %s", permission.verb, resource)
+}
+
+// verifyAccessPermissions lists the Kubernetes permissions needed by each operation, and whether the operation calls the
+// EC2 API.
+var verifyAccessPermissions = map[string]struct {
+	needsEC2   bool
+	kubernetes []kubernetesPermission
+}{
+	VerifyAccessOperationDeploy: {
+		needsEC2: true,
+		kubernetes: []kubernetesPermission{
+			{verb: "list", resource: "nodes"},
+			{verb: "patch", resource: "nodes"},
+			{verb: "list", resource: "pods"},
+			{verb: "create", resource: "pods", subresource: "eviction"},
+		},
+	},
+	VerifyAccessOperationSync: {
+		kubernetes: []kubernetesPermission{
+			{verb: "get", group: "apps", resource: "daemonsets", namespace: "kube-system"},
+			{verb: "patch", group: "apps", resource: "daemonsets", namespace: "kube-system"},
+			{verb: "get", group: "apps", resource: "deployments", namespace: "kube-system"},
+			{verb: "patch", group: "apps", resource: "deployments", namespace: "kube-system"},
+		},
+	},
+	VerifyAccessOperationCleanup: {
+		needsEC2: true,
+		kubernetes: []kubernetesPermission{
+			{verb: "list", resource: "pods"},
+		},
+	},
+}
+
+// VerifyAccessOperations returns the operations that can be passed to VerifyAccess.
+func VerifyAccessOperations() []string {
+	operations := []string{VerifyAccessOperationAll}
+	for operation := range verifyAccessPermissions {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations[1:])
+	return operations
+}
+
+// AccessCheckResult is the outcome of one of the permission checks done by VerifyAccess.
+type AccessCheckResult struct {
+	// Name describes the permission that was checked.
+	Name string
+
+	// Passed is true when the permission is granted.
+	Passed bool
+
+	// Detail holds additional information about the outcome, such as the reason the check failed.
+	Detail string
+}
+
+// VerifyAccess checks that the current credentials have the permissions needed to run the given operation against the
+// EKS cluster, using cheap read only probes: STS GetCallerIdentity, EKS DescribeCluster, a dry run EC2
+// DescribeSecurityGroups call, and a Kubernetes SelfSubjectAccessReview for each of the API permissions that the
+// operation needs. The operation must be one of VerifyAccessOperations. All the checks are run regardless of the outcome
+// of the others, and the results are returned in the order they were checked. If any check failed, a
+// MissingPermissionsError is returned along with the results.
+func VerifyAccess(clusterArn string, operation string) ([]AccessCheckResult, error) {
+	logger := logging.GetProjectLogger().WithField("clusterArn", clusterArn)
+	ctx := context.Background()
+
+	if operation != VerifyAccessOperationAll {
+		if _, isKnownOperation := verifyAccessPermissions[operation]; !isKnownOperation {
+			return nil, errors.WithStackTrace(UnknownVerifyAccessOperationError{Operation: operation})
+		}
+	}
+
+	region, err := eksawshelper.GetRegionFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	clusterID, err := eksawshelper.GetClusterNameFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	sess, err := eksawshelper.NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	logger.Infof("Successfully authenticated with AWS")
+
+	newKubernetesClient := func() (kubernetes.Interface, error) {
+		return kubectl.GetKubernetesClientFromOptions(&kubectl.KubectlOptions{EKSClusterArn: clusterArn})
+	}
+	results := verifyAccess(ctx, sts.New(sess), eks.New(sess), eksawshelper.NewEC2Client(sess), newKubernetesClient, clusterID, operation)
+
+	failedChecks := []string{}
+	for _, result := range results {
+		if !result.Passed {
+			failedChecks = append(failedChecks, result.Name)
+		}
+	}
+	if len(failedChecks) > 0 {
+		return results, errors.WithStackTrace(MissingPermissionsError{Operation: operation, FailedChecks: failedChecks})
+	}
+	logger.Infof("All the permissions needed for the %s operation are granted", operation)
+	return results, nil
+}
+
+// verifyAccess runs the checks of VerifyAccess with the given clients. The Kubernetes client is only created once the
+// AWS checks are done, as it needs access to the EKS cluster.
+func verifyAccess(
+	ctx context.Context,
+	stsSvc stsiface.STSAPI,
+	eksSvc eksiface.EKSAPI,
+	ec2Svc ec2iface.EC2API,
+	newKubernetesClient func() (kubernetes.Interface, error),
+	clusterID string,
+	operation string,
+) []AccessCheckResult {
+	needsEC2, kubernetesPermissions := permissionsForOperation(operation)
+
+	results := []AccessCheckResult{}
+	identity, err := stsSvc.GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		results = append(results, failedAccessCheck("AWS: sts:GetCallerIdentity", err))
+	} else {
+		results = append(results, AccessCheckResult{Name: "AWS: sts:GetCallerIdentity", Passed: true, Detail: aws.StringValue(identity.Arn)})
+	}
+
+	cluster, err := eksSvc.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{Name: aws.String(clusterID)})
+	if err != nil {
+		results = append(results, failedAccessCheck("AWS: eks:DescribeCluster", err))
+	} else {
+		results = append(results, AccessCheckResult{Name: "AWS: eks:DescribeCluster", Passed: true, Detail: aws.StringValue(cluster.Cluster.Status)})
+	}
+
+	if needsEC2 {
+		// With DryRun set, EC2 only checks the permissions, responding with DryRunOperation if the call would succeed.
+		_, err := ec2Svc.DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{DryRun: aws.Bool(true)})
+		if err != nil && !isDryRunOperationErr(err) {
+			results = append(results, failedAccessCheck("AWS: ec2:DescribeSecurityGroups", err))
+		} else {
+			results = append(results, AccessCheckResult{Name: "AWS: ec2:DescribeSecurityGroups", Passed: true})
+		}
+	}
+
+	if len(kubernetesPermissions) == 0 {
+		return results
+	}
+	client, err := newKubernetesClient()
+	for _, permission := range kubernetesPermissions {
+		name := fmt.Sprintf("Kubernetes: %s", permission)
+		if err != nil {
+			results = append(results, failedAccessCheck(name, err))
+			continue
+		}
+		results = append(results, checkKubernetesPermission(ctx, client, name, permission))
+	}
+	return results
+}
+
+// permissionsForOperation returns whether the operation calls the EC2 API, and the Kubernetes permissions it needs.
+func permissionsForOperation(operation string) (bool, []kubernetesPermission) {
+	if operation != VerifyAccessOperationAll {
+		permissions := verifyAccessPermissions[operation]
+		return permissions.needsEC2, permissions.kubernetes
+	}
+
+	needsEC2 := false
+	kubernetesPermissions := []kubernetesPermission{}
+	for _, operation := range VerifyAccessOperations()[1:] {
+		permissions := verifyAccessPermissions[operation]
+		needsEC2 = needsEC2 || permissions.needsEC2
+		for _, permission := range permissions.kubernetes {
+			if !containsKubernetesPermission(kubernetesPermissions, permission) {
+				kubernetesPermissions = append(kubernetesPermissions, permission)
+			}
+		}
+	}
+	return needsEC2, kubernetesPermissions
+}
+
+func containsKubernetesPermission(permissions []kubernetesPermission, permission kubernetesPermission) bool {
+	for _, existing := range permissions {
+		if existing == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// checkKubernetesPermission asks the Kubernetes API server whether the current user has the given permission.
+func checkKubernetesPermission(
+	ctx context.Context,
+	client kubernetes.Interface,
+	name string,
+	permission kubernetesPermission,
+) AccessCheckResult {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   permission.namespace,
+				Verb:        permission.verb,
+				Group:       permission.group,
+				Resource:    permission.resource,
+				Subresource: permission.subresource,
+			},
+		},
+	}
+	response, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return failedAccessCheck(name, err)
+	}
+	if !response.Status.Allowed {
+		detail := "not allowed"
+		if reason := strings.TrimSpace(response.Status.Reason); reason != "" {
+			detail = reason
+		}
+		return AccessCheckResult{Name: name, Passed: false, Detail: detail}
+	}
+	return AccessCheckResult{Name: name, Passed: true}
+}
+
+func failedAccessCheck(name string, err error) AccessCheckResult {
+	return AccessCheckResult{Name: name, Passed: false, Detail: err.Error()}
+}
@@ -0,0 +1,128 @@
+package eks
+
+import (
+	"context"
+	"testing"
+
+	awsgo "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type fakeSTS struct {
+	stsiface.STSAPI
+}
+
+func (fake *fakeSTS) GetCallerIdentityWithContext(ctx awsgo.Context, input *sts.GetCallerIdentityInput, opts ...request.Option) (*sts.GetCallerIdentityOutput, error) {
+	return &sts.GetCallerIdentityOutput{Arn: awsgo.String("arn:aws:iam::123456789012:user/ci")}, nil
+}
+
+func TestVerifyAccessReportsEachCheck(t *testing.T) {
+	t.Parallel()
+
+	// Only allow reading the resources.
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		verb := review.Spec.ResourceAttributes.Verb
+		review.Status.Allowed = verb == "get" || verb == "list"
+		return true, review, nil
+	})
+	newKubernetesClient := func() (kubernetes.Interface, error) { return client, nil }
+
+	results := verifyAccess(
+		context.Background(),
+		&fakeSTS{},
+		&fakeEKS{cluster: &eks.Cluster{Status: awsgo.String(eks.ClusterStatusActive)}},
+		&fakeEC2{describeSecurityGroupsErr: awserr.New("UnauthorizedOperation", "You are not authorized to perform this operation.", nil)},
+		newKubernetesClient,
+		"prod",
+		VerifyAccessOperationDeploy,
+	)
+
+	passed := map[string]bool{}
+	for _, result := range results {
+		passed[result.Name] = result.Passed
+	}
+	assert.Equal(
+		t,
+		map[string]bool{
+			"AWS: sts:GetCallerIdentity":       true,
+			"AWS: eks:DescribeCluster":         true,
+			"AWS: ec2:DescribeSecurityGroups":  false,
+			"Kubernetes: list nodes":           true,
+			"Kubernetes: patch nodes":          false,
+			"Kubernetes: list pods":            true,
+			"Kubernetes: create pods/eviction": false,
+		},
+		passed,
+	)
+	require.Equal(t, "arn:aws:iam::123456789012:user/ci", results[0].Detail)
+}
+
+func TestVerifyAccessScopesChecksToOperation(t *testing.T) {
+	t.Parallel()
+
+	kubernetesErr := awserr.New("Unauthorized", "can not reach the cluster", nil)
+	newKubernetesClient := func() (kubernetes.Interface, error) { return nil, kubernetesErr }
+
+	results := verifyAccess(
+		context.Background(),
+		&fakeSTS{},
+		&fakeEKS{},
+		&fakeEC2{describeSecurityGroupsErr: awserr.New("DryRunOperation", "Request would have succeeded, but DryRun flag is set.", nil)},
+		newKubernetesClient,
+		"prod",
+		VerifyAccessOperationSync,
+	)
+
+	names := []string{}
+	for _, result := range results {
+		names = append(names, result.Name)
+	}
+	// The sync operation does not call EC2, and the Kubernetes checks fail when the cluster can not be reached.
+	assert.Equal(
+		t,
+		[]string{
+			"AWS: sts:GetCallerIdentity",
+			"AWS: eks:DescribeCluster",
+			"Kubernetes: get daemonsets.apps in kube-system",
+			"Kubernetes: patch daemonsets.apps in kube-system",
+			"Kubernetes: get deployments.apps in kube-system",
+			"Kubernetes: patch deployments.apps in kube-system",
+		},
+		names,
+	)
+	assert.False(t, results[1].Passed)
+	for _, result := range results[2:] {
+		assert.False(t, result.Passed)
+		assert.Contains(t, result.Detail, "can not reach the cluster")
+	}
+}
+
+func TestPermissionsForAllOperationsAreDeduplicated(t *testing.T) {
+	t.Parallel()
+
+	needsEC2, permissions := permissionsForOperation(VerifyAccessOperationAll)
+	assert.True(t, needsEC2)
+	seen := map[kubernetesPermission]bool{}
+	for _, permission := range permissions {
+		assert.False(t, seen[permission], "duplicate permission %s", permission)
+		seen[permission] = true
+	}
+	for operation, operationPermissions := range verifyAccessPermissions {
+		for _, permission := range operationPermissions.kubernetes {
+			assert.True(t, seen[permission], "permission %s of operation %s is not checked", permission, operation)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package eks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// selfSubjectReviewVersions are the versions of the authentication.k8s.io API that serve SelfSubjectReview, in order of
+// preference. The API is GA in Kubernetes 1.28, beta in 1.27, and alpha (disabled by default) in 1.26.
+var selfSubjectReviewVersions = []string{"v1", "v1beta1", "v1alpha1"}
+
+// TokenIdentity is the identity that the Kubernetes API server of an EKS cluster authenticates a token as, i.e., the
+// Kubernetes user and groups that the IAM identity is mapped to in the aws-auth ConfigMap or the EKS access entries.
+type TokenIdentity struct {
+	Username string              `json:"username"`
+	UID      string              `json:"uid"`
+	Groups   []string            `json:"groups"`
+	Extra    map[string][]string `json:"extra,omitempty"`
+
+	// IdentityKnown is false when the API server does not serve SelfSubjectReview, in which case the token is only
+	// known to authenticate, and the other fields are empty.
+	IdentityKnown bool `json:"identity_known"`
+}
+
+// VerifyToken generates a token for the EKS cluster with the current AWS credentials, as `kubergrunt eks token` does,
+// and checks that the Kubernetes API server accepts it. See VerifyGivenToken.
+func VerifyToken(clusterArn string) (*TokenIdentity, error) {
+	return VerifyGivenToken(clusterArn, "")
+}
+
+// VerifyGivenToken checks that the Kubernetes API server of the EKS cluster accepts the given token, and returns the
+// identity it is authenticated as, using a SelfSubjectReview. On clusters that do not serve SelfSubjectReview (before
+// Kubernetes 1.27), this falls back to calling the /version endpoint, which only confirms that the token authenticates.
+// The token can either be the raw bearer token, or the ExecCredential JSON output of `kubergrunt eks token`. When the
+// token is empty, a new one is generated with the current AWS credentials.
+//
+// A TokenNotAuthenticatedError is returned when the API server rejects the token, which happens when the token is
+// expired, or when the IAM identity that signed it is not mapped to a Kubernetes user in the aws-auth ConfigMap or the
+// EKS access entries.
+func VerifyGivenToken(clusterArn string, token string) (*TokenIdentity, error) {
+	logger := logging.GetProjectLogger().WithField("clusterArn", clusterArn)
+
+	clusterInfo, err := eksawshelper.GetClusterInfo(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		logger.Info("Generating token for the EKS cluster with the current AWS credentials")
+		tok, _, err := eksawshelper.GetKubernetesTokenForCluster(clusterInfo.Name)
+		if err != nil {
+			return nil, err
+		}
+		token = tok.Token
+	} else {
+		token, err = parseToken(token)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	endpoint, tlsServerName, err := kubehelper.ResolveClusterEndpoint(clusterInfo.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubectl.GetKubernetesClientFromOptions(&kubectl.KubectlOptions{
+		Server:                        endpoint,
+		Base64PEMCertificateAuthority: clusterInfo.CertificateAuthorityData,
+		BearerToken:                   token,
+		TLSServerName:                 tlsServerName,
+	})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	identity, err := reviewTokenIdentity(context.Background(), client)
+	if apierrors.IsUnauthorized(errors.Unwrap(err)) {
+		return nil, errors.WithStackTrace(TokenNotAuthenticatedError{ClusterArn: clusterArn, UnderlyingErr: errors.Unwrap(err)})
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !identity.IdentityKnown {
+		logger.Warn("The Kubernetes API server does not serve SelfSubjectReview. The token authenticates, but the identity it maps to is unknown.")
+	} else if len(identity.Groups) <= 1 {
+		// Every authenticated user is in system:authenticated, which grants no access to the cluster resources.
+		logger.Warnf("The token authenticates as %s, which is not in any group other than system:authenticated. Check the groups mapped to the IAM identity in the aws-auth ConfigMap.", identity.Username)
+	} else {
+		logger.Infof("The token authenticates as %s in groups %s", identity.Username, strings.Join(identity.Groups, ", "))
+	}
+	return identity, nil
+}
+
+// reviewTokenIdentity asks the Kubernetes API server who the client is authenticated as with a SelfSubjectReview,
+// trying each of the versions of the API in turn, as client-go only supports the alpha version. Falls back to calling
+// the /version endpoint when none of the versions is served.
+func reviewTokenIdentity(ctx context.Context, client kubernetes.Interface) (*TokenIdentity, error) {
+	logger := logging.GetProjectLogger()
+	restClient := client.Discovery().RESTClient()
+
+	for _, version := range selfSubjectReviewVersions {
+		body := fmt.Sprintf(`{"apiVersion":"authentication.k8s.io/%s","kind":"SelfSubjectReview"}`, version)
+		data, err := restClient.Post().
+			AbsPath("/apis/authentication.k8s.io", version, "selfsubjectreviews").
+			SetHeader("Content-Type", "application/json").
+			Body([]byte(body)).
+			Do(ctx).
+			Raw()
+		if apierrors.IsNotFound(err) {
+			logger.Debugf("SelfSubjectReview %s is not served by the Kubernetes API server", version)
+			continue
+		} else if err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+
+		var review struct {
+			Status struct {
+				UserInfo authenticationv1.UserInfo `json:"userInfo"`
+			} `json:"status"`
+		}
+		if err := json.Unmarshal(data, &review); err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		userInfo := review.Status.UserInfo
+		identity := &TokenIdentity{
+			Username:      userInfo.Username,
+			UID:           userInfo.UID,
+			Groups:        userInfo.Groups,
+			IdentityKnown: true,
+		}
+		if len(userInfo.Extra) > 0 {
+			identity.Extra = map[string][]string{}
+			for key, values := range userInfo.Extra {
+				identity.Extra[key] = values
+			}
+		}
+		return identity, nil
+	}
+
+	if _, err := client.Discovery().ServerVersion(); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return &TokenIdentity{}, nil
+}
+
+// parseToken returns the bearer token from the given input, which is either the token itself, or the ExecCredential
+// JSON output of `kubergrunt eks token`.
+func parseToken(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if !strings.HasPrefix(input, "{") {
+		return input, nil
+	}
+
+	var execCredential struct {
+		Status struct {
+			Token string `json:"token"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(input), &execCredential); err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	if execCredential.Status.Token == "" {
+		return "", errors.WithStackTrace(NoTokenInExecCredentialError{})
+	}
+	return execCredential.Status.Token, nil
+}
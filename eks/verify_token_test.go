@@ -0,0 +1,134 @@
+package eks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const testSelfSubjectReviewResponse = `{
+	"apiVersion": "authentication.k8s.io/%s",
+	"kind": "SelfSubjectReview",
+	"status": {
+		"userInfo": {
+			"username": "admin",
+			"uid": "aws-iam-authenticator:111111111111:AROAEXAMPLE",
+			"groups": ["system:masters", "system:authenticated"],
+			"extra": {"arn": ["arn:aws:sts::111111111111:assumed-role/admin/session"]}
+		}
+	}
+}`
+
+func TestReviewTokenIdentity(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		servedVersion string
+		expectedKnown bool
+	}{
+		{"GA", "v1", true},
+		{"Beta", "v1beta1", true},
+		{"NotServed", "", false},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case fmt.Sprintf("/apis/authentication.k8s.io/%s/selfsubjectreviews", testCase.servedVersion):
+					assert.Equal(t, http.MethodPost, r.Method)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusCreated)
+					fmt.Fprintf(w, testSelfSubjectReviewResponse, testCase.servedVersion)
+				case "/version":
+					w.Header().Set("Content-Type", "application/json")
+					fmt.Fprint(w, `{"major": "1", "minor": "26", "gitVersion": "v1.26.4-eks-0a21954"}`)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			identity, err := reviewTokenIdentity(context.Background(), newTestKubernetesClient(t, server.URL))
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedKnown, identity.IdentityKnown)
+			if !testCase.expectedKnown {
+				assert.Empty(t, identity.Username)
+				return
+			}
+			assert.Equal(t, "admin", identity.Username)
+			assert.Equal(t, "aws-iam-authenticator:111111111111:AROAEXAMPLE", identity.UID)
+			assert.Equal(t, []string{"system:masters", "system:authenticated"}, identity.Groups)
+			assert.Equal(t, []string{"arn:aws:sts::111111111111:assumed-role/admin/session"}, identity.Extra["arn"])
+		})
+	}
+}
+
+func TestReviewTokenIdentityUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"kind": "Status", "apiVersion": "v1", "status": "Failure", "message": "Unauthorized", "reason": "Unauthorized", "code": 401}`)
+	}))
+	defer server.Close()
+
+	_, err := reviewTokenIdentity(context.Background(), newTestKubernetesClient(t, server.URL))
+	require.Error(t, err)
+	assert.True(t, apierrors.IsUnauthorized(errors.Unwrap(err)))
+}
+
+func TestParseToken(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		input         string
+		expectedToken string
+		expectErr     bool
+	}{
+		{"RawToken", "k8s-aws-v1.abc\n", "k8s-aws-v1.abc", false},
+		{"ExecCredential", `{"kind": "ExecCredential", "apiVersion": "client.authentication.k8s.io/v1beta1", "status": {"token": "k8s-aws-v1.abc"}}`, "k8s-aws-v1.abc", false},
+		{"ExecCredentialWithoutToken", `{"kind": "ExecCredential", "status": {}}`, "", true},
+		{"InvalidJSON", `{"kind": `, "", true},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			token, err := parseToken(testCase.input)
+			if testCase.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedToken, token)
+		})
+	}
+}
+
+func newTestKubernetesClient(t *testing.T, host string) kubernetes.Interface {
+	client, err := kubernetes.NewForConfig(&rest.Config{Host: host})
+	require.NoError(t, err)
+	return client
+}
@@ -3,14 +3,15 @@ package eksawshelper
 import (
 	"strings"
 
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/arn"
 	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/gruntwork-io/go-commons/errors"
 )
 
-// GetClusterNameFromArn extracts the EKS cluster name given the ARN for the cluster.
+// GetClusterNameFromArn extracts the EKS cluster name given the ARN for the cluster. Returns an InvalidClusterArnError
+// if the input is not a valid EKS cluster ARN.
 func GetClusterNameFromArn(eksClusterArnString string) (string, error) {
-	eksClusterArn, err := arn.Parse(eksClusterArnString)
+	eksClusterArn, err := parseClusterArn(eksClusterArnString)
 	if err != nil {
 		return "", err
 	}
@@ -20,15 +21,38 @@ func GetClusterNameFromArn(eksClusterArnString string) (string, error) {
 	return strings.Join(strings.Split(eksClusterArn.Resource, "/")[1:], "/"), nil
 }
 
-// GetRegionFromArn extracts the AWS region that the EKS cluster is in from the ARN of the EKS cluster.
+// GetRegionFromArn extracts the AWS region that the EKS cluster is in from the ARN of the EKS cluster. Returns an
+// InvalidClusterArnError if the input is not a valid EKS cluster ARN.
 func GetRegionFromArn(eksClusterArnString string) (string, error) {
-	eksClusterArn, err := arn.Parse(eksClusterArnString)
+	eksClusterArn, err := parseClusterArn(eksClusterArnString)
 	if err != nil {
 		return "", err
 	}
 	return eksClusterArn.Region, nil
 }
 
+// parseClusterArn parses the given EKS cluster ARN, checking that it is of the form
+// arn:PARTITION:eks:REGION:ACCOUNT_ID:cluster/CLUSTER_NAME.
+func parseClusterArn(eksClusterArnString string) (arn.ARN, error) {
+	eksClusterArn, err := arn.Parse(eksClusterArnString)
+	if err != nil {
+		return arn.ARN{}, errors.WithStackTrace(InvalidClusterArnError{Arn: eksClusterArnString, Reason: err.Error()})
+	}
+
+	resourceType, clusterName, _ := strings.Cut(eksClusterArn.Resource, "/")
+	switch {
+	case eksClusterArn.Service != "eks":
+		return arn.ARN{}, errors.WithStackTrace(InvalidClusterArnError{Arn: eksClusterArnString, Reason: "the service is not eks"})
+	case resourceType != "cluster":
+		return arn.ARN{}, errors.WithStackTrace(InvalidClusterArnError{Arn: eksClusterArnString, Reason: "the resource type is not cluster"})
+	case clusterName == "":
+		return arn.ARN{}, errors.WithStackTrace(InvalidClusterArnError{Arn: eksClusterArnString, Reason: "the cluster name is missing"})
+	case eksClusterArn.Region == "":
+		return arn.ARN{}, errors.WithStackTrace(InvalidClusterArnError{Arn: eksClusterArnString, Reason: "the region is missing"})
+	}
+	return eksClusterArn, nil
+}
+
 // GetClusterArnByNameAndRegion looks up the EKS Cluster ARN using the region and EKS Cluster Name.
 // For instances where we don't have the EKS Cluster ARN, such as within the Fargate Profile resource.
 func GetClusterArnByNameAndRegion(eksClusterName string, region string) (string, error) {
@@ -37,14 +61,9 @@ func GetClusterArnByNameAndRegion(eksClusterName string, region string) (string,
 		return "", err
 	}
 
-	svc := eks.New(sess)
-	input := &eks.DescribeClusterInput{
-		Name: aws.String(eksClusterName),
-	}
-
-	output, err := svc.DescribeCluster(input)
+	cluster, err := describeCluster(eks.New(sess), eksClusterName, eksClusterName, clusterNotFoundRetryTimeout, clusterNotFoundInitialBackoff)
 	if err != nil {
 		return "", err
 	}
-	return *output.Cluster.Arn, nil
+	return *cluster.Arn, nil
 }
@@ -3,6 +3,7 @@ package eksawshelper
 import (
 	"testing"
 
+	"github.com/gruntwork-io/go-commons/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -15,6 +16,8 @@ func TestGetClusterNameFromArn(t *testing.T) {
 	}{
 		{"arn:aws:eks:us-east-2:111111111111:cluster/eks-cluster-srlBd2", "eks-cluster-srlBd2"},
 		{"arn:aws:eks:us-east-2:111111111111:cluster/eks-cluster/srlBd2", "eks-cluster/srlBd2"},
+		{"arn:aws-us-gov:eks:us-gov-west-1:111111111111:cluster/gov-cluster", "gov-cluster"},
+		{"arn:aws-cn:eks:cn-north-1:111111111111:cluster/cn-cluster", "cn-cluster"},
 	}
 	for _, testcase := range testCases {
 		testcase := testcase
@@ -31,12 +34,7 @@ func TestGetClusterNameFromArn(t *testing.T) {
 func TestGetClusterNameFromArnErrorCases(t *testing.T) {
 	t.Parallel()
 
-	var testCases = []string{
-		"eks-cluster-srlBd2",
-		"",
-		"aws:eks:us-east-2:111111111111:cluster/eks-cluster/srlBd2",
-	}
-	for _, testcase := range testCases {
+	for _, testcase := range invalidClusterArnTestCases {
 		testcase := testcase
 		t.Run(testcase, func(t *testing.T) {
 			t.Parallel()
@@ -44,6 +42,9 @@ func TestGetClusterNameFromArnErrorCases(t *testing.T) {
 			name, err := GetClusterNameFromArn(testcase)
 			assert.Error(t, err)
 			assert.Equal(t, name, "")
+			invalidArnErr, isInvalidArnErr := errors.Unwrap(err).(InvalidClusterArnError)
+			assert.True(t, isInvalidArnErr)
+			assert.Equal(t, testcase, invalidArnErr.Arn)
 		})
 	}
 }
@@ -57,7 +58,8 @@ func TestGetRegionFromArn(t *testing.T) {
 	}{
 		{"arn:aws:eks:us-east-2:111111111111:cluster/eks-cluster-srlBd2", "us-east-2"},
 		{"arn:aws:eks:eu-west-1:111111111111:cluster/eks-cluster/srlBd2", "eu-west-1"},
-		{"arn:aws:eks::111111111111:cluster/eks-cluster/srlBd2", ""},
+		{"arn:aws-us-gov:eks:us-gov-east-1:111111111111:cluster/gov-cluster", "us-gov-east-1"},
+		{"arn:aws-cn:eks:cn-northwest-1:111111111111:cluster/cn-cluster", "cn-northwest-1"},
 	}
 	for _, testcase := range testCases {
 		testcase := testcase
@@ -74,19 +76,29 @@ func TestGetRegionFromArn(t *testing.T) {
 func TestGetRegionFromArnErrorCases(t *testing.T) {
 	t.Parallel()
 
-	var testCases = []string{
-		"eks-cluster-srlBd2",
-		"",
-		"aws:eks:us-east-2:111111111111:cluster/eks-cluster/srlBd2",
-	}
-	for _, testcase := range testCases {
+	for _, testcase := range invalidClusterArnTestCases {
 		testcase := testcase
 		t.Run(testcase, func(t *testing.T) {
 			t.Parallel()
 
-			name, err := GetClusterNameFromArn(testcase)
+			region, err := GetRegionFromArn(testcase)
 			assert.Error(t, err)
-			assert.Equal(t, name, "")
+			assert.Equal(t, region, "")
+			invalidArnErr, isInvalidArnErr := errors.Unwrap(err).(InvalidClusterArnError)
+			assert.True(t, isInvalidArnErr)
+			assert.Equal(t, testcase, invalidArnErr.Arn)
 		})
 	}
 }
+
+// invalidClusterArnTestCases lists inputs that are not valid EKS cluster ARNs.
+var invalidClusterArnTestCases = []string{
+	"eks-cluster-srlBd2",
+	"",
+	"aws:eks:us-east-2:111111111111:cluster/eks-cluster/srlBd2",
+	"arn:aws:eks::111111111111:cluster/eks-cluster/srlBd2",
+	"arn:aws-us-gov:eks::111111111111:cluster/gov-cluster",
+	"arn:aws:ecs:us-east-2:111111111111:cluster/ecs-cluster",
+	"arn:aws:eks:us-east-2:111111111111:fargateprofile/eks-cluster/profile/id",
+	"arn:aws:eks:us-east-2:111111111111:cluster/",
+}
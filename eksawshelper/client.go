@@ -1,24 +1,221 @@
 package eksawshelper
 
 import (
+	"net/http"
+	"os"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/gruntwork-io/go-commons/retry"
+
+	"github.com/gruntwork-io/kubergrunt/logging"
 )
 
+const (
+	// EC2MetadataEndpointEnvVar is the environment variable that can be used to override the endpoint of the EC2 instance
+	// metadata service (IMDS). This is primarily useful for testing against a mock metadata service.
+	EC2MetadataEndpointEnvVar = "KUBERGRUNT_EC2_METADATA_ENDPOINT"
+
+	// Retry settings for fetching credentials when the EC2 instance metadata service is temporarily unavailable.
+	credentialsMaxRetries          = 3
+	credentialsSleepBetweenRetries = 2 * time.Second
+)
+
+// AssumeRoleConfig configures an IAM role that is assumed for all the AWS API calls made by kubergrunt.
+type AssumeRoleConfig struct {
+	// RoleArn is the ARN of the IAM role to assume.
+	RoleArn string
+
+	// ExternalID is the optional external ID to pass when assuming the role.
+	ExternalID string
+
+	// SessionName is the optional name of the assumed role session. When empty, a session name is generated.
+	SessionName string
+}
+
+// assumeRoleConfig is the IAM role that is assumed by every session created with NewAuthenticatedSession. This is set
+// globally from the CLI flags, similar to the log level, so that it applies to every AWS operation regardless of which
+// command is run.
+var assumeRoleConfig *AssumeRoleConfig
+
+// profile is the name of the AWS shared config profile used by NewAuthenticatedSession. This is set globally from the
+// CLI flags, similar to the assume role config. When empty, the default credentials chain is used.
+var profile string
+
+// SetProfile sets the name of the AWS shared config profile to use for all the sessions created by
+// NewAuthenticatedSession. Pass in an empty string to use the default credentials chain.
+func SetProfile(profileName string) {
+	profile = profileName
+}
+
+// GetProfile returns the name of the AWS shared config profile used for all the sessions created by
+// NewAuthenticatedSession, or an empty string if the default credentials chain is used.
+func GetProfile() string {
+	return profile
+}
+
+// requestTimeout is the timeout of the HTTP requests made by the sessions created with NewAuthenticatedSession, and of
+// the generation of EKS tokens as a whole. This is set globally from the CLI flags, similar to the profile. When zero,
+// there is no timeout.
+var requestTimeout time.Duration
+
+// SetRequestTimeout sets the timeout of each HTTP request to the AWS APIs made by the sessions created with
+// NewAuthenticatedSession, and bounds the generation of EKS tokens (including fetching the credentials and assuming the
+// IAM role) to the same duration. Pass in zero to disable the timeout.
+func SetRequestTimeout(timeout time.Duration) {
+	requestTimeout = timeout
+}
+
+// SetAssumeRoleConfig sets the IAM role to assume for all the sessions created by NewAuthenticatedSession. Pass in nil
+// to use the base credentials directly.
+func SetAssumeRoleConfig(config *AssumeRoleConfig) {
+	assumeRoleConfig = config
+}
+
+// GetAssumeRoleConfig returns the IAM role that is assumed for all the sessions created by NewAuthenticatedSession, or
+// nil if no role is assumed.
+func GetAssumeRoleConfig() *AssumeRoleConfig {
+	return assumeRoleConfig
+}
+
 // NewAuthenticatedSession gets an AWS Session, checking that the user has credentials properly configured in their environment.
+// When running on EC2, instance role credentials are fetched from the instance metadata service using IMDSv2 session
+// tokens, retrying if the metadata service is temporarily unavailable. If an IAM role is configured with
+// SetAssumeRoleConfig, the returned session uses the credentials of the assumed role, which are refreshed automatically
+// as they expire. If a profile is configured with SetProfile, the credentials are looked up from that profile of the AWS
+// shared config files. The endpoints are resolved within the partition of the region, so this works the same in the
+// GovCloud and China regions. The requests go through the proxy configured with the HTTPS_PROXY and NO_PROXY environment
+// variables, trust the CA bundle configured with SetCABundle, and use the timeouts configured with SetHTTPConfig.
 func NewAuthenticatedSession(region string) (*session.Session, error) {
-	opts := session.Options{
-		Config:            *(aws.NewConfig().WithRegion(region)),
-		SharedConfigState: session.SharedConfigEnable,
+	return NewAuthenticatedSessionWithProfile(region, profile)
+}
+
+// NewAuthenticatedSessionWithProfile is like NewAuthenticatedSession, but uses the credentials of the given profile of
+// the AWS shared config files (~/.aws/config and ~/.aws/credentials) instead of the globally configured one. This honors
+// the role chaining settings of the profile (`role_arn` with `source_profile` or `credential_source`), prompting for the
+// MFA token on stdin if the profile sets `mfa_serial`. When the profile is empty, the default credentials chain is used.
+func NewAuthenticatedSessionWithProfile(region string, profileName string) (*session.Session, error) {
+	logger := logging.GetProjectLogger()
+
+	var sess *session.Session
+	err := retry.DoWithRetry(
+		logger.Logger,
+		"Get AWS credentials",
+		credentialsMaxRetries, credentialsSleepBetweenRetries,
+		func() error {
+			// We create a new session on each try, as the metadata client falls back to IMDSv1 for the remainder of the
+			// session when it fails to fetch an IMDSv2 token, which will never succeed on hosts that require IMDSv2.
+			newSess, err := newSession(region, profileName)
+			if err != nil {
+				return retry.FatalError{Underlying: err}
+			}
+
+			if _, err := newSess.Config.Credentials.Get(); err != nil {
+				if isRetryableMetadataErr(err) {
+					return err
+				}
+				return retry.FatalError{Underlying: CredentialsError{UnderlyingErr: err}}
+			}
+
+			sess = newSess
+			return nil
+		},
+	)
+	if err != nil {
+		if fatalErr, isFatalErr := err.(retry.FatalError); isFatalErr {
+			return nil, fatalErr.Underlying
+		}
+		return nil, CredentialsError{UnderlyingErr: err}
+	}
+
+	if assumeRoleConfig != nil {
+		return assumeRole(sess, *assumeRoleConfig)
+	}
+	return sess, nil
+}
+
+// assumeRole returns a copy of the session that uses the credentials of the given IAM role, assumed with the base
+// credentials of the session.
+func assumeRole(sess *session.Session, config AssumeRoleConfig) (*session.Session, error) {
+	logger := logging.GetProjectLogger()
+	logger.Infof("Assuming IAM role %s", config.RoleArn)
+
+	creds := stscreds.NewCredentials(sess, config.RoleArn, func(provider *stscreds.AssumeRoleProvider) {
+		if config.ExternalID != "" {
+			provider.ExternalID = aws.String(config.ExternalID)
+		}
+		if config.SessionName != "" {
+			provider.RoleSessionName = config.SessionName
+		}
+	})
+	if _, err := creds.Get(); err != nil {
+		return nil, AssumeRoleError{RoleArn: config.RoleArn, UnderlyingErr: err}
 	}
-	sess, err := session.NewSessionWithOptions(opts)
+	return sess.Copy(&aws.Config{Credentials: creds}), nil
+}
+
+// newSession creates a new AWS session for the given region, honoring the shared config files and the metadata endpoint
+// override. When the profile is set, the credentials are looked up from that profile. The requests go through the proxy
+// configured in the environment, trust the CA bundle configured with SetCABundle (see NewHTTPClient), and time out after
+// the duration configured with SetRequestTimeout, falling back to the timeout configured with SetHTTPConfig.
+func newSession(region string, profileName string) (*session.Session, error) {
+	httpClient, err := NewHTTPClient()
 	if err != nil {
 		return nil, err
 	}
+	if requestTimeout > 0 {
+		httpClient.Timeout = requestTimeout
+	}
+	opts := session.Options{
+		Config:            *newSessionConfig(region).WithHTTPClient(httpClient),
+		SharedConfigState: session.SharedConfigEnable,
+		EC2IMDSEndpoint:   os.Getenv(EC2MetadataEndpointEnvVar),
+	}
+	if profileName != "" {
+		opts.Profile = profileName
+		opts.AssumeRoleTokenProvider = stscreds.StdinTokenProvider
+	}
+	return session.NewSessionWithOptions(opts)
+}
 
-	if _, err = sess.Config.Credentials.Get(); err != nil {
-		return nil, CredentialsError{UnderlyingErr: err}
+// newSessionConfig returns the config of the sessions for the given region. The service endpoints are resolved within the
+// AWS partition of the region (e.g., aws-us-gov for the GovCloud regions and aws-cn for the China regions), and STS calls
+// go to the regional STS endpoint, as the legacy global endpoint only exists in the commercial partition. Regions that
+// are not known to the SDK fall back to the default endpoint resolver.
+func newSessionConfig(region string) *aws.Config {
+	config := aws.NewConfig().WithRegion(region).WithSTSRegionalEndpoint(endpoints.RegionalSTSEndpoint)
+	if partition, isKnownRegion := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region); isKnownRegion {
+		config = config.WithEndpointResolver(partition)
 	}
+	return config
+}
 
-	return sess, nil
+// isRetryableMetadataErr returns true if the credentials error was caused by the EC2 instance metadata service
+// responding with an error that may go away on its own: throttling, server side errors, or a rejected request due to a
+// missing IMDSv2 token. Connection errors (e.g., when not running on EC2) are not retried, so that lookups outside of EC2
+// fail fast.
+func isRetryableMetadataErr(err error) bool {
+	switch typedErr := err.(type) {
+	// NOTE: RequestFailure must be checked first, as the SDK request errors also implement BatchedErrors.
+	case awserr.RequestFailure:
+		statusCode := typedErr.StatusCode()
+		if statusCode == http.StatusUnauthorized || statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError {
+			return true
+		}
+	case awserr.BatchedErrors:
+		for _, origErr := range typedErr.OrigErrs() {
+			if isRetryableMetadataErr(origErr) {
+				return true
+			}
+		}
+	case awserr.Error:
+		if typedErr.OrigErr() != nil {
+			return isRetryableMetadataErr(typedErr.OrigErr())
+		}
+	}
+	return false
 }
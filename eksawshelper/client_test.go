@@ -0,0 +1,81 @@
+package eksawshelper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetryableMetadataErr(t *testing.T) {
+	t.Parallel()
+
+	metadataErr := func(statusCode int) error {
+		return awserr.NewBatchError("NoCredentialProviders", "no valid providers in chain", []error{
+			awserr.New("EnvAccessKeyNotFound", "failed to find credentials in the environment.", nil),
+			awserr.New("EC2RoleRequestError", "no EC2 instance role found", awserr.NewRequestFailure(
+				awserr.New("EC2MetadataError", "failed to make EC2Metadata request", nil),
+				statusCode,
+				"",
+			)),
+		})
+	}
+
+	var testCases = []struct {
+		name string
+		in   error
+		out  bool
+	}{
+		{"unauthorized", metadataErr(401), true},
+		{"throttled", metadataErr(429), true},
+		{"unavailable", metadataErr(503), true},
+		{"not-found", metadataErr(404), false},
+		{"connection-error", awserr.New("EC2RoleRequestError", "no EC2 instance role found", awserr.New("RequestError", "send request failed", errors.New("i/o timeout"))), false},
+		{"plain-error", errors.New("boom"), false},
+	}
+	for _, testcase := range testCases {
+		testcase := testcase
+		t.Run(testcase.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, testcase.out, isRetryableMetadataErr(testcase.in))
+		})
+	}
+}
+
+func TestNewSessionUsesProfileFromSharedConfig(t *testing.T) {
+	// NOTE: this test can not run in parallel since it depends on the environment.
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, "config")
+	credentialsPath := filepath.Join(configDir, "credentials")
+	require.NoError(t, os.WriteFile(configPath, []byte("[profile dev]\nregion = eu-west-1\n"), 0600))
+	require.NoError(t, os.WriteFile(
+		credentialsPath,
+		[]byte("[default]\naws_access_key_id = AKIDDEFAULT\naws_secret_access_key = default\n[dev]\naws_access_key_id = AKIDDEV\naws_secret_access_key = dev\n"),
+		0600,
+	))
+	t.Setenv("AWS_CONFIG_FILE", configPath)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsPath)
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	sess, err := newSession("", "dev")
+	require.NoError(t, err)
+	creds, err := sess.Config.Credentials.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "AKIDDEV", creds.AccessKeyID)
+	assert.Equal(t, "eu-west-1", aws.StringValue(sess.Config.Region))
+
+	// The default credentials chain is left untouched when no profile is set
+	sess, err = newSession("us-east-1", "")
+	require.NoError(t, err)
+	creds, err = sess.Config.Credentials.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "AKIDDEFAULT", creds.AccessKeyID)
+}
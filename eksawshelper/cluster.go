@@ -1,15 +1,52 @@
 package eksawshelper
 
 import (
+	"context"
+	"net"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
 	"github.com/gruntwork-io/go-commons/errors"
 	"sigs.k8s.io/aws-iam-authenticator/pkg/token"
 
 	"github.com/gruntwork-io/kubergrunt/logging"
 )
 
-// GetClusterByArn returns the EKS Cluster object that corresponds to the given ARN.
+const (
+	// DefaultClusterNotFoundRetryTimeout is the default amount of time during which DescribeCluster is retried when it
+	// reports that the cluster does not exist, which happens for a short while after the cluster is created.
+	DefaultClusterNotFoundRetryTimeout = 5 * time.Second
+
+	// clusterNotFoundInitialBackoff is the time to wait before the first retry of DescribeCluster, which is doubled on
+	// each subsequent retry.
+	clusterNotFoundInitialBackoff = 500 * time.Millisecond
+)
+
+// clusterNotFoundRetryTimeout is the amount of time during which DescribeCluster is retried when the cluster is not
+// found. This is set globally from the CLI flags, similar to the EC2 max attempts.
+var clusterNotFoundRetryTimeout = DefaultClusterNotFoundRetryTimeout
+
+// SetClusterNotFoundRetryTimeout sets the amount of time during which DescribeCluster is retried, with exponential
+// backoff, when it reports that the cluster does not exist. Pass in zero to disable the retries.
+func SetClusterNotFoundRetryTimeout(timeout time.Duration) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	clusterNotFoundRetryTimeout = timeout
+}
+
+// GetClusterByArn returns the EKS Cluster object that corresponds to the given ARN. As DescribeCluster can report that
+// the cluster does not exist for a short while after it is created, this is retried for the duration set with
+// SetClusterNotFoundRetryTimeout. Returns a ClusterNotFoundError if the cluster is still not found after that.
 func GetClusterByArn(eksClusterArn string) (*eks.Cluster, error) {
 	logger := logging.GetProjectLogger()
 	logger.Infof("Retrieving details for EKS cluster %s", eksClusterArn)
@@ -30,23 +67,268 @@ func GetClusterByArn(eksClusterArn string) (*eks.Cluster, error) {
 		return nil, errors.WithStackTrace(err)
 	}
 
-	describeClusterOutput, err := client.DescribeCluster(&eks.DescribeClusterInput{Name: aws.String(eksClusterName)})
+	cluster, err := describeCluster(client, eksClusterArn, eksClusterName, clusterNotFoundRetryTimeout, clusterNotFoundInitialBackoff)
 	if err != nil {
-		return nil, errors.WithStackTrace(err)
+		return nil, err
 	}
 
 	logger.Infof("Successfully retrieved EKS cluster details")
 
-	return describeClusterOutput.Cluster, nil
+	return cluster, nil
 }
 
+// describeCluster calls DescribeCluster for the named cluster, retrying with exponential backoff for up to the given
+// timeout while the cluster is not found. Other errors are returned right away. The cluster identifier (e.g., the ARN)
+// is only used in the logs and errors.
+func describeCluster(
+	client eksiface.EKSAPI,
+	cluster string,
+	clusterName string,
+	retryTimeout time.Duration,
+	initialBackoff time.Duration,
+) (*eks.Cluster, error) {
+	logger := logging.GetProjectLogger()
+
+	deadline := time.Now().Add(retryTimeout)
+	backoff := initialBackoff
+	for attempt := 1; ; attempt++ {
+		output, err := client.DescribeCluster(&eks.DescribeClusterInput{Name: aws.String(clusterName)})
+		if err == nil {
+			if attempt > 1 {
+				logger.Infof("Found EKS cluster %s after %d attempts", cluster, attempt)
+			}
+			return output.Cluster, nil
+		}
+		if awsErr, isAwsErr := err.(awserr.Error); !isAwsErr || awsErr.Code() != eks.ErrCodeResourceNotFoundException {
+			return nil, errors.WithStackTrace(err)
+		}
+
+		if time.Now().Add(backoff).After(deadline) {
+			return nil, errors.WithStackTrace(ClusterNotFoundError{Cluster: cluster, Attempts: attempt, UnderlyingErr: err})
+		}
+		logger.Warnf("EKS cluster %s was not found, which can happen right after it is created. Retrying in %s.", cluster, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// GetKubernetesTokenForCluster retrieves a token that can be used to authenticate to the given EKS cluster, assuming the
+// IAM role configured with SetAssumeRoleConfig, if any. Returns the token and its JSON representation in the format
+// expected by the kubectl exec credential plugins. When a timeout is configured with SetRequestTimeout and the token can
+// not be generated in time, or STS can not be reached, this returns a STSUnreachableError.
 func GetKubernetesTokenForCluster(clusterID string) (*token.Token, string, error) {
 	gen, err := token.NewGenerator(false, false)
 	if err != nil {
 		return nil, "", errors.WithStackTrace(err)
 	}
-	tok, err := gen.Get(clusterID)
-	return &tok, gen.FormatJSON(tok), errors.WithStackTrace(err)
+
+	// The generator only uses the default credentials chain and HTTP client, and a fixed expiration, so we need to sign
+	// the token with our own session when a profile, a CA bundle, a timeout or a TTL is configured. Note that the role is
+	// assumed from the base session here, so that the generator of aws-iam-authenticator and kubergrunt assume it the
+	// same way.
+	if profile == "" && caBundlePath == "" && requestTimeout == 0 && tokenTTL == 0 {
+		options := &token.GetTokenOptions{ClusterID: clusterID}
+		if assumeRoleConfig != nil {
+			options.AssumeRoleARN = assumeRoleConfig.RoleArn
+			options.AssumeRoleExternalID = assumeRoleConfig.ExternalID
+			options.SessionName = assumeRoleConfig.SessionName
+		}
+		tok, err := gen.GetWithOptions(options)
+		return &tok, gen.FormatJSON(tok), errors.WithStackTrace(err)
+	}
+
+	ctx, cancel := newTokenContext()
+	defer cancel()
+	sess, err := newSession("", profile)
+	if err != nil {
+		return nil, "", errors.WithStackTrace(err)
+	}
+	stsClient := sts.New(sess)
+	if assumeRoleConfig != nil {
+		creds := stscreds.NewCredentials(sess, assumeRoleConfig.RoleArn, func(provider *stscreds.AssumeRoleProvider) {
+			if assumeRoleConfig.ExternalID != "" {
+				provider.ExternalID = aws.String(assumeRoleConfig.ExternalID)
+			}
+			if assumeRoleConfig.SessionName != "" {
+				provider.RoleSessionName = assumeRoleConfig.SessionName
+			}
+		})
+		stsClient = sts.New(sess, &aws.Config{Credentials: creds})
+	}
+	tok, err := getTokenWithSTS(gen, clusterID, contextSTSClient{STSAPI: stsClient, ctx: ctx})
+	if err != nil {
+		return nil, "", tokenGenerationError(ctx, err)
+	}
+	return &tok, gen.FormatJSON(tok), nil
+}
+
+// GetKubernetesTokenForClusterWithCache is like GetKubernetesTokenForCluster, but returns the token from the cache if
+// there is one that is still valid for the cluster and the assumed IAM role. Otherwise, a new token is generated and
+// stored in the cache. Failures to write to the cache are logged, but otherwise ignored.
+func GetKubernetesTokenForClusterWithCache(clusterID string, cache *TokenCache) (*token.Token, error) {
+	return getTokenWithCache(cache, clusterID, func() (*token.Token, error) {
+		tok, _, err := GetKubernetesTokenForCluster(clusterID)
+		return tok, err
+	})
+}
+
+// GetKubernetesTokenForClusterArnWithCache is like GetKubernetesTokenForClusterArn, but returns the token from the cache
+// if there is one that is still valid for the cluster and the assumed IAM role. Otherwise, a new token is generated and
+// stored in the cache.
+func GetKubernetesTokenForClusterArnWithCache(clusterArn string, cache *TokenCache) (*token.Token, error) {
+	return getTokenWithCache(cache, clusterArn, func() (*token.Token, error) {
+		return GetKubernetesTokenForClusterArn(clusterArn)
+	})
+}
+
+// getTokenWithCache returns the cached token for the cluster, generating and caching a new one with the provided
+// function if there is no valid token in the cache.
+func getTokenWithCache(cache *TokenCache, cluster string, generateToken func() (*token.Token, error)) (*token.Token, error) {
+	logger := logging.GetProjectLogger()
+
+	roleArn := ""
+	if assumeRoleConfig != nil {
+		roleArn = assumeRoleConfig.RoleArn
+	}
+	// Tokens generated with different profiles authenticate as different IAM entities, so the profile is part of the
+	// cache key.
+	cacheKey := cluster
+	if profile != "" {
+		cacheKey = profile + "/" + cluster
+	}
+
+	// Tokens cached without a TTL, or with a longer one, may outlive the configured TTL.
+	tok, isCached := cache.Get(cacheKey, roleArn)
+	if isCached && tokenTTL != 0 && tok.Expiration.After(time.Now().Add(tokenTTL)) {
+		logger.Debugf("Cached token for EKS cluster %s expires after the token TTL of %s. Ignoring.", cluster, tokenTTL)
+	} else if isCached {
+		logger.Debugf("Using cached token for EKS cluster %s", cluster)
+		return tok, nil
+	}
+
+	tok, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.Put(cacheKey, roleArn, tok); err != nil {
+		logger.Warnf("Error caching token for EKS cluster %s: %s", cluster, err)
+	}
+	return tok, nil
+}
+
+// GetToken returns a token that can be used to authenticate to the EKS cluster with the given ARN, equivalent to the one
+// returned by `aws eks get-token`. The token is a presigned STS GetCallerIdentity URL for the region of the cluster,
+// signed with the credentials of NewAuthenticatedSession (including the assume role config), that is base64 encoded and
+// prefixed with `k8s-aws-v1.`, as expected by the aws-iam-authenticator server running on EKS.
+func GetToken(clusterArn string) (string, error) {
+	tok, err := GetKubernetesTokenForClusterArn(clusterArn)
+	if err != nil {
+		return "", err
+	}
+	return tok.Token, nil
+}
+
+// GetKubernetesTokenForClusterArn is like GetToken, but returns the full token, including its expiration. Like
+// GetKubernetesTokenForCluster, this returns a STSUnreachableError when the token can not be generated within the timeout
+// configured with SetRequestTimeout, or STS can not be reached.
+func GetKubernetesTokenForClusterArn(clusterArn string) (*token.Token, error) {
+	region, err := GetRegionFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	clusterName, err := GetClusterNameFromArn(clusterArn)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	ctx, cancel := newTokenContext()
+	defer cancel()
+	sess, err := NewAuthenticatedSession(region)
+	if err != nil {
+		return nil, tokenGenerationError(ctx, err)
+	}
+	return getTokenWithSession(ctx, sess, clusterName)
+}
+
+// getTokenWithSession generates a token for the named EKS cluster, signed with the credentials of the given session.
+// The credentials are retrieved within the given context.
+func getTokenWithSession(ctx context.Context, sess *session.Session, clusterName string) (*token.Token, error) {
+	gen, err := token.NewGenerator(false, false)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	// Use the regional STS endpoint so that the token is signed for the region of the session, instead of the legacy
+	// global endpoint.
+	sess = sess.Copy(aws.NewConfig().WithSTSRegionalEndpoint(endpoints.RegionalSTSEndpoint))
+	tok, err := getTokenWithSTS(gen, clusterName, contextSTSClient{STSAPI: sts.New(sess), ctx: ctx})
+	if err != nil {
+		return nil, tokenGenerationError(ctx, err)
+	}
+	return &tok, nil
+}
+
+// newTokenContext returns the context to generate a token in, which expires after the timeout configured with
+// SetRequestTimeout, if any.
+func newTokenContext() (context.Context, context.CancelFunc) {
+	if requestTimeout == 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), requestTimeout)
+}
+
+// contextSTSClient is an STS client that runs the GetCallerIdentity requests within the given context. The token is a
+// presigned GetCallerIdentity request, so this bounds the retrieval of the credentials that it is signed with (e.g.,
+// assuming an IAM role), which is done within the context of the request.
+type contextSTSClient struct {
+	stsiface.STSAPI
+	ctx context.Context
+}
+
+func (client contextSTSClient) GetCallerIdentityRequest(input *sts.GetCallerIdentityInput) (*request.Request, *sts.GetCallerIdentityOutput) {
+	req, output := client.STSAPI.GetCallerIdentityRequest(input)
+	req.SetContext(client.ctx)
+	return req, output
+}
+
+// tokenGenerationError returns a STSUnreachableError if the error generating a token was caused by a timeout or a
+// failure to connect to AWS, so that network problems can be told apart from invalid credentials. Other errors are
+// returned as is.
+func tokenGenerationError(ctx context.Context, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.WithStackTrace(STSUnreachableError{Timeout: requestTimeout, UnderlyingErr: err})
+	}
+	if isConnectionErr(err) {
+		return errors.WithStackTrace(STSUnreachableError{UnderlyingErr: err})
+	}
+	return errors.WithStackTrace(err)
+}
+
+// isConnectionErr returns true if the error was caused by a request to AWS that could not be sent or timed out, as
+// opposed to an error response. Errors about credentials that could not be found in any of the providers of the
+// credentials chain are not considered connection errors, even though they include the failure to connect to the EC2
+// instance metadata service when not running on EC2.
+func isConnectionErr(err error) bool {
+	switch typedErr := errors.Unwrap(err).(type) {
+	case CredentialsError:
+		return isConnectionErr(typedErr.UnderlyingErr)
+	case AssumeRoleError:
+		return isConnectionErr(typedErr.UnderlyingErr)
+	case awserr.Error:
+		switch typedErr.Code() {
+		case "NoCredentialProviders":
+			return false
+		case request.ErrCodeRequestError, request.CanceledErrorCode, request.ErrCodeResponseTimeout:
+			return true
+		}
+		if typedErr.OrigErr() != nil {
+			return isConnectionErr(typedErr.OrigErr())
+		}
+	case net.Error:
+		return true
+	}
+	return false
 }
 
 // NewEksClient creates an EKS client.
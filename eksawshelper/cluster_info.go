@@ -0,0 +1,93 @@
+package eksawshelper
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+)
+
+// ClusterInfo holds the details of an EKS cluster that the commands need to operate on it, as returned by the EKS
+// DescribeCluster API. The fields that are not set on the cluster (e.g., the OIDC issuer of an old cluster) are empty.
+type ClusterInfo struct {
+	Arn    string
+	Name   string
+	Region string
+
+	// Status is the status of the cluster at the time it was described, e.g., ACTIVE or DELETING.
+	Status string
+
+	// Endpoint is the URL of the Kubernetes API server of the cluster.
+	Endpoint string
+
+	// CertificateAuthorityData is the base64 encoded PEM certificate of the CA of the Kubernetes API server.
+	CertificateAuthorityData string
+
+	// OIDCIssuerURL is the URL of the OIDC issuer of the cluster, for IAM Roles for Service Accounts.
+	OIDCIssuerURL string
+
+	VPCID                  string
+	ClusterSecurityGroupID string
+
+	// Cluster is the full output of DescribeCluster, for the details that are not extracted in the other fields.
+	Cluster *eks.Cluster
+}
+
+// clusterInfoCache holds the ClusterInfo of the clusters that were already described in this invocation of kubergrunt,
+// keyed by the cluster ARN.
+var (
+	clusterInfoCache      = map[string]*ClusterInfo{}
+	clusterInfoCacheMutex sync.Mutex
+)
+
+// GetClusterInfo returns the details of the EKS cluster with the given ARN. The cluster is only described once per
+// invocation of kubergrunt: the result is cached, so that the commands that need several details of the cluster don't
+// each call DescribeCluster, which adds to the risk of being throttled. As a result, the info may be stale for
+// long running commands: use GetClusterByArn to get the current state of the cluster, e.g., when polling its status.
+func GetClusterInfo(clusterArn string) (*ClusterInfo, error) {
+	clusterInfoCacheMutex.Lock()
+	defer clusterInfoCacheMutex.Unlock()
+
+	if info, isCached := clusterInfoCache[clusterArn]; isCached {
+		return info, nil
+	}
+
+	cluster, err := GetClusterByArn(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+	info, err := newClusterInfo(clusterArn, cluster)
+	if err != nil {
+		return nil, err
+	}
+	clusterInfoCache[clusterArn] = info
+	return info, nil
+}
+
+// newClusterInfo extracts the ClusterInfo from the output of DescribeCluster for the cluster with the given ARN.
+func newClusterInfo(clusterArn string, cluster *eks.Cluster) (*ClusterInfo, error) {
+	region, err := GetRegionFromArn(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ClusterInfo{
+		Arn:      clusterArn,
+		Name:     aws.StringValue(cluster.Name),
+		Region:   region,
+		Status:   aws.StringValue(cluster.Status),
+		Endpoint: aws.StringValue(cluster.Endpoint),
+		Cluster:  cluster,
+	}
+	if cluster.CertificateAuthority != nil {
+		info.CertificateAuthorityData = aws.StringValue(cluster.CertificateAuthority.Data)
+	}
+	if cluster.Identity != nil && cluster.Identity.Oidc != nil {
+		info.OIDCIssuerURL = aws.StringValue(cluster.Identity.Oidc.Issuer)
+	}
+	if cluster.ResourcesVpcConfig != nil {
+		info.VPCID = aws.StringValue(cluster.ResourcesVpcConfig.VpcId)
+		info.ClusterSecurityGroupID = aws.StringValue(cluster.ResourcesVpcConfig.ClusterSecurityGroupId)
+	}
+	return info, nil
+}
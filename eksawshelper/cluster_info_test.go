@@ -0,0 +1,68 @@
+package eksawshelper
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClusterInfo(t *testing.T) {
+	t.Parallel()
+
+	clusterArn := "arn:aws:eks:us-east-2:111111111111:cluster/prod"
+	cluster := &eks.Cluster{
+		Name:                 aws.String("prod"),
+		Status:               aws.String(eks.ClusterStatusActive),
+		Endpoint:             aws.String("https://prod.eks.amazonaws.com"),
+		CertificateAuthority: &eks.Certificate{Data: aws.String("Y2E=")},
+		Identity:             &eks.Identity{Oidc: &eks.OIDC{Issuer: aws.String("https://oidc.eks.us-east-2.amazonaws.com/id/PROD")}},
+		ResourcesVpcConfig: &eks.VpcConfigResponse{
+			VpcId:                  aws.String("vpc-123"),
+			ClusterSecurityGroupId: aws.String("sg-123"),
+		},
+	}
+
+	info, err := newClusterInfo(clusterArn, cluster)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		&ClusterInfo{
+			Arn:                      clusterArn,
+			Name:                     "prod",
+			Region:                   "us-east-2",
+			Status:                   eks.ClusterStatusActive,
+			Endpoint:                 "https://prod.eks.amazonaws.com",
+			CertificateAuthorityData: "Y2E=",
+			OIDCIssuerURL:            "https://oidc.eks.us-east-2.amazonaws.com/id/PROD",
+			VPCID:                    "vpc-123",
+			ClusterSecurityGroupID:   "sg-123",
+			Cluster:                  cluster,
+		},
+		info,
+	)
+
+	// The details that are not set on the cluster are left empty.
+	info, err = newClusterInfo(clusterArn, &eks.Cluster{Name: aws.String("prod")})
+	require.NoError(t, err)
+	assert.Equal(t, "", info.Endpoint)
+	assert.Equal(t, "", info.OIDCIssuerURL)
+	assert.Equal(t, "", info.VPCID)
+}
+
+func TestGetClusterInfoReturnsCachedInfo(t *testing.T) {
+	t.Parallel()
+
+	// Use an ARN that is unique to this test, as the cache is global.
+	clusterArn := "arn:aws:eks:us-east-2:111111111111:cluster/cached"
+	cached := &ClusterInfo{Arn: clusterArn, Name: "cached"}
+	clusterInfoCacheMutex.Lock()
+	clusterInfoCache[clusterArn] = cached
+	clusterInfoCacheMutex.Unlock()
+
+	info, err := GetClusterInfo(clusterArn)
+	require.NoError(t, err)
+	assert.Same(t, cached, info)
+}
@@ -0,0 +1,63 @@
+package eksawshelper
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/gruntwork-io/go-commons/collections"
+)
+
+// DefaultEC2MaxAttempts is the default number of times each EC2 API call is attempted before giving up on transient
+// errors, such as throttling.
+const DefaultEC2MaxAttempts = 10
+
+// ec2ThrottlingErrorCodes lists the error codes EC2 responds with when the request rate limits of the account are
+// exceeded. These are always retried, including RequestResourceCountExceeded which the SDK does not treat as throttling.
+var ec2ThrottlingErrorCodes = []string{
+	"RequestLimitExceeded",
+	"RequestResourceCountExceeded",
+	"EC2ThrottledException",
+	"Throttling",
+	"ThrottlingException",
+}
+
+// ec2MaxAttempts is the number of times each call is attempted by the clients created with NewEC2Client. This is set
+// globally from the CLI flags, similar to the assume role config.
+var ec2MaxAttempts = DefaultEC2MaxAttempts
+
+// SetEC2MaxAttempts sets the number of times each EC2 API call is attempted by the clients created with NewEC2Client,
+// including the first attempt. Values less than 1 are treated as 1, which disables retries.
+func SetEC2MaxAttempts(maxAttempts int) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	ec2MaxAttempts = maxAttempts
+}
+
+// NewEC2Client creates an EC2 client for the given session that retries the calls that fail due to throttling or other
+// transient errors, up to the number of attempts set with SetEC2MaxAttempts. All the EC2 clients should be created with
+// this function, so that a transient error on a single call does not fail the whole operation.
+func NewEC2Client(sess *session.Session) *ec2.EC2 {
+	return ec2.New(sess, &aws.Config{Retryer: newEC2Retryer(ec2MaxAttempts)})
+}
+
+// ec2Retryer is the SDK default retryer, extended to always retry the EC2 throttling errors.
+type ec2Retryer struct {
+	client.DefaultRetryer
+}
+
+func newEC2Retryer(maxAttempts int) ec2Retryer {
+	return ec2Retryer{client.DefaultRetryer{NumMaxRetries: maxAttempts - 1}}
+}
+
+// ShouldRetry returns true if the request failed with an EC2 throttling error, or any other error that the default
+// retryer considers retryable.
+func (retryer ec2Retryer) ShouldRetry(r *request.Request) bool {
+	if awsErr, isAwsErr := r.Error.(awserr.Error); isAwsErr && collections.ListContainsElement(ec2ThrottlingErrorCodes, awsErr.Code()) {
+		return true
+	}
+	return retryer.DefaultRetryer.ShouldRetry(r)
+}
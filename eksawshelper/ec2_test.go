@@ -0,0 +1,42 @@
+package eksawshelper
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEC2RetryerShouldRetry(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name        string
+		err         error
+		shouldRetry bool
+	}{
+		{"RequestLimitExceeded", awserr.New("RequestLimitExceeded", "Request limit exceeded.", nil), true},
+		{"RequestResourceCountExceeded", awserr.New("RequestResourceCountExceeded", "Request resource count exceeded.", nil), true},
+		{"NotFound", awserr.New("InvalidGroup.NotFound", "The security group does not exist.", nil), false},
+		{"DependencyViolation", awserr.New("DependencyViolation", "resource has a dependent object", nil), false},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			retryer := newEC2Retryer(DefaultEC2MaxAttempts)
+			assert.Equal(t, testCase.shouldRetry, retryer.ShouldRetry(&request.Request{Error: testCase.err}))
+		})
+	}
+}
+
+func TestNewEC2RetryerCountsFirstAttempt(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 4, newEC2Retryer(5).MaxRetries())
+	assert.Equal(t, 0, newEC2Retryer(1).MaxRetries())
+}
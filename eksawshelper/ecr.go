@@ -9,7 +9,6 @@ import (
 	"github.com/aws/aws-sdk-go/service/ecr"
 	"github.com/gruntwork-io/go-commons/errors"
 	"github.com/gruntwork-io/kubergrunt/commonerrors"
-	"github.com/hashicorp/go-cleanhttp"
 )
 
 // TagExistsInRepo queries the ECR repository docker API to see if the given tag exists for the given ECR repository.
@@ -21,7 +20,10 @@ func TagExistsInRepo(token, repoDomain, repoPath, tag string) (bool, error) {
 	}
 	req.Header.Set("Authorization", "Basic "+token)
 
-	httpClient := cleanhttp.DefaultClient()
+	httpClient, err := NewHTTPClient()
+	if err != nil {
+		return false, err
+	}
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return false, errors.WithStackTrace(err)
@@ -1,6 +1,9 @@
 package eksawshelper
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // CredentialsError is an error that occurs because AWS credentials can't be found.
 type CredentialsError struct {
@@ -11,6 +14,43 @@ func (err CredentialsError) Error() string {
 	return fmt.Sprintf("Error finding AWS credentials. Did you set the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables or configure an AWS profile? Underlying error: %v", err.UnderlyingErr)
 }
 
+// AssumeRoleError is an error that occurs when the configured IAM role can not be assumed.
+type AssumeRoleError struct {
+	RoleArn       string
+	UnderlyingErr error
+}
+
+func (err AssumeRoleError) Error() string {
+	return fmt.Sprintf("Error assuming IAM role %s. Underlying error: %v", err.RoleArn, err.UnderlyingErr)
+}
+
+// ClusterNotFoundError is an error that occurs when DescribeCluster keeps reporting that the EKS cluster does not exist,
+// even after retrying for the time it takes for a newly created cluster to become visible.
+type ClusterNotFoundError struct {
+	Cluster       string
+	Attempts      int
+	UnderlyingErr error
+}
+
+func (err ClusterNotFoundError) Error() string {
+	return fmt.Sprintf("EKS cluster %s does not exist: it was not found after %d attempts. Underlying error: %v", err.Cluster, err.Attempts, err.UnderlyingErr)
+}
+
+// STSUnreachableError is an error that occurs when an EKS token can not be generated because AWS can not be reached, or
+// does not respond in time, as opposed to the credentials being invalid.
+type STSUnreachableError struct {
+	// Timeout is the timeout that was exceeded, or zero if the connection failed before the timeout.
+	Timeout       time.Duration
+	UnderlyingErr error
+}
+
+func (err STSUnreachableError) Error() string {
+	if err.Timeout > 0 {
+		return fmt.Sprintf("Timed out after %s generating the EKS token: AWS STS could not be reached in time. This is a network problem, not an issue with the AWS credentials. Underlying error: %v", err.Timeout, err.UnderlyingErr)
+	}
+	return fmt.Sprintf("Error generating the EKS token: AWS STS could not be reached. This is a network problem, not an issue with the AWS credentials. Underlying error: %v", err.UnderlyingErr)
+}
+
 // ECRManifestFetchError is an error that occurs when retrieving information about a given tag in an ECR repository.
 type ECRManifestFetchError struct {
 	manifestURL string
@@ -21,3 +61,36 @@ type ECRManifestFetchError struct {
 func (err ECRManifestFetchError) Error() string {
 	return fmt.Sprintf("Error querying ECR repo URL %s (status code %d) (response body %s)", err.manifestURL, err.statusCode, err.body)
 }
+
+// UnsupportedExecCredentialAPIVersionError is an error that occurs when formatting an ExecCredential with an unknown
+// client.authentication.k8s.io API version.
+type UnsupportedExecCredentialAPIVersionError struct {
+	APIVersion string
+}
+
+func (err UnsupportedExecCredentialAPIVersionError) Error() string {
+	return fmt.Sprintf("Unsupported ExecCredential API version %s. Must be one of v1beta1 or v1.", err.APIVersion)
+}
+
+// InvalidClusterArnError is an error that occurs when the given string is not a valid EKS cluster ARN.
+type InvalidClusterArnError struct {
+	Arn    string
+	Reason string
+}
+
+func (err InvalidClusterArnError) Error() string {
+	return fmt.Sprintf(
+		"%q is not a valid EKS cluster ARN (%s). Expected an ARN of the form arn:PARTITION:eks:REGION:ACCOUNT_ID:cluster/CLUSTER_NAME, such as the one in the output of `aws eks describe-cluster --name CLUSTER_NAME`.",
+		err.Arn,
+		err.Reason,
+	)
+}
+
+// InvalidCABundleError is an error that occurs when the CA bundle does not contain any PEM encoded certificate.
+type InvalidCABundleError struct {
+	Path string
+}
+
+func (err InvalidCABundleError) Error() string {
+	return fmt.Sprintf("The CA bundle %s does not contain any PEM encoded certificate.", err.Path)
+}
@@ -0,0 +1,78 @@
+package eksawshelper
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+	clientauthv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+	"sigs.k8s.io/aws-iam-authenticator/pkg/token"
+)
+
+// The versions of the client.authentication.k8s.io API that can be used to format the ExecCredential returned to
+// kubectl. Older versions of kubectl only support v1beta1, while newer versions negotiate v1.
+const (
+	ExecCredentialAPIVersionV1Beta1 = "v1beta1"
+	ExecCredentialAPIVersionV1      = "v1"
+
+	// execInfoEnvVar is the environment variable that kubectl uses to pass the ExecCredential request, including the
+	// requested API version, to exec credential plugins.
+	execInfoEnvVar = "KUBERNETES_EXEC_INFO"
+)
+
+// GetExecCredentialAPIVersionFromEnv returns the client.authentication.k8s.io API version that kubectl requested when
+// invoking the exec credential plugin, falling back to v1beta1 if kubectl did not provide one.
+func GetExecCredentialAPIVersionFromEnv() string {
+	var execInfo struct {
+		APIVersion string `json:"apiVersion"`
+	}
+	if err := json.Unmarshal([]byte(os.Getenv(execInfoEnvVar)), &execInfo); err != nil || execInfo.APIVersion == "" {
+		return ExecCredentialAPIVersionV1Beta1
+	}
+	return strings.TrimPrefix(execInfo.APIVersion, clientauthv1.SchemeGroupVersion.Group+"/")
+}
+
+// FormatExecCredential formats the token as an ExecCredential object of the given client.authentication.k8s.io API
+// version, which is the JSON format expected by kubectl from exec credential plugins. The expiration timestamp is set
+// to the expiration of the token, which is 14 minutes after it was generated (1 minute before the presigned STS URL
+// expires).
+func FormatExecCredential(tok *token.Token, apiVersion string) (string, error) {
+	expirationTimestamp := metav1.NewTime(tok.Expiration)
+
+	var execCredential interface{}
+	switch apiVersion {
+	case ExecCredentialAPIVersionV1Beta1:
+		execCredential = clientauthv1beta1.ExecCredential{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: clientauthv1beta1.SchemeGroupVersion.String(),
+				Kind:       "ExecCredential",
+			},
+			Status: &clientauthv1beta1.ExecCredentialStatus{
+				ExpirationTimestamp: &expirationTimestamp,
+				Token:               tok.Token,
+			},
+		}
+	case ExecCredentialAPIVersionV1:
+		execCredential = clientauthv1.ExecCredential{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: clientauthv1.SchemeGroupVersion.String(),
+				Kind:       "ExecCredential",
+			},
+			Status: &clientauthv1.ExecCredentialStatus{
+				ExpirationTimestamp: &expirationTimestamp,
+				Token:               tok.Token,
+			},
+		}
+	default:
+		return "", errors.WithStackTrace(UnsupportedExecCredentialAPIVersionError{APIVersion: apiVersion})
+	}
+
+	data, err := json.Marshal(execCredential)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,105 @@
+package eksawshelper
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientauthv1 "k8s.io/client-go/pkg/apis/clientauthentication/v1"
+	clientauthv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+	"sigs.k8s.io/aws-iam-authenticator/pkg/token"
+)
+
+func TestFormatExecCredentialRoundTrips(t *testing.T) {
+	t.Parallel()
+
+	expiration := time.Now().Add(14 * time.Minute).Truncate(time.Second)
+	tok := &token.Token{Token: "k8s-aws-v1.token", Expiration: expiration}
+
+	testCases := []struct {
+		apiVersion         string
+		expectedAPIVersion string
+		decode             func(t *testing.T, data []byte) (string, time.Time)
+	}{
+		{
+			ExecCredentialAPIVersionV1Beta1,
+			"client.authentication.k8s.io/v1beta1",
+			func(t *testing.T, data []byte) (string, time.Time) {
+				var execCredential clientauthv1beta1.ExecCredential
+				require.NoError(t, json.Unmarshal(data, &execCredential))
+				require.NotNil(t, execCredential.Status)
+				return execCredential.Status.Token, execCredential.Status.ExpirationTimestamp.Time
+			},
+		},
+		{
+			ExecCredentialAPIVersionV1,
+			"client.authentication.k8s.io/v1",
+			func(t *testing.T, data []byte) (string, time.Time) {
+				var execCredential clientauthv1.ExecCredential
+				require.NoError(t, json.Unmarshal(data, &execCredential))
+				require.NotNil(t, execCredential.Status)
+				return execCredential.Status.Token, execCredential.Status.ExpirationTimestamp.Time
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.apiVersion, func(t *testing.T) {
+			t.Parallel()
+
+			jsonData, err := FormatExecCredential(tok, testCase.apiVersion)
+			require.NoError(t, err)
+
+			var shape struct {
+				APIVersion string `json:"apiVersion"`
+				Kind       string `json:"kind"`
+				Status     struct {
+					Token               string `json:"token"`
+					ExpirationTimestamp string `json:"expirationTimestamp"`
+				} `json:"status"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(jsonData), &shape))
+			assert.Equal(t, testCase.expectedAPIVersion, shape.APIVersion)
+			assert.Equal(t, "ExecCredential", shape.Kind)
+			assert.Equal(t, tok.Token, shape.Status.Token)
+			assert.Equal(t, expiration.UTC().Format(time.RFC3339), shape.Status.ExpirationTimestamp)
+
+			decodedToken, decodedExpiration := testCase.decode(t, []byte(jsonData))
+			assert.Equal(t, tok.Token, decodedToken)
+			assert.True(t, expiration.Equal(decodedExpiration))
+		})
+	}
+}
+
+func TestFormatExecCredentialRejectsUnknownAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	_, err := FormatExecCredential(&token.Token{Token: "k8s-aws-v1.token"}, "v1alpha1")
+	require.Error(t, err)
+}
+
+func TestGetExecCredentialAPIVersionFromEnv(t *testing.T) {
+	testCases := []struct {
+		name     string
+		execInfo string
+		expected string
+	}{
+		{"unset", "", ExecCredentialAPIVersionV1Beta1},
+		{"v1", `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1","spec":{"interactive":false}}`, ExecCredentialAPIVersionV1},
+		{"v1beta1", `{"kind":"ExecCredential","apiVersion":"client.authentication.k8s.io/v1beta1","spec":{}}`, ExecCredentialAPIVersionV1Beta1},
+		{"invalid", `not json`, ExecCredentialAPIVersionV1Beta1},
+	}
+
+	// NOTE: these tests can not run in parallel since they depend on the environment.
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Setenv(execInfoEnvVar, testCase.execInfo)
+			assert.Equal(t, testCase.expected, GetExecCredentialAPIVersionFromEnv())
+		})
+	}
+}
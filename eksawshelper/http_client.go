@@ -0,0 +1,140 @@
+package eksawshelper
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+)
+
+const (
+	// DefaultHTTPKeepAlive and DefaultHTTPIdleConnTimeout are the defaults of HTTPConfig, which match the default
+	// transport of the Go standard library.
+	DefaultHTTPKeepAlive       = 30 * time.Second
+	DefaultHTTPIdleConnTimeout = 90 * time.Second
+
+	// httpDialTimeout is the maximum amount of time to wait for a TCP connection to be established.
+	httpDialTimeout = 30 * time.Second
+)
+
+// HTTPConfig configures the timeouts and connection reuse of all the HTTP clients of kubergrunt, both for the AWS and
+// the Kubernetes API calls.
+type HTTPConfig struct {
+	// Timeout is the maximum duration of each HTTP request, including reading the response body. Zero means no timeout.
+	Timeout time.Duration
+
+	// KeepAlive is the interval between the TCP keepalive probes of the connections. Negative disables the probes.
+	KeepAlive time.Duration
+
+	// IdleConnTimeout is how long an idle connection is kept open for reuse before it is closed. Zero means no limit.
+	IdleConnTimeout time.Duration
+}
+
+// httpConfig is the HTTP config of all the HTTP clients of kubergrunt. This is set globally from the CLI flags, similar
+// to the CA bundle.
+var httpConfig = HTTPConfig{
+	KeepAlive:       DefaultHTTPKeepAlive,
+	IdleConnTimeout: DefaultHTTPIdleConnTimeout,
+}
+
+// SetHTTPConfig sets the timeouts and connection reuse settings of all the HTTP clients of kubergrunt.
+func SetHTTPConfig(config HTTPConfig) {
+	httpConfig = config
+}
+
+// GetHTTPConfig returns the HTTP config set with SetHTTPConfig.
+func GetHTTPConfig() HTTPConfig {
+	return httpConfig
+}
+
+// NewDialer returns the dialer to open the TCP connections of the HTTP clients with, which sends keepalive probes as
+// configured with SetHTTPConfig.
+func NewDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   httpDialTimeout,
+		KeepAlive: httpConfig.KeepAlive,
+	}
+}
+
+// WrapTransport returns a copy of the transport that closes idle connections as configured with SetHTTPConfig. This is
+// meant to be used as the WrapTransport of the Kubernetes client configs, which do not expose this setting. Round
+// trippers that are not an *http.Transport are returned as is.
+func WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	transport, isTransport := rt.(*http.Transport)
+	if !isTransport {
+		return rt
+	}
+	// The transport may be shared with other clients, so it is copied instead of modified in place.
+	transport = transport.Clone()
+	transport.IdleConnTimeout = httpConfig.IdleConnTimeout
+	return transport
+}
+
+// caBundlePath is the path to a PEM encoded bundle of CA certificates that are trusted, in addition to the system root
+// CAs, by all the HTTP clients of kubergrunt. This is set globally from the CLI flags, similar to the AWS profile, so
+// that it applies to every AWS and Kubernetes call regardless of which command is run.
+var caBundlePath string
+
+// SetCABundle sets the path to the PEM encoded bundle of CA certificates to trust for all the AWS and Kubernetes calls,
+// e.g., the CA of a TLS intercepting proxy. Pass in an empty string to only trust the system root CAs.
+func SetCABundle(path string) {
+	caBundlePath = path
+}
+
+// GetCABundle returns the path to the CA bundle configured with SetCABundle, or an empty string if there is none.
+func GetCABundle() string {
+	return caBundlePath
+}
+
+// LoadCABundle returns the PEM encoded certificates of the CA bundle configured with SetCABundle, or nil if there is
+// none. Returns an InvalidCABundleError if the bundle does not contain any certificate.
+func LoadCABundle() ([]byte, error) {
+	if caBundlePath == "" {
+		return nil, nil
+	}
+	caBundle, err := ioutil.ReadFile(caBundlePath)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(caBundle) {
+		return nil, errors.WithStackTrace(InvalidCABundleError{Path: caBundlePath})
+	}
+	return caBundle, nil
+}
+
+// NewHTTPClient returns an HTTP client that sends the requests through the proxy configured in the environment
+// (HTTPS_PROXY, HTTP_PROXY, and NO_PROXY), and that trusts the CA bundle configured with SetCABundle in addition to the
+// system root CAs. The timeouts and connection reuse settings configured with SetHTTPConfig are applied.
+func NewHTTPClient() (*http.Client, error) {
+	rootCAs, err := newRootCAPool()
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.DialContext = NewDialer().DialContext
+	transport.IdleConnTimeout = httpConfig.IdleConnTimeout
+	if rootCAs != nil {
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+	}
+	return &http.Client{Transport: transport, Timeout: httpConfig.Timeout}, nil
+}
+
+// newRootCAPool returns the system root CAs along with the CA bundle configured with SetCABundle, or nil to use the
+// system root CAs when there is no bundle.
+func newRootCAPool() (*x509.CertPool, error) {
+	caBundle, err := LoadCABundle()
+	if err != nil || caBundle == nil {
+		return nil, err
+	}
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil {
+		rootCAs = x509.NewCertPool()
+	}
+	rootCAs.AppendCertsFromPEM(caBundle)
+	return rootCAs, nil
+}
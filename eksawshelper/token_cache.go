@@ -0,0 +1,110 @@
+package eksawshelper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	homedir "github.com/mitchellh/go-homedir"
+	clientauthv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+	"sigs.k8s.io/aws-iam-authenticator/pkg/token"
+
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// tokenCacheMinValidity is the minimum amount of time a cached token must still be valid for to be returned from the
+// cache. This leaves enough time for kubectl to use the token before it expires.
+const tokenCacheMinValidity = 1 * time.Minute
+
+// TokenCache is a file based cache of the EKS authentication tokens, which avoids generating a new token on every
+// invocation of the exec credential plugin by kubectl. Each token is stored as an ExecCredential JSON file in Dir,
+// keyed by the cluster (name or ARN) and the assumed IAM role ARN.
+type TokenCache struct {
+	Dir string
+}
+
+// NewTokenCache returns a TokenCache that stores the tokens in the given directory.
+func NewTokenCache(dir string) *TokenCache {
+	return &TokenCache{Dir: dir}
+}
+
+// DefaultTokenCacheDir returns the default directory for the token cache in the home directory
+// (~/.kube/cache/kubergrunt). This will error if the home directory can not be determined.
+func DefaultTokenCacheDir() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return filepath.Join(home, ".kube", "cache", "kubergrunt"), nil
+}
+
+// Get returns the cached token for the cluster and IAM role, if there is one that is valid for at least another
+// minute. The second return value is false if there is no usable token in the cache.
+func (cache *TokenCache) Get(clusterID string, roleArn string) (*token.Token, bool) {
+	logger := logging.GetProjectLogger()
+
+	data, err := ioutil.ReadFile(cache.path(clusterID, roleArn))
+	if err != nil {
+		logger.Debugf("No cached token for EKS cluster %s: %s", clusterID, err)
+		return nil, false
+	}
+
+	var execCredential clientauthv1beta1.ExecCredential
+	if err := json.Unmarshal(data, &execCredential); err != nil {
+		logger.Debugf("Ignoring invalid cached token for EKS cluster %s: %s", clusterID, err)
+		return nil, false
+	}
+	if execCredential.Status == nil || execCredential.Status.ExpirationTimestamp == nil || execCredential.Status.Token == "" {
+		logger.Debugf("Ignoring incomplete cached token for EKS cluster %s", clusterID)
+		return nil, false
+	}
+
+	expiration := execCredential.Status.ExpirationTimestamp.Time
+	if time.Until(expiration) < tokenCacheMinValidity {
+		logger.Debugf("Cached token for EKS cluster %s expires at %s. Ignoring.", clusterID, expiration)
+		return nil, false
+	}
+	return &token.Token{Token: execCredential.Status.Token, Expiration: expiration}, true
+}
+
+// Put stores the token for the cluster and IAM role in the cache. The cache file is written atomically, with
+// permissions that only allow the current user to read it, as the token grants access to the cluster.
+func (cache *TokenCache) Put(clusterID string, roleArn string, tok *token.Token) error {
+	data, err := FormatExecCredential(tok, ExecCredentialAPIVersionV1Beta1)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(cache.Dir, 0700); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	// Write to a temporary file in the same directory and rename it into place, so that concurrent invocations never
+	// read a partially written file. Note that TempFile creates the file with 0600 permissions.
+	tmpFile, err := ioutil.TempFile(cache.Dir, ".token-")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(data); err != nil {
+		tmpFile.Close()
+		return errors.WithStackTrace(err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return errors.WithStackTrace(os.Rename(tmpFile.Name(), cache.path(clusterID, roleArn)))
+}
+
+// path returns the path of the cache file for the cluster and IAM role. The key is hashed so that it is safe to use as
+// a file name regardless of the characters in the role ARN.
+func (cache *TokenCache) path(clusterID string, roleArn string) string {
+	hash := sha256.Sum256([]byte(clusterID + "\x00" + roleArn))
+	return filepath.Join(cache.Dir, hex.EncodeToString(hash[:])+".json")
+}
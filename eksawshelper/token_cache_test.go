@@ -0,0 +1,61 @@
+package eksawshelper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/aws-iam-authenticator/pkg/token"
+)
+
+func TestTokenCacheRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTokenCache(filepath.Join(t.TempDir(), "cache"))
+	tok := &token.Token{Token: "k8s-aws-v1.token", Expiration: time.Now().Add(14 * time.Minute).Truncate(time.Second)}
+	require.NoError(t, cache.Put("my-cluster", "arn:aws:iam::123456789012:role/admin", tok))
+
+	cachedTok, isCached := cache.Get("my-cluster", "arn:aws:iam::123456789012:role/admin")
+	require.True(t, isCached)
+	assert.Equal(t, tok.Token, cachedTok.Token)
+	assert.True(t, tok.Expiration.Equal(cachedTok.Expiration))
+
+	// The cache is keyed by both the cluster and the role
+	_, isCached = cache.Get("my-cluster", "")
+	assert.False(t, isCached)
+	_, isCached = cache.Get("other-cluster", "arn:aws:iam::123456789012:role/admin")
+	assert.False(t, isCached)
+
+	info, err := os.Stat(cache.path("my-cluster", "arn:aws:iam::123456789012:role/admin"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Only the cache file should remain in the directory after the atomic write
+	files, err := os.ReadDir(cache.Dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestTokenCacheIgnoresTokensAboutToExpire(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTokenCache(t.TempDir())
+	tok := &token.Token{Token: "k8s-aws-v1.token", Expiration: time.Now().Add(30 * time.Second)}
+	require.NoError(t, cache.Put("my-cluster", "", tok))
+
+	_, isCached := cache.Get("my-cluster", "")
+	assert.False(t, isCached)
+}
+
+func TestTokenCacheIgnoresInvalidFiles(t *testing.T) {
+	t.Parallel()
+
+	cache := NewTokenCache(t.TempDir())
+	require.NoError(t, os.WriteFile(cache.path("my-cluster", ""), []byte("not json"), 0600))
+
+	_, isCached := cache.Get("my-cluster", "")
+	assert.False(t, isCached)
+}
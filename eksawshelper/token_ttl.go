@@ -0,0 +1,82 @@
+package eksawshelper
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/aws/aws-sdk-go/service/sts/stsiface"
+	"github.com/gruntwork-io/go-commons/errors"
+	"sigs.k8s.io/aws-iam-authenticator/pkg/token"
+
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+const (
+	// MinTokenTTL and MaxTokenTTL are the bounds of the TTL that can be set with SetTokenTTL. The aws-iam-authenticator
+	// server running on EKS accepts a token for 15 minutes after it is signed, so the maximum keeps the same 1 minute
+	// cushion as the default expiration of the tokens.
+	MinTokenTTL = 1 * time.Minute
+	MaxTokenTTL = 14 * time.Minute
+
+	// tokenPrefix and clusterIDHeader are the prefix of the base64 encoded presigned URL, and the signed header holding
+	// the name of the EKS cluster, as expected by the aws-iam-authenticator server.
+	tokenPrefix     = "k8s-aws-v1."
+	clusterIDHeader = "x-k8s-aws-id"
+)
+
+// tokenTTL is the lifetime of the generated tokens, or zero to use the default of aws-iam-authenticator. This is set
+// globally from the CLI flags, similar to the request timeout.
+var tokenTTL time.Duration
+
+// SetTokenTTL sets the lifetime of the tokens generated by GetKubernetesTokenForCluster and
+// GetKubernetesTokenForClusterArn, which is used both as the X-Amz-Expires of the presigned STS URL and as the
+// expiration of the token in the ExecCredential output. Values outside of MinTokenTTL and MaxTokenTTL are clamped with a
+// warning. Pass in zero to use the default expiration of 14 minutes.
+//
+// Note that STS ignores X-Amz-Expires on the presigned GetCallerIdentity URL, so the EKS API server still accepts the
+// token for 15 minutes after it is signed. The TTL bounds how long kubectl and the token cache reuse the token.
+func SetTokenTTL(ttl time.Duration) {
+	tokenTTL = clampTokenTTL(ttl)
+}
+
+func clampTokenTTL(ttl time.Duration) time.Duration {
+	logger := logging.GetProjectLogger()
+	switch {
+	case ttl == 0:
+		return 0
+	case ttl < MinTokenTTL:
+		logger.Warnf("Token TTL %s is shorter than the minimum of %s. Using %s.", ttl, MinTokenTTL, MinTokenTTL)
+		return MinTokenTTL
+	case ttl > MaxTokenTTL:
+		logger.Warnf("Token TTL %s is longer than the maximum of %s. Using %s.", ttl, MaxTokenTTL, MaxTokenTTL)
+		return MaxTokenTTL
+	}
+	return ttl
+}
+
+// getTokenWithSTS generates a token for the named EKS cluster with the given STS client, using the TTL configured with
+// SetTokenTTL, if any.
+func getTokenWithSTS(gen token.Generator, clusterID string, stsAPI stsiface.STSAPI) (token.Token, error) {
+	if tokenTTL == 0 {
+		return gen.GetWithSTS(clusterID, stsAPI)
+	}
+	return presignToken(clusterID, stsAPI, tokenTTL)
+}
+
+// presignToken generates a token for the named EKS cluster like the generator of aws-iam-authenticator does, but with
+// the given TTL instead of the fixed expiration of the generator.
+func presignToken(clusterID string, stsAPI stsiface.STSAPI, ttl time.Duration) (token.Token, error) {
+	request, _ := stsAPI.GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
+	request.HTTPRequest.Header.Add(clusterIDHeader, clusterID)
+
+	signedAt := time.Now()
+	presignedURL, err := request.Presign(ttl)
+	if err != nil {
+		return token.Token{}, errors.WithStackTrace(err)
+	}
+	return token.Token{
+		Token:      tokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(presignedURL)),
+		Expiration: signedAt.Local().Add(ttl),
+	}, nil
+}
@@ -14,6 +14,8 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
 
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
 	"github.com/gruntwork-io/kubergrunt/logging"
 )
 
@@ -51,6 +53,24 @@ func AddEksConfigContext(
 		return errors.WithStackTrace(NewContextAlreadyExistsError(contextName))
 	}
 
+	return UpsertEksConfigContext(
+		config,
+		contextName,
+		eksClusterArnString,
+		eksEndpoint,
+		b64CertificateAuthorityData,
+	)
+}
+
+// UpsertEksConfigContext is like AddEksConfigContext, but updates the cluster, auth info, and context entries in place
+// if they already exist, instead of failing. All the other entries in the config are left untouched.
+func UpsertEksConfigContext(
+	config *api.Config,
+	contextName string,
+	eksClusterArnString string,
+	eksEndpoint string,
+	b64CertificateAuthorityData string,
+) error {
 	// Insert new cluster to config
 	err := AddClusterToConfig(
 		config,
@@ -63,7 +83,7 @@ func AddEksConfigContext(
 	}
 
 	// Insert auth info to config
-	err = AddEksAuthInfoToConfig(config, eksClusterArnString, eksClusterName)
+	err = AddEksAuthInfoToConfig(config, eksClusterArnString)
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
@@ -72,6 +92,32 @@ func AddEksConfigContext(
 	return AddContextToConfig(config, contextName, eksClusterArnString, eksClusterArnString)
 }
 
+// RemoveEksConfigContext removes the entries that UpsertEksConfigContext adds for the given EKS cluster from the config
+// in place: the cluster and auth info entries named after the cluster ARN, and every context that refers to either of
+// them, whatever its name. The current context is cleared if it was one of the removed contexts. All the other entries
+// are left untouched, and missing entries are ignored. Returns the names of the removed contexts.
+func RemoveEksConfigContext(config *api.Config, eksClusterArnString string) []string {
+	logger := logging.GetProjectLogger()
+	logger.Infof("Removing kubectl config entries for EKS cluster %s", eksClusterArnString)
+
+	removedContexts := []string{}
+	for name, context := range config.Contexts {
+		if context.Cluster == eksClusterArnString || context.AuthInfo == eksClusterArnString {
+			logger.Infof("Removing context %s from kubectl config.", name)
+			delete(config.Contexts, name)
+			removedContexts = append(removedContexts, name)
+			if config.CurrentContext == name {
+				logger.Infof("Clearing current context, which was set to the removed context %s.", name)
+				config.CurrentContext = ""
+			}
+		}
+	}
+	delete(config.Clusters, eksClusterArnString)
+	delete(config.AuthInfos, eksClusterArnString)
+	logger.Infof("Successfully removed kubectl config entries.")
+	return removedContexts
+}
+
 // AddClusterToConfig will append a new cluster to the kubectl config, based on its endpoint and certificate authority
 // data.
 func AddClusterToConfig(
@@ -96,8 +142,8 @@ func AddClusterToConfig(
 
 // AddEksAuthInfoToConfig will add an exec command based AuthInfo entry to the kubectl config that is designed to
 // retrieve the Kubernetes auth token using AWS IAM credentials. This will use the `token` command provided by
-// `kubergrunt`.
-func AddEksAuthInfoToConfig(config *api.Config, eksClusterArnString string, eksClusterName string) error {
+// `kubergrunt`, passing through the cluster ARN so that the token is generated for the region of the cluster.
+func AddEksAuthInfoToConfig(config *api.Config, eksClusterArnString string) error {
 	logger := logging.GetProjectLogger()
 	logger.Infof("Appending EKS cluster authentication info for %s to kubectl config.", eksClusterArnString)
 
@@ -110,17 +156,35 @@ func AddEksAuthInfoToConfig(config *api.Config, eksClusterArnString string, eksC
 		logger.Warn("Falling back to default kubergrunt, searching in the PATH.")
 	}
 
+	args := []string{"--loglevel", "error"}
+	// Propagate the AWS profile, the assumed IAM role and the CA bundle so that the token is retrieved with the same
+	// credentials, and through the same TLS intercepting proxy if any, as the ones used to configure kubectl. The CA
+	// bundle is made absolute as kubectl does not run the exec plugin from the current directory. The endpoint override
+	// is left out: generating a token only calls STS, never the Kubernetes API server.
+	if profile := eksawshelper.GetProfile(); profile != "" {
+		args = append(args, "--profile", profile)
+	}
+	if caBundle := eksawshelper.GetCABundle(); caBundle != "" {
+		if absCABundle, err := filepath.Abs(caBundle); err == nil {
+			caBundle = absCABundle
+		}
+		args = append(args, "--ca-bundle", caBundle)
+	}
+	if assumeRoleConfig := eksawshelper.GetAssumeRoleConfig(); assumeRoleConfig != nil {
+		args = append(args, "--assume-role", assumeRoleConfig.RoleArn)
+		if assumeRoleConfig.ExternalID != "" {
+			args = append(args, "--assume-role-external-id", assumeRoleConfig.ExternalID)
+		}
+		if assumeRoleConfig.SessionName != "" {
+			args = append(args, "--assume-role-session-name", assumeRoleConfig.SessionName)
+		}
+	}
+	args = append(args, "eks", "token", "--eks-cluster-arn", eksClusterArnString)
+
 	execConfig := api.ExecConfig{
 		APIVersion: "client.authentication.k8s.io/v1beta1",
 		Command:    executablePath,
-		Args: []string{
-			"--loglevel",
-			"error",
-			"eks",
-			"token",
-			"--cluster-id",
-			eksClusterName,
-		},
+		Args:       args,
 	}
 	authInfo := api.NewAuthInfo()
 	authInfo.Exec = &execConfig
@@ -141,6 +205,50 @@ func AddContextToConfig(config *api.Config, contextName string, clusterName stri
 	return nil
 }
 
+// WriteConfigAtomically serializes the kubectl config and writes it to the given path. The config is first written to
+// a temporary file in the same directory, which is then renamed over the original file, so that the existing config is
+// never left partially written if the process is interrupted. The permissions of the existing file are preserved, and
+// new files are only readable by the current user, as the config may contain credentials.
+func WriteConfigAtomically(config *api.Config, path string) error {
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	parentDir := filepath.Dir(path)
+	if err := os.MkdirAll(parentDir, 0755); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	tmpFile, err := ioutil.TempFile(parentDir, "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	// This is a noop once the file is renamed.
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return errors.WithStackTrace(err)
+	}
+	if err := tmpFile.Chmod(mode); err != nil {
+		tmpFile.Close()
+		return errors.WithStackTrace(err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return errors.WithStackTrace(err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return errors.WithStackTrace(os.Rename(tmpFile.Name(), path))
+}
+
 // LoadConfigFromPath will load a ClientConfig object from a file path that points to a location on disk containing a
 // kubectl config.
 func LoadConfigFromPath(path string) clientcmd.ClientConfig {
@@ -151,18 +259,29 @@ func LoadConfigFromPath(path string) clientcmd.ClientConfig {
 }
 
 // LoadApiClientConfigFromOptions will load a ClientConfig object based on the provided KubectlOptions. Specifically,
-// this will create the config in memory if using direct auth, and load from disk if not.
+// this will create the config in memory if using direct auth, and load from disk if not. The CA bundle configured with
+// eksawshelper.SetCABundle is trusted in addition to the CA of the cluster, and the timeouts configured with
+// eksawshelper.SetHTTPConfig are applied. Note that the Kubernetes client sends the requests through the proxy configured
+// with the HTTPS_PROXY and NO_PROXY environment variables.
 func LoadApiClientConfigFromOptions(options *KubectlOptions) (*restclient.Config, error) {
 	logger := logging.GetProjectLogger()
 
-	var server, token string
+	var server, token, tlsServerName string
 	var caData []byte
 
 	authScheme := options.AuthScheme()
 	switch authScheme {
 	case ConfigBased:
 		logger.Infof("Using config on disk and context.")
-		return LoadApiClientConfig(options.ConfigPath, options.ContextName)
+		config, err := LoadApiClientConfig(options.ConfigPath, options.ContextName)
+		if err != nil {
+			return nil, err
+		}
+		if err := addCABundleToConfig(config); err != nil {
+			return nil, err
+		}
+		kubehelper.ApplyHTTPConfig(config)
+		return config, nil
 	// for the other two methods, we need to extract the server cadata and token to construct the client config
 	case DirectAuth:
 		logger.Infof("Using direct auth methods to setup client.")
@@ -173,6 +292,7 @@ func LoadApiClientConfigFromOptions(options *KubectlOptions) (*restclient.Config
 		caData = caDataRaw
 		server = options.Server
 		token = options.BearerToken
+		tlsServerName = options.TLSServerName
 	case EKSClusterBased:
 		info, err := getKubeCredentialsFromEKSCluster(options.EKSClusterArn)
 		if err != nil {
@@ -187,6 +307,7 @@ func LoadApiClientConfigFromOptions(options *KubectlOptions) (*restclient.Config
 		caData = caDataRaw
 		server = info.Server
 		token = info.BearerToken
+		tlsServerName = info.TLSServerName
 	default:
 		// This should never happen, but is required by the compiler
 		return nil, errors.WithStackTrace(AuthSchemeNotSupported{authScheme})
@@ -200,13 +321,42 @@ func LoadApiClientConfigFromOptions(options *KubectlOptions) (*restclient.Config
 			NegotiatedSerializer: scheme.Codecs,
 		},
 		TLSClientConfig: restclient.TLSClientConfig{
-			Insecure: false,
-			CAData:   caData,
+			Insecure:   false,
+			CAData:     caData,
+			ServerName: tlsServerName,
 		},
 	}
+	if err := addCABundleToConfig(config); err != nil {
+		return nil, err
+	}
+	kubehelper.ApplyHTTPConfig(config)
 	return config, nil
 }
 
+// addCABundleToConfig adds the CA bundle configured with eksawshelper.SetCABundle, if any, to the CAs that the client
+// config trusts to verify the Kubernetes API server, e.g., so that the requests can go through a TLS intercepting proxy.
+func addCABundleToConfig(config *restclient.Config) error {
+	// The CAs are not used when TLS verification is disabled, and client-go refuses to set both.
+	if config.TLSClientConfig.Insecure {
+		return nil
+	}
+	caBundle, err := eksawshelper.LoadCABundle()
+	if err != nil || caBundle == nil {
+		return err
+	}
+
+	caData := config.TLSClientConfig.CAData
+	if len(caData) == 0 && config.TLSClientConfig.CAFile != "" {
+		caData, err = ioutil.ReadFile(config.TLSClientConfig.CAFile)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+	config.TLSClientConfig.CAData = append(append(append([]byte{}, caData...), '\n'), caBundle...)
+	config.TLSClientConfig.CAFile = ""
+	return nil
+}
+
 // LoadApiClientConfig will load a ClientConfig object from a file path that points to a location on disk containing a
 // kubectl config, with the requested context loaded.
 func LoadApiClientConfig(path string, context string) (*restclient.Config, error) {
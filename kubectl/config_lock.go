@@ -0,0 +1,51 @@
+package kubectl
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gruntwork-io/go-commons/errors"
+
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// LockConfigFile takes an exclusive advisory lock on the kubectl config at the given path, blocking until any other
+// kubergrunt process holding the lock releases it. Hold the lock around the read-modify-write of the config, so that
+// concurrent runs (e.g., parallel CI steps configuring different clusters) do not overwrite each other's entries. The
+// lock is taken on a separate <path>.lock file, as the config itself is replaced on every write by
+// WriteConfigAtomically.
+//
+// The returned function releases the lock, and must always be called, including on error paths. The lock is a noop on
+// platforms without flock, such as Windows.
+func LockConfigFile(path string) (func(), error) {
+	logger := logging.GetProjectLogger()
+
+	lockPath := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0755); err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+
+	logger.Debugf("Acquiring lock on kubectl config %s", path)
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, errors.WithStackTrace(err)
+	}
+	logger.Debugf("Acquired lock on kubectl config %s", path)
+
+	unlock := func() {
+		// NOTE: we do not remove the lock file, as another process may already be waiting on it, and would then hold a
+		// lock on a file that no longer exists while a third process locks a new one.
+		if err := unlockFile(file); err != nil {
+			logger.Warnf("Error releasing lock on kubectl config %s: %s", path, err)
+		}
+		if err := file.Close(); err != nil {
+			logger.Warnf("Error closing lock file %s: %s", lockPath, err)
+		}
+		logger.Debugf("Released lock on kubectl config %s", path)
+	}
+	return unlock, nil
+}
@@ -13,6 +13,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
 )
 
 type MockEksConfigContextData struct {
@@ -41,6 +43,32 @@ func TestCreateInitialConfigCreatesDir(t *testing.T) {
 	require.NotNil(t, kubeconfig)
 }
 
+func TestWriteConfigAtomicallyPreservesPermissions(t *testing.T) {
+	t.Parallel()
+
+	configDir := t.TempDir()
+	configPath := filepath.Join(configDir, "config")
+	require.NoError(t, os.WriteFile(configPath, []byte(INITIAL_BLANK_KUBECONFIG), 0640))
+
+	mockConfig := api.NewConfig()
+	require.NoError(t, AddContextToConfig(mockConfig, "test", "test", "test"))
+	require.NoError(t, WriteConfigAtomically(mockConfig, configPath))
+
+	info, err := os.Stat(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	// Only the config file should remain in the directory after the atomic write
+	dirEntries, err := os.ReadDir(configDir)
+	require.NoError(t, err)
+	assert.Len(t, dirEntries, 1)
+
+	kubeconfig := k8s.LoadConfigFromPath(configPath)
+	rawConfig, err := kubeconfig.RawConfig()
+	require.NoError(t, err)
+	assert.Contains(t, rawConfig.Contexts, "test")
+}
+
 func TestAddContextToConfig(t *testing.T) {
 	mockConfig := api.NewConfig()
 	contextName := random.UniqueId()
@@ -81,6 +109,43 @@ func TestAddEksConfigContextFailsOnAddingExistingContext(t *testing.T) {
 	require.IsType(t, ContextAlreadyExistsError{}, err, err.Error())
 }
 
+func TestUpsertEksConfigContextUpdatesExistingContext(t *testing.T) {
+	mockData, err := basicAddCall(t)
+	require.NoError(t, err)
+
+	newEndpoint := "new.gruntwork.io"
+	err = UpsertEksConfigContext(mockData.Config, mockData.Name, mockData.EksArn, newEndpoint, mockData.EksCAData)
+	require.NoError(t, err)
+
+	assert.Len(t, mockData.Config.Contexts, 1)
+	cluster, ok := mockData.Config.Clusters[mockData.EksArn]
+	require.True(t, ok)
+	assert.Equal(t, newEndpoint, cluster.Server)
+}
+
+func TestRemoveEksConfigContextOnlyRemovesEntriesOfCluster(t *testing.T) {
+	mockData, err := basicAddCall(t)
+	require.NoError(t, err)
+	mockData.Config.CurrentContext = mockData.Name
+
+	// A context named after the cluster ARN that refers to another cluster should be kept.
+	otherArn := mockData.EksArn + "-other"
+	require.NoError(t, UpsertEksConfigContext(mockData.Config, mockData.EksArn+"-context", otherArn, mockData.EksEndpoint, mockData.EksCAData))
+
+	removedContexts := RemoveEksConfigContext(mockData.Config, mockData.EksArn)
+	assert.Equal(t, []string{mockData.Name}, removedContexts)
+	assert.Equal(t, "", mockData.Config.CurrentContext)
+	assert.NotContains(t, mockData.Config.Clusters, mockData.EksArn)
+	assert.NotContains(t, mockData.Config.AuthInfos, mockData.EksArn)
+	assert.Contains(t, mockData.Config.Contexts, mockData.EksArn+"-context")
+	assert.Contains(t, mockData.Config.Clusters, otherArn)
+	assert.Contains(t, mockData.Config.AuthInfos, otherArn)
+
+	// Removing again is a noop.
+	assert.Empty(t, RemoveEksConfigContext(mockData.Config, mockData.EksArn))
+	assert.Len(t, mockData.Config.Contexts, 1)
+}
+
 func TestAddClusterToConfigAppendsCorrectClusterInfo(t *testing.T) {
 	mockConfig := api.NewConfig()
 	clusterName := "devops"
@@ -121,7 +186,7 @@ func TestAddEksConfigContextAppendsCorrectAuthInfo(t *testing.T) {
 	require.True(t, ok)
 
 	execInfo := authInfo.Exec
-	assert.Contains(t, execInfo.Args, mockData.EksName)
+	assert.Contains(t, execInfo.Args, mockData.EksArn)
 
 	// Verify none of the other authentication styles are set
 	assert.Equal(t, authInfo.ClientCertificate, "")
@@ -139,17 +204,16 @@ func TestAddEksConfigContextAppendsCorrectAuthInfo(t *testing.T) {
 
 func TestAddEksAuthInfoToConfigAppendsCorrectAuthInfo(t *testing.T) {
 	mockConfig := api.NewConfig()
-	name := t.Name()
 	arn := "arn:aws:eks:us-east-2:111111111111:cluster/" + t.Name()
 
-	err := AddEksAuthInfoToConfig(mockConfig, arn, name)
+	err := AddEksAuthInfoToConfig(mockConfig, arn)
 	require.NoError(t, err)
 
 	authInfo, ok := mockConfig.AuthInfos[arn]
 	require.True(t, ok)
 
 	execInfo := authInfo.Exec
-	assert.Contains(t, execInfo.Args, name)
+	assert.Contains(t, execInfo.Args, arn)
 
 	// Verify none of the other authentication styles are set
 	assert.Equal(t, authInfo.ClientCertificate, "")
@@ -165,6 +229,25 @@ func TestAddEksAuthInfoToConfigAppendsCorrectAuthInfo(t *testing.T) {
 	assert.Equal(t, authInfo.Password, "")
 }
 
+// NOTE: This test is not run in parallel since it sets the global CA bundle.
+func TestAddEksAuthInfoToConfigPropagatesCABundle(t *testing.T) {
+	eksawshelper.SetCABundle(filepath.Join("testdata", "ca-bundle.pem"))
+	defer eksawshelper.SetCABundle("")
+
+	mockConfig := api.NewConfig()
+	arn := "arn:aws:eks:us-east-2:111111111111:cluster/" + t.Name()
+
+	err := AddEksAuthInfoToConfig(mockConfig, arn)
+	require.NoError(t, err)
+
+	expectedCABundle, err := filepath.Abs(filepath.Join("testdata", "ca-bundle.pem"))
+	require.NoError(t, err)
+	args := mockConfig.AuthInfos[arn].Exec.Args
+	assert.Contains(t, args, "--ca-bundle")
+	assert.Contains(t, args, expectedCABundle)
+	assert.NotContains(t, args, "--endpoint-override")
+}
+
 // basicAddCall makes a call to AddEksConfigContext with fake data and returns the mock config, fake data, and if there
 // was an error adding the context.
 func basicAddCall(t *testing.T) (MockEksConfigContextData, error) {
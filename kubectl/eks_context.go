@@ -0,0 +1,93 @@
+package kubectl
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+)
+
+// GetEKSClusterArnFromContext returns the ARN of the EKS cluster that the given context of the kubectl config references,
+// so that the ARN based functions can be used with a context name. Use an empty context name for the current context.
+// The kubectl config is loaded from the given path, which may be a list of paths like the KUBECONFIG environment
+// variable, or from the default locations when the path is empty.
+//
+// The ARN is found in the name of the cluster entry or of the context (as set up by `aws eks update-kubeconfig` and
+// `kubergrunt eks configure`), or in the arguments of the exec command of the user entry (`kubergrunt eks token
+// --eks-cluster-arn`). Otherwise, if the exec command is `aws eks get-token` with the cluster name and region (as set up
+// by eksctl), the ARN is looked up with the EKS API. Returns a ContextNotEKSClusterError if the context does not
+// reference an EKS cluster.
+func GetEKSClusterArnFromContext(kubeconfigPath string, contextName string) (string, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfigPath != "" {
+		loadingRules.Precedence = filepath.SplitList(kubeconfigPath)
+	}
+	config, err := loadingRules.Load()
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return eksClusterArnFromContext(config, kubeconfigPath, contextName, eksawshelper.GetClusterArnByNameAndRegion)
+}
+
+func eksClusterArnFromContext(
+	config *api.Config,
+	kubeconfigPath string,
+	contextName string,
+	lookupClusterArn func(clusterName string, region string) (string, error),
+) (string, error) {
+	if contextName == "" {
+		contextName = config.CurrentContext
+	}
+	kubeContext, hasContext := config.Contexts[contextName]
+	if !hasContext {
+		return "", errors.WithStackTrace(KubeContextNotFound{Options: &KubectlOptions{ContextName: contextName, ConfigPath: kubeconfigPath}})
+	}
+
+	for _, candidate := range []string{kubeContext.Cluster, contextName} {
+		if isEKSClusterArn(candidate) {
+			return candidate, nil
+		}
+	}
+
+	authInfo, hasAuthInfo := config.AuthInfos[kubeContext.AuthInfo]
+	if !hasAuthInfo || authInfo.Exec == nil {
+		return "", errors.WithStackTrace(ContextNotEKSClusterError{ContextName: contextName})
+	}
+	args := authInfo.Exec.Args
+	if clusterArn := execArgValue(args, "--eks-cluster-arn"); isEKSClusterArn(clusterArn) {
+		return clusterArn, nil
+	}
+	clusterName := execArgValue(args, "--cluster-name")
+	region := execArgValue(args, "--region")
+	if clusterName == "" || region == "" {
+		return "", errors.WithStackTrace(ContextNotEKSClusterError{ContextName: contextName})
+	}
+	clusterArn, err := lookupClusterArn(clusterName, region)
+	if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	return clusterArn, nil
+}
+
+func isEKSClusterArn(candidate string) bool {
+	_, err := eksawshelper.GetClusterNameFromArn(candidate)
+	return err == nil
+}
+
+// execArgValue returns the value of the given flag in the arguments of an exec command, passed either as `--flag value`
+// or `--flag=value`, or an empty string if the flag is not set.
+func execArgValue(args []string, flagName string) string {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, flagName+"=") {
+			return strings.TrimPrefix(arg, flagName+"=")
+		}
+		if arg == flagName && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
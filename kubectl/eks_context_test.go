@@ -0,0 +1,121 @@
+package kubectl
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const testEksClusterArn = "arn:aws:eks:us-east-2:123456789012:cluster/prod"
+
+func newTestEKSContextConfig(contextName string, clusterName string, execArgs []string) *api.Config {
+	config := api.NewConfig()
+	config.Clusters[clusterName] = api.NewCluster()
+	authInfo := api.NewAuthInfo()
+	if execArgs != nil {
+		authInfo.Exec = &api.ExecConfig{Command: "kubergrunt", Args: execArgs}
+	}
+	config.AuthInfos[contextName] = authInfo
+	config.Contexts[contextName] = &api.Context{Cluster: clusterName, AuthInfo: contextName}
+	config.CurrentContext = contextName
+	return config
+}
+
+func TestEKSClusterArnFromContext(t *testing.T) {
+	t.Parallel()
+
+	lookupClusterArn := func(clusterName string, region string) (string, error) {
+		return "arn:aws:eks:" + region + ":123456789012:cluster/" + clusterName, nil
+	}
+
+	testCases := []struct {
+		name        string
+		config      *api.Config
+		contextName string
+		expectedArn string
+	}{
+		{"ClusterNamedAfterArn", newTestEKSContextConfig("prod", testEksClusterArn, nil), "prod", testEksClusterArn},
+		{"ContextNamedAfterArn", newTestEKSContextConfig(testEksClusterArn, "prod", nil), testEksClusterArn, testEksClusterArn},
+		{"CurrentContext", newTestEKSContextConfig("prod", testEksClusterArn, nil), "", testEksClusterArn},
+		{
+			"KubergruntToken",
+			newTestEKSContextConfig("prod", "prod", []string{"eks", "token", "--eks-cluster-arn", testEksClusterArn}),
+			"prod",
+			testEksClusterArn,
+		},
+		{
+			"KubergruntTokenWithEquals",
+			newTestEKSContextConfig("prod", "prod", []string{"eks", "token", "--eks-cluster-arn=" + testEksClusterArn}),
+			"prod",
+			testEksClusterArn,
+		},
+		{
+			"AWSGetToken",
+			newTestEKSContextConfig("prod", "prod.us-east-2.eksctl.io", []string{"eks", "get-token", "--cluster-name", "prod", "--region", "us-east-2"}),
+			"prod",
+			testEksClusterArn,
+		},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			clusterArn, err := eksClusterArnFromContext(testCase.config, "", testCase.contextName, lookupClusterArn)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedArn, clusterArn)
+		})
+	}
+}
+
+func TestEKSClusterArnFromContextErrorsForNonEKSContext(t *testing.T) {
+	t.Parallel()
+
+	lookupClusterArn := func(clusterName string, region string) (string, error) {
+		t.Fatal("Unexpected lookup of the cluster ARN")
+		return "", nil
+	}
+
+	testCases := []struct {
+		name   string
+		config *api.Config
+	}{
+		{"NoExec", newTestEKSContextConfig("minikube", "minikube", nil)},
+		{"OtherExec", newTestEKSContextConfig("gke", "gke", []string{"--use_application_default_credentials"})},
+		{"GetTokenWithoutRegion", newTestEKSContextConfig("prod", "prod", []string{"eks", "get-token", "--cluster-name", "prod"})},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := eksClusterArnFromContext(testCase.config, "", "", lookupClusterArn)
+			require.Error(t, err)
+			_, isNotEKSErr := errors.Unwrap(err).(ContextNotEKSClusterError)
+			assert.True(t, isNotEKSErr)
+		})
+	}
+}
+
+func TestGetEKSClusterArnFromContextLoadsConfig(t *testing.T) {
+	t.Parallel()
+
+	configPath := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, clientcmd.WriteToFile(*newTestEKSContextConfig("prod", testEksClusterArn, nil), configPath))
+
+	clusterArn, err := GetEKSClusterArnFromContext(configPath, "prod")
+	require.NoError(t, err)
+	assert.Equal(t, testEksClusterArn, clusterArn)
+
+	_, err = GetEKSClusterArnFromContext(configPath, "staging")
+	require.Error(t, err)
+	_, isNotFoundErr := errors.Unwrap(err).(KubeContextNotFound)
+	assert.True(t, isNotFoundErr)
+}
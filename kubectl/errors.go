@@ -2,6 +2,8 @@ package kubectl
 
 import (
 	"fmt"
+	"strings"
+	"time"
 )
 
 // KubeContextNotFound error is returned when the specified Kubernetes context is unabailable in the specified
@@ -101,6 +103,34 @@ func (err ProvisionIngressEndpointTimeoutError) Error() string {
 	)
 }
 
+// IngressEndpointTimeoutError is returned when we time out waiting for the endpoint of an Ingress to be provisioned and
+// pass its health probe. It includes the last observed status to help debug why the endpoint is not ready.
+type IngressEndpointTimeoutError struct {
+	ingressName string
+	namespace   string
+	timeout     time.Duration
+	lastStatus  string
+}
+
+func (err IngressEndpointTimeoutError) Error() string {
+	return fmt.Sprintf(
+		"Timed out after %s waiting for Ingress %s (Namespace: %s) endpoint. Last observed status: %s",
+		err.timeout,
+		err.ingressName,
+		err.namespace,
+		err.lastStatus,
+	)
+}
+
+// UnknownIngressProbeProtocolError is returned when the health probe for an Ingress endpoint has an unsupported protocol.
+type UnknownIngressProbeProtocolError struct {
+	protocol IngressProbeProtocol
+}
+
+func (err UnknownIngressProbeProtocolError) Error() string {
+	return fmt.Sprintf("Unknown Ingress probe protocol %q. Must be one of tcp, http, or https.", err.protocol)
+}
+
 // UnknownAWSLoadBalancerTypeErr is returned when we encounter a load balancer type that we don't expect/support.
 type UnknownAWSLoadBalancerTypeErr struct {
 	typeKey string
@@ -114,3 +144,26 @@ func (err UnknownAWSLoadBalancerTypeErr) Error() string {
 		err.typeStr,
 	)
 }
+
+// ContextNotEKSClusterError is returned when the kubectl config context does not reference an EKS cluster.
+type ContextNotEKSClusterError struct {
+	ContextName string
+}
+
+func (err ContextNotEKSClusterError) Error() string {
+	return fmt.Sprintf(
+		"Context %s does not reference an EKS cluster: neither the context nor its cluster is named after an EKS cluster ARN, and its user does not authenticate with kubergrunt eks token or aws eks get-token.",
+		err.ContextName,
+	)
+}
+
+// InvalidMetadataKeyPrefixError is returned when the configured prefix for the annotation keys set by kubergrunt is not
+// a valid DNS subdomain.
+type InvalidMetadataKeyPrefixError struct {
+	Prefix  string
+	Reasons []string
+}
+
+func (err InvalidMetadataKeyPrefixError) Error() string {
+	return fmt.Sprintf("Invalid metadata key prefix %q: %s", err.Prefix, strings.Join(err.Reasons, "; "))
+}
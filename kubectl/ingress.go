@@ -2,12 +2,22 @@ package kubectl
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/hashicorp/go-multierror"
 	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
 
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
 	"github.com/gruntwork-io/kubergrunt/logging"
 )
 
@@ -56,7 +66,7 @@ func WaitUntilIngressEndpointProvisioned(
 	logger.Infof("Waiting for Ingress %s (Namespace: %s) endpoint to be provisioned.", ingressName, namespace)
 
 	for i := 0; i < maxRetries; i++ {
-		logger.Info("Retrieving Ingress and checking if the endpoint is provisioned.")
+		logger.Debug("Retrieving Ingress and checking if the endpoint is provisioned.")
 
 		ingress, err := GetIngress(options, namespace, ingressName)
 		if err == nil && IsIngressAvailable(ingress) {
@@ -65,9 +75,352 @@ func WaitUntilIngressEndpointProvisioned(
 			return nil
 		}
 
-		logger.Warnf("Endpoint for Ingress %s (Namespace: %s) is not provisioned yet", ingressName, namespace)
-		logger.Infof("Waiting for %s...", sleepBetweenRetries)
+		logger.Debugf("Endpoint for Ingress %s (Namespace: %s) is not provisioned yet", ingressName, namespace)
+		logger.Debugf("Waiting for %s...", sleepBetweenRetries)
 		time.Sleep(sleepBetweenRetries)
 	}
 	return errors.WithStackTrace(ProvisionIngressEndpointTimeoutError{ingressName: ingressName, namespace: namespace})
 }
+
+// defaultIngressPollInterval is how often WaitForIngressEndpoint checks the Ingress status and probes the endpoint.
+const defaultIngressPollInterval = 5 * time.Second
+
+// ingressProbeTimeout is the timeout of a single health probe against the Ingress endpoint.
+const ingressProbeTimeout = 5 * time.Second
+
+// IngressProbeProtocol is the protocol used to probe the endpoint of an Ingress once it is provisioned.
+type IngressProbeProtocol string
+
+const (
+	// IngressProbeTCP probes the endpoint by opening a TCP connection to it.
+	IngressProbeTCP IngressProbeProtocol = "tcp"
+	// IngressProbeHTTP probes the endpoint with an HTTP GET request.
+	IngressProbeHTTP IngressProbeProtocol = "http"
+	// IngressProbeHTTPS probes the endpoint with an HTTPS GET request.
+	IngressProbeHTTPS IngressProbeProtocol = "https"
+)
+
+func (protocol IngressProbeProtocol) isValid() bool {
+	return protocol == IngressProbeTCP || protocol == IngressProbeHTTP || protocol == IngressProbeHTTPS
+}
+
+// IngressProbe configures the health probe that WaitForIngressEndpointWithProbe runs against the endpoint of an Ingress
+// once it is provisioned. For the HTTP protocols, any response with a status code below 500 is considered healthy, as
+// load balancers commonly respond with a 404 to paths that are not routed, unless ExpectedStatus is set.
+type IngressProbe struct {
+	Protocol IngressProbeProtocol
+	// Port defaults to 80 for TCP and HTTP, and 443 for HTTPS.
+	Port int
+	// Path is the path requested by the HTTP protocols. Defaults to /.
+	Path string
+
+	// ExpectedStatus is the status code that the HTTP protocols must respond with for the endpoint to be healthy, e.g.,
+	// 200 to wait for the app behind the load balancer to serve the path. Zero accepts any status code below 500.
+	ExpectedStatus int
+
+	// FollowRedirects follows the redirects returned by the HTTP protocols, and checks the status of the final response.
+	// When false, the status of the redirect response itself is checked.
+	FollowRedirects bool
+
+	// Insecure skips the verification of the TLS certificate for the HTTPS protocol, e.g., for backends with self signed
+	// certificates.
+	Insecure bool
+}
+
+// WaitForIngressEndpoint waits up to the given timeout for the load balancer of the Ingress in the EKS cluster to be
+// provisioned, and returns its endpoint. This is the hostname for ALBs, or the IP address for load balancers that only
+// report an IP.
+func WaitForIngressEndpoint(clusterArn string, namespace string, ingressName string, timeout time.Duration) (string, error) {
+	options := &KubectlOptions{EKSClusterArn: clusterArn}
+	return WaitForIngressEndpointWithProbe(options, namespace, ingressName, timeout, nil)
+}
+
+// WaitForIngressEndpointWithProbe is like WaitForIngressEndpoint, but additionally waits until the endpoint passes the
+// given health probe, if it is not nil. This is useful to block until the load balancer is actually reachable, as
+// hostnames of new load balancers take some time to resolve.
+func WaitForIngressEndpointWithProbe(
+	options *KubectlOptions,
+	namespace string,
+	ingressName string,
+	timeout time.Duration,
+	probe *IngressProbe,
+) (string, error) {
+	client, err := GetKubernetesClientFromOptions(options)
+	if err != nil {
+		return "", err
+	}
+	return waitForIngressEndpoint(client, namespace, ingressName, timeout, defaultIngressPollInterval, probe)
+}
+
+func waitForIngressEndpoint(
+	client kubernetes.Interface,
+	namespace string,
+	ingressName string,
+	timeout time.Duration,
+	pollInterval time.Duration,
+	probe *IngressProbe,
+) (string, error) {
+	if probe != nil && !probe.Protocol.isValid() {
+		return "", errors.WithStackTrace(UnknownIngressProbeProtocolError{protocol: probe.Protocol})
+	}
+
+	logger := logging.GetProjectLogger()
+	logger.Infof("Waiting up to %s for Ingress %s (Namespace: %s) endpoint to be provisioned.", timeout, ingressName, namespace)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	lastStatus := "Ingress not retrieved yet"
+	probed := false
+	for {
+		ingress, err := client.NetworkingV1().Ingresses(namespace).Get(ctx, ingressName, metav1.GetOptions{})
+		switch {
+		case err != nil:
+			lastStatus = fmt.Sprintf("error retrieving Ingress: %s", err)
+		case !IsIngressAvailable(ingress):
+			lastStatus = "no load balancer endpoint provisioned"
+		default:
+			endpoint := GetIngressEndpoints(ingress)[0]
+			if probe == nil {
+				logger.Infof("Endpoint for Ingress %s (Namespace: %s): %s", ingressName, namespace, endpoint)
+				return endpoint, nil
+			}
+			probeErr := probe.run(ctx, endpoint)
+			if probeErr == nil {
+				logger.Infof("Endpoint for Ingress %s (Namespace: %s) %s passed the %s probe", ingressName, namespace, endpoint, probe.Protocol)
+				return endpoint, nil
+			}
+			// Keep the result of the previous probe when this one is cut short by the timeout, so that the last status
+			// observed from the endpoint is reported.
+			if !probed || ctx.Err() == nil {
+				lastStatus = fmt.Sprintf("endpoint %s provisioned, but failed the %s probe: %s", endpoint, probe.Protocol, probeErr)
+			}
+			probed = true
+		}
+		logger.Debugf("Ingress %s (Namespace: %s) is not ready yet: %s", ingressName, namespace, lastStatus)
+
+		select {
+		case <-ctx.Done():
+			return "", errors.WithStackTrace(IngressEndpointTimeoutError{
+				ingressName: ingressName,
+				namespace:   namespace,
+				timeout:     timeout,
+				lastStatus:  lastStatus,
+			})
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// run probes the endpoint, returning an error if it is not healthy.
+func (probe IngressProbe) run(ctx context.Context, endpoint string) error {
+	port := probe.Port
+	if port == 0 {
+		port = 80
+		if probe.Protocol == IngressProbeHTTPS {
+			port = 443
+		}
+	}
+	address := net.JoinHostPort(endpoint, strconv.Itoa(port))
+
+	ctx, cancel := context.WithTimeout(ctx, ingressProbeTimeout)
+	defer cancel()
+
+	switch probe.Protocol {
+	case IngressProbeTCP:
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	case IngressProbeHTTP, IngressProbeHTTPS:
+		path := probe.Path
+		if path == "" {
+			path = "/"
+		}
+		url := fmt.Sprintf("%s://%s%s", probe.Protocol, address, path)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		httpClient, err := probe.newHTTPClient()
+		if err != nil {
+			return err
+		}
+		defer httpClient.CloseIdleConnections()
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		switch {
+		case probe.ExpectedStatus != 0 && resp.StatusCode != probe.ExpectedStatus:
+			return fmt.Errorf("GET %s returned status %s, expected %d", url, resp.Status, probe.ExpectedStatus)
+		case probe.ExpectedStatus == 0 && resp.StatusCode >= http.StatusInternalServerError:
+			return fmt.Errorf("GET %s returned status %s", url, resp.Status)
+		}
+		return nil
+	}
+	return UnknownIngressProbeProtocolError{protocol: probe.Protocol}
+}
+
+// newHTTPClient returns the HTTP client for the probe, which honors the proxy and CA bundle settings of
+// eksawshelper.NewHTTPClient, along with the redirect and TLS verification settings of the probe.
+func (probe IngressProbe) newHTTPClient() (*http.Client, error) {
+	httpClient, err := eksawshelper.NewHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	if !probe.FollowRedirects {
+		httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	if probe.Insecure {
+		transport := httpClient.Transport.(*http.Transport)
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+	return httpClient, nil
+}
+
+// WaitForIngressEndpoints waits up to the given timeout for the load balancers of all the named Ingresses in the
+// namespace of the EKS cluster to be provisioned, and returns a map from the Ingress name to its endpoint. Instead of
+// polling each Ingress, this uses a single watch on the Ingresses in the namespace to reduce the load on the API
+// server. If any of the Ingresses time out, this returns the endpoints that were provisioned along with an error that
+// lists each Ingress that timed out.
+func WaitForIngressEndpoints(
+	clusterArn string,
+	namespace string,
+	names []string,
+	timeout time.Duration,
+) (map[string]string, error) {
+	client, err := GetKubernetesClientFromOptions(&KubectlOptions{EKSClusterArn: clusterArn})
+	if err != nil {
+		return nil, err
+	}
+	return waitForIngressEndpoints(client, namespace, names, timeout, defaultIngressPollInterval)
+}
+
+// waitForIngressEndpoints lists the Ingresses in the namespace to get their current status, and then watches for
+// changes from there. When the watch is closed by the API server, the Ingresses are listed and watched again. The
+// retryInterval is how long to wait before trying again if listing or watching fails.
+func waitForIngressEndpoints(
+	client kubernetes.Interface,
+	namespace string,
+	names []string,
+	timeout time.Duration,
+	retryInterval time.Duration,
+) (map[string]string, error) {
+	logger := logging.GetProjectLogger()
+	logger.Infof("Waiting up to %s for endpoints of Ingresses %v (Namespace: %s) to be provisioned.", timeout, names, namespace)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	lastStatus := map[string]string{}
+	for _, name := range names {
+		lastStatus[name] = "Ingress not found"
+	}
+	endpoints := map[string]string{}
+	observe := func(ingress *networkingv1.Ingress, deleted bool) {
+		name := ingress.Name
+		if _, isWanted := lastStatus[name]; !isWanted {
+			return
+		}
+		switch {
+		case deleted:
+			delete(endpoints, name)
+			lastStatus[name] = "Ingress deleted"
+		case IsIngressAvailable(ingress):
+			if _, isKnown := endpoints[name]; !isKnown {
+				endpoints[name] = GetIngressEndpoints(ingress)[0]
+				logger.Infof("Endpoint for Ingress %s (Namespace: %s): %s", name, namespace, endpoints[name])
+			}
+		default:
+			delete(endpoints, name)
+			lastStatus[name] = "no load balancer endpoint provisioned"
+		}
+	}
+	isDone := func() bool { return len(endpoints) == len(lastStatus) }
+
+	for !isDone() && ctx.Err() == nil {
+		err := watchIngressesOnce(ctx, client, namespace, observe, isDone)
+		if err != nil && ctx.Err() == nil {
+			logger.Warnf("Error watching Ingresses (Namespace: %s): %s. Retrying in %s.", namespace, err, retryInterval)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryInterval):
+			}
+		}
+	}
+	if isDone() {
+		return endpoints, nil
+	}
+
+	var timeoutErrs *multierror.Error
+	for _, name := range names {
+		if _, isProvisioned := endpoints[name]; isProvisioned {
+			continue
+		}
+		timeoutErrs = multierror.Append(timeoutErrs, IngressEndpointTimeoutError{
+			ingressName: name,
+			namespace:   namespace,
+			timeout:     timeout,
+			lastStatus:  lastStatus[name],
+		})
+	}
+	return endpoints, errors.WithStackTrace(timeoutErrs.ErrorOrNil())
+}
+
+// watchIngressesOnce lists the Ingresses in the namespace, and then watches for changes until isDone returns true, the
+// context is done, or the API server closes the watch. Every observed Ingress is passed to observe.
+func watchIngressesOnce(
+	ctx context.Context,
+	client kubernetes.Interface,
+	namespace string,
+	observe func(ingress *networkingv1.Ingress, deleted bool),
+	isDone func() bool,
+) error {
+	ingresses, err := client.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range ingresses.Items {
+		observe(&ingresses.Items[i], false)
+	}
+	if isDone() {
+		return nil
+	}
+
+	watcher, err := client.NetworkingV1().Ingresses(namespace).Watch(ctx, metav1.ListOptions{ResourceVersion: ingresses.ResourceVersion})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, isOpen := <-watcher.ResultChan():
+			if !isOpen {
+				return nil
+			}
+			if event.Type == watch.Error {
+				return apierrors.FromObject(event.Object)
+			}
+			ingress, isIngress := event.Object.(*networkingv1.Ingress)
+			if !isIngress {
+				continue
+			}
+			observe(ingress, event.Type == watch.Deleted)
+			if isDone() {
+				return nil
+			}
+		}
+	}
+}
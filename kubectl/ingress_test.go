@@ -1,14 +1,25 @@
 package kubectl
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/gruntwork-io/go-commons/errors"
 	"github.com/gruntwork-io/terratest/modules/k8s"
 	"github.com/gruntwork-io/terratest/modules/random"
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 const ExampleIngressName = "nginx-service-ingress"
@@ -65,6 +76,204 @@ func TestWaitUntilIngressAvailableReturnsSuccessfully(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestWaitForIngressEndpointReturnsEndpoint(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		status   []networkingv1.IngressLoadBalancerIngress
+		expected string
+	}{
+		{
+			"alb-hostname",
+			[]networkingv1.IngressLoadBalancerIngress{{Hostname: "k8s-default-web-1234.us-east-1.elb.amazonaws.com"}},
+			"k8s-default-web-1234.us-east-1.elb.amazonaws.com",
+		},
+		{
+			"nlb-ip",
+			[]networkingv1.IngressLoadBalancerIngress{{IP: "10.0.0.10"}},
+			"10.0.0.10",
+		},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := fake.NewSimpleClientset(testIngress(testCase.status))
+			endpoint, err := waitForIngressEndpoint(client, "default", "web", time.Second, 10*time.Millisecond, nil)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expected, endpoint)
+		})
+	}
+}
+
+func TestWaitForIngressEndpointTimesOutWithLastStatus(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(testIngress(nil))
+	_, err := waitForIngressEndpoint(client, "default", "web", 50*time.Millisecond, 10*time.Millisecond, nil)
+	require.Error(t, err)
+
+	timeoutErr, isTimeoutErr := errors.Unwrap(err).(IngressEndpointTimeoutError)
+	require.True(t, isTimeoutErr)
+	assert.Equal(t, "no load balancer endpoint provisioned", timeoutErr.lastStatus)
+}
+
+func TestWaitForIngressEndpointWithProbe(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/healthz":
+			w.WriteHeader(http.StatusOK)
+		case "/redirect":
+			http.Redirect(w, r, "/healthz", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	// The subtests run in parallel after this function returns, so close the server on cleanup instead of deferring.
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name           string
+		probe          IngressProbe
+		healthy        bool
+		expectedStatus string
+	}{
+		{"tcp", IngressProbe{Protocol: IngressProbeTCP, Port: port}, true, ""},
+		{"http", IngressProbe{Protocol: IngressProbeHTTP, Port: port}, true, ""},
+		{"http-server-error", IngressProbe{Protocol: IngressProbeHTTP, Port: port, Path: "/broken"}, false, "502"},
+		{"http-expected-status", IngressProbe{Protocol: IngressProbeHTTP, Port: port, Path: "/healthz", ExpectedStatus: 200}, true, ""},
+		{"http-unexpected-status", IngressProbe{Protocol: IngressProbeHTTP, Port: port, ExpectedStatus: 200}, false, "404"},
+		{"http-redirect-not-followed", IngressProbe{Protocol: IngressProbeHTTP, Port: port, Path: "/redirect", ExpectedStatus: 200}, false, "302"},
+		{"http-redirect-followed", IngressProbe{Protocol: IngressProbeHTTP, Port: port, Path: "/redirect", ExpectedStatus: 200, FollowRedirects: true}, true, ""},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := fake.NewSimpleClientset(testIngress([]networkingv1.IngressLoadBalancerIngress{{IP: serverURL.Hostname()}}))
+			endpoint, err := waitForIngressEndpoint(client, "default", "web", 200*time.Millisecond, 10*time.Millisecond, &testCase.probe)
+			if testCase.healthy {
+				require.NoError(t, err)
+				assert.Equal(t, serverURL.Hostname(), endpoint)
+				return
+			}
+			timeoutErr, isTimeoutErr := errors.Unwrap(err).(IngressEndpointTimeoutError)
+			require.True(t, isTimeoutErr)
+			assert.Contains(t, timeoutErr.lastStatus, "failed the http probe")
+			// The last observed status code is reported, so that the failure can be diagnosed.
+			assert.Contains(t, timeoutErr.lastStatus, "returned status "+testCase.expectedStatus)
+		})
+	}
+}
+
+func TestWaitForIngressEndpointWithInsecureProbe(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(serverURL.Port())
+	require.NoError(t, err)
+	client := fake.NewSimpleClientset(testIngress([]networkingv1.IngressLoadBalancerIngress{{IP: serverURL.Hostname()}}))
+
+	// The self signed certificate of the test server is rejected unless the probe is insecure.
+	probe := &IngressProbe{Protocol: IngressProbeHTTPS, Port: port, ExpectedStatus: 200}
+	_, err = waitForIngressEndpoint(client, "default", "web", 100*time.Millisecond, 10*time.Millisecond, probe)
+	timeoutErr, isTimeoutErr := errors.Unwrap(err).(IngressEndpointTimeoutError)
+	require.True(t, isTimeoutErr)
+	assert.Contains(t, timeoutErr.lastStatus, "certificate")
+
+	probe.Insecure = true
+	endpoint, err := waitForIngressEndpoint(client, "default", "web", 100*time.Millisecond, 10*time.Millisecond, probe)
+	require.NoError(t, err)
+	assert.Equal(t, serverURL.Hostname(), endpoint)
+}
+
+func TestWaitForIngressEndpoints(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(
+		testNamedIngress("web", []networkingv1.IngressLoadBalancerIngress{{Hostname: "web.elb.amazonaws.com"}}),
+		testNamedIngress("api", nil),
+	)
+
+	// Provision the api Ingress once the watch is established, so that it is only observed through the watch.
+	go func() {
+		for !hasWatchAction(client) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		ingress := testNamedIngress("api", []networkingv1.IngressLoadBalancerIngress{{IP: "10.0.0.10"}})
+		_, err := client.NetworkingV1().Ingresses("default").UpdateStatus(context.Background(), ingress, metav1.UpdateOptions{})
+		assert.NoError(t, err)
+	}()
+
+	endpoints, err := waitForIngressEndpoints(client, "default", []string{"web", "api"}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"web": "web.elb.amazonaws.com", "api": "10.0.0.10"}, endpoints)
+}
+
+func TestWaitForIngressEndpointsListsTimedOutIngresses(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset(
+		testNamedIngress("web", []networkingv1.IngressLoadBalancerIngress{{Hostname: "web.elb.amazonaws.com"}}),
+		testNamedIngress("api", nil),
+	)
+	endpoints, err := waitForIngressEndpoints(client, "default", []string{"web", "api", "admin"}, 50*time.Millisecond, 10*time.Millisecond)
+	require.Error(t, err)
+	assert.Equal(t, map[string]string{"web": "web.elb.amazonaws.com"}, endpoints)
+
+	multiErr, isMultiErr := errors.Unwrap(err).(*multierror.Error)
+	require.True(t, isMultiErr)
+	lastStatuses := map[string]string{}
+	for _, err := range multiErr.Errors {
+		timeoutErr, isTimeoutErr := err.(IngressEndpointTimeoutError)
+		require.True(t, isTimeoutErr)
+		lastStatuses[timeoutErr.ingressName] = timeoutErr.lastStatus
+	}
+	assert.Equal(t, map[string]string{"api": "no load balancer endpoint provisioned", "admin": "Ingress not found"}, lastStatuses)
+}
+
+func hasWatchAction(client *fake.Clientset) bool {
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "watch" {
+			return true
+		}
+	}
+	return false
+}
+
+func testIngress(status []networkingv1.IngressLoadBalancerIngress) *networkingv1.Ingress {
+	return testNamedIngress("web", status)
+}
+
+func testNamedIngress(name string, status []networkingv1.IngressLoadBalancerIngress) *networkingv1.Ingress {
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status:     networkingv1.IngressStatus{LoadBalancer: networkingv1.IngressLoadBalancerStatus{Ingress: status}},
+	}
+}
+
 const exampleIngressDeploymentYAMLTemplate = `---
 apiVersion: v1
 kind: Namespace
@@ -0,0 +1,122 @@
+package kubectl
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// ManagedByLabelKey is the recommended Kubernetes label that kubergrunt sets to ManagedByLabelValue on the resources
+	// it creates, so that they can be told apart from the resources created by other tools.
+	ManagedByLabelKey   = "app.kubernetes.io/managed-by"
+	ManagedByLabelValue = "kubergrunt"
+
+	// DefaultMetadataKeyPrefix is the prefix of the annotation keys that kubergrunt sets on the resources it creates or
+	// modifies, if none is configured with SetMetadataKeyPrefix.
+	DefaultMetadataKeyPrefix = "gruntwork.io"
+
+	unknownKubergruntVersion = "unknown"
+)
+
+var (
+	metadataKeyPrefix = DefaultMetadataKeyPrefix
+	kubergruntVersion = unknownKubergruntVersion
+)
+
+// SetMetadataKeyPrefix configures the prefix of the annotation keys that kubergrunt sets on the resources it creates or
+// modifies, for organizations with their own conventions. The prefix must be a DNS subdomain, e.g., example.com.
+func SetMetadataKeyPrefix(prefix string) error {
+	if reasons := validation.IsDNS1123Subdomain(prefix); len(reasons) > 0 {
+		return errors.WithStackTrace(InvalidMetadataKeyPrefixError{Prefix: prefix, Reasons: reasons})
+	}
+	metadataKeyPrefix = prefix
+	return nil
+}
+
+// SetKubergruntVersion configures the version of kubergrunt that is recorded on the resources it creates or modifies.
+// Builds without a version are recorded as unknown.
+func SetKubergruntVersion(version string) {
+	if version == "" {
+		version = unknownKubergruntVersion
+	}
+	kubergruntVersion = version
+}
+
+// VersionAnnotationKey returns the key of the annotation that records the version of kubergrunt that last created or
+// modified the resource.
+func VersionAnnotationKey() string {
+	return fmt.Sprintf("%s/kubergrunt-version", metadataKeyPrefix)
+}
+
+// LastModifiedAnnotationKey returns the key of the annotation that records when kubergrunt last created or modified the
+// resource, as an RFC 3339 timestamp in UTC.
+func LastModifiedAnnotationKey() string {
+	return fmt.Sprintf("%s/kubergrunt-last-modified", metadataKeyPrefix)
+}
+
+// ModifiedByKubergruntAnnotations returns the annotations that MarkAsModifiedByKubergrunt sets.
+func ModifiedByKubergruntAnnotations() map[string]string {
+	return map[string]string{
+		VersionAnnotationKey():      kubergruntVersion,
+		LastModifiedAnnotationKey(): time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// MarkAsCreatedByKubergrunt labels the resource with the given metadata as managed by kubergrunt, and records the
+// version of kubergrunt and the current time in its annotations. Use this on the resources that kubergrunt creates and
+// owns, which the cleanup commands may safely delete.
+func MarkAsCreatedByKubergrunt(meta *metav1.ObjectMeta) {
+	if meta.Labels == nil {
+		meta.Labels = map[string]string{}
+	}
+	meta.Labels[ManagedByLabelKey] = ManagedByLabelValue
+	MarkAsModifiedByKubergrunt(meta)
+}
+
+// MarkAsModifiedByKubergrunt records the version of kubergrunt and the current time in the annotations of the resource
+// with the given metadata. Unlike MarkAsCreatedByKubergrunt, this does not label the resource as managed by kubergrunt,
+// as it is used on resources owned by something else, such as the core components deployed by EKS.
+func MarkAsModifiedByKubergrunt(meta *metav1.ObjectMeta) {
+	if meta.Annotations == nil {
+		meta.Annotations = map[string]string{}
+	}
+	for key, value := range ModifiedByKubergruntAnnotations() {
+		meta.Annotations[key] = value
+	}
+}
+
+// ModifiedByKubergruntMergePatch returns the JSON merge patch that sets the annotations of MarkAsModifiedByKubergrunt,
+// for the resources that are updated with a patch instead of the full object.
+func ModifiedByKubergruntMergePatch() ([]byte, error) {
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": ModifiedByKubergruntAnnotations()},
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return data, nil
+}
+
+// IsManagedByKubergrunt returns whether the resource with the given metadata is labeled as managed by kubergrunt.
+func IsManagedByKubergrunt(meta metav1.ObjectMeta) bool {
+	return meta.Labels[ManagedByLabelKey] == ManagedByLabelValue
+}
+
+// AnnotateAsModifiedByKubergrunt runs `kubectl annotate` to record the version of kubergrunt and the current time on
+// the given resource (e.g., daemonset/aws-node), for the resources that are modified with kubectl instead of the API.
+func AnnotateAsModifiedByKubergrunt(options *KubectlOptions, resource string, namespace string) error {
+	args := []string{"annotate", "--overwrite", resource}
+	if namespace != "" {
+		args = append(args, "-n", namespace)
+	}
+	for key, value := range ModifiedByKubergruntAnnotations() {
+		args = append(args, fmt.Sprintf("%s=%s", key, value))
+	}
+	return RunKubectl(options, args...)
+}
@@ -0,0 +1,65 @@
+package kubectl
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMarkAsCreatedByKubergrunt(t *testing.T) {
+	t.Parallel()
+
+	meta := metav1.ObjectMeta{Labels: map[string]string{"app": "web"}}
+	MarkAsCreatedByKubergrunt(&meta)
+	assert.True(t, IsManagedByKubergrunt(meta))
+	assert.Equal(t, "web", meta.Labels["app"])
+	assertModifiedByKubergrunt(t, meta.Annotations)
+}
+
+func TestMarkAsModifiedByKubergruntDoesNotLabel(t *testing.T) {
+	t.Parallel()
+
+	meta := metav1.ObjectMeta{Annotations: map[string]string{"deployment.kubernetes.io/revision": "3"}}
+	MarkAsModifiedByKubergrunt(&meta)
+	assert.False(t, IsManagedByKubergrunt(meta))
+	assert.Equal(t, "3", meta.Annotations["deployment.kubernetes.io/revision"])
+	assertModifiedByKubergrunt(t, meta.Annotations)
+}
+
+func TestModifiedByKubergruntMergePatch(t *testing.T) {
+	t.Parallel()
+
+	patch, err := ModifiedByKubergruntMergePatch()
+	require.NoError(t, err)
+	var parsed struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	}
+	require.NoError(t, json.Unmarshal(patch, &parsed))
+	assertModifiedByKubergrunt(t, parsed.Metadata.Annotations)
+}
+
+func TestSetMetadataKeyPrefixRejectsInvalidPrefix(t *testing.T) {
+	t.Parallel()
+
+	for _, prefix := range []string{"", "Example.com", "example.com/kubergrunt"} {
+		err := SetMetadataKeyPrefix(prefix)
+		require.Error(t, err)
+		_, isInvalidPrefixErr := errors.Unwrap(err).(InvalidMetadataKeyPrefixError)
+		assert.True(t, isInvalidPrefixErr)
+	}
+	assert.Equal(t, "gruntwork.io/kubergrunt-version", VersionAnnotationKey())
+}
+
+func assertModifiedByKubergrunt(t *testing.T, annotations map[string]string) {
+	assert.Equal(t, unknownKubergruntVersion, annotations[VersionAnnotationKey()])
+	modifiedAt, err := time.Parse(time.RFC3339, annotations[LastModifiedAnnotationKey()])
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), modifiedAt, time.Minute)
+}
@@ -30,7 +30,7 @@ func WaitForNodesReady(
 		return errors.WithStackTrace(err)
 	}
 	for i := 0; i < maxRetries; i++ {
-		logger.Infof("Checking if nodes ready")
+		logger.Debugf("Checking if nodes ready")
 		nodes, err := GetNodes(client, metav1.ListOptions{})
 		if err != nil {
 			return errors.WithStackTrace(err)
@@ -43,12 +43,12 @@ func WaitForNodesReady(
 			return nil
 		}
 		if !allNewNodesRegistered {
-			logger.Infof("Not all nodes are registered yet")
+			logger.Debugf("Not all nodes are registered yet")
 		}
 		if !allNewNodesReady {
-			logger.Infof("Not all nodes are ready yet")
+			logger.Debugf("Not all nodes are ready yet")
 		}
-		logger.Infof("Waiting for %s...", sleepBetweenRetries)
+		logger.Debugf("Waiting for %s...", sleepBetweenRetries)
 		time.Sleep(sleepBetweenRetries)
 	}
 	// Time out
@@ -5,13 +5,13 @@ import (
 	"io/ioutil"
 	"os"
 
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/gruntwork-io/go-commons/errors"
 	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
 	"github.com/gruntwork-io/kubergrunt/logging"
 )
 
@@ -35,6 +35,10 @@ type KubectlOptions struct {
 	Base64PEMCertificateAuthority string
 	BearerToken                   string
 
+	// TLSServerName is the server name to verify the certificate of Server against, for the direct authentication
+	// scheme. Defaults to the host of Server when empty.
+	TLSServerName string
+
 	// EKS based authentication scheme. Has precedence over direct or config based scheme.
 	EKSClusterArn string
 }
@@ -43,6 +47,7 @@ type serverInfo struct {
 	Server                        string
 	Base64PEMCertificateAuthority string
 	BearerToken                   string
+	TLSServerName                 string
 }
 
 // TempConfigFromAuthInfo will create a temporary kubeconfig file that can be used with commands that don't support
@@ -71,6 +76,7 @@ func (options *KubectlOptions) TempConfigFromAuthInfo() (string, error) {
 				Server:                        options.Server,
 				Base64PEMCertificateAuthority: options.Base64PEMCertificateAuthority,
 				BearerToken:                   options.BearerToken,
+				TLSServerName:                 options.TLSServerName,
 			},
 		)
 	case EKSClusterBased:
@@ -93,6 +99,7 @@ func tempConfigFromDirectAuthInfo(logger *logrus.Entry, tmpfile *os.File, server
 	if err != nil {
 		return err
 	}
+	config.Clusters["default"].TLSServerName = serverInfo.TLSServerName
 
 	logger.Infof("Adding auth info to config")
 	authInfo := api.NewAuthInfo()
@@ -141,27 +148,27 @@ func saveConfigToFile(config *api.Config, tmpfile *os.File) error {
 }
 
 func getKubeCredentialsFromEKSCluster(eksClusterArn string) (*serverInfo, error) {
-	cluster, err := eksawshelper.GetClusterByArn(eksClusterArn)
+	clusterInfo, err := eksawshelper.GetClusterInfo(eksClusterArn)
 	if err != nil {
 		return nil, err
 	}
 
-	server := aws.StringValue(cluster.Endpoint)
-	b64PEMCA := aws.StringValue(cluster.CertificateAuthority.Data)
-
-	clusterName, err := eksawshelper.GetClusterNameFromArn(eksClusterArn)
+	token, _, err := eksawshelper.GetKubernetesTokenForCluster(clusterInfo.Name)
 	if err != nil {
 		return nil, err
 	}
-	token, _, err := eksawshelper.GetKubernetesTokenForCluster(clusterName)
+
+	// The endpoint of the cluster may not be reachable directly, in which case it is overridden.
+	endpoint, tlsServerName, err := kubehelper.ResolveClusterEndpoint(clusterInfo.Endpoint)
 	if err != nil {
 		return nil, err
 	}
 
 	info := serverInfo{
-		Server:                        server,
-		Base64PEMCertificateAuthority: b64PEMCA,
+		Server:                        endpoint,
+		Base64PEMCertificateAuthority: clusterInfo.CertificateAuthorityData,
 		BearerToken:                   token.Token,
+		TLSServerName:                 tlsServerName,
 	}
 	return &info, nil
 }
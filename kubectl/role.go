@@ -6,6 +6,8 @@ import (
 	"github.com/gruntwork-io/go-commons/errors"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
 )
 
 // PrepareTillerRole will construct a new Role struct with the provided
@@ -27,14 +29,19 @@ func PrepareRole(
 	return &newRole
 }
 
-// CreateRole will create the provided role on the Kubernetes cluster.
+// CreateRole will create the provided role on the Kubernetes cluster, labeled as managed by kubergrunt.
 func CreateRole(options *KubectlOptions, newRole *rbacv1.Role) error {
 	client, err := GetKubernetesClientFromOptions(options)
 	if err != nil {
 		return err
 	}
 
-	_, err = client.RbacV1().Roles(newRole.Namespace).Create(context.Background(), newRole, metav1.CreateOptions{})
+	MarkAsCreatedByKubergrunt(&newRole.ObjectMeta)
+
+	err = kubehelper.RetryOnConflictOrServerError(func() error {
+		_, err := client.RbacV1().Roles(newRole.Namespace).Create(context.Background(), newRole, metav1.CreateOptions{})
+		return err
+	})
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
@@ -76,7 +83,9 @@ func DeleteRole(options *KubectlOptions, namespace string, name string) error {
 		return err
 	}
 
-	err = client.RbacV1().Roles(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+	err = kubehelper.RetryOnConflictOrServerError(func() error {
+		return client.RbacV1().Roles(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+	})
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
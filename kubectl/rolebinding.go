@@ -6,6 +6,8 @@ import (
 	"github.com/gruntwork-io/go-commons/errors"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
 )
 
 // PrepareTillerRoleBinding will construct a new RoleBinding struct with the provided metadata. The role can later
@@ -28,14 +30,19 @@ func PrepareRoleBinding(
 	return &newRoleBinding
 }
 
-// CreateRoleBinding will create the provided role binding on the Kubernetes cluster.
+// CreateRoleBinding will create the provided role binding on the Kubernetes cluster, labeled as managed by kubergrunt.
 func CreateRoleBinding(options *KubectlOptions, newRoleBinding *rbacv1.RoleBinding) error {
 	client, err := GetKubernetesClientFromOptions(options)
 	if err != nil {
 		return err
 	}
 
-	_, err = client.RbacV1().RoleBindings(newRoleBinding.Namespace).Create(context.Background(), newRoleBinding, metav1.CreateOptions{})
+	MarkAsCreatedByKubergrunt(&newRoleBinding.ObjectMeta)
+
+	err = kubehelper.RetryOnConflictOrServerError(func() error {
+		_, err := client.RbacV1().RoleBindings(newRoleBinding.Namespace).Create(context.Background(), newRoleBinding, metav1.CreateOptions{})
+		return err
+	})
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
@@ -77,7 +84,9 @@ func DeleteRoleBinding(options *KubectlOptions, namespace string, name string) e
 		return err
 	}
 
-	err = client.RbacV1().RoleBindings(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+	err = kubehelper.RetryOnConflictOrServerError(func() error {
+		return client.RbacV1().RoleBindings(namespace).Delete(context.Background(), name, metav1.DeleteOptions{})
+	})
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
@@ -7,6 +7,8 @@ import (
 	"github.com/gruntwork-io/go-commons/errors"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
 )
 
 // PrepareSecret will construct a new Secret struct with the provided metadata. This can then be used to append data to
@@ -41,14 +43,19 @@ func AddToSecretFromData(secret *corev1.Secret, key string, rawData []byte) {
 	secret.Data[key] = rawData
 }
 
-// CreateSecret will create the provided secret on the Kubernetes cluster.
+// CreateSecret will create the provided secret on the Kubernetes cluster, labeled as managed by kubergrunt.
 func CreateSecret(options *KubectlOptions, newSecret *corev1.Secret) error {
 	client, err := GetKubernetesClientFromOptions(options)
 	if err != nil {
 		return err
 	}
 
-	_, err = client.CoreV1().Secrets(newSecret.Namespace).Create(context.Background(), newSecret, metav1.CreateOptions{})
+	MarkAsCreatedByKubergrunt(&newSecret.ObjectMeta)
+
+	err = kubehelper.RetryOnConflictOrServerError(func() error {
+		_, err := client.CoreV1().Secrets(newSecret.Namespace).Create(context.Background(), newSecret, metav1.CreateOptions{})
+		return err
+	})
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
@@ -90,7 +97,9 @@ func DeleteSecret(options *KubectlOptions, namespace string, secretName string)
 		return err
 	}
 
-	err = client.CoreV1().Secrets(namespace).Delete(context.Background(), secretName, metav1.DeleteOptions{})
+	err = kubehelper.RetryOnConflictOrServerError(func() error {
+		return client.CoreV1().Secrets(namespace).Delete(context.Background(), secretName, metav1.DeleteOptions{})
+	})
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
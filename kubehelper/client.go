@@ -0,0 +1,110 @@
+package kubehelper
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/gruntwork-io/go-commons/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// NewKubernetesClient returns a Kubernetes API client for the EKS cluster with the given ARN, authenticated with an EKS
+// token. See NewRestConfig for how the client is configured.
+func NewKubernetesClient(clusterArn string) (*kubernetes.Clientset, error) {
+	config, err := NewRestConfig(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return clientset, nil
+}
+
+// NewRestConfig returns the client config to connect to the EKS cluster with the given ARN. The endpoint and CA of the
+// API server are looked up with the EKS DescribeCluster API, and the requests are authenticated with a bearer token
+// generated for the cluster, equivalent to `kubergrunt eks token`. Both use the AWS credentials of
+// eksawshelper.NewAuthenticatedSession, so that the profile and IAM role to assume configured in eksawshelper are
+// honored. The CA bundle configured with eksawshelper.SetCABundle is trusted in addition to the CA of the cluster, and
+// the timeouts configured with eksawshelper.SetHTTPConfig are applied (see ApplyHTTPConfig). The endpoint and TLS server
+// name configured with SetEndpointOverride are honored (see ResolveClusterEndpoint).
+//
+// Note that the token expires after 15 minutes, so get a new config for long running operations.
+func NewRestConfig(clusterArn string) (*rest.Config, error) {
+	logger := logging.GetProjectLogger()
+	logger.Infof("Setting up Kubernetes client for EKS cluster %s", clusterArn)
+
+	clusterInfo, err := eksawshelper.GetClusterInfo(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := eksawshelper.GetKubernetesTokenForClusterArn(clusterArn)
+	if err != nil {
+		return nil, err
+	}
+	caBundle, err := eksawshelper.LoadCABundle()
+	if err != nil {
+		return nil, err
+	}
+	return newRestConfig(clusterArn, clusterInfo.Cluster, tok.Token, caBundle)
+}
+
+// newRestConfig builds the client config to connect to the given EKS cluster with the bearer token, trusting the
+// additional PEM encoded CA bundle, if not nil.
+func newRestConfig(clusterArn string, cluster *eks.Cluster, bearerToken string, caBundle []byte) (*rest.Config, error) {
+	endpoint := aws.StringValue(cluster.Endpoint)
+	if endpoint == "" || cluster.CertificateAuthority == nil || aws.StringValue(cluster.CertificateAuthority.Data) == "" {
+		return nil, errors.WithStackTrace(ClusterEndpointNotAvailableError{ClusterArn: clusterArn})
+	}
+	endpoint, serverName, err := ResolveClusterEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	caData, err := base64.StdEncoding.DecodeString(aws.StringValue(cluster.CertificateAuthority.Data))
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	if caBundle != nil {
+		caData = append(append(caData, '\n'), caBundle...)
+	}
+
+	config := &rest.Config{
+		Host:        endpoint,
+		BearerToken: bearerToken,
+		TLSClientConfig: rest.TLSClientConfig{
+			CAData:     caData,
+			ServerName: serverName,
+		},
+	}
+	ApplyHTTPConfig(config)
+	return config, nil
+}
+
+// ApplyHTTPConfig applies the timeouts and connection reuse settings configured with eksawshelper.SetHTTPConfig to the
+// Kubernetes client config, so that the Kubernetes API calls behave the same as the AWS API calls. The timeout of the
+// config is only overridden when one is configured. Note that the timeout also closes watch requests, which must
+// then be started again.
+func ApplyHTTPConfig(config *rest.Config) {
+	httpConfig := eksawshelper.GetHTTPConfig()
+	if httpConfig.Timeout > 0 {
+		config.Timeout = httpConfig.Timeout
+	}
+	if config.Dial == nil {
+		config.Dial = eksawshelper.NewDialer().DialContext
+	}
+	wrapTransport := config.WrapTransport
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		rt = eksawshelper.WrapTransport(rt)
+		if wrapTransport != nil {
+			rt = wrapTransport(rt)
+		}
+		return rt
+	}
+}
@@ -0,0 +1,102 @@
+package kubehelper
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+
+	"github.com/gruntwork-io/kubergrunt/eksawshelper"
+)
+
+const testClusterArn = "arn:aws:eks:us-east-2:111111111111:cluster/prod"
+
+func TestNewRestConfig(t *testing.T) {
+	t.Parallel()
+
+	cluster := &eks.Cluster{
+		Endpoint:             aws.String("https://prod.eks.amazonaws.com"),
+		CertificateAuthority: &eks.Certificate{Data: aws.String(base64.StdEncoding.EncodeToString([]byte("cluster-ca")))},
+	}
+
+	config, err := newRestConfig(testClusterArn, cluster, "k8s-aws-v1.token", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://prod.eks.amazonaws.com", config.Host)
+	assert.Equal(t, "k8s-aws-v1.token", config.BearerToken)
+	assert.Equal(t, []byte("cluster-ca"), config.TLSClientConfig.CAData)
+	assert.False(t, config.TLSClientConfig.Insecure)
+
+	config, err = newRestConfig(testClusterArn, cluster, "k8s-aws-v1.token", []byte("proxy-ca"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("cluster-ca\nproxy-ca"), config.TLSClientConfig.CAData)
+}
+
+func TestNewRestConfigErrorsForClusterWithoutEndpoint(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		cluster *eks.Cluster
+	}{
+		{"NoEndpoint", &eks.Cluster{CertificateAuthority: &eks.Certificate{Data: aws.String("Y2E=")}}},
+		{"NoCertificateAuthority", &eks.Cluster{Endpoint: aws.String("https://prod.eks.amazonaws.com")}},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := newRestConfig(testClusterArn, testCase.cluster, "k8s-aws-v1.token", nil)
+			require.Error(t, err)
+			_, isNotAvailableErr := errors.Unwrap(err).(ClusterEndpointNotAvailableError)
+			assert.True(t, isNotAvailableErr)
+		})
+	}
+}
+
+// NOTE: This test is not run in parallel since the HTTP config is set globally.
+func TestApplyHTTPConfig(t *testing.T) {
+	eksawshelper.SetHTTPConfig(eksawshelper.HTTPConfig{
+		Timeout:         2 * time.Minute,
+		KeepAlive:       15 * time.Second,
+		IdleConnTimeout: 10 * time.Second,
+	})
+	defer eksawshelper.SetHTTPConfig(eksawshelper.HTTPConfig{
+		KeepAlive:       eksawshelper.DefaultHTTPKeepAlive,
+		IdleConnTimeout: eksawshelper.DefaultHTTPIdleConnTimeout,
+	})
+
+	wrapped := false
+	config := &rest.Config{
+		Host: "https://prod.eks.amazonaws.com",
+		WrapTransport: func(rt http.RoundTripper) http.RoundTripper {
+			wrapped = true
+			return rt
+		},
+	}
+	ApplyHTTPConfig(config)
+	assert.Equal(t, 2*time.Minute, config.Timeout)
+	assert.NotNil(t, config.Dial)
+
+	// The idle connection timeout is applied to the transport, and the original WrapTransport is still called.
+	base := &http.Transport{}
+	transport, isTransport := config.WrapTransport(base).(*http.Transport)
+	require.True(t, isTransport)
+	assert.Equal(t, 10*time.Second, transport.IdleConnTimeout)
+	assert.Equal(t, time.Duration(0), base.IdleConnTimeout)
+	assert.True(t, wrapped)
+
+	// The timeout of the config is kept when no timeout is configured.
+	eksawshelper.SetHTTPConfig(eksawshelper.HTTPConfig{})
+	config = &rest.Config{Timeout: time.Minute}
+	ApplyHTTPConfig(config)
+	assert.Equal(t, time.Minute, config.Timeout)
+}
@@ -0,0 +1,64 @@
+package kubehelper
+
+import (
+	"net/url"
+
+	"github.com/gruntwork-io/go-commons/errors"
+)
+
+// EndpointOverride configures how to reach the Kubernetes API server of the EKS clusters when the endpoint returned by
+// DescribeCluster is not directly reachable, e.g. for a private cluster that is only reachable through a VPN, a bastion
+// host or a custom DNS name.
+type EndpointOverride struct {
+	// Endpoint is the URL of the API server to connect to instead of the endpoint of the EKS cluster. The certificate of
+	// the API server is still verified against the CA of the cluster.
+	Endpoint string
+
+	// TLSServerName is the server name to verify the certificate of the API server against, which must be one of the
+	// SANs of the certificate. When Endpoint is set, this defaults to the host of the endpoint of the EKS cluster, as
+	// the certificate is issued for that host.
+	TLSServerName string
+}
+
+// endpointOverride is set globally from the CLI flags, similar to the HTTP settings of eksawshelper, so that it applies
+// to every Kubernetes client regardless of which command is run.
+var endpointOverride EndpointOverride
+
+// SetEndpointOverride sets the endpoint and the TLS server name to use for all the Kubernetes API calls to EKS
+// clusters. Pass in an empty EndpointOverride to use the endpoint returned by DescribeCluster. Returns an
+// InvalidEndpointOverrideError if the endpoint is not an https URL, as the API server is only served over TLS.
+func SetEndpointOverride(override EndpointOverride) error {
+	if override.Endpoint != "" {
+		parsed, err := url.Parse(override.Endpoint)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return errors.WithStackTrace(InvalidEndpointOverrideError{Endpoint: override.Endpoint})
+		}
+	}
+	endpointOverride = override
+	return nil
+}
+
+// GetEndpointOverride returns the EndpointOverride configured with SetEndpointOverride.
+func GetEndpointOverride() EndpointOverride {
+	return endpointOverride
+}
+
+// ResolveClusterEndpoint returns the endpoint to connect to the API server of an EKS cluster with, given the endpoint
+// returned by DescribeCluster, along with the server name to verify the certificate of the API server against. Both
+// honor the EndpointOverride configured with SetEndpointOverride. The server name is empty when the certificate should
+// be verified against the host of the returned endpoint, which is the default of the Kubernetes clients.
+func ResolveClusterEndpoint(clusterEndpoint string) (string, string, error) {
+	override := endpointOverride
+	if override.Endpoint == "" {
+		return clusterEndpoint, override.TLSServerName, nil
+	}
+	serverName := override.TLSServerName
+	if serverName == "" {
+		parsed, err := url.Parse(clusterEndpoint)
+		if err != nil {
+			return "", "", errors.WithStackTrace(err)
+		}
+		serverName = parsed.Hostname()
+	}
+	return override.Endpoint, serverName, nil
+}
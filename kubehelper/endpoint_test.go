@@ -0,0 +1,71 @@
+package kubehelper
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NOTE: This test is not run in parallel since the endpoint override is set globally.
+func TestResolveClusterEndpoint(t *testing.T) {
+	defer SetEndpointOverride(EndpointOverride{})
+
+	testCases := []struct {
+		name               string
+		override           EndpointOverride
+		expectedEndpoint   string
+		expectedServerName string
+	}{
+		{"NoOverride", EndpointOverride{}, "https://prod.eks.amazonaws.com", ""},
+		{"EndpointOnly", EndpointOverride{Endpoint: "https://localhost:8443"}, "https://localhost:8443", "prod.eks.amazonaws.com"},
+		{
+			"EndpointAndServerName",
+			EndpointOverride{Endpoint: "https://10.0.0.10", TLSServerName: "kubernetes.default"},
+			"https://10.0.0.10",
+			"kubernetes.default",
+		},
+		{"ServerNameOnly", EndpointOverride{TLSServerName: "kubernetes"}, "https://prod.eks.amazonaws.com", "kubernetes"},
+	}
+
+	for _, testCase := range testCases {
+		require.NoError(t, SetEndpointOverride(testCase.override))
+		endpoint, serverName, err := ResolveClusterEndpoint("https://prod.eks.amazonaws.com")
+		require.NoError(t, err, testCase.name)
+		assert.Equal(t, testCase.expectedEndpoint, endpoint, testCase.name)
+		assert.Equal(t, testCase.expectedServerName, serverName, testCase.name)
+	}
+}
+
+// NOTE: This test is not run in parallel since the endpoint override is set globally.
+func TestNewRestConfigHonorsEndpointOverride(t *testing.T) {
+	require.NoError(t, SetEndpointOverride(EndpointOverride{Endpoint: "https://localhost:8443"}))
+	defer SetEndpointOverride(EndpointOverride{})
+
+	cluster := &eks.Cluster{
+		Endpoint:             aws.String("https://prod.eks.amazonaws.com"),
+		CertificateAuthority: &eks.Certificate{Data: aws.String(base64.StdEncoding.EncodeToString([]byte("cluster-ca")))},
+	}
+	config, err := newRestConfig(testClusterArn, cluster, "k8s-aws-v1.token", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://localhost:8443", config.Host)
+	assert.Equal(t, "prod.eks.amazonaws.com", config.TLSClientConfig.ServerName)
+	assert.Equal(t, []byte("cluster-ca"), config.TLSClientConfig.CAData)
+}
+
+// NOTE: This test is not run in parallel since the endpoint override is set globally.
+func TestSetEndpointOverrideRejectsInvalidEndpoints(t *testing.T) {
+	defer SetEndpointOverride(EndpointOverride{})
+
+	for _, endpoint := range []string{"localhost:8443", "http://localhost:8443", "https://", "https://%zz"} {
+		err := SetEndpointOverride(EndpointOverride{Endpoint: endpoint})
+		require.Error(t, err, endpoint)
+		_, isInvalidErr := errors.Unwrap(err).(InvalidEndpointOverrideError)
+		assert.True(t, isInvalidErr, endpoint)
+	}
+	assert.Equal(t, EndpointOverride{}, GetEndpointOverride())
+}
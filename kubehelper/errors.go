@@ -0,0 +1,22 @@
+package kubehelper
+
+import "fmt"
+
+// ClusterEndpointNotAvailableError is returned when the EKS cluster does not report an API server endpoint or a
+// certificate authority yet, e.g., because it is still being created.
+type ClusterEndpointNotAvailableError struct {
+	ClusterArn string
+}
+
+func (err ClusterEndpointNotAvailableError) Error() string {
+	return fmt.Sprintf("The Kubernetes API server endpoint of EKS cluster %s is not available yet. Wait for the cluster to be active and try again.", err.ClusterArn)
+}
+
+// InvalidEndpointOverrideError is returned when the endpoint override of the Kubernetes API server is not an https URL.
+type InvalidEndpointOverrideError struct {
+	Endpoint string
+}
+
+func (err InvalidEndpointOverrideError) Error() string {
+	return fmt.Sprintf("The endpoint override %s is not valid: it must be an https URL, such as https://localhost:8443.", err.Endpoint)
+}
@@ -0,0 +1,4 @@
+// kubehelper contains helper functions for building authenticated Kubernetes API clients for EKS clusters, shared by
+// all the commands that operate within the cluster. This package only depends on eksawshelper, so that both eks and
+// kubectl can use it.
+package kubehelper
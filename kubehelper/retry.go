@@ -0,0 +1,76 @@
+package kubehelper
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// conflictBackoff is the backoff between the attempts of RetryOnConflict. Conflicts are resolved by reading the latest
+// version of the resource, so there is no need to wait long between attempts.
+var conflictBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 10 * time.Millisecond,
+	Factor:   1.0,
+	Jitter:   0.1,
+}
+
+// serverErrorBackoff is the backoff between the attempts of RetryOnConflictOrServerError. This waits for up to 15
+// seconds in total, to give an overloaded or restarting API server the time to recover.
+var serverErrorBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 1 * time.Second,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+// RetryOnConflict calls fn until it does not return a Conflict error, which the API server returns when a resource was
+// modified since it was read, up to 5 attempts. The last error is returned if all the attempts fail, and any other error
+// is returned immediately. fn must read the latest version of the resource before modifying it on every attempt, or the
+// retries will keep conflicting. Errors wrapped with errors.WithStackTrace are supported.
+func RetryOnConflict(fn func() error) error {
+	return retryOnError(conflictBackoff, isConflictError, fn)
+}
+
+// RetryOnConflictOrServerError is like RetryOnConflict, but also retries with exponential backoff when the API server
+// fails to handle the request with a 5xx status code (e.g., a ServerTimeout or an InternalError), or asks the client to
+// back off with a 429 status code. Use this for requests that are safe to repeat.
+func RetryOnConflictOrServerError(fn func() error) error {
+	return retryOnError(serverErrorBackoff, isConflictOrServerError, fn)
+}
+
+func retryOnError(backoff wait.Backoff, retriable func(error) bool, fn func() error) error {
+	logger := logging.GetProjectLogger()
+	attempt := 0
+	err := retry.OnError(backoff, retriable, func() error {
+		attempt++
+		err := fn()
+		if err != nil && retriable(err) && attempt < backoff.Steps {
+			logger.Warnf("Kubernetes API request failed with a retriable error (attempt %d of %d): %s", attempt, backoff.Steps, err)
+		}
+		return err
+	})
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	return nil
+}
+
+func isConflictError(err error) bool {
+	return apierrors.IsConflict(errors.Unwrap(err))
+}
+
+func isConflictOrServerError(err error) bool {
+	err = errors.Unwrap(err)
+	if apierrors.IsConflict(err) || apierrors.IsTooManyRequests(err) {
+		return true
+	}
+	status, isStatus := err.(apierrors.APIStatus)
+	return isStatus && status.Status().Code >= http.StatusInternalServerError
+}
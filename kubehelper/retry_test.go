@@ -0,0 +1,125 @@
+package kubehelper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+var testConfigMapsResource = schema.GroupResource{Resource: "configmaps"}
+
+func TestRetryOnConflictRetriesUntilSuccess(t *testing.T) {
+	t.Parallel()
+
+	attempts := 0
+	err := RetryOnConflict(func() error {
+		attempts++
+		if attempts < 3 {
+			// Conflicts wrapped with a stack trace are retried as well.
+			return errors.WithStackTrace(newTestConflictError())
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestRetryOnConflictGivesUpAfterMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	attempts := 0
+	err := RetryOnConflict(func() error {
+		attempts++
+		return newTestConflictError()
+	})
+	require.Error(t, err)
+	assert.True(t, apierrors.IsConflict(errors.Unwrap(err)))
+	assert.Equal(t, conflictBackoff.Steps, attempts)
+}
+
+func TestRetryOnConflictDoesNotRetryOtherErrors(t *testing.T) {
+	t.Parallel()
+
+	attempts := 0
+	err := RetryOnConflict(func() error {
+		attempts++
+		return apierrors.NewServerTimeout(testConfigMapsResource, "update", 1)
+	})
+	require.Error(t, err)
+	assert.True(t, apierrors.IsServerTimeout(errors.Unwrap(err)))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryOnConflictOrServerErrorRetriesServerErrors(t *testing.T) {
+	t.Parallel()
+
+	backoff := wait.Backoff{Steps: 5, Duration: time.Millisecond, Factor: 2.0}
+	retriableErrs := []error{
+		newTestConflictError(),
+		apierrors.NewServerTimeout(testConfigMapsResource, "update", 1),
+		apierrors.NewInternalError(errors.WithStackTrace(assert.AnError)),
+		apierrors.NewServiceUnavailable("etcd is restarting"),
+		apierrors.NewTooManyRequests("slow down", 1),
+	}
+	attempts := 0
+	err := retryOnError(backoff, isConflictOrServerError, func() error {
+		attempts++
+		if attempts <= len(retriableErrs) {
+			return retriableErrs[attempts-1]
+		}
+		return nil
+	})
+	// The backoff allows 5 attempts in total, so the last retriable error is returned.
+	require.Error(t, err)
+	assert.True(t, apierrors.IsTooManyRequests(errors.Unwrap(err)))
+	assert.Equal(t, 5, attempts)
+
+	attempts = 0
+	err = retryOnError(backoff, isConflictOrServerError, func() error {
+		attempts++
+		if attempts == 1 {
+			return apierrors.NewServerTimeout(testConfigMapsResource, "update", 1)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestIsConflictOrServerError(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name      string
+		err       error
+		retriable bool
+	}{
+		{"Conflict", newTestConflictError(), true},
+		{"WrappedConflict", errors.WithStackTrace(newTestConflictError()), true},
+		{"ServerTimeout", apierrors.NewServerTimeout(testConfigMapsResource, "update", 1), true},
+		{"GatewayTimeout", apierrors.NewTimeoutError("timed out", 1), true},
+		{"TooManyRequests", apierrors.NewTooManyRequests("slow down", 1), true},
+		{"NotFound", apierrors.NewNotFound(testConfigMapsResource, "aws-auth"), false},
+		{"Forbidden", apierrors.NewForbidden(testConfigMapsResource, "aws-auth", assert.AnError), false},
+		{"NotAnAPIError", assert.AnError, false},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, testCase.retriable, isConflictOrServerError(testCase.err))
+		})
+	}
+}
+
+func newTestConflictError() error {
+	return apierrors.NewConflict(testConfigMapsResource, "aws-auth", assert.AnError)
+}
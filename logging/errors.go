@@ -0,0 +1,21 @@
+package logging
+
+import "fmt"
+
+// UnknownLogFormatError is returned when the requested log format is not supported.
+type UnknownLogFormatError struct {
+	Format string
+}
+
+func (err UnknownLogFormatError) Error() string {
+	return fmt.Sprintf("Unknown log format %s. Must be one of text or json.", err.Format)
+}
+
+// UnknownLogLevelError is returned when the requested log level is not supported.
+type UnknownLogLevelError struct {
+	Level string
+}
+
+func (err UnknownLogLevelError) Error() string {
+	return fmt.Sprintf("Unknown log level %s. Must be one of debug, info, warn, or error.", err.Level)
+}
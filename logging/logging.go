@@ -2,11 +2,111 @@
 package logging
 
 import (
+	"os"
+	"sync"
+
+	"github.com/gruntwork-io/go-commons/collections"
 	"github.com/gruntwork-io/go-commons/logging"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// TextLogFormat formats the log messages as human readable text. This is the default.
+	TextLogFormat = "text"
+
+	// JSONLogFormat formats each log message as a JSON object, with the level, message, time and structured fields as
+	// discrete keys.
+	JSONLogFormat = "json"
+
+	// LogLevelEnvVar is the environment variable that sets the log level, if the --log-level option is not passed in.
+	LogLevelEnvVar = "KUBERGRUNT_LOG_LEVEL"
+)
+
+// LogFormats lists the supported log formats.
+var LogFormats = []string{TextLogFormat, JSONLogFormat}
+
+// LogLevels lists the advertised log levels, from the most to the least verbose. The progress of the wait loops is only
+// logged at the debug level. For compatibility with the --loglevel option, SetGlobalLogLevel also accepts the other
+// levels of logrus (trace, warning, fatal and panic).
+var LogLevels = []string{
+	logrus.DebugLevel.String(),
+	logrus.InfoLevel.String(),
+	"warn",
+	logrus.ErrorLevel.String(),
+}
+
+// Apply the log level from the environment when the package is loaded, so that it takes effect before any package logs
+// its first message. An invalid level is ignored here, as the CLI reports it when parsing the --log-level option.
+func init() {
+	if level := os.Getenv(LogLevelEnvVar); level != "" {
+		SetGlobalLogLevel(level)
+	}
+}
+
+var (
+	globalLogFormat      = TextLogFormat
+	globalLogFormatMutex sync.RWMutex
+
+	globalQuiet      bool
+	globalQuietMutex sync.RWMutex
+)
+
+// SetGlobalLogLevel sets the minimum level of the messages logged by all the loggers returned by GetProjectLogger.
+// Returns an UnknownLogLevelError if the level is not recognized by logrus.
+func SetGlobalLogLevel(level string) error {
+	parsedLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		return UnknownLogLevelError{level}
+	}
+	logging.SetGlobalLogLevel(parsedLevel)
+	return nil
+}
+
+// SetGlobalLogFormat sets the format of the messages logged by all the loggers returned by GetProjectLogger. Returns an
+// UnknownLogFormatError if the format is not one of LogFormats.
+func SetGlobalLogFormat(format string) error {
+	if !collections.ListContainsElement(LogFormats, format) {
+		return UnknownLogFormatError{format}
+	}
+	globalLogFormatMutex.Lock()
+	defer globalLogFormatMutex.Unlock()
+	globalLogFormat = format
+	return nil
+}
+
+// SetGlobalQuiet turns the quiet mode on or off. In quiet mode, the loggers returned by ProgressLogger only log errors,
+// so that long running operations only log the completion of each phase and the errors.
+func SetGlobalQuiet(quiet bool) {
+	globalQuietMutex.Lock()
+	defer globalQuietMutex.Unlock()
+	globalQuiet = quiet
+}
+
+// ProgressLogger returns the logger to use for the progress messages of the individual resources and retries of a long
+// running operation (e.g., each network interface that is being waited on). This is the given logger, unless the quiet
+// mode is on, in which case it is a copy of the given logger, with the same fields and output, that only logs errors.
+func ProgressLogger(logger *logrus.Entry) *logrus.Entry {
+	globalQuietMutex.RLock()
+	defer globalQuietMutex.RUnlock()
+	if !globalQuiet {
+		return logger
+	}
+
+	quietLogger := logrus.New()
+	quietLogger.Out = logger.Logger.Out
+	quietLogger.Formatter = logger.Logger.Formatter
+	quietLogger.Hooks = logger.Logger.Hooks
+	quietLogger.Level = logrus.ErrorLevel
+	return quietLogger.WithFields(logger.Data)
+}
+
 func GetProjectLogger() *logrus.Entry {
 	logger := logging.GetLogger("")
+
+	globalLogFormatMutex.RLock()
+	defer globalLogFormatMutex.RUnlock()
+	if globalLogFormat == JSONLogFormat {
+		logger.Formatter = &logrus.JSONFormatter{}
+	}
 	return logger.WithField("name", "kubergrunt")
 }
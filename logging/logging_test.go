@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProjectLoggerWithJSONLogFormat(t *testing.T) {
+	require.NoError(t, SetGlobalLogFormat(JSONLogFormat))
+	defer SetGlobalLogFormat(TextLogFormat)
+
+	var out bytes.Buffer
+	logger := GetProjectLogger()
+	logger.Logger.Out = &out
+	logger.WithField("securityGroupID", "sg-123").Info("Deleting security group")
+
+	var message map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &message))
+	assert.Equal(t, "info", message["level"])
+	assert.Equal(t, "Deleting security group", message["msg"])
+	assert.Equal(t, "kubergrunt", message["name"])
+	assert.Equal(t, "sg-123", message["securityGroupID"])
+	assert.Contains(t, message, "time")
+}
+
+func TestSetGlobalLogFormatRejectsUnknownFormats(t *testing.T) {
+	err := SetGlobalLogFormat("yaml")
+	assert.Equal(t, UnknownLogFormatError{"yaml"}, err)
+}
+
+func TestSetGlobalLogLevelRejectsUnknownLevels(t *testing.T) {
+	err := SetGlobalLogLevel("verbose")
+	assert.Equal(t, UnknownLogLevelError{"verbose"}, err)
+}
+
+func TestSetGlobalLogLevelAcceptsLogrusLevels(t *testing.T) {
+	defer SetGlobalLogLevel(logrus.InfoLevel.String())
+
+	// The levels that are not advertised are still accepted, as the --loglevel option always accepted them.
+	testCases := []struct {
+		level    string
+		expected logrus.Level
+	}{
+		{"debug", logrus.DebugLevel},
+		{"warn", logrus.WarnLevel},
+		{"trace", logrus.TraceLevel},
+		{"warning", logrus.WarnLevel},
+		{"fatal", logrus.FatalLevel},
+		{"panic", logrus.PanicLevel},
+	}
+	for _, testCase := range testCases {
+		require.NoError(t, SetGlobalLogLevel(testCase.level), testCase.level)
+		assert.Equal(t, testCase.expected, GetProjectLogger().Logger.Level, testCase.level)
+	}
+}
+
+func TestProgressLoggerInQuietMode(t *testing.T) {
+	SetGlobalQuiet(true)
+	defer SetGlobalQuiet(false)
+
+	var out bytes.Buffer
+	logger := GetProjectLogger()
+	logger.Logger.Out = &out
+	progressLogger := ProgressLogger(logger.WithField("securityGroupID", "sg-123"))
+
+	progressLogger.Info("Waiting for network interface to reach detached state.")
+	progressLogger.Warn("Network interface is still attached.")
+	assert.Empty(t, out.String())
+
+	progressLogger.Error("Error polling attachment for network interface")
+	assert.Contains(t, out.String(), "Error polling attachment for network interface")
+	assert.Contains(t, out.String(), "sg-123")
+
+	// The logger itself is not silenced, so that the completion of each phase is still logged.
+	logger.Info("Cleanup completed")
+	assert.Contains(t, out.String(), "Cleanup completed")
+}
+
+func TestProgressLoggerReturnsLoggerOutsideQuietMode(t *testing.T) {
+	logger := GetProjectLogger()
+	assert.Same(t, logger, ProgressLogger(logger))
+}
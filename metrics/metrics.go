@@ -0,0 +1,120 @@
+// Metrics package includes the Prometheus metrics that kubergrunt exposes on the progress of the long running
+// operations, such as the cleanup of the network interfaces and the roll out of the worker nodes.
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+const (
+	// MetricsPath is the path of the HTTP server started by Serve that exposes the metrics.
+	MetricsPath = "/metrics"
+
+	namespace = "kubergrunt"
+)
+
+// The metrics are registered in a dedicated registry rather than the default registry of the Prometheus client, so
+// that they don't collide with the metrics of a program that embeds kubergrunt.
+var (
+	registry = prometheus.NewRegistry()
+
+	networkInterfacesDetached = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "network_interfaces_detached_total",
+		Help:      "The number of network interfaces that were requested to be detached.",
+	})
+	networkInterfacesDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "network_interfaces_deleted_total",
+		Help:      "The number of network interfaces that were deleted.",
+	})
+	nodesDrained = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "nodes_drained",
+		Help:      "The number of nodes that were drained.",
+	})
+	phaseDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "phase_duration_seconds",
+			Help:      "The time spent in each phase of the long running operations, by operation and phase.",
+			// The phases range from a few seconds for the API calls to tens of minutes for the waits.
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+		},
+		[]string{"operation", "phase"},
+	)
+)
+
+func init() {
+	registry.MustRegister(networkInterfacesDetached, networkInterfacesDeleted, nodesDrained, phaseDuration)
+}
+
+// Registry returns the dedicated registry of the kubergrunt metrics, e.g., to expose them with the HTTP server of a
+// program that embeds kubergrunt.
+func Registry() *prometheus.Registry {
+	return registry
+}
+
+// Serve starts an HTTP server that exposes the metrics on MetricsPath of the given address (e.g., :9090) in the
+// background. The address is bound before returning, so that an address that is in use is reported right away. The
+// server runs until the process exits.
+func Serve(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	server := &http.Server{Handler: newHandler()}
+
+	logger := logging.GetProjectLogger()
+	logger.Infof("Serving metrics on http://%s%s", listener.Addr(), MetricsPath)
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Errorf("Error serving metrics: %s", err)
+		}
+	}()
+	return nil
+}
+
+// newHandler returns the handler of the HTTP server started by Serve.
+func newHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// AddNetworkInterfacesDetached counts the given number of network interfaces as requested to be detached.
+func AddNetworkInterfacesDetached(count int) {
+	networkInterfacesDetached.Add(float64(count))
+}
+
+// AddNetworkInterfacesDeleted counts the given number of network interfaces as deleted.
+func AddNetworkInterfacesDeleted(count int) {
+	networkInterfacesDeleted.Add(float64(count))
+}
+
+// AddNodesDrained counts the given number of nodes as drained.
+func AddNodesDrained(count int) {
+	nodesDrained.Add(float64(count))
+}
+
+// ObservePhaseDuration records the time spent in a phase of the given operation (e.g., the detach phase of the cleanup).
+func ObservePhaseDuration(operation string, phase string, duration time.Duration) {
+	phaseDuration.WithLabelValues(operation, phase).Observe(duration.Seconds())
+}
+
+// TimePhase runs the given phase of the operation and records the time it took, whether it succeeded or not.
+func TimePhase(operation string, phase string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	ObservePhaseDuration(operation, phase, time.Since(start))
+	return err
+}
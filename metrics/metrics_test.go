@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NOTE: This test is not run in parallel since the metrics are recorded globally.
+func TestMetricsAreExposedOnTheDedicatedRegistry(t *testing.T) {
+	detachedBefore := testutil.ToFloat64(networkInterfacesDetached)
+	deletedBefore := testutil.ToFloat64(networkInterfacesDeleted)
+	drainedBefore := testutil.ToFloat64(nodesDrained)
+
+	AddNetworkInterfacesDetached(3)
+	AddNetworkInterfacesDeleted(2)
+	AddNodesDrained(1)
+	ObservePhaseDuration("cleanup", "detach", 1500*time.Millisecond)
+
+	assert.Equal(t, detachedBefore+3, testutil.ToFloat64(networkInterfacesDetached))
+	assert.Equal(t, deletedBefore+2, testutil.ToFloat64(networkInterfacesDeleted))
+	assert.Equal(t, drainedBefore+1, testutil.ToFloat64(nodesDrained))
+
+	server := httptest.NewServer(newHandler())
+	defer server.Close()
+	resp, err := http.Get(server.URL + MetricsPath)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(body), "kubergrunt_network_interfaces_detached_total")
+	assert.Contains(t, string(body), "kubergrunt_network_interfaces_deleted_total")
+	assert.Contains(t, string(body), "kubergrunt_nodes_drained")
+	assert.Contains(t, string(body), `kubergrunt_phase_duration_seconds_count{operation="cleanup",phase="detach"}`)
+	// The default collectors of the Prometheus client are not registered in the dedicated registry.
+	assert.NotContains(t, string(body), "go_goroutines")
+}
+
+// NOTE: This test is not run in parallel since the metrics are recorded globally.
+func TestTimePhaseRecordsFailedPhases(t *testing.T) {
+	countBefore := testutil.CollectAndCount(phaseDuration)
+
+	err := TimePhase("deploy", "test_failed_phase", func() error { return assert.AnError })
+	assert.Equal(t, assert.AnError, err)
+	assert.Equal(t, countBefore+1, testutil.CollectAndCount(phaseDuration))
+}
+
+func TestServeReportsAddressInUse(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	assert.Error(t, Serve(server.Listener.Addr().String()))
+}
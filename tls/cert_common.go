@@ -2,6 +2,7 @@ package tls
 
 import (
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
@@ -31,7 +32,9 @@ func StoreCertificate(certificate *x509.Certificate, path string) error {
 
 // CreateCertificateFromKeys will take the provided key pair and generate the associated TLS certificate. You can
 // customize the distinguished name on the certificate, the validity time span, whether or not it is a CA certificate,
-// and sign the certificate with a given CA using the available parameters.
+// and sign the certificate with a given CA using the available parameters. The DNS names and IP addresses are added as
+// Subject Alternative Names. The certificate is restricted to the given extended key usages, which default to
+// DefaultExtKeyUsages when empty.
 // Note: The passed in private key should be the private key of the SIGNER (certificate signing), while the public key
 // should be the public key of the SIGNEE (certificate being signed).
 // Code based on generate_cert command in crypto/tls: https://golang.org/src/crypto/tls/generate_cert.go
@@ -41,6 +44,8 @@ func CreateCertificateFromKeys(
 	signedBy *x509.Certificate,
 	isCA bool,
 	dnsNames []string,
+	ipAddresses []net.IP,
+	extKeyUsages []x509.ExtKeyUsage,
 	pubKey interface{}, // This has to be able to accept the key in any format, like the underlying go func
 	privKey interface{}, // This has to be able to accept the key in any format, like the underlying go func
 ) ([]byte, error) {
@@ -49,7 +54,14 @@ func CreateCertificateFromKeys(
 		return nil, errors.WithStackTrace(err)
 	}
 
-	template := createCertificateTemplate(serialNumber, distinguishedName, validityTimeSpan, isCA, dnsNames)
+	template := createCertificateTemplate(serialNumber, distinguishedName, validityTimeSpan, isCA, dnsNames, ipAddresses, extKeyUsages)
+	// Key encipherment is only valid for RSA keys. Other keys, like ECDSA keys, can only be used for signatures, and
+	// some TLS stacks reject certificates that claim otherwise.
+	if _, isRSA := pubKey.(*rsa.PublicKey); !isRSA {
+		template.KeyUsage &^= x509.KeyUsageKeyEncipherment
+	}
+	// Note that we leave the SignatureAlgorithm of the template unset, so that x509.CreateCertificate picks one that is
+	// compatible with the signing key (e.g., ECDSAWithSHA256 for P256 keys, and SHA256WithRSA for RSA keys).
 	// If signedBy is nil, we will set it to the template so that the generated certificate is self signed
 	if signedBy == nil {
 		signedBy = &template
@@ -75,7 +87,12 @@ func createCertificateTemplate(
 	validityTimeSpan time.Duration,
 	isCA bool,
 	dnsNames []string,
+	ipAddresses []net.IP,
+	extKeyUsages []x509.ExtKeyUsage,
 ) x509.Certificate {
+	if len(extKeyUsages) == 0 {
+		extKeyUsages = DefaultExtKeyUsages
+	}
 	validFrom := time.Now()
 	template := x509.Certificate{
 		SerialNumber: serialNumber,
@@ -85,21 +102,17 @@ func createCertificateTemplate(
 		NotAfter:  validFrom.Add(validityTimeSpan),
 
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		ExtKeyUsage:           extKeyUsages,
 		BasicConstraintsValid: true,
 
-		DNSNames: dnsNames,
+		DNSNames:    dnsNames,
+		IPAddresses: ipAddresses,
 	}
 	if isCA {
 		template.IsCA = true
 		template.KeyUsage |= x509.KeyUsageCertSign
 	}
 
-	// TODO: make generic so can be used for generating other kinds of certs
-	// Add localhost, because the helm client will open a port forwarder via the Kubernetes API to access Tiller.
-	// Because of that, helm requires a certificate that allows localhost.
-	template.IPAddresses = append(template.IPAddresses, net.ParseIP("127.0.0.1"))
-
 	return template
 }
 
@@ -118,7 +131,7 @@ func LoadCertificate(path string) (*x509.Certificate, error) {
 }
 
 // StoreCertificateKeyPairAsKubernetesSecret will store the provided certificate key pair (which is available in the
-// local file system) in the Kubernetes cluster as a secret.
+// local file system) in the Kubernetes cluster as a secret, labeled as managed by kubergrunt.
 func StoreCertificateKeyPairAsKubernetesSecret(
 	kubectlOptions *kubectl.KubectlOptions,
 	secretName string,
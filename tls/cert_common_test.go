@@ -226,7 +226,7 @@ func TestCreateCertificateCreatesWithConfiguredDNSName(t *testing.T) {
 
 	// openssl text output will encode the distinguished name in the following format
 	dnsNameString := fmt.Sprintf(
-		"DNS:%s,",
+		"DNS:%s",
 		dnsName,
 	)
 	assert.True(t, strings.Contains(out, dnsNameString))
@@ -289,7 +289,7 @@ func CreateSampleCertKeyPair(t *testing.T, signedBy *x509.Certificate, signedByK
 	if signedBy != nil {
 		signingKey = signedByKey
 	}
-	certificateBytes, err := CreateCertificateFromKeys(1*time.Hour, distinguishedName, signedBy, isCA, dnsNames, pubKey, signingKey)
+	certificateBytes, err := CreateCertificateFromKeys(1*time.Hour, distinguishedName, signedBy, isCA, dnsNames, nil, nil, pubKey, signingKey)
 	require.NoError(t, err)
 
 	certificate, err := x509.ParseCertificate(certificateBytes)
@@ -0,0 +1,170 @@
+package tls
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+// TLSSecretDeletion reports what DeleteTLSSecret deleted.
+type TLSSecretDeletion struct {
+	// Ref is the namespace/name reference to the Secret.
+	Ref string
+
+	// Deleted is false when the Secret did not exist.
+	Deleted bool
+
+	// UntrackedFromCA is the namespace/name reference to the CA Secret that the Secret was removed from the issued
+	// certificates of, or empty if it was not tracked by a CA.
+	UntrackedFromCA string
+}
+
+// DeleteTLSSecret will delete the Secret with the given name and namespace in the EKS cluster, which must have been
+// created by one of the tls commands (`tls gen`, `tls gen-mtls` or `tls gen-tls-secret`). A Secret that does not exist
+// is not an error: the returned TLSSecretDeletion reports whether anything was deleted.
+func DeleteTLSSecret(clusterArn string, namespace string, secretName string) (TLSSecretDeletion, error) {
+	return DeleteTLSSecretWithOptions(&kubectl.KubectlOptions{EKSClusterArn: clusterArn}, namespace, secretName, false, false)
+}
+
+// DeleteTLSSecretWithOptions is like DeleteTLSSecret, but uses the given kubectl options to authenticate to the cluster.
+// Secrets that are not labeled as managed by kubergrunt are refused with a SecretNotManagedError, unless force is true.
+// Note that the Secrets created before kubergrunt labeled them can only be deleted with force.
+//
+// When untrackFromCA is true and the Secret holds a certificate issued by a CA created with `tls gen --ca`, the Secret
+// is also removed from the issued certificates recorded on the CA Secret, so that RotateCA no longer tries to reissue
+// it.
+func DeleteTLSSecretWithOptions(
+	kubectlOptions *kubectl.KubectlOptions,
+	namespace string,
+	secretName string,
+	force bool,
+	untrackFromCA bool,
+) (TLSSecretDeletion, error) {
+	client, err := kubectl.GetKubernetesClientFromOptions(kubectlOptions)
+	if err != nil {
+		return TLSSecretDeletion{Ref: fmt.Sprintf("%s/%s", namespace, secretName)}, err
+	}
+	return deleteTLSSecret(client, namespace, secretName, force, untrackFromCA)
+}
+
+func deleteTLSSecret(
+	client kubernetes.Interface,
+	namespace string,
+	secretName string,
+	force bool,
+	untrackFromCA bool,
+) (TLSSecretDeletion, error) {
+	logger := logging.GetProjectLogger()
+	deletion := TLSSecretDeletion{Ref: fmt.Sprintf("%s/%s", namespace, secretName)}
+
+	secrets := client.CoreV1().Secrets(namespace)
+	secret, err := secrets.Get(context.Background(), secretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		logger.Infof("Secret %s does not exist. Nothing to delete.", deletion.Ref)
+		return deletion, nil
+	} else if err != nil {
+		return deletion, errors.WithStackTrace(err)
+	}
+
+	if !kubectl.IsManagedByKubergrunt(secret.ObjectMeta) {
+		if !force {
+			return deletion, errors.WithStackTrace(SecretNotManagedError{Namespace: namespace, Name: secretName})
+		}
+		logger.Warnf("Secret %s is not labeled as managed by kubergrunt. Deleting it anyway.", deletion.Ref)
+	}
+	if refs := issuedCertificateRefs(secret); len(refs) > 0 {
+		logger.Warnf("Secret %s holds the CA that issued the certificates in %s. These will no longer be rotated.", deletion.Ref, strings.Join(refs, ", "))
+	}
+
+	err = secrets.Delete(context.Background(), secretName, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		logger.Infof("Secret %s was already deleted.", deletion.Ref)
+		return deletion, nil
+	} else if err != nil {
+		return deletion, errors.WithStackTrace(err)
+	}
+	deletion.Deleted = true
+	logger.Infof("Successfully deleted Secret %s", deletion.Ref)
+
+	if untrackFromCA {
+		caRef, err := untrackIssuedCertificate(client, secret)
+		if err != nil {
+			return deletion, err
+		}
+		deletion.UntrackedFromCA = caRef
+	}
+	return deletion, nil
+}
+
+// untrackIssuedCertificate removes the given Secret from the issued certificates recorded on the CA Secret that signed
+// it, as found by the signed-by annotation. Returns the namespace/name reference to the CA Secret, or empty if the Secret
+// was not tracked by a CA that still exists.
+func untrackIssuedCertificate(client kubernetes.Interface, secret *corev1.Secret) (string, error) {
+	logger := logging.GetProjectLogger()
+
+	signedBy := secret.Annotations[kubernetesSecretSignedByAnnotationKey]
+	if signedBy == "" {
+		return "", nil
+	}
+	caRef, err := parseSignedByAnnotation(signedBy)
+	if err != nil {
+		return "", err
+	}
+	caSecret, err := getSecretByRef(client, caRef)
+	if apierrors.IsNotFound(err) {
+		logger.Infof("CA Secret %s that signed Secret %s/%s no longer exists.", caRef, secret.Namespace, secret.Name)
+		return "", nil
+	} else if err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+
+	ref := fmt.Sprintf("%s/%s", secret.Namespace, secret.Name)
+	refs := []string{}
+	isTracked := false
+	for _, issuedRef := range issuedCertificateRefs(caSecret) {
+		if issuedRef == ref {
+			isTracked = true
+			continue
+		}
+		refs = append(refs, issuedRef)
+	}
+	if !isTracked {
+		return "", nil
+	}
+
+	caSecret.Annotations[kubernetesSecretIssuedCertificatesAnnotationKey] = strings.Join(refs, ",")
+	kubectl.MarkAsModifiedByKubergrunt(&caSecret.ObjectMeta)
+	if _, err := client.CoreV1().Secrets(caSecret.Namespace).Update(context.Background(), caSecret, metav1.UpdateOptions{}); err != nil {
+		return "", errors.WithStackTrace(err)
+	}
+	logger.Infof("Removed Secret %s from the issued certificates of CA %s", ref, caRef)
+	return caRef, nil
+}
+
+// parseSignedByAnnotation converts the signed-by annotation set by `tls gen`, in the format namespace=NS,name=NAME, to a
+// namespace/name reference to the CA Secret.
+func parseSignedByAnnotation(signedBy string) (string, error) {
+	var namespace, name string
+	for _, field := range strings.Split(signedBy, ",") {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "namespace":
+			namespace = value
+		case "name":
+			name = value
+		}
+	}
+	if namespace == "" || name == "" {
+		return "", errors.WithStackTrace(InvalidSecretRefError{signedBy})
+	}
+	return fmt.Sprintf("%s/%s", namespace, name), nil
+}
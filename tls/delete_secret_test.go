@@ -0,0 +1,118 @@
+package tls
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+)
+
+func TestDeleteTLSSecretUntracksFromCA(t *testing.T) {
+	t.Parallel()
+
+	caSecret := newTestManagedSecret("ca")
+	caSecret.Annotations[kubernetesSecretIssuedCertificatesAnnotationKey] = "default/tls,default/other"
+	tlsSecret := newTestManagedSecret("tls")
+	tlsSecret.Annotations[kubernetesSecretSignedByAnnotationKey] = "namespace=default,name=ca"
+	client := fake.NewSimpleClientset(caSecret, tlsSecret)
+
+	deletion, err := deleteTLSSecret(client, "default", "tls", false, true)
+	require.NoError(t, err)
+	assert.Equal(t, TLSSecretDeletion{Ref: "default/tls", Deleted: true, UntrackedFromCA: "default/ca"}, deletion)
+
+	_, err = client.CoreV1().Secrets("default").Get(context.Background(), "tls", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+	assert.Equal(t, []string{"default/other"}, issuedCertificateRefs(getTestSecret(t, client, "ca")))
+}
+
+func TestDeleteTLSSecretKeepsCATrackingByDefault(t *testing.T) {
+	t.Parallel()
+
+	caSecret := newTestManagedSecret("ca")
+	caSecret.Annotations[kubernetesSecretIssuedCertificatesAnnotationKey] = "default/tls"
+	tlsSecret := newTestManagedSecret("tls")
+	tlsSecret.Annotations[kubernetesSecretSignedByAnnotationKey] = "namespace=default,name=ca"
+	client := fake.NewSimpleClientset(caSecret, tlsSecret)
+
+	deletion, err := deleteTLSSecret(client, "default", "tls", false, false)
+	require.NoError(t, err)
+	assert.Equal(t, TLSSecretDeletion{Ref: "default/tls", Deleted: true}, deletion)
+	assert.Equal(t, []string{"default/tls"}, issuedCertificateRefs(getTestSecret(t, client, "ca")))
+}
+
+func TestDeleteTLSSecretToleratesNotFound(t *testing.T) {
+	t.Parallel()
+
+	client := fake.NewSimpleClientset()
+	deletion, err := deleteTLSSecret(client, "default", "tls", false, true)
+	require.NoError(t, err)
+	assert.Equal(t, TLSSecretDeletion{Ref: "default/tls"}, deletion)
+}
+
+func TestDeleteTLSSecretRefusesUnmanagedSecret(t *testing.T) {
+	t.Parallel()
+
+	unmanaged := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tls", Namespace: "default"}}
+	client := fake.NewSimpleClientset(unmanaged)
+
+	_, err := deleteTLSSecret(client, "default", "tls", false, false)
+	require.Error(t, err)
+	_, isNotManagedErr := errors.Unwrap(err).(SecretNotManagedError)
+	assert.True(t, isNotManagedErr)
+	getTestSecret(t, client, "tls")
+
+	deletion, err := deleteTLSSecret(client, "default", "tls", true, false)
+	require.NoError(t, err)
+	assert.True(t, deletion.Deleted)
+}
+
+func TestParseSignedByAnnotation(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		signedBy    string
+		expectedRef string
+		expectErr   bool
+	}{
+		{"namespace=default,name=ca", "default/ca", false},
+		{"name=ca,namespace=kube-system", "kube-system/ca", false},
+		{"namespace=default", "", true},
+		{"default/ca", "", true},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.signedBy, func(t *testing.T) {
+			t.Parallel()
+
+			ref, err := parseSignedByAnnotation(testCase.signedBy)
+			if testCase.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedRef, ref)
+		})
+	}
+}
+
+func newTestManagedSecret(name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Labels:      map[string]string{kubectl.ManagedByLabelKey: kubectl.ManagedByLabelValue},
+			Annotations: map[string]string{},
+		},
+	}
+}
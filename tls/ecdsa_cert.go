@@ -4,6 +4,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"net"
 	"time"
 
 	"github.com/gruntwork-io/go-commons/errors"
@@ -23,7 +24,9 @@ func (certificateKeyPair *TLSECDSACertificateKeyPair) Certificate() (*x509.Certi
 
 // CreateECDSACertificateKeyPair will generate a new certificate key pair using the ECDSA algorithm. You can
 // customize the distinguished name on the certificate, the validity time span, whether or not it is a CA certificate,
-// and sign the certificate with a given CA using the available parameters.
+// and sign the certificate with a given CA using the available parameters. The DNS names and IP addresses are added as
+// Subject Alternative Names. The certificate is restricted to the given extended key usages, which default to
+// DefaultExtKeyUsages when empty.
 // The elliptic curve is configurable, and it must be one of P224, P256, P384, P521.
 func CreateECDSACertificateKeyPair(
 	validityTimeSpan time.Duration,
@@ -32,6 +35,8 @@ func CreateECDSACertificateKeyPair(
 	signedByKey interface{}, // We don't know what format the signing key is in, so we will accept any type
 	isCA bool,
 	dnsNames []string,
+	ipAddresses []net.IP,
+	extKeyUsages []x509.ExtKeyUsage,
 	ecdsaCurve string,
 ) (TLSECDSACertificateKeyPair, error) {
 	privateKey, publicKey, err := CreateECDSAKeyPair(ecdsaCurve)
@@ -50,6 +55,8 @@ func CreateECDSACertificateKeyPair(
 		signedBy,
 		isCA,
 		dnsNames,
+		ipAddresses,
+		extKeyUsages,
 		publicKey,
 		signingKey,
 	)
@@ -13,12 +13,12 @@ func TestCreateECDSACertificateKeyPairSupportsSigningCerts(t *testing.T) {
 	t.Parallel()
 
 	distinguishedName := CreateSampleDistinguishedName(t)
-	caKeyPair, err := CreateECDSACertificateKeyPair(1*time.Hour, distinguishedName, nil, nil, true, nil, "P256")
+	caKeyPair, err := CreateECDSACertificateKeyPair(1*time.Hour, distinguishedName, nil, nil, true, nil, nil, nil, "P256")
 	require.NoError(t, err)
 	caCert, err := caKeyPair.Certificate()
 	require.NoError(t, err)
 
-	signedKeyPair, err := CreateECDSACertificateKeyPair(1*time.Hour, distinguishedName, caCert, caKeyPair.PrivateKey, false, nil, "P256")
+	signedKeyPair, err := CreateECDSACertificateKeyPair(1*time.Hour, distinguishedName, caCert, caKeyPair.PrivateKey, false, nil, nil, nil, "P256")
 	require.NoError(t, err)
 	signedCert, err := signedKeyPair.Certificate()
 	require.NoError(t, err)
@@ -40,12 +40,12 @@ func TestCreateECDSACertificateKeyPairSupportsSigningByRSACerts(t *testing.T) {
 	t.Parallel()
 
 	distinguishedName := CreateSampleDistinguishedName(t)
-	caKeyPair, err := CreateRSACertificateKeyPair(1*time.Hour, distinguishedName, nil, nil, true, nil, 2048)
+	caKeyPair, err := CreateRSACertificateKeyPair(1*time.Hour, distinguishedName, nil, nil, true, nil, nil, nil, 2048)
 	require.NoError(t, err)
 	caCert, err := caKeyPair.Certificate()
 	require.NoError(t, err)
 
-	signedKeyPair, err := CreateECDSACertificateKeyPair(1*time.Hour, distinguishedName, caCert, caKeyPair.PrivateKey, false, nil, "P256")
+	signedKeyPair, err := CreateECDSACertificateKeyPair(1*time.Hour, distinguishedName, caCert, caKeyPair.PrivateKey, false, nil, nil, nil, "P256")
 	require.NoError(t, err)
 	signedCert, err := signedKeyPair.Certificate()
 	require.NoError(t, err)
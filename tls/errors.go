@@ -30,3 +30,55 @@ type RSABitsTooLow struct {
 func (err RSABitsTooLow) Error() string {
 	return fmt.Sprintf("RSA Key length of %d is too low. Choose at least 2048.", err.RSABits)
 }
+
+// NoCertificateInPEMError is returned when PEM encoded data that is expected to hold a certificate does not.
+type NoCertificateInPEMError struct{}
+
+func (err NoCertificateInPEMError) Error() string {
+	return "No certificate found in PEM encoded data."
+}
+
+// NoPrivateKeyInPEMError is returned when PEM encoded data that is expected to hold an unencrypted RSA or ECDSA private
+// key does not.
+type NoPrivateKeyInPEMError struct{}
+
+func (err NoPrivateKeyInPEMError) Error() string {
+	return "No RSA or ECDSA private key found in PEM encoded data."
+}
+
+// NoSubjectAltNamesError is returned when trying to issue a TLS certificate without any Subject Alternative Names.
+type NoSubjectAltNamesError struct{}
+
+func (err NoSubjectAltNamesError) Error() string {
+	return "TLS certificates require at least one Subject Alternative Name. Set a DNS name, IP address, or common name."
+}
+
+// InvalidSecretRefError is returned when a reference to a Secret is not in the namespace/name format.
+type InvalidSecretRefError struct {
+	Ref string
+}
+
+func (err InvalidSecretRefError) Error() string {
+	return fmt.Sprintf("Invalid Secret reference %s. Expected namespace/name.", err.Ref)
+}
+
+// SecretNotManagedError is returned when trying to delete a Secret that is not labeled as managed by kubergrunt.
+type SecretNotManagedError struct {
+	Namespace string
+	Name      string
+}
+
+func (err SecretNotManagedError) Error() string {
+	return fmt.Sprintf("Secret %s (namespace %s) is not labeled as managed by kubergrunt. Pass --force to delete it anyway.", err.Name, err.Namespace)
+}
+
+// PreviousCANotDroppedError is returned when trying to rotate a CA whose previous CA is still in the trust bundles, as
+// rotating again would drop the previous CA before the clients stop trusting it.
+type PreviousCANotDroppedError struct {
+	Namespace string
+	Name      string
+}
+
+func (err PreviousCANotDroppedError) Error() string {
+	return fmt.Sprintf("CA %s (namespace %s) was already rotated, and all its certificates are signed by the new CA. Pass --drop-previous-ca to drop the previous CA before rotating it again.", err.Name, err.Namespace)
+}
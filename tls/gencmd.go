@@ -16,6 +16,8 @@ const (
 	kubernetesSecretPrivateKeyAlgorithmAnnotationKey = "gruntwork.io/private-key-algorithm"
 	kubernetesSecretFileNameBaseAnnotationKey        = "gruntwork.io/filename-base"
 	kubernetesSecretSignedByAnnotationKey            = "gruntwork.io/signed-by"
+	// Comma separated list of namespace/name references to the Secrets holding the certificates issued by a CA.
+	kubernetesSecretIssuedCertificatesAnnotationKey = "gruntwork.io/issued-certificates"
 )
 
 type KubernetesSecretOptions struct {
@@ -34,7 +36,6 @@ func GenerateAndStoreAsK8SSecret(
 	genCA bool,
 	filenameBase string,
 	tlsOptions TLSOptions,
-	dnsNames []string,
 ) error {
 	logger := logging.GetProjectLogger()
 	logger.Info("Generating certificate key pairs")
@@ -68,7 +69,7 @@ func GenerateAndStoreAsK8SSecret(
 			return err
 		}
 		caCertPath = caKeyPairPath.CertificatePath
-		keyPairPath, err = generateSignedTLSKeyPair(tlsPath, tlsOptions, caKeyPairPath, caKeyPairAlgorithm, filenameBase, dnsNames)
+		keyPairPath, err = generateSignedTLSKeyPair(tlsPath, tlsOptions, caKeyPairPath, caKeyPairAlgorithm, filenameBase)
 		if err != nil {
 			return err
 		}
@@ -82,7 +83,7 @@ func GenerateAndStoreAsK8SSecret(
 	// Augment annotation to indicate private key algorithm and filename base used to generate the cert
 	secretOptions.Annotations[kubernetesSecretPrivateKeyAlgorithmAnnotationKey] = tlsOptions.PrivateKeyAlgorithm
 	secretOptions.Annotations[kubernetesSecretFileNameBaseAnnotationKey] = filenameBase
-	return StoreCertificateKeyPairAsKubernetesSecret(
+	err = StoreCertificateKeyPairAsKubernetesSecret(
 		kubectlOptions,
 		secretOptions.Name,
 		secretOptions.Namespace,
@@ -92,6 +93,12 @@ func GenerateAndStoreAsK8SSecret(
 		keyPairPath,
 		caCertPath,
 	)
+	if err != nil || genCA {
+		return err
+	}
+
+	// Record the issued certificate on the CA, so that RotateCA can reissue it.
+	return trackIssuedCertificate(kubectlOptions, caSecretOptions, secretOptions)
 }
 
 // generateCAKeyPair will issue a new CA TLS certificate key pair.
@@ -106,7 +113,6 @@ func generateCAKeyPair(tlsPath string, tlsOptions TLSOptions, filenameBase strin
 		true,
 		nil,
 		nil,
-		nil,
 	)
 	if err == nil {
 		logger.Info("Successfully generated CA TLS certificate key pair and stored in temp workspace.")
@@ -123,7 +129,6 @@ func generateSignedTLSKeyPair(
 	caKeyPairPath CertificateKeyPairPath,
 	caKeyPairAlgorithm string,
 	filenameBase string,
-	dnsNames []string,
 ) (CertificateKeyPairPath, error) {
 	logger := logging.GetProjectLogger()
 	logger.Info("Generating signed certificate key pairs from loaded CA and storing into temporary workspace")
@@ -152,7 +157,6 @@ func generateSignedTLSKeyPair(
 		tlsPath,
 		"", // TODO: support passworded key pairs
 		false,
-		dnsNames,
 		signingCertificate,
 		signingKey,
 	)
@@ -0,0 +1,219 @@
+package tls
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/gruntwork-io/go-commons/errors"
+
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+const (
+	// Filename bases of the certificate key pairs issued by GenerateMutualTLSAsK8SSecrets and
+	// GenerateMutualTLSToDirectory.
+	mutualTLSCAFileNameBase     = "ca"
+	mutualTLSServerFileNameBase = "server"
+	mutualTLSClientFileNameBase = "client"
+)
+
+// mutualTLSKeyPairPaths holds the paths to the certificate key pairs issued for mutual TLS.
+type mutualTLSKeyPairPaths struct {
+	CA          CertificateKeyPairPath
+	CAAlgorithm string
+	Server      CertificateKeyPairPath
+	Client      CertificateKeyPairPath
+}
+
+// GenerateMutualTLSAsK8SSecrets will issue a server and a client certificate key pair signed by the same CA, for
+// mutual TLS between two services, and store each of them as a Kubernetes Secret resource in the same format as
+// GenerateAndStoreAsK8SSecret (e.g., server.crt, server.pem, server.pub and ca.crt). The server certificate can only be
+// used to authenticate TLS servers, and the client certificate can only be used to authenticate TLS clients.
+//
+// When genCA is true, a new CA certificate key pair is generated with the subject of the server certificate and stored
+// in the Secret described by caSecretOptions. Otherwise, the CA key pair is loaded from that Secret, which must have
+// been created with `tls gen --ca`. Either way, the issued certificates are recorded on the CA Secret, so that
+// RotateCA can reissue them.
+func GenerateMutualTLSAsK8SSecrets(
+	kubectlOptions *kubectl.KubectlOptions,
+	caSecretOptions KubernetesSecretOptions,
+	serverSecretOptions KubernetesSecretOptions,
+	clientSecretOptions KubernetesSecretOptions,
+	genCA bool,
+	serverTLSOptions TLSOptions,
+	clientTLSOptions TLSOptions,
+) error {
+	logger := logging.GetProjectLogger()
+
+	logger.Info("Creating a temporary directory as a workspace")
+	tlsPath, err := ioutil.TempDir("", "")
+	if err != nil {
+		logger.Errorf("Error creating temp directory to store certificate key pairs: %s", err)
+		return errors.WithStackTrace(err)
+	}
+	logger.Infof("Using %s as temp path for storing certificates", tlsPath)
+	defer func() {
+		logger.Infof("Cleaning up temp workspace %s", tlsPath)
+		os.RemoveAll(tlsPath)
+	}()
+
+	keyPairPaths, err := generateMutualTLSKeyPairs(kubectlOptions, caSecretOptions, genCA, tlsPath, serverTLSOptions, clientTLSOptions)
+	if err != nil {
+		return err
+	}
+
+	if genCA {
+		caSecretOptions.Annotations[kubernetesSecretPrivateKeyAlgorithmAnnotationKey] = keyPairPaths.CAAlgorithm
+		caSecretOptions.Annotations[kubernetesSecretFileNameBaseAnnotationKey] = mutualTLSCAFileNameBase
+		err := StoreCertificateKeyPairAsKubernetesSecret(
+			kubectlOptions,
+			caSecretOptions.Name,
+			caSecretOptions.Namespace,
+			caSecretOptions.Labels,
+			caSecretOptions.Annotations,
+			mutualTLSCAFileNameBase,
+			keyPairPaths.CA,
+			"",
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	caSignedByString := fmt.Sprintf("namespace=%s,name=%s", caSecretOptions.Namespace, caSecretOptions.Name)
+	issued := []struct {
+		secretOptions KubernetesSecretOptions
+		tlsOptions    TLSOptions
+		filenameBase  string
+		keyPairPath   CertificateKeyPairPath
+	}{
+		{serverSecretOptions, serverTLSOptions, mutualTLSServerFileNameBase, keyPairPaths.Server},
+		{clientSecretOptions, clientTLSOptions, mutualTLSClientFileNameBase, keyPairPaths.Client},
+	}
+	for _, keyPair := range issued {
+		secretOptions := keyPair.secretOptions
+		secretOptions.Annotations[kubernetesSecretSignedByAnnotationKey] = caSignedByString
+		secretOptions.Annotations[kubernetesSecretPrivateKeyAlgorithmAnnotationKey] = keyPair.tlsOptions.PrivateKeyAlgorithm
+		secretOptions.Annotations[kubernetesSecretFileNameBaseAnnotationKey] = keyPair.filenameBase
+		err := StoreCertificateKeyPairAsKubernetesSecret(
+			kubectlOptions,
+			secretOptions.Name,
+			secretOptions.Namespace,
+			secretOptions.Labels,
+			secretOptions.Annotations,
+			keyPair.filenameBase,
+			keyPair.keyPairPath,
+			keyPairPaths.CA.CertificatePath,
+		)
+		if err != nil {
+			return err
+		}
+		if err := trackIssuedCertificate(kubectlOptions, caSecretOptions, secretOptions); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateMutualTLSToDirectory is like GenerateMutualTLSAsK8SSecrets, but writes the certificate key pairs as PEM files
+// to the given directory instead of storing them in Kubernetes: ca.crt, server.crt, server.pem, server.pub,
+// client.crt, client.pem and client.pub. When genCA is true, the private and public keys of the generated CA are also
+// written (ca.pem and ca.pub). Otherwise, the CA key pair is loaded from the Secret described by caSecretOptions, and
+// only its certificate is written. The kubectl options are only used to load the CA key pair.
+func GenerateMutualTLSToDirectory(
+	kubectlOptions *kubectl.KubectlOptions,
+	caSecretOptions KubernetesSecretOptions,
+	genCA bool,
+	outputDir string,
+	serverTLSOptions TLSOptions,
+	clientTLSOptions TLSOptions,
+) error {
+	logger := logging.GetProjectLogger()
+
+	if err := os.MkdirAll(outputDir, 0700); err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	// The CA key pair is loaded in a temporary workspace, so that the private key of an existing CA is not written to
+	// the output directory.
+	tlsPath, err := ioutil.TempDir("", "")
+	if err != nil {
+		logger.Errorf("Error creating temp directory to store certificate key pairs: %s", err)
+		return errors.WithStackTrace(err)
+	}
+	defer os.RemoveAll(tlsPath)
+
+	keyPairPaths, err := generateMutualTLSKeyPairs(kubectlOptions, caSecretOptions, genCA, tlsPath, serverTLSOptions, clientTLSOptions)
+	if err != nil {
+		return err
+	}
+
+	files := []string{
+		keyPairPaths.CA.CertificatePath,
+		keyPairPaths.Server.CertificatePath,
+		keyPairPaths.Server.PrivateKeyPath,
+		keyPairPaths.Server.PublicKeyPath,
+		keyPairPaths.Client.CertificatePath,
+		keyPairPaths.Client.PrivateKeyPath,
+		keyPairPaths.Client.PublicKeyPath,
+	}
+	if genCA {
+		files = append(files, keyPairPaths.CA.PrivateKeyPath, keyPairPaths.CA.PublicKeyPath)
+	}
+	for _, path := range files {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(outputDir, filepath.Base(path)), data, 0600); err != nil {
+			return errors.WithStackTrace(err)
+		}
+	}
+	logger.Infof("Successfully wrote mutual TLS certificate key pairs to %s", outputDir)
+	return nil
+}
+
+// generateMutualTLSKeyPairs generates (or loads) the CA key pair, and issues the server and client certificate key
+// pairs signed by it in the given workspace. The server certificate is restricted to server authentication, and the
+// client certificate to client authentication.
+func generateMutualTLSKeyPairs(
+	kubectlOptions *kubectl.KubectlOptions,
+	caSecretOptions KubernetesSecretOptions,
+	genCA bool,
+	tlsPath string,
+	serverTLSOptions TLSOptions,
+	clientTLSOptions TLSOptions,
+) (mutualTLSKeyPairPaths, error) {
+	logger := logging.GetProjectLogger()
+	keyPairPaths := mutualTLSKeyPairPaths{}
+
+	var err error
+	if genCA {
+		logger.Info("Requested CA key pair.")
+		caTLSOptions := serverTLSOptions
+		caTLSOptions.DistinguishedName.CommonName = fmt.Sprintf("%s CA", serverTLSOptions.DistinguishedName.CommonName)
+		keyPairPaths.CA, err = generateCAKeyPair(tlsPath, caTLSOptions, mutualTLSCAFileNameBase)
+		keyPairPaths.CAAlgorithm = caTLSOptions.PrivateKeyAlgorithm
+	} else {
+		keyPairPaths.CA, keyPairPaths.CAAlgorithm, err = loadCAKeyPair(kubectlOptions, caSecretOptions, tlsPath)
+	}
+	if err != nil {
+		return keyPairPaths, err
+	}
+
+	logger.Info("Requested server TLS key pair.")
+	serverTLSOptions.ExtKeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	keyPairPaths.Server, err = generateSignedTLSKeyPair(tlsPath, serverTLSOptions, keyPairPaths.CA, keyPairPaths.CAAlgorithm, mutualTLSServerFileNameBase)
+	if err != nil {
+		return keyPairPaths, err
+	}
+
+	logger.Info("Requested client TLS key pair.")
+	clientTLSOptions.ExtKeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	keyPairPaths.Client, err = generateSignedTLSKeyPair(tlsPath, clientTLSOptions, keyPairPaths.CA, keyPairPaths.CAAlgorithm, mutualTLSClientFileNameBase)
+	return keyPairPaths, err
+}
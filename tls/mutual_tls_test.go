@@ -0,0 +1,100 @@
+package tls
+
+import (
+	gotls "crypto/tls"
+	"crypto/x509"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateMutualTLSToDirectorySetsKeyUsages(t *testing.T) {
+	t.Parallel()
+
+	for _, algorithm := range PrivateKeyAlgorithms {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		algorithm := algorithm
+
+		t.Run(algorithm, func(t *testing.T) {
+			t.Parallel()
+
+			outputDir := t.TempDir()
+			serverOptions := SampleTlsOptions(algorithm)
+			serverOptions.DNSNames = []string{"server.default.svc"}
+			clientOptions := SampleTlsOptions(algorithm)
+			clientOptions.DistinguishedName.CommonName = "client"
+			require.NoError(t, GenerateMutualTLSToDirectory(nil, KubernetesSecretOptions{}, true, outputDir, serverOptions, clientOptions))
+
+			for _, name := range []string{"ca.crt", "ca.pem", "ca.pub", "server.crt", "server.pem", "server.pub", "client.crt", "client.pem", "client.pub"} {
+				assert.FileExists(t, filepath.Join(outputDir, name))
+			}
+
+			caCert, err := LoadCertificate(filepath.Join(outputDir, "ca.crt"))
+			require.NoError(t, err)
+			roots := x509.NewCertPool()
+			roots.AddCert(caCert)
+
+			serverCert, err := LoadCertificate(filepath.Join(outputDir, "server.crt"))
+			require.NoError(t, err)
+			assert.Equal(t, []string{"server.default.svc"}, serverCert.DNSNames)
+			assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, serverCert.ExtKeyUsage)
+			_, err = serverCert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+			assert.NoError(t, err)
+			_, err = serverCert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+			assert.Error(t, err)
+
+			clientCert, err := LoadCertificate(filepath.Join(outputDir, "client.crt"))
+			require.NoError(t, err)
+			assert.Equal(t, "client", clientCert.Subject.CommonName)
+			assert.Equal(t, []string{"client"}, clientCert.DNSNames)
+			assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, clientCert.ExtKeyUsage)
+			_, err = clientCert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+			assert.NoError(t, err)
+			_, err = clientCert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestGenerateMutualTLSToDirectoryCertsCompleteHandshake(t *testing.T) {
+	t.Parallel()
+
+	outputDir := t.TempDir()
+	serverOptions := SampleTlsOptions(ECDSAAlgorithm)
+	serverOptions.DNSNames = []string{"server.default.svc"}
+	clientOptions := SampleTlsOptions(ECDSAAlgorithm)
+	clientOptions.DistinguishedName.CommonName = "client"
+	require.NoError(t, GenerateMutualTLSToDirectory(nil, KubernetesSecretOptions{}, true, outputDir, serverOptions, clientOptions))
+
+	caCert, err := LoadCertificate(filepath.Join(outputDir, "ca.crt"))
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	serverKeyPair, err := gotls.LoadX509KeyPair(filepath.Join(outputDir, "server.crt"), filepath.Join(outputDir, "server.pem"))
+	require.NoError(t, err)
+	clientKeyPair, err := gotls.LoadX509KeyPair(filepath.Join(outputDir, "client.crt"), filepath.Join(outputDir, "client.pem"))
+	require.NoError(t, err)
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	server := gotls.Server(serverConn, &gotls.Config{
+		Certificates: []gotls.Certificate{serverKeyPair},
+		ClientCAs:    roots,
+		ClientAuth:   gotls.RequireAndVerifyClientCert,
+	})
+	client := gotls.Client(clientConn, &gotls.Config{
+		Certificates: []gotls.Certificate{clientKeyPair},
+		RootCAs:      roots,
+		ServerName:   "server.default.svc",
+	})
+
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- server.Handshake() }()
+	require.NoError(t, client.Handshake())
+	require.NoError(t, <-serverErr)
+	assert.Equal(t, "client", server.ConnectionState().PeerCertificates[0].Subject.CommonName)
+}
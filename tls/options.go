@@ -4,6 +4,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"fmt"
+	"net"
 	"path/filepath"
 	"time"
 
@@ -42,6 +43,10 @@ var (
 		P384Curve,
 		P521Curve,
 	}
+
+	// DefaultExtKeyUsages are the extended key usages of the certificates that are not restricted to a role, which can be
+	// used both by TLS servers and TLS clients.
+	DefaultExtKeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
 )
 
 // TLSOptions is a convenient struct to capture all the options needed for generating a TLS certificate key pair.
@@ -51,6 +56,17 @@ type TLSOptions struct {
 	PrivateKeyAlgorithm string
 	RSABits             int
 	ECDSACurve          string
+
+	// DNSNames and IPAddresses are added as Subject Alternative Names (SANs) to TLS certificates. If neither is set, the
+	// common name is used as the DNS name, as modern TLS clients ignore the common name. These are not added to CA
+	// certificates.
+	DNSNames    []string
+	IPAddresses []net.IP
+
+	// ExtKeyUsages restricts what TLS certificates can be used for, e.g., x509.ExtKeyUsageServerAuth for a certificate
+	// that is only accepted by TLS clients when presented by a server. Defaults to DefaultExtKeyUsages when empty. This is
+	// not applied to CA certificates, which can issue certificates for any of the default usages.
+	ExtKeyUsages []x509.ExtKeyUsage
 }
 
 // Validate will validate the provided TLSOptions struct is valid.
@@ -81,36 +97,64 @@ func (options *TLSOptions) GenerateAndStoreTLSCertificateKeyPair(
 	rootPath string,
 	keyPassword string,
 	isCA bool,
-	dnsNames []string,
 	signedBy *x509.Certificate,
 	signedByKey interface{}, // We don't know what format the signing key is in, so we will accept any type
 ) (CertificateKeyPairPath, error) {
-	var err error
 	path := CertificateKeyPairPath{
 		CertificatePath: filepath.Join(rootPath, fmt.Sprintf("%s.crt", name)),
 		PrivateKeyPath:  filepath.Join(rootPath, fmt.Sprintf("%s.pem", name)),
 		PublicKeyPath:   filepath.Join(rootPath, fmt.Sprintf("%s.pub", name)),
 	}
+	dnsNames, ipAddresses, err := options.subjectAltNames(isCA)
+	if err != nil {
+		return path, err
+	}
 	switch options.PrivateKeyAlgorithm {
 	case ECDSAAlgorithm:
-		err = options.generateECDSATLSCertificateKeyPair(path, keyPassword, isCA, dnsNames, signedBy, signedByKey)
+		err = options.generateECDSATLSCertificateKeyPair(path, keyPassword, isCA, dnsNames, ipAddresses, signedBy, signedByKey)
 	case RSAAlgorithm:
-		err = options.generateRSATLSCertificateKeyPair(path, keyPassword, isCA, dnsNames, signedBy, signedByKey)
+		err = options.generateRSATLSCertificateKeyPair(path, keyPassword, isCA, dnsNames, ipAddresses, signedBy, signedByKey)
 	default:
 		err = errors.WithStackTrace(UnknownPrivateKeyAlgorithm{options.PrivateKeyAlgorithm})
 	}
 	return path, err
 }
 
+// subjectAltNames returns the DNS names and IP addresses to add as SANs to the certificate. CA certificates get none,
+// while TLS certificates must have at least one, as otherwise they are rejected by modern TLS clients.
+func (options *TLSOptions) subjectAltNames(isCA bool) ([]string, []net.IP, error) {
+	if isCA {
+		return nil, nil, nil
+	}
+	dnsNames := options.DNSNames
+	if len(dnsNames) == 0 && len(options.IPAddresses) == 0 && options.DistinguishedName.CommonName != "" {
+		dnsNames = []string{options.DistinguishedName.CommonName}
+	}
+	if len(dnsNames) == 0 && len(options.IPAddresses) == 0 {
+		return nil, nil, errors.WithStackTrace(NoSubjectAltNamesError{})
+	}
+	return dnsNames, options.IPAddresses, nil
+}
+
+// extKeyUsages returns the extended key usages to restrict the certificate to. CA certificates always get the default
+// usages, as Go rejects certificates with usages that are not allowed by every CA in the chain.
+func (options *TLSOptions) extKeyUsages(isCA bool) []x509.ExtKeyUsage {
+	if isCA {
+		return nil
+	}
+	return options.ExtKeyUsages
+}
+
 func (options *TLSOptions) generateECDSATLSCertificateKeyPair(
 	certificateKeyPairPath CertificateKeyPairPath,
 	keyPassword string,
 	isCA bool,
 	dnsNames []string,
+	ipAddresses []net.IP,
 	signedBy *x509.Certificate,
 	signedByKey interface{}, // We don't know what format the signing key is in, so we will accept any type
 ) error {
-	keypair, err := CreateECDSACertificateKeyPair(options.ValidityTimeSpan, options.DistinguishedName, signedBy, signedByKey, isCA, dnsNames, options.ECDSACurve)
+	keypair, err := CreateECDSACertificateKeyPair(options.ValidityTimeSpan, options.DistinguishedName, signedBy, signedByKey, isCA, dnsNames, ipAddresses, options.extKeyUsages(isCA), options.ECDSACurve)
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
@@ -134,10 +178,11 @@ func (options *TLSOptions) generateRSATLSCertificateKeyPair(
 	keyPassword string,
 	isCA bool,
 	dnsNames []string,
+	ipAddresses []net.IP,
 	signedBy *x509.Certificate,
 	signedByKey interface{}, // We don't know what format the signing key is in, so we will accept any type
 ) error {
-	keypair, err := CreateRSACertificateKeyPair(options.ValidityTimeSpan, options.DistinguishedName, signedBy, signedByKey, isCA, dnsNames, options.RSABits)
+	keypair, err := CreateRSACertificateKeyPair(options.ValidityTimeSpan, options.DistinguishedName, signedBy, signedByKey, isCA, dnsNames, ipAddresses, options.extKeyUsages(isCA), options.RSABits)
 	if err != nil {
 		return errors.WithStackTrace(err)
 	}
@@ -1,10 +1,12 @@
 package tls
 
 import (
+	"net"
 	"testing"
 
 	"github.com/gruntwork-io/go-commons/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTLSOptionsValidateAcceptsAllKnownAlgorithms(t *testing.T) {
@@ -104,3 +106,61 @@ func TestTLSOptionsValidateRejectsRSABitsBelowMinimum(t *testing.T) {
 		t.Fatalf("Wrong validation error type: %s", err)
 	}
 }
+
+func TestTLSOptionsSubjectAltNames(t *testing.T) {
+	t.Parallel()
+
+	clusterIP := net.ParseIP("172.20.0.10")
+	testCases := []struct {
+		name                string
+		commonName          string
+		dnsNames            []string
+		ipAddresses         []net.IP
+		isCA                bool
+		expectedDNSNames    []string
+		expectedIPAddresses []net.IP
+	}{
+		{"explicit", "webhook", []string{"webhook.default.svc"}, []net.IP{clusterIP}, false, []string{"webhook.default.svc"}, []net.IP{clusterIP}},
+		{"ip-only", "webhook", nil, []net.IP{clusterIP}, false, nil, []net.IP{clusterIP}},
+		{"defaults-to-common-name", "webhook.default.svc", nil, nil, false, []string{"webhook.default.svc"}, nil},
+		{"ca", "webhook-ca", []string{"webhook.default.svc"}, nil, true, nil, nil},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			options := SampleTlsOptions(ECDSAAlgorithm)
+			options.DistinguishedName.CommonName = testCase.commonName
+			options.DNSNames = testCase.dnsNames
+			options.IPAddresses = testCase.ipAddresses
+			dnsNames, ipAddresses, err := options.subjectAltNames(testCase.isCA)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedDNSNames, dnsNames)
+			assert.Equal(t, testCase.expectedIPAddresses, ipAddresses)
+
+			// The SANs end up in the issued certificate
+			cert, _, err := options.createCertificateKeyPair(testCase.isCA, nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedDNSNames, cert.DNSNames)
+			assert.Equal(t, len(testCase.expectedIPAddresses), len(cert.IPAddresses))
+			for i, ipAddress := range testCase.expectedIPAddresses {
+				assert.True(t, ipAddress.Equal(cert.IPAddresses[i]))
+			}
+		})
+	}
+}
+
+func TestTLSOptionsSubjectAltNamesRejectsCertificatesWithoutSANs(t *testing.T) {
+	t.Parallel()
+
+	options := SampleTlsOptions(ECDSAAlgorithm)
+	options.DistinguishedName.CommonName = ""
+	_, _, err := options.subjectAltNames(false)
+	require.Error(t, err)
+	_, isNoSANsErr := errors.Unwrap(err).(NoSubjectAltNamesError)
+	assert.True(t, isNoSANsErr)
+}
@@ -12,6 +12,12 @@ import (
 	"github.com/gruntwork-io/go-commons/errors"
 )
 
+const (
+	// PEM block types of private keys
+	rsaPrivateKeyPEMType = "RSA PRIVATE KEY"
+	ecPrivateKeyPEMType  = "EC PRIVATE KEY"
+)
+
 // EncodeCertificateToPEM will take the raw x509 Certificate and encode it to a pem Block struct.
 func EncodeCertificateToPEM(certificate *x509.Certificate) pem.Block {
 	return pem.Block{
@@ -24,7 +30,7 @@ func EncodeCertificateToPEM(certificate *x509.Certificate) pem.Block {
 // optionally encrypt the private key by providing a password (passing in "" will keep it unencrypted).
 func EncodeRSAPrivateKeyToPEM(privateKey *rsa.PrivateKey, password string) (pem.Block, error) {
 	// TODO: make encoding type (PKCS) configurable
-	return NewPrivateKeyPEMBlock("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(privateKey), password)
+	return NewPrivateKeyPEMBlock(rsaPrivateKeyPEMType, x509.MarshalPKCS1PrivateKey(privateKey), password)
 }
 
 // EncodeECDSAPrivateKeyToPEM will take the provided ECDSA private key and encode it to a pem Block struct. You can
@@ -34,7 +40,7 @@ func EncodeECDSAPrivateKeyToPEM(privateKey *ecdsa.PrivateKey, password string) (
 	if err != nil {
 		return pem.Block{}, errors.WithStackTrace(err)
 	}
-	return NewPrivateKeyPEMBlock("EC PRIVATE KEY", blockBytes, password)
+	return NewPrivateKeyPEMBlock(ecPrivateKeyPEMType, blockBytes, password)
 }
 
 // EncodePublicKeyToPEM will take the provided public key and encode it to a pem Block struct.
@@ -0,0 +1,337 @@
+package tls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/gruntwork-io/go-commons/collections"
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/hashicorp/go-multierror"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+const (
+	// previousCACertSecretKey is the key in the CA Secret that holds the certificate of the CA that was replaced by
+	// RotateCA, until it is dropped from the trust bundle.
+	previousCACertSecretKey = "previous-ca.crt"
+
+	// defaultFileNameBase is the filename base used by `tls gen` for TLS certificates, if none is passed in.
+	defaultFileNameBase = "tls"
+)
+
+// RotateCA will rotate the CA certificate key pair stored in the Secret with the given name and namespace by the
+// `tls gen --ca` command. This generates a new CA with the same subject, key type and validity time span, and reissues
+// all the TLS certificates that were issued by the CA with `tls gen`, signing them with the new CA while keeping their
+// private keys. To allow clients to pick up the new CA, the ca.crt trust bundle in the Secrets of the reissued
+// certificates holds both the new and the old CA certificate.
+//
+// Once all clients trust the new CA, call this again with dropPreviousCA set to true. Instead of rotating the CA again,
+// this removes the old CA certificate from the trust bundles.
+//
+// Calling this again before dropping the previous CA resumes the rotation: the certificates that are not signed by the
+// new CA yet (e.g., because updating their Secret failed) are reissued with it, without generating another CA. Once all
+// the certificates are signed by the new CA, this returns a PreviousCANotDroppedError until the previous CA is dropped.
+//
+// Returns the namespace/name of the Secrets that were updated. Issued certificates whose Secret no longer exists are
+// skipped with a warning.
+func RotateCA(
+	kubectlOptions *kubectl.KubectlOptions,
+	secretName string,
+	namespace string,
+	dropPreviousCA bool,
+) ([]string, error) {
+	client, err := kubectl.GetKubernetesClientFromOptions(kubectlOptions)
+	if err != nil {
+		return nil, err
+	}
+	return rotateCA(client, secretName, namespace, dropPreviousCA)
+}
+
+func rotateCA(client kubernetes.Interface, secretName string, namespace string, dropPreviousCA bool) ([]string, error) {
+	logger := logging.GetProjectLogger()
+
+	caSecret, err := client.CoreV1().Secrets(namespace).Get(context.Background(), secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	caFileNameBase := caSecret.Annotations[kubernetesSecretFileNameBaseAnnotationKey]
+	caCert, err := parseCertificatePEM(caSecret.Data[fmt.Sprintf("%s.crt", caFileNameBase)])
+	if err != nil {
+		return nil, err
+	}
+
+	// signingCACert and signingCAKey are the CA that the issued certificates are reissued with, if any.
+	var signingCACert *x509.Certificate
+	var signingCAKey interface{}
+	var trustBundle []byte
+	resuming := false
+	switch {
+	case dropPreviousCA:
+		logger.Infof("Dropping the previous CA certificate from the trust bundle of CA %s (namespace %s)", secretName, namespace)
+		delete(caSecret.Data, previousCACertSecretKey)
+		trustBundle = encodeCertificate(caCert)
+
+	// A previous CA means that the CA was already rotated. Rotating it again would replace the previous CA in the trust
+	// bundles with the current one, so that the clients that only trust the original CA are cut off. Instead, resume a
+	// rotation that failed to reissue some of the certificates, and refuse to rotate again until the previous CA is
+	// dropped.
+	case len(caSecret.Data[previousCACertSecretKey]) > 0:
+		pendingRefs := issuedCertificateRefsNotSignedBy(client, caSecret, caCert)
+		if len(pendingRefs) == 0 {
+			return nil, errors.WithStackTrace(PreviousCANotDroppedError{Namespace: namespace, Name: secretName})
+		}
+		logger.Infof("Resuming the rotation of CA %s (namespace %s): %d certificates are not signed by the new CA yet.", secretName, namespace, len(pendingRefs))
+		previousCACert, err := parseCertificatePEM(caSecret.Data[previousCACertSecretKey])
+		if err != nil {
+			return nil, err
+		}
+		signingCAKey, err = parsePrivateKeyPEM(caSecret.Data[fmt.Sprintf("%s.pem", caFileNameBase)])
+		if err != nil {
+			return nil, err
+		}
+		signingCACert = caCert
+		trustBundle = append(encodeCertificate(caCert), encodeCertificate(previousCACert)...)
+		resuming = true
+
+	default:
+		logger.Infof("Generating new CA certificate key pair to replace CA %s (namespace %s)", secretName, namespace)
+		caKey, err := parsePrivateKeyPEM(caSecret.Data[fmt.Sprintf("%s.pem", caFileNameBase)])
+		if err != nil {
+			return nil, err
+		}
+		signingCACert, signingCAKey, err = createCertificateAuthorityLike(caCert, caKey)
+		if err != nil {
+			return nil, err
+		}
+		newCAKeyPEM, err := encodePrivateKeyToPEM(signingCAKey)
+		if err != nil {
+			return nil, err
+		}
+		newCAPublicKeyPEM, err := EncodePublicKeyToPEM(signingCACert.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		caSecret.Data[fmt.Sprintf("%s.crt", caFileNameBase)] = encodeCertificate(signingCACert)
+		caSecret.Data[fmt.Sprintf("%s.pem", caFileNameBase)] = newCAKeyPEM
+		caSecret.Data[fmt.Sprintf("%s.pub", caFileNameBase)] = pem.EncodeToMemory(&newCAPublicKeyPEM)
+		caSecret.Data[previousCACertSecretKey] = encodeCertificate(caCert)
+		trustBundle = append(encodeCertificate(signingCACert), encodeCertificate(caCert)...)
+	}
+	// When resuming, the CA Secret already holds the new CA.
+	if !resuming {
+		kubectl.MarkAsModifiedByKubergrunt(&caSecret.ObjectMeta)
+		if _, err := client.CoreV1().Secrets(namespace).Update(context.Background(), caSecret, metav1.UpdateOptions{}); err != nil {
+			return nil, errors.WithStackTrace(err)
+		}
+		logger.Infof("Successfully updated CA %s (namespace %s)", secretName, namespace)
+	}
+
+	updated := []string{}
+	var allErrs *multierror.Error
+	for _, ref := range issuedCertificateRefs(caSecret) {
+		secret, err := getSecretByRef(client, ref)
+		if apierrors.IsNotFound(err) {
+			logger.Warnf("Secret %s with a certificate issued by CA %s (namespace %s) no longer exists. Skipping.", ref, secretName, namespace)
+			continue
+		} else if err != nil {
+			allErrs = multierror.Append(allErrs, err)
+			continue
+		}
+		if resuming && isCertificateInSecretSignedBy(secret, signingCACert) {
+			logger.Infof("Certificate in Secret %s is already signed by the new CA. Skipping.", ref)
+			continue
+		}
+
+		if signingCACert != nil {
+			if err := reissueCertificateInSecret(secret, signingCACert, signingCAKey); err != nil {
+				logger.Errorf("Error reissuing certificate in Secret %s: %s", ref, err)
+				allErrs = multierror.Append(allErrs, err)
+				continue
+			}
+		}
+		secret.Data[TLSSecretCACertKey] = trustBundle
+		kubectl.MarkAsModifiedByKubergrunt(&secret.ObjectMeta)
+		if _, err := client.CoreV1().Secrets(secret.Namespace).Update(context.Background(), secret, metav1.UpdateOptions{}); err != nil {
+			logger.Errorf("Error updating Secret %s: %s", ref, err)
+			allErrs = multierror.Append(allErrs, errors.WithStackTrace(err))
+			continue
+		}
+		logger.Infof("Successfully updated certificate in Secret %s", ref)
+		updated = append(updated, ref)
+	}
+	return updated, errors.WithStackTrace(allErrs.ErrorOrNil())
+}
+
+// issuedCertificateRefsNotSignedBy returns the references to the Secrets of the certificates issued by the CA in the
+// given Secret that are not signed by the given CA certificate. Secrets that no longer exist are left out, while Secrets
+// that can not be read are included, so that the error is surfaced when reissuing them.
+func issuedCertificateRefsNotSignedBy(client kubernetes.Interface, caSecret *corev1.Secret, caCert *x509.Certificate) []string {
+	refs := []string{}
+	for _, ref := range issuedCertificateRefs(caSecret) {
+		secret, err := getSecretByRef(client, ref)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil || !isCertificateInSecretSignedBy(secret, caCert) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// isCertificateInSecretSignedBy returns whether the certificate in the Secret created by `tls gen` is signed by the
+// given CA certificate.
+func isCertificateInSecretSignedBy(secret *corev1.Secret, caCert *x509.Certificate) bool {
+	fileNameBase := secret.Annotations[kubernetesSecretFileNameBaseAnnotationKey]
+	if fileNameBase == "" {
+		fileNameBase = defaultFileNameBase
+	}
+	cert, err := parseCertificatePEM(secret.Data[fmt.Sprintf("%s.crt", fileNameBase)])
+	if err != nil {
+		return false
+	}
+	return cert.CheckSignatureFrom(caCert) == nil
+}
+
+// createCertificateAuthorityLike generates a new self signed CA certificate key pair with the same key type, subject and
+// validity time span as the given CA.
+func createCertificateAuthorityLike(caCert *x509.Certificate, caKey interface{}) (*x509.Certificate, interface{}, error) {
+	var newKey interface{}
+	var newPublicKey interface{}
+	switch key := caKey.(type) {
+	case *ecdsa.PrivateKey:
+		ecdsaKey, err := ecdsa.GenerateKey(key.Curve, rand.Reader)
+		if err != nil {
+			return nil, nil, errors.WithStackTrace(err)
+		}
+		newKey, newPublicKey = ecdsaKey, &ecdsaKey.PublicKey
+	case *rsa.PrivateKey:
+		rsaKey, _, err := CreateRSAKeyPair(key.N.BitLen())
+		if err != nil {
+			return nil, nil, err
+		}
+		newKey, newPublicKey = rsaKey, &rsaKey.PublicKey
+	default:
+		return nil, nil, errors.WithStackTrace(UnknownPrivateKeyAlgorithm{fmt.Sprintf("%T", caKey)})
+	}
+
+	certificateBytes, err := CreateCertificateFromKeys(
+		caCert.NotAfter.Sub(caCert.NotBefore),
+		caCert.Subject,
+		nil,
+		true,
+		nil,
+		nil,
+		caCert.ExtKeyUsage,
+		newPublicKey,
+		newKey,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	newCert, err := x509.ParseCertificate(certificateBytes)
+	if err != nil {
+		return nil, nil, errors.WithStackTrace(err)
+	}
+	return newCert, newKey, nil
+}
+
+// reissueCertificateInSecret replaces the certificate in the Secret created by `tls gen` with one that has the same
+// subject, SANs, extended key usages, validity time span and public key, but is signed by the given CA.
+func reissueCertificateInSecret(secret *corev1.Secret, caCert *x509.Certificate, caKey interface{}) error {
+	fileNameBase := secret.Annotations[kubernetesSecretFileNameBaseAnnotationKey]
+	if fileNameBase == "" {
+		fileNameBase = defaultFileNameBase
+	}
+	certKey := fmt.Sprintf("%s.crt", fileNameBase)
+	cert, err := parseCertificatePEM(secret.Data[certKey])
+	if err != nil {
+		return err
+	}
+
+	certificateBytes, err := CreateCertificateFromKeys(
+		cert.NotAfter.Sub(cert.NotBefore),
+		cert.Subject,
+		caCert,
+		false,
+		cert.DNSNames,
+		cert.IPAddresses,
+		cert.ExtKeyUsage,
+		cert.PublicKey,
+		caKey,
+	)
+	if err != nil {
+		return err
+	}
+	newCert, err := x509.ParseCertificate(certificateBytes)
+	if err != nil {
+		return errors.WithStackTrace(err)
+	}
+	secret.Data[certKey] = encodeCertificate(newCert)
+	return nil
+}
+
+// trackIssuedCertificate records the Secret holding a certificate issued by the CA in the annotations of the CA
+// Secret, so that RotateCA knows which certificates to reissue.
+func trackIssuedCertificate(
+	kubectlOptions *kubectl.KubectlOptions,
+	caSecretOptions KubernetesSecretOptions,
+	secretOptions KubernetesSecretOptions,
+) error {
+	client, err := kubectl.GetKubernetesClientFromOptions(kubectlOptions)
+	if err != nil {
+		return err
+	}
+	return kubehelper.RetryOnConflictOrServerError(func() error {
+		caSecret, err := client.CoreV1().Secrets(caSecretOptions.Namespace).Get(context.Background(), caSecretOptions.Name, metav1.GetOptions{})
+		if err != nil {
+			return errors.WithStackTrace(err)
+		}
+
+		ref := fmt.Sprintf("%s/%s", secretOptions.Namespace, secretOptions.Name)
+		refs := issuedCertificateRefs(caSecret)
+		if collections.ListContainsElement(refs, ref) {
+			return nil
+		}
+		if caSecret.Annotations == nil {
+			caSecret.Annotations = map[string]string{}
+		}
+		caSecret.Annotations[kubernetesSecretIssuedCertificatesAnnotationKey] = strings.Join(append(refs, ref), ",")
+		kubectl.MarkAsModifiedByKubergrunt(&caSecret.ObjectMeta)
+		_, err = client.CoreV1().Secrets(caSecretOptions.Namespace).Update(context.Background(), caSecret, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// issuedCertificateRefs returns the namespace/name references to the Secrets holding the certificates issued by the CA
+// in the given Secret.
+func issuedCertificateRefs(caSecret *corev1.Secret) []string {
+	refs := []string{}
+	for _, ref := range strings.Split(caSecret.Annotations[kubernetesSecretIssuedCertificatesAnnotationKey], ",") {
+		if ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+func getSecretByRef(client kubernetes.Interface, ref string) (*corev1.Secret, error) {
+	namespace, name, isValidRef := strings.Cut(ref, "/")
+	if !isValidRef {
+		return nil, errors.WithStackTrace(InvalidSecretRefError{ref})
+	}
+	return client.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+}
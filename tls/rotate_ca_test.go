@@ -0,0 +1,168 @@
+package tls
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRotateCAReissuesTrackedCertificates(t *testing.T) {
+	t.Parallel()
+
+	for _, algorithm := range PrivateKeyAlgorithms {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		algorithm := algorithm
+
+		t.Run(algorithm, func(t *testing.T) {
+			t.Parallel()
+
+			opts := SampleTlsOptions(algorithm)
+			caSecret, caCert, caKey := newTestCASecret(t, opts, "default/tls", "default/deleted")
+			tlsSecret := newTestIssuedSecret(t, opts, caCert, caKey)
+			client := fake.NewSimpleClientset(caSecret, tlsSecret)
+			originalKey := tlsSecret.Data["tls.pem"]
+
+			// Rotating the CA reissues the certificate, trusting both the new and the old CA.
+			updated, err := rotateCA(client, "ca", "default", false)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"default/tls"}, updated)
+
+			rotatedCASecret := getTestSecret(t, client, "ca")
+			newCACert, err := parseCertificatePEM(rotatedCASecret.Data["ca.crt"])
+			require.NoError(t, err)
+			assert.NotEqual(t, caCert.Raw, newCACert.Raw)
+			assert.Equal(t, encodeCertificate(caCert), rotatedCASecret.Data[previousCACertSecretKey])
+
+			rotatedSecret := getTestSecret(t, client, "tls")
+			assert.Equal(t, originalKey, rotatedSecret.Data["tls.pem"])
+			assert.Equal(t, [][]byte{newCACert.Raw, caCert.Raw}, parseTestBundle(t, rotatedSecret.Data[TLSSecretCACertKey]))
+			cert, err := parseCertificatePEM(rotatedSecret.Data["tls.crt"])
+			require.NoError(t, err)
+			assert.Equal(t, []string{"gruntwork.io"}, cert.DNSNames)
+			verifyTestCertificate(t, cert, newCACert)
+
+			// Dropping the previous CA leaves only the new CA in the trust bundle.
+			updated, err = rotateCA(client, "ca", "default", true)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"default/tls"}, updated)
+			assert.NotContains(t, getTestSecret(t, client, "ca").Data, previousCACertSecretKey)
+			droppedSecret := getTestSecret(t, client, "tls")
+			assert.Equal(t, [][]byte{newCACert.Raw}, parseTestBundle(t, droppedSecret.Data[TLSSecretCACertKey]))
+			assert.Equal(t, rotatedSecret.Data["tls.crt"], droppedSecret.Data["tls.crt"])
+		})
+	}
+}
+
+func TestRotateCAResumesAfterFailedReissue(t *testing.T) {
+	t.Parallel()
+
+	opts := SampleTlsOptions(ECDSAAlgorithm)
+	caSecret, caCert, caKey := newTestCASecret(t, opts, "default/tls", "default/api")
+	tlsSecret := newTestIssuedSecret(t, opts, caCert, caKey)
+	apiSecret := newTestIssuedSecret(t, opts, caCert, caKey)
+	apiSecret.Name = "api"
+	client := fake.NewSimpleClientset(caSecret, tlsSecret, apiSecret)
+
+	// Fail the first update of the api Secret, as if the API server was unavailable.
+	failedAPIUpdate := false
+	client.PrependReactor("update", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		secret := action.(k8stesting.UpdateAction).GetObject().(*corev1.Secret)
+		if secret.Name == "api" && !failedAPIUpdate {
+			failedAPIUpdate = true
+			return true, nil, fmt.Errorf("the server is currently unable to handle the request")
+		}
+		return false, nil, nil
+	})
+
+	updated, err := rotateCA(client, "ca", "default", false)
+	require.Error(t, err)
+	assert.Equal(t, []string{"default/tls"}, updated)
+	rotatedCASecret := getTestSecret(t, client, "ca")
+	newCACert, err := parseCertificatePEM(rotatedCASecret.Data["ca.crt"])
+	require.NoError(t, err)
+
+	// Rerunning resumes the rotation with the new CA, instead of rotating again, so that the original CA stays in the
+	// trust bundles.
+	updated, err = rotateCA(client, "ca", "default", false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default/api"}, updated)
+	assert.Equal(t, rotatedCASecret.Data, getTestSecret(t, client, "ca").Data)
+	assert.Equal(t, encodeCertificate(caCert), rotatedCASecret.Data[previousCACertSecretKey])
+	for _, name := range []string{"tls", "api"} {
+		secret := getTestSecret(t, client, name)
+		assert.Equal(t, [][]byte{newCACert.Raw, caCert.Raw}, parseTestBundle(t, secret.Data[TLSSecretCACertKey]), name)
+		cert, err := parseCertificatePEM(secret.Data["tls.crt"])
+		require.NoError(t, err)
+		verifyTestCertificate(t, cert, newCACert)
+	}
+
+	// Once all the certificates are signed by the new CA, rotating again is refused until the previous CA is dropped.
+	_, err = rotateCA(client, "ca", "default", false)
+	_, isNotDroppedErr := errors.Unwrap(err).(PreviousCANotDroppedError)
+	assert.True(t, isNotDroppedErr)
+}
+
+// newTestCASecret returns a CA Secret in the format created by `tls gen --ca`, which tracks the given issued
+// certificates.
+func newTestCASecret(t *testing.T, opts TLSOptions, issuedRefs ...string) (*corev1.Secret, *x509.Certificate, interface{}) {
+	cert, key, err := opts.createCertificateKeyPair(true, nil, nil)
+	require.NoError(t, err)
+	secret := newTestKeyPairSecret(t, "ca", "ca", cert, key)
+	secret.Annotations[kubernetesSecretIssuedCertificatesAnnotationKey] = strings.Join(issuedRefs, ",")
+	return secret, cert, key
+}
+
+// newTestIssuedSecret returns a Secret in the format created by `tls gen`, holding a certificate signed by the CA.
+func newTestIssuedSecret(t *testing.T, opts TLSOptions, caCert *x509.Certificate, caKey interface{}) *corev1.Secret {
+	cert, key, err := opts.createCertificateKeyPair(false, caCert, caKey)
+	require.NoError(t, err)
+	secret := newTestKeyPairSecret(t, "tls", "tls", cert, key)
+	secret.Annotations[kubernetesSecretSignedByAnnotationKey] = "namespace=default,name=ca"
+	secret.Data[TLSSecretCACertKey] = encodeCertificate(caCert)
+	return secret
+}
+
+func newTestKeyPairSecret(t *testing.T, name string, fileNameBase string, cert *x509.Certificate, key interface{}) *corev1.Secret {
+	keyPEM, err := encodePrivateKeyToPEM(key)
+	require.NoError(t, err)
+	publicKeyPEM, err := EncodePublicKeyToPEM(cert.PublicKey)
+	require.NoError(t, err)
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Annotations: map[string]string{kubernetesSecretFileNameBaseAnnotationKey: fileNameBase},
+		},
+		Data: map[string][]byte{
+			fmt.Sprintf("%s.crt", fileNameBase): encodeCertificate(cert),
+			fmt.Sprintf("%s.pem", fileNameBase): keyPEM,
+			fmt.Sprintf("%s.pub", fileNameBase): pem.EncodeToMemory(&publicKeyPEM),
+		},
+	}
+}
+
+func parseTestBundle(t *testing.T, data []byte) [][]byte {
+	certs := [][]byte{}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		certs = append(certs, block.Bytes)
+	}
+	return certs
+}
+
+func verifyTestCertificate(t *testing.T, cert *x509.Certificate, caCert *x509.Certificate) {
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	_, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	require.NoError(t, err)
+}
@@ -0,0 +1,259 @@
+package tls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/gruntwork-io/go-commons/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+	"github.com/gruntwork-io/kubergrunt/kubehelper"
+	"github.com/gruntwork-io/kubergrunt/logging"
+)
+
+const (
+	// TLSSecretCACertKey is the key in kubernetes.io/tls Secrets that holds the CA certificate that issued the TLS
+	// certificate, as used by cert-manager and other tools.
+	TLSSecretCACertKey = "ca.crt"
+
+	// maxTLSSecretRenewBefore is the maximum amount of time before the certificate in a TLS Secret expires, in which
+	// GenerateAndStoreTLS considers it near expiry and reissues it.
+	maxTLSSecretRenewBefore = 30 * 24 * time.Hour
+)
+
+// GenerateAndStoreTLS will generate a new CA certificate key pair and a TLS certificate key pair signed by it for the
+// SANs in opts, and store them in the Secret of type kubernetes.io/tls with the given name in the EKS cluster. The Secret holds the TLS
+// certificate in tls.crt, its private key in tls.key, and the CA certificate in ca.crt. Note that the private key of
+// the CA is discarded once the TLS certificate is signed. Use GenerateAndStoreAsK8SSecret instead to issue multiple
+// certificates from the same CA.
+//
+// This is idempotent: if the Secret already holds a certificate that is not near expiry, this does nothing unless
+// force is true. The certificate is near expiry when it expires within a third of the validity time span, or 30 days,
+// whichever is shorter.
+func GenerateAndStoreTLS(clusterArn string, namespace string, secretName string, opts TLSOptions, force bool) error {
+	return GenerateAndStoreTLSSecret(&kubectl.KubectlOptions{EKSClusterArn: clusterArn}, namespace, secretName, opts, force)
+}
+
+// GenerateAndStoreTLSSecret is like GenerateAndStoreTLS, but authenticates to the Kubernetes cluster using the given
+// kubectl options.
+func GenerateAndStoreTLSSecret(
+	kubectlOptions *kubectl.KubectlOptions,
+	namespace string,
+	secretName string,
+	opts TLSOptions,
+	force bool,
+) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	client, err := kubectl.GetKubernetesClientFromOptions(kubectlOptions)
+	if err != nil {
+		return err
+	}
+	return generateAndStoreTLSSecret(client, namespace, secretName, opts, force, time.Now())
+}
+
+func generateAndStoreTLSSecret(
+	client kubernetes.Interface,
+	namespace string,
+	secretName string,
+	opts TLSOptions,
+	force bool,
+	now time.Time,
+) error {
+	logger := logging.GetProjectLogger()
+	secrets := client.CoreV1().Secrets(namespace)
+
+	existing, err := secrets.Get(context.Background(), secretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		existing = nil
+	} else if err != nil {
+		return errors.WithStackTrace(err)
+	}
+
+	if existing != nil && !force {
+		cert, err := parseCertificatePEM(existing.Data[corev1.TLSCertKey])
+		switch {
+		case err != nil:
+			logger.Warnf("Could not parse the certificate in Secret %s (namespace %s): %s. Regenerating.", secretName, namespace, err)
+		case !isCertificateNearExpiry(cert, opts.ValidityTimeSpan, now):
+			logger.Infof("Certificate in Secret %s (namespace %s) is valid until %s. Skipping regeneration.", secretName, namespace, cert.NotAfter)
+			return nil
+		default:
+			logger.Infof("Certificate in Secret %s (namespace %s) expires at %s. Regenerating.", secretName, namespace, cert.NotAfter)
+		}
+	}
+
+	logger.Info("Generating CA certificate key pair")
+	caOpts := opts
+	caOpts.DistinguishedName.CommonName = fmt.Sprintf("%s CA", opts.DistinguishedName.CommonName)
+	caCert, caPrivateKey, err := caOpts.createCertificateKeyPair(true, nil, nil)
+	if err != nil {
+		return err
+	}
+	logger.Info("Generating TLS certificate key pair signed by the CA")
+	cert, privateKey, err := opts.createCertificateKeyPair(false, caCert, caPrivateKey)
+	if err != nil {
+		return err
+	}
+	privateKeyPEM, err := encodePrivateKeyToPEM(privateKey)
+	if err != nil {
+		return err
+	}
+	data := map[string][]byte{
+		corev1.TLSCertKey:       encodeCertificate(cert),
+		corev1.TLSPrivateKeyKey: privateKeyPEM,
+		TLSSecretCACertKey:      encodeCertificate(caCert),
+	}
+
+	// The Secret read above is used on the first attempt, and the latest version of the Secret is read again on retries,
+	// e.g. when the update conflicts. The certificates are not regenerated.
+	refresh := false
+	return kubehelper.RetryOnConflictOrServerError(func() error {
+		if refresh {
+			existing, err = secrets.Get(context.Background(), secretName, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				existing = nil
+			} else if err != nil {
+				return errors.WithStackTrace(err)
+			}
+		}
+		refresh = true
+
+		if existing == nil {
+			secret := kubectl.PrepareSecret(namespace, secretName, map[string]string{}, map[string]string{})
+			secret.Type = corev1.SecretTypeTLS
+			secret.Data = data
+			kubectl.MarkAsCreatedByKubergrunt(&secret.ObjectMeta)
+			if _, err := secrets.Create(context.Background(), secret, metav1.CreateOptions{}); err != nil {
+				return errors.WithStackTrace(err)
+			}
+			logger.Infof("Successfully created Secret %s (namespace %s)", secretName, namespace)
+			return nil
+		}
+
+		existing.Data = data
+		kubectl.MarkAsModifiedByKubergrunt(&existing.ObjectMeta)
+		if _, err := secrets.Update(context.Background(), existing, metav1.UpdateOptions{}); err != nil {
+			return errors.WithStackTrace(err)
+		}
+		logger.Infof("Successfully updated Secret %s (namespace %s)", secretName, namespace)
+		return nil
+	})
+}
+
+// createCertificateKeyPair generates a new certificate key pair with the configured private key algorithm in memory,
+// and returns the certificate along with its private key.
+func (options *TLSOptions) createCertificateKeyPair(
+	isCA bool,
+	signedBy *x509.Certificate,
+	signedByKey interface{}, // We don't know what format the signing key is in, so we will accept any type
+) (*x509.Certificate, interface{}, error) {
+	dnsNames, ipAddresses, err := options.subjectAltNames(isCA)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var certificateBytes []byte
+	var privateKey interface{}
+	switch options.PrivateKeyAlgorithm {
+	case ECDSAAlgorithm:
+		keypair, err := CreateECDSACertificateKeyPair(options.ValidityTimeSpan, options.DistinguishedName, signedBy, signedByKey, isCA, dnsNames, ipAddresses, options.extKeyUsages(isCA), options.ECDSACurve)
+		if err != nil {
+			return nil, nil, err
+		}
+		certificateBytes, privateKey = keypair.CertificateBytes, keypair.PrivateKey
+	case RSAAlgorithm:
+		keypair, err := CreateRSACertificateKeyPair(options.ValidityTimeSpan, options.DistinguishedName, signedBy, signedByKey, isCA, dnsNames, ipAddresses, options.extKeyUsages(isCA), options.RSABits)
+		if err != nil {
+			return nil, nil, err
+		}
+		certificateBytes, privateKey = keypair.CertificateBytes, keypair.PrivateKey
+	default:
+		return nil, nil, errors.WithStackTrace(UnknownPrivateKeyAlgorithm{options.PrivateKeyAlgorithm})
+	}
+
+	cert, err := x509.ParseCertificate(certificateBytes)
+	if err != nil {
+		return nil, nil, errors.WithStackTrace(err)
+	}
+	return cert, privateKey, nil
+}
+
+// isCertificateNearExpiry returns true if the certificate expires within a third of the validity time span, or
+// maxTLSSecretRenewBefore, whichever is shorter.
+func isCertificateNearExpiry(cert *x509.Certificate, validityTimeSpan time.Duration, now time.Time) bool {
+	renewBefore := validityTimeSpan / 3
+	if renewBefore > maxTLSSecretRenewBefore {
+		renewBefore = maxTLSSecretRenewBefore
+	}
+	return cert.NotAfter.Sub(now) < renewBefore
+}
+
+// encodePrivateKeyToPEM encodes the unencrypted private key to PEM, using the block type for its algorithm.
+func encodePrivateKeyToPEM(privateKey interface{}) ([]byte, error) {
+	var block pem.Block
+	var err error
+	switch key := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		block, err = EncodeECDSAPrivateKeyToPEM(key, "")
+	case *rsa.PrivateKey:
+		block, err = EncodeRSAPrivateKeyToPEM(key, "")
+	default:
+		return nil, errors.WithStackTrace(UnknownPrivateKeyAlgorithm{fmt.Sprintf("%T", privateKey)})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&block), nil
+}
+
+// parsePrivateKeyPEM parses the unencrypted private key in the PEM encoded data, based on the block type written by
+// encodePrivateKeyToPEM.
+func parsePrivateKeyPEM(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.WithStackTrace(NoPrivateKeyInPEMError{})
+	}
+	var privateKey interface{}
+	var err error
+	switch block.Type {
+	case ecPrivateKeyPEMType:
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+	case rsaPrivateKeyPEMType:
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, errors.WithStackTrace(NoPrivateKeyInPEMError{})
+	}
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return privateKey, nil
+}
+
+func encodeCertificate(cert *x509.Certificate) []byte {
+	block := EncodeCertificateToPEM(cert)
+	return pem.EncodeToMemory(&block)
+}
+
+// parseCertificatePEM parses the first certificate in the PEM encoded data.
+func parseCertificatePEM(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.WithStackTrace(NoCertificateInPEMError{})
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.WithStackTrace(err)
+	}
+	return cert, nil
+}
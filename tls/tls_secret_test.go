@@ -0,0 +1,145 @@
+package tls
+
+import (
+	"context"
+	"crypto"
+	gotls "crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/gruntwork-io/kubergrunt/kubectl"
+)
+
+func TestGenerateAndStoreTLSSecretCreatesTLSSecret(t *testing.T) {
+	t.Parallel()
+
+	for _, algorithm := range PrivateKeyAlgorithms {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		algorithm := algorithm
+
+		t.Run(algorithm, func(t *testing.T) {
+			t.Parallel()
+
+			client := fake.NewSimpleClientset()
+			err := generateAndStoreTLSSecret(client, "default", "tls", SampleTlsOptions(algorithm), false, time.Now())
+			require.NoError(t, err)
+
+			secret := getTestSecret(t, client, "tls")
+			assert.Equal(t, corev1.SecretTypeTLS, secret.Type)
+			assert.True(t, kubectl.IsManagedByKubergrunt(secret.ObjectMeta))
+			_, err = gotls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+			require.NoError(t, err)
+
+			// The certificate is signed by the CA in ca.crt
+			cert, err := parseCertificatePEM(secret.Data[corev1.TLSCertKey])
+			require.NoError(t, err)
+			caCert, err := parseCertificatePEM(secret.Data[TLSSecretCACertKey])
+			require.NoError(t, err)
+			assert.True(t, caCert.IsCA)
+			roots := x509.NewCertPool()
+			roots.AddCert(caCert)
+			_, err = cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestGenerateAndStoreTLSSecretIsIdempotent(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name          string
+		force         bool
+		timePassed    time.Duration
+		expectRenewal bool
+	}{
+		{"valid", false, 0, false},
+		{"force", true, 0, true},
+		{"near-expiry", false, 50 * time.Minute, true},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			// The sample options are valid for an hour, so the certificate is near expiry after 40 minutes.
+			opts := SampleTlsOptions(ECDSAAlgorithm)
+			client := fake.NewSimpleClientset()
+			require.NoError(t, generateAndStoreTLSSecret(client, "default", "tls", opts, false, time.Now()))
+			original := getTestSecret(t, client, "tls")
+
+			err := generateAndStoreTLSSecret(client, "default", "tls", opts, testCase.force, time.Now().Add(testCase.timePassed))
+			require.NoError(t, err)
+			updated := getTestSecret(t, client, "tls")
+			if testCase.expectRenewal {
+				assert.NotEqual(t, original.Data[corev1.TLSCertKey], updated.Data[corev1.TLSCertKey])
+			} else {
+				assert.Equal(t, original.Data, updated.Data)
+			}
+		})
+	}
+}
+
+func TestCreateCertificateKeyPairRoundTripsPrivateKeys(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name                 string
+		algorithm            string
+		curve                string
+		expectedPEMType      string
+		expectedSignatureAlg x509.SignatureAlgorithm
+		expectedKeyUsage     x509.KeyUsage
+	}{
+		{"ecdsa-p256", ECDSAAlgorithm, P256Curve, "EC PRIVATE KEY", x509.ECDSAWithSHA256, x509.KeyUsageDigitalSignature},
+		{"ecdsa-p384", ECDSAAlgorithm, P384Curve, "EC PRIVATE KEY", x509.ECDSAWithSHA384, x509.KeyUsageDigitalSignature},
+		{"ecdsa-p521", ECDSAAlgorithm, P521Curve, "EC PRIVATE KEY", x509.ECDSAWithSHA512, x509.KeyUsageDigitalSignature},
+		{"rsa", RSAAlgorithm, "", "RSA PRIVATE KEY", x509.SHA256WithRSA, x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to bring in scope within for loop to avoid it changing
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Self signed, so that the signature algorithm is picked based on the key type under test.
+			opts := SampleTlsOptions(testCase.algorithm)
+			opts.ECDSACurve = testCase.curve
+			cert, privateKey, err := opts.createCertificateKeyPair(false, nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.expectedSignatureAlg, cert.SignatureAlgorithm)
+			assert.Equal(t, testCase.expectedKeyUsage, cert.KeyUsage)
+
+			privateKeyPEM, err := encodePrivateKeyToPEM(privateKey)
+			require.NoError(t, err)
+			block, _ := pem.Decode(privateKeyPEM)
+			require.NotNil(t, block)
+			assert.Equal(t, testCase.expectedPEMType, block.Type)
+
+			parsedKey, err := parsePrivateKeyPEM(privateKeyPEM)
+			require.NoError(t, err)
+			signer, isSigner := parsedKey.(crypto.Signer)
+			require.True(t, isSigner)
+			assert.True(t, signer.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(cert.PublicKey))
+		})
+	}
+}
+
+func getTestSecret(t *testing.T, client *fake.Clientset, name string) *corev1.Secret {
+	secret, err := client.CoreV1().Secrets("default").Get(context.Background(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	return secret
+}